@@ -0,0 +1,401 @@
+// Package audit defines a sub-module for auditing finalized heights, by re-executing
+// the deterministic parts of the decision logic on a journal of consensus messages
+package audit
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/renloi/ibft/core"
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// Step is the decision step a verdict is about
+type Step string
+
+const (
+	// StepMessage is the validation of a single journal message
+	StepMessage Step = "message"
+
+	// StepProposal is the validation of a proposal for the round
+	StepProposal Step = "proposal"
+
+	// StepJustification is the validation of the RCC justifying
+	// a proposal for a round above 0
+	StepJustification Step = "justification"
+
+	// StepPrepareQuorum is the quorum check of the PREPARE messages for the proposal
+	StepPrepareQuorum Step = "prepare quorum"
+
+	// StepCommitQuorum is the quorum check of the COMMIT messages for the proposal
+	StepCommitQuorum Step = "commit quorum"
+)
+
+// Verdict is a single entry of the verdict trail
+type Verdict struct {
+	// Step is the decision step
+	Step Step
+
+	// Round is the round of the step
+	Round uint64
+
+	// From is the sender of the message the verdict is about, if any
+	From []byte
+
+	// Passed is the flag indicating if the step passed
+	Passed bool
+
+	// Reason explains the verdict
+	Reason string
+}
+
+// Result is the outcome of the audit of a height
+type Result struct {
+	// Height is the audited height
+	Height uint64
+
+	// Justified is the flag indicating if the journal justifies a finalized proposal
+	Justified bool
+
+	// Round is the round the proposal was finalized in, if justified
+	Round uint64
+
+	// ProposalHash is the hash of the finalized proposal, if justified
+	ProposalHash []byte
+
+	// Trail are the verdicts of the audit, in the order they were reached
+	Trail []Verdict
+}
+
+// ValidatorSet is the validator set of the audited height
+type ValidatorSet struct {
+	// Validators are the IDs of the validators
+	Validators [][]byte
+
+	// Proposer returns the proposer for the round. If it is not set,
+	// the proposal senders are only required to be validators
+	Proposer func(round uint64) []byte
+}
+
+// isValidator checks if the sender is a validator
+func (s ValidatorSet) isValidator(sender []byte) bool {
+	for _, validator := range s.Validators {
+		if bytes.Equal(validator, sender) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isProposer checks if the sender is the proposer for the round.
+// Any sender is the proposer if the proposer selection is not set
+func (s ValidatorSet) isProposer(sender []byte, round uint64) bool {
+	if s.Proposer == nil {
+		return true
+	}
+
+	return bytes.Equal(s.Proposer(round), sender)
+}
+
+// Verifier verifies the cryptographic parts of the messages, which are
+// specific to the backend. Its methods match the ones of the core Backend
+type Verifier interface {
+	// IsValidProposalHash checks if the hash matches the proposal
+	IsValidProposalHash(proposal *proto.Proposal, hash []byte) bool
+
+	// IsValidCommittedSeal checks if the committed seal is signed by its signer
+	IsValidCommittedSeal(proposalHash []byte, committedSeal *messages.CommittedSeal) bool
+}
+
+// Option is the optional auditor configuration setter
+type Option func(*Auditor)
+
+// WithQuorumVerifier sets the quorum rules of the network.
+// By default, the core.CountQuorum rules over the validator set are used
+func WithQuorumVerifier(verifier core.QuorumVerifier) Option {
+	return func(a *Auditor) {
+		a.quorum = verifier
+	}
+}
+
+// WithVerifier sets the verifier of the proposal hashes and committed seals.
+// Without it, the audit trusts the journal signatures, and only re-executes
+// the decision logic
+func WithVerifier(verifier Verifier) Option {
+	return func(a *Auditor) {
+		a.verifier = verifier
+	}
+}
+
+// Auditor re-executes the deterministic parts of the decision logic
+// (message validation, quorum and justification) on a journal of messages,
+// letting auditors independently confirm that a finalized proposal was justified
+type Auditor struct {
+	// validators is the validator set of the audited height
+	validators ValidatorSet
+
+	// quorum determines if the messages reach quorum
+	quorum core.QuorumVerifier
+
+	// verifier verifies the proposal hashes and committed seals, if set
+	verifier Verifier
+}
+
+// NewAuditor creates a new auditor for the validator set
+func NewAuditor(validators ValidatorSet, opts ...Option) *Auditor {
+	a := &Auditor{
+		validators: validators,
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	if a.quorum == nil {
+		quorum := core.CountQuorum{
+			ValidatorCount: func(uint64) uint64 {
+				return uint64(len(validators.Validators))
+			},
+		}
+
+		if validators.Proposer != nil {
+			quorum.Proposer = func(_, round uint64) []byte {
+				return validators.Proposer(round)
+			}
+		}
+
+		a.quorum = quorum
+	}
+
+	return a
+}
+
+// audit is the state of a single height audit
+type audit struct {
+	*Auditor
+
+	result *Result
+}
+
+// record appends the verdict to the trail
+func (a *audit) record(step Step, round uint64, from []byte, passed bool, reason string) {
+	a.result.Trail = append(a.result.Trail, Verdict{
+		Step:   step,
+		Round:  round,
+		From:   from,
+		Passed: passed,
+		Reason: reason,
+	})
+}
+
+// Audit audits the height using the journal, which are the messages the node received
+// (or sent), in their arrival order, ex. read from a wiretap recording. The rounds
+// are examined in ascending order, and the first round with a valid proposal,
+// a PREPARE quorum and a COMMIT quorum for it justifies the height.
+// The COMMIT messages carried by the FINALITY messages are taken into account as well
+func (a *Auditor) Audit(height uint64, journal []*proto.Message) *Result {
+	run := &audit{
+		Auditor: a,
+		result: &Result{
+			Height: height,
+			Trail:  make([]Verdict, 0),
+		},
+	}
+
+	rounds := run.collect(height, journal)
+
+	for _, round := range sortedRounds(rounds) {
+		if run.auditRound(height, round, rounds[round]) {
+			return run.result
+		}
+	}
+
+	return run.result
+}
+
+// roundMessages are the valid journal messages of a round, by type, in the journal order
+type roundMessages map[proto.MessageType][]*proto.Message
+
+// collect groups the journal messages of the height by round and type,
+// dropping the messages that are malformed or not sent by validators
+func (a *audit) collect(height uint64, journal []*proto.Message) map[uint64]roundMessages {
+	rounds := make(map[uint64]roundMessages)
+
+	add := func(message *proto.Message) {
+		round := message.View.Round
+
+		if !a.validators.isValidator(message.From) {
+			a.record(StepMessage, round, message.From, false, fmt.Sprintf("%s sender is not a validator", message.Type))
+
+			return
+		}
+
+		if rounds[round] == nil {
+			rounds[round] = make(roundMessages)
+		}
+
+		rounds[round][message.Type] = append(rounds[round][message.Type], message)
+	}
+
+	for _, message := range journal {
+		if message == nil || message.View == nil || message.View.Height != height {
+			continue
+		}
+
+		switch message.Type {
+		case proto.MessageType_PREPREPARE, proto.MessageType_PREPARE, proto.MessageType_COMMIT:
+			add(message)
+		case proto.MessageType_FINALITY:
+			commits, err := messages.ExtractFinalityCommits(message)
+			if err != nil {
+				a.record(StepMessage, message.View.Round, message.From, false, fmt.Sprintf("malformed finality: %v", err))
+
+				continue
+			}
+
+			for _, commit := range commits {
+				add(commit)
+			}
+		}
+	}
+
+	return rounds
+}
+
+// sortedRounds returns the rounds in ascending order
+func sortedRounds(rounds map[uint64]roundMessages) []uint64 {
+	sorted := make([]uint64, 0, len(rounds))
+	for round := range rounds {
+		sorted = append(sorted, round)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	return sorted
+}
+
+// auditRound audits the round, and returns true if it justifies the height
+func (a *audit) auditRound(height, round uint64, msgs roundMessages) bool {
+	var proposal *proto.Message
+
+	for _, candidate := range msgs[proto.MessageType_PREPREPARE] {
+		if reason, ok := a.validateProposal(height, round, candidate); !ok {
+			a.record(StepProposal, round, candidate.From, false, reason)
+
+			continue
+		}
+
+		a.record(StepProposal, round, candidate.From, true, "valid proposal")
+
+		proposal = candidate
+
+		break
+	}
+
+	if proposal == nil {
+		return false
+	}
+
+	proposalHash := messages.ExtractProposalHash(proposal)
+
+	if !a.hasPrepareQuorum(height, round, proposal, proposalHash, msgs[proto.MessageType_PREPARE]) {
+		return false
+	}
+
+	if !a.hasCommitQuorum(height, round, proposalHash, msgs[proto.MessageType_COMMIT]) {
+		return false
+	}
+
+	a.result.Justified = true
+	a.result.Round = round
+	a.result.ProposalHash = proposalHash
+
+	return true
+}
+
+// hasPrepareQuorum checks if the PREPARE messages for the proposal reach quorum,
+// along with the proposal, as the proposer does not send a PREPARE message
+func (a *audit) hasPrepareQuorum(
+	height, round uint64,
+	proposal *proto.Message,
+	proposalHash []byte,
+	prepares []*proto.Message,
+) bool {
+	matching := uniqueSenders(prepares, func(prepare *proto.Message) bool {
+		return bytes.Equal(messages.ExtractPrepareHash(prepare), proposalHash)
+	})
+
+	quorum := a.quorum.HasQuorum(
+		height,
+		append([]*proto.Message{proposal}, matching...),
+		proto.MessageType_PREPARE,
+	)
+
+	a.record(
+		StepPrepareQuorum,
+		round,
+		nil,
+		quorum,
+		fmt.Sprintf("%d matching PREPARE messages of %d", len(matching), len(prepares)),
+	)
+
+	return quorum
+}
+
+// hasCommitQuorum checks if the COMMIT messages for the proposal reach quorum.
+// The committed seals are verified, if the verifier is set
+func (a *audit) hasCommitQuorum(height, round uint64, proposalHash []byte, commits []*proto.Message) bool {
+	matching := uniqueSenders(commits, func(commit *proto.Message) bool {
+		if !bytes.Equal(messages.ExtractCommitHash(commit), proposalHash) {
+			return false
+		}
+
+		if a.verifier == nil {
+			return true
+		}
+
+		if !a.verifier.IsValidCommittedSeal(proposalHash, messages.ExtractCommittedSeal(commit)) {
+			a.record(StepMessage, round, commit.From, false, "invalid committed seal")
+
+			return false
+		}
+
+		return true
+	})
+
+	quorum := a.quorum.HasQuorum(height, matching, proto.MessageType_COMMIT)
+
+	a.record(
+		StepCommitQuorum,
+		round,
+		nil,
+		quorum,
+		fmt.Sprintf("%d matching COMMIT messages of %d", len(matching), len(commits)),
+	)
+
+	return quorum
+}
+
+// uniqueSenders returns the first message of each sender that matches the filter
+func uniqueSenders(msgs []*proto.Message, matches func(*proto.Message) bool) []*proto.Message {
+	var (
+		senders = make(map[string]struct{}, len(msgs))
+		unique  = make([]*proto.Message, 0, len(msgs))
+	)
+
+	for _, message := range msgs {
+		if _, seen := senders[string(message.From)]; seen || !matches(message) {
+			continue
+		}
+
+		senders[string(message.From)] = struct{}{}
+		unique = append(unique, message)
+	}
+
+	return unique
+}
@@ -0,0 +1,306 @@
+package audit
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+var (
+	validators = [][]byte{[]byte("v0"), []byte("v1"), []byte("v2"), []byte("v3")}
+
+	hashA = []byte("proposal A")
+	hashB = []byte("proposal B")
+)
+
+// validatorSet returns the test validator set, with a round-robin proposer
+func validatorSet() ValidatorSet {
+	return ValidatorSet{
+		Validators: validators,
+		Proposer: func(round uint64) []byte {
+			return validators[round%uint64(len(validators))]
+		},
+	}
+}
+
+func view(round uint64) *proto.View {
+	return &proto.View{Height: 1, Round: round}
+}
+
+func preprepare(from []byte, round uint64, hash []byte, rcc *proto.RoundChangeCertificate) *proto.Message {
+	return &proto.Message{
+		View: view(round),
+		From: from,
+		Type: proto.MessageType_PREPREPARE,
+		Payload: &proto.Message_PreprepareData{
+			PreprepareData: &proto.PrePrepareMessage{
+				Proposal:     &proto.Proposal{RawProposal: hash, Round: round},
+				ProposalHash: hash,
+				Certificate:  rcc,
+			},
+		},
+	}
+}
+
+func prepare(from []byte, round uint64, hash []byte) *proto.Message {
+	return &proto.Message{
+		View: view(round),
+		From: from,
+		Type: proto.MessageType_PREPARE,
+		Payload: &proto.Message_PrepareData{
+			PrepareData: &proto.PrepareMessage{
+				ProposalHash: hash,
+			},
+		},
+	}
+}
+
+func commit(from []byte, round uint64, hash []byte) *proto.Message {
+	return &proto.Message{
+		View: view(round),
+		From: from,
+		Type: proto.MessageType_COMMIT,
+		Payload: &proto.Message_CommitData{
+			CommitData: &proto.CommitMessage{
+				ProposalHash:  hash,
+				CommittedSeal: from,
+			},
+		},
+	}
+}
+
+func roundChange(from []byte, round uint64, pc *proto.PreparedCertificate) *proto.Message {
+	return &proto.Message{
+		View: view(round),
+		From: from,
+		Type: proto.MessageType_ROUND_CHANGE,
+		Payload: &proto.Message_RoundChangeData{
+			RoundChangeData: &proto.RoundChangeMessage{
+				LatestPreparedCertificate: pc,
+			},
+		},
+	}
+}
+
+// decidedRound returns the journal of a round deciding the proposal
+func decidedRound(round uint64, proposal *proto.Message) []*proto.Message {
+	hash := messages.ExtractProposalHash(proposal)
+	journal := []*proto.Message{proposal}
+
+	for _, validator := range validators {
+		if bytes.Equal(validator, proposal.From) {
+			continue
+		}
+
+		journal = append(journal, prepare(validator, round, hash))
+	}
+
+	for _, validator := range validators[:3] {
+		journal = append(journal, commit(validator, round, hash))
+	}
+
+	return journal
+}
+
+// roundOneRCC returns the RCC for round 1, in which v2 holds a PC for proposal A
+func roundOneRCC() *proto.RoundChangeCertificate {
+	pc := &proto.PreparedCertificate{
+		ProposalMessage: preprepare(validators[0], 0, hashA, nil),
+		PrepareMessages: []*proto.Message{
+			prepare(validators[1], 0, hashA),
+			prepare(validators[2], 0, hashA),
+		},
+	}
+
+	return &proto.RoundChangeCertificate{
+		RoundChangeMessages: []*proto.Message{
+			roundChange(validators[0], 1, nil),
+			roundChange(validators[2], 1, pc),
+			roundChange(validators[3], 1, nil),
+		},
+	}
+}
+
+func TestAuditor_Audit(t *testing.T) {
+	t.Parallel()
+
+	testTable := []struct {
+		name      string
+		journal   []*proto.Message
+		justified bool
+		round     uint64
+	}{
+		{
+			"decided in round 0",
+			decidedRound(0, preprepare(validators[0], 0, hashA, nil)),
+			true,
+			0,
+		},
+		{
+			"proposal of a non-proposer",
+			decidedRound(0, preprepare(validators[1], 0, hashA, nil)),
+			false,
+			0,
+		},
+		{
+			"commits of non-validators",
+			[]*proto.Message{
+				preprepare(validators[0], 0, hashA, nil),
+				prepare(validators[1], 0, hashA),
+				prepare(validators[2], 0, hashA),
+				commit(validators[0], 0, hashA),
+				commit([]byte("outsider 1"), 0, hashA),
+				commit([]byte("outsider 2"), 0, hashA),
+			},
+			false,
+			0,
+		},
+		{
+			"commits for another proposal",
+			[]*proto.Message{
+				preprepare(validators[0], 0, hashA, nil),
+				prepare(validators[1], 0, hashA),
+				prepare(validators[2], 0, hashA),
+				commit(validators[0], 0, hashA),
+				commit(validators[1], 0, hashB),
+				commit(validators[2], 0, hashB),
+			},
+			false,
+			0,
+		},
+		{
+			"repeated commits of a validator",
+			[]*proto.Message{
+				preprepare(validators[0], 0, hashA, nil),
+				prepare(validators[1], 0, hashA),
+				prepare(validators[2], 0, hashA),
+				commit(validators[0], 0, hashA),
+				commit(validators[1], 0, hashA),
+				commit(validators[1], 0, hashA),
+			},
+			false,
+			0,
+		},
+		{
+			"reproposal of the highest PC in round 1",
+			decidedRound(1, preprepare(validators[1], 1, hashA, roundOneRCC())),
+			true,
+			1,
+		},
+		{
+			"proposal ignoring the highest PC in round 1",
+			decidedRound(1, preprepare(validators[1], 1, hashB, roundOneRCC())),
+			false,
+			0,
+		},
+		{
+			"boosted reproposal by the PC holder",
+			decidedRound(1, preprepare(validators[2], 1, hashA, roundOneRCC())),
+			true,
+			1,
+		},
+		{
+			"proposal without RCC in round 1",
+			decidedRound(1, preprepare(validators[1], 1, hashA, nil)),
+			false,
+			0,
+		},
+		{
+			"decided by a finality proof",
+			[]*proto.Message{
+				preprepare(validators[0], 0, hashA, nil),
+				prepare(validators[1], 0, hashA),
+				prepare(validators[2], 0, hashA),
+				messages.NewFinalityMessage(view(0), nil, hashA, []*messages.CommittedSeal{
+					{Signer: validators[0], Signature: validators[0]},
+					{Signer: validators[1], Signature: validators[1]},
+					{Signer: validators[3], Signature: validators[3]},
+				}),
+			},
+			true,
+			0,
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			result := NewAuditor(validatorSet()).Audit(1, testCase.journal)
+
+			assert.Equal(t, uint64(1), result.Height)
+			assert.Equal(t, testCase.justified, result.Justified)
+			assert.NotEmpty(t, result.Trail)
+
+			if testCase.justified {
+				assert.Equal(t, testCase.round, result.Round)
+				assert.Equal(t, hashA, result.ProposalHash)
+			}
+		})
+	}
+}
+
+// TestAuditor_Audit_Trail makes sure the verdicts
+// of the decision steps are recorded in order
+func TestAuditor_Audit_Trail(t *testing.T) {
+	t.Parallel()
+
+	journal := append(
+		[]*proto.Message{commit([]byte("outsider"), 0, hashA)},
+		decidedRound(1, preprepare(validators[1], 1, hashA, roundOneRCC()))...,
+	)
+
+	result := NewAuditor(validatorSet()).Audit(1, journal)
+
+	steps := make([]Step, 0, len(result.Trail))
+	for _, verdict := range result.Trail {
+		assert.Equal(t, verdict.Step != StepMessage, verdict.Passed)
+
+		steps = append(steps, verdict.Step)
+	}
+
+	assert.Equal(
+		t,
+		[]Step{StepMessage, StepJustification, StepProposal, StepPrepareQuorum, StepCommitQuorum},
+		steps,
+	)
+}
+
+type mockVerifier struct {
+	invalidSigner []byte
+}
+
+func (v mockVerifier) IsValidProposalHash(proposal *proto.Proposal, hash []byte) bool {
+	return bytes.Equal(proposal.RawProposal, hash)
+}
+
+func (v mockVerifier) IsValidCommittedSeal(_ []byte, seal *messages.CommittedSeal) bool {
+	return !bytes.Equal(seal.Signer, v.invalidSigner)
+}
+
+// TestAuditor_WithVerifier makes sure the committed seals
+// are verified, if the verifier is set
+func TestAuditor_WithVerifier(t *testing.T) {
+	t.Parallel()
+
+	journal := decidedRound(0, preprepare(validators[0], 0, hashA, nil))
+
+	// Make sure the valid seals justify the height
+	result := NewAuditor(validatorSet(), WithVerifier(mockVerifier{})).Audit(1, journal)
+
+	assert.True(t, result.Justified)
+
+	// Make sure an invalid seal breaks the COMMIT quorum
+	result = NewAuditor(
+		validatorSet(),
+		WithVerifier(mockVerifier{invalidSigner: validators[2]}),
+	).Audit(1, journal)
+
+	assert.False(t, result.Justified)
+}
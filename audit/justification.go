@@ -0,0 +1,189 @@
+package audit
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// validateProposal validates the proposal for the round, as the validators do.
+// It returns the reason if the proposal is invalid
+func (a *audit) validateProposal(height, round uint64, msg *proto.Message) (string, bool) {
+	preprepareData, err := messages.ExtractPayload[*proto.PrePrepareMessage](msg)
+	if err != nil {
+		return fmt.Sprintf("malformed proposal: %v", err), false
+	}
+
+	if preprepareData.Proposal.GetRound() != round {
+		return "proposal round does not match the view", false
+	}
+
+	// NIL proposals skip the round, so they never finalize the height
+	if preprepareData.NilProposal {
+		return "NIL proposal", false
+	}
+
+	if a.verifier != nil && !a.verifier.IsValidProposalHash(preprepareData.Proposal, preprepareData.ProposalHash) {
+		return "proposal hash does not match the proposal", false
+	}
+
+	proposer := a.validators.isProposer(msg.From, round)
+
+	if round == 0 {
+		if !proposer {
+			return "sender is not the proposer", false
+		}
+
+		return "", true
+	}
+
+	return a.validateJustification(height, round, msg, preprepareData, proposer)
+}
+
+// validateJustification validates the RCC of the proposal for a round above 0.
+// The proposal must match the highest valid PC of the RCC, if any. A sender other than
+// the proposer may only repropose the highest PC it holds (see core.WithReproposalBoost)
+func (a *audit) validateJustification(
+	height, round uint64,
+	msg *proto.Message,
+	preprepareData *proto.PrePrepareMessage,
+	proposer bool,
+) (string, bool) {
+	reason, ok := a.justify(height, round, msg, preprepareData, proposer)
+
+	a.record(StepJustification, round, msg.From, ok, reason)
+
+	return reason, ok
+}
+
+// justify checks the RCC of the proposal, and returns the reason of the verdict
+func (a *audit) justify(
+	height, round uint64,
+	msg *proto.Message,
+	preprepareData *proto.PrePrepareMessage,
+	proposer bool,
+) (string, bool) {
+	rcc := preprepareData.Certificate
+	if rcc == nil {
+		return "missing round change certificate", false
+	}
+
+	if !a.quorum.HasQuorum(height, rcc.RoundChangeMessages, proto.MessageType_ROUND_CHANGE) {
+		return "round change certificate does not reach quorum", false
+	}
+
+	if !messages.HasUniqueSenders(rcc.RoundChangeMessages) {
+		return "round change certificate has duplicate senders", false
+	}
+
+	var (
+		prepared     bool
+		highestRound uint64
+		highestHash  []byte
+		holders      = make([][]byte, 0)
+	)
+
+	for _, rc := range rcc.RoundChangeMessages {
+		if rc.Type != proto.MessageType_ROUND_CHANGE ||
+			rc.View.GetHeight() != height ||
+			rc.View.GetRound() != round ||
+			!a.validators.isValidator(rc.From) {
+			return "round change certificate has an invalid message", false
+		}
+
+		rcData, err := messages.ExtractPayload[*proto.RoundChangeMessage](rc)
+		if err != nil {
+			return fmt.Sprintf("malformed round change: %v", err), false
+		}
+
+		pc := rcData.LatestPreparedCertificate
+		if pc == nil || !a.isValidPC(height, round, pc) {
+			continue
+		}
+
+		var (
+			pcRound = pc.ProposalMessage.View.Round
+			pcHash  = messages.ExtractProposalHash(pc.ProposalMessage)
+		)
+
+		switch {
+		case !prepared || pcRound > highestRound:
+			prepared, highestRound, highestHash = true, pcRound, pcHash
+			holders = [][]byte{rc.From}
+		case pcRound == highestRound:
+			holders = append(holders, rc.From)
+		}
+	}
+
+	if !prepared {
+		if !proposer {
+			return "sender is not the proposer", false
+		}
+
+		return "justified by a round change certificate without prepared certificates", true
+	}
+
+	if !proposer && !containsSender(holders, msg.From) {
+		return "sender is neither the proposer nor a holder of the highest prepared certificate", false
+	}
+
+	if !bytes.Equal(highestHash, preprepareData.ProposalHash) {
+		return fmt.Sprintf("proposal does not match the highest prepared certificate (round %d)", highestRound), false
+	}
+
+	return fmt.Sprintf("justified by the highest prepared certificate (round %d)", highestRound), true
+}
+
+// isValidPC checks if the prepared certificate carried in the RCC for the round is valid.
+// The sender of the PC proposal is only required to be a validator,
+// as it may be a boosted reproposer instead of the proposer for its round
+func (a *audit) isValidPC(height, round uint64, certificate *proto.PreparedCertificate) bool {
+	if certificate.ProposalMessage == nil || certificate.PrepareMessages == nil {
+		return false
+	}
+
+	allMessages := append(
+		[]*proto.Message{certificate.ProposalMessage},
+		certificate.PrepareMessages...,
+	)
+
+	if certificate.ProposalMessage.Type != proto.MessageType_PREPREPARE {
+		return false
+	}
+
+	for _, message := range certificate.PrepareMessages {
+		if message.Type != proto.MessageType_PREPARE {
+			return false
+		}
+	}
+
+	if !a.quorum.HasQuorum(height, allMessages, proto.MessageType_PREPARE) ||
+		!messages.HasUniqueSenders(allMessages) ||
+		!messages.HaveSameProposalHash(allMessages) ||
+		!messages.AllHaveLowerRound(allMessages, round) ||
+		!messages.AllHaveSameHeight(allMessages, height) ||
+		!messages.AllHaveSameRound(allMessages) {
+		return false
+	}
+
+	for _, message := range allMessages {
+		if !a.validators.isValidator(message.From) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// containsSender checks if the sender is among the senders
+func containsSender(senders [][]byte, sender []byte) bool {
+	for _, candidate := range senders {
+		if bytes.Equal(candidate, sender) {
+			return true
+		}
+	}
+
+	return false
+}
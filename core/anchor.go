@@ -0,0 +1,203 @@
+package core
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+var (
+	// ErrFinalityGadgetDisabled is returned when the anchoring is confirmed,
+	// but no finality gadget is set (see WithFinalityGadget)
+	ErrFinalityGadgetDisabled = errors.New("finality gadget is not set")
+
+	// ErrHeightNotCommitted is returned when the anchoring of a height
+	// the node did not commit is confirmed
+	ErrHeightNotCommitted = errors.New("height is not committed")
+)
+
+// FinalityGadget is an external finality layer (ex. a checkpoint contract,
+// or the anchoring to a parent chain) that withholds the final status of the heights
+// committed by IBFT, until it confirms them (enabled using WithFinalityGadget)
+type FinalityGadget interface {
+	// OnCommitted is called when the proposal of the height is committed and inserted.
+	// The height stays committed until the gadget confirms its anchoring
+	// using IBFT.ConfirmAnchored. It is called from the sequence routine,
+	// so it must not block
+	OnCommitted(height uint64, proposalHash []byte)
+}
+
+// FinalityStatus is the finality status of a height
+type FinalityStatus uint8
+
+const (
+	// FinalityUnknown is the status of the heights not committed by the node
+	FinalityUnknown FinalityStatus = iota
+
+	// FinalityCommitted is the status of the heights committed by IBFT,
+	// whose anchoring is not yet confirmed by the finality gadget
+	FinalityCommitted
+
+	// FinalityAnchored is the status of the heights
+	// whose anchoring is confirmed by the finality gadget
+	FinalityAnchored
+)
+
+// String returns the human-readable finality status
+func (s FinalityStatus) String() string {
+	switch s {
+	case FinalityUnknown:
+		return "unknown"
+	case FinalityCommitted:
+		return "committed"
+	case FinalityAnchored:
+		return "anchored"
+	}
+
+	return "invalid"
+}
+
+// finalityTracker tracks the finality status of the committed heights.
+// Anchoring is monotonic, so only the heights above the latest anchored
+// height are tracked individually
+type finalityTracker struct {
+	sync.Mutex
+
+	// anchored is the latest anchored height, if hasAnchored is set
+	anchored    uint64
+	hasAnchored bool
+
+	// committed are the proposal hashes of the committed heights
+	// awaiting the anchoring confirmation, by height
+	committed map[uint64][]byte
+}
+
+// newFinalityTracker creates a new finality tracker
+func newFinalityTracker() *finalityTracker {
+	return &finalityTracker{
+		committed: make(map[uint64][]byte),
+	}
+}
+
+// isAnchored checks if the height is covered by the latest anchored height
+func (t *finalityTracker) isAnchored(height uint64) bool {
+	return t.hasAnchored && height <= t.anchored
+}
+
+// status returns the finality status of the height
+func (t *finalityTracker) status(height uint64) FinalityStatus {
+	t.Lock()
+	defer t.Unlock()
+
+	switch {
+	case t.isAnchored(height):
+		return FinalityAnchored
+	case t.committed[height] != nil:
+		return FinalityCommitted
+	default:
+		return FinalityUnknown
+	}
+}
+
+// commit marks the height as committed, and returns true
+// if the height was not already committed or anchored
+func (t *finalityTracker) commit(height uint64, proposalHash []byte) bool {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.isAnchored(height) || t.committed[height] != nil {
+		return false
+	}
+
+	t.committed[height] = append([]byte{}, proposalHash...)
+
+	return true
+}
+
+// anchor marks the committed heights up to the specified height as anchored,
+// and returns the newly anchored heights with their proposal hashes, in ascending order
+func (t *finalityTracker) anchor(height uint64) ([]FinalityStatusData, error) {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.isAnchored(height) {
+		return nil, nil
+	}
+
+	if t.committed[height] == nil {
+		return nil, ErrHeightNotCommitted
+	}
+
+	anchored := make([]FinalityStatusData, 0, len(t.committed))
+
+	for committed, proposalHash := range t.committed {
+		if committed > height {
+			continue
+		}
+
+		anchored = append(anchored, FinalityStatusData{
+			Height:       committed,
+			ProposalHash: proposalHash,
+			Previous:     FinalityCommitted,
+			Status:       FinalityAnchored,
+		})
+
+		delete(t.committed, committed)
+	}
+
+	sort.Slice(anchored, func(a, b int) bool {
+		return anchored[a].Height < anchored[b].Height
+	})
+
+	t.anchored, t.hasAnchored = height, true
+
+	return anchored, nil
+}
+
+// markCommitted records the height as committed, and hands it over
+// to the finality gadget, if set. The transition is emitted as EventFinalityStatus
+func (i *IBFT) markCommitted(height uint64, proposalHash []byte) {
+	if i.finalityGadget == nil || !i.finalityStatus.commit(height, proposalHash) {
+		return
+	}
+
+	i.emitEvent(EventFinalityStatus, &proto.View{Height: height}, FinalityStatusData{
+		Height:       height,
+		ProposalHash: proposalHash,
+		Previous:     FinalityUnknown,
+		Status:       FinalityCommitted,
+	})
+
+	i.finalityGadget.OnCommitted(height, proposalHash)
+}
+
+// ConfirmAnchored is called by the finality gadget to confirm the anchoring
+// of the committed height. The anchoring of a height covers all the lower heights
+// (ex. a checkpoint covers the heights since the previous checkpoint), so the committed
+// heights up to the specified height are anchored, each emitting EventFinalityStatus.
+// Confirming an already anchored height is a no-op
+func (i *IBFT) ConfirmAnchored(height uint64) error {
+	if i.finalityGadget == nil {
+		return ErrFinalityGadgetDisabled
+	}
+
+	anchored, err := i.finalityStatus.anchor(height)
+	if err != nil {
+		return err
+	}
+
+	for _, transition := range anchored {
+		i.log.Info("height anchored", "height", transition.Height)
+		i.emitEvent(EventFinalityStatus, &proto.View{Height: transition.Height}, transition)
+	}
+
+	return nil
+}
+
+// FinalityStatus returns the finality status of the height,
+// tracked if the finality gadget is set (see WithFinalityGadget)
+func (i *IBFT) FinalityStatus(height uint64) FinalityStatus {
+	return i.finalityStatus.status(height)
+}
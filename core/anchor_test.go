@@ -0,0 +1,91 @@
+package core
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gadgetRecorder is a finality gadget recording the committed heights
+type gadgetRecorder struct {
+	sync.Mutex
+
+	committed []uint64
+}
+
+func (g *gadgetRecorder) OnCommitted(height uint64, _ []byte) {
+	g.Lock()
+	defer g.Unlock()
+
+	g.committed = append(g.committed, height)
+}
+
+// TestIBFT_ConfirmAnchored makes sure the committed heights are handed over
+// to the finality gadget, and stay committed until their anchoring is confirmed
+func TestIBFT_ConfirmAnchored(t *testing.T) {
+	t.Parallel()
+
+	gadget := &gadgetRecorder{}
+
+	i := NewIBFT(mockLogger{}, mockBackend{}, mockTransport{}, WithFinalityGadget(gadget))
+
+	sub := i.SubscribeEvents()
+	defer i.UnsubscribeEvents(sub.ID)
+
+	for height := uint64(1); height <= 3; height++ {
+		i.markCommitted(height, []byte{byte(height)})
+	}
+
+	// Make sure a height is committed once
+	i.markCommitted(2, []byte{2})
+
+	assert.Equal(t, []uint64{1, 2, 3}, gadget.committed)
+	assert.Equal(t, FinalityCommitted, i.FinalityStatus(2))
+	assert.Equal(t, FinalityUnknown, i.FinalityStatus(4))
+
+	// Make sure only the committed heights can be anchored
+	assert.ErrorIs(t, i.ConfirmAnchored(4), ErrHeightNotCommitted)
+
+	// Make sure the anchoring covers the lower heights
+	require.NoError(t, i.ConfirmAnchored(2))
+
+	assert.Equal(t, FinalityAnchored, i.FinalityStatus(1))
+	assert.Equal(t, FinalityAnchored, i.FinalityStatus(2))
+	assert.Equal(t, FinalityCommitted, i.FinalityStatus(3))
+
+	// Make sure the anchored heights are not committed again,
+	// and their anchoring is not confirmed twice
+	i.markCommitted(1, []byte{1})
+	require.NoError(t, i.ConfirmAnchored(1))
+
+	events := drainEvents(sub, EventFinalityStatus)
+	require.Len(t, events, 5)
+
+	transitions := make([]FinalityStatusData, 0, len(events))
+	for _, event := range events {
+		transitions = append(transitions, event.Data.(FinalityStatusData))
+	}
+
+	assert.Equal(t, []FinalityStatusData{
+		{Height: 1, ProposalHash: []byte{1}, Previous: FinalityUnknown, Status: FinalityCommitted},
+		{Height: 2, ProposalHash: []byte{2}, Previous: FinalityUnknown, Status: FinalityCommitted},
+		{Height: 3, ProposalHash: []byte{3}, Previous: FinalityUnknown, Status: FinalityCommitted},
+		{Height: 1, ProposalHash: []byte{1}, Previous: FinalityCommitted, Status: FinalityAnchored},
+		{Height: 2, ProposalHash: []byte{2}, Previous: FinalityCommitted, Status: FinalityAnchored},
+	}, transitions)
+}
+
+// TestIBFT_ConfirmAnchored_Disabled makes sure the finality status
+// is not tracked without a finality gadget
+func TestIBFT_ConfirmAnchored_Disabled(t *testing.T) {
+	t.Parallel()
+
+	i := NewIBFT(mockLogger{}, mockBackend{}, mockTransport{})
+
+	i.markCommitted(1, []byte{1})
+
+	assert.Equal(t, FinalityUnknown, i.FinalityStatus(1))
+	assert.ErrorIs(t, i.ConfirmAnchored(1), ErrFinalityGadgetDisabled)
+}
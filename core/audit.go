@@ -0,0 +1,61 @@
+package core
+
+import (
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// QueryMessages fetches the stored messages selected by the query, unvalidated,
+// ordered by round, type and arrival. It lets auditors reconstruct why a height
+// was finalized. Only the heights retained by the prune policy can be queried;
+// see WithPrunePolicy
+func (i *IBFT) QueryMessages(query messages.MessageQuery) []*proto.Message {
+	return i.messages.Query(query)
+}
+
+// MessageStats returns the message store statistics of each stored view
+// (messages and senders by type, duplicate and invalid message counts),
+// ordered by the view. It helps debugging quorum stalls, by showing
+// which validators the quorum is missing
+func (i *IBFT) MessageStats() []messages.RoundStats {
+	return i.messages.Stats()
+}
+
+// StoreOccupancy is the occupancy of the message store
+type StoreOccupancy struct {
+	// Messages is the number of stored messages, of all types
+	Messages int
+
+	// Views are the numbers of stored messages by type, for each view
+	Views []messages.ViewCount
+
+	// Subscriptions is the number of active message subscriptions
+	Subscriptions int
+
+	// OldestHeight is the lowest height with stored messages,
+	// zero if the store is empty
+	OldestHeight uint64
+}
+
+// MessageStoreOccupancy returns the occupancy of the message store. Node health
+// endpoints and metrics adapters can use it to detect leaks and pruning failures,
+// such as messages retained far below the current height, or subscriptions
+// outliving the sequences
+func (i *IBFT) MessageStoreOccupancy() StoreOccupancy {
+	oldest, _ := i.messages.OldestHeight()
+
+	return StoreOccupancy{
+		Messages:      i.messages.Len(),
+		Views:         i.messages.ViewCounts(),
+		Subscriptions: i.messages.NumSubscriptions(),
+		OldestHeight:  oldest,
+	}
+}
+
+// HighestObservedRound returns the highest round of the height for which
+// the node stored a ROUND_CHANGE or PREPREPARE message of a validator,
+// and false if there is none. Node software and dashboards can compare it
+// with the local round, to report how far ahead of the node the network is
+func (i *IBFT) HighestObservedRound(height uint64) (uint64, bool) {
+	return i.messages.HighestRound(height)
+}
@@ -0,0 +1,96 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+func TestIBFT_QueryMessages(t *testing.T) {
+	t.Parallel()
+
+	var (
+		query    = messages.MessageQuery{Height: 1}
+		expected = []*proto.Message{{Type: proto.MessageType_COMMIT}}
+	)
+
+	i := NewIBFT(mockLogger{}, mockBackend{}, mockTransport{})
+	i.messages = mockMessages{
+		queryFn: func(q messages.MessageQuery) []*proto.Message {
+			assert.Equal(t, query, q)
+
+			return expected
+		},
+	}
+
+	assert.Equal(t, expected, i.QueryMessages(query))
+}
+
+func TestIBFT_MessageStats(t *testing.T) {
+	t.Parallel()
+
+	expected := []messages.RoundStats{{Height: 1, Round: 2}}
+
+	i := NewIBFT(mockLogger{}, mockBackend{}, mockTransport{})
+	i.messages = mockMessages{
+		statsFn: func() []messages.RoundStats {
+			return expected
+		},
+	}
+
+	assert.Equal(t, expected, i.MessageStats())
+}
+
+func TestIBFT_HighestObservedRound(t *testing.T) {
+	t.Parallel()
+
+	i := NewIBFT(mockLogger{}, mockBackend{}, mockTransport{})
+	i.messages = mockMessages{
+		highestRoundFn: func(height uint64) (uint64, bool) {
+			assert.Equal(t, uint64(3), height)
+
+			return 7, true
+		},
+	}
+
+	round, found := i.HighestObservedRound(3)
+
+	assert.True(t, found)
+	assert.Equal(t, uint64(7), round)
+}
+
+func TestIBFT_MessageStoreOccupancy(t *testing.T) {
+	t.Parallel()
+
+	views := []messages.ViewCount{{Height: 4, Round: 0}}
+
+	i := NewIBFT(mockLogger{}, mockBackend{}, mockTransport{})
+	i.messages = mockMessages{
+		lenFn: func() int {
+			return 12
+		},
+		viewCountsFn: func() []messages.ViewCount {
+			return views
+		},
+		numSubscriptionsFn: func() int {
+			return 2
+		},
+		oldestHeightFn: func() (uint64, bool) {
+			return 4, true
+		},
+	}
+
+	assert.Equal(
+		t,
+		StoreOccupancy{
+			Messages:      12,
+			Views:         views,
+			Subscriptions: 2,
+			OldestHeight:  4,
+		},
+		i.MessageStoreOccupancy(),
+	)
+}
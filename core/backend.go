@@ -3,6 +3,8 @@
 package core
 
 import (
+	"time"
+
 	"github.com/renloi/ibft/messages"
 	"github.com/renloi/ibft/messages/proto"
 )
@@ -76,3 +78,170 @@ type Backend interface {
 	// for the specified height.
 	HasQuorum(height uint64, msgs []*proto.Message, msgType proto.MessageType) bool
 }
+
+// TimestampVerifier is an optional Backend extension for chains
+// that enforce monotonic or bounded proposal timestamps.
+// If the backend implements it, proposals with unacceptable timestamps are rejected
+// at the consensus layer
+type TimestampVerifier interface {
+	// IsValidProposalTimestamp checks if the proposal timestamp is acceptable
+	// for the given view (ex. the clock skew is bounded, the timestamp is monotonic).
+	// The timestamp is the zero time if the proposer did not set it
+	IsValidProposalTimestamp(timestamp time.Time, view *proto.View) bool
+}
+
+// PreparedObserver is an optional Backend extension for observing
+// prepared certificates. If the backend implements it, each freshly built
+// PC is passed to it, so it can be persisted externally (for recovery)
+// or exposed to monitoring
+type PreparedObserver interface {
+	// OnPrepared is called when the node receives a quorum of PREPARE messages,
+	// with the built prepared certificate and the prepared proposal.
+	// The arguments are copies, and can be freely retained or modified
+	OnPrepared(certificate *proto.PreparedCertificate, proposal *proto.Proposal)
+}
+
+// KeyVerifier is an optional Backend extension for chains
+// supporting validator key rotation. If the backend implements it, messages and
+// committed seals of validators that rotated their key (using RotateKey)
+// are validated against the key in effect for the view, instead of
+// using IsValidValidator and IsValidCommittedSeal
+type KeyVerifier interface {
+	// IsValidValidatorKey checks if the message is signed using the specified key,
+	// and the sender is one of the validators at the height in the message
+	IsValidValidatorKey(msg *proto.Message, key []byte) bool
+
+	// IsValidCommittedSealKey checks if the committed seal
+	// for the proposal hash is signed using the specified key
+	IsValidCommittedSealKey(proposalHash []byte, committedSeal *messages.CommittedSeal, key []byte) bool
+}
+
+// DigestVerifier is an optional Backend extension for verifying the messages
+// against their cached canonical digest. If the backend implements it, messages
+// (of validators that did not rotate their key) are validated using IsValidValidatorDigest
+// instead of IsValidValidator, so the backend does not re-encode the message to recover the signer
+type DigestVerifier interface {
+	// IsValidValidatorDigest checks if the message is signed by the sender,
+	// and the sender is one of the validators at the height in the message.
+	// The digest signing payload is the data covered by the message signature,
+	// it is shared and must not be modified
+	IsValidValidatorDigest(msg *proto.Message, digest *messages.Digest) bool
+}
+
+// MembershipVerifier is an optional Backend extension for accepting the messages
+// authenticated by their envelope (see WithEnvelopeVerifier) without verifying
+// the message signature. If the backend implements it, the senders of the messages
+// received in verified envelopes are only checked for validator membership, instead
+// of using IsValidValidator. The PREPREPARE, PREPARE and ROUND_CHANGE messages are
+// relayed to the peers in the certificates, without their envelope, so their
+// signature is still verified using IsValidValidator
+type MembershipVerifier interface {
+	// IsValidator checks if the sender is one of the validators at the height
+	IsValidator(from []byte, height uint64) bool
+}
+
+// ProposerSelector is an optional Backend extension for demoting
+// the proposers whose rounds repeatedly fail. If the backend implements it,
+// the engine records the rounds that expire without a proposal, and the rounds
+// with invalid proposals, and selects the proposers using SelectProposer
+// instead of IsProposer
+type ProposerSelector interface {
+	// SelectProposer returns the proposer for the view, given the records
+	// of the failed rounds of each proposer (ex. skipping proposers with
+	// too many consecutive failures). The records are only updated once a height
+	// is finalized, so they are the same during a height. The records are local
+	// observations, which can differ between validators; the validators that
+	// disagree on the proposer fail the round, so policies should only demote
+	// proposers with a clear failure record.
+	// The quorum verifier proposer (see CountQuorum) must match the selection
+	SelectProposer(height, round uint64, records ProposerRecords) []byte
+}
+
+// NilProposalBuilder is an optional Backend extension for chains
+// supporting explicit NIL proposals (enabled using WithNilProposals).
+// If the backend implements it, a proposer that cannot build a proposal
+// proposes to skip the round, so the round is decided without waiting for the timeout
+type NilProposalBuilder interface {
+	// BuildNilPrePrepareMessage builds a signed PREPREPARE message for the view,
+	// marked as a NIL proposal. The raw proposal and the proposal hash
+	// must be empty, and the proposal round must be the view round
+	BuildNilPrePrepareMessage(certificate *proto.RoundChangeCertificate, view *proto.View) *proto.Message
+}
+
+// VetoBuilder is an optional Backend extension for chains rejecting
+// invalid proposals explicitly (enabled using WithVetoes). If the backend implements it,
+// a validator that finds the proposal of the round invalid multicasts a VETO message,
+// and the round is skipped once the vetoes reach the rejection quorum
+type VetoBuilder interface {
+	// BuildVetoMessage builds a signed VETO message for the view,
+	// rejecting the proposal with the specified hash
+	BuildVetoMessage(proposalHash []byte, view *proto.View) *proto.Message
+}
+
+// ProposerAvailability is an optional Backend extension for validators
+// that can detect they are unable to fulfill their proposer duty
+// (ex. the node is still syncing). If the backend implements it, an unavailable
+// proposer multicasts a PROPOSER_UNAVAILABLE message instead of a proposal,
+// which shortens the round timeout of the other validators
+type ProposerAvailability interface {
+	// IsProposerAvailable checks if the node can build a proposal for the view
+	IsProposerAvailable(view *proto.View) bool
+
+	// BuildProposerUnavailableMessage builds a signed
+	// PROPOSER_UNAVAILABLE message for the view
+	BuildProposerUnavailableMessage(view *proto.View) *proto.Message
+}
+
+// ThresholdSigner is an optional Backend extension for chains sealing
+// the proposals with a t-of-n threshold signature. If the backend implements it,
+// the committed seal of each COMMIT message (built by BuildCommitMessage) is
+// the partial signature of its sender, and the proposal is finalized as soon as
+// the partial signatures combine into a valid threshold signature, which can
+// happen before a quorum of COMMIT messages is received
+type ThresholdSigner interface {
+	// Threshold returns the number of partial signatures
+	// needed to combine the threshold signature at the height
+	Threshold(height uint64) uint64
+
+	// IsValidPartialSeal checks if the committed seal is a valid
+	// partial signature of its signer over the proposal hash
+	IsValidPartialSeal(height uint64, proposalHash []byte, partialSeal *messages.CommittedSeal) bool
+
+	// CombineSeals combines the partial signatures over the proposal hash
+	// into the threshold signature
+	CombineSeals(height uint64, proposalHash []byte, partialSeals []*messages.CommittedSeal) ([]byte, error)
+
+	// IsValidThresholdSeal checks if the threshold signature
+	// over the proposal hash is valid
+	IsValidThresholdSeal(height uint64, proposalHash []byte, thresholdSeal []byte) bool
+
+	// InsertThresholdProposal inserts the proposal with its threshold signature,
+	// instead of InsertProposal. The partial signatures it was combined from are passed along
+	InsertThresholdProposal(proposal *proto.Proposal, thresholdSeal []byte, partialSeals []*messages.CommittedSeal)
+}
+
+// RandomnessBeacon is an optional Backend extension for chains deriving
+// a randomness beacon from the finalizations, without extra round trips.
+// If the backend implements it, each COMMIT message (built by BuildCommitMessage)
+// must carry the randomness contribution of its sender (ex. a VRF output with its proof),
+// which is validated before the message is counted toward the quorum. The contributions
+// are passed to InsertProposal along with the committed seals, and can be combined
+// into the beacon output using messages.BeaconOutput
+type RandomnessBeacon interface {
+	// IsValidRandomness checks if the randomness contribution of the committed seal
+	// signer is valid for the view and the proposal hash
+	// (ex. the VRF proof over the previous beacon output verifies)
+	IsValidRandomness(view *proto.View, proposalHash []byte, seal *messages.CommittedSeal) bool
+}
+
+// ParticipationGate is an optional Backend extension for nodes that temporarily
+// cannot participate in the consensus (ex. the node has fallen behind, or is
+// snapshot-syncing). If the backend implements it, a node that should not participate
+// keeps collecting messages as an observer, following the rounds and finalizing
+// the height on the quorums of the other validators, but does not build
+// or sign any message. It is checked at the sequence start, and on each round change
+type ParticipationGate interface {
+	// ShouldParticipate checks if the node should participate
+	// in the consensus at the height
+	ShouldParticipate(height uint64) bool
+}
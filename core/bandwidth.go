@@ -0,0 +1,50 @@
+package core
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// The directions of the accounted bandwidth
+const (
+	bandwidthSent     = "sent"
+	bandwidthReceived = "received"
+)
+
+// bandwidthKey returns the counter key of the bytes of the message type,
+// in the direction
+func bandwidthKey(direction string, messageType proto.MessageType) []string {
+	return []string{"ibft", "bandwidth", direction, strings.ToLower(messageType.String())}
+}
+
+// peerBandwidthKey returns the counter key of the bytes of the message type,
+// exchanged with the peer in the direction
+func peerBandwidthKey(direction string, peer []byte, messageType proto.MessageType) []string {
+	return []string{
+		"ibft",
+		"bandwidth",
+		direction,
+		"peer",
+		hex.EncodeToString(peer),
+		strings.ToLower(messageType.String()),
+	}
+}
+
+// messageSizeKey returns the histogram key of the encoded sizes of the message type,
+// which exposes the oversized messages (ex. PREPREPARE messages carrying big RCCs)
+func messageSizeKey(messageType proto.MessageType) []string {
+	return []string{"ibft", "bandwidth", "size", strings.ToLower(messageType.String())}
+}
+
+// recordBandwidth accounts the size of the encoded message of the type, exchanged
+// in the direction, to the message type, and to the peer, if known
+func recordBandwidth(metrics Metrics, direction string, peer []byte, messageType proto.MessageType, size int) {
+	metrics.IncrCounter(bandwidthKey(direction, messageType), float32(size))
+	metrics.AddSample(messageSizeKey(messageType), float32(size))
+
+	if peer != nil {
+		metrics.IncrCounter(peerBandwidthKey(direction, peer, messageType), float32(size))
+	}
+}
@@ -0,0 +1,262 @@
+package core
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	protoBuf "google.golang.org/protobuf/proto"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// benchValidatorCounts are the validator set sizes the engine is benchmarked with
+var benchValidatorCounts = []int{100, 500, 1000}
+
+// benchSetup is a validator set whose messages are signed with
+// a hash-based mock signature, so verifying a message costs roughly
+// as much as encoding and hashing it
+type benchSetup struct {
+	validators [][]byte
+	quorum     CountQuorum
+}
+
+// newBenchSetup creates a benchmark setup with the specified number of validators
+func newBenchSetup(count int) *benchSetup {
+	validators := make([][]byte, count)
+	for index := range validators {
+		validators[index] = []byte(fmt.Sprintf("validator %d", index))
+	}
+
+	return &benchSetup{
+		validators: validators,
+		quorum: CountQuorum{
+			ValidatorCount: func(_ uint64) uint64 {
+				return uint64(count)
+			},
+		},
+	}
+}
+
+// signature returns the mock signature of the message
+func (s *benchSetup) signature(message *proto.Message) []byte {
+	payload, _ := message.PayloadNoSig()
+	signature := sha256.Sum256(payload)
+
+	return signature[:]
+}
+
+// sign sets the mock signature of the message
+func (s *benchSetup) sign(message *proto.Message) *proto.Message {
+	message.Signature = s.signature(message)
+
+	return message
+}
+
+// proposer returns the proposer for the view
+func (s *benchSetup) proposer(height, round uint64) []byte {
+	return s.validators[(height+round)%uint64(len(s.validators))]
+}
+
+// quorumSize returns the number of messages required for quorum
+func (s *benchSetup) quorumSize() int {
+	return int(QuorumSize(uint64(len(s.validators))))
+}
+
+// newIBFT creates an IBFT instance for the validator at the specified index
+func (s *benchSetup) newIBFT(index int) *IBFT {
+	backend := mockBackend{
+		idFn: func() []byte {
+			return s.validators[index]
+		},
+		IsValidValidatorFn: func(message *proto.Message) bool {
+			return bytes.Equal(message.Signature, s.signature(message))
+		},
+		isProposerFn: func(id []byte, height, round uint64) bool {
+			return bytes.Equal(id, s.proposer(height, round))
+		},
+		isValidProposalHashFn: func(_ *proto.Proposal, hash []byte) bool {
+			return bytes.Equal(hash, []byte("proposal hash"))
+		},
+	}
+
+	i := NewIBFT(mockLogger{}, backend, mockTransport{}, WithQuorumVerifier(s.quorum))
+	i.state.setView(&proto.View{Height: 1, Round: 1})
+
+	return i
+}
+
+// buildProposal builds a signed PREPREPARE message for the view
+func (s *benchSetup) buildProposal(view *proto.View, certificate *proto.RoundChangeCertificate) *proto.Message {
+	return s.sign(&proto.Message{
+		View: &proto.View{Height: view.Height, Round: view.Round},
+		From: s.proposer(view.Height, view.Round),
+		Type: proto.MessageType_PREPREPARE,
+		Payload: &proto.Message_PreprepareData{
+			PreprepareData: &proto.PrePrepareMessage{
+				Proposal: &proto.Proposal{
+					RawProposal: []byte("proposal"),
+					Round:       view.Round,
+				},
+				ProposalHash: []byte("proposal hash"),
+				Certificate:  certificate,
+			},
+		},
+	})
+}
+
+// buildPrepares builds a quorum of signed PREPARE messages for the view,
+// excluding the proposer
+func (s *benchSetup) buildPrepares(view *proto.View) []*proto.Message {
+	var (
+		proposer = s.proposer(view.Height, view.Round)
+		prepares = make([]*proto.Message, 0, s.quorumSize())
+	)
+
+	for _, validator := range s.validators {
+		if len(prepares) == s.quorumSize()-1 {
+			break
+		}
+
+		if bytes.Equal(validator, proposer) {
+			continue
+		}
+
+		prepares = append(prepares, s.sign(&proto.Message{
+			View: &proto.View{Height: view.Height, Round: view.Round},
+			From: validator,
+			Type: proto.MessageType_PREPARE,
+			Payload: &proto.Message_PrepareData{
+				PrepareData: &proto.PrepareMessage{
+					ProposalHash: []byte("proposal hash"),
+				},
+			},
+		}))
+	}
+
+	return prepares
+}
+
+// buildRoundChanges builds a quorum of signed ROUND CHANGE messages for the view,
+// each carrying its own copy of the same PC, as if decoded from the network
+func (s *benchSetup) buildRoundChanges(view *proto.View) []*proto.Message {
+	var (
+		pcView = &proto.View{Height: view.Height, Round: view.Round - 1}
+		pc     = &proto.PreparedCertificate{
+			ProposalMessage: s.buildProposal(pcView, nil),
+			PrepareMessages: s.buildPrepares(pcView),
+		}
+
+		roundChanges = make([]*proto.Message, 0, s.quorumSize())
+	)
+
+	for _, validator := range s.validators[:s.quorumSize()] {
+		certificate, _ := protoBuf.Clone(pc).(*proto.PreparedCertificate)
+
+		roundChanges = append(roundChanges, s.sign(&proto.Message{
+			View: &proto.View{Height: view.Height, Round: view.Round},
+			From: validator,
+			Type: proto.MessageType_ROUND_CHANGE,
+			Payload: &proto.Message_RoundChangeData{
+				RoundChangeData: &proto.RoundChangeMessage{
+					LastPreparedProposal:      certificate.ProposalMessage.GetPreprepareData().Proposal,
+					LatestPreparedCertificate: certificate,
+				},
+			},
+		}))
+	}
+
+	return roundChanges
+}
+
+// nonProposerIndex returns the index of a validator that is not the proposer for the view
+func (s *benchSetup) nonProposerIndex(view *proto.View) int {
+	return int((view.Height + view.Round + 1) % uint64(len(s.validators)))
+}
+
+func BenchmarkIBFT_ValidateProposal(b *testing.B) {
+	for _, count := range benchValidatorCounts {
+		count := count
+
+		b.Run(fmt.Sprintf("%d validators", count), func(b *testing.B) {
+			var (
+				setup    = newBenchSetup(count)
+				view     = &proto.View{Height: 1, Round: 1}
+				proposal = setup.buildProposal(view, &proto.RoundChangeCertificate{
+					RoundChangeMessages: setup.buildRoundChanges(view),
+				})
+
+				i = setup.newIBFT(setup.nonProposerIndex(view))
+			)
+
+			if !i.validateProposal(proposal, view) {
+				b.Fatal("invalid proposal")
+			}
+
+			b.ResetTimer()
+
+			for n := 0; n < b.N; n++ {
+				i.validateProposal(proposal, view)
+			}
+		})
+	}
+}
+
+func BenchmarkIBFT_HandleRoundChangeMessage(b *testing.B) {
+	for _, count := range benchValidatorCounts {
+		count := count
+
+		b.Run(fmt.Sprintf("%d validators", count), func(b *testing.B) {
+			var (
+				setup = newBenchSetup(count)
+				view  = &proto.View{Height: 1, Round: 1}
+				i     = setup.newIBFT(setup.nonProposerIndex(view))
+			)
+
+			for _, roundChange := range setup.buildRoundChanges(view) {
+				i.messages.AddMessage(roundChange)
+			}
+
+			if i.handleRoundChangeMessage(view) == nil {
+				b.Fatal("missing RCC")
+			}
+
+			b.ResetTimer()
+
+			for n := 0; n < b.N; n++ {
+				i.handleRoundChangeMessage(view)
+			}
+		})
+	}
+}
+
+func BenchmarkIBFT_PrepareQuorum(b *testing.B) {
+	for _, count := range benchValidatorCounts {
+		count := count
+
+		b.Run(fmt.Sprintf("%d validators", count), func(b *testing.B) {
+			var (
+				setup    = newBenchSetup(count)
+				view     = &proto.View{Height: 1, Round: 1}
+				proposal = setup.buildProposal(view, nil)
+				prepares = setup.buildPrepares(view)
+			)
+
+			b.ResetTimer()
+
+			for n := 0; n < b.N; n++ {
+				i := setup.newIBFT(setup.nonProposerIndex(view))
+				i.state.setProposalMessage(proposal)
+
+				for _, prepare := range prepares {
+					i.AddMessage(prepare)
+				}
+
+				if i.handlePrepare(view, make(validatedMessages)) == nil {
+					b.Fatal("quorum not reached")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package core
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// highestPrepared returns the ROUND_CHANGE messages of the certificate
+// carrying the PC of the highest round, if any.
+// The PCs are not validated; that is left to the caller
+func highestPrepared(rcc *proto.RoundChangeCertificate) []*proto.Message {
+	var (
+		highest  []*proto.Message
+		maxRound uint64
+	)
+
+	for _, rcMessage := range rcc.GetRoundChangeMessages() {
+		rcData, err := messages.ExtractPayload[*proto.RoundChangeMessage](rcMessage)
+		if err != nil || rcData.LatestPreparedCertificate == nil {
+			continue
+		}
+
+		round := rcData.LatestPreparedCertificate.GetProposalMessage().GetView().GetRound()
+
+		switch {
+		case len(highest) == 0 || round > maxRound:
+			highest = []*proto.Message{rcMessage}
+			maxRound = round
+		case round == maxRound:
+			highest = append(highest, rcMessage)
+		}
+	}
+
+	return highest
+}
+
+// highestPreparedSenders returns the senders of the ROUND_CHANGE messages
+// of the certificate carrying the PC of the highest round, if any.
+// The PCs are not validated; that is left to the caller
+func highestPreparedSenders(rcc *proto.RoundChangeCertificate) [][]byte {
+	highest := highestPrepared(rcc)
+
+	senders := make([][]byte, 0, len(highest))
+	for _, rcMessage := range highest {
+		senders = append(senders, rcMessage.From)
+	}
+
+	return senders
+}
+
+// highestPreparedProposal returns the raw proposal of the highest PC of the certificate
+// held by the sender, or nil if the sender does not hold the highest PC
+func highestPreparedProposal(rcc *proto.RoundChangeCertificate, sender []byte) []byte {
+	for _, rcMessage := range highestPrepared(rcc) {
+		if !bytes.Equal(rcMessage.From, sender) {
+			continue
+		}
+
+		rcData, err := messages.ExtractPayload[*proto.RoundChangeMessage](rcMessage)
+		if err != nil {
+			return nil
+		}
+
+		preprepareData, err := messages.ExtractPayload[*proto.PrePrepareMessage](
+			rcData.LatestPreparedCertificate.GetProposalMessage(),
+		)
+		if err != nil {
+			return nil
+		}
+
+		return preprepareData.Proposal.GetRawProposal()
+	}
+
+	return nil
+}
+
+// isBoostedProposal checks if the proposal is sent by a holder of the highest PC
+// of its RCC, which may propose it in place of the round proposer (see WithReproposalBoost).
+// The RCC is not validated; that is left to the caller
+func (i *IBFT) isBoostedProposal(proposalMessage *proto.Message) bool {
+	if !i.reproposalBoost || proposalMessage.GetView().GetRound() == 0 {
+		return false
+	}
+
+	preprepareData, err := messages.ExtractPayload[*proto.PrePrepareMessage](proposalMessage)
+	if err != nil || preprepareData.NilProposal {
+		return false
+	}
+
+	for _, sender := range highestPreparedSenders(preprepareData.Certificate) {
+		if bytes.Equal(sender, proposalMessage.From) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// shouldBoostProposal checks if the node may propose its prepared proposal again
+// in the current round, in place of the round proposer (see WithReproposalBoost)
+func (i *IBFT) shouldBoostProposal() bool {
+	view := i.state.getView()
+
+	return i.reproposalBoost &&
+		view.Round > 0 &&
+		i.state.getLatestPC() != nil &&
+		!i.isObserving() &&
+		!i.isProposer(i.backend.ID(), view.Height, view.Round)
+}
+
+// runBoostedProposal waits for the RCC of the current round, and proposes the proposal
+// of the highest PC of the RCC again, if the node holds it,
+// and no proposal was accepted for the round meanwhile
+func (i *IBFT) runBoostedProposal(ctx context.Context) {
+	view := i.state.getView()
+
+	rcc := i.waitForRCC(ctx, view.Height, view.Round)
+	if rcc == nil || i.state.hasProposalMessage() {
+		return
+	}
+
+	// The proposal is taken from the highest PC, as the validators require it
+	// to match, regardless of the order of the ROUND_CHANGE messages in the RCC
+	rawProposal := highestPreparedProposal(rcc, i.backend.ID())
+	if rawProposal == nil {
+		return
+	}
+
+	proposalMessage := i.backend.BuildPrePrepareMessage(rawProposal, rcc, view)
+	if proposalMessage == nil || !i.claimProposal(proposalMessage) {
+		return
+	}
+
+	i.log.Info("proposing the prepared proposal again", "round", view.Round)
+	i.metrics.IncrCounter(proposalBoostedKey, 1)
+
+	i.sendPreprepareMessage(ctx, proposalMessage)
+}
@@ -0,0 +1,265 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// buildPreparedRoundChange builds a ROUND_CHANGE message carrying the PC
+// of the correct proposal, proposed in the prepared round by the proposer,
+// and prepared by the preparers
+func buildPreparedRoundChange(
+	from []byte,
+	view *proto.View,
+	preparedRound uint64,
+	proposer []byte,
+	preparers [][]byte,
+) *proto.Message {
+	preparedView := &proto.View{Height: view.Height, Round: preparedRound}
+
+	prepares := make([]*proto.Message, 0, len(preparers))
+	for _, preparer := range preparers {
+		prepares = append(prepares, buildBasicPrepareMessage(correctRoundMessage.hash, preparer, preparedView))
+	}
+
+	proposal := &proto.Proposal{
+		RawProposal: correctRoundMessage.proposal.GetRawProposal(),
+		Round:       preparedRound,
+	}
+
+	return &proto.Message{
+		View: view,
+		From: from,
+		Type: proto.MessageType_ROUND_CHANGE,
+		Payload: &proto.Message_RoundChangeData{
+			RoundChangeData: &proto.RoundChangeMessage{
+				LastPreparedProposal: proposal,
+				LatestPreparedCertificate: &proto.PreparedCertificate{
+					ProposalMessage: &proto.Message{
+						View: preparedView,
+						From: proposer,
+						Type: proto.MessageType_PREPREPARE,
+						Payload: &proto.Message_PreprepareData{
+							PreprepareData: &proto.PrePrepareMessage{
+								Proposal:     proposal,
+								ProposalHash: correctRoundMessage.hash,
+							},
+						},
+					},
+					PrepareMessages: prepares,
+				},
+			},
+		},
+	}
+}
+
+// boostBackend builds the backend of the node, in a network of the nodes
+// where the proposer of each round is picked in turns
+func boostBackend(nodes [][]byte, id []byte) mockBackend {
+	return mockBackend{
+		idFn: func() []byte {
+			return id
+		},
+		isProposerFn: func(from []byte, _, round uint64) bool {
+			return bytes.Equal(from, nodes[round%uint64(len(nodes))])
+		},
+		isValidProposalHashFn: func(_ *proto.Proposal, hash []byte) bool {
+			return bytes.Equal(hash, correctRoundMessage.hash)
+		},
+		hasQuorumFn: commonHasQuorumFn(uint64(len(nodes))),
+		buildPrePrepareMessageFn: func(
+			rawProposal []byte,
+			certificate *proto.RoundChangeCertificate,
+			view *proto.View,
+		) *proto.Message {
+			return buildBasicPreprepareMessage(rawProposal, correctRoundMessage.hash, certificate, id, view)
+		},
+	}
+}
+
+// boostRoundChanges builds the ROUND_CHANGE messages of round 1, after the proposal
+// of node 0 was prepared in round 0 by nodes 1 and 2, but not finalized
+func boostRoundChanges(nodes [][]byte, view *proto.View) []*proto.Message {
+	return []*proto.Message{
+		buildPreparedRoundChange(nodes[1], view, 0, nodes[0], nodes[1:3]),
+		buildPreparedRoundChange(nodes[2], view, 0, nodes[0], nodes[1:3]),
+		buildBasicRoundChangeMessage(nil, nil, view, nodes[3]),
+	}
+}
+
+func TestHighestPreparedSenders(t *testing.T) {
+	t.Parallel()
+
+	var (
+		nodes = generateNodeAddresses(4)
+		view  = &proto.View{Height: 1, Round: 3}
+	)
+
+	rcc := &proto.RoundChangeCertificate{
+		RoundChangeMessages: []*proto.Message{
+			buildPreparedRoundChange(nodes[0], view, 1, nodes[1], nodes[2:]),
+			buildPreparedRoundChange(nodes[1], view, 2, nodes[2], nodes[:2]),
+			buildBasicRoundChangeMessage(nil, nil, view, nodes[2]),
+			buildPreparedRoundChange(nodes[3], view, 2, nodes[2], nodes[:2]),
+		},
+	}
+
+	assert.Equal(t, [][]byte{nodes[1], nodes[3]}, highestPreparedSenders(rcc))
+	assert.Empty(t, highestPreparedSenders(nil))
+}
+
+// TestIBFT_ValidateProposal_Boost makes sure the proposals of the holders
+// of the highest PC are accepted in place of the round proposer, if the boost is enabled
+func TestIBFT_ValidateProposal_Boost(t *testing.T) {
+	t.Parallel()
+
+	var (
+		nodes = generateNodeAddresses(4)
+		view  = &proto.View{Height: 1, Round: 1}
+		rcc   = &proto.RoundChangeCertificate{RoundChangeMessages: boostRoundChanges(nodes, view)}
+	)
+
+	proposal := func(from []byte) *proto.Message {
+		return buildBasicPreprepareMessage(
+			correctRoundMessage.proposal.GetRawProposal(),
+			correctRoundMessage.hash,
+			rcc,
+			from,
+			view,
+		)
+	}
+
+	testTable := []struct {
+		name  string
+		from  []byte
+		opts  []Option
+		valid bool
+	}{
+		{"round proposer", nodes[1], nil, true},
+		{"holder of the highest PC, without the boost", nodes[2], nil, false},
+		{"holder of the highest PC", nodes[2], []Option{WithReproposalBoost()}, true},
+		{"node without a PC", nodes[3], []Option{WithReproposalBoost()}, false},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			i := NewIBFT(mockLogger{}, boostBackend(nodes, nodes[0]), mockTransport{}, testCase.opts...)
+			i.state.setView(view)
+
+			assert.Equal(t, testCase.valid, i.validateProposal(proposal(testCase.from), view))
+		})
+	}
+}
+
+// TestIBFT_RunBoostedProposal makes sure the holder of the highest PC
+// proposes it again, once the RCC is observed
+func TestIBFT_RunBoostedProposal(t *testing.T) {
+	t.Parallel()
+
+	var (
+		nodes    = generateNodeAddresses(4)
+		view     = &proto.View{Height: 1, Round: 1}
+		recorder = &multicastRecorder{}
+	)
+
+	i := NewIBFT(mockLogger{}, boostBackend(nodes, nodes[2]), recorder, WithReproposalBoost())
+	i.state.setView(view)
+
+	for _, message := range boostRoundChanges(nodes, view) {
+		i.messages.AddMessage(message)
+	}
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), time.Second)
+	defer cancelFn()
+
+	i.runBoostedProposal(ctx)
+
+	multicasted := recorder.messages()
+	require.Len(t, multicasted, 1)
+
+	assert.Equal(t, proto.MessageType_PREPREPARE, multicasted[0].Type)
+	assert.Equal(t, nodes[2], multicasted[0].From)
+	assert.Equal(t, correctRoundMessage.hash, i.state.getProposalHash())
+
+	// Make sure the proposal is accepted by the other validators
+	validator := NewIBFT(mockLogger{}, boostBackend(nodes, nodes[3]), mockTransport{}, WithReproposalBoost())
+	validator.state.setView(view)
+
+	assert.True(t, validator.validateProposal(multicasted[0], view))
+	assert.True(t, validator.Config().ReproposalBoost)
+
+	// Make sure the nodes without the highest PC do not propose
+	recorder = &multicastRecorder{}
+
+	i = NewIBFT(mockLogger{}, boostBackend(nodes, nodes[3]), recorder, WithReproposalBoost())
+	i.state.setView(view)
+
+	for _, message := range boostRoundChanges(nodes, view) {
+		i.messages.AddMessage(message)
+	}
+
+	i.runBoostedProposal(ctx)
+
+	assert.Empty(t, recorder.messages())
+	assert.False(t, i.state.hasProposalMessage())
+}
+
+// TestIBFT_RunBoostedProposal_HighestPC makes sure the boosted proposal is the one
+// of the highest PC, even if a lower PC precedes it in the RCC
+func TestIBFT_RunBoostedProposal_HighestPC(t *testing.T) {
+	t.Parallel()
+
+	var (
+		nodes    = generateNodeAddresses(4)
+		view     = &proto.View{Height: 1, Round: 2}
+		recorder = &multicastRecorder{}
+	)
+
+	// Node 1 prepared a stale proposal in round 0,
+	// and node 3 prepared the correct proposal in round 1
+	stale := buildPreparedRoundChange(nodes[1], view, 0, nodes[0], nodes[1:3])
+	stale.GetRoundChangeData().LastPreparedProposal.RawProposal = []byte("stale proposal")
+
+	roundChanges := []*proto.Message{
+		stale,
+		buildPreparedRoundChange(nodes[3], view, 1, nodes[1], nodes[2:4]),
+		buildBasicRoundChangeMessage(nil, nil, view, nodes[0]),
+	}
+
+	i := NewIBFT(mockLogger{}, boostBackend(nodes, nodes[3]), recorder, WithReproposalBoost())
+	i.state.setView(view)
+
+	for _, message := range roundChanges {
+		i.messages.AddMessage(message)
+	}
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), time.Second)
+	defer cancelFn()
+
+	i.runBoostedProposal(ctx)
+
+	multicasted := recorder.messages()
+	require.Len(t, multicasted, 1)
+
+	preprepareData := multicasted[0].GetPreprepareData()
+	require.NotNil(t, preprepareData)
+
+	assert.Equal(t, correctRoundMessage.proposal.GetRawProposal(), preprepareData.Proposal.GetRawProposal())
+
+	// Make sure the proposal is accepted by the other validators
+	validator := NewIBFT(mockLogger{}, boostBackend(nodes, nodes[0]), mockTransport{}, WithReproposalBoost())
+	validator.state.setView(view)
+
+	assert.True(t, validator.validateProposal(multicasted[0], view))
+}
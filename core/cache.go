@@ -0,0 +1,189 @@
+package core
+
+import (
+	"bytes"
+	"sync"
+
+	protoBuf "google.golang.org/protobuf/proto"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// senderKey identifies a signed message by its sender and signature
+type senderKey struct {
+	from      string
+	signature string
+}
+
+// validatorCache memoizes the sender validation of the messages repeated
+// across the certificates of a single validation pass. The ROUND CHANGE messages
+// of an RCC usually carry the same PC, so without the cache each PREPARE signature
+// is verified once per ROUND CHANGE message, which is quadratic in the validator count.
+// Only valid messages are cached, and a cached message is reused only if
+// the message being validated is identical to it
+type validatorCache struct {
+	isValid  func(*proto.Message) bool
+	verified map[senderKey]*proto.Message
+}
+
+// newValidatorCache creates a new cache for the passed in validation function
+func newValidatorCache(isValid func(*proto.Message) bool) *validatorCache {
+	return &validatorCache{
+		isValid:  isValid,
+		verified: make(map[senderKey]*proto.Message),
+	}
+}
+
+// isValidValidator checks if the message is signed by a validator,
+// reusing the result for an identical, previously validated message
+func (c *validatorCache) isValidValidator(msg *proto.Message) bool {
+	key := senderKey{
+		from:      string(msg.From),
+		signature: string(msg.Signature),
+	}
+
+	if verified, ok := c.verified[key]; ok && sameMessage(verified, msg) {
+		return true
+	}
+
+	if !c.isValid(msg) {
+		return false
+	}
+
+	c.verified[key] = msg
+
+	return true
+}
+
+// sameMessage checks if the messages are identical.
+// PREPARE messages make up most of the certificates, so they are
+// compared field by field, avoiding the cost of the reflective comparison
+func sameMessage(a, b *proto.Message) bool {
+	if a == b {
+		return true
+	}
+
+	aPrepare, aOk := a.Payload.(*proto.Message_PrepareData)
+	bPrepare, bOk := b.Payload.(*proto.Message_PrepareData)
+
+	if !aOk || !bOk {
+		return protoBuf.Equal(a, b)
+	}
+
+	return a.Type == b.Type &&
+		a.GetView().GetHeight() == b.GetView().GetHeight() &&
+		a.GetView().GetRound() == b.GetView().GetRound() &&
+		bytes.Equal(a.From, b.From) &&
+		bytes.Equal(a.Signature, b.Signature) &&
+		bytes.Equal(aPrepare.PrepareData.GetProposalHash(), bPrepare.PrepareData.GetProposalHash()) &&
+		a.Ttl == b.Ttl &&
+		a.Hops == b.Hops &&
+		!hasUnknownFields(a, a.View, aPrepare.PrepareData) &&
+		!hasUnknownFields(b, b.View, bPrepare.PrepareData)
+}
+
+// hasUnknownFields checks if any of the messages carries unknown fields,
+// which are not covered by the field by field comparison
+func hasUnknownFields(messages ...protoBuf.Message) bool {
+	for _, message := range messages {
+		if message.ProtoReflect().IsValid() && len(message.ProtoReflect().GetUnknown()) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validatedMessages tracks the stored messages that already passed validation
+// for a single phase worker, so each wake-up of the worker only validates
+// the newly arrived messages, instead of the full set.
+// The store replaces a message by storing a new one, so the messages
+// are tracked by reference
+type validatedMessages map[*proto.Message]struct{}
+
+// isValid checks if the message is valid, running the validation
+// only for messages that have not passed it before
+func (v validatedMessages) isValid(msg *proto.Message, isValid func(*proto.Message) bool) bool {
+	if _, ok := v[msg]; ok {
+		return true
+	}
+
+	if !isValid(msg) {
+		return false
+	}
+
+	v[msg] = struct{}{}
+
+	return true
+}
+
+// quorumKey identifies the messages of a type for a view
+type quorumKey struct {
+	height      uint64
+	round       uint64
+	messageType proto.MessageType
+}
+
+// quorumMemo memoizes the views and message types that reached quorum.
+// Adding messages never breaks a quorum, so once a quorum is reached
+// new messages for the view signal the subscribers without scanning the store.
+// A memoized quorum can outlive messages that the subscribers later prune
+// as invalid, which only causes a spurious wake-up, as the subscribers
+// validate the messages themselves
+type quorumMemo struct {
+	sync.RWMutex
+
+	reached map[quorumKey]struct{}
+}
+
+// newQuorumMemo creates a new empty quorum memo
+func newQuorumMemo() *quorumMemo {
+	return &quorumMemo{
+		reached: make(map[quorumKey]struct{}),
+	}
+}
+
+// hasQuorum checks if the messages of the type reached quorum for the view
+func (m *quorumMemo) hasQuorum(view *proto.View, messageType proto.MessageType) bool {
+	m.RLock()
+	defer m.RUnlock()
+
+	_, ok := m.reached[quorumKey{view.Height, view.Round, messageType}]
+
+	return ok
+}
+
+// setQuorum marks the messages of the type as reaching quorum for the view
+func (m *quorumMemo) setQuorum(view *proto.View, messageType proto.MessageType) {
+	m.Lock()
+	defer m.Unlock()
+
+	m.reached[quorumKey{view.Height, view.Round, messageType}] = struct{}{}
+}
+
+// hasQuorumFrom checks if the messages of the type reached quorum
+// for the view, or for a higher round of the view height
+func (m *quorumMemo) hasQuorumFrom(view *proto.View, messageType proto.MessageType) bool {
+	m.RLock()
+	defer m.RUnlock()
+
+	for key := range m.reached {
+		if key.height == view.Height && key.round >= view.Round && key.messageType == messageType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// prune removes the quorums for heights lower than the specified height
+func (m *quorumMemo) prune(height uint64) {
+	m.Lock()
+	defer m.Unlock()
+
+	for key := range m.reached {
+		if key.height < height {
+			delete(m.reached, key)
+		}
+	}
+}
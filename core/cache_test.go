@@ -0,0 +1,116 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	protoBuf "google.golang.org/protobuf/proto"
+
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+func TestValidatorCache_IsValidValidator(t *testing.T) {
+	t.Parallel()
+
+	var (
+		validations int
+
+		cache = newValidatorCache(func(message *proto.Message) bool {
+			validations++
+
+			return string(message.From) != "invalid"
+		})
+
+		prepare = &proto.Message{
+			View:      &proto.View{Height: 1, Round: 1},
+			From:      []byte("validator"),
+			Signature: []byte("signature"),
+			Type:      proto.MessageType_PREPARE,
+			Payload: &proto.Message_PrepareData{
+				PrepareData: &proto.PrepareMessage{
+					ProposalHash: []byte("proposal hash"),
+				},
+			},
+		}
+	)
+
+	t.Run("identical message reuses the validation", func(t *testing.T) {
+		assert.True(t, cache.isValidValidator(prepare))
+
+		clone, _ := protoBuf.Clone(prepare).(*proto.Message)
+
+		assert.True(t, cache.isValidValidator(clone))
+		assert.Equal(t, 1, validations)
+	})
+
+	t.Run("altered message is validated again", func(t *testing.T) {
+		altered, _ := protoBuf.Clone(prepare).(*proto.Message)
+		altered.View.Round = 2
+
+		assert.True(t, cache.isValidValidator(altered))
+		assert.Equal(t, 2, validations)
+	})
+
+	t.Run("invalid message is not cached", func(t *testing.T) {
+		invalid, _ := protoBuf.Clone(prepare).(*proto.Message)
+		invalid.From = []byte("invalid")
+
+		assert.False(t, cache.isValidValidator(invalid))
+		assert.False(t, cache.isValidValidator(invalid))
+		assert.Equal(t, 4, validations)
+	})
+}
+
+func TestIBFT_HandleCommit_IncrementalValidation(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view        = &proto.View{Height: 1, Round: 0}
+		validations int
+
+		backend = mockBackend{
+			hasQuorumFn: func(_ uint64, messages []*proto.Message, _ proto.MessageType) bool {
+				return len(messages) >= 3
+			},
+			isValidCommittedSealFn: func(_ []byte, _ *messages.CommittedSeal) bool {
+				validations++
+
+				return true
+			},
+		}
+	)
+
+	i := NewIBFT(mockLogger{}, backend, mockTransport{})
+	i.state.setView(view)
+	i.state.setProposalMessage(
+		buildBasicPreprepareMessage(
+			correctRoundMessage.proposal.GetRawProposal(),
+			correctRoundMessage.hash,
+			nil,
+			[]byte("proposer"),
+			view,
+		),
+	)
+
+	addCommits := func(senders ...string) {
+		for _, sender := range senders {
+			i.messages.AddMessage(
+				buildBasicCommitMessage(correctRoundMessage.hash, correctRoundMessage.seal, []byte(sender), view),
+			)
+		}
+	}
+
+	validated := make(validatedMessages)
+
+	addCommits("node 0", "node 1")
+
+	assert.False(t, i.handleCommit(view, validated))
+	assert.Equal(t, 2, validations)
+
+	// Make sure only the newly arrived message is validated on the next wake-up
+	addCommits("node 2")
+
+	assert.True(t, i.handleCommit(view, validated))
+	assert.Equal(t, 3, validations)
+}
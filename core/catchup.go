@@ -0,0 +1,236 @@
+package core
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// ErrNoFinalityProof is an error indicating the imported messages
+// do not prove the finalization of a proposal for the height
+var ErrNoFinalityProof = errors.New("messages do not prove the finality of the height")
+
+// ImportForHeight verifies the messages of a height explicitly requested
+// from the peers during catch-up, and inserts the proposal they prove finalized.
+// Unlike AddMessage, the messages are not rejected for being below the current height,
+// and they are not stored; they are verified right away, and must contain
+// the PREPREPARE message of the finalized round, along with a quorum
+// of COMMIT messages for its proposal, or a FINALITY message proving
+// the finalization. The messages of other heights,
+// and of senders that are not validators, are ignored.
+// The height must not be run by a sequence at the same time,
+// as the proposal could be inserted twice
+func (i *IBFT) ImportForHeight(height uint64, msgs []*proto.Message) error {
+	var (
+		proposals = make(map[uint64][]*proto.Message)
+		commits   = make(map[uint64][]*proto.Message)
+		senders   = make(map[uint64]map[string]struct{})
+		proofs    = make([]*proto.Message, 0)
+	)
+
+	for _, message := range msgs {
+		if message == nil || message.View == nil || message.View.Height != height {
+			continue
+		}
+
+		// Finality proofs are authenticated by their committed seals
+		if message.Type == proto.MessageType_FINALITY {
+			proofs = append(proofs, message)
+
+			continue
+		}
+
+		if i.ignored.isIgnored(message.From, i.clock()) || !i.isValidSender(message, untrustedSender) {
+			continue
+		}
+
+		round := message.View.Round
+
+		switch message.Type {
+		case proto.MessageType_PREPREPARE:
+			proposals[round] = append(proposals[round], message)
+		case proto.MessageType_COMMIT:
+			// Only the first COMMIT message of each sender is counted
+			if senders[round] == nil {
+				senders[round] = make(map[string]struct{})
+			}
+
+			if _, ok := senders[round][string(message.From)]; ok {
+				continue
+			}
+
+			senders[round][string(message.From)] = struct{}{}
+			commits[round] = append(commits[round], message)
+		}
+	}
+
+	rounds := make([]uint64, 0, len(commits))
+	for round := range commits {
+		rounds = append(rounds, round)
+	}
+
+	sort.Slice(rounds, func(a, b int) bool {
+		return rounds[a] < rounds[b]
+	})
+
+	for _, round := range rounds {
+		view := &proto.View{Height: height, Round: round}
+
+		for _, proposalMessage := range proposals[round] {
+			if i.importFinalized(view, proposalMessage, commits[round]) {
+				i.recordFinalizedHeight(height)
+
+				return nil
+			}
+		}
+	}
+
+	for _, proof := range proofs {
+		if i.importFinalityProof(proof) {
+			i.recordFinalizedHeight(height)
+
+			return nil
+		}
+	}
+
+	return ErrNoFinalityProof
+}
+
+// importFinalized inserts the proposal of the PREPREPARE message,
+// if the COMMIT messages of the view contain a quorum for it
+func (i *IBFT) importFinalized(
+	view *proto.View,
+	proposalMessage *proto.Message,
+	commitMessages []*proto.Message,
+) bool {
+	preprepareData, err := messages.ExtractPayload[*proto.PrePrepareMessage](proposalMessage)
+	if err != nil || preprepareData.Proposal == nil || messages.IsNilProposal(proposalMessage) {
+		return false
+	}
+
+	var (
+		proposal     = preprepareData.Proposal
+		proposalHash = preprepareData.ProposalHash
+	)
+
+	commitSeals, ok := i.verifyFinalized(view, proposal, proposalHash, commitMessages)
+	if !ok || !i.insertFinalized(view, proposal, proposalHash, commitSeals) {
+		return false
+	}
+
+	i.log.Info("imported finalized proposal", "height", view.Height, "round", view.Round)
+
+	i.emitEvent(EventFinalized, view, ProposalData{
+		Proposer:     proposalMessage.From,
+		ProposalHash: proposalHash,
+	})
+
+	i.markCommitted(view.Height, proposalHash)
+
+	return true
+}
+
+// importFinalityProof inserts the proposal of the FINALITY message,
+// if its committed seals prove the finalization
+func (i *IBFT) importFinalityProof(message *proto.Message) bool {
+	commitSeals, ok := i.verifyFinalityProof(message)
+	if !ok {
+		return false
+	}
+
+	finalityData, err := messages.ExtractPayload[*proto.FinalityMessage](message)
+	if err != nil {
+		return false
+	}
+
+	if !i.insertFinalized(message.View, finalityData.Proposal, finalityData.ProposalHash, commitSeals) {
+		return false
+	}
+
+	i.log.Info("imported finality proof", "height", message.View.Height, "round", message.View.Round)
+
+	i.emitEvent(EventFinalized, message.View, ProposalData{
+		ProposalHash: finalityData.ProposalHash,
+	})
+
+	i.markCommitted(message.View.Height, finalityData.ProposalHash)
+
+	return true
+}
+
+// verifyFinalized returns the committed seals of the COMMIT messages
+// of the view that are valid for the proposal, if they reach quorum
+func (i *IBFT) verifyFinalized(
+	view *proto.View,
+	proposal *proto.Proposal,
+	proposalHash []byte,
+	commitMessages []*proto.Message,
+) ([]*messages.CommittedSeal, bool) {
+	if !i.backend.IsValidProposalHash(proposal, proposalHash) {
+		return nil, false
+	}
+
+	valid := make([]*proto.Message, 0, len(commitMessages))
+
+	for _, message := range commitMessages {
+		commitData, err := messages.ExtractPayload[*proto.CommitMessage](message)
+		if err != nil {
+			continue
+		}
+
+		committedSeal := &messages.CommittedSeal{
+			Signer:     message.From,
+			Signature:  commitData.CommittedSeal,
+			Randomness: commitData.Randomness,
+		}
+
+		if !i.backend.IsValidProposalHash(proposal, commitData.ProposalHash) ||
+			!i.isValidCommitSeal(view.Height, commitData.ProposalHash, committedSeal) ||
+			!i.isValidRandomness(view, commitData.ProposalHash, committedSeal) {
+			continue
+		}
+
+		valid = append(valid, message)
+	}
+
+	if !i.hasQuorum(view.Height, valid, proto.MessageType_COMMIT) {
+		return nil, false
+	}
+
+	commitSeals, err := messages.ExtractCommittedSeals(valid)
+	if err != nil {
+		// safe check
+		return nil, false
+	}
+
+	return commitSeals, true
+}
+
+// insertFinalized inserts the proposal finalized in the view,
+// along with its committed seals
+func (i *IBFT) insertFinalized(
+	view *proto.View,
+	proposal *proto.Proposal,
+	proposalHash []byte,
+	commitSeals []*messages.CommittedSeal,
+) bool {
+	committed := &proto.Proposal{
+		RawProposal: proposal.RawProposal,
+		Round:       view.Round,
+	}
+
+	if signer, ok := i.backend.(ThresholdSigner); ok {
+		thresholdSeal, ok := i.combineThresholdSeal(signer, view, proposalHash, commitSeals)
+		if !ok {
+			return false
+		}
+
+		signer.InsertThresholdProposal(committed, thresholdSeal, commitSeals)
+	} else {
+		i.backend.InsertProposal(committed, commitSeals)
+	}
+
+	return true
+}
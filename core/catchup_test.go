@@ -0,0 +1,149 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// TestIBFT_ImportForHeight makes sure a past height is finalized from the
+// imported messages only if they contain a quorum of valid COMMIT messages
+// for a proposal of the same round, or a valid finality proof
+func TestIBFT_ImportForHeight(t *testing.T) {
+	t.Parallel()
+
+	var (
+		height       = uint64(5)
+		view         = &proto.View{Height: height, Round: 1}
+		nodes        = generateNodeAddresses(4)
+		proposalHash = []byte("proposal hash")
+		rawProposal  = []byte("proposal")
+		outsider     = []byte("outsider")
+	)
+
+	proposal := buildBasicPreprepareMessage(rawProposal, proposalHash, nil, nodes[0], view)
+
+	commits := func(view *proto.View, hash []byte, senders ...[]byte) []*proto.Message {
+		msgs := make([]*proto.Message, 0, len(senders))
+		for _, sender := range senders {
+			msgs = append(msgs, buildBasicCommitMessage(hash, []byte("seal"), sender, view))
+		}
+
+		return msgs
+	}
+
+	finality := func(signers ...[]byte) *proto.Message {
+		seals := make([]*messages.CommittedSeal, 0, len(signers))
+		for _, signer := range signers {
+			seals = append(seals, &messages.CommittedSeal{Signer: signer, Signature: []byte("seal")})
+		}
+
+		return messages.NewFinalityMessage(view, &proto.Proposal{RawProposal: rawProposal}, proposalHash, seals)
+	}
+
+	testTable := []struct {
+		name     string
+		messages []*proto.Message
+		inserted bool
+	}{
+		{
+			"quorum of COMMIT messages for the proposal",
+			append([]*proto.Message{proposal}, commits(view, proposalHash, nodes[0], nodes[1], nodes[2])...),
+			true,
+		},
+		{
+			"missing proposal",
+			commits(view, proposalHash, nodes[0], nodes[1], nodes[2]),
+			false,
+		},
+		{
+			"COMMIT messages of another round",
+			append(
+				[]*proto.Message{proposal},
+				commits(&proto.View{Height: height, Round: 2}, proposalHash, nodes[0], nodes[1], nodes[2])...,
+			),
+			false,
+		},
+		{
+			"COMMIT messages for another proposal",
+			append([]*proto.Message{proposal}, commits(view, []byte("other hash"), nodes[0], nodes[1], nodes[2])...),
+			false,
+		},
+		{
+			"duplicate COMMIT messages",
+			append([]*proto.Message{proposal}, commits(view, proposalHash, nodes[0], nodes[1], nodes[1])...),
+			false,
+		},
+		{
+			"COMMIT messages of a non-validator",
+			append([]*proto.Message{proposal}, commits(view, proposalHash, nodes[0], nodes[1], outsider)...),
+			false,
+		},
+		{
+			"finality proof",
+			[]*proto.Message{finality(nodes[0], nodes[1], nodes[2])},
+			true,
+		},
+		{
+			"finality proof without a quorum",
+			[]*proto.Message{finality(nodes[0], nodes[1])},
+			false,
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				insertedProposal *proto.Proposal
+				insertedSeals    []*messages.CommittedSeal
+			)
+
+			backend := mockBackend{
+				IsValidValidatorFn: func(message *proto.Message) bool {
+					return !bytes.Equal(message.From, outsider)
+				},
+				isValidProposalHashFn: func(_ *proto.Proposal, hash []byte) bool {
+					return bytes.Equal(hash, proposalHash)
+				},
+				hasQuorumFn: commonHasQuorumFn(uint64(len(nodes))),
+				insertProposalFn: func(proposal *proto.Proposal, seals []*messages.CommittedSeal) {
+					insertedProposal = proposal
+					insertedSeals = seals
+				},
+			}
+
+			i := NewIBFT(mockLogger{}, backend, mockTransport{})
+
+			// The node is past the imported height
+			i.state.setView(&proto.View{Height: height + 5})
+
+			err := i.ImportForHeight(height, testCase.messages)
+
+			finalized, hasFinalized := i.HighestFinalizedHeight()
+
+			if !testCase.inserted {
+				assert.ErrorIs(t, err, ErrNoFinalityProof)
+				assert.Nil(t, insertedProposal)
+				assert.False(t, hasFinalized)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, &proto.Proposal{RawProposal: rawProposal, Round: view.Round}, insertedProposal)
+			assert.Len(t, insertedSeals, 3)
+
+			// Make sure the imported height is recorded as finalized
+			assert.True(t, hasFinalized)
+			assert.Equal(t, height, finalized)
+		})
+	}
+}
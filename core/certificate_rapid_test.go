@@ -0,0 +1,366 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"pgregory.net/rapid"
+
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// certificateTestSetup is the randomly-generated validator set
+// the certificates are validated against
+type certificateTestSetup struct {
+	// validators are the addresses of the validator set
+	validators [][]byte
+
+	// height is the height the certificates are created for
+	height uint64
+}
+
+// generateCertificateTestSetup generates the validator set and height
+func generateCertificateTestSetup(t *rapid.T) *certificateTestSetup {
+	var (
+		numNodes = rapid.Uint64Range(4, 20).Draw(t, "number of validators")
+		height   = rapid.Uint64Range(0, 100).Draw(t, "height")
+	)
+
+	return &certificateTestSetup{
+		validators: generateNodeAddresses(numNodes),
+		height:     height,
+	}
+}
+
+// numValidators returns the size of the validator set
+func (s *certificateTestSetup) numValidators() uint64 {
+	return uint64(len(s.validators))
+}
+
+// proposer returns the proposer for the specified round
+func (s *certificateTestSetup) proposer(height, round uint64) []byte {
+	return s.validators[getProposer(height, round, s.numValidators())]
+}
+
+// isProposer checks if the sender is the proposer for the view
+func (s *certificateTestSetup) isProposer(from []byte, height, round uint64) bool {
+	return bytes.Equal(from, s.proposer(height, round))
+}
+
+// isValidator checks if the sender is part of the validator set
+func (s *certificateTestSetup) isValidator(from []byte) bool {
+	for _, validator := range s.validators {
+		if bytes.Equal(from, validator) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// nonProposers returns the validators that are not the proposer for the round
+func (s *certificateTestSetup) nonProposers(round uint64) [][]byte {
+	nonProposers := make([][]byte, 0, len(s.validators)-1)
+
+	for _, validator := range s.validators {
+		if !s.isProposer(validator, s.height, round) {
+			nonProposers = append(nonProposers, validator)
+		}
+	}
+
+	return nonProposers
+}
+
+// newIBFT creates an IBFT instance backed by the validator set
+func (s *certificateTestSetup) newIBFT(id []byte) *IBFT {
+	i := NewIBFT(
+		mockLogger{},
+		mockBackend{
+			idFn: func() []byte {
+				return id
+			},
+			isProposerFn: s.isProposer,
+			IsValidValidatorFn: func(message *proto.Message) bool {
+				return s.isValidator(message.From)
+			},
+			hasQuorumFn: commonHasQuorumFn(s.numValidators()),
+		},
+		mockTransport{},
+	)
+
+	i.state.setView(&proto.View{Height: s.height})
+
+	return i
+}
+
+// generatePC generates a prepared certificate for the specified round.
+// The certificate is honestly constructed, unless one or more
+// of the randomly drawn faults are applied to it
+func generatePC(t *rapid.T, s *certificateTestSetup, round uint64, label string) *proto.PreparedCertificate {
+	var (
+		nonProposers = rapid.Permutation(s.nonProposers(round)).Draw(t, label+" prepare senders")
+		minPrepares  = 0
+		view         = &proto.View{Height: s.height, Round: round}
+	)
+
+	// Most of the certificates should carry enough prepares to reach quorum
+	if rapid.Bool().Draw(t, label+" has quorum prepares") {
+		minPrepares = int(QuorumSize(s.numValidators())) - 2
+	}
+
+	numPrepares := rapid.IntRange(minPrepares, len(nonProposers)).Draw(t, label+" number of prepares")
+
+	certificate := &proto.PreparedCertificate{
+		ProposalMessage: buildBasicPreprepareMessage(
+			validEthereumBlock,
+			validProposalHash,
+			nil,
+			s.proposer(s.height, round),
+			view,
+		),
+		PrepareMessages: make([]*proto.Message, 0, numPrepares),
+	}
+
+	for _, sender := range nonProposers[:numPrepares] {
+		certificate.PrepareMessages = append(
+			certificate.PrepareMessages,
+			buildBasicPrepareMessage(validProposalHash, sender, view),
+		)
+	}
+
+	// Randomly apply faults to the certificate
+	if rapid.Bool().Draw(t, label+" proposal from non-proposer") {
+		certificate.ProposalMessage.From = nonProposers[0]
+	}
+
+	if numPrepares == 0 {
+		return certificate
+	}
+
+	// Faults are applied to a randomly selected prepare message
+	target := certificate.PrepareMessages[rapid.IntRange(0, numPrepares-1).Draw(t, label+" faulty prepare")]
+
+	switch rapid.IntRange(0, 7).Draw(t, label+" prepare fault") {
+	case 1:
+		// Duplicate sender
+		target.From = certificate.PrepareMessages[0].From
+		if numPrepares == 1 {
+			target.From = certificate.ProposalMessage.From
+		}
+	case 2:
+		// Sender outside of the validator set
+		target.From = []byte("not a validator")
+	case 3:
+		// Sender is the proposer for the round
+		target.From = s.proposer(s.height, round)
+	case 4:
+		// Mismatching proposal hash
+		target.Payload = &proto.Message_PrepareData{
+			PrepareData: &proto.PrepareMessage{ProposalHash: []byte("different hash")},
+		}
+	case 5:
+		// Message of wrong type
+		target.Type = proto.MessageType_COMMIT
+	case 6:
+		// Message for a different round
+		target.View = &proto.View{Height: s.height, Round: round + 1}
+	case 7:
+		// Message for a different height
+		target.View = &proto.View{Height: s.height + 1, Round: round}
+	}
+
+	return certificate
+}
+
+// assertPCInvariants makes sure a certificate accepted by validPC holds
+// a quorum of unique validators with matching hashes and views
+func assertPCInvariants(
+	t *rapid.T,
+	s *certificateTestSetup,
+	certificate *proto.PreparedCertificate,
+	rLimit uint64,
+) {
+	allMessages := append(
+		[]*proto.Message{certificate.ProposalMessage},
+		certificate.PrepareMessages...,
+	)
+
+	// Quorum of PP + P messages
+	assert.GreaterOrEqual(t, len(allMessages), int(QuorumSize(s.numValidators()))-1)
+
+	// Unique senders, all of which are validators
+	assert.True(t, messages.HasUniqueSenders(allMessages))
+
+	for _, message := range allMessages {
+		assert.True(t, s.isValidator(message.From))
+	}
+
+	// Matching proposal hashes
+	assert.True(t, messages.HaveSameProposalHash(allMessages))
+
+	// Matching views, lower than the round limit
+	round := certificate.ProposalMessage.View.Round
+
+	for _, message := range allMessages {
+		assert.Equal(t, s.height, message.View.Height)
+		assert.Equal(t, round, message.View.Round)
+	}
+
+	assert.Less(t, round, rLimit)
+
+	// The proposal was sent by the proposer, and the prepares were not
+	assert.Equal(t, proto.MessageType_PREPREPARE, certificate.ProposalMessage.Type)
+	assert.True(t, s.isProposer(certificate.ProposalMessage.From, s.height, round))
+
+	for _, message := range certificate.PrepareMessages {
+		assert.Equal(t, proto.MessageType_PREPARE, message.Type)
+		assert.False(t, s.isProposer(message.From, s.height, round))
+	}
+}
+
+// isHonestPC checks if the certificate was constructed without faults,
+// and carries enough prepare messages
+func isHonestPC(s *certificateTestSetup, certificate *proto.PreparedCertificate) bool {
+	var (
+		round       = certificate.ProposalMessage.View.Round
+		allMessages = append([]*proto.Message{certificate.ProposalMessage}, certificate.PrepareMessages...)
+	)
+
+	if len(allMessages) < int(QuorumSize(s.numValidators()))-1 {
+		return false
+	}
+
+	if !s.isProposer(certificate.ProposalMessage.From, s.height, round) {
+		return false
+	}
+
+	for _, message := range certificate.PrepareMessages {
+		if message.Type != proto.MessageType_PREPARE ||
+			message.View.Height != s.height ||
+			message.View.Round != round ||
+			!s.isValidator(message.From) ||
+			s.isProposer(message.From, s.height, round) ||
+			!bytes.Equal(messages.ExtractPrepareHash(message), validProposalHash) {
+			return false
+		}
+	}
+
+	return messages.HasUniqueSenders(allMessages)
+}
+
+// TestProperty_ValidPC makes sure validPC only accepts
+// certificates with a quorum of unique validators with matching hashes,
+// and that it accepts every honestly constructed certificate
+func TestProperty_ValidPC(t *testing.T) {
+	t.Parallel()
+
+	rapid.Check(t, func(t *rapid.T) {
+		var (
+			setup       = generateCertificateTestSetup(t)
+			round       = rapid.Uint64Range(0, 10).Draw(t, "certificate round")
+			rLimit      = rapid.Uint64Range(0, 12).Draw(t, "round limit")
+			certificate = generatePC(t, setup, round, "certificate")
+			i           = setup.newIBFT(setup.validators[0])
+		)
+
+		isValid := i.validPC(certificate, rLimit, setup.height)
+
+		if isValid {
+			assertPCInvariants(t, setup, certificate, rLimit)
+		}
+
+		if round < rLimit && isHonestPC(setup, certificate) {
+			assert.True(t, isValid)
+		}
+	})
+}
+
+// TestProperty_ValidateProposal makes sure validateProposal only accepts
+// proposals justified by a quorum RCC, re-proposing the block prepared
+// in the highest round, if any
+func TestProperty_ValidateProposal(t *testing.T) {
+	t.Parallel()
+
+	rapid.Check(t, func(t *rapid.T) {
+		var (
+			setup    = generateCertificateTestSetup(t)
+			round    = rapid.Uint64Range(1, 10).Draw(t, "proposal round")
+			view     = &proto.View{Height: setup.height, Round: round}
+			senders  = rapid.Permutation(setup.validators).Draw(t, "round change senders")
+			numRCs   = rapid.IntRange(1, len(senders)).Draw(t, "number of round changes")
+			rcc      = &proto.RoundChangeCertificate{}
+			hashes   = [][]byte{validProposalHash, []byte("other proposal hash")}
+			proposer = setup.proposer(setup.height, round)
+		)
+
+		for index, sender := range senders[:numRCs] {
+			var (
+				label       = fmt.Sprintf("round change %d", index)
+				certificate *proto.PreparedCertificate
+				proposal    *proto.Proposal
+			)
+
+			if rapid.Bool().Draw(t, label+" has certificate") {
+				pcRound := rapid.Uint64Range(0, round-1).Draw(t, label+" certificate round")
+
+				certificate = generatePC(t, setup, pcRound, label+" certificate")
+				proposal = &proto.Proposal{RawProposal: validEthereumBlock, Round: pcRound}
+			}
+
+			rcView := view
+			if rapid.Bool().Draw(t, label+" has wrong round") {
+				rcView = &proto.View{Height: setup.height, Round: round + 1}
+			}
+
+			rcc.RoundChangeMessages = append(
+				rcc.RoundChangeMessages,
+				buildBasicRoundChangeMessage(proposal, certificate, rcView, sender),
+			)
+		}
+
+		var (
+			proposalHash = rapid.SampledFrom(hashes).Draw(t, "proposal hash")
+			msg          = buildBasicPreprepareMessage(validEthereumBlock, proposalHash, rcc, proposer, view)
+			i            = setup.newIBFT(setup.nonProposers(round)[0])
+		)
+
+		if !i.validateProposal(msg, view) {
+			return
+		}
+
+		// The RCC must contain a quorum of unique validators for the proposal view
+		assert.GreaterOrEqual(t, len(rcc.RoundChangeMessages), int(QuorumSize(setup.numValidators())))
+		assert.True(t, messages.HasUniqueSenders(rcc.RoundChangeMessages))
+
+		var (
+			maxRound     uint64
+			expectedHash []byte
+		)
+
+		for _, rc := range rcc.RoundChangeMessages {
+			assert.True(t, setup.isValidator(rc.From))
+			assert.Equal(t, proto.MessageType_ROUND_CHANGE, rc.Type)
+			assert.Equal(t, setup.height, rc.View.Height)
+			assert.Equal(t, round, rc.View.Round)
+
+			// Find the highest valid prepared certificate
+			certificate := messages.ExtractLatestPC(rc)
+			if certificate == nil || !i.validPC(certificate, round, setup.height) {
+				continue
+			}
+
+			if pcRound := certificate.ProposalMessage.View.Round; pcRound >= maxRound {
+				maxRound = pcRound
+				expectedHash = messages.ExtractProposalHash(certificate.ProposalMessage)
+			}
+		}
+
+		// The proposal must match the highest prepared proposal
+		if expectedHash != nil {
+			assert.Equal(t, expectedHash, proposalHash)
+		}
+	})
+}
@@ -0,0 +1,279 @@
+package core
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// faultRule is a single scriptable fault applied by the chaos transport.
+// A rule matches a message if the message type, and (optionally) the
+// height and round match
+type faultRule struct {
+	// msgType is the message type the rule applies to
+	msgType proto.MessageType
+
+	// height is the height the rule applies to, if hasHeight is set
+	height    uint64
+	hasHeight bool
+
+	// round is the round the rule applies to, if hasRound is set
+	round    uint64
+	hasRound bool
+
+	// dropPercent is the chance [0, 100] of the message being dropped
+	dropPercent int
+
+	// delay is the amount of time the message delivery is postponed for
+	delay time.Duration
+}
+
+// matches checks if the fault rule applies to the message
+func (r faultRule) matches(message *proto.Message) bool {
+	if message.Type != r.msgType {
+		return false
+	}
+
+	if r.hasHeight && message.View.Height != r.height {
+		return false
+	}
+
+	return !r.hasRound || message.View.Round == r.round
+}
+
+// faultSchedule is a reproducible set of fault rules
+// that is applied on message multicast
+type faultSchedule struct {
+	rules []faultRule
+
+	// seed is the seed the random sources of the nodes are derived from
+	seed int64
+
+	// pending keeps track of delayed deliveries
+	pending sync.WaitGroup
+
+	numDropped int64
+	numDelayed int64
+}
+
+// newFaultSchedule creates a new fault schedule with a fixed seed,
+// so every run of the schedule makes the same drop decisions
+// for the same sequence of multicasts of each node
+func newFaultSchedule(seed int64, rules ...faultRule) *faultSchedule {
+	return &faultSchedule{
+		rules: rules,
+		seed:  seed,
+	}
+}
+
+// multicastFn wraps the gossip of the cluster node with the fault schedule.
+// Each node rolls the dice with its own random source derived from the seed,
+// so its drop decisions do not depend on the multicasts of the other nodes
+func (s *faultSchedule) multicastFn(c *cluster, nodeIndex int) multicastFnDelegate {
+	var (
+		// rngLock guards the rng, as the multicasts of a node can be concurrent
+		rngLock sync.Mutex
+		rng     = rand.New(rand.NewSource(s.seed + int64(nodeIndex))) //nolint:gosec
+	)
+
+	// shouldDrop rolls the dice for the given drop percentage
+	shouldDrop := func(percent int) bool {
+		if percent <= 0 {
+			return false
+		}
+
+		rngLock.Lock()
+		defer rngLock.Unlock()
+
+		return rng.Intn(100) < percent
+	}
+
+	return func(message *proto.Message) {
+		var delay time.Duration
+
+		for _, rule := range s.rules {
+			if !rule.matches(message) {
+				continue
+			}
+
+			if shouldDrop(rule.dropPercent) {
+				atomic.AddInt64(&s.numDropped, 1)
+
+				return
+			}
+
+			delay += rule.delay
+		}
+
+		if delay == 0 {
+			c.gossip(message)
+
+			return
+		}
+
+		atomic.AddInt64(&s.numDelayed, 1)
+		s.pending.Add(1)
+
+		time.AfterFunc(delay, func() {
+			defer s.pending.Done()
+
+			c.gossip(message)
+		})
+	}
+}
+
+// wait waits for all delayed deliveries to finish
+func (s *faultSchedule) wait() {
+	s.pending.Wait()
+}
+
+// dropped returns the number of dropped messages
+func (s *faultSchedule) dropped() int64 {
+	return atomic.LoadInt64(&s.numDropped)
+}
+
+// delayed returns the number of delayed messages
+func (s *faultSchedule) delayed() int64 {
+	return atomic.LoadInt64(&s.numDelayed)
+}
+
+// chaosInsertDelegate is the proposal insertion callback of the chaos cluster nodes,
+// along with the height the node inserts the proposal at
+type chaosInsertDelegate func(height uint64, proposal *proto.Proposal, seals []*messages.CommittedSeal)
+
+// newChaosCluster creates a cluster of honest nodes
+// whose transport is driven by the fault schedule
+func newChaosCluster(
+	numNodes uint64,
+	schedule *faultSchedule,
+	insertFn chaosInsertDelegate,
+) *cluster {
+	return newCluster(
+		numNodes,
+		func(c *cluster) {
+			for index, node := range c.nodes {
+				node := node
+
+				var insertProposalFn insertProposalDelegate
+				if insertFn != nil {
+					insertProposalFn = func(proposal *proto.Proposal, seals []*messages.CommittedSeal) {
+						insertFn(node.core.state.getHeight(), proposal, seals)
+					}
+				}
+
+				node.core = NewIBFT(
+					mockLogger{},
+					&mockBackend{
+						isValidProposalFn:     isValidProposal,
+						isValidProposalHashFn: isValidProposalHash,
+						isProposerFn:          c.isProposer,
+
+						idFn: node.addr,
+
+						buildProposalFn:           buildValidEthereumBlock,
+						buildPrePrepareMessageFn:  node.buildPrePrepare,
+						buildPrepareMessageFn:     node.buildPrepare,
+						buildCommitMessageFn:      node.buildCommit,
+						buildRoundChangeMessageFn: node.buildRoundChange,
+
+						insertProposalFn: insertProposalFn,
+						hasQuorumFn:      c.hasQuorumFn,
+					},
+					&mockTransport{multicastFn: schedule.multicastFn(c, index)},
+				)
+
+				node.core.baseRoundTimeout = testRoundTimeout
+			}
+		},
+	)
+}
+
+// TestChaos_DropCommitsInRoundZero makes sure the cluster
+// recovers when a large portion of COMMIT messages is lost
+// in the first round of every height
+func TestChaos_DropCommitsInRoundZero(t *testing.T) {
+	t.Parallel()
+
+	schedule := newFaultSchedule(
+		1,
+		faultRule{
+			msgType:     proto.MessageType_COMMIT,
+			round:       0,
+			hasRound:    true,
+			dropPercent: 50,
+		},
+	)
+
+	cluster := newChaosCluster(6, schedule, nil)
+
+	defer schedule.wait()
+
+	assert.NoError(t, cluster.progressToHeight(40*time.Second, 5))
+	assert.Equal(t, uint64(5), cluster.latestHeight)
+
+	// Make sure the schedule actually interfered
+	assert.Positive(t, schedule.dropped())
+}
+
+// TestChaos_DelayedProposal makes sure the cluster
+// moves past a proposal that arrives well after the round timeout
+// at a specific height, and finalizes the height in a later round
+func TestChaos_DelayedProposal(t *testing.T) {
+	t.Parallel()
+
+	const faultyHeight = uint64(2)
+
+	var (
+		numNodes = uint64(4)
+
+		insertedLock   sync.Mutex
+		insertedRounds = make(map[uint64]uint64)
+	)
+
+	schedule := newFaultSchedule(
+		1,
+		faultRule{
+			msgType:   proto.MessageType_PREPREPARE,
+			height:    faultyHeight,
+			hasHeight: true,
+			round:     0,
+			hasRound:  true,
+			delay:     2 * testRoundTimeout,
+		},
+	)
+
+	cluster := newChaosCluster(
+		numNodes,
+		schedule,
+		func(height uint64, proposal *proto.Proposal, _ []*messages.CommittedSeal) {
+			insertedLock.Lock()
+			defer insertedLock.Unlock()
+
+			insertedRounds[height] = proposal.Round
+		},
+	)
+
+	defer schedule.wait()
+
+	assert.NoError(t, cluster.progressToHeight(20*time.Second, 3))
+	assert.Equal(t, uint64(3), cluster.latestHeight)
+
+	// Make sure the schedule actually interfered
+	assert.Equal(t, int64(1), schedule.delayed())
+
+	insertedLock.Lock()
+	defer insertedLock.Unlock()
+
+	// The height before the fault is finalized in the first round
+	assert.Equal(t, uint64(0), insertedRounds[1])
+
+	// The affected height is finalized only after a round change
+	assert.Positive(t, insertedRounds[faultyHeight])
+}
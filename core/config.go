@@ -0,0 +1,360 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/renloi/ibft/messages"
+)
+
+var (
+	// ErrInvalidConfig is returned when the IBFT configuration is not usable
+	ErrInvalidConfig = errors.New("invalid IBFT configuration")
+)
+
+// Config is the effective configuration of the IBFT instance,
+// with the options applied. It is the snapshot returned by IBFT.Config
+type Config struct {
+	// Logger, Backend and Transport are the dependencies of the instance
+	Logger    Logger
+	Backend   Backend
+	Transport Transport
+
+	// BaseRoundTimeout is the round 0 timeout the timeout strategy grows from
+	BaseRoundTimeout time.Duration
+
+	// MaxRoundTimeout is the ceiling for the round timeout, if set
+	MaxRoundTimeout time.Duration
+
+	// MaxRound is the round the round timeout stops growing at, if set
+	MaxRound uint64
+
+	// TimeoutStrategy determines the round timeouts
+	TimeoutStrategy TimeoutStrategy
+
+	// RoundTimer is the timer used for expiring rounds
+	RoundTimer RoundTimer
+
+	// UnavailableProposerTimeout is the shortened round timeout
+	// after the round proposer announces it is unavailable
+	UnavailableProposerTimeout time.Duration
+
+	// WorkerStopTimeout is the time the round workers are given to stop
+	WorkerStopTimeout time.Duration
+
+	// SequenceDeadline is the maximum duration of a sequence, if set
+	SequenceDeadline time.Duration
+
+	// RoundChangeRebroadcastInterval is the minimum time between
+	// two ROUND_CHANGE multicasts for the same view
+	RoundChangeRebroadcastInterval time.Duration
+
+	// RoundChangeBackoffLimit is the maximum time between
+	// two ROUND_CHANGE multicasts for the same view
+	RoundChangeBackoffLimit time.Duration
+
+	// HashMismatchReportInterval is the minimum time between
+	// two proposal hash mismatch reports for the same sender
+	HashMismatchReportInterval time.Duration
+
+	// RetryPolicy is the policy for retrying failed multicasts
+	RetryPolicy RetryPolicy
+
+	// QuorumVerifier is the verifier used for determining quorum
+	QuorumVerifier QuorumVerifier
+
+	// Codec is the encoding of the messages
+	Codec messages.Codec
+
+	// NilProposals is the flag indicating if explicit NIL proposals are enabled
+	NilProposals bool
+
+	// Vetoes is the flag indicating if invalid proposals are explicitly rejected
+	Vetoes bool
+
+	// ClockSkewTolerance is the allowed skew of the message timestamps
+	// ahead of the local clock, if the timestamp check is enabled
+	ClockSkewTolerance time.Duration
+
+	// ViewGossipInterval is the time between two periodic CURRENT_VIEW
+	// multicasts, if the backend implements ViewAnnouncer
+	ViewGossipInterval time.Duration
+
+	// CommitRebroadcastFraction is the fraction of the round timeout after which
+	// the COMMIT message is rebroadcasted, if the commit quorum is not observed
+	CommitRebroadcastFraction float64
+
+	// CommitRebroadcastRetries is the maximum number of COMMIT rebroadcasts in a round
+	CommitRebroadcastRetries uint
+
+	// TrustVerifiedMessages is the flag indicating if the message verification
+	// of the network layer is trusted (see AddVerifiedMessage)
+	TrustVerifiedMessages bool
+
+	// RelayMode is the flag indicating if the node is not a validator,
+	// and only handles the messages of the validators
+	RelayMode bool
+
+	// ReproposalBoost is the flag indicating if the holders of the highest PC
+	// propose it again after a failed round
+	ReproposalBoost bool
+
+	// Sentries are the transport peers the node talks to exclusively,
+	// if the node is behind sentries
+	Sentries [][]byte
+
+	// HeightRegressionCheck is the flag indicating if the sequences
+	// of the already finalized heights are refused
+	HeightRegressionCheck bool
+
+	// RoundPruning is the flag indicating if the round-scoped messages
+	// of the lower rounds are pruned after round changes
+	RoundPruning bool
+
+	// Height is the current height of the instance. The validator set
+	// of the quorum verifier is checked at this height
+	Height uint64
+}
+
+// Config returns the effective configuration of the instance
+func (i *IBFT) Config() Config {
+	// The round timeouts may be tuned at runtime
+	i.timeoutsLock.RLock()
+	baseRoundTimeout, maxRound, timeoutStrategy := i.baseRoundTimeout, i.maxRound, i.timeoutStrategy
+	i.timeoutsLock.RUnlock()
+
+	return Config{
+		Logger:                         i.log,
+		Backend:                        i.backend,
+		Transport:                      i.transport,
+		BaseRoundTimeout:               baseRoundTimeout,
+		MaxRoundTimeout:                i.maxRoundTimeout,
+		MaxRound:                       maxRound,
+		TimeoutStrategy:                timeoutStrategy,
+		RoundTimer:                     i.roundTimer,
+		UnavailableProposerTimeout:     i.unavailableProposerTimeout,
+		WorkerStopTimeout:              i.workerStopTimeout,
+		SequenceDeadline:               i.sequenceDeadline,
+		RoundChangeRebroadcastInterval: i.roundChangeThrottle.interval,
+		RoundChangeBackoffLimit:        i.roundChangeThrottle.backoffLimit,
+		HashMismatchReportInterval:     i.hashMismatches.interval,
+		RetryPolicy:                    i.retryPolicy,
+		QuorumVerifier:                 i.quorum,
+		Codec:                          i.codec,
+		NilProposals:                   i.nilProposals,
+		Vetoes:                         i.vetoes,
+		ClockSkewTolerance:             i.clockSkewTolerance,
+		ViewGossipInterval:             i.viewGossipInterval,
+		CommitRebroadcastFraction:      i.commitRebroadcastFraction,
+		CommitRebroadcastRetries:       i.commitRebroadcastRetries,
+		TrustVerifiedMessages:          i.trustVerifiedMessages,
+		RelayMode:                      i.relayMode,
+		ReproposalBoost:                i.reproposalBoost,
+		Sentries:                       i.sentries,
+		HeightRegressionCheck:          i.heightRegressionCheck,
+		RoundPruning:                   i.roundPruning,
+		Height:                         i.state.getHeight(),
+	}
+}
+
+// Validate sanity-checks the configuration, so a misconfigured instance
+// is reported at startup, instead of stalling at runtime. All the problems
+// found are reported in the returned error, which wraps ErrInvalidConfig
+func (c Config) Validate() error {
+	var problems []string
+
+	report := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	// Dependencies
+	if c.Logger == nil {
+		report("logger is not set")
+	}
+
+	if c.Backend == nil {
+		report("backend is not set")
+	}
+
+	if c.Transport == nil {
+		report("transport is not set")
+	}
+
+	if c.RoundTimer == nil {
+		report("round timer is not set")
+	}
+
+	if c.Codec == nil {
+		report("codec is not set")
+	}
+
+	// Timeouts
+	if c.TimeoutStrategy == nil {
+		report("timeout strategy is not set")
+	} else {
+		problems = append(problems, timeoutStrategyProblems(c.TimeoutStrategy)...)
+
+		roundTimeout := getRoundTimeout(
+			c.TimeoutStrategy,
+			c.BaseRoundTimeout,
+			c.MaxRoundTimeout,
+			c.MaxRound,
+			0,
+		)
+		if roundTimeout <= 0 {
+			report("round 0 timeout is %s, rounds would expire immediately", roundTimeout)
+		}
+	}
+
+	if c.BaseRoundTimeout <= 0 {
+		report("base round timeout is %s, it must be positive", c.BaseRoundTimeout)
+	}
+
+	if c.MaxRoundTimeout < 0 {
+		report("max round timeout is %s, it must not be negative", c.MaxRoundTimeout)
+	}
+
+	if c.UnavailableProposerTimeout <= 0 {
+		report("unavailable proposer timeout is %s, it must be positive", c.UnavailableProposerTimeout)
+	}
+
+	if c.WorkerStopTimeout <= 0 {
+		report("worker stop timeout is %s, round workers would be abandoned right away", c.WorkerStopTimeout)
+	}
+
+	if c.RoundChangeRebroadcastInterval < 0 {
+		report("round change rebroadcast interval is %s, it must not be negative", c.RoundChangeRebroadcastInterval)
+	}
+
+	if c.RoundChangeBackoffLimit < 0 {
+		report("round change backoff limit is %s, it must not be negative", c.RoundChangeBackoffLimit)
+	}
+
+	if c.SequenceDeadline < 0 {
+		report("sequence deadline is %s, it must not be negative", c.SequenceDeadline)
+	}
+
+	if c.HashMismatchReportInterval < 0 {
+		report("hash mismatch report interval is %s, it must not be negative", c.HashMismatchReportInterval)
+	}
+
+	if c.RetryPolicy.MaxRetries > 0 && c.RetryPolicy.InitialBackoff < 0 {
+		report("retry initial backoff is %s, it must not be negative", c.RetryPolicy.InitialBackoff)
+	}
+
+	if c.RetryPolicy.MaxBackoff > 0 && c.RetryPolicy.MaxBackoff < c.RetryPolicy.InitialBackoff {
+		report(
+			"retry max backoff %s is below the initial backoff %s",
+			c.RetryPolicy.MaxBackoff,
+			c.RetryPolicy.InitialBackoff,
+		)
+	}
+
+	if c.ClockSkewTolerance < 0 {
+		report("clock skew tolerance is %s, it must not be negative", c.ClockSkewTolerance)
+	}
+
+	if c.ViewGossipInterval < 0 {
+		report("view gossip interval is %s, it must not be negative", c.ViewGossipInterval)
+	}
+
+	if c.CommitRebroadcastFraction < 0 || c.CommitRebroadcastFraction >= 1 {
+		report(
+			"commit rebroadcast fraction is %g, it must be in [0, 1), so the rebroadcasts precede the round change",
+			c.CommitRebroadcastFraction,
+		)
+	}
+
+	// Quorum
+	if c.QuorumVerifier == nil {
+		report("quorum verifier is not set")
+	} else {
+		problems = append(problems, quorumProblems(c.QuorumVerifier, c.Height)...)
+	}
+
+	// Backend extensions required by the options
+	if c.NilProposals && c.Backend != nil {
+		if _, ok := c.Backend.(NilProposalBuilder); !ok {
+			report("NIL proposals are enabled, but the backend does not implement NilProposalBuilder")
+		}
+	}
+
+	if c.Vetoes && c.Backend != nil {
+		if _, ok := c.Backend.(VetoBuilder); !ok {
+			report("vetoes are enabled, but the backend does not implement VetoBuilder")
+		}
+	}
+
+	// Transport extensions required by the options
+	if len(c.Sentries) > 0 && c.Transport != nil {
+		if _, ok := c.Transport.(Unicaster); !ok {
+			report("sentries are set, but the transport does not implement Unicaster")
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrInvalidConfig, strings.Join(problems, "; "))
+}
+
+// timeoutStrategyProblems returns the problems of the built-in timeout strategies
+func timeoutStrategyProblems(strategy TimeoutStrategy) []string {
+	var problems []string
+
+	switch s := strategy.(type) {
+	case ScheduleTimeout:
+		for round, timeout := range s {
+			if timeout <= 0 {
+				problems = append(problems, fmt.Sprintf("round %d scheduled timeout is %s, it must be positive", round, timeout))
+			}
+		}
+	case AdaptiveTimeout:
+		if s.Multiplier <= 0 {
+			problems = append(problems, fmt.Sprintf("adaptive timeout multiplier is %v, it must be positive", s.Multiplier))
+		}
+
+		if s.MinTimeout <= 0 {
+			problems = append(problems, fmt.Sprintf("adaptive timeout minimum is %s, it must be positive", s.MinTimeout))
+		}
+
+		if s.Strategy != nil {
+			problems = append(problems, timeoutStrategyProblems(s.Strategy)...)
+		}
+	}
+
+	return problems
+}
+
+// quorumProblems returns the problems of the built-in quorum verifiers,
+// checking the validator set at the height
+func quorumProblems(verifier QuorumVerifier, height uint64) []string {
+	switch q := verifier.(type) {
+	case CountQuorum:
+		if q.ValidatorCount == nil {
+			return []string{"count quorum has no validator count"}
+		}
+
+		if count := q.ValidatorCount(height); count == 0 {
+			return []string{fmt.Sprintf("count quorum has no validators at height %d", height)}
+		}
+	case WeightQuorum:
+		if q.Weights == nil {
+			return []string{"weight quorum has no validator weights"}
+		}
+
+		var total uint64
+		for _, weight := range q.Weights(height) {
+			total += weight
+		}
+
+		if total == 0 {
+			return []string{fmt.Sprintf("weight quorum has no voting power at height %d", height)}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,253 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// nilProposalBackend is the backend supporting explicit NIL proposals
+type nilProposalBackend struct {
+	mockBackend
+}
+
+func (b nilProposalBackend) BuildNilPrePrepareMessage(
+	_ *proto.RoundChangeCertificate,
+	view *proto.View,
+) *proto.Message {
+	return &proto.Message{View: view, Type: proto.MessageType_PREPREPARE}
+}
+
+func TestIBFT_Config(t *testing.T) {
+	t.Parallel()
+
+	verifier := CountQuorum{
+		ValidatorCount: func(uint64) uint64 {
+			return 4
+		},
+	}
+
+	i := NewIBFT(
+		mockLogger{},
+		mockBackend{},
+		mockTransport{},
+		WithMaxRoundTimeout(time.Minute),
+		WithWorkerStopTimeout(time.Second),
+		WithSequenceDeadline(time.Hour),
+		WithRoundChangeRebroadcastInterval(3*time.Second),
+		WithHashMismatchReportInterval(time.Minute),
+		WithQuorumVerifier(verifier),
+	)
+
+	config := i.Config()
+
+	assert.Equal(t, round0Timeout, config.BaseRoundTimeout)
+	assert.Equal(t, time.Minute, config.MaxRoundTimeout)
+	assert.Equal(t, time.Second, config.WorkerStopTimeout)
+	assert.Equal(t, time.Hour, config.SequenceDeadline)
+	assert.Equal(t, 3*time.Second, config.RoundChangeRebroadcastInterval)
+	assert.Equal(t, defaultRoundChangeBackoffLimit, config.RoundChangeBackoffLimit)
+	assert.Equal(t, time.Minute, config.HashMismatchReportInterval)
+	assert.Equal(t, defaultUnavailableProposerTimeout, config.UnavailableProposerTimeout)
+	assert.IsType(t, CountQuorum{}, config.QuorumVerifier)
+	assert.False(t, config.NilProposals)
+	assert.True(t, config.HeightRegressionCheck)
+	assert.True(t, config.RoundPruning)
+
+	// The defaults are valid
+	assert.NoError(t, config.Validate())
+}
+
+func TestConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	testTable := []struct {
+		name     string
+		backend  Backend
+		opts     []Option
+		modifyFn func(config *Config)
+		problems []string
+	}{
+		{
+			name:    "valid NIL proposals",
+			backend: nilProposalBackend{},
+			opts:    []Option{WithNilProposals()},
+		},
+		{
+			name: "missing dependencies",
+			modifyFn: func(config *Config) {
+				config.Logger = nil
+				config.Transport = nil
+				config.RoundTimer = nil
+			},
+			problems: []string{
+				"logger is not set",
+				"transport is not set",
+				"round timer is not set",
+			},
+		},
+		{
+			name: "non-positive timeouts",
+			opts: []Option{
+				WithUnavailableProposerTimeout(0),
+				WithWorkerStopTimeout(0),
+			},
+			modifyFn: func(config *Config) {
+				config.BaseRoundTimeout = 0
+			},
+			problems: []string{
+				"round 0 timeout is 0s, rounds would expire immediately",
+				"base round timeout is 0s, it must be positive",
+				"unavailable proposer timeout is 0s, it must be positive",
+				"worker stop timeout is 0s, round workers would be abandoned right away",
+			},
+		},
+		{
+			name: "invalid timeout schedule",
+			opts: []Option{WithRoundTimeoutSchedule(time.Second, 0)},
+			problems: []string{
+				"round 1 scheduled timeout is 0s, it must be positive",
+			},
+		},
+		{
+			name: "invalid adaptive timeout",
+			opts: []Option{WithAdaptiveTimeout(0, 0)},
+			problems: []string{
+				"adaptive timeout multiplier is 0, it must be positive",
+				"adaptive timeout minimum is 0s, it must be positive",
+			},
+		},
+		{
+			name: "retry backoff ceiling below the initial backoff",
+			opts: []Option{WithRetryPolicy(RetryPolicy{
+				MaxRetries:     3,
+				InitialBackoff: time.Second,
+				MaxBackoff:     time.Millisecond,
+			})},
+			problems: []string{
+				"retry max backoff 1ms is below the initial backoff 1s",
+			},
+		},
+		{
+			name: "negative sequence deadline",
+			opts: []Option{WithSequenceDeadline(-time.Second)},
+			problems: []string{
+				"sequence deadline is -1s, it must not be negative",
+			},
+		},
+		{
+			name: "negative round change backoff limit",
+			opts: []Option{WithRoundChangeBackoff(-time.Second)},
+			problems: []string{
+				"round change backoff limit is -1s, it must not be negative",
+			},
+		},
+		{
+			name: "negative hash mismatch report interval",
+			opts: []Option{WithHashMismatchReportInterval(-time.Second)},
+			problems: []string{
+				"hash mismatch report interval is -1s, it must not be negative",
+			},
+		},
+		{
+			name: "negative clock skew tolerance",
+			opts: []Option{WithClockSkewTolerance(-time.Second)},
+			problems: []string{
+				"clock skew tolerance is -1s, it must not be negative",
+			},
+		},
+		{
+			name: "negative view gossip interval",
+			opts: []Option{WithViewGossipInterval(-time.Second)},
+			problems: []string{
+				"view gossip interval is -1s, it must not be negative",
+			},
+		},
+		{
+			name: "commit rebroadcast after the round timeout",
+			opts: []Option{WithCommitRebroadcast(1, 2)},
+			problems: []string{
+				"commit rebroadcast fraction is 1, it must be in [0, 1), so the rebroadcasts precede the round change",
+			},
+		},
+		{
+			name: "no validators",
+			opts: []Option{WithQuorumVerifier(CountQuorum{
+				ValidatorCount: func(uint64) uint64 {
+					return 0
+				},
+			})},
+			problems: []string{
+				"count quorum has no validators at height 0",
+			},
+		},
+		{
+			name: "no voting power",
+			opts: []Option{WithQuorumVerifier(WeightQuorum{
+				Weights: func(uint64) map[string]uint64 {
+					return map[string]uint64{"node 0": 0}
+				},
+			})},
+			problems: []string{
+				"weight quorum has no voting power at height 0",
+			},
+		},
+		{
+			name: "vetoes without the backend extension",
+			opts: []Option{WithVetoes()},
+			problems: []string{
+				"vetoes are enabled, but the backend does not implement VetoBuilder",
+			},
+		},
+		{
+			name: "sentries without the transport extension",
+			opts: []Option{WithSentries([]byte("sentry 0"))},
+			problems: []string{
+				"sentries are set, but the transport does not implement Unicaster",
+			},
+		},
+		{
+			name: "NIL proposals without the backend extension",
+			opts: []Option{WithNilProposals()},
+			problems: []string{
+				"NIL proposals are enabled, but the backend does not implement NilProposalBuilder",
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			var backend Backend = mockBackend{}
+			if testCase.backend != nil {
+				backend = testCase.backend
+			}
+
+			config := NewIBFT(mockLogger{}, backend, mockTransport{}, testCase.opts...).Config()
+			if testCase.modifyFn != nil {
+				testCase.modifyFn(&config)
+			}
+
+			err := config.Validate()
+			if len(testCase.problems) == 0 {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			require.ErrorIs(t, err, ErrInvalidConfig)
+
+			// Make sure all the problems are reported
+			for _, problem := range testCase.problems {
+				assert.Contains(t, err.Error(), problem)
+			}
+		})
+	}
+}
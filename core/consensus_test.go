@@ -3,7 +3,6 @@ package core
 import (
 	"bytes"
 	"fmt"
-	"math"
 	"testing"
 	"time"
 
@@ -107,25 +106,8 @@ func buildBasicRoundChangeMessage(
 	}
 }
 
-// maxFaulty returns the maximum number of allowed
-// faulty nodes
-func maxFaulty(nodeCount uint64) uint64 {
-	return (nodeCount - 1) / 3
-}
-
-// quorum returns the minimum number of
-// required nodes to reach quorum
-func quorum(numNodes uint64) uint64 {
-	switch maxFaulty(numNodes) {
-	case 0:
-		return numNodes
-	default:
-		return uint64(math.Ceil(2 * float64(numNodes) / 3))
-	}
-}
-
 func commonHasQuorumFn(numNodes uint64) func(height uint64, messages []*proto.Message, msgType proto.MessageType) bool {
-	quorum := quorum(numNodes)
+	quorum := QuorumSize(numNodes)
 
 	return func(height uint64, messages []*proto.Message, msgType proto.MessageType) bool {
 		switch msgType {
@@ -0,0 +1,96 @@
+package core
+
+import (
+	"encoding/hex"
+	"encoding/json"
+)
+
+// debugDump is the JSON document describing the engine state
+type debugDump struct {
+	Height       uint64 `json:"height"`
+	Round        uint64 `json:"round"`
+	RoundStarted bool   `json:"roundStarted"`
+	CommitSent   bool   `json:"commitSent"`
+
+	// ProposalHash is the hash of the accepted proposal, if any
+	ProposalHash string `json:"proposalHash,omitempty"`
+
+	// PreparedRound and PreparedHash describe the latest PC, if any
+	PreparedRound *uint64 `json:"preparedRound,omitempty"`
+	PreparedHash  string  `json:"preparedHash,omitempty"`
+
+	SealCount          int `json:"sealCount"`
+	Subscriptions      int `json:"subscriptions"`
+	EventSubscriptions int `json:"eventSubscriptions"`
+
+	// Messages are the message store counts, by view
+	Messages []debugViewMessages `json:"messages"`
+}
+
+// debugViewMessages is the number of stored messages for a view
+type debugViewMessages struct {
+	Height uint64         `json:"height"`
+	Round  uint64         `json:"round"`
+	Counts map[string]int `json:"counts"`
+}
+
+// DebugDump returns a JSON document of the engine's current state:
+// the view, the accepted and prepared proposals, the seal count,
+// the active subscriptions and the message store occupancy.
+// It is intended to be served under the node's debug endpoint
+func (i *IBFT) DebugDump() ([]byte, error) {
+	dump := i.state.debugDump()
+
+	dump.Subscriptions = i.messages.NumSubscriptions()
+	dump.EventSubscriptions = i.events.numSubscriptions()
+	dump.Messages = make([]debugViewMessages, 0)
+
+	for _, viewCount := range i.messages.ViewCounts() {
+		counts := make(map[string]int, len(viewCount.Counts))
+		for messageType, count := range viewCount.Counts {
+			counts[messageType.String()] = count
+		}
+
+		dump.Messages = append(dump.Messages, debugViewMessages{
+			Height: viewCount.Height,
+			Round:  viewCount.Round,
+			Counts: counts,
+		})
+	}
+
+	return json.Marshal(dump)
+}
+
+// debugDump creates the debug dump of the state
+func (s *state) debugDump() *debugDump {
+	return s.load().debugDump()
+}
+
+// debugDump creates the debug dump of the state snapshot
+func (s *stateSnapshot) debugDump() *debugDump {
+	dump := &debugDump{
+		Height:       s.view.Height,
+		Round:        s.view.Round,
+		RoundStarted: s.roundStarted,
+		CommitSent:   s.commitSent,
+		SealCount:    len(s.seals),
+	}
+
+	if s.proposalMessage != nil {
+		dump.ProposalHash = encodeHash(extractProposalHash(s.proposalMessage))
+	}
+
+	if s.latestPC != nil && s.latestPC.ProposalMessage != nil {
+		preparedRound := s.latestPC.ProposalMessage.GetView().GetRound()
+
+		dump.PreparedRound = &preparedRound
+		dump.PreparedHash = encodeHash(extractProposalHash(s.latestPC.ProposalMessage))
+	}
+
+	return dump
+}
+
+// encodeHash encodes the hash as a 0x-prefixed hex string
+func encodeHash(hash []byte) string {
+	return "0x" + hex.EncodeToString(hash)
+}
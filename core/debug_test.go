@@ -0,0 +1,109 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+func TestIBFT_DebugDump(t *testing.T) {
+	t.Parallel()
+
+	t.Run("empty state", func(t *testing.T) {
+		t.Parallel()
+
+		i := NewIBFT(mockLogger{}, mockBackend{}, mockTransport{})
+
+		raw, err := i.DebugDump()
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		var dump debugDump
+
+		assert.NoError(t, json.Unmarshal(raw, &dump))
+		assert.Equal(t, debugDump{Messages: []debugViewMessages{}}, dump)
+	})
+
+	t.Run("populated state", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			view = &proto.View{
+				Height: 3,
+				Round:  1,
+			}
+			preparedView = &proto.View{
+				Height: 3,
+				Round:  0,
+			}
+		)
+
+		store := messages.NewMessages()
+		defer store.Close()
+
+		i := NewIBFT(mockLogger{}, mockBackend{}, mockTransport{})
+		i.messages = store
+
+		i.state.setView(view)
+		i.state.newRound()
+		i.state.setProposalMessage(buildBasicPreprepareMessage(nil, []byte{0xab}, nil, nil, view))
+		i.state.finalizePrepare(
+			&proto.PreparedCertificate{
+				ProposalMessage: buildBasicPreprepareMessage(nil, []byte{0xcd}, nil, nil, preparedView),
+			},
+			&proto.Proposal{},
+		)
+		i.state.setCommittedSeals([]*messages.CommittedSeal{
+			{Signer: []byte("node 1"), Signature: []byte("seal 1")},
+			{Signer: []byte("node 2"), Signature: []byte("seal 2")},
+		})
+
+		i.AddMessage(buildBasicPrepareMessage(nil, []byte("node 1"), view))
+		i.AddMessage(buildBasicPrepareMessage(nil, []byte("node 2"), view))
+		i.AddMessage(buildBasicCommitMessage(nil, nil, []byte("node 1"), view))
+
+		sub := i.SubscribeEvents()
+		defer i.UnsubscribeEvents(sub.ID)
+
+		raw, err := i.DebugDump()
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		var dump debugDump
+
+		assert.NoError(t, json.Unmarshal(raw, &dump))
+
+		preparedRound := uint64(0)
+
+		assert.Equal(
+			t,
+			debugDump{
+				Height:             3,
+				Round:              1,
+				RoundStarted:       true,
+				ProposalHash:       "0xab",
+				PreparedRound:      &preparedRound,
+				PreparedHash:       "0xcd",
+				SealCount:          2,
+				EventSubscriptions: 1,
+				Messages: []debugViewMessages{
+					{
+						Height: 3,
+						Round:  1,
+						Counts: map[string]int{
+							"PREPARE": 2,
+							"COMMIT":  1,
+						},
+					},
+				},
+			},
+			dump,
+		)
+	})
+}
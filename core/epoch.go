@@ -0,0 +1,99 @@
+package core
+
+import (
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// Epoch is a fixed-length range of heights, at the boundaries
+// of which the validator set and the proposer schedule are refreshed
+type Epoch struct {
+	// Number is the sequential number of the epoch
+	Number uint64 `json:"number"`
+
+	// FirstHeight is the first height of the epoch
+	FirstHeight uint64 `json:"firstHeight"`
+
+	// LastHeight is the last height of the epoch
+	LastHeight uint64 `json:"lastHeight"`
+}
+
+// EpochAt returns the epoch the height belongs to, for the specified epoch length.
+// Epoch 0 starts at height 0. A zero length places all heights in epoch 0
+func EpochAt(height, length uint64) Epoch {
+	if length == 0 {
+		return Epoch{
+			Number:      0,
+			FirstHeight: 0,
+			LastHeight:  ^uint64(0),
+		}
+	}
+
+	number := height / length
+
+	return Epoch{
+		Number:      number,
+		FirstHeight: number * length,
+		LastHeight:  number*length + length - 1,
+	}
+}
+
+// Contains checks if the height belongs to the epoch
+func (e Epoch) Contains(height uint64) bool {
+	return height >= e.FirstHeight && height <= e.LastHeight
+}
+
+// IsBoundary checks if the height is the first height of the epoch
+func (e Epoch) IsBoundary(height uint64) bool {
+	return height == e.FirstHeight
+}
+
+// EpochObserver is an optional Backend extension for chains with epochs.
+// If the backend implements it, it is notified when the engine enters a new epoch,
+// so it can refresh the validator set and the proposer schedule before
+// any message of the epoch is validated
+type EpochObserver interface {
+	// OnEpochStart is called when the engine starts the first sequence of the epoch.
+	// The height of the sequence is not necessarily the first height of the epoch,
+	// if the node was syncing across the boundary
+	OnEpochStart(epoch Epoch)
+}
+
+// enterEpoch updates the current epoch for the height, and announces
+// the transition if the height belongs to a new epoch
+func (i *IBFT) enterEpoch(height uint64) {
+	if i.epochLength == 0 {
+		return
+	}
+
+	epoch := EpochAt(height, i.epochLength)
+
+	if i.epoch != nil && i.epoch.Number == epoch.Number {
+		return
+	}
+
+	i.epoch = &epoch
+
+	i.log.Info(
+		"epoch started",
+		"epoch", epoch.Number,
+		"first height", epoch.FirstHeight,
+		"last height", epoch.LastHeight,
+	)
+
+	if observer, ok := i.backend.(EpochObserver); ok {
+		observer.OnEpochStart(epoch)
+	}
+
+	i.emitEvent(EventEpochTransition, &proto.View{Height: height}, epoch)
+}
+
+// pruneHeight returns the height below which the messages are pruned
+// when the sequence starts. If epochs are configured, the messages
+// of the whole current epoch are retained
+func (i *IBFT) pruneHeight(height uint64) uint64 {
+	if i.epochLength == 0 {
+		return height
+	}
+
+	return EpochAt(height, i.epochLength).FirstHeight
+}
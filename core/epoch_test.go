@@ -0,0 +1,220 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// epochBackend is a mock backend that observes epoch transitions
+type epochBackend struct {
+	mockBackend
+
+	onEpochStartFn func(Epoch)
+}
+
+func (b epochBackend) OnEpochStart(epoch Epoch) {
+	b.onEpochStartFn(epoch)
+}
+
+func TestEpochAt(t *testing.T) {
+	t.Parallel()
+
+	testTable := []struct {
+		name          string
+		height        uint64
+		length        uint64
+		expectedEpoch Epoch
+	}{
+		{
+			"epochs disabled",
+			25,
+			0,
+			Epoch{Number: 0, FirstHeight: 0, LastHeight: ^uint64(0)},
+		},
+		{
+			"first height",
+			0,
+			10,
+			Epoch{Number: 0, FirstHeight: 0, LastHeight: 9},
+		},
+		{
+			"last height",
+			19,
+			10,
+			Epoch{Number: 1, FirstHeight: 10, LastHeight: 19},
+		},
+		{
+			"boundary height",
+			20,
+			10,
+			Epoch{Number: 2, FirstHeight: 20, LastHeight: 29},
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			epoch := EpochAt(testCase.height, testCase.length)
+
+			assert.Equal(t, testCase.expectedEpoch, epoch)
+			assert.True(t, epoch.Contains(testCase.height))
+			assert.Equal(t, testCase.height == epoch.FirstHeight, epoch.IsBoundary(testCase.height))
+		})
+	}
+}
+
+func TestIBFT_EnterEpoch(t *testing.T) {
+	t.Parallel()
+
+	var (
+		observed []Epoch
+		backend  = epochBackend{
+			onEpochStartFn: func(epoch Epoch) {
+				observed = append(observed, epoch)
+			},
+		}
+	)
+
+	i := NewIBFT(mockLogger{}, backend, mockTransport{}, WithEpochLength(10))
+
+	sub := i.SubscribeEvents()
+	defer i.UnsubscribeEvents(sub.ID)
+
+	// Start in the middle of an epoch, and sync across a boundary
+	for _, height := range []uint64{5, 6, 9, 10, 11, 25} {
+		i.enterEpoch(height)
+	}
+
+	expected := []Epoch{
+		{Number: 0, FirstHeight: 0, LastHeight: 9},
+		{Number: 1, FirstHeight: 10, LastHeight: 19},
+		{Number: 2, FirstHeight: 20, LastHeight: 29},
+	}
+
+	// Make sure the backend was notified once per epoch
+	assert.Equal(t, expected, observed)
+
+	// Make sure the transitions were announced
+	if !assert.Len(t, sub.EventCh, len(expected)) {
+		return
+	}
+
+	for index, height := range []uint64{5, 10, 25} {
+		event := <-sub.EventCh
+
+		assert.Equal(t, EventEpochTransition, event.Type)
+		assert.Equal(t, height, event.View.Height)
+		assert.Equal(t, expected[index], event.Data)
+	}
+}
+
+func TestIBFT_EnterEpoch_Disabled(t *testing.T) {
+	t.Parallel()
+
+	backend := epochBackend{
+		onEpochStartFn: func(_ Epoch) {
+			t.Fatal("epoch observer notified")
+		},
+	}
+
+	i := NewIBFT(mockLogger{}, backend, mockTransport{})
+
+	sub := i.SubscribeEvents()
+	defer i.UnsubscribeEvents(sub.ID)
+
+	i.enterEpoch(10)
+
+	assert.Len(t, sub.EventCh, 0)
+}
+
+func TestIBFT_PruneHeight(t *testing.T) {
+	t.Parallel()
+
+	// Make sure only the messages of the past heights are pruned by default
+	i := NewIBFT(mockLogger{}, mockBackend{}, mockTransport{})
+	assert.Equal(t, uint64(15), i.pruneHeight(15))
+
+	// Make sure the messages of the current epoch are retained
+	i = NewIBFT(mockLogger{}, mockBackend{}, mockTransport{}, WithEpochLength(10))
+	assert.Equal(t, uint64(10), i.pruneHeight(15))
+	assert.Equal(t, uint64(20), i.pruneHeight(20))
+}
+
+func TestIBFT_WithPrunePolicy(t *testing.T) {
+	t.Parallel()
+
+	i := NewIBFT(
+		mockLogger{},
+		mockBackend{},
+		mockTransport{},
+		WithPrunePolicy(messages.HeightWindowPolicy{Window: 1}),
+	)
+
+	view := &proto.View{Height: 1}
+
+	i.messages.AddMessage(&proto.Message{
+		View: view,
+		From: []byte("validator"),
+		Type: proto.MessageType_PREPARE,
+	})
+
+	// Make sure the store retains the messages within the window
+	i.messages.PruneByHeight(2)
+	assert.Len(t, i.messages.GetValidMessages(view, proto.MessageType_PREPARE, func(_ *proto.Message) bool {
+		return true
+	}), 1)
+
+	i.messages.PruneByHeight(3)
+	assert.Len(t, i.messages.GetValidMessages(view, proto.MessageType_PREPARE, func(_ *proto.Message) bool {
+		return true
+	}), 0)
+}
+
+func TestIBFT_WithRoundChangeRetention(t *testing.T) {
+	t.Parallel()
+
+	i := NewIBFT(
+		mockLogger{},
+		mockBackend{},
+		mockTransport{},
+		WithPrunePolicy(messages.HeightWindowPolicy{Window: 1}),
+		WithRoundChangeRetention(messages.RetainLatestRoundChange),
+	)
+
+	view := &proto.View{Height: 1, Round: 1}
+
+	// Make sure the latest ROUND_CHANGE message is kept, even without the PC
+	i.messages.AddMessage(buildBasicRoundChangeMessage(
+		correctRoundMessage.proposal,
+		&proto.PreparedCertificate{
+			ProposalMessage: buildBasicPreprepareMessage(nil, nil, nil, []byte("proposer"), &proto.View{Height: 1}),
+		},
+		view,
+		[]byte("validator"),
+	))
+	i.messages.AddMessage(buildBasicRoundChangeMessage(nil, nil, view, []byte("validator")))
+
+	stored := i.messages.GetValidMessages(view, proto.MessageType_ROUND_CHANGE, func(_ *proto.Message) bool {
+		return true
+	})
+
+	if assert.Len(t, stored, 1) {
+		roundChangeData, err := messages.ExtractPayload[*proto.RoundChangeMessage](stored[0])
+
+		assert.NoError(t, err)
+		assert.Nil(t, roundChangeData.LatestPreparedCertificate)
+	}
+
+	// Make sure the combined options are applied
+	i.messages.PruneByHeight(2)
+	assert.Len(t, i.messages.GetValidMessages(view, proto.MessageType_ROUND_CHANGE, func(_ *proto.Message) bool {
+		return true
+	}), 1)
+}
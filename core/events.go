@@ -0,0 +1,458 @@
+package core
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+	"time"
+
+	protoBuf "google.golang.org/protobuf/proto"
+
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+const (
+	// eventBufferSize is the buffer size of each event subscription
+	eventBufferSize = 64
+)
+
+// EventType is the type of the consensus event
+type EventType uint8
+
+const (
+	// EventPreparedMismatch is emitted when the node accepts a proposal
+	// for a higher round that does not carry over its locally prepared proposal
+	EventPreparedMismatch EventType = iota
+
+	// EventEpochTransition is emitted when the node starts
+	// the first sequence of a new epoch. The payload is the Epoch
+	EventEpochTransition
+
+	// EventNilDecision is emitted when the validators decide
+	// on an explicit NIL proposal, skipping the round
+	EventNilDecision
+
+	// EventProposerUnavailable is emitted when the proposer of the current round
+	// announces it is unavailable. The payload is the proposer ID
+	EventProposerUnavailable
+
+	// EventRoundStarted is emitted when the node starts a round
+	EventRoundStarted
+
+	// EventRoundChange is emitted when the node moves to a higher round.
+	// The view is the new view, and the payload is the RoundChangeData
+	EventRoundChange
+
+	// EventProposalAccepted is emitted when the node accepts the proposal
+	// for the round. The payload is the ProposalData
+	EventProposalAccepted
+
+	// EventPrepareQuorum is emitted when the node receives a quorum
+	// of PREPARE messages for the accepted proposal. The payload is the ProposalData,
+	// with the proposer and the PREPARE senders as the participants
+	EventPrepareQuorum
+
+	// EventCommitQuorum is emitted when the node receives a quorum
+	// of COMMIT messages for the accepted proposal. The payload is the ProposalData,
+	// with the signers of the committed seals as the participants
+	EventCommitQuorum
+
+	// EventFinalized is emitted when the proposal is inserted,
+	// finishing the sequence for the height. The payload is the ProposalData
+	EventFinalized
+
+	// EventMalformedCommit is emitted when a COMMIT message counted toward
+	// the quorum turns out to be malformed, and is excluded from the quorum.
+	// The payload is the MalformedMessageData
+	EventMalformedCommit
+
+	// EventEquivocation is emitted when a validator submits conflicting messages
+	// for the same view, and the conflicting message is rejected
+	// (see WithEquivocationPolicy). The payload is the EquivocationData
+	EventEquivocation
+
+	// EventViewAhead is emitted when a validator announces a view
+	// of a higher height (see ViewAnnouncer), so the node is lagging behind.
+	// The view is the announced view, and the payload is the validator ID
+	EventViewAhead
+
+	// EventSequenceDone is emitted when the sequence for a height ends,
+	// as the final event of the sequence. The payload is the SequenceResult
+	EventSequenceDone
+
+	// EventProposalHashMismatch is emitted when a PREPARE or COMMIT message carries
+	// a proposal hash not matching the accepted proposal, which usually means the sender
+	// derives the proposal hash differently. The reports are rate-limited by sender
+	// (see WithHashMismatchReportInterval). The payload is the HashMismatchData
+	EventProposalHashMismatch
+
+	// EventVetoQuorum is emitted when the proposal of the round is rejected
+	// by a rejection quorum of validators (see WithVetoes), so the node moves
+	// to the next round without waiting for the timeout. The payload is the VetoQuorumData
+	EventVetoQuorum
+
+	// EventFinalityStatus is emitted when the finality status of a height changes,
+	// if the finality gadget is set (see WithFinalityGadget): once the height
+	// is committed, and once its anchoring is confirmed (see IBFT.ConfirmAnchored).
+	// The view round is not set, and the payload is the FinalityStatusData
+	EventFinalityStatus
+)
+
+// String returns the human-readable event type
+func (t EventType) String() string {
+	switch t {
+	case EventPreparedMismatch:
+		return "prepared mismatch"
+	case EventEpochTransition:
+		return "epoch transition"
+	case EventNilDecision:
+		return "nil decision"
+	case EventProposerUnavailable:
+		return "proposer unavailable"
+	case EventRoundStarted:
+		return "round started"
+	case EventRoundChange:
+		return "round change"
+	case EventProposalAccepted:
+		return "proposal accepted"
+	case EventPrepareQuorum:
+		return "prepare quorum"
+	case EventCommitQuorum:
+		return "commit quorum"
+	case EventFinalized:
+		return "finalized"
+	case EventMalformedCommit:
+		return "malformed commit"
+	case EventEquivocation:
+		return "equivocation"
+	case EventViewAhead:
+		return "view ahead"
+	case EventSequenceDone:
+		return "sequence done"
+	case EventProposalHashMismatch:
+		return "proposal hash mismatch"
+	case EventVetoQuorum:
+		return "veto quorum"
+	case EventFinalityStatus:
+		return "finality status"
+	}
+
+	return "unknown"
+}
+
+// Event is a consensus event emitted by the IBFT engine
+type Event struct {
+	// Type is the type of the event
+	Type EventType
+
+	// View is the view the event occurred in
+	View *proto.View
+
+	// Timestamp is the time the event occurred at
+	Timestamp time.Time
+
+	// Data is the event specific payload
+	Data interface{}
+}
+
+// PreparedMismatchData is the payload of the EventPreparedMismatch event
+type PreparedMismatchData struct {
+	// PreparedRound is the round in which the node prepared the proposal
+	PreparedRound uint64
+
+	// PreparedHash is the hash of the locally prepared proposal
+	PreparedHash []byte
+
+	// ProposalHash is the hash of the accepted proposal
+	ProposalHash []byte
+}
+
+// RoundChangeReason is the reason the node moved to a higher round
+type RoundChangeReason string
+
+const (
+	// RoundChangeTimeout is the round change caused by the round expiry
+	RoundChangeTimeout RoundChangeReason = "round timeout"
+
+	// RoundChangeCertificate is the round change caused by a higher round RCC
+	RoundChangeCertificate RoundChangeReason = "round change certificate"
+
+	// RoundChangeProposal is the round change caused by a higher round proposal
+	RoundChangeProposal RoundChangeReason = "future proposal"
+
+	// RoundChangeHint is the round change caused by a round hint
+	RoundChangeHint RoundChangeReason = "round hint"
+
+	// RoundChangeFailure is the round change caused by a failed round worker
+	RoundChangeFailure RoundChangeReason = "worker failure"
+
+	// RoundChangeVeto is the round change caused by the rejection quorum
+	// of the round proposal
+	RoundChangeVeto RoundChangeReason = "proposal vetoed"
+)
+
+// RoundChangeData is the payload of the EventRoundChange event
+type RoundChangeData struct {
+	// PreviousRound is the round the node moved from
+	PreviousRound uint64
+
+	// Reason is the reason the node moved to the new round
+	Reason RoundChangeReason
+}
+
+// ProposalData is the payload of the proposal progress events
+type ProposalData struct {
+	// Proposer is the ID of the proposal sender
+	Proposer []byte
+
+	// ProposalHash is the hash of the proposal
+	ProposalHash []byte
+
+	// Participants are the IDs of the validators whose messages
+	// make up the quorum, sorted. Set only for the quorum events
+	Participants [][]byte
+}
+
+// MalformedMessageData is the payload of the EventMalformedCommit event
+type MalformedMessageData struct {
+	// Sender is the ID of the malformed message sender
+	Sender []byte
+
+	// Err is the reason the message is malformed
+	Err error
+}
+
+// HashMismatchData is the payload of the EventProposalHashMismatch event
+type HashMismatchData struct {
+	// Sender is the ID of the message sender
+	Sender []byte
+
+	// MessageType is the type of the message (PREPARE or COMMIT)
+	MessageType proto.MessageType
+
+	// ExpectedHash is the hash of the accepted proposal.
+	// It is empty for an accepted NIL proposal
+	ExpectedHash []byte
+
+	// ReceivedHash is the proposal hash carried by the message
+	ReceivedHash []byte
+}
+
+// VetoQuorumData is the payload of the EventVetoQuorum event
+type VetoQuorumData struct {
+	// Vetoers are the IDs of the validators that rejected the proposal
+	Vetoers [][]byte
+}
+
+// FinalityStatusData is the payload of the EventFinalityStatus event
+type FinalityStatusData struct {
+	// Height is the height whose finality status changed
+	Height uint64
+
+	// ProposalHash is the hash of the proposal committed for the height
+	ProposalHash []byte
+
+	// Previous is the finality status the height moved from
+	Previous FinalityStatus
+
+	// Status is the new finality status of the height
+	Status FinalityStatus
+}
+
+// EquivocationData is the payload of the EventEquivocation event
+type EquivocationData struct {
+	// Sender is the ID of the equivocating validator
+	Sender []byte
+
+	// Evidence are the conflicting signed messages.
+	// The messages are copies, and can be freely retained or modified
+	Evidence messages.Equivocation
+}
+
+// EventSubscriptionID is the unique identifier of an event subscription
+type EventSubscriptionID int32
+
+// EventSubscription is the subscription to consensus events
+type EventSubscription struct {
+	// ID is the unique identifier of the subscription
+	ID EventSubscriptionID
+
+	// EventCh is the channel on which the events are received
+	EventCh <-chan Event
+}
+
+// eventBus dispatches consensus events to the subscribers.
+// Publishing never blocks the consensus engine; events
+// are dropped for subscribers that do not keep up
+type eventBus struct {
+	sync.RWMutex
+
+	// subscriptions are the active event subscriptions
+	subscriptions map[EventSubscriptionID]chan Event
+
+	// nextID is the ID of the next subscription
+	nextID EventSubscriptionID
+}
+
+// newEventBus creates a new event bus
+func newEventBus() *eventBus {
+	return &eventBus{
+		subscriptions: make(map[EventSubscriptionID]chan Event),
+	}
+}
+
+// subscribe creates a new event subscription
+func (b *eventBus) subscribe() *EventSubscription {
+	b.Lock()
+	defer b.Unlock()
+
+	id := b.nextID
+	b.nextID++
+
+	eventCh := make(chan Event, eventBufferSize)
+	b.subscriptions[id] = eventCh
+
+	return &EventSubscription{
+		ID:      id,
+		EventCh: eventCh,
+	}
+}
+
+// unsubscribe removes the event subscription, and closes its channel
+func (b *eventBus) unsubscribe(id EventSubscriptionID) {
+	b.Lock()
+	defer b.Unlock()
+
+	eventCh, ok := b.subscriptions[id]
+	if !ok {
+		return
+	}
+
+	delete(b.subscriptions, id)
+	close(eventCh)
+}
+
+// numSubscriptions returns the number of active event subscriptions
+func (b *eventBus) numSubscriptions() int {
+	b.RLock()
+	defer b.RUnlock()
+
+	return len(b.subscriptions)
+}
+
+// publish sends the event to all subscribers
+func (b *eventBus) publish(event Event) {
+	b.RLock()
+	defer b.RUnlock()
+
+	for _, eventCh := range b.subscriptions {
+		select {
+		case eventCh <- event:
+		default:
+			// The subscriber is not keeping up, drop the event
+		}
+	}
+}
+
+// SubscribeEvents creates a new subscription to consensus events.
+// The subscriber is expected to drain the channel,
+// as events are dropped if the subscription buffer is full
+func (i *IBFT) SubscribeEvents() *EventSubscription {
+	return i.events.subscribe()
+}
+
+// UnsubscribeEvents cancels the event subscription
+func (i *IBFT) UnsubscribeEvents(id EventSubscriptionID) {
+	i.events.unsubscribe(id)
+}
+
+// emitEvent publishes the event for the specified view
+func (i *IBFT) emitEvent(eventType EventType, view *proto.View, data interface{}) {
+	i.events.publish(Event{
+		Type:      eventType,
+		View:      view,
+		Timestamp: time.Now(),
+		Data:      data,
+	})
+}
+
+// emitRoundChange publishes the round change to the current view
+func (i *IBFT) emitRoundChange(previousRound uint64, reason RoundChangeReason) {
+	i.emitEvent(EventRoundChange, i.state.getView(), RoundChangeData{
+		PreviousRound: previousRound,
+		Reason:        reason,
+	})
+}
+
+// handleEquivocation reports the equivocation evidence of the message store
+func (i *IBFT) handleEquivocation(evidence messages.Equivocation) {
+	var (
+		stored, _   = protoBuf.Clone(evidence.Stored).(*proto.Message)
+		rejected, _ = protoBuf.Clone(evidence.Rejected).(*proto.Message)
+	)
+
+	i.log.Error(
+		"conflicting message rejected",
+		"sender", rejected.GetFrom(),
+		"type", rejected.GetType(),
+		"height", rejected.GetView().GetHeight(),
+		"round", rejected.GetView().GetRound(),
+	)
+
+	i.metrics.IncrCounter(equivocationKey, 1)
+	i.peerReporter.ReportPeer(rejected.GetFrom(), OffenseEquivocation)
+	i.emitEvent(EventEquivocation, &proto.View{
+		Height: rejected.GetView().GetHeight(),
+		Round:  rejected.GetView().GetRound(),
+	}, EquivocationData{
+		Sender: rejected.GetFrom(),
+		Evidence: messages.Equivocation{
+			Stored:   stored,
+			Rejected: rejected,
+		},
+	})
+}
+
+// emitProposalEvent publishes the proposal progress event,
+// for the accepted proposal of the view
+func (i *IBFT) emitProposalEvent(eventType EventType, view *proto.View) {
+	i.emitQuorumEvent(eventType, view, nil)
+}
+
+// emitQuorumEvent publishes the proposal progress event for the accepted
+// proposal of the view, with the validators that make up the quorum
+func (i *IBFT) emitQuorumEvent(eventType EventType, view *proto.View, participants [][]byte) {
+	proposalMessage := i.state.getProposalMessage()
+	if proposalMessage == nil {
+		return
+	}
+
+	if participants != nil {
+		var (
+			unique = make([][]byte, 0, len(participants))
+			seen   = make(map[string]struct{}, len(participants))
+		)
+
+		for _, participant := range participants {
+			if _, ok := seen[string(participant)]; ok {
+				continue
+			}
+
+			seen[string(participant)] = struct{}{}
+			unique = append(unique, copyBytes(participant))
+		}
+
+		sort.Slice(unique, func(i, j int) bool {
+			return bytes.Compare(unique[i], unique[j]) < 0
+		})
+
+		participants = unique
+	}
+
+	i.emitEvent(eventType, &proto.View{Height: view.Height, Round: view.Round}, ProposalData{
+		Proposer:     proposalMessage.From,
+		ProposalHash: extractProposalHash(proposalMessage),
+		Participants: participants,
+	})
+}
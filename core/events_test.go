@@ -0,0 +1,519 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	protoBuf "google.golang.org/protobuf/proto"
+
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// drainEvents returns the buffered events of the given types
+func drainEvents(sub *EventSubscription, eventTypes ...EventType) []Event {
+	events := make([]Event, 0)
+
+	for {
+		select {
+		case event := <-sub.EventCh:
+			for _, eventType := range eventTypes {
+				if event.Type == eventType {
+					events = append(events, event)
+
+					break
+				}
+			}
+		default:
+			return events
+		}
+	}
+}
+
+func TestEventBus_PublishSubscribe(t *testing.T) {
+	t.Parallel()
+
+	var (
+		bus  = newEventBus()
+		sub1 = bus.subscribe()
+		sub2 = bus.subscribe()
+
+		event = Event{
+			Type: EventPreparedMismatch,
+			View: &proto.View{Height: 1, Round: 2},
+		}
+	)
+
+	assert.NotEqual(t, sub1.ID, sub2.ID)
+
+	bus.publish(event)
+
+	// Make sure all subscribers received the event
+	assert.Equal(t, event, <-sub1.EventCh)
+	assert.Equal(t, event, <-sub2.EventCh)
+
+	// Make sure the channel is closed on unsubscribe
+	bus.unsubscribe(sub1.ID)
+
+	_, ok := <-sub1.EventCh
+	assert.False(t, ok)
+
+	// Make sure the unsubscribed subscriber does not receive events
+	bus.publish(event)
+	assert.Equal(t, event, <-sub2.EventCh)
+
+	// Make sure a repeated unsubscribe is a no-op
+	bus.unsubscribe(sub1.ID)
+}
+
+func TestEventBus_SlowSubscriber(t *testing.T) {
+	t.Parallel()
+
+	var (
+		bus = newEventBus()
+		sub = bus.subscribe()
+	)
+
+	// Make sure publishing does not block when the buffer is full
+	for index := 0; index < 2*eventBufferSize; index++ {
+		bus.publish(Event{
+			Type: EventPreparedMismatch,
+			View: &proto.View{Height: uint64(index)},
+		})
+	}
+
+	assert.Len(t, sub.EventCh, eventBufferSize)
+
+	// Make sure the oldest events are kept
+	assert.Equal(t, uint64(0), (<-sub.EventCh).View.Height)
+}
+
+func TestIBFT_VerifyPreparedCarryover(t *testing.T) {
+	t.Parallel()
+
+	var (
+		preparedHash = []byte("prepared hash")
+		otherHash    = []byte("other hash")
+	)
+
+	buildProposal := func(round uint64, hash []byte) *proto.Message {
+		return &proto.Message{
+			View: &proto.View{
+				Height: 1,
+				Round:  round,
+			},
+			Type: proto.MessageType_PREPREPARE,
+			Payload: &proto.Message_PreprepareData{
+				PreprepareData: &proto.PrePrepareMessage{
+					ProposalHash: hash,
+				},
+			},
+		}
+	}
+
+	testTable := []struct {
+		name          string
+		latestPC      *proto.PreparedCertificate
+		proposal      *proto.Message
+		expectedEvent bool
+	}{
+		{
+			"no prepared proposal",
+			nil,
+			buildProposal(2, otherHash),
+			false,
+		},
+		{
+			"prepared proposal carried over",
+			&proto.PreparedCertificate{
+				ProposalMessage: buildProposal(1, preparedHash),
+			},
+			buildProposal(2, preparedHash),
+			false,
+		},
+		{
+			"prepared proposal not carried over",
+			&proto.PreparedCertificate{
+				ProposalMessage: buildProposal(1, preparedHash),
+			},
+			buildProposal(2, otherHash),
+			true,
+		},
+		{
+			"round 0 proposal",
+			&proto.PreparedCertificate{
+				ProposalMessage: buildProposal(0, preparedHash),
+			},
+			buildProposal(0, otherHash),
+			false,
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			i := NewIBFT(mockLogger{}, mockBackend{}, mockTransport{})
+			i.state.update(func(next *stateSnapshot) {
+				next.latestPC = testCase.latestPC
+			})
+
+			sub := i.SubscribeEvents()
+			defer i.UnsubscribeEvents(sub.ID)
+
+			i.acceptProposal(testCase.proposal)
+
+			// Make sure the proposal is accepted regardless of the mismatch
+			assert.Equal(t, testCase.proposal, i.state.getProposalMessage())
+
+			mismatches := drainEvents(sub, EventPreparedMismatch)
+
+			if !testCase.expectedEvent {
+				assert.Len(t, mismatches, 0)
+
+				return
+			}
+
+			if !assert.Len(t, mismatches, 1) {
+				return
+			}
+
+			event := mismatches[0]
+
+			assert.Equal(t, EventPreparedMismatch, event.Type)
+			assert.Equal(t, testCase.proposal.View, event.View)
+			assert.Equal(
+				t,
+				PreparedMismatchData{
+					PreparedRound: 1,
+					PreparedHash:  preparedHash,
+					ProposalHash:  otherHash,
+				},
+				event.Data,
+			)
+		})
+	}
+}
+
+func TestIBFT_RoundProgressEvents(t *testing.T) {
+	t.Parallel()
+
+	var (
+		nodeID   = []byte("node 0")
+		proposal = newCorrectRoundMessage(1)
+
+		timer = &signalTimer{
+			views:   make(chan *proto.View, 2),
+			expires: make(chan struct{}),
+		}
+
+		multicastFn func(message *proto.Message)
+
+		backend = mockBackend{
+			hasQuorumFn: commonHasQuorumFn(1),
+			idFn: func() []byte {
+				return nodeID
+			},
+			// Make sure the node is the proposer only for round 1
+			isProposerFn: func(id []byte, _ uint64, round uint64) bool {
+				return round == 1 && bytes.Equal(id, nodeID)
+			},
+			buildProposalFn: func(_ uint64) []byte {
+				return proposal.proposal.GetRawProposal()
+			},
+			buildPrePrepareMessageFn: func(
+				rawProposal []byte,
+				certificate *proto.RoundChangeCertificate,
+				view *proto.View,
+			) *proto.Message {
+				return buildBasicPreprepareMessage(rawProposal, proposal.hash, certificate, nodeID, view)
+			},
+			buildPrepareMessageFn: func(_ []byte, view *proto.View) *proto.Message {
+				return buildBasicPrepareMessage(proposal.hash, nodeID, view)
+			},
+			buildCommitMessageFn: func(_ []byte, view *proto.View) *proto.Message {
+				return buildBasicCommitMessage(proposal.hash, proposal.seal, nodeID, view)
+			},
+			buildRoundChangeMessageFn: func(
+				lastProposal *proto.Proposal,
+				certificate *proto.PreparedCertificate,
+				view *proto.View,
+			) *proto.Message {
+				return buildBasicRoundChangeMessage(lastProposal, certificate, view, nodeID)
+			},
+		}
+		transport = mockTransport{
+			multicastFn: func(message *proto.Message) {
+				multicastFn(message)
+			},
+		}
+	)
+
+	i := NewIBFT(mockLogger{}, backend, transport, WithRoundTimer(timer))
+
+	// Relay the multicast messages back to the node
+	multicastFn = i.AddMessage
+
+	sub := i.SubscribeEvents()
+	defer i.UnsubscribeEvents(sub.ID)
+
+	sequenceDone := make(chan struct{})
+
+	go func() {
+		defer close(sequenceDone)
+
+		i.RunSequence(context.Background(), 1)
+	}()
+
+	// Expire round 0, the node proposes in round 1
+	<-timer.views
+	timer.expires <- struct{}{}
+
+	<-sequenceDone
+
+	var (
+		events = drainEvents(
+			sub,
+			EventRoundStarted,
+			EventRoundChange,
+			EventProposalAccepted,
+			EventPrepareQuorum,
+			EventCommitQuorum,
+			EventFinalized,
+		)
+		eventTypes = make([]EventType, 0, len(events))
+	)
+
+	for _, event := range events {
+		eventTypes = append(eventTypes, event.Type)
+	}
+
+	// Make sure the round progress is emitted in order
+	if !assert.Equal(
+		t,
+		[]EventType{
+			EventRoundStarted,
+			EventRoundChange,
+			EventRoundStarted,
+			EventProposalAccepted,
+			EventPrepareQuorum,
+			EventCommitQuorum,
+			EventFinalized,
+		},
+		eventTypes,
+	) {
+		return
+	}
+
+	assert.Equal(t, uint64(0), events[0].View.Round)
+	assert.Equal(
+		t,
+		RoundChangeData{
+			PreviousRound: 0,
+			Reason:        RoundChangeTimeout,
+		},
+		events[1].Data,
+	)
+	assert.Equal(t, uint64(1), events[1].View.Round)
+
+	// Make sure the proposal events describe the accepted proposal,
+	// and the quorum events the validators that make up the quorum
+	for _, event := range events[3:] {
+		var participants [][]byte
+		if event.Type == EventPrepareQuorum || event.Type == EventCommitQuorum {
+			participants = [][]byte{nodeID}
+		}
+
+		assert.Equal(t, &proto.View{Height: 1, Round: 1}, event.View)
+		assert.Equal(
+			t,
+			ProposalData{
+				Proposer:     nodeID,
+				ProposalHash: proposal.hash,
+				Participants: participants,
+			},
+			event.Data,
+		)
+	}
+}
+
+func TestIBFT_MalformedCommitExcluded(t *testing.T) {
+	t.Parallel()
+
+	testTable := []struct {
+		name           string
+		quorum         int
+		expectedCommit bool
+	}{
+		{
+			"quorum reached without the malformed message",
+			2,
+			true,
+		},
+		{
+			"quorum lost without the malformed message",
+			3,
+			false,
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				view    = &proto.View{Height: 1, Round: 0}
+				metrics = &counterMetrics{}
+
+				backend = mockBackend{
+					hasQuorumFn: func(_ uint64, messages []*proto.Message, _ proto.MessageType) bool {
+						return len(messages) >= testCase.quorum
+					},
+					isValidCommittedSealFn: func(_ []byte, _ *messages.CommittedSeal) bool {
+						return true
+					},
+				}
+
+				malformed = &proto.Message{
+					View: view,
+					From: []byte("node 2"),
+					Type: proto.MessageType_COMMIT,
+				}
+			)
+
+			i := NewIBFT(mockLogger{}, backend, mockTransport{}, WithMetrics(metrics))
+			i.state.setView(view)
+			i.state.setProposalMessage(
+				buildBasicPreprepareMessage(
+					correctRoundMessage.proposal.GetRawProposal(),
+					correctRoundMessage.hash,
+					nil,
+					[]byte("proposer"),
+					view,
+				),
+			)
+
+			sub := i.SubscribeEvents()
+			defer i.UnsubscribeEvents(sub.ID)
+
+			for _, sender := range []string{"node 0", "node 1"} {
+				i.messages.AddMessage(
+					buildBasicCommitMessage(correctRoundMessage.hash, correctRoundMessage.seal, []byte(sender), view),
+				)
+			}
+
+			i.messages.AddMessage(malformed)
+
+			// Mark the malformed message as validated,
+			// so it is counted toward the quorum
+			validated := make(validatedMessages)
+			validated[malformed] = struct{}{}
+
+			assert.Equal(t, testCase.expectedCommit, i.handleCommit(view, validated))
+
+			// Make sure the malformed message is reported, and excluded
+			assert.Equal(t, float32(1), metrics.counter(commitMalformedKey))
+			assert.NotContains(t, validated, malformed)
+
+			events := drainEvents(sub, EventMalformedCommit)
+			if assert.Len(t, events, 1) {
+				data, ok := events[0].Data.(MalformedMessageData)
+
+				assert.True(t, ok)
+				assert.Equal(t, malformed.From, data.Sender)
+				assert.ErrorIs(t, data.Err, messages.ErrMissingPayload)
+			}
+
+			if testCase.expectedCommit {
+				assert.Len(t, i.state.getCommittedSeals(), 2)
+			}
+		})
+	}
+}
+
+func TestIBFT_QuorumEventParticipants(t *testing.T) {
+	t.Parallel()
+
+	view := &proto.View{Height: 1, Round: 0}
+
+	i := NewIBFT(mockLogger{}, mockBackend{}, mockTransport{})
+	i.state.setView(view)
+	i.state.setProposalMessage(
+		buildBasicPreprepareMessage(
+			correctRoundMessage.proposal.GetRawProposal(),
+			correctRoundMessage.hash,
+			nil,
+			[]byte("node 1"),
+			view,
+		),
+	)
+
+	sub := i.SubscribeEvents()
+	defer i.UnsubscribeEvents(sub.ID)
+
+	i.emitQuorumEvent(EventPrepareQuorum, view, [][]byte{
+		[]byte("node 1"),
+		[]byte("node 2"),
+		[]byte("node 0"),
+		[]byte("node 1"),
+	})
+
+	// Make sure the participants are unique, and sorted
+	events := drainEvents(sub, EventPrepareQuorum)
+	if assert.Len(t, events, 1) {
+		assert.Equal(
+			t,
+			ProposalData{
+				Proposer:     []byte("node 1"),
+				ProposalHash: correctRoundMessage.hash,
+				Participants: [][]byte{[]byte("node 0"), []byte("node 1"), []byte("node 2")},
+			},
+			events[0].Data,
+		)
+	}
+}
+
+func TestIBFT_EquivocationEvent(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view    = &proto.View{Height: 1020, Round: 0}
+		metrics = &counterMetrics{}
+
+		first  = buildBasicPrepareMessage([]byte("first hash"), []byte("node 1"), view)
+		second = buildBasicPrepareMessage([]byte("second hash"), []byte("node 1"), view)
+	)
+
+	i := NewIBFT(
+		mockLogger{},
+		mockBackend{},
+		mockTransport{},
+		WithMetrics(metrics),
+		WithEquivocationPolicy(messages.EquivocationReject),
+	)
+
+	sub := i.SubscribeEvents()
+	defer i.UnsubscribeEvents(sub.ID)
+
+	i.messages.AddMessage(first)
+	i.messages.AddMessage(second)
+
+	// Make sure the rejected message is reported, with the evidence
+	assert.Equal(t, float32(1), metrics.counter(equivocationKey))
+
+	events := drainEvents(sub, EventEquivocation)
+	if assert.Len(t, events, 1) {
+		data, ok := events[0].Data.(EquivocationData)
+
+		assert.True(t, ok)
+		assert.Equal(t, []byte("node 1"), data.Sender)
+		assert.True(t, protoBuf.Equal(first, data.Evidence.Stored))
+		assert.True(t, protoBuf.Equal(second, data.Evidence.Rejected))
+	}
+}
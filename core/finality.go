@@ -0,0 +1,116 @@
+package core
+
+import (
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// FinalityProof returns the FINALITY message proving the finalization of the height,
+// which can be served to the nodes still working on it (ex. in reply to a lagging
+// CURRENT_VIEW announcement). Only the proof of the latest height finalized
+// by the node is kept; nil is returned for other heights
+func (i *IBFT) FinalityProof(height uint64) *proto.Message {
+	proof := i.finalityProof.Load()
+	if proof == nil || proof.View.Height != height {
+		return nil
+	}
+
+	return proof
+}
+
+// recordFinalityProof keeps the proof of the proposal the node finalized
+// in the current round, so it can be served to the nodes lagging behind
+func (i *IBFT) recordFinalityProof() {
+	i.finalityProof.Store(messages.NewFinalityMessage(
+		i.state.getView(),
+		i.state.getProposal(),
+		i.state.getProposalHash(),
+		i.state.getCommittedSeals(),
+	))
+}
+
+// handleFinality handles the FINALITY message served by a node that finalized
+// the current height. A valid proof ends the sequence with its proposal inserted,
+// without waiting for the rounds of the node to reach the commit quorum.
+// Proofs for the higher heights are left to the sync layer
+func (i *IBFT) handleFinality(message *proto.Message) {
+	if message.View.Height != i.state.getHeight() {
+		return
+	}
+
+	commitSeals, ok := i.verifyFinalityProof(message)
+	if !ok {
+		i.log.Debug("invalid finality proof", "height", message.View.Height, "round", message.View.Round)
+
+		return
+	}
+
+	i.signalFinality(roundEvent{
+		eventType:       roundEventFinality,
+		round:           message.View.Round,
+		proposalMessage: message,
+		committedSeals:  commitSeals,
+	})
+}
+
+// verifyFinalityProof verifies the FINALITY message proves the finalization
+// of its proposal, and returns the committed seals of the finalization
+func (i *IBFT) verifyFinalityProof(message *proto.Message) ([]*messages.CommittedSeal, bool) {
+	finalityData, err := messages.ExtractPayload[*proto.FinalityMessage](message)
+	if err != nil || finalityData.Proposal == nil || len(finalityData.ProposalHash) == 0 {
+		return nil, false
+	}
+
+	commitMessages, err := messages.ExtractFinalityCommits(message)
+	if err != nil || !messages.HasUniqueSenders(commitMessages) {
+		return nil, false
+	}
+
+	return i.verifyFinalized(message.View, finalityData.Proposal, finalityData.ProposalHash, commitMessages)
+}
+
+// signalFinality notifies the sequence routine (RunSequence) of the verified
+// finality proof, without blocking. A pending proof is replaced
+func (i *IBFT) signalFinality(event roundEvent) {
+	for {
+		select {
+		case i.finality <- event:
+			return
+		default:
+		}
+
+		select {
+		case <-i.finality:
+		default:
+		}
+	}
+}
+
+// finalizeWithProof inserts the proposal of the verified finality proof,
+// which finalizes the current height
+func (i *IBFT) finalizeWithProof(event roundEvent) bool {
+	message := event.proposalMessage
+
+	finalityData, err := messages.ExtractPayload[*proto.FinalityMessage](message)
+	if err != nil {
+		return false
+	}
+
+	if !i.insertFinalized(message.View, finalityData.Proposal, finalityData.ProposalHash, event.committedSeals) {
+		return false
+	}
+
+	i.state.setCommittedSeals(event.committedSeals)
+	i.finalityProof.Store(message)
+
+	// Remove stale messages
+	i.messages.PruneByHeight(i.state.getHeight())
+
+	i.log.Info("finalized with a finality proof", "height", message.View.Height, "round", message.View.Round)
+
+	i.emitEvent(EventFinalized, message.View, ProposalData{
+		ProposalHash: finalityData.ProposalHash,
+	})
+
+	return true
+}
@@ -0,0 +1,189 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// buildFinalityMessage builds the FINALITY message of the view,
+// with a seal of each of the signers
+func buildFinalityMessage(view *proto.View, proposalHash []byte, signers ...[]byte) *proto.Message {
+	seals := make([]*messages.CommittedSeal, 0, len(signers))
+	for _, signer := range signers {
+		seals = append(seals, &messages.CommittedSeal{
+			Signer:    signer,
+			Signature: []byte("seal"),
+		})
+	}
+
+	proposal := &proto.Proposal{
+		RawProposal: []byte("proposal"),
+		Round:       view.Round,
+	}
+
+	return messages.NewFinalityMessage(view, proposal, proposalHash, seals)
+}
+
+// TestIBFT_AddMessage_Finality makes sure only the valid finality proofs
+// for the current height are signaled to the sequence
+func TestIBFT_AddMessage_Finality(t *testing.T) {
+	t.Parallel()
+
+	var (
+		nodes        = generateNodeAddresses(4)
+		proposalHash = []byte("proposal hash")
+		invalidSeal  = []byte("invalid seal signer")
+		view         = &proto.View{Height: 5, Round: 2}
+	)
+
+	testTable := []struct {
+		name     string
+		message  *proto.Message
+		signaled bool
+	}{
+		{
+			"valid proof",
+			buildFinalityMessage(view, proposalHash, nodes[0], nodes[1], nodes[2]),
+			true,
+		},
+		{
+			"valid proof of a lower round",
+			buildFinalityMessage(&proto.View{Height: 5, Round: 0}, proposalHash, nodes[0], nodes[1], nodes[2]),
+			true,
+		},
+		{
+			"proof without a quorum",
+			buildFinalityMessage(view, proposalHash, nodes[0], nodes[1]),
+			false,
+		},
+		{
+			"proof with duplicate signers",
+			buildFinalityMessage(view, proposalHash, nodes[0], nodes[1], nodes[1]),
+			false,
+		},
+		{
+			"proof with an invalid seal",
+			buildFinalityMessage(view, proposalHash, nodes[0], nodes[1], invalidSeal),
+			false,
+		},
+		{
+			"proof for another proposal",
+			buildFinalityMessage(view, []byte("other hash"), nodes[0], nodes[1], nodes[2]),
+			false,
+		},
+		{
+			"proof of a future height",
+			buildFinalityMessage(&proto.View{Height: 6, Round: 0}, proposalHash, nodes[0], nodes[1], nodes[2]),
+			false,
+		},
+		{
+			"proof of a past height",
+			buildFinalityMessage(&proto.View{Height: 4, Round: 0}, proposalHash, nodes[0], nodes[1], nodes[2]),
+			false,
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			backend := mockBackend{
+				// The proofs are not signed by their senders
+				IsValidValidatorFn: func(_ *proto.Message) bool {
+					return false
+				},
+				isValidProposalHashFn: func(_ *proto.Proposal, hash []byte) bool {
+					return bytes.Equal(hash, proposalHash)
+				},
+				isValidCommittedSealFn: func(_ []byte, seal *messages.CommittedSeal) bool {
+					return !bytes.Equal(seal.Signer, invalidSeal)
+				},
+				hasQuorumFn: commonHasQuorumFn(uint64(len(nodes))),
+			}
+
+			i := NewIBFT(mockLogger{}, backend, mockTransport{})
+			i.state.setView(view)
+
+			i.AddMessage(testCase.message)
+
+			assert.Equal(t, testCase.signaled, len(i.finality) == 1)
+		})
+	}
+}
+
+// TestIBFT_RunSequence_Finality makes sure a valid finality proof
+// finalizes the height the node is still working on, and is kept
+// so the node can serve it to other nodes
+func TestIBFT_RunSequence_Finality(t *testing.T) {
+	t.Parallel()
+
+	var (
+		nodes        = generateNodeAddresses(4)
+		proposalHash = []byte("proposal hash")
+		view         = &proto.View{Height: 1, Round: 3}
+		proof        = buildFinalityMessage(view, proposalHash, nodes[0], nodes[1], nodes[2])
+
+		insertedProposal *proto.Proposal
+		insertedSeals    []*messages.CommittedSeal
+	)
+
+	backend := mockBackend{
+		isValidProposalHashFn: func(_ *proto.Proposal, hash []byte) bool {
+			return bytes.Equal(hash, proposalHash)
+		},
+		hasQuorumFn: commonHasQuorumFn(uint64(len(nodes))),
+		insertProposalFn: func(proposal *proto.Proposal, seals []*messages.CommittedSeal) {
+			insertedProposal = proposal
+			insertedSeals = seals
+		},
+	}
+
+	i := NewIBFT(mockLogger{}, backend, mockTransport{})
+
+	sub := i.SubscribeEvents()
+	defer i.UnsubscribeEvents(sub.ID)
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+
+	resultCh := make(chan SequenceResult, 1)
+
+	go func() {
+		result, _ := i.RunSequence(ctx, 1)
+		resultCh <- result
+	}()
+
+	// Wait for the node to start working on the height
+	for event := range sub.EventCh {
+		if event.Type == EventRoundStarted {
+			break
+		}
+	}
+
+	i.AddMessage(proof)
+
+	result := <-resultCh
+
+	// Make sure the proposal of the proof is inserted,
+	// while the node is still in its first round
+	assert.Equal(t, SequenceResult{Height: 1, Round: 0, Reason: SequenceFinalized, Phase: PhasePrePrepare}, result)
+	assert.Equal(t, &proto.Proposal{RawProposal: []byte("proposal"), Round: view.Round}, insertedProposal)
+	assert.Len(t, insertedSeals, 3)
+
+	events := drainEvents(sub, EventFinalized)
+	require.Len(t, events, 1)
+	assert.Equal(t, view, events[0].View)
+
+	// Make sure the node can serve the proof onwards
+	assert.Equal(t, proof, i.FinalityProof(1))
+	assert.Nil(t, i.FinalityProof(2))
+}
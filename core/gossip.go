@@ -0,0 +1,183 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+const (
+	// defaultViewGossipInterval is the default time between
+	// two periodic CURRENT_VIEW multicasts
+	defaultViewGossipInterval = 5 * time.Second
+
+	// minViewReplyInterval is the minimum time between two CURRENT_VIEW
+	// multicasts in reply to the validators lagging behind
+	minViewReplyInterval = time.Second
+)
+
+// ViewAnnouncer is an optional Backend extension for gossiping the view of the node.
+// If the backend implements it, the node periodically multicasts a compact, signed
+// CURRENT_VIEW message (see WithViewGossipInterval), and replies with it to the validators
+// announcing a lower view, so late-joining or reconnecting validators learn the active
+// height and round. The CURRENT_VIEW messages are received regardless
+type ViewAnnouncer interface {
+	// BuildCurrentViewMessage builds a signed CURRENT_VIEW message for the view.
+	// The message has no payload
+	BuildCurrentViewMessage(view *proto.View) *proto.Message
+}
+
+// peerViews are the latest views announced by the validators for the current height
+type peerViews struct {
+	sync.Mutex
+
+	// height is the height of the tracked views
+	height uint64
+
+	// views are the latest CURRENT_VIEW messages, by sender
+	views map[string]*proto.Message
+
+	// lastReply is the time of the latest reply to a lagging validator
+	lastReply time.Time
+}
+
+// newPeerViews creates a new tracker of the announced views
+func newPeerViews() *peerViews {
+	return &peerViews{
+		views: make(map[string]*proto.Message),
+	}
+}
+
+// record records the view announced by the sender for the height, and returns
+// the announced messages, ordered from the highest round to the lowest one
+func (p *peerViews) record(height uint64, message *proto.Message) []*proto.Message {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.height != height {
+		p.height = height
+		p.views = make(map[string]*proto.Message)
+	}
+
+	if latest, ok := p.views[string(message.From)]; !ok || latest.View.Round < message.View.Round {
+		p.views[string(message.From)] = message
+	}
+
+	announced := make([]*proto.Message, 0, len(p.views))
+	for _, view := range p.views {
+		announced = append(announced, view)
+	}
+
+	sort.Slice(announced, func(a, b int) bool {
+		return announced[a].View.Round > announced[b].View.Round
+	})
+
+	return announced
+}
+
+// allowReply checks if enough time passed since the latest reply,
+// and records the reply if it is allowed
+func (p *peerViews) allowReply(now time.Time) bool {
+	p.Lock()
+	defer p.Unlock()
+
+	if now.Sub(p.lastReply) < minViewReplyInterval {
+		return false
+	}
+
+	p.lastReply = now
+
+	return true
+}
+
+// GossipView multicasts the CURRENT_VIEW message of the node, if the backend
+// implements ViewAnnouncer. Besides the periodic gossip, it can be called
+// on request (ex. when a peer connects). An observing node does not gossip its view
+func (i *IBFT) GossipView(ctx context.Context) {
+	announcer, ok := i.backend.(ViewAnnouncer)
+	if !ok || i.isObserving() {
+		return
+	}
+
+	i.multicast(ctx, announcer.BuildCurrentViewMessage(i.state.getView()))
+}
+
+// gossipView multicasts the CURRENT_VIEW message of the node periodically,
+// and in reply to the validators lagging behind. The multicasts run
+// in the round worker, so they never block the message reception
+func (i *IBFT) gossipView(ctx context.Context) {
+	var tick <-chan time.Time
+
+	if i.viewGossipInterval > 0 {
+		ticker := time.NewTicker(i.viewGossipInterval)
+		defer ticker.Stop()
+
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+			i.GossipView(ctx)
+		case <-i.viewRequests:
+			if i.peerViews.allowReply(time.Now()) {
+				i.GossipView(ctx)
+			}
+		}
+	}
+}
+
+// handleCurrentView handles the view announced by a validator. Validators lagging
+// behind are sent the view of the node, and the node moves to the highest round
+// of the current height announced by a quorum of the validators
+func (i *IBFT) handleCurrentView(message *proto.Message) {
+	if bytes.Equal(message.From, i.backend.ID()) {
+		return
+	}
+
+	view := i.state.getView()
+
+	switch {
+	case message.View.Height < view.Height ||
+		message.View.Height == view.Height && message.View.Round < view.Round:
+		i.signalViewRequest()
+	case message.View.Height > view.Height:
+		// The node is lagging behind, which is resolved by the sync layer
+		i.emitEvent(EventViewAhead, message.View, message.From)
+	default:
+		announced := i.peerViews.record(view.Height, message)
+
+		if round, ok := i.quorumRound(view.Height, announced); ok {
+			i.SetRoundHint(view.Height, round)
+		}
+	}
+}
+
+// quorumRound returns the highest round announced by a quorum of the validators,
+// if any. The quorum of the announced views is counted as the ROUND_CHANGE quorum,
+// as a quorum of the validators moving to a round is what starts the round
+func (i *IBFT) quorumRound(height uint64, announced []*proto.Message) (uint64, bool) {
+	for count := 1; count <= len(announced); count++ {
+		if i.quorum.HasQuorum(height, announced[:count], proto.MessageType_ROUND_CHANGE) {
+			return announced[count-1].View.Round, true
+		}
+	}
+
+	return 0, false
+}
+
+// signalViewRequest notifies the round worker that a validator
+// is lagging behind, without blocking
+func (i *IBFT) signalViewRequest() {
+	select {
+	case i.viewRequests <- struct{}{}:
+	default:
+		// A reply is already pending
+	}
+}
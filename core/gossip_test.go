@@ -0,0 +1,308 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// announcerBackend is a mock backend that announces the view of the node
+type announcerBackend struct {
+	mockBackend
+}
+
+func (b announcerBackend) BuildCurrentViewMessage(view *proto.View) *proto.Message {
+	return buildCurrentViewMessage(b.ID(), view)
+}
+
+// buildCurrentViewMessage builds the CURRENT_VIEW message of the sender
+func buildCurrentViewMessage(from []byte, view *proto.View) *proto.Message {
+	return &proto.Message{
+		View: view,
+		From: from,
+		Type: proto.MessageType_CURRENT_VIEW,
+	}
+}
+
+// newAnnouncerBackend creates the backend of the node, with the quorum of 4 validators
+func newAnnouncerBackend(id []byte) announcerBackend {
+	return announcerBackend{
+		mockBackend: mockBackend{
+			idFn: func() []byte {
+				return id
+			},
+			hasQuorumFn: commonHasQuorumFn(4),
+		},
+	}
+}
+
+func TestIBFT_GossipView(t *testing.T) {
+	t.Parallel()
+
+	view := &proto.View{Height: 4, Round: 2}
+
+	t.Run("view announced", func(t *testing.T) {
+		t.Parallel()
+
+		recorder := &multicastRecorder{}
+
+		i := NewIBFT(mockLogger{}, newAnnouncerBackend([]byte("node")), recorder)
+		i.state.setView(view)
+
+		i.GossipView(context.Background())
+
+		multicasted := recorder.messages()
+		if assert.Len(t, multicasted, 1) {
+			assert.Equal(t, proto.MessageType_CURRENT_VIEW, multicasted[0].Type)
+			assert.Equal(t, view, multicasted[0].View)
+		}
+	})
+
+	t.Run("backend does not announce the view", func(t *testing.T) {
+		t.Parallel()
+
+		recorder := &multicastRecorder{}
+
+		i := NewIBFT(mockLogger{}, mockBackend{}, recorder)
+		i.state.setView(view)
+
+		i.GossipView(context.Background())
+
+		assert.Empty(t, recorder.messages())
+	})
+}
+
+func TestIBFT_GossipView_Periodic(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx, cancelFn = context.WithCancel(context.Background())
+		gossiped      = make(chan *proto.Message, 2)
+		transport     = mockTransport{
+			multicastFn: func(message *proto.Message) {
+				select {
+				case gossiped <- message:
+				default:
+					cancelFn()
+				}
+			},
+		}
+	)
+
+	defer cancelFn()
+
+	i := NewIBFT(
+		mockLogger{},
+		newAnnouncerBackend([]byte("node")),
+		transport,
+		WithViewGossipInterval(10*time.Millisecond),
+	)
+
+	// Make sure the view is gossiped repeatedly, until the worker is stopped
+	i.gossipView(ctx)
+
+	assert.Len(t, gossiped, 2)
+}
+
+func TestIBFT_HandleCurrentView_LaggingValidator(t *testing.T) {
+	t.Parallel()
+
+	validators := generateNodeAddresses(4)
+
+	testTable := []struct {
+		name      string
+		announced *proto.View
+		replied   bool
+	}{
+		{
+			"lower height",
+			&proto.View{Height: 3, Round: 6},
+			true,
+		},
+		{
+			"lower round",
+			&proto.View{Height: 4, Round: 1},
+			true,
+		},
+		{
+			"same view",
+			&proto.View{Height: 4, Round: 2},
+			false,
+		},
+		{
+			"higher round",
+			&proto.View{Height: 4, Round: 3},
+			false,
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			i := NewIBFT(mockLogger{}, newAnnouncerBackend(validators[0]), mockTransport{})
+			i.state.setView(&proto.View{Height: 4, Round: 2})
+
+			i.AddMessage(buildCurrentViewMessage(validators[1], testCase.announced))
+
+			// Make sure a reply is requested only for the lagging validators
+			assert.Equal(t, testCase.replied, len(i.viewRequests) == 1)
+		})
+	}
+}
+
+func TestIBFT_HandleCurrentView_ReplyThrottled(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx, cancelFn = context.WithCancel(context.Background())
+		recorder      = &multicastRecorder{}
+		validators    = generateNodeAddresses(4)
+	)
+
+	i := NewIBFT(mockLogger{}, newAnnouncerBackend(validators[0]), recorder, WithViewGossipInterval(0))
+	i.state.setView(&proto.View{Height: 4, Round: 2})
+
+	workerDone := make(chan struct{})
+
+	go func() {
+		defer close(workerDone)
+
+		i.gossipView(ctx)
+	}()
+
+	// The lagging validators keep announcing their views
+	for _, validator := range validators[1:] {
+		i.AddMessage(buildCurrentViewMessage(validator, &proto.View{Height: 4, Round: 0}))
+
+		require.Eventually(t, func() bool {
+			return len(i.viewRequests) == 0
+		}, time.Second, time.Millisecond)
+	}
+
+	cancelFn()
+	<-workerDone
+
+	// Make sure the node replied once
+	assert.Len(t, recorder.messages(), 1)
+}
+
+func TestIBFT_HandleCurrentView_QuorumRound(t *testing.T) {
+	t.Parallel()
+
+	validators := generateNodeAddresses(5)
+
+	i := NewIBFT(mockLogger{}, newAnnouncerBackend(validators[0]), mockTransport{})
+	i.state.setView(&proto.View{Height: 4, Round: 0})
+
+	announce := func(validator int, round uint64) {
+		i.AddMessage(buildCurrentViewMessage(validators[validator], &proto.View{Height: 4, Round: round}))
+	}
+
+	// A single validator, possibly faulty, cannot move the node
+	announce(1, 100)
+	announce(2, 5)
+
+	assert.Len(t, i.roundHint, 0)
+
+	// Make sure the node is hinted to the highest round announced by a quorum
+	announce(3, 7)
+
+	if assert.Len(t, i.roundHint, 1) {
+		assert.Equal(t, &proto.View{Height: 4, Round: 5}, <-i.roundHint)
+	}
+
+	// Make sure the later announcements of a validator replace its earlier ones
+	announce(2, 9)
+
+	if assert.Len(t, i.roundHint, 1) {
+		assert.Equal(t, &proto.View{Height: 4, Round: 7}, <-i.roundHint)
+	}
+
+	// Make sure the own announcements are ignored
+	announce(0, 100)
+
+	assert.Len(t, i.roundHint, 0)
+}
+
+func TestIBFT_HandleCurrentView_HeightAhead(t *testing.T) {
+	t.Parallel()
+
+	validators := generateNodeAddresses(4)
+
+	i := NewIBFT(mockLogger{}, newAnnouncerBackend(validators[0]), mockTransport{})
+	i.state.setView(&proto.View{Height: 4, Round: 0})
+
+	sub := i.SubscribeEvents()
+	defer i.UnsubscribeEvents(sub.ID)
+
+	announced := &proto.View{Height: 9, Round: 1}
+
+	i.AddMessage(buildCurrentViewMessage(validators[1], announced))
+
+	// Make sure the lag is announced to the sync layer, without hinting the round
+	assert.Len(t, i.roundHint, 0)
+
+	if assert.Len(t, sub.EventCh, 1) {
+		event := <-sub.EventCh
+
+		assert.Equal(t, EventViewAhead, event.Type)
+		assert.Equal(t, announced, event.View)
+		assert.Equal(t, validators[1], event.Data)
+	}
+}
+
+// TestIBFT_ViewGossip_CatchUp makes sure a node that joins late
+// moves to the active round of the height, and announces it
+func TestIBFT_ViewGossip_CatchUp(t *testing.T) {
+	t.Parallel()
+
+	var (
+		validators  = generateNodeAddresses(4)
+		multicasted = make(chan *proto.Message, 8)
+		backend     = newAnnouncerBackend(validators[0])
+	)
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+
+	i := NewIBFT(mockLogger{}, backend, mockTransport{
+		multicastFn: func(message *proto.Message) {
+			multicasted <- message
+		},
+	})
+
+	sub := i.SubscribeEvents()
+	defer i.UnsubscribeEvents(sub.ID)
+
+	sequenceDone := runSequenceAsync(ctx, i, 1)
+
+	// Wait for the node to start the height
+	for event := range sub.EventCh {
+		if event.Type == EventRoundStarted {
+			break
+		}
+	}
+
+	for _, validator := range validators[1:] {
+		i.AddMessage(buildCurrentViewMessage(validator, &proto.View{Height: 1, Round: 3}))
+	}
+
+	select {
+	case message := <-multicasted:
+		assert.Equal(t, proto.MessageType_ROUND_CHANGE, message.Type)
+		assert.Equal(t, uint64(3), message.View.Round)
+	case <-time.After(5 * time.Second):
+		t.Fatal("round not changed")
+	}
+
+	cancelFn()
+	waitSequenceDone(t, sequenceDone)
+}
@@ -0,0 +1,181 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"runtime/pprof"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrWorkersStuck is returned when the round workers
+	// do not stop within the worker stop timeout
+	ErrWorkersStuck = errors.New("round workers did not stop in time")
+)
+
+// defaultWorkerStopTimeout is the time the round workers
+// are given to stop, once the round is torn down
+const defaultWorkerStopTimeout = 5 * time.Second
+
+// workerPanicError is the failure of a worker that panicked
+type workerPanicError struct {
+	// worker is the name of the worker
+	worker string
+
+	// value is the recovered panic value
+	value interface{}
+
+	// stack is the stack trace of the panicking goroutine
+	stack []byte
+}
+
+func (e *workerPanicError) Error() string {
+	return fmt.Sprintf("worker %s panicked: %v", e.worker, e.value)
+}
+
+// workerGroup manages the workers of a single round, in the manner of an errgroup.
+// The first worker failure (a returned error or a recovered panic) cancels
+// the group context, so the remaining workers stop and the round can be ended
+type workerGroup struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	wg sync.WaitGroup
+
+	// failOnce guards the first failure
+	failOnce sync.Once
+	err      error
+
+	// failedCh is closed on the first worker failure
+	failedCh chan struct{}
+}
+
+// newWorkerGroup creates a new worker group, with the context derived
+// from the passed in one
+func newWorkerGroup(ctx context.Context) *workerGroup {
+	ctx, cancel := context.WithCancel(ctx)
+
+	return &workerGroup{
+		ctx:      ctx,
+		cancel:   cancel,
+		failedCh: make(chan struct{}),
+	}
+}
+
+// run runs the worker in a new goroutine, labeled with the worker name
+// and the labels of the group context. Panics are recovered
+// and reported as worker failures. The worker context is cancelled once
+// the worker returns, which removes the message subscriptions bound to it
+func (g *workerGroup) run(name string, worker func(ctx context.Context) error) {
+	g.wg.Add(1)
+
+	go func() {
+		defer g.wg.Done()
+
+		pprof.Do(g.ctx, pprof.Labels(labelWorker, name), func(ctx context.Context) {
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			defer func() {
+				if value := recover(); value != nil {
+					g.fail(&workerPanicError{
+						worker: name,
+						value:  value,
+						stack:  debug.Stack(),
+					})
+				}
+			}()
+
+			if err := worker(ctx); err != nil {
+				g.fail(fmt.Errorf("worker %s failed: %w", name, err))
+			}
+		})
+	}()
+}
+
+// spawn runs the worker that does not return errors in a new goroutine.
+// Panics are recovered and reported as worker failures
+func (g *workerGroup) spawn(name string, worker func(ctx context.Context)) {
+	g.run(name, func(ctx context.Context) error {
+		worker(ctx)
+
+		return nil
+	})
+}
+
+// fail records the first worker failure, and cancels the group context
+func (g *workerGroup) fail(err error) {
+	g.failOnce.Do(func() {
+		g.err = err
+
+		close(g.failedCh)
+		g.cancel()
+	})
+}
+
+// failed returns the channel closed on the first worker failure
+func (g *workerGroup) failed() <-chan struct{} {
+	return g.failedCh
+}
+
+// failure returns the first worker failure, if any
+func (g *workerGroup) failure() error {
+	select {
+	case <-g.failedCh:
+		return g.err
+	default:
+		return nil
+	}
+}
+
+// wait waits for the workers to finish, and returns the first worker failure
+func (g *workerGroup) wait() error {
+	defer g.cancel()
+
+	g.wg.Wait()
+
+	return g.failure()
+}
+
+// stop cancels the group context, and waits for the workers to stop.
+// If the workers do not stop within the timeout, the wait is abandoned,
+// so a stuck worker cannot deadlock the caller. The first worker failure is returned
+func (g *workerGroup) stop(timeout time.Duration) error {
+	g.cancel()
+
+	stopped := make(chan struct{})
+
+	go pprof.Do(g.ctx, pprof.Labels(labelWorker, "stop"), func(context.Context) {
+		g.wg.Wait()
+		close(stopped)
+	})
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-stopped:
+		return g.failure()
+	case <-timer.C:
+		if err := g.failure(); err != nil {
+			return fmt.Errorf("%w: %v", ErrWorkersStuck, err)
+		}
+
+		return ErrWorkersStuck
+	}
+}
+
+// logWorkerFailure logs the failure of the round workers
+func (i *IBFT) logWorkerFailure(round uint64, err error) {
+	var panicErr *workerPanicError
+	if errors.As(err, &panicErr) {
+		i.log.Error("round worker panicked", "round", round, "err", err, "stack", string(panicErr.stack))
+
+		return
+	}
+
+	i.log.Error("round workers failed", "round", round, "err", err)
+}
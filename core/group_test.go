@@ -0,0 +1,172 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkerGroup_Failure(t *testing.T) {
+	t.Parallel()
+
+	t.Run("panic is recovered and cancels the other workers", func(t *testing.T) {
+		t.Parallel()
+
+		group := newWorkerGroup(context.Background())
+
+		group.spawn("waiting", func(ctx context.Context) {
+			<-ctx.Done()
+		})
+		group.spawn("panicking", func(_ context.Context) {
+			panic("boom")
+		})
+
+		select {
+		case <-group.failed():
+		case <-time.After(5 * time.Second):
+			t.Fatal("failure not signaled")
+		}
+
+		err := group.wait()
+
+		var panicErr *workerPanicError
+		require.ErrorAs(t, err, &panicErr)
+		assert.Equal(t, "panicking", panicErr.worker)
+		assert.Equal(t, "boom", panicErr.value)
+		assert.NotEmpty(t, panicErr.stack)
+	})
+
+	t.Run("first error is returned", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			errFirst  = errors.New("first")
+			errSecond = errors.New("second")
+
+			group = newWorkerGroup(context.Background())
+		)
+
+		group.run("first", func(_ context.Context) error {
+			return errFirst
+		})
+
+		<-group.failed()
+
+		group.run("second", func(_ context.Context) error {
+			return errSecond
+		})
+
+		err := group.wait()
+
+		assert.ErrorIs(t, err, errFirst)
+		assert.NotErrorIs(t, err, errSecond)
+	})
+
+	t.Run("finished workers are not failures", func(t *testing.T) {
+		t.Parallel()
+
+		group := newWorkerGroup(context.Background())
+
+		group.spawn("finished", func(_ context.Context) {})
+
+		assert.NoError(t, group.wait())
+
+		select {
+		case <-group.failed():
+			t.Fatal("failure signaled")
+		default:
+		}
+	})
+}
+
+func TestWorkerGroup_Stop(t *testing.T) {
+	t.Parallel()
+
+	t.Run("workers are stopped", func(t *testing.T) {
+		t.Parallel()
+
+		group := newWorkerGroup(context.Background())
+
+		group.spawn("waiting", func(ctx context.Context) {
+			<-ctx.Done()
+		})
+
+		assert.NoError(t, group.stop(5*time.Second))
+	})
+
+	t.Run("stuck worker does not block the stop", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			unblock = make(chan struct{})
+			group   = newWorkerGroup(context.Background())
+		)
+
+		defer close(unblock)
+
+		group.spawn("stuck", func(_ context.Context) {
+			<-unblock
+		})
+
+		assert.ErrorIs(t, group.stop(10*time.Millisecond), ErrWorkersStuck)
+	})
+}
+
+// TestIBFT_RunSequence_WorkerPanic makes sure a panicking round worker
+// ends the round, instead of leaving it without one of its phases
+func TestIBFT_RunSequence_WorkerPanic(t *testing.T) {
+	t.Parallel()
+
+	var (
+		height = uint64(1)
+
+		backend = mockBackend{
+			isProposerFn: func(_ []byte, _ uint64, round uint64) bool {
+				if round == 0 {
+					panic("proposer lookup failed")
+				}
+
+				return false
+			},
+		}
+	)
+
+	i := NewIBFT(mockLogger{}, backend, mockTransport{})
+	sub := i.SubscribeEvents()
+
+	defer i.UnsubscribeEvents(sub.ID)
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	sequenceDone := make(chan struct{})
+
+	go func() {
+		defer close(sequenceDone)
+
+		i.RunSequence(ctx, height)
+	}()
+
+	// Make sure the node moves to the next round because of the failure
+	var data RoundChangeData
+
+	for data.Reason == "" {
+		select {
+		case event := <-sub.EventCh:
+			if event.Type == EventRoundChange {
+				data, _ = event.Data.(RoundChangeData)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("round not changed")
+		}
+	}
+
+	cancelFn()
+	<-sequenceDone
+
+	assert.Equal(t, RoundChangeFailure, data.Reason)
+	assert.Equal(t, uint64(0), data.PreviousRound)
+	assert.Equal(t, uint64(1), i.state.getRound())
+}
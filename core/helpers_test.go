@@ -233,7 +233,7 @@ func (c *cluster) gossip(msg *proto.Message) {
 }
 
 func (c *cluster) maxFaulty() uint64 {
-	return (uint64(len(c.nodes)) - 1) / 3
+	return MaxFaulty(uint64(len(c.nodes)))
 }
 
 func (c *cluster) makeNByzantine(num int) {
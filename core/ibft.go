@@ -3,8 +3,9 @@ package core
 import (
 	"bytes"
 	"context"
-	"math"
+	"errors"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/renloi/ibft/messages"
@@ -22,9 +23,9 @@ type Logger interface {
 type Messages interface {
 	// Messages modifiers //
 	AddMessage(message *proto.Message)
+	AddMessages(messages []*proto.Message)
 	PruneByHeight(height uint64)
-
-	SignalEvent(message *proto.Message)
+	PruneByRound(height, round uint64)
 
 	// Messages fetchers //
 	GetValidMessages(
@@ -32,21 +33,57 @@ type Messages interface {
 		messageType proto.MessageType,
 		isValid func(*proto.Message) bool,
 	) []*proto.Message
+	ScanValidMessages(
+		view *proto.View,
+		messageType proto.MessageType,
+		isValid func(*proto.Message) bool,
+		visit func(*proto.Message) bool,
+	)
 	GetExtendedRCC(
 		height uint64,
 		isValidMessage func(message *proto.Message) bool,
 		isValidRCC func(round uint64, msgs []*proto.Message) bool,
 	) []*proto.Message
 	GetMostRoundChangeMessages(minRound, height uint64) []*proto.Message
+	GetMessages(
+		view *proto.View,
+		messageType proto.MessageType,
+		since uint64,
+	) ([]*proto.Message, uint64)
 
 	// Messages subscription handlers //
-	Subscribe(details messages.SubscriptionDetails) *messages.Subscription
+	Subscribe(ctx context.Context, details messages.SubscriptionDetails) *messages.Subscription
 	Unsubscribe(id messages.SubscriptionID)
+
+	// Messages introspection //
+	NumSubscriptions() int
+	ViewCounts() []messages.ViewCount
+	Len() int
+	OldestHeight() (uint64, bool)
+	Stats() []messages.RoundStats
+	Query(query messages.MessageQuery) []*proto.Message
+	HighestRound(height uint64) (uint64, bool)
 }
 
 const (
 	round0Timeout   = 10 * time.Second
 	roundFactorBase = float64(2)
+
+	// minRebroadcastInterval is the minimum time between
+	// two automatic rebroadcasts of the latest sent message
+	minRebroadcastInterval = time.Second
+
+	// defaultUnavailableProposerTimeout is the shortened round timeout
+	// after the round proposer announces it is unavailable
+	defaultUnavailableProposerTimeout = time.Second
+
+	// defaultCommitRebroadcastFraction is the default fraction of the round timeout
+	// after which the COMMIT message is rebroadcasted, if the commit quorum is not observed
+	defaultCommitRebroadcastFraction = 0.25
+
+	// defaultCommitRebroadcastRetries is the default maximum
+	// number of COMMIT rebroadcasts in a round
+	defaultCommitRebroadcastRetries = 2
 )
 
 // IBFT represents a single instance of the IBFT state machine
@@ -68,33 +105,219 @@ type IBFT struct {
 	// Transport implementation
 	transport Transport
 
-	// roundDone is the channel used for signalizing
-	// consensus finalization upon a certain sequence
-	roundDone chan struct{}
+	// roundEvents is the channel used by the round workers for signalizing
+	// the events that end the round (finalization, round expiry,
+	// future proposals and RCCs)
+	roundEvents chan roundEvent
 
-	// roundExpired is the channel used for signalizing
-	// round changing events
-	roundExpired chan struct{}
+	// roundHint is the channel used for signalizing
+	// when the sync layer learns of a higher active round
+	// for the current height
+	roundHint chan *proto.View
 
-	// newProposal is the channel used for signalizing
-	// when new proposals for a view greater than the current
-	// one arrive
-	newProposal chan newProposalEvent
+	// unavailableProposer is the channel used for signalizing
+	// when the proposer of a round announces it is unavailable
+	unavailableProposer chan *proto.View
 
-	// roundCertificate is the channel used for signalizing
-	// when a valid RCC for a greater round than the current
-	// one is present
-	roundCertificate chan uint64
+	// unavailableProposerTimeout is the shortened round timeout
+	// after the round proposer announces it is unavailable
+	unavailableProposerTimeout time.Duration
 
-	//	User configured additional timeout for each round of consensus
-	additionalTimeout time.Duration
+	// timeoutsLock guards the round timeout settings tunable at runtime
+	// (the base round timeout, the max round and the timeout strategy)
+	timeoutsLock sync.RWMutex
 
 	// baseRoundTimeout is the base round timeout for each round of consensus
 	baseRoundTimeout time.Duration
 
-	// wg is a simple barrier used for synchronizing
-	// state modification routines
-	wg sync.WaitGroup
+	// maxRoundTimeout is the ceiling for the round timeout, if set
+	maxRoundTimeout time.Duration
+
+	// maxRound is the round the round timeout stops growing at, if set
+	maxRound uint64
+
+	// timeoutStrategy determines the timeout for each round
+	timeoutStrategy TimeoutStrategy
+
+	// tuning are the round timeout settings staged at runtime
+	tuning timeoutTuning
+
+	// roundTimer signals the round expiry
+	roundTimer RoundTimer
+
+	// latency is the estimator of the time it takes
+	// to reach quorum after a local multicast
+	latency *LatencyEstimator
+
+	// phases times the phases of the current round (see phaseKey)
+	phases *phaseTimer
+
+	// events is the bus for emitting consensus events
+	events *eventBus
+
+	// duplicateProposal is the channel used for signalizing
+	// when a duplicate proposal for the current view arrives
+	duplicateProposal chan struct{}
+
+	// proposalReplies is the channel used for signalizing the proposer
+	// of a duplicate proposal, to reply to with the node's messages for the view
+	proposalReplies chan []byte
+
+	// rebroadcastLock guards the time of the last automatic rebroadcast
+	rebroadcastLock sync.Mutex
+	lastRebroadcast time.Time
+
+	// roundChangeThrottle rate-limits the ROUND_CHANGE rebroadcasts
+	roundChangeThrottle *roundChangeThrottle
+
+	// retryPolicy is the policy for retrying failed multicasts
+	retryPolicy RetryPolicy
+
+	// metrics is the sink for the engine metrics
+	metrics Metrics
+
+	// peerReporter reports the offenses of the message senders
+	peerReporter PeerReporter
+
+	// messageTTL is the relay hop limit for multicasted messages, if set
+	messageTTL uint32
+
+	// codec decodes the raw messages received from the network
+	codec messages.Codec
+
+	// envelopeVerifier verifies the envelopes of the raw messages, if set
+	envelopeVerifier messages.EnvelopeVerifier
+
+	// epochLength is the number of heights in an epoch, if set
+	epochLength uint64
+
+	// epoch is the current epoch, if epochs are configured.
+	// It is only accessed from the sequence routine
+	epoch *Epoch
+
+	// quorum determines if the messages reach quorum
+	quorum QuorumVerifier
+
+	// quorumMemo memoizes the views and message types that reached quorum
+	quorumMemo *quorumMemo
+
+	// receptionWakeup wakes the PREPARE and COMMIT receptions
+	// once a proposal is accepted (see wakeReceptions)
+	receptionWakeup *wakeup
+
+	// nilProposals is the flag indicating if
+	// explicit NIL proposals are built and accepted
+	nilProposals bool
+
+	// keys are the rotated validator keys
+	keys *keyRegistry
+
+	// proposalInjection is the flag indicating if
+	// proposals can be injected using InjectProposal
+	proposalInjection bool
+
+	// workerStopTimeout is the time the round workers
+	// are given to stop, once the round is torn down
+	workerStopTimeout time.Duration
+
+	// proposers are the records of the failed rounds of each proposer
+	proposers *proposerTracker
+
+	// messageOptions are the options of the message store
+	messageOptions []messages.Option
+
+	// rejections samples the rejected incoming messages for logging
+	rejections *rejectionSampler
+
+	// clockSkewTolerance is the allowed skew of the message timestamps
+	// ahead of the local clock. A zero tolerance disables the timestamp check
+	clockSkewTolerance time.Duration
+
+	// clock returns the local time the message timestamps are checked against
+	clock func() time.Time
+
+	// ignored are the senders whose messages are ignored
+	ignored *ignoreSet
+
+	// viewGossipInterval is the time between two periodic CURRENT_VIEW multicasts.
+	// A zero interval disables the periodic gossip
+	viewGossipInterval time.Duration
+
+	// viewRequests is the channel used for signalizing
+	// when a validator lagging behind announces its view
+	viewRequests chan struct{}
+
+	// peerViews are the views announced by the validators
+	peerViews *peerViews
+
+	// commitRebroadcastFraction is the fraction of the round timeout after which
+	// the COMMIT message is rebroadcasted, if the commit quorum is not observed.
+	// A zero fraction disables the rebroadcasts
+	commitRebroadcastFraction float64
+
+	// commitRebroadcastRetries is the maximum number of COMMIT rebroadcasts in a round
+	commitRebroadcastRetries uint
+
+	// trustVerifiedMessages is the flag indicating if the signatures of the messages
+	// verified by the network layer (see AddVerifiedMessage) are not verified again
+	trustVerifiedMessages bool
+
+	// finality is the channel used for signalizing
+	// when a valid finality proof for the current height is received
+	finality chan roundEvent
+
+	// finalityProof is the proof of the latest height finalized by the node
+	finalityProof atomic.Pointer[proto.Message]
+
+	// hashMismatches rate-limits the reports of the proposal hash mismatches
+	hashMismatches *hashMismatchLimiter
+
+	// vetoes is the flag indicating if the invalid proposals
+	// are explicitly rejected using VETO messages
+	vetoes bool
+
+	// finalityGadget is the external finality layer confirming
+	// the anchoring of the committed heights, if set
+	finalityGadget FinalityGadget
+
+	// finalityStatus tracks the finality status of the committed heights
+	finalityStatus *finalityTracker
+
+	// sequenceDeadline is the maximum duration of a sequence,
+	// after which it is aborted. A zero deadline disables it
+	sequenceDeadline time.Duration
+
+	// sequenceRunning is the flag indicating if a sequence is running,
+	// which guards against overlapping RunSequence calls
+	sequenceRunning atomic.Bool
+
+	// finalizedHeight is the highest height finalized by the node, plus one,
+	// so zero means no height was finalized yet
+	finalizedHeight atomic.Uint64
+
+	// heightRegressionCheck is the flag indicating if the sequences
+	// of the already finalized heights are refused
+	heightRegressionCheck bool
+
+	// roundPruning is the flag indicating if the round-scoped messages
+	// of the lower rounds are pruned after round changes (see WithoutRoundPruning)
+	roundPruning bool
+
+	// observing is the flag indicating if the node only observes the consensus,
+	// as the backend reported it should not participate (see ParticipationGate)
+	observing atomic.Bool
+
+	// relayMode is the flag indicating if the node is not a validator,
+	// and only handles the messages of the validators (see WithRelayMode)
+	relayMode bool
+
+	// reproposalBoost is the flag indicating if the holders of the highest PC
+	// may propose it again after a failed round (see WithReproposalBoost)
+	reproposalBoost bool
+
+	// sentries are the transport peers the node talks to exclusively,
+	// if the node is behind sentries (see WithSentries)
+	sentries [][]byte
 }
 
 // NewIBFT creates a new instance of the IBFT consensus protocol
@@ -102,90 +325,222 @@ func NewIBFT(
 	log Logger,
 	backend Backend,
 	transport Transport,
+	opts ...Option,
 ) *IBFT {
-	return &IBFT{
-		log:              log,
-		backend:          backend,
-		transport:        transport,
-		messages:         messages.NewMessages(),
-		roundDone:        make(chan struct{}),
-		roundExpired:     make(chan struct{}),
-		newProposal:      make(chan newProposalEvent),
-		roundCertificate: make(chan uint64),
-		state: &state{
-			view: &proto.View{
-				Height: 0,
-				Round:  0,
-			},
-			seals:        make([]*messages.CommittedSeal, 0),
-			roundStarted: false,
-			commitSent:   false,
-		},
+	i := &IBFT{
+		log:         log,
+		backend:     backend,
+		transport:   transport,
+		roundEvents: make(chan roundEvent, roundEventsBufferSize),
+		roundHint:   make(chan *proto.View, 1),
+
+		duplicateProposal:          make(chan struct{}, 1),
+		proposalReplies:            make(chan []byte, 1),
+		viewRequests:               make(chan struct{}, 1),
+		finality:                   make(chan roundEvent, 1),
+		unavailableProposer:        make(chan *proto.View, 1),
+		unavailableProposerTimeout: defaultUnavailableProposerTimeout,
+		workerStopTimeout:          defaultWorkerStopTimeout,
+
+		state:            newState(0),
 		baseRoundTimeout: round0Timeout,
-	}
+		timeoutStrategy:  ExponentialTimeout{},
+		roundTimer:       WallClockTimer{},
+		latency:          NewLatencyEstimator(defaultLatencySmoothing),
+		phases:           newPhaseTimer(),
+		events:           newEventBus(),
+		metrics:          nopMetrics{},
+		peerReporter:     nopPeerReporter{},
+		codec:            messages.ProtoCodec{},
+		keys:             newKeyRegistry(),
+		quorum:           backendQuorum{backend},
+		quorumMemo:       newQuorumMemo(),
+		receptionWakeup:  newWakeup(),
+		proposers:        newProposerTracker(),
+
+		roundChangeThrottle: newRoundChangeThrottle(
+			defaultRoundChangeRebroadcastInterval,
+			defaultRoundChangeBackoffLimit,
+		),
+		rejections:         newRejectionSampler(0),
+		clock:              time.Now,
+		ignored:            newIgnoreSet(),
+		viewGossipInterval: defaultViewGossipInterval,
+		peerViews:          newPeerViews(),
+		hashMismatches:     newHashMismatchLimiter(defaultHashMismatchReportInterval),
+		finalityStatus:     newFinalityTracker(),
+
+		commitRebroadcastFraction: defaultCommitRebroadcastFraction,
+		commitRebroadcastRetries:  defaultCommitRebroadcastRetries,
+		trustVerifiedMessages:     true,
+		heightRegressionCheck:     true,
+		roundPruning:              true,
+	}
+
+	for _, opt := range opts {
+		opt(i)
+	}
+
+	i.messages = messages.NewMessages(
+		append(
+			i.messageOptions,
+			messages.WithEquivocationHandler(i.handleEquivocation),
+			messages.WithQuorumFn(i.reachesQuorum),
+		)...,
+	)
+
+	return i
 }
 
-// startRoundTimer starts the exponential round timer, based on the
-// passed in round number
+// startRoundTimer starts the round timer, with the timeout based
+// on the passed in round number
 func (i *IBFT) startRoundTimer(ctx context.Context, round uint64) {
-	defer i.wg.Done()
-
-	roundTimeout := getRoundTimeout(i.baseRoundTimeout, i.additionalTimeout, round)
+	var (
+		view = &proto.View{
+			Height: i.state.getHeight(),
+			Round:  round,
+		}
 
-	//	Create a new timer instance
-	timer := time.NewTimer(roundTimeout)
+		roundTimeout = i.roundTimeout(round)
+	)
 
-	select {
-	case <-ctx.Done():
-		// Stop signal received, stop the timer
-		timer.Stop()
-	case <-timer.C:
+	if i.roundTimer.Wait(ctx, view, roundTimeout) {
 		// Timer expired, alert the round change channel to move
 		// to the next round
 		i.signalRoundExpired(ctx)
 	}
 }
 
-// signalRoundExpired notifies the sequence routine (RunSequence) that it
-// should move to a new round. The quit channel is used to abort this call
-// if another routine has already signaled a round change request.
-func (i *IBFT) signalRoundExpired(ctx context.Context) {
+// watchForUnavailableProposer expires the round after the shortened
+// timeout, if the proposer of the round announces it is unavailable
+func (i *IBFT) watchForUnavailableProposer(ctx context.Context) {
+	view := i.state.getView()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case unavailable := <-i.unavailableProposer:
+			if unavailable.Height != view.Height || unavailable.Round != view.Round {
+				// The announcement is stale
+				continue
+			}
+
+			i.log.Info("proposer unavailable, shortening round", "round", view.Round)
+
+			// The shortened round is expired by the configured round timer,
+			// so timers that are not driven by the wall clock stay in control
+			if i.roundTimer.Wait(ctx, view, i.unavailableProposerTimeout) {
+				i.signalRoundExpired(ctx)
+			}
+
+			return
+		}
+	}
+}
+
+// roundEventsBufferSize is the capacity of the round event channel.
+// Each round worker signals at most one event per round, so the workers
+// never block on signaling, and the events signaled concurrently
+// are not lost when the round is torn down
+const roundEventsBufferSize = 8
+
+// roundEventType is the type of the event that ends the round.
+// Types are ordered by priority, when multiple events are signaled
+// in the same round, the one with the highest priority is handled
+type roundEventType uint8
+
+const (
+	// roundEventFailed is the failure of a round worker
+	roundEventFailed roundEventType = iota
+
+	// roundEventExpired is the expiry of the round
+	roundEventExpired
+
+	// roundEventVeto is the rejection of the round proposal
+	// by a rejection quorum of validators
+	roundEventVeto
+
+	// roundEventHint is a round hint for a higher round
+	roundEventHint
+
+	// roundEventCertificate is a valid RCC for a higher round
+	roundEventCertificate
+
+	// roundEventProposal is a valid proposal for a higher round
+	roundEventProposal
+
+	// roundEventFinality is a valid finality proof for the height,
+	// served by a node that finalized it
+	roundEventFinality
+
+	// roundEventDone is the finalization of the sequence
+	roundEventDone
+)
+
+// roundEvent is the event that ends the round
+type roundEvent struct {
+	eventType roundEventType
+
+	// round is the higher round of a hint, a proposal or an RCC
+	round uint64
+
+	// proposalMessage is the proposal for the higher round,
+	// or the FINALITY message of a finality proof
+	proposalMessage *proto.Message
+
+	// committedSeals are the verified committed seals of a finality proof
+	committedSeals []*messages.CommittedSeal
+}
+
+// outranks checks if the event has a higher priority than the other event.
+// Of the events of the same type, the one for the higher round has priority
+func (e roundEvent) outranks(other roundEvent) bool {
+	if e.eventType != other.eventType {
+		return e.eventType > other.eventType
+	}
+
+	return e.round > other.round
+}
+
+// signalRoundEvent notifies the sequence routine (RunSequence)
+// of the event that ends the round
+func (i *IBFT) signalRoundEvent(ctx context.Context, event roundEvent) {
 	select {
-	case i.roundExpired <- struct{}{}:
+	case i.roundEvents <- event:
 	case <-ctx.Done():
 	}
 }
 
+// signalRoundExpired notifies the sequence routine (RunSequence) that it
+// should move to a new round
+func (i *IBFT) signalRoundExpired(ctx context.Context) {
+	i.signalRoundEvent(ctx, roundEvent{eventType: roundEventExpired})
+}
+
 // signalRoundDone notifies the sequence routine (RunSequence) that the
 // consensus sequence is finished
 func (i *IBFT) signalRoundDone(ctx context.Context) {
-	select {
-	case i.roundDone <- struct{}{}:
-	case <-ctx.Done():
-	}
+	i.signalRoundEvent(ctx, roundEvent{eventType: roundEventDone})
 }
 
 // signalNewRCC notifies the sequence routine (RunSequence) that
 // a valid Round Change Certificate for a higher round appeared
 func (i *IBFT) signalNewRCC(ctx context.Context, round uint64) {
-	select {
-	case i.roundCertificate <- round:
-	case <-ctx.Done():
-	}
-}
-
-type newProposalEvent struct {
-	proposalMessage *proto.Message
-	round           uint64
+	i.signalRoundEvent(ctx, roundEvent{
+		eventType: roundEventCertificate,
+		round:     round,
+	})
 }
 
 // signalNewProposal notifies the sequence routine (RunSequence) that
 // a valid proposal for a higher round appeared
-func (i *IBFT) signalNewProposal(ctx context.Context, event newProposalEvent) {
-	select {
-	case i.newProposal <- event:
-	case <-ctx.Done():
-	}
+func (i *IBFT) signalNewProposal(ctx context.Context, proposalMessage *proto.Message, round uint64) {
+	i.signalRoundEvent(ctx, roundEvent{
+		eventType:       roundEventProposal,
+		round:           round,
+		proposalMessage: proposalMessage,
+	})
 }
 
 // watchForFutureProposal listens for new proposal messages
@@ -197,6 +552,7 @@ func (i *IBFT) watchForFutureProposal(ctx context.Context) {
 		nextRound = view.Round + 1
 
 		sub = i.messages.Subscribe(
+			ctx,
 			messages.SubscriptionDetails{
 				MessageType: proto.MessageType_PREPREPARE,
 				View: &proto.View{
@@ -204,31 +560,31 @@ func (i *IBFT) watchForFutureProposal(ctx context.Context) {
 					Round:  nextRound,
 				},
 				HasMinRound: true,
-				HasQuorumFn: i.backend.HasQuorum,
+				HasQuorumFn: i.quorum.HasQuorum,
 			})
 	)
 
-	defer func() {
-		i.messages.Unsubscribe(sub.ID)
-
-		i.wg.Done()
-	}()
-
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case round := <-sub.SubCh:
-			proposal := i.handlePrePrepare(&proto.View{Height: height, Round: round})
-			if proposal == nil {
+		case round, ok := <-sub.SubCh:
+			if !ok {
+				// The subscription was removed
+				return
+			}
+
+			proposalView := &proto.View{Height: height, Round: round}
+
+			// Only proposals that are fully valid for their round are signaled,
+			// rejected proposals would be signaled again once resubscribed
+			proposal := i.handlePrePrepare(proposalView)
+			if proposal == nil || !i.validateProposalMessage(proposal, proposalView) {
 				continue
 			}
 
 			// Extract the proposal
-			i.signalNewProposal(
-				ctx,
-				newProposalEvent{proposal, round},
-			)
+			i.signalNewProposal(ctx, proposal, round)
 
 			return
 		}
@@ -239,14 +595,12 @@ func (i *IBFT) watchForFutureProposal(ctx context.Context) {
 // for future valid Round Change Certificates that could
 // trigger a round hop
 func (i *IBFT) watchForRoundChangeCertificates(ctx context.Context) {
-	defer i.wg.Done()
-
 	var (
 		view   = i.state.getView()
 		height = view.Height
 		round  = view.Round
 
-		sub = i.messages.Subscribe(messages.SubscriptionDetails{
+		sub = i.messages.Subscribe(ctx, messages.SubscriptionDetails{
 			MessageType: proto.MessageType_ROUND_CHANGE,
 			View: &proto.View{
 				Height: height,
@@ -259,13 +613,16 @@ func (i *IBFT) watchForRoundChangeCertificates(ctx context.Context) {
 		})
 	)
 
-	defer i.messages.Unsubscribe(sub.ID)
-
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-sub.SubCh:
+		case _, ok := <-sub.SubCh:
+			if !ok {
+				// The subscription was removed
+				return
+			}
+
 			rcc := i.handleRoundChangeMessage(
 				&proto.View{
 					Height: height,
@@ -286,111 +643,374 @@ func (i *IBFT) watchForRoundChangeCertificates(ctx context.Context) {
 	}
 }
 
-// RunSequence runs the IBFT sequence for the specified height
-func (i *IBFT) RunSequence(ctx context.Context, h uint64) {
+// RunSequence runs the IBFT sequence for the specified height.
+// It returns once the height is finalized, the context is cancelled,
+// or the sequence deadline passes, along with the reason it ended and the phase
+// the round was in. Only one sequence can run at a time; an overlapping call,
+// for any height, returns ErrSequenceRunning without touching the running sequence.
+// A call for a height at or below the highest height finalized by the node
+// returns ErrHeightFinalized, unless the check is disabled (see WithoutHeightRegressionCheck)
+func (i *IBFT) RunSequence(ctx context.Context, h uint64) (SequenceResult, error) {
+	if !i.sequenceRunning.CompareAndSwap(false, true) {
+		i.log.Error("sequence already running", "height", h)
+
+		return SequenceResult{}, ErrSequenceRunning
+	}
+
+	defer i.sequenceRunning.Store(false)
+
+	if finalized, ok := i.HighestFinalizedHeight(); ok && h <= finalized && i.heightRegressionCheck {
+		i.log.Error("sequence height already finalized", "height", h, "finalized", finalized)
+
+		return SequenceResult{}, ErrHeightFinalized
+	}
+
+	result := i.runSequence(ctx, h)
+	if result.Finalized() {
+		i.recordFinalizedHeight(h)
+	}
+
+	return result, nil
+}
+
+// runSequence runs the IBFT sequence for the specified height
+func (i *IBFT) runSequence(ctx context.Context, h uint64) SequenceResult {
 	// Set the starting state data
 	i.state.clear(h)
-	i.messages.PruneByHeight(h)
+	i.enterEpoch(h)
+	i.messages.PruneByHeight(i.pruneHeight(h))
+	i.quorumMemo.prune(h)
+	i.keys.prune(h)
+	i.proposers.reset()
+
+	// Drop any round hint left over from the previous height
+	select {
+	case <-i.roundHint:
+	default:
+	}
+
+	// Drop any unavailability announcement left over from the previous height
+	select {
+	case <-i.unavailableProposer:
+	default:
+	}
+
+	// Drop any duplicate proposal left over from the previous height
+	select {
+	case <-i.duplicateProposal:
+	default:
+	}
+
+	// Drop any proposal reply left over from the previous height
+	select {
+	case <-i.proposalReplies:
+	default:
+	}
+
+	// Drop any view request left over from the previous height
+	select {
+	case <-i.viewRequests:
+	default:
+	}
+
+	// Drop any finality proof left over from the previous height
+	select {
+	case <-i.finality:
+	default:
+	}
 
 	i.log.Info("sequence started", "height", h)
 	defer i.log.Info("sequence done", "height", h)
 
+	// Resume the height, if the node crashed during it
+	i.restoreRecoveryState(ctx, h)
+
+	// Abort the sequence once its deadline passes, so the node can switch to block sync
+	var deadline <-chan time.Time
+
+	if i.sequenceDeadline > 0 {
+		deadlineTimer := time.NewTimer(i.sequenceDeadline)
+		defer deadlineTimer.Stop()
+
+		deadline = deadlineTimer.C
+	}
+
 	for {
 		view := i.state.getView()
 
+		// Apply the round timeouts tuned at runtime
+		i.applyTuning()
+
+		// Observe the round, if the node should not participate
+		i.updateParticipation(h)
+
 		i.log.Info("round started", "round", view.Round)
+		i.emitEvent(EventRoundStarted, view, nil)
 
 		currentRound := view.Round
-		ctxRound, cancelRound := context.WithCancel(ctx)
-
-		i.wg.Add(4)
+		group := newWorkerGroup(withViewLabels(ctx, view))
 
 		// Start the round timer worker
-		go i.startRoundTimer(ctxRound, currentRound)
+		group.spawn("round timer", func(ctx context.Context) {
+			i.startRoundTimer(ctx, currentRound)
+		})
+
+		//	Shorten the round if the proposer is unavailable
+		group.spawn("unavailable proposer", i.watchForUnavailableProposer)
+
+		//	Skip the round if its proposal is vetoed
+		if i.vetoes {
+			group.spawn("vetoes", i.watchForVetoes)
+		}
+
+		//	Rebroadcast on duplicate proposals
+		group.spawn("duplicate proposals", i.watchForDuplicateProposals)
+
+		//	Gossip the view, if the backend announces it
+		if _, ok := i.backend.(ViewAnnouncer); ok {
+			group.spawn("view gossip", i.gossipView)
+		}
 
 		//	Jump round on proposals from higher rounds
-		go i.watchForFutureProposal(ctxRound)
+		group.spawn("future proposal", i.watchForFutureProposal)
 
 		//	Jump round on certificates
-		go i.watchForRoundChangeCertificates(ctxRound)
+		group.spawn("round change certificates", i.watchForRoundChangeCertificates)
 
 		// Start the state machine worker
-		go i.startRound(ctxRound)
+		group.run("state machine", i.startRound)
 
 		teardown := func() {
-			cancelRound()
-			i.wg.Wait()
+			if err := group.stop(i.workerStopTimeout); err != nil {
+				i.logWorkerFailure(currentRound, err)
+			}
 		}
 
-		select {
-		case ev := <-i.newProposal:
-			teardown()
-			i.log.Info("received future proposal", "round", ev.round)
+		// Wait for the event that ends the round. Rejected events
+		// keep the current round running
+		var event roundEvent
+
+		for {
+			select {
+			case event = <-i.roundEvents:
+				if event.eventType == roundEventProposal && !i.isValidFutureProposal(h, event) {
+					// Keep watching for future proposals
+					group.spawn("future proposal", i.watchForFutureProposal)
+
+					continue
+				}
+			case hint := <-i.roundHint:
+				if hint.Height != h || hint.Round <= currentRound {
+					// The hint is stale, keep running the current round
+					continue
+				}
+
+				event = roundEvent{
+					eventType: roundEventHint,
+					round:     hint.Round,
+				}
+			case event = <-i.finality:
+				if event.proposalMessage.View.Height != h {
+					// The proof is stale, keep running the current round
+					continue
+				}
+			case <-group.failed():
+				// A failed worker leaves the round without one of its phases,
+				// so the round is abandoned in favor of the next one
+				event = roundEvent{eventType: roundEventFailed}
+			case <-ctx.Done():
+				teardown()
+				i.log.Debug("sequence cancelled")
+				i.dropRoundEvents()
+
+				return i.endSequence(SequenceCancelled)
+			case <-deadline:
+				teardown()
+				i.log.Info("sequence deadline exceeded", "height", h, "round", currentRound)
+				i.dropRoundEvents()
+
+				return i.endSequence(SequenceDeadlineExceeded)
+			}
+
+			// The round is over, move on to the next one
+			break
+		}
 
-			i.moveToNewRound(ev.round)
-			i.acceptProposal(ev.proposalMessage)
+		// Stop all running worker threads, and handle the event
+		// with the highest priority of the ones they signaled
+		teardown()
+
+		event = i.prioritizeRoundEvent(h, event)
+
+		switch event.eventType {
+		case roundEventProposal:
+			i.log.Info("received future proposal", "round", event.round)
+
+			i.moveToNewRound(event.round)
+			i.emitRoundChange(currentRound, RoundChangeProposal)
+			i.acceptProposal(event.proposalMessage)
 			i.state.setRoundStarted(true)
-			i.sendPrepareMessage(view)
-		case round := <-i.roundCertificate:
-			teardown()
-			i.log.Info("received future RCC", "round", round)
-
-			i.moveToNewRound(round)
-		case <-i.roundExpired:
-			teardown()
+			i.sendPrepareMessage(ctx, &proto.View{
+				Height: h,
+				Round:  event.round,
+			})
+		case roundEventCertificate:
+			i.log.Info("received future RCC", "round", event.round)
+
+			i.moveToNewRound(event.round)
+			i.emitRoundChange(currentRound, RoundChangeCertificate)
+		case roundEventHint:
+			i.log.Info("received round hint", "round", event.round)
+
+			i.moveToNewRound(event.round)
+			i.emitRoundChange(currentRound, RoundChangeHint)
+
+			i.sendRoundChangeMessage(ctx, h, event.round)
+		case roundEventExpired:
 			i.log.Info("round timeout expired", "round", currentRound)
 
+			i.recordRoundTimeout(h, currentRound)
+
 			newRound := currentRound + 1
 			i.moveToNewRound(newRound)
+			i.emitRoundChange(currentRound, RoundChangeTimeout)
 
-			i.sendRoundChangeMessage(h, newRound)
-		case <-i.roundDone:
-			// The consensus cycle for the block height is finished.
-			// Stop all running worker threads
-			teardown()
+			i.sendRoundChangeMessage(ctx, h, newRound)
+		case roundEventVeto:
+			newRound := currentRound + 1
+			i.moveToNewRound(newRound)
+			i.emitRoundChange(currentRound, RoundChangeVeto)
 
-			return
-		case <-ctxRound.Done():
-			teardown()
-			i.log.Debug("sequence cancelled")
+			i.sendRoundChangeMessage(ctx, h, newRound)
+		case roundEventFailed:
+			newRound := currentRound + 1
+			i.moveToNewRound(newRound)
+			i.emitRoundChange(currentRound, RoundChangeFailure)
 
-			return
+			i.sendRoundChangeMessage(ctx, h, newRound)
+		case roundEventFinality:
+			if i.finalizeWithProof(event) {
+				i.recordFinalization(h, event.round)
+				finalityData, _ := messages.ExtractPayload[*proto.FinalityMessage](event.proposalMessage)
+				i.markCommitted(h, finalityData.GetProposalHash())
+
+				return i.endSequence(SequenceFinalized)
+			}
+
+			// The proposal of the proof could not be inserted,
+			// so the round is abandoned in favor of the next one
+			newRound := currentRound + 1
+			i.moveToNewRound(newRound)
+			i.emitRoundChange(currentRound, RoundChangeFailure)
+
+			i.sendRoundChangeMessage(ctx, h, newRound)
+		case roundEventDone:
+			// The consensus cycle for the block height is finished
+			i.recordFinalization(h, currentRound)
+			i.recordFinalityProof()
+			i.markCommitted(h, i.state.getProposalHash())
+
+			return i.endSequence(SequenceFinalized)
 		}
 	}
 }
 
-// startRound runs the state machine loop for the current round
-func (i *IBFT) startRound(ctx context.Context) {
-	// Register this worker thread with the barrier
-	defer i.wg.Done()
+// dropRoundEvents drops the events signaled by the stopped round workers
+func (i *IBFT) dropRoundEvents() {
+	for len(i.roundEvents) > 0 {
+		<-i.roundEvents
+	}
+}
+
+// isValidFutureProposal checks if the signaled future proposal
+// is valid for the round it moves the node to
+func (i *IBFT) isValidFutureProposal(height uint64, event roundEvent) bool {
+	// The proposal was filtered by the future proposal worker,
+	// but it needs to be valid for the round it moves the node to
+	if !i.validateProposalMessage(event.proposalMessage, &proto.View{
+		Height: height,
+		Round:  event.round,
+	}) {
+		i.log.Error("future proposal is not valid for the new round", "round", event.round)
+
+		return false
+	}
+
+	return true
+}
+
+// prioritizeRoundEvent returns the event with the highest priority,
+// of the passed in event and the events signaled by the stopped round workers
+func (i *IBFT) prioritizeRoundEvent(height uint64, event roundEvent) roundEvent {
+	for {
+		select {
+		case pending := <-i.roundEvents:
+			if !pending.outranks(event) {
+				continue
+			}
+
+			if pending.eventType == roundEventProposal && !i.isValidFutureProposal(height, pending) {
+				continue
+			}
+
+			event = pending
+		default:
+			return event
+		}
+	}
+}
 
+// startRound runs the state machine loop for the current round.
+// The failure of a message reception worker is returned
+func (i *IBFT) startRound(ctx context.Context) error {
 	i.state.newRound()
+	i.startRoundPhases()
 
 	var (
 		id   = i.backend.ID()
 		view = i.state.getView()
 	)
 
-	// Check if any block needs to be proposed
-	if i.backend.IsProposer(id, view.Height, view.Round) {
+	isProposer := i.isProposer(id, view.Height, view.Round)
+	ctx = withRoleLabel(ctx, isProposer)
+
+	// Check if any block needs to be proposed. A proposal restored
+	// after a crash is not built again, as the node already proposed it.
+	// An observing node does not propose, so the round times out
+	if isProposer && !i.state.hasProposalMessage() && !i.isObserving() {
 		i.log.Info("we are the proposer")
 
+		// Announce the proposer duty cannot be fulfilled, if the backend detects it
+		if availability, ok := i.backend.(ProposerAvailability); ok &&
+			!availability.IsProposerAvailable(view) {
+			i.log.Info("proposer unavailable, skipping proposal")
+
+			i.multicast(ctx, availability.BuildProposerUnavailableMessage(view))
+			i.signalUnavailableProposer(view)
+
+			return nil
+		}
+
+		buildStart := time.Now()
+
 		proposalMessage := i.buildProposal(ctx, view)
 		if proposalMessage == nil {
 			i.log.Error("unable to build proposal")
 
-			return
+			return nil
 		}
 
+		i.metrics.MeasureSince(proposalBuildKey, buildStart)
+
 		i.acceptProposal(proposalMessage)
 		i.log.Debug("block proposal accepted")
 
-		i.sendPreprepareMessage(proposalMessage)
+		i.sendPreprepareMessage(ctx, proposalMessage)
 
 		i.log.Debug("pre-prepare message multicasted")
 	}
 
-	i.runReceptions(ctx)
+	return i.runReceptions(ctx)
 }
 
 // waitForRCC waits for valid RCC for the specified height and round
@@ -399,6 +1019,11 @@ func (i *IBFT) waitForRCC(
 	height,
 	round uint64,
 ) *proto.RoundChangeCertificate {
+	// The subscription is removed once the RCC is found,
+	// as the worker keeps running to build the proposal
+	ctx, cancelFn := context.WithCancel(ctx)
+	defer cancelFn()
+
 	var (
 		view = &proto.View{
 			Height: height,
@@ -406,21 +1031,25 @@ func (i *IBFT) waitForRCC(
 		}
 
 		sub = i.messages.Subscribe(
+			ctx,
 			messages.SubscriptionDetails{
 				MessageType: proto.MessageType_ROUND_CHANGE,
 				View:        view,
-				HasQuorumFn: i.backend.HasQuorum,
+				HasQuorumFn: i.quorum.HasQuorum,
 			},
 		)
 	)
 
-	defer i.messages.Unsubscribe(sub.ID)
-
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
-		case <-sub.SubCh:
+		case _, ok := <-sub.SubCh:
+			if !ok {
+				// The subscription was removed
+				return nil
+			}
+
 			rcc := i.handleRoundChangeMessage(view)
 			if rcc == nil {
 				continue
@@ -436,20 +1065,32 @@ func (i *IBFT) waitForRCC(
 func (i *IBFT) handleRoundChangeMessage(view *proto.View) *proto.RoundChangeCertificate {
 	var (
 		height              = view.Height
-		hasAcceptedProposal = i.state.getProposal() != nil
+		hasAcceptedProposal = i.state.hasProposalMessage()
+
+		// The ROUND CHANGE messages usually carry the same PC
+		cache = newValidatorCache(i.isValidValidator)
 	)
 
 	isValidMsgFn := func(msg *proto.Message) bool {
-		proposal := messages.ExtractLastPreparedProposal(msg)
-		certificate := messages.ExtractLatestPC(msg)
+		rcData, err := messages.ExtractPayload[*proto.RoundChangeMessage](msg)
+		if err != nil {
+			i.log.Debug("malformed round change message", "err", err)
 
-		// Check if the prepared certificate is valid
-		if !i.validPC(certificate, msg.View.Round, height) {
 			return false
 		}
 
-		// Make sure the certificate matches the proposal
-		return i.proposalMatchesCertificate(proposal, certificate)
+		var (
+			proposal    = rcData.LastPreparedProposal
+			certificate = rcData.LatestPreparedCertificate
+		)
+
+		// Check if the prepared certificate is valid
+		if !i.validPCCached(certificate, msg.View.Round, height, cache) {
+			return false
+		}
+
+		// Make sure the certificate matches the proposal
+		return i.proposalMatchesCertificate(proposal, certificate)
 	}
 
 	isValidRCCFn := func(round uint64, msgs []*proto.Message) bool {
@@ -459,7 +1100,7 @@ func (i *IBFT) handleRoundChangeMessage(view *proto.View) *proto.RoundChangeCert
 			return false
 		}
 
-		return i.backend.HasQuorum(height, msgs, proto.MessageType_ROUND_CHANGE)
+		return i.quorum.HasQuorum(height, msgs, proto.MessageType_ROUND_CHANGE)
 	}
 
 	extendedRCC := i.messages.GetExtendedRCC(
@@ -496,14 +1137,21 @@ func (i *IBFT) proposalMatchesCertificate(
 	hashesInCertificate := make([][]byte, 0)
 
 	//	collect hash from pre-prepare message
-	proposalHash := messages.ExtractProposalHash(certificate.ProposalMessage)
-	hashesInCertificate = append(hashesInCertificate, proposalHash)
+	preprepareData, err := messages.ExtractPayload[*proto.PrePrepareMessage](certificate.ProposalMessage)
+	if err != nil {
+		return false
+	}
+
+	hashesInCertificate = append(hashesInCertificate, preprepareData.ProposalHash)
 
 	//	collect hashes from prepare messages
 	for _, msg := range certificate.PrepareMessages {
-		proposalHash := messages.ExtractPrepareHash(msg)
+		prepareData, err := messages.ExtractPayload[*proto.PrepareMessage](msg)
+		if err != nil {
+			return false
+		}
 
-		hashesInCertificate = append(hashesInCertificate, proposalHash)
+		hashesInCertificate = append(hashesInCertificate, prepareData.ProposalHash)
 	}
 
 	//	verify all hashes match the proposal
@@ -517,30 +1165,20 @@ func (i *IBFT) proposalMatchesCertificate(
 }
 
 // runReceptions spawn processes to handle message for the round
-func (i *IBFT) runReceptions(ctx context.Context) {
-	var wg sync.WaitGroup
-
-	wg.Add(3)
+func (i *IBFT) runReceptions(ctx context.Context) error {
+	group := newWorkerGroup(ctx)
 
-	go func() {
-		defer wg.Done()
+	group.spawn("PREPREPARE reception", i.runPrePrepare)
+	group.spawn("PREPARE reception", i.runPrepare)
+	group.spawn("COMMIT reception", i.runCommit)
 
-		i.runPrePrepare(ctx)
-	}()
-
-	go func() {
-		defer wg.Done()
-
-		i.runPrepare(ctx)
-	}()
-
-	go func() {
-		defer wg.Done()
-
-		i.runCommit(ctx)
-	}()
+	if i.shouldBoostProposal() {
+		group.spawn("boosted reproposal", i.runBoostedProposal)
+	}
 
-	wg.Wait()
+	// A failed reception stops the remaining ones.
+	// Stuck receptions are covered by the round worker stop timeout
+	return group.wait()
 }
 
 // runPrePrepare starts reception of PREPREPARE message
@@ -554,6 +1192,7 @@ func (i *IBFT) runPrePrepare(ctx context.Context) {
 
 		// Subscribe for PREPREPARE messages
 		sub = i.messages.Subscribe(
+			ctx,
 			messages.SubscriptionDetails{
 				MessageType: proto.MessageType_PREPREPARE,
 				View:        view,
@@ -564,18 +1203,29 @@ func (i *IBFT) runPrePrepare(ctx context.Context) {
 		)
 	)
 
-	// The subscription is not needed anymore after
-	// this state is done executing
-	defer i.messages.Unsubscribe(sub.ID)
-
 	for {
+		// A node that vetoed the proposal of the round does not prepare it
+		if i.hasVetoed(view) {
+			return
+		}
+
 		// SubscriptionDetails conditions have been met,
 		// grab the proposal messages
-		proposalMessage := i.handlePrePrepare(view)
+		proposalMessage, rejected := i.selectProposal(view)
+		if proposalMessage == nil && rejected != nil {
+			i.sendVeto(ctx, view, rejected)
+
+			return
+		}
+
 		if proposalMessage != nil {
+			// The boosted reproposal of the node may have been accepted meanwhile
+			if !i.claimProposal(proposalMessage) {
+				return
+			}
+
 			// Multicast the PREPARE message
-			i.acceptProposal(proposalMessage)
-			i.sendPrepareMessage(view)
+			i.sendPrepareMessage(ctx, view)
 
 			i.log.Debug("prepare message multicasted")
 
@@ -586,8 +1236,11 @@ func (i *IBFT) runPrePrepare(ctx context.Context) {
 		case <-ctx.Done():
 			// Stop signal received, exit
 			return
-		case <-sub.SubCh:
-			continue
+		case _, ok := <-sub.SubCh:
+			if !ok {
+				// The subscription was removed, exit
+				return
+			}
 		}
 	}
 }
@@ -595,29 +1248,57 @@ func (i *IBFT) runPrePrepare(ctx context.Context) {
 // validateProposalCommon does common validations for each proposal, no
 // matter the round
 func (i *IBFT) validateProposalCommon(msg *proto.Message, view *proto.View) bool {
+	preprepareData, err := messages.ExtractPayload[*proto.PrePrepareMessage](msg)
+	if err != nil {
+		i.log.Debug("malformed proposal message", "err", err)
+
+		return false
+	}
+
 	var (
 		height = view.Height
 		round  = view.Round
 
-		proposal     = messages.ExtractProposal(msg)
-		proposalHash = messages.ExtractProposalHash(msg)
+		proposal     = preprepareData.Proposal
+		proposalHash = preprepareData.ProposalHash
 	)
 
 	//	round matches
-	if proposal.Round != view.Round {
+	if proposal.GetRound() != view.Round {
 		return false
 	}
 
-	//	is proposer
-	if !i.backend.IsProposer(msg.From, height, round) {
+	//	is proposer, or the boosted reproposer (see WithReproposalBoost)
+	if !i.isProposer(msg.From, height, round) && !i.isBoostedProposal(msg) {
 		return false
 	}
 
+	//	NIL proposals skip the round, and carry no proposal to validate
+	if preprepareData.NilProposal {
+		return i.nilProposals &&
+			len(proposal.GetRawProposal()) == 0 &&
+			len(proposalHash) == 0
+	}
+
 	//	hash matches keccak(proposal)
 	if !i.backend.IsValidProposalHash(proposal, proposalHash) {
 		return false
 	}
 
+	//	timestamp is within the time range, if set
+	if !messages.IsValidTimestamp(preprepareData.Timestamp) {
+		return false
+	}
+
+	//	timestamp is acceptable, if the backend enforces it
+	if verifier, ok := i.backend.(TimestampVerifier); ok {
+		timestamp := messages.ProposalTimestamp(preprepareData)
+
+		if !verifier.IsValidProposalTimestamp(timestamp, view) {
+			return false
+		}
+	}
+
 	//	is valid proposal
 	return i.backend.IsValidProposal(proposal.GetRawProposal())
 }
@@ -640,7 +1321,7 @@ func (i *IBFT) validateProposal0(msg *proto.Message, view *proto.View) bool {
 	}
 
 	// Make sure the current node is not the proposer for this round
-	if i.backend.IsProposer(i.backend.ID(), height, round) {
+	if i.isProposer(i.backend.ID(), height, round) {
 		return false
 	}
 
@@ -649,31 +1330,34 @@ func (i *IBFT) validateProposal0(msg *proto.Message, view *proto.View) bool {
 
 // validateProposal validates a proposal for round > 0
 func (i *IBFT) validateProposal(msg *proto.Message, view *proto.View) bool {
+	// Make sure common proposal validations pass,
+	// including the well-formedness of the payload
+	if !i.validateProposalCommon(msg, view) {
+		return false
+	}
+
+	preprepareData, _ := messages.ExtractPayload[*proto.PrePrepareMessage](msg)
+
 	var (
 		height = view.Height
 		round  = view.Round
 
-		proposalHash = messages.ExtractProposalHash(msg)
-		rcc          = messages.ExtractRoundChangeCertificate(msg)
+		proposalHash = preprepareData.ProposalHash
+		rcc          = preprepareData.Certificate
 	)
 
-	// Make sure common proposal validations pass
-	if !i.validateProposalCommon(msg, view) {
-		return false
-	}
-
 	// Make sure there is a certificate
 	if rcc == nil {
 		return false
 	}
 
 	// Make sure there are Quorum RCC
-	if !i.backend.HasQuorum(view.Height, rcc.RoundChangeMessages, proto.MessageType_ROUND_CHANGE) {
+	if !i.quorum.HasQuorum(view.Height, rcc.RoundChangeMessages, proto.MessageType_ROUND_CHANGE) {
 		return false
 	}
 
 	// Make sure the current node is not the proposer for this round
-	if i.backend.IsProposer(i.backend.ID(), height, round) {
+	if i.isProposer(i.backend.ID(), height, round) {
 		return false
 	}
 
@@ -699,7 +1383,7 @@ func (i *IBFT) validateProposal(msg *proto.Message, view *proto.View) bool {
 		}
 
 		// Sender of RCC is valid
-		if !i.backend.IsValidValidator(rc) {
+		if !i.isValidValidator(rc) {
 			return false
 		}
 	}
@@ -709,26 +1393,46 @@ func (i *IBFT) validateProposal(msg *proto.Message, view *proto.View) bool {
 	type roundHashTuple struct {
 		round uint64
 		hash  []byte
+		from  []byte
 	}
 
-	roundsAndPreparedBlockHashes := make([]roundHashTuple, 0)
+	var (
+		roundsAndPreparedBlockHashes = make([]roundHashTuple, 0)
+
+		// The ROUND CHANGE messages usually carry the same PC
+		cache = newValidatorCache(i.isValidValidator)
+	)
 
 	for _, rcMessage := range rcc.RoundChangeMessages {
-		cert := messages.ExtractLatestPC(rcMessage)
+		rcData, err := messages.ExtractPayload[*proto.RoundChangeMessage](rcMessage)
+		if err != nil {
+			i.log.Debug("malformed round change message in RCC", "err", err)
+
+			return false
+		}
+
+		cert := rcData.LatestPreparedCertificate
 
 		// Check if there is a certificate, and if it's a valid PC
-		if cert != nil && i.validPC(cert, msg.View.Round, height) {
-			hash := messages.ExtractProposalHash(cert.ProposalMessage)
+		if cert != nil && i.validPCCached(cert, msg.View.Round, height, cache) {
+			preprepareData, err := messages.ExtractPayload[*proto.PrePrepareMessage](cert.ProposalMessage)
+			if err != nil {
+				return false
+			}
 
 			roundsAndPreparedBlockHashes = append(roundsAndPreparedBlockHashes, roundHashTuple{
 				round: cert.ProposalMessage.View.Round,
-				hash:  hash,
+				hash:  preprepareData.ProposalHash,
+				from:  rcMessage.From,
 			})
 		}
 	}
 
+	// The boosted reproposer must hold the highest valid PC
+	boosted := !i.isProposer(msg.From, height, round)
+
 	if len(roundsAndPreparedBlockHashes) == 0 {
-		return true
+		return !boosted
 	}
 
 	// Find the max round
@@ -744,37 +1448,107 @@ func (i *IBFT) validateProposal(msg *proto.Message, view *proto.View) bool {
 		}
 	}
 
+	if boosted {
+		holdsHighest := false
+
+		for _, tuple := range roundsAndPreparedBlockHashes {
+			if tuple.round == maxRound && bytes.Equal(tuple.from, msg.From) {
+				holdsHighest = true
+			}
+		}
+
+		if !holdsHighest {
+			return false
+		}
+	}
+
 	return bytes.Equal(expectedHash, proposalHash)
 }
 
+// validateProposalMessage fully validates a proposal message,
+// against the view of the round it is intended for
+func (i *IBFT) validateProposalMessage(msg *proto.Message, view *proto.View) bool {
+	if msg == nil || msg.Type != proto.MessageType_PREPREPARE {
+		return false
+	}
+
+	// Make sure the proposal is for the specified view
+	if msg.View.GetHeight() != view.Height || msg.View.GetRound() != view.Round {
+		return false
+	}
+
+	// Make sure the proposal is signed by a validator
+	if !i.isValidValidator(msg) {
+		return false
+	}
+
+	if view.Round == 0 {
+		return i.validateProposal0(msg, view)
+	}
+
+	return i.validateProposal(msg, view)
+}
+
 // handlePrePrepare parses the received proposal and performs
 // a transition to PREPARE state, if the proposal is valid
 func (i *IBFT) handlePrePrepare(view *proto.View) *proto.Message {
+	proposalMessage, _ := i.selectProposal(view)
+
+	return proposalMessage
+}
+
+// selectProposal returns the valid proposal of the view, if any. Otherwise,
+// it returns the invalid proposal of the round proposer the node vetoes, if vetoes
+// are enabled (see WithVetoes)
+func (i *IBFT) selectProposal(view *proto.View) (*proto.Message, *proto.Message) {
 	// exit if node has received valid proposal
-	if i.state.getProposalMessage() != nil {
-		return nil
+	if i.state.hasProposalMessage() {
+		return nil, nil
 	}
 
+	var rejected *proto.Message
+
 	isValidPrePrepare := func(message *proto.Message) bool {
+		var valid bool
+
 		if view.Round == 0 {
 			//	proposal must be for round 0
-			return i.validateProposal0(message, view)
+			valid = i.validateProposal0(message, view)
+		} else {
+			valid = i.validateProposal(message, view)
+		}
+
+		if !valid {
+			i.recordInvalidProposal(view.Height, view.Round, message.From)
+			i.peerReporter.ReportPeer(message.From, OffenseInvalidProposal)
+
+			if i.isVetoable(message, view) {
+				rejected = message
+			}
 		}
 
-		return i.validateProposal(message, view)
+		return valid
 	}
 
-	msgs := i.messages.GetValidMessages(
+	var proposal *proto.Message
+
+	// Only the first valid proposal is needed, the rest are not validated
+	i.messages.ScanValidMessages(
 		view,
 		proto.MessageType_PREPREPARE,
 		isValidPrePrepare,
+		func(message *proto.Message) bool {
+			proposal = message
+
+			return false
+		},
 	)
 
-	if len(msgs) < 1 {
-		return nil
+	if proposal == nil {
+		return nil, rejected
 	}
 
-	return msgs[0]
+	return proposal, nil
 }
 
 // runPrepare starts reception of PREPARE messages
@@ -788,36 +1562,65 @@ func (i *IBFT) runPrepare(ctx context.Context) {
 
 		// Subscribe to PREPARE messages
 		sub = i.messages.Subscribe(
+			ctx,
 			messages.SubscriptionDetails{
 				MessageType: proto.MessageType_PREPARE,
 				View:        view,
-				HasQuorumFn: i.backend.HasQuorum,
+				HasQuorumFn: i.quorum.HasQuorum,
 			},
 		)
 	)
 
-	// The subscription is not needed anymore after
-	// this state is done executing
-	defer i.messages.Unsubscribe(sub.ID)
+	// Messages validated on previous wake-ups are not validated again
+	validated := make(validatedMessages)
 
 	for {
-		prepareMessages := i.handlePrepare(view)
+		// Grab the wakeup before the check, so a proposal accepted meanwhile is not missed
+		woken := i.receptionWakeup.wait()
+
+		prepareMessages := i.handlePrepare(view, validated)
 		if prepareMessages != nil {
-			i.state.finalizePrepare(
-				&proto.PreparedCertificate{
+			i.observeLatency(proto.MessageType_PREPARE)
+			i.advancePhase(phasePrepare, phaseCommit)
+
+			// The proposer is counted towards the PREPARE quorum
+			participants := make([][]byte, 0, len(prepareMessages)+1)
+			participants = append(participants, i.state.getProposalMessage().GetFrom())
+
+			for _, message := range prepareMessages {
+				participants = append(participants, message.From)
+			}
+
+			i.emitQuorumEvent(EventPrepareQuorum, view, participants)
+
+			var (
+				certificate = &proto.PreparedCertificate{
 					ProposalMessage: i.state.getProposalMessage(),
 					PrepareMessages: prepareMessages,
-				},
-				i.state.getProposal(),
+				}
+				proposal = i.state.getProposal()
 			)
 
+			// NIL proposals are never locked, as they are not carried over
+			if !i.state.isNilProposal() {
+				i.state.finalizePrepare(certificate, proposal)
+
+				// Notify the backend of the new prepared certificate, if it observes them
+				if observer, ok := i.backend.(PreparedObserver); ok {
+					observer.OnPrepared(certificate.Copy(), proposal.Copy())
+				}
+			}
+
 			i.state.setCommitSent(true)
 
 			// Multicast the COMMIT message
-			i.sendCommitMessage(view)
+			i.sendCommitMessage(ctx, view)
 
 			i.log.Debug("commit message multicasted")
 
+			// Make up for lost COMMIT messages, before the round expires
+			i.rebroadcastCommit(ctx, view)
+
 			return
 		}
 
@@ -826,27 +1629,47 @@ func (i *IBFT) runPrepare(ctx context.Context) {
 		case <-ctx.Done():
 			// Stop signal received, exit
 			return
-		case <-sub.SubCh:
-			continue
+		case _, ok := <-sub.SubCh:
+			if !ok {
+				// The subscription was removed, exit
+				return
+			}
+		case <-woken:
+			// A proposal was accepted, check the stored messages
 		}
 	}
 }
 
 // handlePrepare parses available prepare messages and performs
-// a transition to COMMIT state, if quorum was reached
-func (i *IBFT) handlePrepare(view *proto.View) []*proto.Message {
+// a transition to COMMIT state, if quorum was reached.
+// Messages in the validated set are not validated again
+func (i *IBFT) handlePrepare(view *proto.View, validated validatedMessages) []*proto.Message {
 	// exit if node has not received a proposal for round yet
 	// or node has sent commit message already
-	if i.state.getProposalMessage() == nil || i.state.getCommitSent() {
+	if !i.state.hasProposalMessage() || i.state.getCommitSent() {
 		return nil
 	}
 
+	proposal := i.state.getProposal()
+
 	isValidPrepare := func(message *proto.Message) bool {
-		// Verify that the proposal hash is valid
-		return i.backend.IsValidProposalHash(
-			i.state.getProposal(),
-			messages.ExtractPrepareHash(message),
-		)
+		return validated.isValid(message, func(message *proto.Message) bool {
+			prepareData, err := messages.ExtractPayload[*proto.PrepareMessage](message)
+			if err != nil {
+				i.log.Debug("malformed prepare message", "err", err)
+
+				return false
+			}
+
+			// Verify that the proposal hash is valid
+			if !i.isValidProposalHash(proposal, prepareData.ProposalHash) {
+				i.reportHashMismatch(view, message, prepareData.ProposalHash)
+
+				return false
+			}
+
+			return true
+		})
 	}
 
 	prepareMessages := i.messages.GetValidMessages(
@@ -855,7 +1678,7 @@ func (i *IBFT) handlePrepare(view *proto.View) []*proto.Message {
 		isValidPrepare,
 	)
 
-	if !i.backend.HasQuorum(view.Height, prepareMessages, proto.MessageType_PREPARE) {
+	if !i.quorum.HasQuorum(view.Height, prepareMessages, proto.MessageType_PREPARE) {
 		//	quorum not reached, keep polling
 		return nil
 	}
@@ -874,20 +1697,34 @@ func (i *IBFT) runCommit(ctx context.Context) {
 
 		// Subscribe to COMMIT messages
 		sub = i.messages.Subscribe(
+			ctx,
 			messages.SubscriptionDetails{
 				MessageType: proto.MessageType_COMMIT,
 				View:        view,
-				HasQuorumFn: i.backend.HasQuorum,
+				HasQuorumFn: i.hasQuorum,
 			},
 		)
 	)
 
-	// The subscription is not needed anymore after
-	// this state is done executing
-	defer i.messages.Unsubscribe(sub.ID)
+	// Messages validated on previous wake-ups are not validated again
+	validated := make(validatedMessages)
 
 	for {
-		if i.handleCommit(view) {
+		// Grab the wakeup before the check, so a proposal accepted meanwhile is not missed
+		woken := i.receptionWakeup.wait()
+
+		if i.handleCommit(view, validated) {
+			if i.state.isNilProposal() {
+				i.log.Info("NIL proposal decided, skipping round", "height", view.Height, "round", view.Round)
+				i.emitEvent(EventNilDecision, view, nil)
+
+				// Move to the next round without waiting for the timeout
+				i.signalRoundExpired(ctx)
+
+				return
+			}
+
+			i.emitProposalEvent(EventFinalized, view)
 			i.signalRoundDone(ctx)
 
 			return
@@ -898,59 +1735,123 @@ func (i *IBFT) runCommit(ctx context.Context) {
 		case <-ctx.Done():
 			// Stop signal received, exit
 			return
-		case <-sub.SubCh:
-			continue
+		case _, ok := <-sub.SubCh:
+			if !ok {
+				// The subscription was removed, exit
+				return
+			}
+		case <-woken:
+			// A proposal was accepted, check the stored messages
 		}
 	}
 }
 
+// isValidProposalHash checks if the hash matches the accepted proposal.
+// The hash of an accepted NIL proposal is empty
+func (i *IBFT) isValidProposalHash(proposal *proto.Proposal, hash []byte) bool {
+	if i.state.isNilProposal() {
+		return len(hash) == 0
+	}
+
+	return i.backend.IsValidProposalHash(proposal, hash)
+}
+
 // handleCommit parses available commit messages and performs
-// a transition to FIN state, if quorum was reached
-func (i *IBFT) handleCommit(view *proto.View) bool {
-	if i.state.getProposalMessage() == nil {
+// a transition to FIN state, if quorum was reached.
+// Messages in the validated set are not validated again
+func (i *IBFT) handleCommit(view *proto.View, validated validatedMessages) bool {
+	if !i.state.hasProposalMessage() {
 		return false
 	}
 
+	proposal := i.state.getProposal()
+
 	isValidCommit := func(message *proto.Message) bool {
-		var (
-			proposalHash  = messages.ExtractCommitHash(message)
-			committedSeal = messages.ExtractCommittedSeal(message)
-		)
-		//	Verify that the proposal hash is valid
-		if !i.backend.IsValidProposalHash(i.state.getProposal(), proposalHash) {
-			return false
-		}
+		return validated.isValid(message, func(message *proto.Message) bool {
+			commitData, err := messages.ExtractPayload[*proto.CommitMessage](message)
+			if err != nil {
+				i.log.Debug("malformed commit message", "err", err)
+
+				return false
+			}
 
-		//	Verify that the committed seal is valid
-		return i.backend.IsValidCommittedSeal(proposalHash, committedSeal)
+			var (
+				proposalHash  = commitData.ProposalHash
+				committedSeal = &messages.CommittedSeal{
+					Signer:     message.From,
+					Signature:  commitData.CommittedSeal,
+					Randomness: commitData.Randomness,
+				}
+			)
+
+			//	Verify that the proposal hash is valid
+			if !i.isValidProposalHash(proposal, proposalHash) {
+				i.reportHashMismatch(view, message, proposalHash)
+
+				return false
+			}
+
+			//	Verify that the committed seal and the randomness contribution are valid
+			return i.isValidCommitSeal(view.Height, proposalHash, committedSeal) &&
+				i.isValidRandomness(view, proposalHash, committedSeal)
+		})
 	}
 
 	commitMessages := i.messages.GetValidMessages(view, proto.MessageType_COMMIT, isValidCommit)
-	if !i.backend.HasQuorum(view.Height, commitMessages, proto.MessageType_COMMIT) {
+	if !i.hasQuorum(view.Height, commitMessages, proto.MessageType_COMMIT) {
 		//	quorum not reached, keep polling
 		return false
 	}
 
-	commitSeals, err := messages.ExtractCommittedSeals(commitMessages)
-	if err != nil {
-		// safe check
-		i.log.Error("failed to extract committed seals from commit messages: %+v", err)
-
+	commitSeals, ok := i.extractCommittedSeals(view, commitMessages, validated)
+	if !ok {
 		return false
 	}
 
+	// With threshold signing, the partial signatures must combine
+	// into a valid threshold signature
+	var thresholdSeal []byte
+
+	signer, isThreshold := i.backend.(ThresholdSigner)
+	if isThreshold && !i.state.isNilProposal() {
+		thresholdSeal, ok = i.combineThresholdSeal(signer, view, i.state.getProposalHash(), commitSeals)
+		if !ok {
+			return false
+		}
+	}
+
+	// The quorum is observed once the committed seals are usable,
+	// so the retried checks of a failed quorum are not measured again
+	i.observeLatency(proto.MessageType_COMMIT)
+	i.advancePhase(phaseCommit, "")
+
 	// Set the committed seals
 	i.state.setCommittedSeals(commitSeals)
 
+	participants := make([][]byte, 0, len(commitSeals))
+	for _, seal := range commitSeals {
+		participants = append(participants, seal.Signer)
+	}
+
+	i.emitQuorumEvent(EventCommitQuorum, view, participants)
+
+	// A decided NIL proposal skips the round, there is nothing to insert
+	if i.state.isNilProposal() {
+		return true
+	}
+
+	committed := &proto.Proposal{
+		RawProposal: i.state.getRawDataFromProposal(),
+		Round:       i.state.getRound(),
+	}
+
 	// Insert the block to the node's underlying
 	// blockchain layer
-	i.backend.InsertProposal(
-		&proto.Proposal{
-			RawProposal: i.state.getRawDataFromProposal(),
-			Round:       i.state.getRound(),
-		},
-		i.state.getCommittedSeals(),
-	)
+	if isThreshold {
+		signer.InsertThresholdProposal(committed, thresholdSeal, i.state.getCommittedSeals())
+	} else {
+		i.backend.InsertProposal(committed, i.state.getCommittedSeals())
+	}
 
 	// Remove stale messages
 	i.messages.PruneByHeight(i.state.getHeight())
@@ -958,6 +1859,54 @@ func (i *IBFT) handleCommit(view *proto.View) bool {
 	return true
 }
 
+// extractCommittedSeals extracts the committed seals from the quorum of COMMIT messages.
+// A malformed message is reported and excluded, and the quorum is checked again
+// with the remaining messages, instead of dropping the whole quorum
+func (i *IBFT) extractCommittedSeals(
+	view *proto.View,
+	commitMessages []*proto.Message,
+	validated validatedMessages,
+) ([]*messages.CommittedSeal, bool) {
+	for {
+		commitSeals, err := messages.ExtractCommittedSeals(commitMessages)
+		if err == nil {
+			return commitSeals, true
+		}
+
+		var sealErr *messages.CommittedSealError
+		if !errors.As(err, &sealErr) {
+			// safe check
+			i.log.Error("failed to extract committed seals from commit messages", "err", err)
+
+			return nil, false
+		}
+
+		i.log.Error(
+			"malformed commit message excluded from the quorum",
+			"sender", sealErr.From,
+			"err", sealErr.Err,
+		)
+
+		i.metrics.IncrCounter(commitMalformedKey, 1)
+		i.peerReporter.ReportPeer(sealErr.From, OffenseMalformedCommit)
+		i.emitEvent(EventMalformedCommit, &proto.View{Height: view.Height, Round: view.Round}, MalformedMessageData{
+			Sender: sealErr.From,
+			Err:    sealErr.Err,
+		})
+
+		// The excluded message is validated again (and rejected) on the next wake-up
+		delete(validated, commitMessages[sealErr.Index])
+
+		remaining := make([]*proto.Message, 0, len(commitMessages)-1)
+		remaining = append(remaining, commitMessages[:sealErr.Index]...)
+		commitMessages = append(remaining, commitMessages[sealErr.Index+1:]...)
+
+		if !i.hasQuorum(view.Height, commitMessages, proto.MessageType_COMMIT) {
+			return nil, false
+		}
+	}
+}
+
 // moveToNewRound changes round and resets state
 func (i *IBFT) moveToNewRound(round uint64) {
 	i.state.setView(&proto.View{
@@ -968,6 +1917,14 @@ func (i *IBFT) moveToNewRound(round uint64) {
 	i.state.setRoundStarted(false)
 	i.state.setProposalMessage(nil)
 	i.state.setCommitSent(false)
+	i.state.resetPhaseStarts()
+	i.phases.reset()
+
+	if i.roundPruning {
+		// The messages of the lower rounds are rejected from now on,
+		// so the stored ones are no longer needed
+		i.messages.PruneByRound(i.state.getHeight(), round)
+	}
 }
 
 func (i *IBFT) buildProposal(ctx context.Context, view *proto.View) *proto.Message {
@@ -983,14 +1940,7 @@ func (i *IBFT) buildProposal(ctx context.Context, view *proto.View) *proto.Messa
 				Round:  round,
 			})
 
-		return i.backend.BuildPrePrepareMessage(
-			rawProposal,
-			nil,
-			&proto.View{
-				Height: height,
-				Round:  round,
-			},
-		)
+		return i.buildPrePrepare(rawProposal, nil, view)
 	}
 
 	//	round > 0 -> needs RCC
@@ -1000,6 +1950,17 @@ func (i *IBFT) buildProposal(ctx context.Context, view *proto.View) *proto.Messa
 		return nil
 	}
 
+	return i.buildCertifiedProposal(rcc, view)
+}
+
+// buildCertifiedProposal builds the proposal for the round > 0, justified by the RCC.
+// The proposal prepared in a previous round, if any, is proposed again
+func (i *IBFT) buildCertifiedProposal(rcc *proto.RoundChangeCertificate, view *proto.View) *proto.Message {
+	var (
+		height = view.Height
+		round  = view.Round
+	)
+
 	//	check the messages for any previous proposal (if they have any, it's the same proposal)
 	var (
 		previousProposal []byte
@@ -1008,8 +1969,8 @@ func (i *IBFT) buildProposal(ctx context.Context, view *proto.View) *proto.Messa
 
 	// take previous proposal among the round change messages for the highest round
 	for _, msg := range rcc.RoundChangeMessages {
-		latestPC := messages.ExtractLatestPC(msg)
-		if latestPC == nil {
+		rcData, err := messages.ExtractPayload[*proto.RoundChangeMessage](msg)
+		if err != nil || rcData.LatestPreparedCertificate == nil {
 			continue
 		}
 
@@ -1019,7 +1980,7 @@ func (i *IBFT) buildProposal(ctx context.Context, view *proto.View) *proto.Messa
 			continue
 		}
 
-		lastPB := messages.ExtractLastPreparedProposal(msg)
+		lastPB := rcData.LastPreparedProposal
 		if lastPB == nil {
 			continue
 		}
@@ -1038,14 +1999,7 @@ func (i *IBFT) buildProposal(ctx context.Context, view *proto.View) *proto.Messa
 				Round:  round,
 			})
 
-		return i.backend.BuildPrePrepareMessage(
-			proposal,
-			rcc,
-			&proto.View{
-				Height: height,
-				Round:  round,
-			},
-		)
+		return i.buildPrePrepare(proposal, rcc, view)
 	}
 
 	return i.backend.BuildPrePrepareMessage(
@@ -1058,75 +2012,484 @@ func (i *IBFT) buildProposal(ctx context.Context, view *proto.View) *proto.Messa
 	)
 }
 
-// acceptProposal accepts the proposal and saves it into state
-func (i *IBFT) acceptProposal(proposalMessage *proto.Message) {
-	//	accept newly proposed block
-	i.state.setProposalMessage(proposalMessage)
+// buildPrePrepare builds the PREPREPARE message for the freshly built proposal.
+// If the backend could not build a proposal, and NIL proposals are enabled,
+// an explicit NIL proposal is built instead
+func (i *IBFT) buildPrePrepare(
+	rawProposal []byte,
+	certificate *proto.RoundChangeCertificate,
+	view *proto.View,
+) *proto.Message {
+	if len(rawProposal) == 0 && i.nilProposals {
+		if builder, ok := i.backend.(NilProposalBuilder); ok {
+			i.log.Info("building NIL proposal", "height", view.Height, "round", view.Round)
+
+			return builder.BuildNilPrePrepareMessage(
+				certificate,
+				&proto.View{
+					Height: view.Height,
+					Round:  view.Round,
+				},
+			)
+		}
+	}
+
+	return i.backend.BuildPrePrepareMessage(
+		rawProposal,
+		certificate,
+		&proto.View{
+			Height: view.Height,
+			Round:  view.Round,
+		},
+	)
 }
 
-// AddMessage adds a new message to the IBFT message system
-func (i *IBFT) AddMessage(message *proto.Message) {
-	// Make sure the message is present
-	if message == nil {
-		return
+// acceptProposal accepts the proposal and saves it into state
+func (i *IBFT) acceptProposal(proposalMessage *proto.Message) {
+	// Proposals for higher rounds are justified by an RCC,
+	// make sure they carry over the locally prepared proposal
+	if proposalMessage.GetView().GetRound() > 0 {
+		i.verifyPreparedCarryover(proposalMessage)
 	}
 
-	// Check if the message should even be considered
-	if i.isAcceptableMessage(message) {
-		i.messages.AddMessage(message)
+	//	accept newly proposed block
+	i.state.setProposalMessage(proposalMessage)
+	i.emitProposalEvent(EventProposalAccepted, i.state.getView())
+	i.advancePhase(phasePrePrepare, phasePrepare)
 
-		msgs := i.messages.GetValidMessages(
-			message.View,
-			message.Type,
-			func(_ *proto.Message) bool { return true })
-		if i.backend.HasQuorum(message.View.Height, msgs, message.Type) {
-			i.messages.SignalEvent(message)
-		}
-	}
+	// The PREPARE and COMMIT messages may have arrived before the proposal
+	i.wakeReceptions()
 }
 
-// isAcceptableMessage checks if the message can even be accepted
-func (i *IBFT) isAcceptableMessage(message *proto.Message) bool {
-	//	Make sure the message sender is ok
-	if !i.backend.IsValidValidator(message) {
-		return false
+// claimProposal accepts the proposal, as acceptProposal does, unless a proposal
+// was already accepted for the round. It returns false if one was
+func (i *IBFT) claimProposal(proposalMessage *proto.Message) bool {
+	if proposalMessage.GetView().GetRound() > 0 {
+		i.verifyPreparedCarryover(proposalMessage)
 	}
 
-	// Invalid messages are discarded
-	if message.View == nil {
+	if !i.state.claimProposalMessage(proposalMessage) {
 		return false
 	}
 
-	// Make sure the message is in accordance with
-	// the current state height, or greater
-	if i.state.getHeight() > message.View.Height {
-		return false
-	}
+	i.emitProposalEvent(EventProposalAccepted, i.state.getView())
+	i.advancePhase(phasePrePrepare, phasePrepare)
+	i.wakeReceptions()
 
-	// Make sure the message round is >= the current state round
-	return message.View.Round >= i.state.getRound()
+	return true
 }
 
-// ExtendRoundTimeout extends each round's timer by the specified amount.
-func (i *IBFT) ExtendRoundTimeout(amount time.Duration) {
-	i.additionalTimeout = amount
+// wakeReceptions wakes the PREPARE and COMMIT receptions,
+// so they check the messages stored before the proposal was accepted.
+// A quorum reached before the proposal signals the receptions only once,
+// while they cannot act on it yet, and no later message may signal them again
+func (i *IBFT) wakeReceptions() {
+	i.receptionWakeup.broadcast()
 }
 
-// validPC verifies that the prepared certificate is valid
-func (i *IBFT) validPC(
-	certificate *proto.PreparedCertificate,
-	rLimit,
-	height uint64,
-) bool {
-	if certificate == nil {
-		// PCs that are not set are valid by default
-		return true
+// verifyPreparedCarryover checks if the proposal matches the proposal
+// the node has previously prepared in the current height, if any.
+// A mismatch is not necessarily a safety violation (the prepared proposal
+// may have not been part of the RCC), but it is emitted as an event for diagnostics
+func (i *IBFT) verifyPreparedCarryover(proposalMessage *proto.Message) {
+	latestPC := i.state.getLatestPC()
+	if latestPC == nil || latestPC.ProposalMessage == nil {
+		return
 	}
 
-	// Make sure that either both the proposal message and the prepare messages are set together
-	if certificate.ProposalMessage == nil || certificate.PrepareMessages == nil {
-		return false
-	}
+	var (
+		preparedHash = extractProposalHash(latestPC.ProposalMessage)
+		proposalHash = extractProposalHash(proposalMessage)
+	)
+
+	if bytes.Equal(preparedHash, proposalHash) {
+		return
+	}
+
+	preparedRound := latestPC.ProposalMessage.GetView().GetRound()
+
+	i.log.Error(
+		"accepted proposal does not match the prepared proposal",
+		"round", proposalMessage.View.Round,
+		"prepared round", preparedRound,
+	)
+
+	i.emitEvent(
+		EventPreparedMismatch,
+		proposalMessage.View,
+		PreparedMismatchData{
+			PreparedRound: preparedRound,
+			PreparedHash:  preparedHash,
+			ProposalHash:  proposalHash,
+		},
+	)
+}
+
+// AddMessage adds a new message to the IBFT message system
+func (i *IBFT) AddMessage(message *proto.Message) {
+	i.addMessage(message, untrustedSender, nil)
+}
+
+// addMessage adds a new message to the IBFT message system.
+// The trust indicates how the sender of the message is already authenticated,
+// and the peer is the transport peer that delivered the message, if known
+func (i *IBFT) addMessage(message *proto.Message, trust senderTrust, peer []byte) {
+	// Make sure the message is present
+	if message == nil {
+		return
+	}
+
+	if !i.admitMessage(message, trust, peer) {
+		return
+	}
+
+	i.messages.AddMessage(message)
+}
+
+// AddMessages adds a batch of messages to the IBFT message system, as AddMessage does.
+// The acceptable messages are stored with a single store call, which evaluates the quorum
+// once for each view and message type of the batch, after the batch is stored.
+// It is meant for the network layers delivering gossip in batches
+func (i *IBFT) AddMessages(batch []*proto.Message) {
+	admitted := make([]*proto.Message, 0, len(batch))
+
+	for _, message := range batch {
+		if message == nil || !i.admitMessage(message, untrustedSender, nil) {
+			continue
+		}
+
+		admitted = append(admitted, message)
+	}
+
+	if len(admitted) == 0 {
+		return
+	}
+
+	i.messages.AddMessages(admitted)
+}
+
+// admitMessage checks if the message can be accepted, and handles the messages
+// that are not stored. It returns true if the message should be stored
+func (i *IBFT) admitMessage(message *proto.Message, trust senderTrust, peer []byte) bool {
+	// Check if the message should even be considered
+	if !i.isAcceptableDelivery(message, trust, peer) {
+		return false
+	}
+
+	return i.handleUnstoredMessage(message)
+}
+
+// isUnstoredType checks if the messages of the type are never stored,
+// as they are handled on arrival (see handleUnstoredMessage)
+func isUnstoredType(messageType proto.MessageType) bool {
+	switch messageType {
+	case proto.MessageType_PROPOSER_UNAVAILABLE,
+		proto.MessageType_CURRENT_VIEW,
+		proto.MessageType_FINALITY:
+		return true
+	default:
+		return false
+	}
+}
+
+// handleUnstoredMessage handles the acceptable messages that are not stored.
+// It returns true if the message should be stored
+func (i *IBFT) handleUnstoredMessage(message *proto.Message) bool {
+	// Unavailability announcements are not stored,
+	// they only shorten the current round
+	if message.Type == proto.MessageType_PROPOSER_UNAVAILABLE {
+		i.handleUnavailableProposer(message)
+
+		return false
+	}
+
+	// View announcements are not stored,
+	// they only move the node to the active round
+	if message.Type == proto.MessageType_CURRENT_VIEW {
+		i.handleCurrentView(message)
+
+		return false
+	}
+
+	// Finality proofs are not stored,
+	// they only finalize the current height
+	if message.Type == proto.MessageType_FINALITY {
+		i.handleFinality(message)
+
+		return false
+	}
+
+	// A repeated proposal for the current view indicates
+	// the peers have not received the node's messages
+	if i.isDuplicateProposal(message) {
+		i.signalDuplicateProposal()
+		i.signalProposalReply(message.From)
+	}
+
+	return true
+}
+
+// reachesQuorum is the quorum function of the message store (see messages.WithQuorumFn),
+// deciding when the subscribers of the view and type are signaled.
+// The reached quorums are memoized for the engine as well
+func (i *IBFT) reachesQuorum(view *proto.View, msgs []*proto.Message, messageType proto.MessageType) bool {
+	if !i.hasQuorum(view.Height, msgs, messageType) {
+		return false
+	}
+
+	i.quorumMemo.setQuorum(view, messageType)
+
+	return true
+}
+
+// AddRawMessage decodes the raw message received from the network
+// using the configured codec, and adds it to the IBFT message system.
+// If envelopes are verified (see WithEnvelopeVerifier), the message is decoded
+// only if its envelope is signed by its sender, so unauthenticated data
+// is dropped before reaching the consensus
+func (i *IBFT) AddRawMessage(data []byte) error {
+	return i.addRawMessage(data, nil)
+}
+
+// AddRawPeerMessage decodes the raw message delivered by the transport peer,
+// and adds it to the IBFT message system, as AddRawMessage and AddPeerMessage do.
+// The received bytes are also accounted to the peer (see WithMetrics)
+func (i *IBFT) AddRawPeerMessage(peer, data []byte) error {
+	return i.addRawMessage(data, peer)
+}
+
+// addRawMessage decodes the raw message delivered by the transport peer, if known,
+// and adds it to the IBFT message system
+func (i *IBFT) addRawMessage(data, peer []byte) error {
+	message := &proto.Message{}
+
+	if i.envelopeVerifier == nil {
+		if err := i.codec.Unmarshal(data, message); err != nil {
+			return err
+		}
+
+		recordBandwidth(i.metrics, bandwidthReceived, peer, message.Type, len(data))
+		i.addMessage(message, untrustedSender, peer)
+
+		return nil
+	}
+
+	if err := messages.OpenEnvelope(data, i.envelopeVerifier, i.codec, message); err != nil {
+		i.rejectDelivery(message, peer, rejectInvalidEnvelope)
+
+		return err
+	}
+
+	recordBandwidth(i.metrics, bandwidthReceived, peer, message.Type, len(data))
+	i.addMessage(message, authenticatedSender, peer)
+
+	return nil
+}
+
+// isAcceptableMessage checks if the message can even be accepted.
+// Each rejection is counted by its reason (see WithRejectionLogSampling)
+func (i *IBFT) isAcceptableMessage(message *proto.Message, trust senderTrust) bool {
+	return i.isAcceptableDelivery(message, trust, nil)
+}
+
+// isAcceptableDelivery checks if the message delivered by the transport peer
+// can even be accepted, as isAcceptableMessage does. The offenses of the rejected
+// messages are attributed to the peer, if known (see DeliveryReporter)
+func (i *IBFT) isAcceptableDelivery(message *proto.Message, trust senderTrust, peer []byte) bool {
+	// Only the sentries deliver the messages of a validator behind them
+	if peer != nil && !i.isSentry(peer) {
+		return i.rejectDelivery(message, peer, rejectUnknownPeer)
+	}
+
+	// The DKG ceremony messages are handled by the dkg package
+	if message.Type == proto.MessageType_DKG {
+		return i.rejectDelivery(message, peer, rejectUnsupportedType)
+	}
+
+	// Drop the messages of the ignored senders before verifying them
+	if i.ignored.isIgnored(message.From, i.clock()) {
+		return i.rejectDelivery(message, peer, rejectIgnoredSender)
+	}
+
+	//	Make sure the message sender is ok. Finality proofs are authenticated
+	// by their committed seals, so any node that finalized the height can serve them
+	if message.Type != proto.MessageType_FINALITY && !i.isValidSender(message, trust) {
+		return i.rejectDelivery(message, peer, rejectInvalidValidator)
+	}
+
+	// Invalid messages are discarded
+	if message.View == nil {
+		return i.rejectDelivery(message, peer, rejectNilView)
+	}
+
+	// View announcements of lower views are accepted,
+	// so the validators lagging behind can be replied to
+	if message.Type == proto.MessageType_CURRENT_VIEW {
+		return true
+	}
+
+	// Make sure the message is in accordance with
+	// the current state height, or greater
+	if i.state.getHeight() > message.View.Height {
+		return i.rejectDelivery(message, peer, rejectStaleHeight)
+	}
+
+	// Finality proofs of any round finalize the height
+	if message.Type == proto.MessageType_FINALITY {
+		return true
+	}
+
+	// Make sure the message round is >= the current state round
+	if message.View.Round < i.state.getRound() {
+		return i.rejectDelivery(message, peer, rejectStaleRound)
+	}
+
+	// Make sure the message timestamp, if any, is not too far in the future
+	if i.isFutureTimestamp(message) {
+		return i.rejectDelivery(message, peer, rejectFutureTimestamp)
+	}
+
+	return true
+}
+
+// isFutureTimestamp checks if the message carries a timestamp beyond the time range,
+// or further ahead of the local clock than the allowed clock skew
+func (i *IBFT) isFutureTimestamp(message *proto.Message) bool {
+	// Only the PREPREPARE messages carry the proposal timestamp, if set
+	if message.Type != proto.MessageType_PREPREPARE {
+		return false
+	}
+
+	preprepareData, err := messages.ExtractPayload[*proto.PrePrepareMessage](message)
+	if err != nil || preprepareData.Timestamp == 0 {
+		return false
+	}
+
+	if !messages.IsValidTimestamp(preprepareData.Timestamp) {
+		return true
+	}
+
+	if i.clockSkewTolerance <= 0 {
+		return false
+	}
+
+	return messages.ProposalTimestamp(preprepareData).Sub(i.clock()) > i.clockSkewTolerance
+}
+
+// observeLatency records the time it took to reach quorum for the phase,
+// measured from the local multicast that started the phase
+func (i *IBFT) observeLatency(messageType proto.MessageType) {
+	start, ok := i.state.getPhaseStart(messageType)
+	if !ok {
+		// Nothing was multicasted locally in this round
+		return
+	}
+
+	i.latency.Observe(time.Since(start))
+}
+
+// LatencyEstimator returns the estimator of the network latency,
+// measured as the time between a local multicast and the arrival of the phase quorum
+func (i *IBFT) LatencyEstimator() *LatencyEstimator {
+	return i.latency
+}
+
+// SetRoundHint notifies the engine of the network's active round for the height,
+// as learned by the node's sync layer from its peers. If the hint is for the current
+// height and a higher round, the node moves to that round immediately,
+// instead of waiting for the round timeouts to expire.
+// Stale hints are ignored
+func (i *IBFT) SetRoundHint(height, round uint64) {
+	view := i.state.getView()
+	if height != view.Height || round <= view.Round {
+		return
+	}
+
+	hint := &proto.View{
+		Height: height,
+		Round:  round,
+	}
+
+	for {
+		select {
+		case i.roundHint <- hint:
+			return
+		default:
+		}
+
+		// Replace the pending hint with the latest one
+		select {
+		case <-i.roundHint:
+		default:
+		}
+	}
+}
+
+// handleUnavailableProposer shortens the current round, if the message
+// is sent by the proposer of the current round
+func (i *IBFT) handleUnavailableProposer(message *proto.Message) {
+	view := i.state.getView()
+	if message.View.Height != view.Height || message.View.Round != view.Round {
+		return
+	}
+
+	if !i.isProposer(message.From, view.Height, view.Round) {
+		return
+	}
+
+	i.emitEvent(EventProposerUnavailable, view, message.From)
+	i.signalUnavailableProposer(view)
+}
+
+// signalUnavailableProposer notifies the round worker that
+// the proposer of the view is unavailable, without blocking
+func (i *IBFT) signalUnavailableProposer(view *proto.View) {
+	unavailable := &proto.View{
+		Height: view.Height,
+		Round:  view.Round,
+	}
+
+	for {
+		select {
+		case i.unavailableProposer <- unavailable:
+			return
+		default:
+		}
+
+		// Replace the pending announcement with the latest one
+		select {
+		case <-i.unavailableProposer:
+		default:
+		}
+	}
+}
+
+// validPC verifies that the prepared certificate is valid
+func (i *IBFT) validPC(
+	certificate *proto.PreparedCertificate,
+	rLimit,
+	height uint64,
+) bool {
+	return i.validPCCached(certificate, rLimit, height, newValidatorCache(i.isValidValidator))
+}
+
+// validPCCached verifies that the prepared certificate is valid,
+// reusing the sender validations of the cache
+func (i *IBFT) validPCCached(
+	certificate *proto.PreparedCertificate,
+	rLimit,
+	height uint64,
+	cache *validatorCache,
+) bool {
+	if certificate == nil {
+		// PCs that are not set are valid by default
+		return true
+	}
+
+	// Make sure that either both the proposal message and the prepare messages are set together
+	if certificate.ProposalMessage == nil || certificate.PrepareMessages == nil {
+		return false
+	}
 
 	// Order of messages is important!
 	// Message with type of MessageType_PREPREPARE must be the first element of allMessages slice
@@ -1136,7 +2499,7 @@ func (i *IBFT) validPC(
 	)
 
 	// Make sure there are at least Quorum (PP + P) messages
-	if !i.backend.HasQuorum(i.state.getHeight(), allMessages, proto.MessageType_PREPARE) {
+	if !i.quorum.HasQuorum(i.state.getHeight(), allMessages, proto.MessageType_PREPARE) {
 		return false
 	}
 
@@ -1178,26 +2541,26 @@ func (i *IBFT) validPC(
 	}
 
 	// Make sure the proposal message is sent by the proposer
-	// for the round
+	// for the round, or by the boosted reproposer (see WithReproposalBoost)
 	proposal := certificate.ProposalMessage
-	if !i.backend.IsProposer(proposal.From, proposal.View.Height, proposal.View.Round) {
+	if !i.isProposer(proposal.From, proposal.View.Height, proposal.View.Round) && !i.isBoostedProposal(proposal) {
 		return false
 	}
 
 	// Make sure that the proposal sender is valid
-	if !i.backend.IsValidValidator(proposal) {
+	if !cache.isValidValidator(proposal) {
 		return false
 	}
 
 	// Make sure the Prepare messages are validators, apart from the proposer
 	for _, message := range certificate.PrepareMessages {
 		// Make sure the sender is part of the validator set
-		if !i.backend.IsValidValidator(message) {
+		if !cache.isValidValidator(message) {
 			return false
 		}
 
 		// Make sure the current node is not the proposer
-		if i.backend.IsProposer(message.From, message.View.Height, message.View.Round) {
+		if i.isProposer(message.From, message.View.Height, message.View.Round) {
 			return false
 		}
 	}
@@ -1206,27 +2569,57 @@ func (i *IBFT) validPC(
 }
 
 // sendPreprepareMessage sends out the preprepare message
-func (i *IBFT) sendPreprepareMessage(message *proto.Message) {
-	i.transport.Multicast(message)
+func (i *IBFT) sendPreprepareMessage(ctx context.Context, message *proto.Message) {
+	// The proposer is waiting for PREPARE messages from this point
+	i.state.setPhaseStart(proto.MessageType_PREPARE, time.Now())
+
+	if !i.saveRecoveryState() {
+		return
+	}
+
+	i.multicast(ctx, message)
 }
 
 // sendRoundChangeMessage sends out the round change message
-func (i *IBFT) sendRoundChangeMessage(height, newRound uint64) {
-	i.transport.Multicast(
+func (i *IBFT) sendRoundChangeMessage(ctx context.Context, height, newRound uint64) {
+	if i.isObserving() {
+		return
+	}
+
+	view := &proto.View{
+		Height: height,
+		Round:  newRound,
+	}
+
+	// The ROUND_CHANGE message for the view may already be sent,
+	// if the round was entered again (ex. the timer re-fired)
+	if !i.roundChangeThrottle.allow(view, time.Now()) {
+		i.log.Debug("round change throttled", "round", newRound)
+		i.metrics.IncrCounter(roundChangeThrottledKey, 1)
+
+		return
+	}
+
+	i.multicastAndRecord(
+		ctx,
 		i.backend.BuildRoundChangeMessage(
 			i.state.getLatestPreparedProposal(),
 			i.state.getLatestPC(),
-			&proto.View{
-				Height: height,
-				Round:  newRound,
-			},
+			view,
 		),
 	)
 }
 
 // sendPrepareMessage sends out the prepare message
-func (i *IBFT) sendPrepareMessage(view *proto.View) {
-	i.transport.Multicast(
+func (i *IBFT) sendPrepareMessage(ctx context.Context, view *proto.View) {
+	i.state.setPhaseStart(proto.MessageType_PREPARE, time.Now())
+
+	if i.isObserving() {
+		return
+	}
+
+	i.multicastAndRecord(
+		ctx,
 		i.backend.BuildPrepareMessage(
 			i.state.getProposalHash(),
 			view,
@@ -1235,8 +2628,15 @@ func (i *IBFT) sendPrepareMessage(view *proto.View) {
 }
 
 // sendCommitMessage sends out the commit message
-func (i *IBFT) sendCommitMessage(view *proto.View) {
-	i.transport.Multicast(
+func (i *IBFT) sendCommitMessage(ctx context.Context, view *proto.View) {
+	i.state.setPhaseStart(proto.MessageType_COMMIT, time.Now())
+
+	if i.isObserving() {
+		return
+	}
+
+	i.multicastAndRecord(
+		ctx,
 		i.backend.BuildCommitMessage(
 			i.state.getProposalHash(),
 			view,
@@ -1244,19 +2644,245 @@ func (i *IBFT) sendCommitMessage(view *proto.View) {
 	)
 }
 
-// getRoundTimeout creates a round timeout based on the base timeout and the current round.
-// Exponentially increases timeout depending on the round number.
+// rebroadcastCommit rebroadcasts the COMMIT message of the node each time
+// the configured fraction of the round timeout passes without the commit quorum
+// being observed, up to the configured number of retries. A single lost COMMIT
+// of a validator needed for the quorum would otherwise force a round change
+func (i *IBFT) rebroadcastCommit(ctx context.Context, view *proto.View) {
+	if i.commitRebroadcastFraction <= 0 {
+		return
+	}
+
+	interval := time.Duration(i.commitRebroadcastFraction * float64(i.roundTimeout(view.Round)))
+
+	// The round timer is not used, as it signals the round expiry only
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	for retry := uint(0); retry < i.commitRebroadcastRetries; retry++ {
+		if retry > 0 {
+			timer.Reset(interval)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		if i.quorumMemo.hasQuorum(view, proto.MessageType_COMMIT) {
+			return
+		}
+
+		commit := i.state.getLastSent()
+		if commit.GetType() != proto.MessageType_COMMIT {
+			return
+		}
+
+		i.log.Debug("rebroadcasting commit message", "round", view.Round, "retry", retry+1)
+		i.metrics.IncrCounter(commitRebroadcastKey, 1)
+
+		i.multicast(ctx, commit)
+	}
+}
+
+// multicastAndRecord multicasts the message, and saves it
+// as the latest sent message, for possible rebroadcasts
+func (i *IBFT) multicastAndRecord(ctx context.Context, message *proto.Message) {
+	i.state.setLastSent(message)
+
+	if !i.saveRecoveryState() {
+		return
+	}
+
+	i.multicast(ctx, message)
+}
+
+// Rebroadcast multicasts the latest PREPARE, COMMIT or ROUND_CHANGE message
+// the node sent in the current height, if any. It can be used for recovering
+// from message loss without waiting for a round change.
+// ROUND_CHANGE rebroadcasts are rate-limited (see WithRoundChangeRebroadcastInterval),
+// and suppressed once a quorum of ROUND_CHANGE messages for the round is observed.
+// Retries of a failed multicast are aborted once the context is cancelled
+func (i *IBFT) Rebroadcast(ctx context.Context) {
+	message := i.state.getLastSent()
+	if message == nil {
+		return
+	}
+
+	if message.Type == proto.MessageType_ROUND_CHANGE && !i.allowRoundChangeRebroadcast(message.View) {
+		return
+	}
+
+	i.log.Debug("rebroadcasting message", "type", message.Type)
+
+	i.multicast(ctx, message)
+}
+
+// watchForDuplicateProposals rebroadcasts the latest sent message when
+// duplicate proposals for the current view are received, and replies to
+// their proposers. The rebroadcasts and replies run in the round worker,
+// so they never block the message reception
+func (i *IBFT) watchForDuplicateProposals(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-i.duplicateProposal:
+			i.rebroadcastOnDuplicate(ctx)
+		case proposer := <-i.proposalReplies:
+			i.replyToProposer(proposer)
+		}
+	}
+}
+
+// signalProposalReply notifies the round worker that the proposer resent
+// its proposal, without blocking. Nothing is signalled if the transport
+// cannot unicast the reply
+func (i *IBFT) signalProposalReply(proposer []byte) {
+	// The node behind sentries does not talk to the proposer directly
+	if _, ok := i.transport.(Unicaster); !ok || len(i.sentries) > 0 {
+		return
+	}
+
+	select {
+	case i.proposalReplies <- proposer:
+	default:
+		// A reply is already pending
+	}
+}
+
+// replyToProposer unicasts the node's PREPARE and COMMIT messages for the current view
+// to the proposer that resent its proposal, so the proposer can converge without
+// waiting for the round to time out. Nothing is sent if the node has not yet
+// prepared the proposal. Failed replies are not retried, the proposer resends
+// the proposal if it is still missing the messages
+func (i *IBFT) replyToProposer(proposer []byte) {
+	unicaster, ok := i.transport.(Unicaster)
+	if !ok || bytes.Equal(proposer, i.backend.ID()) {
+		return
+	}
+
+	for _, message := range i.sentForView(i.state.getView()) {
+		if err := unicaster.Unicast(proposer, message); err != nil {
+			i.log.Error("unable to reply to proposer", "type", message.Type, "err", err)
+
+			continue
+		}
+
+		i.metrics.IncrCounter(proposalReplyKey, 1)
+	}
+}
+
+// sentForView returns the PREPARE and COMMIT messages the node sent for the view.
+// The messages are looked up in the store, falling back to the latest sent message
+// if the transport does not deliver the node's messages back to it
+func (i *IBFT) sentForView(view *proto.View) []*proto.Message {
+	sent := i.messages.Query(messages.MessageQuery{
+		Height:  view.Height,
+		Round:   &view.Round,
+		Types:   []proto.MessageType{proto.MessageType_PREPARE, proto.MessageType_COMMIT},
+		Senders: [][]byte{i.backend.ID()},
+	})
+
+	lastSent := i.state.getLastSent()
+	if lastSent == nil ||
+		lastSent.View.Height != view.Height || lastSent.View.Round != view.Round ||
+		lastSent.Type != proto.MessageType_PREPARE && lastSent.Type != proto.MessageType_COMMIT {
+		return sent
+	}
+
+	for _, message := range sent {
+		if message.Type == lastSent.Type {
+			return sent
+		}
+	}
+
+	return append(sent, lastSent)
+}
+
+// signalDuplicateProposal notifies the round worker that
+// a duplicate proposal was received, without blocking
+func (i *IBFT) signalDuplicateProposal() {
+	select {
+	case i.duplicateProposal <- struct{}{}:
+	default:
+		// A rebroadcast is already pending
+	}
+}
+
+// rebroadcastOnDuplicate rebroadcasts the latest sent message when peers
+// appear to be stuck in the current view. The rebroadcasts are throttled,
+// so peers cannot use duplicates to amplify the node's traffic
+func (i *IBFT) rebroadcastOnDuplicate(ctx context.Context) {
+	i.rebroadcastLock.Lock()
+
+	if time.Since(i.lastRebroadcast) < minRebroadcastInterval {
+		i.rebroadcastLock.Unlock()
+
+		return
+	}
+
+	i.lastRebroadcast = time.Now()
+	i.rebroadcastLock.Unlock()
+
+	i.Rebroadcast(ctx)
+}
+
+// isDuplicateProposal checks if the proposal is a resend of a proposal
+// already received for the current view, from the same sender
+func (i *IBFT) isDuplicateProposal(message *proto.Message) bool {
+	if message.Type != proto.MessageType_PREPREPARE {
+		return false
+	}
+
+	view := i.state.getView()
+	if message.View.Height != view.Height || message.View.Round != view.Round {
+		return false
+	}
+
+	proposals := i.messages.GetValidMessages(
+		view,
+		proto.MessageType_PREPREPARE,
+		func(_ *proto.Message) bool { return true },
+	)
+
+	for _, proposal := range proposals {
+		if bytes.Equal(proposal.From, message.From) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getRoundTimeout creates a round timeout based on the timeout strategy,
+// the base timeout and the current round.
+// The default strategy exponentially increases timeout depending on the round number.
 // For instance:
 //   - round 1: 1 sec
 //   - round 2: 2 sec
 //   - round 3: 4 sec
 //   - round 4: 8 sec
-func getRoundTimeout(baseRoundTimeout, additionalTimeout time.Duration, round uint64) time.Duration {
-	var (
-		duration     = int(baseRoundTimeout)
-		roundFactor  = int(math.Pow(roundFactorBase, float64(round)))
-		roundTimeout = time.Duration(duration * roundFactor)
-	)
+//
+// If the max round is set, the later rounds get the timeout of the max round.
+// If the max timeout is set, the round timeout saturates at that value
+func getRoundTimeout(
+	strategy TimeoutStrategy,
+	baseRoundTimeout,
+	maxRoundTimeout time.Duration,
+	maxRound,
+	round uint64,
+) time.Duration {
+	if maxRound > 0 && round > maxRound {
+		round = maxRound
+	}
+
+	roundTimeout := strategy.RoundTimeout(baseRoundTimeout, round)
+
+	if maxRoundTimeout > 0 && roundTimeout > maxRoundTimeout {
+		return maxRoundTimeout
+	}
 
-	return roundTimeout + additionalTimeout
+	return roundTimeout
 }
@@ -4,12 +4,14 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"math"
 	"math/rand"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	protoBuf "google.golang.org/protobuf/proto"
 
 	"github.com/renloi/ibft/messages"
 	"github.com/renloi/ibft/messages/proto"
@@ -283,13 +285,10 @@ func TestRunNewRound_Proposer(t *testing.T) {
 			i := NewIBFT(log, backend, transport)
 			i.messages = messages
 
-			i.wg.Add(1)
 			i.startRound(ctx)
 
-			i.wg.Wait()
-
 			// Make sure the accepted proposal is the one proposed to other nodes
-			assert.Equal(t, multicastedProposal, i.state.proposalMessage)
+			assert.Equal(t, multicastedProposal, i.state.getProposalMessage())
 
 			// Make sure the accepted proposal matches what was built
 			assert.True(
@@ -413,13 +412,10 @@ func TestRunNewRound_Proposer(t *testing.T) {
 
 			notifyCh <- 1
 
-			i.wg.Add(1)
 			i.startRound(ctx)
 
-			i.wg.Wait()
-
 			// Make sure the multicasted proposal is the accepted proposal
-			assert.Equal(t, multicastedPreprepare, i.state.proposalMessage)
+			assert.Equal(t, multicastedPreprepare, i.state.getProposalMessage())
 
 			// Make sure the correct proposal value was multicasted
 			assert.True(t, proposalMatches(correctRoundMessage.proposal, multicastedPreprepare))
@@ -586,13 +582,10 @@ func TestRunNewRound_Proposer(t *testing.T) {
 
 			notifyCh <- 1
 
-			i.wg.Add(1)
 			i.startRound(ctx)
 
-			i.wg.Wait()
-
 			// Make sure the multicasted proposal is the accepted proposal
-			assert.Equal(t, multicastedPreprepare, i.state.proposalMessage)
+			assert.Equal(t, multicastedPreprepare, i.state.getProposalMessage())
 
 			// Make sure the correct proposal was multicasted
 			assert.True(t, proposalMatches(lastPreparedProposedProposal, multicastedPreprepare))
@@ -688,11 +681,8 @@ func TestRunNewRound_Validator_Zero(t *testing.T) {
 	// Make sure the notification is sent out
 	notifyCh <- 0
 
-	i.wg.Add(1)
 	i.startRound(ctx)
 
-	i.wg.Wait()
-
 	// Make sure the accepted proposal is the one that was sent out
 	assert.Equal(t, correctRoundMessage.proposal, i.state.getProposal())
 
@@ -853,11 +843,8 @@ func TestRunNewRound_Validator_NonZero(t *testing.T) {
 			// Make sure the notification is sent out
 			notifyCh <- 1
 
-			i.wg.Add(1)
 			i.startRound(ctx)
 
-			i.wg.Wait()
-
 			// Make sure the accepted proposal is the one that was sent out
 			assert.Equal(t, correctRoundMessage.proposal, i.state.getProposal())
 
@@ -942,27 +929,24 @@ func TestRunPrepare(t *testing.T) {
 			)
 
 			i := NewIBFT(log, backend, transport)
-			i.state.roundStarted = true
-			i.state.proposalMessage = &proto.Message{
+			i.state.setRoundStarted(true)
+			i.state.setProposalMessage(&proto.Message{
 				Payload: &proto.Message_PreprepareData{
 					PreprepareData: &proto.PrePrepareMessage{
 						Proposal:     correctRoundMessage.proposal,
 						ProposalHash: correctRoundMessage.hash,
 					},
 				},
-			}
+			})
 			i.messages = &messages
 
 			// Make sure the notification is present
 			notifyCh <- 0
 
-			i.wg.Add(1)
 			i.startRound(ctx)
 
-			i.wg.Wait()
-
 			// Make sure the node sent commit message
-			assert.True(t, i.state.commitSent)
+			assert.True(t, i.state.getCommitSent())
 
 			// Make sure the proposal didn't change
 			assert.Equal(t, correctRoundMessage.proposal, i.state.getProposal())
@@ -971,6 +955,103 @@ func TestRunPrepare(t *testing.T) {
 			assert.True(t, commitHashMatches(correctRoundMessage.hash, multicastedCommit))
 		},
 	)
+
+	t.Run(
+		"backend observes the prepared certificate",
+		func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancelFn := context.WithCancel(context.Background())
+
+			var (
+				observedPC       *proto.PreparedCertificate
+				observedProposal *proto.Proposal
+				notifyCh         = make(chan uint64, 1)
+
+				// The proposal is not shared with the parallel tests
+				roundMessage = newCorrectRoundMessage(0)
+
+				log     = mockLogger{}
+				backend = preparedObserverBackend{
+					mockBackend: mockBackend{
+						hasQuorumFn: func(_ uint64, messages []*proto.Message, _ proto.MessageType) bool {
+							return len(messages) >= 1
+						},
+					},
+					onPreparedFn: func(certificate *proto.PreparedCertificate, proposal *proto.Proposal) {
+						observedPC = certificate
+						observedProposal = proposal
+					},
+				}
+				prepareMessage = &proto.Message{
+					View: &proto.View{},
+					Type: proto.MessageType_PREPARE,
+					Payload: &proto.Message_PrepareData{
+						PrepareData: &proto.PrepareMessage{
+							ProposalHash: roundMessage.hash,
+						},
+					},
+				}
+				messages = mockMessages{
+					subscribeFn: func(_ messages.SubscriptionDetails) *messages.Subscription {
+						return &messages.Subscription{
+							ID:    messages.SubscriptionID(1),
+							SubCh: notifyCh,
+						}
+					},
+					unsubscribeFn: func(_ messages.SubscriptionID) {
+						cancelFn()
+					},
+					getValidMessagesFn: func(
+						_ *proto.View,
+						_ proto.MessageType,
+						isValid func(message *proto.Message) bool,
+					) []*proto.Message {
+						return filterMessages([]*proto.Message{prepareMessage}, isValid)
+					},
+				}
+			)
+
+			i := NewIBFT(log, backend, mockTransport{})
+			i.state.setRoundStarted(true)
+			i.state.setProposalMessage(&proto.Message{
+				Payload: &proto.Message_PreprepareData{
+					PreprepareData: &proto.PrePrepareMessage{
+						Proposal:     roundMessage.proposal,
+						ProposalHash: roundMessage.hash,
+					},
+				},
+			})
+			i.messages = &messages
+
+			// Make sure the notification is present
+			notifyCh <- 0
+
+			i.startRound(ctx)
+
+			// Make sure the backend observed the prepared certificate
+			if !assert.NotNil(t, observedPC) {
+				return
+			}
+
+			assert.True(t, protoBuf.Equal(i.state.getLatestPC(), observedPC))
+			assert.True(t, protoBuf.Equal(roundMessage.proposal, observedProposal))
+
+			// Make sure the observed certificate is a copy
+			assert.NotSame(t, i.state.getLatestPC(), observedPC)
+		},
+	)
+}
+
+// preparedObserverBackend is a mock backend that observes prepared certificates
+type preparedObserverBackend struct {
+	mockBackend
+
+	onPreparedFn func(*proto.PreparedCertificate, *proto.Proposal)
+}
+
+func (b preparedObserverBackend) OnPrepared(certificate *proto.PreparedCertificate, proposal *proto.Proposal) {
+	b.onPreparedFn(certificate, proposal)
 }
 
 // TestRunCommit makes sure the node
@@ -1046,16 +1127,16 @@ func TestRunCommit(t *testing.T) {
 
 			i := NewIBFT(log, backend, transport)
 			i.messages = messages
-			i.state.proposalMessage = &proto.Message{
+			i.state.setProposalMessage(&proto.Message{
 				Payload: &proto.Message_PreprepareData{
 					PreprepareData: &proto.PrePrepareMessage{
 						Proposal:     correctRoundMessage.proposal,
 						ProposalHash: correctRoundMessage.hash,
 					},
 				},
-			}
-			i.state.roundStarted = true
-			i.state.commitSent = true
+			})
+			i.state.setRoundStarted(true)
+			i.state.setCommitSent(true)
 
 			ctx, cancelFn := context.WithCancel(context.Background())
 
@@ -1068,8 +1149,8 @@ func TestRunCommit(t *testing.T) {
 				}()
 
 				select {
-				case <-i.roundDone:
-					doneReceived = true
+				case event := <-i.roundEvents:
+					doneReceived = event.eventType == roundEventDone
 				case <-time.After(5 * time.Second):
 					return
 				}
@@ -1078,11 +1159,8 @@ func TestRunCommit(t *testing.T) {
 			// Make sure the notification is ready
 			notifyCh <- 0
 
-			i.wg.Add(1)
 			i.startRound(ctx)
 
-			i.wg.Wait()
-
 			// Make sure the inserted proposal was the one present
 			assert.Equal(t, insertedProposal, correctRoundMessage.proposal.RawProposal)
 
@@ -1112,6 +1190,7 @@ func TestIBFT_IsAcceptableMessage(t *testing.T) {
 		currentView   *proto.View
 		invalidSender bool
 		acceptable    bool
+		rejection     rejectionReason
 	}{
 		{
 			"invalid sender",
@@ -1119,6 +1198,7 @@ func TestIBFT_IsAcceptableMessage(t *testing.T) {
 			baseView,
 			true,
 			false,
+			rejectInvalidValidator,
 		},
 		{
 			"malformed message",
@@ -1126,6 +1206,7 @@ func TestIBFT_IsAcceptableMessage(t *testing.T) {
 			baseView,
 			false,
 			false,
+			rejectNilView,
 		},
 		{
 			"higher height number",
@@ -1136,6 +1217,7 @@ func TestIBFT_IsAcceptableMessage(t *testing.T) {
 			baseView,
 			false,
 			true,
+			"",
 		},
 		{
 			"higher round number",
@@ -1146,6 +1228,7 @@ func TestIBFT_IsAcceptableMessage(t *testing.T) {
 			baseView,
 			false,
 			true,
+			"",
 		},
 		{
 			"lower height number",
@@ -1156,6 +1239,18 @@ func TestIBFT_IsAcceptableMessage(t *testing.T) {
 			},
 			false,
 			false,
+			rejectStaleHeight,
+		},
+		{
+			"lower round number",
+			baseView,
+			&proto.View{
+				Height: baseView.Height,
+				Round:  baseView.Round + 1,
+			},
+			false,
+			false,
+			rejectStaleRound,
 		},
 	}
 
@@ -1168,24 +1263,208 @@ func TestIBFT_IsAcceptableMessage(t *testing.T) {
 			var (
 				log       = mockLogger{}
 				transport = mockTransport{}
+				metrics   = &counterMetrics{}
 				backend   = mockBackend{
 					IsValidValidatorFn: func(message *proto.Message) bool {
 						return !testCase.invalidSender
 					},
 				}
 			)
-			i := NewIBFT(log, backend, transport)
-			i.state.view = testCase.currentView
+			i := NewIBFT(log, backend, transport, WithMetrics(metrics))
+			i.state.setView(testCase.currentView)
 
 			message := &proto.Message{
 				View: testCase.view,
 			}
 
-			assert.Equal(t, testCase.acceptable, i.isAcceptableMessage(message))
+			assert.Equal(t, testCase.acceptable, i.isAcceptableMessage(message, untrustedSender))
+
+			// Make sure the rejection is counted by its reason
+			for _, reason := range rejectionReasons {
+				expected := float32(0)
+				if reason == testCase.rejection {
+					expected = 1
+				}
+
+				assert.Equal(t, expected, metrics.counter(rejectionKey(reason)), reason)
+			}
+		})
+	}
+}
+
+// TestIBFT_IsAcceptableMessage_DKG makes sure the DKG ceremony
+// messages are not accepted by the consensus
+func TestIBFT_IsAcceptableMessage_DKG(t *testing.T) {
+	t.Parallel()
+
+	metrics := &counterMetrics{}
+
+	i := NewIBFT(mockLogger{}, mockBackend{}, mockTransport{}, WithMetrics(metrics))
+
+	message := &proto.Message{
+		View: &proto.View{Height: 0, Round: 0},
+		Type: proto.MessageType_DKG,
+	}
+
+	assert.False(t, i.isAcceptableMessage(message, untrustedSender))
+	assert.Equal(t, float32(1), metrics.counter(rejectionKey(rejectUnsupportedType)))
+}
+
+// TestIBFT_IsAcceptableMessage_FutureTimestamp makes sure messages
+// with timestamps too far in the future are rejected, if the check is enabled
+func TestIBFT_IsAcceptableMessage_FutureTimestamp(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1700000000, 0)
+
+	testTable := []struct {
+		name       string
+		timestamp  time.Time
+		raw        uint64
+		tolerance  time.Duration
+		acceptable bool
+	}{
+		{
+			"check disabled",
+			now.Add(time.Hour),
+			0,
+			0,
+			true,
+		},
+		{
+			"timestamp not set",
+			time.Time{},
+			0,
+			time.Second,
+			true,
+		},
+		{
+			"past timestamp",
+			now.Add(-time.Hour),
+			0,
+			time.Second,
+			true,
+		},
+		{
+			"timestamp within the skew",
+			now.Add(time.Second),
+			0,
+			time.Second,
+			true,
+		},
+		{
+			"timestamp beyond the skew",
+			now.Add(time.Second + time.Nanosecond),
+			0,
+			time.Second,
+			false,
+		},
+		{
+			"timestamp beyond the time range",
+			time.Time{},
+			math.MaxInt64 + 1,
+			time.Second,
+			false,
+		},
+		{
+			"timestamp beyond the time range, check disabled",
+			time.Time{},
+			math.MaxUint64,
+			0,
+			false,
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			metrics := &counterMetrics{}
+
+			i := NewIBFT(
+				mockLogger{},
+				mockBackend{},
+				mockTransport{},
+				WithMetrics(metrics),
+				WithClockSkewTolerance(testCase.tolerance),
+				WithClock(func() time.Time {
+					return now
+				}),
+			)
+
+			timestamp := testCase.raw
+			if !testCase.timestamp.IsZero() {
+				timestamp = uint64(testCase.timestamp.UnixNano())
+			}
+
+			message := &proto.Message{
+				View: &proto.View{Height: 0, Round: 0},
+				Type: proto.MessageType_PREPREPARE,
+				Payload: &proto.Message_PreprepareData{
+					PreprepareData: &proto.PrePrepareMessage{
+						Timestamp: timestamp,
+					},
+				},
+			}
+
+			assert.Equal(t, testCase.acceptable, i.isAcceptableMessage(message, untrustedSender))
+
+			expected := float32(1)
+			if testCase.acceptable {
+				expected = 0
+			}
+
+			assert.Equal(t, expected, metrics.counter(rejectionKey(rejectFutureTimestamp)))
 		})
 	}
 }
 
+// TestIBFT_RejectionLogSampling makes sure only every n-th
+// rejected message is logged, for each rejection reason
+func TestIBFT_RejectionLogSampling(t *testing.T) {
+	t.Parallel()
+
+	var (
+		logged = make(map[string]int)
+		log    = mockLogger{
+			infoFn: func(msg string, args ...interface{}) {
+				if msg != "message rejected" {
+					return
+				}
+
+				// The mock logger passes the fields as a single argument
+				fields, _ := args[0].([]interface{})
+
+				logged[fields[1].(string)]++
+			},
+		}
+		transport = mockTransport{}
+		backend   = mockBackend{
+			IsValidValidatorFn: func(message *proto.Message) bool {
+				return true
+			},
+		}
+	)
+
+	i := NewIBFT(log, backend, transport, WithRejectionLogSampling(2))
+	i.state.setView(&proto.View{Height: 1, Round: 1})
+
+	for n := 0; n < 5; n++ {
+		assert.False(t, i.isAcceptableMessage(&proto.Message{}, untrustedSender))
+		assert.False(t, i.isAcceptableMessage(&proto.Message{
+			View: &proto.View{Height: 1, Round: 0},
+		}, untrustedSender))
+	}
+
+	// Rejections 1, 3 and 5 of each reason are logged
+	assert.Equal(t, map[string]int{
+		string(rejectNilView):    3,
+		string(rejectStaleRound): 3,
+	}, logged)
+}
+
 // TestIBFT_StartRoundTimer makes sure that the
 // round timer behaves correctly
 func TestIBFT_StartRoundTimer(t *testing.T) {
@@ -1207,7 +1486,6 @@ func TestIBFT_StartRoundTimer(t *testing.T) {
 		ctx, cancelFn := context.WithCancel(context.Background())
 
 		wg.Add(1)
-		i.wg.Add(1)
 		go func() {
 			i.startRoundTimer(ctx, 0)
 
@@ -1245,13 +1523,12 @@ func TestIBFT_StartRoundTimer(t *testing.T) {
 			}()
 
 			select {
-			case <-i.roundExpired:
-				expired = true
+			case event := <-i.roundEvents:
+				expired = event.eventType == roundEventExpired
 			case <-time.After(5 * time.Second):
 			}
 		}()
 
-		i.wg.Add(1)
 		i.startRoundTimer(ctx, 0)
 
 		wg.Wait()
@@ -1287,6 +1564,48 @@ func TestIBFT_MoveToNewRound(t *testing.T) {
 		// Make sure the proposal is not present
 		assert.Nil(t, i.state.getProposal())
 	})
+
+	t.Run("lower rounds are pruned", func(t *testing.T) {
+		t.Parallel()
+
+		testTable := []struct {
+			name   string
+			opts   []Option
+			pruned bool
+		}{
+			{"round pruning by default", nil, true},
+			{"round pruning disabled", []Option{WithoutRoundPruning()}, false},
+		}
+
+		for _, testCase := range testTable {
+			testCase := testCase
+
+			t.Run(testCase.name, func(t *testing.T) {
+				t.Parallel()
+
+				var prunedView *proto.View
+
+				i := NewIBFT(mockLogger{}, mockBackend{}, mockTransport{}, testCase.opts...)
+				i.messages = mockMessages{
+					pruneByRoundFn: func(height, round uint64) {
+						prunedView = &proto.View{Height: height, Round: round}
+					},
+				}
+
+				i.state.setView(&proto.View{Height: 5, Round: 0})
+				i.moveToNewRound(2)
+
+				if !testCase.pruned {
+					assert.Nil(t, prunedView)
+
+					return
+				}
+
+				// Make sure the messages below the new round are pruned
+				assert.Equal(t, &proto.View{Height: 5, Round: 2}, prunedView)
+			})
+		}
+	})
 }
 
 // TestIBFT_FutureProposal checks the
@@ -1386,8 +1705,8 @@ func TestIBFT_FutureProposal(t *testing.T) {
 
 			var (
 				wg                    sync.WaitGroup
-				receivedProposalEvent *newProposalEvent = nil
-				notifyCh                                = make(chan uint64, 1)
+				receivedProposalEvent *roundEvent = nil
+				notifyCh                          = make(chan uint64, 1)
 
 				log     = mockLogger{}
 				backend = mockBackend{
@@ -1442,14 +1761,13 @@ func TestIBFT_FutureProposal(t *testing.T) {
 
 				select {
 				case <-time.After(5 * time.Second):
-				case event := <-i.newProposal:
+				case event := <-i.roundEvents:
 					receivedProposalEvent = &event
 				}
 			}()
 
 			notifyCh <- testCase.notifyRound
 
-			i.wg.Add(1)
 			i.watchForFutureProposal(ctx)
 
 			wg.Wait()
@@ -1975,31 +2293,85 @@ func TestIBFT_ValidPC(t *testing.T) {
 
 		assert.True(t, i.validPC(certificate, rLimit, 0))
 	})
-}
-
-func TestIBFT_ValidateProposal(t *testing.T) {
-	t.Parallel()
 
-	t.Run("proposer is not valid", func(t *testing.T) {
+	t.Run("count quorum counts the proposer once", func(t *testing.T) {
 		t.Parallel()
 
 		var (
-			log     = mockLogger{}
-			backend = mockBackend{
-				isProposerFn: func(_ []byte, _ uint64, _ uint64) bool {
-					return false
+			validators = uint64(4)
+			quorum     = QuorumSize(validators)
+			rLimit     = uint64(1)
+			sender     = []byte("unique node")
+
+			log       = mockLogger{}
+			transport = mockTransport{}
+			backend   = mockBackend{
+				isProposerFn: func(proposer []byte, _ uint64, _ uint64) bool {
+					return bytes.Equal(proposer, sender)
+				},
+				IsValidValidatorFn: func(message *proto.Message) bool {
+					return true
 				},
 			}
-			transport = mockTransport{}
 		)
 
-		i := NewIBFT(log, backend, transport)
+		i := NewIBFT(log, backend, transport, WithQuorumVerifier(CountQuorum{
+			ValidatorCount: func(_ uint64) uint64 {
+				return validators
+			},
+		}))
 
-		baseView := &proto.View{
-			Height: 0,
+		buildCertificate := func(prepares uint64) *proto.PreparedCertificate {
+			certificate := &proto.PreparedCertificate{
+				ProposalMessage: generateMessagesWithSender(1, proto.MessageType_PREPREPARE, sender)[0],
+				PrepareMessages: generateMessagesWithUniqueSender(prepares, proto.MessageType_PREPARE),
+			}
+
+			allMessages := append([]*proto.Message{certificate.ProposalMessage}, certificate.PrepareMessages...)
+			appendProposalHash(allMessages, correctRoundMessage.hash)
+			setRoundForMessages(allMessages, rLimit-1)
+
+			return certificate
+		}
+
+		// Make sure the proposer is not counted on top of its PREPREPARE
+		assert.False(t, i.validPC(buildCertificate(quorum-2), rLimit, 0))
+
+		// Make sure the PREPREPARE and the PREPAREs make up the quorum
+		assert.True(t, i.validPC(buildCertificate(quorum-1), rLimit, 0))
+	})
+}
+
+// timestampBackend is a mock backend that verifies proposal timestamps
+type timestampBackend struct {
+	mockBackend
+
+	isValidProposalTimestampFn func(time.Time, *proto.View) bool
+}
+
+func (b timestampBackend) IsValidProposalTimestamp(timestamp time.Time, view *proto.View) bool {
+	return b.isValidProposalTimestampFn(timestamp, view)
+}
+
+func TestIBFT_ValidateProposalTimestamp(t *testing.T) {
+	t.Parallel()
+
+	var (
+		maxSkew  = time.Second
+		baseView = &proto.View{
+			Height: 1,
 			Round:  0,
 		}
-		proposal := &proto.Message{
+		now = time.Now()
+	)
+
+	buildProposal := func(timestamp time.Time) *proto.Message {
+		var rawTimestamp uint64
+		if !timestamp.IsZero() {
+			rawTimestamp = uint64(timestamp.UnixNano())
+		}
+
+		return &proto.Message{
 			View: baseView,
 			Type: proto.MessageType_PREPREPARE,
 			Payload: &proto.Message_PreprepareData{
@@ -2007,30 +2379,140 @@ func TestIBFT_ValidateProposal(t *testing.T) {
 					Proposal: &proto.Proposal{
 						Round: baseView.Round,
 					},
+					Timestamp: rawTimestamp,
 				},
 			},
 		}
+	}
 
-		assert.False(t, i.validateProposal(proposal, baseView))
-	})
-
-	t.Run("block is not valid", func(t *testing.T) {
-		t.Parallel()
+	backend := timestampBackend{
+		mockBackend: mockBackend{
+			isProposerFn: func(_ []byte, _ uint64, _ uint64) bool {
+				return true
+			},
+		},
+		isValidProposalTimestampFn: func(timestamp time.Time, view *proto.View) bool {
+			assert.Equal(t, baseView, view)
 
-		var (
-			log     = mockLogger{}
-			backend = mockBackend{
-				isProposerFn: func(_ []byte, _ uint64, _ uint64) bool {
-					return true
-				},
-				isValidProposalFn: func(_ []byte) bool {
-					return false
-				},
+			if timestamp.IsZero() {
+				return false
 			}
-			transport = mockTransport{}
-		)
 
-		i := NewIBFT(log, backend, transport)
+			skew := timestamp.Sub(now)
+			if skew < 0 {
+				skew = -skew
+			}
+
+			return skew <= maxSkew
+		},
+	}
+
+	testTable := []struct {
+		name      string
+		timestamp time.Time
+		valid     bool
+	}{
+		{
+			"timestamp within the skew",
+			now.Add(maxSkew / 2),
+			true,
+		},
+		{
+			"timestamp too far in the future",
+			now.Add(2 * maxSkew),
+			false,
+		},
+		{
+			"timestamp too far in the past",
+			now.Add(-2 * maxSkew),
+			false,
+		},
+		{
+			"timestamp not set",
+			time.Time{},
+			false,
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			i := NewIBFT(mockLogger{}, backend, mockTransport{})
+
+			assert.Equal(
+				t,
+				testCase.valid,
+				i.validateProposalCommon(buildProposal(testCase.timestamp), baseView),
+			)
+		})
+	}
+
+	t.Run("backend does not verify timestamps", func(t *testing.T) {
+		t.Parallel()
+
+		i := NewIBFT(mockLogger{}, backend.mockBackend, mockTransport{})
+
+		assert.True(t, i.validateProposalCommon(buildProposal(time.Time{}), baseView))
+	})
+}
+
+func TestIBFT_ValidateProposal(t *testing.T) {
+	t.Parallel()
+
+	t.Run("proposer is not valid", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			log     = mockLogger{}
+			backend = mockBackend{
+				isProposerFn: func(_ []byte, _ uint64, _ uint64) bool {
+					return false
+				},
+			}
+			transport = mockTransport{}
+		)
+
+		i := NewIBFT(log, backend, transport)
+
+		baseView := &proto.View{
+			Height: 0,
+			Round:  0,
+		}
+		proposal := &proto.Message{
+			View: baseView,
+			Type: proto.MessageType_PREPREPARE,
+			Payload: &proto.Message_PreprepareData{
+				PreprepareData: &proto.PrePrepareMessage{
+					Proposal: &proto.Proposal{
+						Round: baseView.Round,
+					},
+				},
+			},
+		}
+
+		assert.False(t, i.validateProposal(proposal, baseView))
+	})
+
+	t.Run("block is not valid", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			log     = mockLogger{}
+			backend = mockBackend{
+				isProposerFn: func(_ []byte, _ uint64, _ uint64) bool {
+					return true
+				},
+				isValidProposalFn: func(_ []byte) bool {
+					return false
+				},
+			}
+			transport = mockTransport{}
+		)
+
+		i := NewIBFT(log, backend, transport)
 
 		baseView := &proto.View{
 			Height: 0,
@@ -2436,8 +2918,8 @@ func TestIBFT_WatchForFutureRCC(t *testing.T) {
 		}()
 
 		select {
-		case r := <-i.roundCertificate:
-			receivedRound = r
+		case event := <-i.roundEvents:
+			receivedRound = event.round
 		case <-time.After(5 * time.Second):
 		}
 	}()
@@ -2445,9 +2927,7 @@ func TestIBFT_WatchForFutureRCC(t *testing.T) {
 	// Have the notification waiting
 	notifyCh <- rccRound
 
-	i.wg.Add(1)
 	i.watchForRoundChangeCertificates(ctx)
-	i.wg.Wait()
 
 	// Make sure the notification round was correct
 	wg.Wait()
@@ -2459,57 +2939,158 @@ func TestIBFT_WatchForFutureRCC(t *testing.T) {
 func TestIBFT_RunSequence_NewProposal(t *testing.T) {
 	t.Parallel()
 
-	ctx, cancelFn := context.WithCancel(context.Background())
-	defer cancelFn()
-
 	var (
-		proposal = &proto.Proposal{}
 		round    = uint64(10)
 		height   = uint64(1)
 		quorum   = uint64(4)
+		nodeID   = []byte("node ID")
+		proposer = []byte("proposer")
+	)
 
-		log     = mockLogger{}
-		backend = mockBackend{
-			hasQuorumFn: defaultHasQuorumFn(quorum),
+	// generateFutureProposal generates a proposal for the specified round,
+	// accompanied by an RCC with no prepared certificates
+	generateFutureProposal := func(view *proto.View) *proto.Message {
+		roundChangeMessages := generateMessagesWithUniqueSender(quorum, proto.MessageType_ROUND_CHANGE)
+		for _, message := range roundChangeMessages {
+			message.View = view
 		}
-		transport = mockTransport{}
-	)
 
-	i := NewIBFT(log, backend, transport)
-	i.newProposal = make(chan newProposalEvent, 1)
+		roundMessage := newCorrectRoundMessage(view.Round)
 
-	ev := newProposalEvent{
-		proposalMessage: &proto.Message{
+		return &proto.Message{
+			View: view,
+			From: proposer,
+			Type: proto.MessageType_PREPREPARE,
 			Payload: &proto.Message_PreprepareData{
 				PreprepareData: &proto.PrePrepareMessage{
-					Proposal: proposal,
+					Proposal:     roundMessage.proposal,
+					ProposalHash: roundMessage.hash,
+					Certificate: &proto.RoundChangeCertificate{
+						RoundChangeMessages: roundChangeMessages,
+					},
 				},
 			},
+		}
+	}
+
+	testTable := []struct {
+		name         string
+		proposalView *proto.View
+		accepted     bool
+	}{
+		{
+			"valid future proposal",
+			&proto.View{
+				Height: height,
+				Round:  round,
+			},
+			true,
+		},
+		{
+			"future proposal for a different round",
+			&proto.View{
+				Height: height,
+				Round:  round + 1,
+			},
+			false,
+		},
+		{
+			"future proposal for a different height",
+			&proto.View{
+				Height: height + 1,
+				Round:  round,
+			},
+			false,
 		},
-		round: round,
 	}
 
-	// Make sure the event is waiting
-	i.newProposal <- ev
+	for _, testCase := range testTable {
+		testCase := testCase
 
-	// Spawn a go-routine that's going to turn off the sequence after 1s
-	go func() {
-		defer cancelFn()
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
 
-		<-time.After(1 * time.Second)
-	}()
+			ctx, cancelFn := context.WithCancel(context.Background())
+			defer cancelFn()
 
-	i.RunSequence(ctx, height)
+			var (
+				prepareViews = make(chan *proto.View, 1)
+				timer        = &signalTimer{
+					views:   make(chan *proto.View, 2),
+					expires: make(chan struct{}),
+				}
+
+				log     = mockLogger{}
+				backend = mockBackend{
+					hasQuorumFn: defaultHasQuorumFn(quorum),
+					idFn: func() []byte {
+						return nodeID
+					},
+					isProposerFn: func(id []byte, _ uint64, _ uint64) bool {
+						return bytes.Equal(id, proposer)
+					},
+					buildPrepareMessageFn: func(_ []byte, view *proto.View) *proto.Message {
+						prepareViews <- view
 
-	// Make sure the correct proposal message was accepted
-	assert.Equal(t, ev.proposalMessage, i.state.proposalMessage)
+						return &proto.Message{
+							View: view,
+							Type: proto.MessageType_PREPARE,
+						}
+					},
+				}
+				transport = mockTransport{}
+			)
 
-	// Make sure the correct round was moved to
-	assert.Equal(t, ev.round, i.state.view.Round)
-	assert.Equal(t, height, i.state.view.Height)
+			i := NewIBFT(log, backend, transport, WithRoundTimer(timer))
+			ev := roundEvent{
+				eventType:       roundEventProposal,
+				proposalMessage: generateFutureProposal(testCase.proposalView),
+				round:           round,
+			}
+
+			// Make sure the event is waiting
+			i.roundEvents <- ev
+
+			// Spawn a go-routine that's going to turn off the sequence after 1s
+			go func() {
+				defer cancelFn()
+
+				<-time.After(1 * time.Second)
+			}()
+
+			i.RunSequence(ctx, height)
+
+			if !testCase.accepted {
+				// Make sure the proposal was not accepted, and the round was not changed
+				assert.Nil(t, i.state.getProposalMessage())
+				assert.Equal(t, uint64(0), i.state.getRound())
+				assert.Len(t, prepareViews, 0)
+
+				// Make sure the round was not restarted
+				assert.Len(t, timer.views, 1)
+
+				return
+			}
+
+			// Make sure the correct proposal message was accepted
+			assert.Equal(t, ev.proposalMessage, i.state.getProposalMessage())
+
+			// Make sure the correct round was moved to
+			assert.Equal(t, ev.round, i.state.getRound())
+			assert.Equal(t, height, i.state.getHeight())
 
-	// Make sure the round has been started
-	assert.True(t, i.state.roundStarted)
+			// Make sure the round has been started
+			assert.True(t, i.state.getRoundStarted())
+
+			// Make sure the PREPARE was built for the new view
+			if assert.Len(t, prepareViews, 1) {
+				prepareView := <-prepareViews
+
+				assert.Equal(t, height, prepareView.Height)
+				assert.Equal(t, round, prepareView.Round)
+			}
+		})
+	}
 }
 
 // TestIBFT_RunSequence_FutureRCC verifies that the
@@ -2533,10 +3114,11 @@ func TestIBFT_RunSequence_FutureRCC(t *testing.T) {
 	)
 
 	i := NewIBFT(log, backend, transport)
-	i.roundCertificate = make(chan uint64, 1)
-
 	// Make sure the round event is waiting
-	i.roundCertificate <- round
+	i.roundEvents <- roundEvent{
+		eventType: roundEventCertificate,
+		round:     round,
+	}
 
 	// Spawn a go-routine that's going to turn off the sequence after 1s
 	go func() {
@@ -2548,44 +3130,271 @@ func TestIBFT_RunSequence_FutureRCC(t *testing.T) {
 	i.RunSequence(ctx, height)
 
 	// Make sure the proposal message is not set
-	assert.Nil(t, i.state.proposalMessage)
+	assert.Nil(t, i.state.getProposalMessage())
 
 	// Make sure the correct round was moved to
-	assert.Equal(t, round, i.state.view.Round)
-	assert.Equal(t, height, i.state.view.Height)
+	assert.Equal(t, round, i.state.getRound())
+	assert.Equal(t, height, i.state.getHeight())
 
 	// Make sure the new round has been started
-	assert.True(t, i.state.roundStarted)
+	assert.True(t, i.state.getRoundStarted())
 }
 
-// TestIBFT_ExtendRoundTimer makes sure the round timeout
-// is extended correctly
-func TestIBFT_ExtendRoundTimer(t *testing.T) {
+// TestIBFT_RunSequence_RoundHint verifies that the
+// node moves to the hinted round for the current height
+func TestIBFT_RunSequence_RoundHint(t *testing.T) {
 	t.Parallel()
 
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+
 	var (
-		additionalTimeout = 10 * time.Second
+		round  = uint64(5)
+		height = uint64(1)
+		quorum = uint64(4)
 
-		log       = mockLogger{}
-		backend   = mockBackend{}
-		transport = mockTransport{}
+		roundChanges = make(chan *proto.Message, 1)
+
+		log     = mockLogger{}
+		backend = mockBackend{
+			hasQuorumFn: defaultHasQuorumFn(quorum),
+		}
+		transport = mockTransport{func(message *proto.Message) {
+			if message != nil && message.Type == proto.MessageType_ROUND_CHANGE {
+				roundChanges <- message
+			}
+		}}
 	)
 
 	i := NewIBFT(log, backend, transport)
 
-	i.ExtendRoundTimeout(additionalTimeout)
+	sequenceDone := make(chan struct{})
+
+	go func() {
+		defer close(sequenceDone)
+
+		i.RunSequence(ctx, height)
+	}()
+
+	// Keep hinting until the sequence picks up the hint,
+	// as hints are only accepted for the current height
+	var (
+		roundChange *proto.Message
+		deadline    = time.After(5 * time.Second)
+	)
+
+	for roundChange == nil {
+		i.SetRoundHint(height, round)
+
+		select {
+		case roundChange = <-roundChanges:
+		case <-time.After(10 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("round hint not received")
+		}
+	}
+
+	cancelFn()
+	<-sequenceDone
+
+	// Make sure the round change was multicasted for the hinted round
+	assert.Equal(t, height, roundChange.View.Height)
+	assert.Equal(t, round, roundChange.View.Round)
+
+	// Make sure the correct round was moved to
+	assert.Equal(t, round, i.state.getRound())
+	assert.Equal(t, height, i.state.getHeight())
+}
+
+// TestIBFT_RunSequence_StaleRoundHint makes sure a stale
+// round hint does not restart the current round
+func TestIBFT_RunSequence_StaleRoundHint(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+
+	var (
+		height = uint64(1)
+		quorum = uint64(4)
+
+		timer = &signalTimer{
+			views:   make(chan *proto.View, 2),
+			expires: make(chan struct{}),
+		}
+
+		log     = mockLogger{}
+		backend = mockBackend{
+			hasQuorumFn: defaultHasQuorumFn(quorum),
+		}
+	)
+
+	i := NewIBFT(log, backend, mockTransport{}, WithRoundTimer(timer))
+
+	sequenceDone := make(chan struct{})
+
+	go func() {
+		defer close(sequenceDone)
+
+		i.RunSequence(ctx, height)
+	}()
+
+	// Wait for the round to start
+	<-timer.views
+
+	// Hint the current round, which the node is already in
+	i.roundHint <- &proto.View{
+		Height: height,
+		Round:  0,
+	}
+
+	// Make sure the hint is consumed, without restarting the round
+	assert.Eventually(t, func() bool {
+		return len(i.roundHint) == 0
+	}, time.Second, 10*time.Millisecond)
+
+	select {
+	case <-timer.views:
+		t.Fatal("round restarted on a stale hint")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancelFn()
+	<-sequenceDone
+
+	assert.Equal(t, uint64(0), i.state.getRound())
+}
+
+// TestIBFT_RunSequence_RoundEventPriority makes sure the events
+// signaled in the same round are handled by priority, so a finalization
+// signaled along with the round expiry is not lost
+func TestIBFT_RunSequence_RoundEventPriority(t *testing.T) {
+	t.Parallel()
+
+	var (
+		height = uint64(1)
+		quorum = uint64(4)
 
-	// Make sure the round timeout was extended
-	assert.Equal(t, additionalTimeout, i.additionalTimeout)
+		log     = mockLogger{}
+		backend = mockBackend{
+			hasQuorumFn: defaultHasQuorumFn(quorum),
+		}
+	)
+
+	i := NewIBFT(log, backend, mockTransport{})
+
+	// Make sure the events are waiting, the expiry first
+	i.roundEvents <- roundEvent{eventType: roundEventExpired}
+	i.roundEvents <- roundEvent{eventType: roundEventDone}
+
+	sequenceDone := make(chan struct{})
+
+	go func() {
+		defer close(sequenceDone)
+
+		i.RunSequence(context.Background(), height)
+	}()
+
+	// Make sure the sequence is finished, instead of moving to the next round
+	select {
+	case <-sequenceDone:
+	case <-time.After(5 * time.Second):
+		t.Fatal("sequence not finished")
+	}
+
+	assert.Equal(t, uint64(0), i.state.getRound())
+	assert.Len(t, i.roundEvents, 0)
+}
+
+// TestIBFT_RunSequence_SubscriptionsRemoved makes sure the message subscriptions
+// of the round workers are removed once the round is torn down
+func TestIBFT_RunSequence_SubscriptionsRemoved(t *testing.T) {
+	t.Parallel()
+
+	i := NewIBFT(mockLogger{}, mockBackend{}, mockTransport{})
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	sequenceDone := make(chan struct{})
+
+	go func() {
+		defer close(sequenceDone)
+
+		i.RunSequence(ctx, 1)
+	}()
+
+	// Make sure the round workers are subscribed
+	assert.Eventually(t, func() bool {
+		return i.messages.NumSubscriptions() > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancelFn()
+	<-sequenceDone
+
+	assert.Eventually(t, func() bool {
+		return i.messages.NumSubscriptions() == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestRoundEvent_Outranks(t *testing.T) {
+	t.Parallel()
+
+	var (
+		failed      = roundEvent{eventType: roundEventFailed}
+		expired     = roundEvent{eventType: roundEventExpired}
+		hint        = roundEvent{eventType: roundEventHint, round: 5}
+		certificate = roundEvent{eventType: roundEventCertificate, round: 2}
+		proposal    = roundEvent{eventType: roundEventProposal, round: 1}
+		done        = roundEvent{eventType: roundEventDone}
+	)
+
+	ordered := []roundEvent{failed, expired, hint, certificate, proposal, done}
+
+	for index := 1; index < len(ordered); index++ {
+		assert.True(t, ordered[index].outranks(ordered[index-1]))
+		assert.False(t, ordered[index-1].outranks(ordered[index]))
+	}
+
+	// Make sure the higher round has priority for the same event type
+	assert.True(t, roundEvent{eventType: roundEventCertificate, round: 3}.outranks(certificate))
+	assert.False(t, certificate.outranks(certificate))
+}
+
+// TestIBFT_SetRoundHint makes sure only relevant
+// round hints are passed to the sequence routine
+func TestIBFT_SetRoundHint(t *testing.T) {
+	t.Parallel()
+
+	i := NewIBFT(mockLogger{}, mockBackend{}, mockTransport{})
+	i.state.setView(&proto.View{
+		Height: 10,
+		Round:  2,
+	})
+
+	// Make sure stale hints are ignored
+	i.SetRoundHint(9, 5)
+	i.SetRoundHint(11, 5)
+	i.SetRoundHint(10, 2)
+	i.SetRoundHint(10, 1)
+
+	assert.Len(t, i.roundHint, 0)
+
+	// Make sure the pending hint is replaced by the latest one
+	i.SetRoundHint(10, 3)
+	i.SetRoundHint(10, 4)
+
+	if assert.Len(t, i.roundHint, 1) {
+		assert.Equal(t, &proto.View{Height: 10, Round: 4}, <-i.roundHint)
+	}
 }
 
 func Test_getRoundTimeout(t *testing.T) {
 	t.Parallel()
 
 	type args struct {
-		baseRoundTimeout  time.Duration
-		additionalTimeout time.Duration
-		round             uint64
+		baseRoundTimeout time.Duration
+		maxRoundTimeout  time.Duration
+		maxRound         uint64
+		round            uint64
 	}
 
 	tests := []struct {
@@ -2593,23 +3402,74 @@ func Test_getRoundTimeout(t *testing.T) {
 		args args
 		want time.Duration
 	}{
+		{
+			name: "zero round duration",
+			args: args{
+				baseRoundTimeout: time.Second,
+				round:            0,
+			},
+			want: time.Second,
+		},
 		{
 			name: "first round duration",
 			args: args{
-				baseRoundTimeout:  time.Second,
-				additionalTimeout: time.Second,
-				round:             0,
+				baseRoundTimeout: time.Second,
+				round:            1,
 			},
 			want: time.Second * 2,
 		},
 		{
-			name: "zero round duration",
+			name: "duration below the ceiling",
+			args: args{
+				baseRoundTimeout: time.Second,
+				maxRoundTimeout:  time.Minute,
+				round:            3,
+			},
+			want: time.Second * 8,
+		},
+		{
+			name: "duration saturates at the ceiling",
+			args: args{
+				baseRoundTimeout: time.Second,
+				maxRoundTimeout:  time.Minute,
+				round:            10,
+			},
+			want: time.Minute,
+		},
+		{
+			name: "duration saturates at the ceiling for huge rounds",
+			args: args{
+				baseRoundTimeout: time.Second,
+				maxRoundTimeout:  time.Minute,
+				round:            1000,
+			},
+			want: time.Minute,
+		},
+		{
+			name: "duration doesn't overflow without a ceiling",
 			args: args{
-				baseRoundTimeout:  time.Second,
-				additionalTimeout: time.Second,
-				round:             1,
+				baseRoundTimeout: time.Second,
+				round:            100,
 			},
-			want: time.Second * 3,
+			want: time.Duration(math.MaxInt64),
+		},
+		{
+			name: "duration below the max round",
+			args: args{
+				baseRoundTimeout: time.Second,
+				maxRound:         3,
+				round:            2,
+			},
+			want: time.Second * 4,
+		},
+		{
+			name: "duration stops growing at the max round",
+			args: args{
+				baseRoundTimeout: time.Second,
+				maxRound:         3,
+				round:            100,
+			},
+			want: time.Second * 8,
 		},
 	}
 
@@ -2619,12 +3479,37 @@ func Test_getRoundTimeout(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
-			got := getRoundTimeout(tt.args.baseRoundTimeout, tt.args.additionalTimeout, tt.args.round)
-			assert.Equalf(t, tt.want, got, "getRoundTimeout(%v, %v, %v)", tt.args.baseRoundTimeout, tt.args.additionalTimeout, tt.args.round)
+			got := getRoundTimeout(
+				ExponentialTimeout{},
+				tt.args.baseRoundTimeout,
+				tt.args.maxRoundTimeout,
+				tt.args.maxRound,
+				tt.args.round,
+			)
+			assert.Equalf(
+				t,
+				tt.want,
+				got,
+				"getRoundTimeout(%v, %v, %v, %v)",
+				tt.args.baseRoundTimeout,
+				tt.args.maxRoundTimeout,
+				tt.args.maxRound,
+				tt.args.round,
+			)
 		})
 	}
 }
 
+// TestIBFT_WithMaxRoundTimeout makes sure the
+// round timeout ceiling option is applied
+func TestIBFT_WithMaxRoundTimeout(t *testing.T) {
+	t.Parallel()
+
+	i := NewIBFT(mockLogger{}, mockBackend{}, mockTransport{}, WithMaxRoundTimeout(time.Minute))
+
+	assert.Equal(t, time.Minute, i.maxRoundTimeout)
+}
+
 func TestIBFT_AddMessage(t *testing.T) {
 	t.Parallel()
 
@@ -2636,57 +3521,38 @@ func TestIBFT_AddMessage(t *testing.T) {
 
 	var validSender = []byte{1, 2, 3}
 
-	executeTest := func(
-		msg *proto.Message,
-		hasQuorum, shouldAddMessageCalled, shouldHasQuorumCalled, shouldSignalEventCalled bool) {
+	executeTest := func(msg *proto.Message, shouldAddMessageCalled bool) {
 		var (
-			hasQuorumCalled   = false
-			signalEventCalled = false
-			addMessageCalled  = false
-			log               = mockLogger{}
-			backend           = mockBackend{}
-			transport         = mockTransport{}
-			messages          = mockMessages{}
+			addMessageCalled = false
+			log              = mockLogger{}
+			backend          = mockBackend{}
+			transport        = mockTransport{}
+			messages         = mockMessages{}
 		)
 
 		backend.IsValidValidatorFn = func(m *proto.Message) bool {
 			return bytes.Equal(m.From, validSender)
 		}
 
-		backend.hasQuorumFn = func(height uint64, _ []*proto.Message, msgType proto.MessageType) bool {
-			hasQuorumCalled = true
-
-			assert.Equal(t, validHeight, height)
-			assert.Equal(t, validMsgType, msgType)
-
-			return hasQuorum
-		}
-
 		messages.addMessageFn = func(m *proto.Message) {
 			addMessageCalled = true
 
 			assert.Equal(t, msg, m)
 		}
 
-		messages.signalEventFn = func(*proto.Message) {
-			signalEventCalled = true
-		}
-
 		i := NewIBFT(log, backend, transport)
 		i.messages = messages
-		i.state.view = &proto.View{Height: validHeight, Round: validRound}
+		i.state.setView(&proto.View{Height: validHeight, Round: validRound})
 
 		i.AddMessage(msg)
 
 		assert.Equal(t, shouldAddMessageCalled, addMessageCalled)
-		assert.Equal(t, shouldHasQuorumCalled, hasQuorumCalled)
-		assert.Equal(t, shouldSignalEventCalled, signalEventCalled)
 	}
 
 	t.Run("nil message case", func(t *testing.T) {
 		t.Parallel()
 
-		executeTest(nil, true, false, false, false)
+		executeTest(nil, false)
 	})
 
 	t.Run("!isAcceptableMessage - invalid sender", func(t *testing.T) {
@@ -2696,7 +3562,7 @@ func TestIBFT_AddMessage(t *testing.T) {
 			View: &proto.View{Height: validHeight, Round: validRound},
 			Type: validMsgType,
 		}
-		executeTest(msg, true, false, false, false)
+		executeTest(msg, false)
 	})
 
 	t.Run("!isAcceptableMessage - invalid view", func(t *testing.T) {
@@ -2706,7 +3572,7 @@ func TestIBFT_AddMessage(t *testing.T) {
 			From: validSender,
 			Type: validMsgType,
 		}
-		executeTest(msg, true, false, false, false)
+		executeTest(msg, false)
 	})
 
 	t.Run("!isAcceptableMessage - invalid height", func(t *testing.T) {
@@ -2717,7 +3583,7 @@ func TestIBFT_AddMessage(t *testing.T) {
 			Type: validMsgType,
 			View: &proto.View{Height: validHeight - 1, Round: validRound},
 		}
-		executeTest(msg, true, false, false, false)
+		executeTest(msg, false)
 	})
 
 	t.Run("!isAcceptableMessage - invalid round", func(t *testing.T) {
@@ -2728,10 +3594,10 @@ func TestIBFT_AddMessage(t *testing.T) {
 			Type: validMsgType,
 			View: &proto.View{Height: validHeight, Round: validRound - 1},
 		}
-		executeTest(msg, true, false, false, false)
+		executeTest(msg, false)
 	})
 
-	t.Run("correct - but quorum not reached", func(t *testing.T) {
+	t.Run("correct", func(t *testing.T) {
 		t.Parallel()
 
 		msg := &proto.Message{
@@ -2739,17 +3605,180 @@ func TestIBFT_AddMessage(t *testing.T) {
 			Type: validMsgType,
 			View: &proto.View{Height: validHeight, Round: validRound},
 		}
-		executeTest(msg, false, true, true, false)
+		executeTest(msg, true)
 	})
+}
 
-	t.Run("correct - quorum reached", func(t *testing.T) {
-		t.Parallel()
+// TestIBFT_AddMessage_QuorumMemo makes sure the store checks the quorum
+// of the added messages until it is reached, and the engine memoizes it
+func TestIBFT_AddMessage_QuorumMemo(t *testing.T) {
+	t.Parallel()
 
-		msg := &proto.Message{
-			From: validSender,
-			Type: validMsgType,
-			View: &proto.View{Height: validHeight, Round: validRound},
+	var (
+		view = &proto.View{Height: 1, Round: 0}
+
+		quorumChecks int
+
+		backend = mockBackend{
+			hasQuorumFn: func(_ uint64, messages []*proto.Message, _ proto.MessageType) bool {
+				quorumChecks++
+
+				return len(messages) >= 2
+			},
 		}
-		executeTest(msg, true, true, true, true)
+	)
+
+	i := NewIBFT(mockLogger{}, backend, mockTransport{})
+	i.state.setView(view)
+
+	addCommit := func(view *proto.View, from string) {
+		i.AddMessage(buildBasicCommitMessage(nil, nil, []byte(from), view))
+	}
+
+	// Make sure the quorum is checked until it is reached
+	addCommit(view, "node 1")
+	assert.False(t, i.quorumMemo.hasQuorum(view, proto.MessageType_COMMIT))
+
+	addCommit(view, "node 2")
+	assert.True(t, i.quorumMemo.hasQuorum(view, proto.MessageType_COMMIT))
+
+	assert.Equal(t, 2, quorumChecks)
+
+	// Make sure the reached quorum is not checked again
+	addCommit(view, "node 3")
+
+	assert.Equal(t, 2, quorumChecks)
+
+	// Make sure the quorum is memoized per view
+	addCommit(&proto.View{Height: 1, Round: 1}, "node 1")
+
+	assert.Equal(t, 3, quorumChecks)
+
+	// Make sure the memoized quorums of previous heights are pruned
+	i.quorumMemo.prune(2)
+
+	assert.False(t, i.quorumMemo.hasQuorum(view, proto.MessageType_COMMIT))
+}
+
+// TestIBFT_AddMessages makes sure a batch of messages
+// is stored with a single store call
+func TestIBFT_AddMessages(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view       = &proto.View{Height: 1, Round: 0}
+		futureView = &proto.View{Height: 1, Round: 1}
+		staleView  = &proto.View{Height: 0, Round: 0}
+
+		storeCalls int
+		stored     = make(map[uint64][]*proto.Message)
+	)
+
+	i := NewIBFT(mockLogger{}, mockBackend{}, mockTransport{})
+	i.state.setView(view)
+	i.messages = mockMessages{
+		addMessageFn: func(_ *proto.Message) {
+			t.Fatal("messages of the batch added one by one")
+		},
+		addMessagesFn: func(messages []*proto.Message) {
+			storeCalls++
+
+			for _, message := range messages {
+				stored[message.View.Round] = append(stored[message.View.Round], message)
+			}
+		},
+	}
+
+	i.AddMessages([]*proto.Message{
+		buildBasicCommitMessage(nil, nil, []byte("node 1"), view),
+		nil,
+		buildBasicCommitMessage(nil, nil, []byte("node 2"), view),
+		buildBasicCommitMessage(nil, nil, []byte("node 3"), futureView),
+		buildBasicCommitMessage(nil, nil, []byte("node 3"), view),
+		buildBasicCommitMessage(nil, nil, []byte("node 4"), staleView),
 	})
+
+	// Make sure the acceptable messages are stored at once
+	assert.Equal(t, 1, storeCalls)
+	assert.Len(t, stored[view.Round], 3)
+	assert.Len(t, stored[futureView.Round], 1)
+
+	// Make sure an empty batch does not reach the store
+	i.AddMessages([]*proto.Message{nil})
+
+	assert.Equal(t, 1, storeCalls)
+}
+
+// TestIBFT_MalformedPayloads makes sure the messages with payloads
+// not matching their type are rejected, instead of crashing the engine
+func TestIBFT_MalformedPayloads(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view = &proto.View{
+			Height: 1,
+			Round:  1,
+		}
+		sender = []byte("sender")
+
+		store   = messages.NewMessages()
+		backend = mockBackend{
+			isProposerFn: func(_ []byte, _ uint64, _ uint64) bool {
+				return false
+			},
+			hasQuorumFn: func(_ uint64, msgs []*proto.Message, _ proto.MessageType) bool {
+				return len(msgs) >= 1
+			},
+		}
+	)
+
+	defer store.Close()
+
+	i := NewIBFT(mockLogger{}, backend, mockTransport{})
+	i.messages = store
+	i.state.setView(view)
+	i.state.setProposalMessage(buildBasicPreprepareMessage(
+		correctRoundMessage.proposal.RawProposal,
+		correctRoundMessage.hash,
+		nil,
+		sender,
+		view,
+	))
+
+	// Store the messages with the payloads of other message types
+	store.AddMessage(&proto.Message{
+		View: view,
+		From: sender,
+		Type: proto.MessageType_PREPARE,
+		Payload: &proto.Message_CommitData{
+			CommitData: &proto.CommitMessage{},
+		},
+	})
+	store.AddMessage(&proto.Message{
+		View: view,
+		From: sender,
+		Type: proto.MessageType_COMMIT,
+	})
+	store.AddMessage(&proto.Message{
+		View: view,
+		From: sender,
+		Type: proto.MessageType_ROUND_CHANGE,
+		Payload: &proto.Message_PrepareData{
+			PrepareData: &proto.PrepareMessage{},
+		},
+	})
+
+	assert.Nil(t, i.handlePrepare(view, make(validatedMessages)))
+	assert.False(t, i.handleCommit(view, make(validatedMessages)))
+	assert.Nil(t, i.handleRoundChangeMessage(view))
+
+	// Make sure the malformed proposal is rejected
+	assert.False(t, i.validateProposalMessage(&proto.Message{
+		View: view,
+		From: sender,
+		Type: proto.MessageType_PREPREPARE,
+		Payload: &proto.Message_RoundChangeData{
+			RoundChangeData: &proto.RoundChangeMessage{},
+		},
+	}, view))
 }
@@ -0,0 +1,123 @@
+package core
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInvalidIgnoreDuration is an error indicating
+	// the sender would not be ignored for any time
+	ErrInvalidIgnoreDuration = errors.New("ignore duration must be positive")
+
+	// ErrInvalidIgnoredSender is an error indicating the ignored sender is missing
+	ErrInvalidIgnoredSender = errors.New("invalid ignored sender")
+)
+
+// ignoreSet keeps track of the senders whose messages are ignored,
+// until their ignore periods expire
+type ignoreSet struct {
+	sync.RWMutex
+
+	// expiries are the ends of the ignore periods, by sender
+	expiries map[string]time.Time
+}
+
+// newIgnoreSet creates a new empty ignore set
+func newIgnoreSet() *ignoreSet {
+	return &ignoreSet{
+		expiries: make(map[string]time.Time),
+	}
+}
+
+// ignore ignores the sender until the expiry,
+// replacing any previous ignore period of the sender
+func (s *ignoreSet) ignore(sender []byte, expiry time.Time) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.expiries[string(sender)] = expiry
+}
+
+// unignore stops ignoring the sender, and returns false
+// if it was not ignored at the specified time
+func (s *ignoreSet) unignore(sender []byte, now time.Time) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	expiry, ok := s.expiries[string(sender)]
+	delete(s.expiries, string(sender))
+
+	return ok && now.Before(expiry)
+}
+
+// isIgnored checks if the sender is ignored at the specified time
+func (s *ignoreSet) isIgnored(sender []byte, now time.Time) bool {
+	s.RLock()
+	expiry, ok := s.expiries[string(sender)]
+	s.RUnlock()
+
+	return ok && now.Before(expiry)
+}
+
+// active returns the ends of the ignore periods that have not expired
+// at the specified time, by sender, and drops the expired ones
+func (s *ignoreSet) active(now time.Time) map[string]time.Time {
+	s.Lock()
+	defer s.Unlock()
+
+	active := make(map[string]time.Time, len(s.expiries))
+
+	for sender, expiry := range s.expiries {
+		if !now.Before(expiry) {
+			delete(s.expiries, sender)
+
+			continue
+		}
+
+		active[sender] = expiry
+	}
+
+	return active
+}
+
+// IgnoreSender ignores the messages of the sender for the specified duration
+// (ex. a compromised validator awaiting its removal from the validator set).
+// The messages are dropped before their signature is verified, and counted as rejected.
+// Ignoring an ignored sender again replaces its ignore period
+func (i *IBFT) IgnoreSender(sender []byte, duration time.Duration) error {
+	if len(sender) == 0 {
+		return ErrInvalidIgnoredSender
+	}
+
+	if duration <= 0 {
+		return ErrInvalidIgnoreDuration
+	}
+
+	expiry := i.clock().Add(duration)
+
+	i.ignored.ignore(sender, expiry)
+
+	i.log.Info("ignoring sender", "sender", sender, "until", expiry)
+
+	return nil
+}
+
+// UnignoreSender stops ignoring the messages of the sender before
+// its ignore period expires. It returns false if the sender was not ignored
+func (i *IBFT) UnignoreSender(sender []byte) bool {
+	if !i.ignored.unignore(sender, i.clock()) {
+		return false
+	}
+
+	i.log.Info("sender no longer ignored", "sender", sender)
+
+	return true
+}
+
+// IgnoredSenders returns the ends of the ignore periods
+// of the currently ignored senders, by sender
+func (i *IBFT) IgnoredSenders() map[string]time.Time {
+	return i.ignored.active(i.clock())
+}
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// manualClock is a clock that is moved manually
+type manualClock struct {
+	sync.Mutex
+
+	now time.Time
+}
+
+func (c *manualClock) time() time.Time {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.now
+}
+
+func (c *manualClock) advance(duration time.Duration) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.now = c.now.Add(duration)
+}
+
+func TestIBFT_IgnoreSender(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view     = &proto.View{Height: 1, Round: 0}
+		ignored  = []byte("node 1")
+		other    = []byte("node 2")
+		clock    = &manualClock{now: time.Unix(1700000000, 0)}
+		metrics  = &counterMetrics{}
+		verified = 0
+	)
+
+	i := NewIBFT(mockLogger{}, mockBackend{
+		IsValidValidatorFn: func(_ *proto.Message) bool {
+			verified++
+
+			return true
+		},
+	}, mockTransport{}, WithClock(clock.time), WithMetrics(metrics))
+	i.state.setView(view)
+
+	assert.NoError(t, i.IgnoreSender(ignored, time.Minute))
+
+	// Make sure the messages of the ignored sender are dropped without verifying them
+	assert.False(t, i.isAcceptableMessage(buildBasicPrepareMessage(nil, ignored, view), untrustedSender))
+	assert.Equal(t, 0, verified)
+	assert.Equal(t, float32(1), metrics.counter(rejectionKey(rejectIgnoredSender)))
+
+	assert.True(t, i.isAcceptableMessage(buildBasicPrepareMessage(nil, other, view), untrustedSender))
+
+	assert.Equal(t, map[string]time.Time{
+		string(ignored): clock.time().Add(time.Minute),
+	}, i.IgnoredSenders())
+
+	// Make sure the sender is accepted again once the ignore period expires
+	clock.advance(time.Minute)
+
+	assert.True(t, i.isAcceptableMessage(buildBasicPrepareMessage(nil, ignored, view), untrustedSender))
+	assert.Empty(t, i.IgnoredSenders())
+	assert.False(t, i.UnignoreSender(ignored))
+}
+
+func TestIBFT_UnignoreSender(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view   = &proto.View{Height: 1, Round: 0}
+		sender = []byte("node 1")
+	)
+
+	i := NewIBFT(mockLogger{}, mockBackend{}, mockTransport{})
+	i.state.setView(view)
+
+	assert.False(t, i.UnignoreSender(sender))
+
+	assert.NoError(t, i.IgnoreSender(sender, time.Hour))
+	assert.False(t, i.isAcceptableMessage(buildBasicPrepareMessage(nil, sender, view), untrustedSender))
+
+	// Make sure the sender is accepted again before the ignore period expires
+	assert.True(t, i.UnignoreSender(sender))
+	assert.True(t, i.isAcceptableMessage(buildBasicPrepareMessage(nil, sender, view), untrustedSender))
+	assert.Empty(t, i.IgnoredSenders())
+}
+
+func TestIBFT_IgnoreSender_Invalid(t *testing.T) {
+	t.Parallel()
+
+	i := NewIBFT(mockLogger{}, mockBackend{}, mockTransport{})
+
+	assert.ErrorIs(t, i.IgnoreSender(nil, time.Minute), ErrInvalidIgnoredSender)
+	assert.ErrorIs(t, i.IgnoreSender([]byte("node 1"), 0), ErrInvalidIgnoreDuration)
+	assert.ErrorIs(t, i.IgnoreSender([]byte("node 1"), -time.Minute), ErrInvalidIgnoreDuration)
+	assert.Empty(t, i.IgnoredSenders())
+}
@@ -0,0 +1,52 @@
+package core
+
+import (
+	"errors"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+var (
+	// ErrProposalInjectionDisabled is an error indicating the engine
+	// was not configured to accept injected proposals
+	ErrProposalInjectionDisabled = errors.New("proposal injection is disabled")
+
+	// ErrStaleProposal is an error indicating the injected proposal
+	// is not for the current height, or is for a past round
+	ErrStaleProposal = errors.New("proposal is not for the current height or a future round")
+
+	// ErrInvalidProposal is an error indicating the injected proposal
+	// did not pass the proposal validation
+	ErrInvalidProposal = errors.New("invalid proposal")
+)
+
+// InjectProposal injects a pre-built, signed PREPREPARE message into the engine,
+// as if it was received from the network. It is meant for disaster-recovery tooling
+// and advanced tests, and is only available if the engine was created
+// with the WithProposalInjection option.
+// The proposal is subject to full validation for the view it is intended for
+func (i *IBFT) InjectProposal(message *proto.Message) error {
+	if !i.proposalInjection {
+		return ErrProposalInjectionDisabled
+	}
+
+	if message == nil || message.View == nil {
+		return ErrInvalidProposal
+	}
+
+	view := i.state.getView()
+	if message.View.Height != view.Height || message.View.Round < view.Round {
+		return ErrStaleProposal
+	}
+
+	if !i.validateProposalMessage(message, message.View) {
+		return ErrInvalidProposal
+	}
+
+	i.log.Info("proposal injected", "height", message.View.Height, "round", message.View.Round)
+
+	// Hand the proposal over to the regular message pipeline
+	i.messages.AddMessage(message)
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+func TestIBFT_InjectProposal(t *testing.T) {
+	t.Parallel()
+
+	var (
+		nodeID   = []byte("node ID")
+		proposer = []byte("proposer")
+		height   = uint64(5)
+	)
+
+	buildProposal := func(view *proto.View, from []byte) *proto.Message {
+		return &proto.Message{
+			View: view,
+			From: from,
+			Type: proto.MessageType_PREPREPARE,
+			Payload: &proto.Message_PreprepareData{
+				PreprepareData: &proto.PrePrepareMessage{
+					Proposal:     correctRoundMessage.proposal,
+					ProposalHash: correctRoundMessage.hash,
+				},
+			},
+		}
+	}
+
+	backend := mockBackend{
+		idFn: func() []byte {
+			return nodeID
+		},
+		isProposerFn: func(id []byte, _ uint64, _ uint64) bool {
+			return bytes.Equal(id, proposer)
+		},
+	}
+
+	testTable := []struct {
+		name        string
+		opts        []Option
+		message     *proto.Message
+		expectedErr error
+	}{
+		{
+			"injection disabled",
+			nil,
+			buildProposal(&proto.View{Height: height}, proposer),
+			ErrProposalInjectionDisabled,
+		},
+		{
+			"missing proposal",
+			[]Option{WithProposalInjection()},
+			nil,
+			ErrInvalidProposal,
+		},
+		{
+			"proposal for a past height",
+			[]Option{WithProposalInjection()},
+			buildProposal(&proto.View{Height: height - 1}, proposer),
+			ErrStaleProposal,
+		},
+		{
+			"proposal from a non-proposer",
+			[]Option{WithProposalInjection()},
+			buildProposal(&proto.View{Height: height}, nodeID),
+			ErrInvalidProposal,
+		},
+		{
+			"proposal for a future round without a certificate",
+			[]Option{WithProposalInjection()},
+			buildProposal(&proto.View{Height: height, Round: 1}, proposer),
+			ErrInvalidProposal,
+		},
+		{
+			"valid proposal",
+			[]Option{WithProposalInjection()},
+			buildProposal(&proto.View{Height: height}, proposer),
+			nil,
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := messages.NewMessages()
+			defer store.Close()
+
+			i := NewIBFT(mockLogger{}, backend, mockTransport{}, testCase.opts...)
+			i.messages = store
+			i.state.setView(&proto.View{Height: height})
+
+			sub := store.Subscribe(context.Background(), messages.SubscriptionDetails{
+				MessageType: proto.MessageType_PREPREPARE,
+				View:        &proto.View{Height: height},
+				HasQuorumFn: func(_ uint64, msgs []*proto.Message, _ proto.MessageType) bool {
+					return len(msgs) >= 1
+				},
+			})
+			defer store.Unsubscribe(sub.ID)
+
+			assert.ErrorIs(t, i.InjectProposal(testCase.message), testCase.expectedErr)
+
+			injected := store.GetValidMessages(
+				&proto.View{Height: height},
+				proto.MessageType_PREPREPARE,
+				func(_ *proto.Message) bool { return true },
+			)
+
+			if testCase.expectedErr != nil {
+				// Make sure the proposal was not added
+				assert.Len(t, injected, 0)
+
+				return
+			}
+
+			// Make sure the proposal was added, and the subscribers were notified
+			assert.Equal(t, []*proto.Message{testCase.message}, injected)
+			assert.Equal(t, uint64(0), <-sub.SubCh)
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package core
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+var (
+	// ErrInvalidKeyRotation is an error indicating the key rotation
+	// is missing the validator or the key
+	ErrInvalidKeyRotation = errors.New("invalid key rotation")
+
+	// ErrPastKeyRotation is an error indicating the key rotation
+	// does not take effect at a future height
+	ErrPastKeyRotation = errors.New("key rotation must take effect at a future height")
+)
+
+// keyRotation is a validator key that takes effect at a height
+type keyRotation struct {
+	height uint64
+	key    []byte
+}
+
+// keyRegistry keeps track of the rotated validator keys
+type keyRegistry struct {
+	sync.RWMutex
+
+	// rotations are the key rotations of each validator,
+	// sorted by the height they take effect at
+	rotations map[string][]keyRotation
+}
+
+// newKeyRegistry creates a new key registry
+func newKeyRegistry() *keyRegistry {
+	return &keyRegistry{
+		rotations: make(map[string][]keyRotation),
+	}
+}
+
+// rotate registers the validator key that takes effect at the specified height,
+// replacing any key registered for the same height
+func (r *keyRegistry) rotate(validator, key []byte, height uint64) {
+	r.Lock()
+	defer r.Unlock()
+
+	var (
+		rotations = r.rotations[string(validator)]
+		index     = sort.Search(len(rotations), func(i int) bool {
+			return rotations[i].height >= height
+		})
+		rotation = keyRotation{
+			height: height,
+			key:    append([]byte(nil), key...),
+		}
+	)
+
+	if index < len(rotations) && rotations[index].height == height {
+		rotations[index] = rotation
+
+		return
+	}
+
+	rotations = append(rotations, keyRotation{})
+	copy(rotations[index+1:], rotations[index:])
+	rotations[index] = rotation
+
+	r.rotations[string(validator)] = rotations
+}
+
+// keyAt returns the validator key in effect at the specified height,
+// if the validator rotated its key at or before the height
+func (r *keyRegistry) keyAt(validator []byte, height uint64) ([]byte, bool) {
+	r.RLock()
+	defer r.RUnlock()
+
+	rotations := r.rotations[string(validator)]
+
+	// Find the first rotation that takes effect after the height
+	index := sort.Search(len(rotations), func(i int) bool {
+		return rotations[i].height > height
+	})
+
+	if index == 0 {
+		return nil, false
+	}
+
+	return rotations[index-1].key, true
+}
+
+// prune drops the keys superseded at the specified height.
+// The key in effect at the height is retained
+func (r *keyRegistry) prune(height uint64) {
+	r.Lock()
+	defer r.Unlock()
+
+	for validator, rotations := range r.rotations {
+		index := sort.Search(len(rotations), func(i int) bool {
+			return rotations[i].height > height
+		})
+
+		if index > 1 {
+			r.rotations[validator] = append([]keyRotation(nil), rotations[index-1:]...)
+		}
+	}
+}
+
+// RotateKey registers the new validator key that takes effect at the specified
+// (future) height. Messages for views at or above the height are validated against the key,
+// using the KeyVerifier backend extension.
+// The announcement is expected to be authenticated by the caller
+// (ex. included in a finalized block, signed by the old key)
+func (i *IBFT) RotateKey(validator, key []byte, height uint64) error {
+	if len(validator) == 0 || len(key) == 0 {
+		return ErrInvalidKeyRotation
+	}
+
+	if height <= i.state.getHeight() {
+		return ErrPastKeyRotation
+	}
+
+	i.keys.rotate(validator, key, height)
+
+	i.log.Info(
+		"validator key rotation registered",
+		"validator", validator,
+		"height", height,
+	)
+
+	return nil
+}
+
+// isValidSender checks if the message is sent by a validator. The senders authenticated
+// by the message envelope are only checked for membership, if the backend supports it,
+// unless the message is relayed in the certificates, whose messages must carry a valid signature.
+// The messages verified by the network layer are not checked, if they are trusted
+func (i *IBFT) isValidSender(msg *proto.Message, trust senderTrust) bool {
+	if trust == verifiedSender && i.isTrustedVerification(msg) {
+		return true
+	}
+
+	membership, ok := i.backend.(MembershipVerifier)
+	if !ok || trust != authenticatedSender || msg.View == nil || isCertificateType(msg.Type) {
+		return i.isValidValidator(msg)
+	}
+
+	return membership.IsValidator(msg.From, msg.View.Height)
+}
+
+// isCertificateType checks if the messages of the type are relayed to the peers
+// in the prepared and round change certificates
+func isCertificateType(messageType proto.MessageType) bool {
+	switch messageType {
+	case proto.MessageType_PREPREPARE,
+		proto.MessageType_PREPARE,
+		proto.MessageType_ROUND_CHANGE:
+		return true
+	default:
+		return false
+	}
+}
+
+// isValidValidator checks if the message is signed by a validator,
+// using the rotated validator key in effect for the message view, if any
+func (i *IBFT) isValidValidator(msg *proto.Message) bool {
+	verifier, ok := i.backend.(KeyVerifier)
+	if !ok || msg.View == nil {
+		return i.isValidValidatorDigest(msg)
+	}
+
+	key, rotated := i.keys.keyAt(msg.From, msg.View.Height)
+	if !rotated {
+		return i.isValidValidatorDigest(msg)
+	}
+
+	return verifier.IsValidValidatorKey(msg, key)
+}
+
+// isValidValidatorDigest checks if the message is signed by a validator,
+// using the cached message digest if the backend supports it
+func (i *IBFT) isValidValidatorDigest(msg *proto.Message) bool {
+	verifier, ok := i.backend.(DigestVerifier)
+	if !ok {
+		return i.backend.IsValidValidator(msg)
+	}
+
+	digest, err := messages.MessageDigest(msg)
+	if err != nil {
+		return false
+	}
+
+	return verifier.IsValidValidatorDigest(msg, digest)
+}
+
+// isValidCommittedSeal checks if the committed seal is signed by a validator,
+// using the rotated validator key in effect for the height, if any
+func (i *IBFT) isValidCommittedSeal(
+	height uint64,
+	proposalHash []byte,
+	committedSeal *messages.CommittedSeal,
+) bool {
+	verifier, ok := i.backend.(KeyVerifier)
+	if !ok || committedSeal == nil {
+		return i.backend.IsValidCommittedSeal(proposalHash, committedSeal)
+	}
+
+	key, rotated := i.keys.keyAt(committedSeal.Signer, height)
+	if !rotated {
+		return i.backend.IsValidCommittedSeal(proposalHash, committedSeal)
+	}
+
+	return verifier.IsValidCommittedSealKey(proposalHash, committedSeal, key)
+}
@@ -0,0 +1,272 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// keyBackend is a mock backend that verifies rotated validator keys
+type keyBackend struct {
+	mockBackend
+
+	isValidValidatorKeyFn     func(*proto.Message, []byte) bool
+	isValidCommittedSealKeyFn func([]byte, *messages.CommittedSeal, []byte) bool
+}
+
+func (b keyBackend) IsValidValidatorKey(msg *proto.Message, key []byte) bool {
+	return b.isValidValidatorKeyFn(msg, key)
+}
+
+func (b keyBackend) IsValidCommittedSealKey(
+	proposalHash []byte,
+	committedSeal *messages.CommittedSeal,
+	key []byte,
+) bool {
+	return b.isValidCommittedSealKeyFn(proposalHash, committedSeal, key)
+}
+
+func TestKeyRegistry_KeyAt(t *testing.T) {
+	t.Parallel()
+
+	var (
+		validator = []byte("validator")
+		registry  = newKeyRegistry()
+	)
+
+	// Register the rotations out of order
+	registry.rotate(validator, []byte("key 20"), 20)
+	registry.rotate(validator, []byte("key 10"), 10)
+	registry.rotate(validator, []byte("key 30"), 30)
+
+	// Make sure a repeated rotation replaces the key
+	registry.rotate(validator, []byte("key 30 replaced"), 30)
+
+	testTable := []struct {
+		height      uint64
+		expectedKey []byte
+	}{
+		{9, nil},
+		{10, []byte("key 10")},
+		{19, []byte("key 10")},
+		{20, []byte("key 20")},
+		{30, []byte("key 30 replaced")},
+		{100, []byte("key 30 replaced")},
+	}
+
+	for _, testCase := range testTable {
+		key, rotated := registry.keyAt(validator, testCase.height)
+
+		assert.Equal(t, testCase.expectedKey != nil, rotated)
+		assert.Equal(t, testCase.expectedKey, key)
+	}
+
+	// Make sure other validators are not affected
+	_, rotated := registry.keyAt([]byte("other validator"), 100)
+	assert.False(t, rotated)
+
+	// Make sure pruning retains the key in effect
+	registry.prune(25)
+
+	_, rotated = registry.keyAt(validator, 19)
+	assert.False(t, rotated)
+
+	key, _ := registry.keyAt(validator, 25)
+	assert.Equal(t, []byte("key 20"), key)
+
+	key, _ = registry.keyAt(validator, 30)
+	assert.Equal(t, []byte("key 30 replaced"), key)
+}
+
+func TestIBFT_RotateKey(t *testing.T) {
+	t.Parallel()
+
+	var (
+		validator = []byte("validator")
+		key       = []byte("key")
+		height    = uint64(5)
+	)
+
+	testTable := []struct {
+		name        string
+		validator   []byte
+		key         []byte
+		height      uint64
+		expectedErr error
+	}{
+		{
+			"missing validator",
+			nil,
+			key,
+			height + 1,
+			ErrInvalidKeyRotation,
+		},
+		{
+			"missing key",
+			validator,
+			nil,
+			height + 1,
+			ErrInvalidKeyRotation,
+		},
+		{
+			"current height",
+			validator,
+			key,
+			height,
+			ErrPastKeyRotation,
+		},
+		{
+			"future height",
+			validator,
+			key,
+			height + 1,
+			nil,
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			i := NewIBFT(mockLogger{}, mockBackend{}, mockTransport{})
+			i.state.setView(&proto.View{Height: height})
+
+			assert.ErrorIs(
+				t,
+				i.RotateKey(testCase.validator, testCase.key, testCase.height),
+				testCase.expectedErr,
+			)
+
+			_, rotated := i.keys.keyAt(validator, testCase.height)
+			assert.Equal(t, testCase.expectedErr == nil, rotated)
+		})
+	}
+}
+
+func TestIBFT_KeyRotationValidation(t *testing.T) {
+	t.Parallel()
+
+	var (
+		validator      = []byte("validator")
+		newKey         = []byte("new key")
+		rotationHeight = uint64(10)
+		proposalHash   = []byte("proposal hash")
+
+		backend = keyBackend{
+			mockBackend: mockBackend{
+				// The old key is no longer valid after the rotation
+				IsValidValidatorFn: func(msg *proto.Message) bool {
+					return msg.View.Height < rotationHeight
+				},
+				isValidCommittedSealFn: func(_ []byte, _ *messages.CommittedSeal) bool {
+					return false
+				},
+			},
+			isValidValidatorKeyFn: func(_ *proto.Message, key []byte) bool {
+				return bytes.Equal(key, newKey)
+			},
+			isValidCommittedSealKeyFn: func(_ []byte, _ *messages.CommittedSeal, key []byte) bool {
+				return bytes.Equal(key, newKey)
+			},
+		}
+
+		seal = &messages.CommittedSeal{
+			Signer: validator,
+		}
+	)
+
+	buildMessage := func(height uint64) *proto.Message {
+		return &proto.Message{
+			View: &proto.View{Height: height},
+			From: validator,
+		}
+	}
+
+	i := NewIBFT(mockLogger{}, backend, mockTransport{})
+	assert.NoError(t, i.RotateKey(validator, newKey, rotationHeight))
+
+	// Make sure the old key is used before the rotation
+	assert.True(t, i.isValidValidator(buildMessage(rotationHeight-1)))
+	assert.False(t, i.isValidCommittedSeal(rotationHeight-1, proposalHash, seal))
+
+	// Make sure the new key is used from the rotation height
+	assert.True(t, i.isValidValidator(buildMessage(rotationHeight)))
+	assert.True(t, i.isValidValidator(buildMessage(rotationHeight+1)))
+	assert.True(t, i.isValidCommittedSeal(rotationHeight, proposalHash, seal))
+
+	// Make sure the rotation is ignored if the backend does not verify keys
+	i.backend = backend.mockBackend
+
+	assert.False(t, i.isValidValidator(buildMessage(rotationHeight)))
+	assert.False(t, i.isValidCommittedSeal(rotationHeight, proposalHash, seal))
+}
+
+// digestBackend is a mock backend that verifies the message digests
+type digestBackend struct {
+	mockBackend
+
+	isValidValidatorDigestFn func(*proto.Message, *messages.Digest) bool
+}
+
+func (b digestBackend) IsValidValidatorDigest(msg *proto.Message, digest *messages.Digest) bool {
+	return b.isValidValidatorDigestFn(msg, digest)
+}
+
+func TestIBFT_DigestValidation(t *testing.T) {
+	t.Parallel()
+
+	var (
+		digests []*messages.Digest
+
+		backend = digestBackend{
+			mockBackend: mockBackend{
+				IsValidValidatorFn: func(_ *proto.Message) bool {
+					return false
+				},
+			},
+			isValidValidatorDigestFn: func(_ *proto.Message, digest *messages.Digest) bool {
+				digests = append(digests, digest)
+
+				return true
+			},
+		}
+
+		message = &proto.Message{
+			View:      &proto.View{Height: 1010, Round: 1},
+			From:      []byte("validator"),
+			Signature: []byte("signature"),
+			Type:      proto.MessageType_PREPARE,
+			Payload: &proto.Message_PrepareData{
+				PrepareData: &proto.PrepareMessage{
+					ProposalHash: []byte("proposal hash"),
+				},
+			},
+		}
+	)
+
+	i := NewIBFT(mockLogger{}, backend, mockTransport{})
+
+	// Make sure the digest verification is used, with the signed payload
+	assert.True(t, i.isValidValidator(message))
+
+	// Make sure the digest is computed once the message is stored
+	i.messages.AddMessage(message)
+
+	assert.True(t, i.isValidValidator(message))
+	assert.True(t, i.isValidValidator(message))
+
+	signingPayload, err := message.PayloadNoSig()
+	assert.NoError(t, err)
+
+	if assert.Len(t, digests, 3) {
+		assert.Equal(t, signingPayload, digests[0].SigningPayload)
+		assert.Equal(t, digests[0], digests[1])
+		assert.Same(t, digests[1], digests[2])
+	}
+}
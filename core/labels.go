@@ -0,0 +1,55 @@
+package core
+
+import (
+	"context"
+	"runtime/pprof"
+	"strconv"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// The profiler labels of the consensus goroutines, so the goroutines
+// in the profiles can be attributed to the view and the role of the node
+const (
+	// labelHeight is the label of the height of the round
+	labelHeight = "height"
+
+	// labelRound is the label of the round
+	labelRound = "round"
+
+	// labelRole is the label of the role of the node in the round
+	labelRole = "role"
+
+	// labelWorker is the label of the name of the worker
+	labelWorker = "worker"
+)
+
+const (
+	roleProposer  = "proposer"
+	roleValidator = "validator"
+)
+
+// withViewLabels returns the context carrying the labels of the view.
+// The labels are applied to the workers started with the context
+func withViewLabels(ctx context.Context, view *proto.View) context.Context {
+	return pprof.WithLabels(ctx, pprof.Labels(
+		labelHeight, strconv.FormatUint(view.GetHeight(), 10),
+		labelRound, strconv.FormatUint(view.GetRound(), 10),
+	))
+}
+
+// withRoleLabel returns the context carrying the label of the role of the node,
+// and applies the labels to the calling goroutine. The role is only known
+// to the state machine worker, so the label is applied to it and
+// the goroutines it starts (message receptions and subscriptions)
+func withRoleLabel(ctx context.Context, proposer bool) context.Context {
+	role := roleValidator
+	if proposer {
+		role = roleProposer
+	}
+
+	ctx = pprof.WithLabels(ctx, pprof.Labels(labelRole, role))
+	pprof.SetGoroutineLabels(ctx)
+
+	return ctx
+}
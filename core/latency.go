@@ -0,0 +1,90 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// defaultLatencySmoothing is the default EWMA smoothing factor,
+	// the weight given to the newest latency sample
+	defaultLatencySmoothing = 0.2
+)
+
+// LatencyEstimator keeps an exponentially weighted moving average
+// of the observed network latency. The latency is measured as the time between
+// the local multicast of a message, and the arrival of the quorum for that phase.
+// The estimate can be consumed by round timeout policies, instead of guessing
+// the base round timeout
+type LatencyEstimator struct {
+	sync.RWMutex
+
+	// smoothing is the weight of the newest sample, in the (0, 1] range
+	smoothing float64
+
+	// estimate is the current moving average
+	estimate time.Duration
+
+	// samples is the number of observed samples
+	samples uint64
+}
+
+// NewLatencyEstimator creates a new latency estimator with the given
+// smoothing factor. Invalid factors fall back to the default one
+func NewLatencyEstimator(smoothing float64) *LatencyEstimator {
+	e := &LatencyEstimator{}
+	e.setSmoothing(smoothing)
+
+	return e
+}
+
+// setSmoothing sets the smoothing factor of the estimator.
+// Invalid factors fall back to the default one
+func (e *LatencyEstimator) setSmoothing(smoothing float64) {
+	if smoothing <= 0 || smoothing > 1 {
+		smoothing = defaultLatencySmoothing
+	}
+
+	e.Lock()
+	defer e.Unlock()
+
+	e.smoothing = smoothing
+}
+
+// Observe adds a new latency sample to the moving average
+func (e *LatencyEstimator) Observe(sample time.Duration) {
+	if sample < 0 {
+		return
+	}
+
+	e.Lock()
+	defer e.Unlock()
+
+	if e.samples == 0 {
+		// The first sample is the initial estimate
+		e.estimate = sample
+	} else {
+		e.estimate = time.Duration(
+			e.smoothing*float64(sample) + (1-e.smoothing)*float64(e.estimate),
+		)
+	}
+
+	e.samples++
+}
+
+// Estimate returns the current latency estimate, and a flag
+// indicating if any samples have been observed yet
+func (e *LatencyEstimator) Estimate() (time.Duration, bool) {
+	e.RLock()
+	defer e.RUnlock()
+
+	return e.estimate, e.samples > 0
+}
+
+// Samples returns the number of observed samples
+func (e *LatencyEstimator) Samples() uint64 {
+	e.RLock()
+	defer e.RUnlock()
+
+	return e.samples
+}
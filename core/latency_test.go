@@ -0,0 +1,153 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+func TestLatencyEstimator_Observe(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no samples", func(t *testing.T) {
+		t.Parallel()
+
+		e := NewLatencyEstimator(0.5)
+
+		estimate, ok := e.Estimate()
+
+		assert.False(t, ok)
+		assert.Equal(t, time.Duration(0), estimate)
+	})
+
+	t.Run("first sample is the estimate", func(t *testing.T) {
+		t.Parallel()
+
+		e := NewLatencyEstimator(0.5)
+		e.Observe(100 * time.Millisecond)
+
+		estimate, ok := e.Estimate()
+
+		assert.True(t, ok)
+		assert.Equal(t, 100*time.Millisecond, estimate)
+	})
+
+	t.Run("moving average", func(t *testing.T) {
+		t.Parallel()
+
+		e := NewLatencyEstimator(0.5)
+
+		e.Observe(100 * time.Millisecond)
+		e.Observe(200 * time.Millisecond)
+		e.Observe(400 * time.Millisecond)
+
+		estimate, _ := e.Estimate()
+
+		// 0.5 * 400 + 0.5 * (0.5 * 200 + 0.5 * 100)
+		assert.Equal(t, 275*time.Millisecond, estimate)
+		assert.Equal(t, uint64(3), e.Samples())
+	})
+
+	t.Run("negative samples are ignored", func(t *testing.T) {
+		t.Parallel()
+
+		e := NewLatencyEstimator(0.5)
+		e.Observe(-time.Second)
+
+		assert.Equal(t, uint64(0), e.Samples())
+	})
+
+	t.Run("invalid smoothing factor", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, defaultLatencySmoothing, NewLatencyEstimator(0).smoothing)
+		assert.Equal(t, defaultLatencySmoothing, NewLatencyEstimator(2).smoothing)
+	})
+}
+
+func TestIBFT_ObserveLatency(t *testing.T) {
+	t.Parallel()
+
+	i := NewIBFT(mockLogger{}, mockBackend{}, mockTransport{})
+
+	// Nothing was multicasted, so there is nothing to measure
+	i.observeLatency(proto.MessageType_PREPARE)
+	assert.Equal(t, uint64(0), i.LatencyEstimator().Samples())
+
+	// Multicast a prepare, and reach quorum
+	view := &proto.View{Height: 1, Round: 0}
+	i.acceptProposal(buildBasicPreprepareMessage(validEthereumBlock, validProposalHash, nil, nil, view))
+	i.sendPrepareMessage(context.Background(), view)
+	i.observeLatency(proto.MessageType_PREPARE)
+	assert.Equal(t, uint64(1), i.LatencyEstimator().Samples())
+
+	// Moving to a new round resets the measurement
+	i.moveToNewRound(1)
+	i.observeLatency(proto.MessageType_PREPARE)
+	assert.Equal(t, uint64(1), i.LatencyEstimator().Samples())
+}
+
+func TestAdaptiveTimeout_RoundTimeout(t *testing.T) {
+	t.Parallel()
+
+	var (
+		base      = 10 * time.Second
+		estimator = NewLatencyEstimator(1)
+		strategy  = AdaptiveTimeout{
+			Estimator:  estimator,
+			Multiplier: 4,
+			MinTimeout: time.Second,
+		}
+	)
+
+	// Make sure the base round timeout is used without samples
+	assert.Equal(t, base, strategy.RoundTimeout(base, 0))
+
+	// Make sure the timeout follows the observed latency
+	estimator.Observe(500 * time.Millisecond)
+	assert.Equal(t, 2*time.Second, strategy.RoundTimeout(base, 0))
+	assert.Equal(t, 8*time.Second, strategy.RoundTimeout(base, 2))
+
+	estimator.Observe(2 * time.Second)
+	assert.Equal(t, 8*time.Second, strategy.RoundTimeout(base, 0))
+
+	// Make sure the timeout is floored
+	estimator.Observe(time.Millisecond)
+	assert.Equal(t, time.Second, strategy.RoundTimeout(base, 0))
+
+	// Make sure the growth over rounds is delegated
+	strategy.Strategy = ScheduleTimeout{}
+	assert.Equal(t, time.Second, strategy.RoundTimeout(base, 5))
+}
+
+func TestIBFT_WithAdaptiveTimeout(t *testing.T) {
+	t.Parallel()
+
+	i := NewIBFT(
+		mockLogger{},
+		mockBackend{},
+		mockTransport{},
+		WithAdaptiveTimeout(3, 100*time.Millisecond),
+		WithLatencySmoothing(0.5),
+	)
+
+	roundTimeout := i.roundTimeout
+
+	// Make sure the configured smoothing factor is used by the strategy's estimator
+	assert.Equal(t, 0.5, i.LatencyEstimator().smoothing)
+
+	// Make sure the base round timeout is used until latency is observed
+	assert.Equal(t, round0Timeout, roundTimeout(0))
+
+	// Make sure the round timeout follows the observed samples
+	i.LatencyEstimator().Observe(time.Second)
+	assert.Equal(t, 3*time.Second, roundTimeout(0))
+
+	i.LatencyEstimator().Observe(3 * time.Second)
+	assert.Equal(t, 6*time.Second, roundTimeout(0))
+	assert.Equal(t, 12*time.Second, roundTimeout(1))
+}
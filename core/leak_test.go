@@ -0,0 +1,270 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"runtime/pprof"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/goleak"
+
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// The leak tests are not parallel, so the goroutines of other tests
+// are not mistaken for the leaked ones
+
+// runSequenceAsync runs the sequence for the height in a new goroutine,
+// and returns the channel closed once the sequence is done
+func runSequenceAsync(ctx context.Context, i *IBFT, height uint64) <-chan struct{} {
+	sequenceDone := make(chan struct{})
+
+	go func() {
+		defer close(sequenceDone)
+
+		i.RunSequence(ctx, height)
+	}()
+
+	return sequenceDone
+}
+
+// waitSequenceDone waits for the sequence to be done
+func waitSequenceDone(t *testing.T, sequenceDone <-chan struct{}) {
+	t.Helper()
+
+	select {
+	case <-sequenceDone:
+	case <-time.After(5 * time.Second):
+		t.Fatal("sequence not done")
+	}
+}
+
+// TestIBFT_RunSequence_NoLeaks makes sure the sequence does not leave
+// any goroutines behind, on each of its exit paths
+func TestIBFT_RunSequence_NoLeaks(t *testing.T) {
+	t.Run("sequence cancelled", func(t *testing.T) {
+		defer goleak.VerifyNone(t)
+
+		i := NewIBFT(mockLogger{}, mockBackend{}, mockTransport{})
+
+		ctx, cancelFn := context.WithCancel(context.Background())
+		sequenceDone := runSequenceAsync(ctx, i, 1)
+
+		// Make sure the round workers are running
+		assert.Eventually(t, func() bool {
+			return i.messages.NumSubscriptions() > 0
+		}, 5*time.Second, 10*time.Millisecond)
+
+		cancelFn()
+		waitSequenceDone(t, sequenceDone)
+	})
+
+	t.Run("sequence finalized", func(t *testing.T) {
+		defer goleak.VerifyNone(t)
+
+		i := NewIBFT(mockLogger{}, mockBackend{}, mockTransport{})
+		i.roundEvents <- roundEvent{eventType: roundEventDone}
+
+		waitSequenceDone(t, runSequenceAsync(context.Background(), i, 1))
+	})
+
+	t.Run("worker panicked", func(t *testing.T) {
+		defer goleak.VerifyNone(t)
+
+		backend := mockBackend{
+			isProposerFn: func(_ []byte, _ uint64, round uint64) bool {
+				if round == 0 {
+					panic("proposer lookup failed")
+				}
+
+				return false
+			},
+		}
+
+		i := NewIBFT(mockLogger{}, backend, mockTransport{})
+
+		ctx, cancelFn := context.WithCancel(context.Background())
+		sequenceDone := runSequenceAsync(ctx, i, 1)
+
+		// Make sure the node moves to the next round because of the failure
+		assert.Eventually(t, func() bool {
+			return i.state.getRound() > 0
+		}, 5*time.Second, 10*time.Millisecond)
+
+		cancelFn()
+		waitSequenceDone(t, sequenceDone)
+	})
+
+	t.Run("stuck worker abandoned", func(t *testing.T) {
+		defer goleak.VerifyNone(t)
+
+		var (
+			blocked = make(chan struct{})
+			unblock = make(chan struct{})
+
+			backend = mockBackend{
+				isProposerFn: func(_ []byte, _ uint64, _ uint64) bool {
+					close(blocked)
+					<-unblock
+
+					return false
+				},
+			}
+		)
+
+		i := NewIBFT(mockLogger{}, backend, mockTransport{}, WithWorkerStopTimeout(10*time.Millisecond))
+
+		ctx, cancelFn := context.WithCancel(context.Background())
+		sequenceDone := runSequenceAsync(ctx, i, 1)
+
+		<-blocked
+
+		// The sequence is done without the stuck worker
+		cancelFn()
+		waitSequenceDone(t, sequenceDone)
+
+		// The abandoned worker stops once the backend call returns
+		close(unblock)
+	})
+}
+
+// TestIBFT_Watchers_NoLeaks makes sure the round watchers
+// and their subscriptions are stopped with the round context
+func TestIBFT_Watchers_NoLeaks(t *testing.T) {
+	i := NewIBFT(mockLogger{}, mockBackend{}, mockTransport{})
+	i.state.setView(&proto.View{Height: 1, Round: 0})
+
+	watchers := map[string]func(ctx context.Context){
+		"unavailable proposer":      i.watchForUnavailableProposer,
+		"duplicate proposals":       i.watchForDuplicateProposals,
+		"future proposal":           i.watchForFutureProposal,
+		"round change certificates": i.watchForRoundChangeCertificates,
+	}
+
+	for name, watcher := range watchers {
+		watcher := watcher
+
+		t.Run(name, func(t *testing.T) {
+			defer goleak.VerifyNone(t)
+
+			ctx, cancelFn := context.WithCancel(context.Background())
+			watcherDone := make(chan struct{})
+
+			go func() {
+				defer close(watcherDone)
+
+				watcher(ctx)
+			}()
+
+			cancelFn()
+
+			select {
+			case <-watcherDone:
+			case <-time.After(5 * time.Second):
+				t.Fatal("watcher not stopped")
+			}
+
+			assert.Eventually(t, func() bool {
+				return i.messages.NumSubscriptions() == 0
+			}, 5*time.Second, 10*time.Millisecond)
+		})
+	}
+}
+
+// TestIBFT_Subscriptions_NoLeaks makes sure the dispatch goroutines
+// of the message subscriptions are stopped, however the subscriptions are removed
+func TestIBFT_Subscriptions_NoLeaks(t *testing.T) {
+	details := messages.SubscriptionDetails{
+		MessageType: proto.MessageType_PREPARE,
+		View:        &proto.View{Height: 1, Round: 0},
+		HasQuorumFn: func(_ uint64, _ []*proto.Message, _ proto.MessageType) bool {
+			return false
+		},
+	}
+
+	t.Run("context cancelled", func(t *testing.T) {
+		defer goleak.VerifyNone(t)
+
+		i := NewIBFT(mockLogger{}, mockBackend{}, mockTransport{})
+
+		ctx, cancelFn := context.WithCancel(context.Background())
+
+		i.messages.Subscribe(ctx, details)
+
+		cancelFn()
+
+		assert.Eventually(t, func() bool {
+			return i.messages.NumSubscriptions() == 0
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("unsubscribed", func(t *testing.T) {
+		defer goleak.VerifyNone(t)
+
+		i := NewIBFT(mockLogger{}, mockBackend{}, mockTransport{})
+
+		sub := i.messages.Subscribe(context.Background(), details)
+		i.messages.Unsubscribe(sub.ID)
+
+		assert.Equal(t, 0, i.messages.NumSubscriptions())
+	})
+
+	t.Run("events unsubscribed", func(t *testing.T) {
+		defer goleak.VerifyNone(t)
+
+		i := NewIBFT(mockLogger{}, mockBackend{}, mockTransport{})
+
+		sub := i.SubscribeEvents()
+		i.UnsubscribeEvents(sub.ID)
+	})
+}
+
+// TestIBFT_RunSequence_GoroutineLabels makes sure the round workers,
+// and the goroutines they start, are labeled with the view and the role
+func TestIBFT_RunSequence_GoroutineLabels(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	backend := mockBackend{
+		isProposerFn: func(_ []byte, _ uint64, _ uint64) bool {
+			return false
+		},
+	}
+
+	i := NewIBFT(mockLogger{}, backend, mockTransport{})
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	sequenceDone := runSequenceAsync(ctx, i, 7)
+
+	defer func() {
+		cancelFn()
+		waitSequenceDone(t, sequenceDone)
+	}()
+
+	expected := []string{
+		// Round watcher
+		`{"height":"7", "round":"0", "worker":"round timer"}`,
+		// Message reception of the state machine
+		`{"height":"7", "role":"validator", "round":"0", "worker":"PREPARE reception"}`,
+		// Subscription of the message reception
+		`{"height":"7", "role":"validator", "round":"0", "subscription":"COMMIT", "worker":"COMMIT reception"}`,
+	}
+
+	assert.Eventually(t, func() bool {
+		var profile bytes.Buffer
+		if err := pprof.Lookup("goroutine").WriteTo(&profile, 1); err != nil {
+			return false
+		}
+
+		for _, labels := range expected {
+			if !strings.Contains(profile.String(), "# labels: "+labels) {
+				return false
+			}
+		}
+
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+}
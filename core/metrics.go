@@ -0,0 +1,84 @@
+package core
+
+import "time"
+
+// Metrics is the sink for the engine metrics.
+// The method set matches the one of the go-metrics package,
+// so its sinks can be used directly
+type Metrics interface {
+	// IncrCounter increments the counter with the given key
+	IncrCounter(key []string, val float32)
+
+	// SetGauge sets the gauge with the given key
+	SetGauge(key []string, val float32)
+
+	// AddSample adds a sample to the histogram with the given key
+	AddSample(key []string, val float32)
+
+	// MeasureSince adds the time elapsed since start
+	// to the histogram with the given key
+	MeasureSince(key []string, start time.Time)
+}
+
+var (
+	// multicastFailedKey is the counter of failed multicast attempts
+	multicastFailedKey = []string{"ibft", "multicast", "failed"}
+
+	// multicastRetriedKey is the counter of multicast retries
+	multicastRetriedKey = []string{"ibft", "multicast", "retried"}
+
+	// multicastDroppedKey is the counter of messages that
+	// could not be multicasted after all retries
+	multicastDroppedKey = []string{"ibft", "multicast", "dropped"}
+
+	// commitMalformedKey is the counter of malformed COMMIT
+	// messages excluded from the commit quorum
+	commitMalformedKey = []string{"ibft", "commit", "malformed"}
+
+	// commitRebroadcastKey is the counter of COMMIT messages
+	// rebroadcasted without the commit quorum observed
+	commitRebroadcastKey = []string{"ibft", "commit", "rebroadcast"}
+
+	// thresholdSealFailedKey is the counter of partial signatures
+	// that could not be combined into a valid threshold signature
+	thresholdSealFailedKey = []string{"ibft", "commit", "threshold_failed"}
+
+	// recoverySaveFailedKey is the counter of messages not multicasted
+	// because the recovery state could not be saved
+	recoverySaveFailedKey = []string{"ibft", "recovery", "save_failed"}
+
+	// roundChangeThrottledKey is the counter of ROUND_CHANGE
+	// rebroadcasts dropped by the rate limit
+	roundChangeThrottledKey = []string{"ibft", "round_change", "throttled"}
+
+	// roundChangeSuppressedKey is the counter of ROUND_CHANGE rebroadcasts
+	// dropped after a quorum of ROUND_CHANGE messages was observed
+	roundChangeSuppressedKey = []string{"ibft", "round_change", "suppressed"}
+
+	// proposalReplyKey is the counter of messages unicasted
+	// to the proposers of duplicate proposals
+	proposalReplyKey = []string{"ibft", "proposal", "replied"}
+
+	// proposalBoostedKey is the counter of prepared proposals proposed again
+	// by the node in place of the round proposer (see WithReproposalBoost)
+	proposalBoostedKey = []string{"ibft", "proposal", "boosted"}
+
+	// equivocationKey is the counter of conflicting messages
+	// rejected by the message store
+	equivocationKey = []string{"ibft", "messages", "equivocation"}
+
+	// proposalHashMismatchKey is the counter of PREPARE and COMMIT messages
+	// whose proposal hash does not match the accepted proposal
+	proposalHashMismatchKey = []string{"ibft", "proposal", "hash_mismatch"}
+)
+
+// nopMetrics is the default metrics sink, which discards all metrics
+type nopMetrics struct{}
+
+func (nopMetrics) IncrCounter(_ []string, _ float32) {}
+
+func (nopMetrics) SetGauge(_ []string, _ float32) {}
+
+func (nopMetrics) AddSample(_ []string, _ float32) {}
+
+func (nopMetrics) MeasureSince(_ []string, _ time.Time) {}
@@ -0,0 +1,87 @@
+package core
+
+import (
+	"sync"
+	"time"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// defaultHashMismatchReportInterval is the minimum time between
+// two reports of the proposal hash mismatches of the same sender
+const defaultHashMismatchReportInterval = 10 * time.Second
+
+// hashMismatchLimiter rate-limits the reports of the proposal hash mismatches,
+// by sender. A validator deriving the hash differently mismatches every
+// PREPARE and COMMIT message, so reporting each one would flood the logs and events
+type hashMismatchLimiter struct {
+	sync.Mutex
+
+	// interval is the minimum time between two reports
+	// for the same sender. A zero interval disables the rate limit
+	interval time.Duration
+
+	// reported are the times of the latest reports, by sender
+	reported map[string]time.Time
+}
+
+// newHashMismatchLimiter creates a new limiter with the specified interval
+func newHashMismatchLimiter(interval time.Duration) *hashMismatchLimiter {
+	return &hashMismatchLimiter{
+		interval: interval,
+		reported: make(map[string]time.Time),
+	}
+}
+
+// allow checks if the mismatch of the sender can be reported,
+// and marks it as reported if so
+func (l *hashMismatchLimiter) allow(sender []byte, now time.Time) bool {
+	l.Lock()
+	defer l.Unlock()
+
+	if reported, ok := l.reported[string(sender)]; ok && now.Sub(reported) < l.interval {
+		return false
+	}
+
+	// Forget the senders whose reports expired, so the records do not grow
+	for key, reported := range l.reported {
+		if now.Sub(reported) >= l.interval {
+			delete(l.reported, key)
+		}
+	}
+
+	l.reported[string(sender)] = now
+
+	return true
+}
+
+// reportHashMismatch reports the message whose proposal hash does not match
+// the accepted proposal. Mismatches are always counted in the metrics,
+// and are logged and emitted as EventProposalHashMismatch at most once
+// per sender in the report interval (see WithHashMismatchReportInterval)
+func (i *IBFT) reportHashMismatch(view *proto.View, message *proto.Message, receivedHash []byte) {
+	i.metrics.IncrCounter(proposalHashMismatchKey, 1)
+
+	if !i.hashMismatches.allow(message.From, i.clock()) {
+		return
+	}
+
+	expectedHash := i.state.getProposalHash()
+
+	i.log.Error(
+		"proposal hash mismatch",
+		"type", message.Type.String(),
+		"sender", message.From,
+		"height", view.Height,
+		"round", view.Round,
+		"expected", expectedHash,
+		"received", receivedHash,
+	)
+
+	i.emitEvent(EventProposalHashMismatch, &proto.View{Height: view.Height, Round: view.Round}, HashMismatchData{
+		Sender:       message.From,
+		MessageType:  message.Type,
+		ExpectedHash: expectedHash,
+		ReceivedHash: receivedHash,
+	})
+}
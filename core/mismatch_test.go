@@ -0,0 +1,96 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+func TestHashMismatchLimiter(t *testing.T) {
+	t.Parallel()
+
+	var (
+		limiter = newHashMismatchLimiter(10 * time.Second)
+		now     = time.Unix(1000, 0)
+	)
+
+	assert.True(t, limiter.allow([]byte("node 0"), now))
+	assert.True(t, limiter.allow([]byte("node 1"), now))
+
+	// Make sure the reports are limited by sender
+	assert.False(t, limiter.allow([]byte("node 0"), now.Add(9*time.Second)))
+	assert.True(t, limiter.allow([]byte("node 0"), now.Add(10*time.Second)))
+
+	// Make sure the expired records are forgotten
+	assert.Len(t, limiter.reported, 1)
+
+	// Make sure a zero interval reports every mismatch
+	unlimited := newHashMismatchLimiter(0)
+
+	assert.True(t, unlimited.allow([]byte("node 0"), now))
+	assert.True(t, unlimited.allow([]byte("node 0"), now))
+}
+
+// TestIBFT_ReportHashMismatch makes sure the PREPARE and COMMIT messages
+// with a mismatching proposal hash are reported, once per sender
+func TestIBFT_ReportHashMismatch(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view         = &proto.View{Height: 1, Round: 0}
+		mismatchHash = []byte("mismatching hash")
+
+		backend = mockBackend{
+			isValidProposalHashFn: func(_ *proto.Proposal, hash []byte) bool {
+				return bytes.Equal(hash, correctRoundMessage.hash)
+			},
+			hasQuorumFn: commonHasQuorumFn(4),
+		}
+	)
+
+	i := NewIBFT(mockLogger{}, backend, mockTransport{})
+	i.state.setView(view)
+	i.state.setProposalMessage(
+		buildBasicPreprepareMessage(
+			correctRoundMessage.proposal.GetRawProposal(),
+			correctRoundMessage.hash,
+			nil,
+			[]byte("proposer"),
+			view,
+		),
+	)
+
+	sub := i.SubscribeEvents()
+	defer i.UnsubscribeEvents(sub.ID)
+
+	i.messages.AddMessage(buildBasicPrepareMessage(mismatchHash, []byte("node 0"), view))
+	i.messages.AddMessage(buildBasicPrepareMessage(correctRoundMessage.hash, []byte("node 1"), view))
+	i.messages.AddMessage(buildBasicCommitMessage(mismatchHash, correctRoundMessage.seal, []byte("node 0"), view))
+	i.messages.AddMessage(buildBasicCommitMessage(mismatchHash, correctRoundMessage.seal, []byte("node 2"), view))
+
+	assert.Nil(t, i.handlePrepare(view, make(validatedMessages)))
+	assert.False(t, i.handleCommit(view, make(validatedMessages)))
+
+	events := drainEvents(sub, EventProposalHashMismatch)
+	require.Len(t, events, 2)
+
+	// The COMMIT mismatch of the node 0 is within the report interval of its PREPARE mismatch
+	assert.Equal(t, HashMismatchData{
+		Sender:       []byte("node 0"),
+		MessageType:  proto.MessageType_PREPARE,
+		ExpectedHash: correctRoundMessage.hash,
+		ReceivedHash: mismatchHash,
+	}, events[0].Data)
+
+	assert.Equal(t, HashMismatchData{
+		Sender:       []byte("node 2"),
+		MessageType:  proto.MessageType_COMMIT,
+		ExpectedHash: correctRoundMessage.hash,
+		ReceivedHash: mismatchHash,
+	}, events[1].Data)
+}
@@ -207,10 +207,12 @@ type mockTransport struct {
 	multicastFn multicastFnDelegate
 }
 
-func (t mockTransport) Multicast(msg *proto.Message) {
+func (t mockTransport) Multicast(msg *proto.Message) error {
 	if t.multicastFn != nil {
 		t.multicastFn(msg)
 	}
+
+	return nil
 }
 
 // Define delegation methods
@@ -243,23 +245,95 @@ func (l mockLogger) Error(msg string, args ...interface{}) {
 
 type mockMessages struct {
 	addMessageFn    func(message *proto.Message)
+	addMessagesFn   func(messages []*proto.Message)
 	pruneByHeightFn func(height uint64)
-	signalEventFn   func(message *proto.Message)
+	pruneByRoundFn  func(height, round uint64)
 
 	getValidMessagesFn func(
 		view *proto.View,
 		messageType proto.MessageType,
 		isValid func(message *proto.Message) bool,
 	) []*proto.Message
+	scanValidMessagesFn func(
+		view *proto.View,
+		messageType proto.MessageType,
+		isValid func(message *proto.Message) bool,
+		visit func(message *proto.Message) bool,
+	)
 	getExtendedRCCFn func(
 		height uint64,
 		isValidMessage func(message *proto.Message) bool,
 		isValidRCC func(round uint64, messages []*proto.Message) bool,
 	) []*proto.Message
 	getMostRoundChangeMessagesFn func(uint64, uint64) []*proto.Message
+	getMessagesFn                func(*proto.View, proto.MessageType, uint64) ([]*proto.Message, uint64)
 
 	subscribeFn   func(details messages.SubscriptionDetails) *messages.Subscription
 	unsubscribeFn func(id messages.SubscriptionID)
+
+	numSubscriptionsFn func() int
+	viewCountsFn       func() []messages.ViewCount
+	lenFn              func() int
+	oldestHeightFn     func() (uint64, bool)
+	statsFn            func() []messages.RoundStats
+	queryFn            func(messages.MessageQuery) []*proto.Message
+	highestRoundFn     func(uint64) (uint64, bool)
+}
+
+func (m mockMessages) Query(query messages.MessageQuery) []*proto.Message {
+	if m.queryFn != nil {
+		return m.queryFn(query)
+	}
+
+	return nil
+}
+
+func (m mockMessages) HighestRound(height uint64) (uint64, bool) {
+	if m.highestRoundFn != nil {
+		return m.highestRoundFn(height)
+	}
+
+	return 0, false
+}
+
+func (m mockMessages) NumSubscriptions() int {
+	if m.numSubscriptionsFn != nil {
+		return m.numSubscriptionsFn()
+	}
+
+	return 0
+}
+
+func (m mockMessages) ViewCounts() []messages.ViewCount {
+	if m.viewCountsFn != nil {
+		return m.viewCountsFn()
+	}
+
+	return nil
+}
+
+func (m mockMessages) Len() int {
+	if m.lenFn != nil {
+		return m.lenFn()
+	}
+
+	return 0
+}
+
+func (m mockMessages) OldestHeight() (uint64, bool) {
+	if m.oldestHeightFn != nil {
+		return m.oldestHeightFn()
+	}
+
+	return 0, false
+}
+
+func (m mockMessages) Stats() []messages.RoundStats {
+	if m.statsFn != nil {
+		return m.statsFn()
+	}
+
+	return nil
 }
 
 func (m mockMessages) GetValidMessages(
@@ -274,12 +348,56 @@ func (m mockMessages) GetValidMessages(
 	return nil
 }
 
-func (m mockMessages) Subscribe(details messages.SubscriptionDetails) *messages.Subscription {
-	if m.subscribeFn != nil {
-		return m.subscribeFn(details)
+func (m mockMessages) ScanValidMessages(
+	view *proto.View,
+	messageType proto.MessageType,
+	isValid func(*proto.Message) bool,
+	visit func(*proto.Message) bool,
+) {
+	if m.scanValidMessagesFn != nil {
+		m.scanValidMessagesFn(view, messageType, isValid, visit)
+
+		return
 	}
 
-	return nil
+	// Fall back to the valid messages of the mock
+	for _, message := range m.GetValidMessages(view, messageType, isValid) {
+		if !visit(message) {
+			return
+		}
+	}
+}
+
+func (m mockMessages) GetMessages(
+	view *proto.View,
+	messageType proto.MessageType,
+	since uint64,
+) ([]*proto.Message, uint64) {
+	if m.getMessagesFn != nil {
+		return m.getMessagesFn(view, messageType, since)
+	}
+
+	return nil, 0
+}
+
+// Subscribe simulates the removal of the subscription
+// by the store, once the context is cancelled
+func (m mockMessages) Subscribe(ctx context.Context, details messages.SubscriptionDetails) *messages.Subscription {
+	if m.subscribeFn == nil {
+		return nil
+	}
+
+	sub := m.subscribeFn(details)
+
+	if sub != nil && m.unsubscribeFn != nil {
+		go func() {
+			<-ctx.Done()
+
+			m.unsubscribeFn(sub.ID)
+		}()
+	}
+
+	return sub
 }
 
 func (m mockMessages) Unsubscribe(id messages.SubscriptionID) {
@@ -294,15 +412,21 @@ func (m mockMessages) AddMessage(msg *proto.Message) {
 	}
 }
 
+func (m mockMessages) AddMessages(msgs []*proto.Message) {
+	if m.addMessagesFn != nil {
+		m.addMessagesFn(msgs)
+	}
+}
+
 func (m mockMessages) PruneByHeight(height uint64) {
 	if m.pruneByHeightFn != nil {
 		m.pruneByHeightFn(height)
 	}
 }
 
-func (m mockMessages) SignalEvent(msg *proto.Message) {
-	if m.signalEventFn != nil {
-		m.signalEventFn(msg)
+func (m mockMessages) PruneByRound(height, round uint64) {
+	if m.pruneByRoundFn != nil {
+		m.pruneByRoundFn(height, round)
 	}
 }
 
@@ -0,0 +1,260 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// nilBackend is a mock backend that builds NIL proposals
+type nilBackend struct {
+	mockBackend
+
+	buildNilPrePrepareMessageFn func(*proto.RoundChangeCertificate, *proto.View) *proto.Message
+}
+
+func (b nilBackend) BuildNilPrePrepareMessage(
+	certificate *proto.RoundChangeCertificate,
+	view *proto.View,
+) *proto.Message {
+	return b.buildNilPrePrepareMessageFn(certificate, view)
+}
+
+// buildNilProposal builds a NIL PREPREPARE message for the view
+func buildNilProposal(view *proto.View, from []byte) *proto.Message {
+	return &proto.Message{
+		View: view,
+		From: from,
+		Type: proto.MessageType_PREPREPARE,
+		Payload: &proto.Message_PreprepareData{
+			PreprepareData: &proto.PrePrepareMessage{
+				Proposal: &proto.Proposal{
+					Round: view.Round,
+				},
+				NilProposal: true,
+			},
+		},
+	}
+}
+
+func TestIBFT_BuildNilProposal(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view    = &proto.View{Height: 1, Round: 0}
+		regular = &proto.Message{Type: proto.MessageType_PREPREPARE}
+
+		backend = nilBackend{
+			mockBackend: mockBackend{
+				buildProposalFn: func(_ uint64) []byte {
+					// The backend cannot build a proposal
+					return nil
+				},
+				buildPrePrepareMessageFn: func(
+					_ []byte,
+					_ *proto.RoundChangeCertificate,
+					_ *proto.View,
+				) *proto.Message {
+					return regular
+				},
+			},
+			buildNilPrePrepareMessageFn: func(
+				_ *proto.RoundChangeCertificate,
+				view *proto.View,
+			) *proto.Message {
+				return buildNilProposal(view, []byte("proposer"))
+			},
+		}
+	)
+
+	// Make sure NIL proposals are built only if enabled
+	i := NewIBFT(mockLogger{}, backend, mockTransport{})
+	assert.Equal(t, regular, i.buildProposal(context.Background(), view))
+
+	i = NewIBFT(mockLogger{}, backend, mockTransport{}, WithNilProposals())
+	assert.True(t, messages.IsNilProposal(i.buildProposal(context.Background(), view)))
+
+	// Make sure non-empty proposals are built as usual
+	backend.buildProposalFn = func(_ uint64) []byte {
+		return []byte("proposal")
+	}
+
+	i = NewIBFT(mockLogger{}, backend, mockTransport{}, WithNilProposals())
+	assert.Equal(t, regular, i.buildProposal(context.Background(), view))
+}
+
+func TestIBFT_ValidateNilProposal(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view     = &proto.View{Height: 1, Round: 0}
+		proposer = []byte("proposer")
+	)
+
+	testTable := []struct {
+		name     string
+		enabled  bool
+		modify   func(*proto.PrePrepareMessage)
+		expected bool
+	}{
+		{
+			"valid NIL proposal",
+			true,
+			func(_ *proto.PrePrepareMessage) {},
+			true,
+		},
+		{
+			"NIL proposals disabled",
+			false,
+			func(_ *proto.PrePrepareMessage) {},
+			false,
+		},
+		{
+			"non-empty proposal hash",
+			true,
+			func(data *proto.PrePrepareMessage) {
+				data.ProposalHash = []byte("proposal hash")
+			},
+			false,
+		},
+		{
+			"non-empty raw proposal",
+			true,
+			func(data *proto.PrePrepareMessage) {
+				data.Proposal.RawProposal = []byte("proposal")
+			},
+			false,
+		},
+		{
+			"round mismatch",
+			true,
+			func(data *proto.PrePrepareMessage) {
+				data.Proposal.Round = view.Round + 1
+			},
+			false,
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				backend = mockBackend{
+					isProposerFn: func(from []byte, _, _ uint64) bool {
+						return string(from) == string(proposer)
+					},
+					isValidProposalHashFn: func(_ *proto.Proposal, _ []byte) bool {
+						t.Fatal("proposal hash of a NIL proposal verified")
+
+						return false
+					},
+				}
+
+				opts    []Option
+				message = buildNilProposal(&proto.View{Height: view.Height, Round: view.Round}, proposer)
+			)
+
+			if testCase.enabled {
+				opts = append(opts, WithNilProposals())
+			}
+
+			testCase.modify(message.GetPreprepareData())
+
+			i := NewIBFT(mockLogger{}, backend, mockTransport{}, opts...)
+
+			assert.Equal(t, testCase.expected, i.validateProposal0(message, view))
+		})
+	}
+}
+
+func TestIBFT_NilProposalDecision(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view       = &proto.View{Height: 1, Round: 2}
+		validators = [][]byte{[]byte("node 0"), []byte("node 1"), []byte("node 2")}
+
+		backend = mockBackend{
+			isValidProposalHashFn: func(_ *proto.Proposal, _ []byte) bool {
+				t.Fatal("proposal hash of a NIL proposal verified")
+
+				return false
+			},
+			isValidCommittedSealFn: func(hash []byte, _ *messages.CommittedSeal) bool {
+				return len(hash) == 0
+			},
+			hasQuorumFn: func(_ uint64, msgs []*proto.Message, _ proto.MessageType) bool {
+				return len(msgs) == len(validators)
+			},
+			insertProposalFn: func(_ *proto.Proposal, _ []*messages.CommittedSeal) {
+				t.Fatal("NIL proposal inserted")
+			},
+		}
+	)
+
+	i := NewIBFT(mockLogger{}, backend, mockTransport{}, WithNilProposals())
+	i.state.setView(view)
+	i.state.setProposalMessage(buildNilProposal(view, validators[0]))
+
+	sub := i.SubscribeEvents()
+	defer i.UnsubscribeEvents(sub.ID)
+
+	for index, validator := range validators {
+		hash := []byte(nil)
+		if index == 0 {
+			// Make sure votes for a proposal hash are not counted
+			hash = []byte("proposal hash")
+		}
+
+		i.messages.AddMessage(&proto.Message{
+			View: view,
+			From: validator,
+			Type: proto.MessageType_COMMIT,
+			Payload: &proto.Message_CommitData{
+				CommitData: &proto.CommitMessage{
+					ProposalHash:  hash,
+					CommittedSeal: validator,
+				},
+			},
+		})
+	}
+
+	assert.False(t, i.handleCommit(view, make(validatedMessages)))
+
+	i.messages.AddMessage(&proto.Message{
+		View: view,
+		From: []byte("node 3"),
+		Type: proto.MessageType_COMMIT,
+		Payload: &proto.Message_CommitData{
+			CommitData: &proto.CommitMessage{
+				CommittedSeal: []byte("node 3"),
+			},
+		},
+	})
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+
+	go i.runCommit(ctx)
+
+	// Make sure the node moves to the next round, instead of finishing the sequence
+	if event := <-i.roundEvents; event.eventType != roundEventExpired {
+		t.Fatal("sequence finished on a NIL decision")
+	}
+
+	decisions := drainEvents(sub, EventNilDecision)
+
+	if assert.Len(t, decisions, 1) {
+		event := decisions[0]
+
+		assert.Equal(t, EventNilDecision, event.Type)
+		assert.Equal(t, view.Round, event.View.Round)
+	}
+}
@@ -0,0 +1,382 @@
+package core
+
+import (
+	"time"
+
+	"github.com/renloi/ibft/messages"
+)
+
+// Option is the optional IBFT configuration setter
+type Option func(*IBFT)
+
+// WithMaxRoundTimeout sets the ceiling for the round timeout.
+// The exponential round timeout growth saturates at this value,
+// so a network that has healed after a long outage doesn't wait
+// for hours before the next round starts. A zero value disables the ceiling
+func WithMaxRoundTimeout(timeout time.Duration) Option {
+	return func(i *IBFT) {
+		i.maxRoundTimeout = timeout
+	}
+}
+
+// WithTimeoutStrategy sets the strategy used for determining the round timeouts
+func WithTimeoutStrategy(strategy TimeoutStrategy) Option {
+	return func(i *IBFT) {
+		i.timeoutStrategy = strategy
+	}
+}
+
+// WithRoundTimeoutSchedule sets an explicit per-round timeout table.
+// For instance, a schedule of (1s, 1s, 1s, 30s) keeps rounds 0-2 short,
+// and makes rounds 3+ long
+func WithRoundTimeoutSchedule(schedule ...time.Duration) Option {
+	return WithTimeoutStrategy(ScheduleTimeout(schedule))
+}
+
+// WithAdaptiveTimeout derives the base round timeout from the engine's latency
+// estimate (see LatencyEstimator), scaled by the multiplier and floored at the
+// minimum timeout. The timeout grows exponentially over rounds, as by default
+func WithAdaptiveTimeout(multiplier float64, minTimeout time.Duration) Option {
+	return func(i *IBFT) {
+		i.timeoutStrategy = AdaptiveTimeout{
+			Estimator:  i.latency,
+			Multiplier: multiplier,
+			MinTimeout: minTimeout,
+		}
+	}
+}
+
+// WithLatencySmoothing sets the EWMA smoothing factor of the latency estimator,
+// the weight given to the newest sample. Invalid factors fall back to the default one
+func WithLatencySmoothing(smoothing float64) Option {
+	return func(i *IBFT) {
+		i.latency.setSmoothing(smoothing)
+	}
+}
+
+// WithRoundTimer sets the timer used for expiring rounds
+func WithRoundTimer(timer RoundTimer) Option {
+	return func(i *IBFT) {
+		i.roundTimer = timer
+	}
+}
+
+// WithProposalInjection enables the injection of pre-built proposals
+// using InjectProposal, for recovery tooling
+func WithProposalInjection() Option {
+	return func(i *IBFT) {
+		i.proposalInjection = true
+	}
+}
+
+// WithRetryPolicy sets the policy for retrying failed multicasts.
+// By default, failed multicasts are not retried
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(i *IBFT) {
+		i.retryPolicy = policy
+	}
+}
+
+// WithMetrics sets the sink for the engine metrics
+func WithMetrics(metrics Metrics) Option {
+	return func(i *IBFT) {
+		i.metrics = metrics
+	}
+}
+
+// WithMessageTTL sets the maximum number of relay hops for the multicasted messages,
+// for relay networks built on top of the Transport
+func WithMessageTTL(ttl uint32) Option {
+	return func(i *IBFT) {
+		i.messageTTL = ttl
+	}
+}
+
+// WithCodec sets the codec used for decoding raw messages received from the network.
+// The same codec should be used by the transport for encoding them
+func WithCodec(codec messages.Codec) Option {
+	return func(i *IBFT) {
+		i.codec = codec
+	}
+}
+
+// WithEpochLength sets the number of heights in an epoch.
+// At the start of each epoch the EpochObserver backend is notified, and an
+// EventEpochTransition is emitted. Messages of the current epoch are retained
+// in the message store, instead of only the messages of the current height
+func WithEpochLength(length uint64) Option {
+	return func(i *IBFT) {
+		i.epochLength = length
+	}
+}
+
+// WithPrunePolicy sets the policy used for pruning the message store
+// when the node moves to a new height. By default, all messages
+// below the current height (or epoch, if set) are pruned
+func WithPrunePolicy(policy messages.PrunePolicy) Option {
+	return func(i *IBFT) {
+		i.messageOptions = append(i.messageOptions, messages.WithPrunePolicy(policy))
+	}
+}
+
+// WithStoreTTL enables the periodic sweep of the message store, discarding the messages
+// of the views that did not receive any message for longer than the TTL, regardless
+// of the height progress (see messages.WithStoreTTL). The sweep runs for the lifetime
+// of the instance, and is meant for the nodes that may stall for long periods
+func WithStoreTTL(ttl time.Duration) Option {
+	return func(i *IBFT) {
+		i.messageOptions = append(i.messageOptions, messages.WithStoreTTL(ttl))
+	}
+}
+
+// WithEquivocationPolicy sets the policy for handling conflicting messages
+// a validator submitted for the same view and message type. With the
+// messages.EquivocationReject policy, each rejected message is reported
+// as an EventEquivocation, carrying the evidence
+func WithEquivocationPolicy(policy messages.EquivocationPolicy) Option {
+	return func(i *IBFT) {
+		i.messageOptions = append(i.messageOptions, messages.WithEquivocationPolicy(policy))
+	}
+}
+
+// WithRoundChangeRetention sets the policy for choosing which of the ROUND_CHANGE
+// messages a sender submitted for the same view is kept in the message store.
+// By default, the message with the highest PC is kept
+func WithRoundChangeRetention(retention messages.RoundChangeRetention) Option {
+	return func(i *IBFT) {
+		i.messageOptions = append(i.messageOptions, messages.WithRoundChangeRetention(retention))
+	}
+}
+
+// WithQuorumVerifier sets the verifier used for determining quorum,
+// instead of the Backend HasQuorum method
+func WithQuorumVerifier(verifier QuorumVerifier) Option {
+	return func(i *IBFT) {
+		i.quorum = verifier
+	}
+}
+
+// WithNilProposals enables explicit NIL proposals. A proposer that cannot build
+// a proposal (the backend returns an empty one) proposes to skip the round,
+// if the backend implements NilProposalBuilder. A decided NIL proposal is not
+// inserted; instead, the node moves to the next round without waiting for the timeout
+func WithNilProposals() Option {
+	return func(i *IBFT) {
+		i.nilProposals = true
+	}
+}
+
+// WithVetoes enables the explicit rejection of invalid proposals. A validator that finds
+// the proposal of the round proposer invalid multicasts a VETO message, if the backend
+// implements VetoBuilder, instead of staying silent until the round times out.
+// Once the vetoes reach the rejection quorum (see RejectionQuorumSize),
+// the PREPARE quorum can no longer be reached, so the nodes move to the next round.
+// All validators must enable the vetoes, as the vetoers do not prepare the round
+func WithVetoes() Option {
+	return func(i *IBFT) {
+		i.vetoes = true
+	}
+}
+
+// WithFinalityGadget sets the external finality layer (ex. a checkpoint contract,
+// or the anchoring to a parent chain) confirming the final status of the committed heights.
+// Each committed height is handed over to the gadget, and stays committed until the gadget
+// confirms its anchoring (see IBFT.ConfirmAnchored). The finality status transitions
+// are emitted as EventFinalityStatus
+func WithFinalityGadget(gadget FinalityGadget) Option {
+	return func(i *IBFT) {
+		i.finalityGadget = gadget
+	}
+}
+
+// WithUnavailableProposerTimeout sets the shortened round timeout, applied
+// after the proposer of the round announces it is unavailable.
+// The timeout is passed to the round timer, like the regular round timeouts
+func WithUnavailableProposerTimeout(timeout time.Duration) Option {
+	return func(i *IBFT) {
+		i.unavailableProposerTimeout = timeout
+	}
+}
+
+// WithWorkerStopTimeout sets the time the round workers are given to stop,
+// once the round is torn down. Workers that do not stop in time are abandoned,
+// so a worker stuck in a backend call cannot stall the sequence
+func WithWorkerStopTimeout(timeout time.Duration) Option {
+	return func(i *IBFT) {
+		i.workerStopTimeout = timeout
+	}
+}
+
+// WithSequenceDeadline sets the maximum wall-clock duration of a sequence. A sequence
+// that does not finalize the height in time ends with the SequenceDeadlineExceeded
+// result, so the node can switch to block sync instead of looping through the rounds
+// of a height the rest of the network has long passed (ex. on the minority side
+// of a partition). A zero deadline disables it, which is the default
+func WithSequenceDeadline(deadline time.Duration) Option {
+	return func(i *IBFT) {
+		i.sequenceDeadline = deadline
+	}
+}
+
+// WithRoundChangeRebroadcastInterval sets the minimum time between two multicasts
+// of the ROUND_CHANGE message for the same view, which limits the round change
+// storms after an outage. A zero interval disables the rate limit
+func WithRoundChangeRebroadcastInterval(interval time.Duration) Option {
+	return func(i *IBFT) {
+		i.roundChangeThrottle.interval = interval
+	}
+}
+
+// WithRoundChangeBackoff sets the maximum time between two multicasts of the ROUND_CHANGE
+// message for the same view. The minimum time between them starts at the rebroadcast
+// interval (see WithRoundChangeRebroadcastInterval), and doubles after each multicast,
+// up to the limit, so recovering networks are not flooded with identical messages.
+// The backoff is reset once the node moves to another round. A limit not above
+// the rebroadcast interval disables the backoff
+func WithRoundChangeBackoff(limit time.Duration) Option {
+	return func(i *IBFT) {
+		i.roundChangeThrottle.backoffLimit = limit
+	}
+}
+
+// WithHashMismatchReportInterval sets the minimum time between two reports
+// (see EventProposalHashMismatch) of the proposal hash mismatches of the same sender.
+// The mismatches are always counted in the metrics. A zero interval reports every mismatch
+func WithHashMismatchReportInterval(interval time.Duration) Option {
+	return func(i *IBFT) {
+		i.hashMismatches = newHashMismatchLimiter(interval)
+	}
+}
+
+// WithRejectionLogSampling logs every n-th incoming message rejected for each reason
+// (not signed by a validator, no view, stale height or round, unsupported type,
+// or a timestamp too far in the future).
+// The rejections are always counted in the metrics; the logs are disabled by default
+func WithRejectionLogSampling(every uint64) Option {
+	return func(i *IBFT) {
+		i.rejections = newRejectionSampler(every)
+	}
+}
+
+// WithClockSkewTolerance enables the timestamp check of the incoming messages.
+// Messages carrying a timestamp (ex. the proposal timestamp of PREPREPARE messages)
+// more than the tolerance ahead of the local clock are rejected, which blunts
+// replays and confusion attacks using forged future timestamps.
+// A zero tolerance disables the check, which is the default
+func WithClockSkewTolerance(tolerance time.Duration) Option {
+	return func(i *IBFT) {
+		i.clockSkewTolerance = tolerance
+	}
+}
+
+// WithClock sets the clock the message timestamps and the ignore periods
+// of the senders are checked against (ex. a network-adjusted clock).
+// By default, the local wall clock is used
+func WithClock(clock func() time.Time) Option {
+	return func(i *IBFT) {
+		i.clock = clock
+	}
+}
+
+// WithViewGossipInterval sets the time between two periodic multicasts of the
+// CURRENT_VIEW message, if the backend implements ViewAnnouncer.
+// A zero interval disables the periodic gossip, leaving only the replies
+// to the validators lagging behind, and the GossipView calls
+func WithViewGossipInterval(interval time.Duration) Option {
+	return func(i *IBFT) {
+		i.viewGossipInterval = interval
+	}
+}
+
+// WithCommitRebroadcast sets the fraction of the round timeout after which
+// the node rebroadcasts its COMMIT message, if it does not observe the commit
+// quorum, and the maximum number of rebroadcasts in a round.
+// By default, the COMMIT message is rebroadcasted at most twice, each time
+// a quarter of the round timeout passes. A zero fraction disables the rebroadcasts
+func WithCommitRebroadcast(fraction float64, retries uint) Option {
+	return func(i *IBFT) {
+		i.commitRebroadcastFraction = fraction
+		i.commitRebroadcastRetries = retries
+	}
+}
+
+// WithEnvelopeVerifier enables the authentication of the raw messages received
+// from the network. Each raw message must be sealed in an envelope signed by
+// its sender (see messages.EnvelopeMarshaler), which is verified before the message
+// is decoded. The transport should seal the messages using the same scheme
+func WithEnvelopeVerifier(verifier messages.EnvelopeVerifier) Option {
+	return func(i *IBFT) {
+		i.envelopeVerifier = verifier
+	}
+}
+
+// WithPeerReporter sets the reporter of the message sender offenses,
+// so the P2P layer can score and disconnect abusive peers
+func WithPeerReporter(reporter PeerReporter) Option {
+	return func(i *IBFT) {
+		i.peerReporter = reporter
+	}
+}
+
+// WithoutVerifiedMessageTrust disables the trust in the message verification
+// of the network layer, so the messages added using AddVerifiedMessage
+// are verified by the engine like any other message, for defense in depth
+func WithoutVerifiedMessageTrust() Option {
+	return func(i *IBFT) {
+		i.trustVerifiedMessages = false
+	}
+}
+
+// WithoutHeightRegressionCheck disables the check refusing the sequences of the heights
+// at or below the highest height finalized by the node (see ErrHeightFinalized),
+// for the callers that run a height again on purpose (ex. after a chain rollback)
+func WithoutHeightRegressionCheck() Option {
+	return func(i *IBFT) {
+		i.heightRegressionCheck = false
+	}
+}
+
+// WithoutRoundPruning disables the pruning of the PREPREPARE, PREPARE, COMMIT and VETO
+// messages of the lower rounds once the node moves to a higher round of the height
+// (see Messages.PruneByRound), retaining them until the height is pruned
+func WithoutRoundPruning() Option {
+	return func(i *IBFT) {
+		i.roundPruning = false
+	}
+}
+
+// WithRelayMode runs the instance on a node that is not in the validator set
+// (ex. a sentry or a relay node), as the message-handling layer. The messages
+// of the validators are ingested and validated as usual, and the relay policy is evaluated
+// using RelayMessage. The node never builds or signs any message; its sequences
+// only observe the heights, finalizing them on the quorums of the validators
+func WithRelayMode() Option {
+	return func(i *IBFT) {
+		i.relayMode = true
+	}
+}
+
+// WithSentries runs the validator behind the sentry nodes with the specified
+// transport peer addresses. The messages of the validator are sent only to its sentries
+// (using the Unicaster extension of the transport), which forward them unchanged
+// (see RelayMessage), and only the messages delivered by the sentries are accepted
+// (see AddPeerMessage). The consensus attribution still uses the validator identities,
+// as the forwarded messages are signed by their senders
+func WithSentries(sentries ...[]byte) Option {
+	return func(i *IBFT) {
+		i.sentries = sentries
+	}
+}
+
+// WithReproposalBoost biases the proposer selection after a failed round toward
+// the validators holding the highest PC. Once a quorum of ROUND_CHANGE messages
+// is observed for a round, a validator that holds the highest PC among them proposes it
+// again, along with the round proposer, which may not be aware of the PC and propose
+// a conflicting block, wasting another round. The validators accept the first of the
+// proposals that is valid; the boosted one must carry an RCC in which its sender holds
+// the highest valid PC. All the validators must enable the boost
+func WithReproposalBoost() Option {
+	return func(i *IBFT) {
+		i.reproposalBoost = true
+	}
+}
@@ -0,0 +1,35 @@
+package core
+
+// updateParticipation checks if the node participates in the consensus
+// at the height, if the backend implements the ParticipationGate extension.
+// It is checked at the sequence start, and on each round change
+func (i *IBFT) updateParticipation(height uint64) {
+	gate, ok := i.backend.(ParticipationGate)
+	if !ok {
+		return
+	}
+
+	observing := !gate.ShouldParticipate(height)
+	if i.observing.Swap(observing) == observing {
+		return
+	}
+
+	if observing {
+		i.log.Info("not participating, observing the height", "height", height)
+	} else {
+		i.log.Info("participating again", "height", height)
+	}
+}
+
+// isObserving checks if the node only observes the consensus,
+// without building or signing any message
+func (i *IBFT) isObserving() bool {
+	return i.relayMode || i.observing.Load()
+}
+
+// IsObserving checks if the node only observes the current height,
+// as the backend reported it should not participate (see ParticipationGate),
+// or the node is not a validator (see WithRelayMode)
+func (i *IBFT) IsObserving() bool {
+	return i.isObserving()
+}
@@ -0,0 +1,150 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// gatedBackend is a mock backend reporting if the node should participate
+type gatedBackend struct {
+	mockBackend
+
+	participating *atomic.Bool
+}
+
+func (b gatedBackend) ShouldParticipate(_ uint64) bool {
+	return b.participating.Load()
+}
+
+// TestIBFT_RunSequence_Observer makes sure a node that should not participate
+// finalizes the height on the quorums of the other validators,
+// without multicasting any message
+func TestIBFT_RunSequence_Observer(t *testing.T) {
+	t.Parallel()
+
+	testTable := []struct {
+		name          string
+		participating bool
+		multicasted   []proto.MessageType
+	}{
+		{"participating", true, []proto.MessageType{proto.MessageType_PREPARE, proto.MessageType_COMMIT}},
+		{"observing", false, nil},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				nodes = generateNodeAddresses(4)
+				view  = &proto.View{Height: 1, Round: 0}
+
+				participating atomic.Bool
+				recorder      = &multicastRecorder{}
+			)
+
+			participating.Store(testCase.participating)
+
+			backend := gatedBackend{
+				mockBackend: mockBackend{
+					idFn: func() []byte {
+						return nodes[3]
+					},
+					isProposerFn: func(from []byte, _, _ uint64) bool {
+						return bytes.Equal(from, nodes[0])
+					},
+					isValidProposalHashFn: func(_ *proto.Proposal, hash []byte) bool {
+						return bytes.Equal(hash, correctRoundMessage.hash)
+					},
+					hasQuorumFn: commonHasQuorumFn(4),
+					buildPrepareMessageFn: func(_ []byte, view *proto.View) *proto.Message {
+						return buildBasicPrepareMessage(correctRoundMessage.hash, nodes[3], view)
+					},
+					buildCommitMessageFn: func(_ []byte, view *proto.View) *proto.Message {
+						return buildBasicCommitMessage(correctRoundMessage.hash, correctRoundMessage.seal, nodes[3], view)
+					},
+				},
+				participating: &participating,
+			}
+
+			i := NewIBFT(mockLogger{}, backend, recorder)
+
+			sub := i.SubscribeEvents()
+			defer i.UnsubscribeEvents(sub.ID)
+
+			resultCh := make(chan SequenceResult, 1)
+
+			go func() {
+				result, _ := i.RunSequence(context.Background(), 1)
+				resultCh <- result
+			}()
+
+			// Wait for the node to start working on the height
+			for event := range sub.EventCh {
+				if event.Type == EventRoundStarted {
+					break
+				}
+			}
+
+			assert.Equal(t, !testCase.participating, i.IsObserving())
+
+			i.AddMessage(buildBasicPreprepareMessage(
+				correctRoundMessage.proposal.GetRawProposal(),
+				correctRoundMessage.hash,
+				nil,
+				nodes[0],
+				view,
+			))
+
+			for _, node := range nodes[1:3] {
+				i.AddMessage(buildBasicPrepareMessage(correctRoundMessage.hash, node, view))
+			}
+
+			for _, node := range nodes[:3] {
+				i.AddMessage(buildBasicCommitMessage(correctRoundMessage.hash, correctRoundMessage.seal, node, view))
+			}
+
+			require.True(t, (<-resultCh).Finalized())
+
+			multicasted := make([]proto.MessageType, 0)
+			for _, message := range recorder.messages() {
+				multicasted = append(multicasted, message.Type)
+			}
+
+			assert.ElementsMatch(t, testCase.multicasted, multicasted)
+		})
+	}
+}
+
+// TestIBFT_UpdateParticipation makes sure the participation
+// is checked again, once the node should participate
+func TestIBFT_UpdateParticipation(t *testing.T) {
+	t.Parallel()
+
+	var participating atomic.Bool
+
+	i := NewIBFT(mockLogger{}, gatedBackend{participating: &participating}, mockTransport{})
+
+	i.updateParticipation(1)
+	assert.True(t, i.IsObserving())
+
+	participating.Store(true)
+
+	i.updateParticipation(1)
+	assert.False(t, i.IsObserving())
+
+	// Make sure the nodes without the gate always participate
+	i = NewIBFT(mockLogger{}, mockBackend{}, mockTransport{})
+
+	i.updateParticipation(1)
+	assert.False(t, i.IsObserving())
+}
@@ -0,0 +1,92 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// roundPhase is a phase of the round, timed by the phase histograms
+type roundPhase string
+
+const (
+	// phasePrePrepare lasts from the round start until the proposal is accepted.
+	// For the proposer, it includes building the proposal
+	phasePrePrepare roundPhase = "preprepare"
+
+	// phasePrepare lasts from the proposal acceptance until the PREPARE quorum
+	phasePrepare roundPhase = "prepare"
+
+	// phaseCommit lasts from the PREPARE quorum until the COMMIT quorum
+	phaseCommit roundPhase = "commit"
+)
+
+// phaseKey returns the histogram key of the durations of the round phase
+func phaseKey(phase roundPhase) []string {
+	return []string{"ibft", "phase", string(phase)}
+}
+
+// proposalBuildKey is the histogram of the time the proposer spends building the proposal
+var proposalBuildKey = []string{"ibft", "proposal", "build"}
+
+// phaseTimer keeps track of the start of the running phases of the current round
+type phaseTimer struct {
+	sync.Mutex
+
+	// starts are the starts of the running phases
+	starts map[roundPhase]time.Time
+}
+
+// newPhaseTimer creates a new phase timer
+func newPhaseTimer() *phaseTimer {
+	return &phaseTimer{
+		starts: make(map[roundPhase]time.Time),
+	}
+}
+
+// reset drops the phases of the previous round
+func (t *phaseTimer) reset() {
+	t.Lock()
+	defer t.Unlock()
+
+	t.starts = make(map[roundPhase]time.Time)
+}
+
+// start marks the start of the phase
+func (t *phaseTimer) start(phase roundPhase, start time.Time) {
+	t.Lock()
+	defer t.Unlock()
+
+	t.starts[phase] = start
+}
+
+// end ends the phase, returning its start, if the phase was running.
+// A phase ends only once, so repeated quorum checks are not measured again
+func (t *phaseTimer) end(phase roundPhase) (time.Time, bool) {
+	t.Lock()
+	defer t.Unlock()
+
+	start, ok := t.starts[phase]
+	delete(t.starts, phase)
+
+	return start, ok
+}
+
+// startRoundPhases starts timing the phases of the new round
+func (i *IBFT) startRoundPhases() {
+	i.phases.reset()
+	i.phases.start(phasePrePrepare, time.Now())
+}
+
+// advancePhase ends the phase, recording its duration, and starts the next one, if any.
+// The phases entered midway (ex. through a proposal for a future round), and the phases
+// overtaken by a later quorum (ex. the COMMIT quorum observed before the PREPARE quorum)
+// are not recorded
+func (i *IBFT) advancePhase(phase roundPhase, next roundPhase) {
+	if start, ok := i.phases.end(phase); ok {
+		i.metrics.MeasureSince(phaseKey(phase), start)
+	}
+
+	if next != "" {
+		i.phases.start(next, time.Now())
+	}
+}
@@ -0,0 +1,149 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// durationMetrics is a metrics sink that tracks the measured durations
+type durationMetrics struct {
+	nopMetrics
+	sync.Mutex
+
+	measured map[string]int
+}
+
+func (d *durationMetrics) MeasureSince(key []string, _ time.Time) {
+	d.Lock()
+	defer d.Unlock()
+
+	if d.measured == nil {
+		d.measured = make(map[string]int)
+	}
+
+	d.measured[strings.Join(key, ".")]++
+}
+
+func (d *durationMetrics) count(key []string) int {
+	d.Lock()
+	defer d.Unlock()
+
+	return d.measured[strings.Join(key, ".")]
+}
+
+func TestPhaseTimer(t *testing.T) {
+	t.Parallel()
+
+	var (
+		timer = newPhaseTimer()
+		start = time.Unix(1700000000, 0)
+	)
+
+	timer.start(phasePrepare, start)
+
+	ended, ok := timer.end(phasePrepare)
+	require.True(t, ok)
+	assert.Equal(t, start, ended)
+
+	// Make sure a phase ends only once
+	_, ok = timer.end(phasePrepare)
+	assert.False(t, ok)
+
+	// Make sure the phases of the previous round are dropped
+	timer.start(phaseCommit, start)
+	timer.reset()
+
+	_, ok = timer.end(phaseCommit)
+	assert.False(t, ok)
+}
+
+// TestIBFT_RunSequence_PhaseDurations makes sure each phase
+// of the finalized round is measured once
+func TestIBFT_RunSequence_PhaseDurations(t *testing.T) {
+	t.Parallel()
+
+	var (
+		nodes   = generateNodeAddresses(4)
+		view    = &proto.View{Height: 1, Round: 0}
+		metrics = &durationMetrics{}
+
+		backend = mockBackend{
+			idFn: func() []byte {
+				return nodes[3]
+			},
+			isProposerFn: func(from []byte, _, _ uint64) bool {
+				return bytes.Equal(from, nodes[0])
+			},
+			isValidProposalHashFn: func(_ *proto.Proposal, hash []byte) bool {
+				return bytes.Equal(hash, correctRoundMessage.hash)
+			},
+			hasQuorumFn: commonHasQuorumFn(4),
+			buildPrepareMessageFn: func(_ []byte, view *proto.View) *proto.Message {
+				return buildBasicPrepareMessage(correctRoundMessage.hash, nodes[3], view)
+			},
+			buildCommitMessageFn: func(_ []byte, view *proto.View) *proto.Message {
+				return buildBasicCommitMessage(correctRoundMessage.hash, correctRoundMessage.seal, nodes[3], view)
+			},
+		}
+	)
+
+	i := NewIBFT(mockLogger{}, backend, mockTransport{}, WithMetrics(metrics))
+
+	sub := i.SubscribeEvents()
+	defer i.UnsubscribeEvents(sub.ID)
+
+	resultCh := make(chan SequenceResult, 1)
+
+	go func() {
+		result, _ := i.RunSequence(context.Background(), 1)
+		resultCh <- result
+	}()
+
+	// Wait for the node to start working on the height
+	for event := range sub.EventCh {
+		if event.Type == EventRoundStarted {
+			break
+		}
+	}
+
+	i.AddMessage(buildBasicPreprepareMessage(
+		correctRoundMessage.proposal.GetRawProposal(),
+		correctRoundMessage.hash,
+		nil,
+		nodes[0],
+		view,
+	))
+
+	for _, node := range nodes[1:3] {
+		i.AddMessage(buildBasicPrepareMessage(correctRoundMessage.hash, node, view))
+	}
+
+	// Wait for the PREPARE quorum, so the COMMIT quorum does not overtake it
+	for event := range sub.EventCh {
+		if event.Type == EventPrepareQuorum {
+			break
+		}
+	}
+
+	for _, node := range nodes[:3] {
+		i.AddMessage(buildBasicCommitMessage(correctRoundMessage.hash, correctRoundMessage.seal, node, view))
+	}
+
+	require.True(t, (<-resultCh).Finalized())
+
+	for _, phase := range []roundPhase{phasePrePrepare, phasePrepare, phaseCommit} {
+		assert.Equal(t, 1, metrics.count(phaseKey(phase)), phase)
+	}
+
+	// Make sure only the proposer measures the proposal building
+	assert.Zero(t, metrics.count(proposalBuildKey))
+}
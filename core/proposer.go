@@ -0,0 +1,189 @@
+package core
+
+import (
+	"bytes"
+	"sync"
+)
+
+// ProposerRecord is the record of the failed rounds of a proposer
+type ProposerRecord struct {
+	// Timeouts is the number of rounds that expired
+	// without the node accepting a proposal
+	Timeouts uint64
+
+	// InvalidProposals is the number of rounds in which
+	// the proposer sent an invalid proposal
+	InvalidProposals uint64
+
+	// ConsecutiveFailures is the number of rounds the proposer failed
+	// since its last proposal that was finalized
+	ConsecutiveFailures uint64
+
+	// LastFailureHeight is the height of the latest failed round
+	LastFailureHeight uint64
+}
+
+// ProposerRecords are the proposer records, by proposer ID.
+// The records are shared, and must not be modified
+type ProposerRecords map[string]ProposerRecord
+
+// proposerFailure is a failed round of the current height
+type proposerFailure struct {
+	// proposer is the ID of the round proposer
+	proposer []byte
+
+	// invalidProposal is the flag indicating if the proposer
+	// sent an invalid proposal, instead of not sending one in time
+	invalidProposal bool
+}
+
+// proposerTracker keeps track of the failed rounds of each proposer.
+// The failed rounds of a height are applied to the records only once the height
+// is finalized, so the records stay the same during a height
+type proposerTracker struct {
+	sync.RWMutex
+
+	// records are the proposer records, replaced on each update
+	records ProposerRecords
+
+	// pending are the failed rounds of the current height, by round
+	pending map[uint64]proposerFailure
+}
+
+// newProposerTracker creates a new proposer tracker
+func newProposerTracker() *proposerTracker {
+	return &proposerTracker{
+		records: make(ProposerRecords),
+		pending: make(map[uint64]proposerFailure),
+	}
+}
+
+// getRecords returns the proposer records
+func (t *proposerTracker) getRecords() ProposerRecords {
+	t.RLock()
+	defer t.RUnlock()
+
+	return t.records
+}
+
+// reset drops the failed rounds of the unfinished height
+func (t *proposerTracker) reset() {
+	t.Lock()
+	defer t.Unlock()
+
+	t.pending = make(map[uint64]proposerFailure)
+}
+
+// fail records the failed round of the current height. An invalid proposal
+// takes precedence over the timeout of the same round
+func (t *proposerTracker) fail(round uint64, failure proposerFailure) {
+	if len(failure.proposer) == 0 {
+		return
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	if recorded, ok := t.pending[round]; ok && (recorded.invalidProposal || !failure.invalidProposal) {
+		return
+	}
+
+	t.pending[round] = failure
+}
+
+// finalize applies the failed rounds of the finalized height to the records,
+// and resets the consecutive failures of the proposer of the finalized proposal
+func (t *proposerTracker) finalize(height uint64, proposer []byte) {
+	t.Lock()
+	defer t.Unlock()
+
+	records := make(ProposerRecords, len(t.records)+len(t.pending))
+	for id, record := range t.records {
+		records[id] = record
+	}
+
+	for _, failure := range t.pending {
+		record := records[string(failure.proposer)]
+
+		if failure.invalidProposal {
+			record.InvalidProposals++
+		} else {
+			record.Timeouts++
+		}
+
+		record.ConsecutiveFailures++
+		record.LastFailureHeight = height
+
+		records[string(failure.proposer)] = record
+	}
+
+	if record, ok := records[string(proposer)]; ok {
+		record.ConsecutiveFailures = 0
+		records[string(proposer)] = record
+	}
+
+	t.records = records
+	t.pending = make(map[uint64]proposerFailure)
+}
+
+// isProposer checks if the passed in ID is the proposer for the view,
+// using the ProposerSelector backend extension, if implemented
+func (i *IBFT) isProposer(id []byte, height, round uint64) bool {
+	selector, ok := i.backend.(ProposerSelector)
+	if !ok {
+		return i.backend.IsProposer(id, height, round)
+	}
+
+	return bytes.Equal(selector.SelectProposer(height, round, i.proposers.getRecords()), id)
+}
+
+// recordRoundTimeout records the expired round as failed,
+// if the node did not accept a proposal for it
+func (i *IBFT) recordRoundTimeout(height, round uint64) {
+	selector, ok := i.backend.(ProposerSelector)
+	if !ok || i.state.hasProposalMessage() {
+		return
+	}
+
+	i.proposers.fail(round, proposerFailure{
+		proposer: selector.SelectProposer(height, round, i.proposers.getRecords()),
+	})
+}
+
+// recordInvalidProposal records the round as failed, if the invalid
+// proposal was sent by the proposer of the round (other than the node)
+func (i *IBFT) recordInvalidProposal(height, round uint64, from []byte) {
+	if _, ok := i.backend.(ProposerSelector); !ok ||
+		bytes.Equal(from, i.backend.ID()) ||
+		!i.isProposer(from, height, round) {
+		return
+	}
+
+	i.proposers.fail(round, proposerFailure{
+		proposer:        from,
+		invalidProposal: true,
+	})
+}
+
+// recordFinalization applies the failed rounds of the finalized height
+func (i *IBFT) recordFinalization(height, round uint64) {
+	selector, ok := i.backend.(ProposerSelector)
+	if !ok {
+		return
+	}
+
+	i.proposers.finalize(height, selector.SelectProposer(height, round, i.proposers.getRecords()))
+}
+
+// ProposerRecords returns the records of the failed rounds of each proposer,
+// tracked if the backend implements the ProposerSelector extension
+func (i *IBFT) ProposerRecords() ProposerRecords {
+	records := i.proposers.getRecords()
+
+	copied := make(ProposerRecords, len(records))
+	for id, record := range records {
+		copied[id] = record
+	}
+
+	return copied
+}
@@ -0,0 +1,151 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// selectorBackend is a mock backend that selects the proposers
+type selectorBackend struct {
+	mockBackend
+
+	selectProposerFn func(uint64, uint64, ProposerRecords) []byte
+}
+
+func (b selectorBackend) SelectProposer(height, round uint64, records ProposerRecords) []byte {
+	return b.selectProposerFn(height, round, records)
+}
+
+func TestProposerTracker_Finalize(t *testing.T) {
+	t.Parallel()
+
+	var (
+		nodeA = []byte("node A")
+		nodeB = []byte("node B")
+
+		tracker = newProposerTracker()
+	)
+
+	tracker.fail(0, proposerFailure{proposer: nodeA})
+	tracker.fail(1, proposerFailure{proposer: nodeB, invalidProposal: true})
+
+	// Make sure the invalid proposal takes precedence over the timeout
+	tracker.fail(2, proposerFailure{proposer: nodeA})
+	tracker.fail(2, proposerFailure{proposer: nodeA, invalidProposal: true})
+	tracker.fail(2, proposerFailure{proposer: nodeA})
+
+	// Make sure the records are not updated before the height is finalized
+	assert.Empty(t, tracker.getRecords())
+
+	tracker.finalize(10, nodeB)
+
+	assert.Equal(
+		t,
+		ProposerRecords{
+			string(nodeA): {
+				Timeouts:            1,
+				InvalidProposals:    1,
+				ConsecutiveFailures: 2,
+				LastFailureHeight:   10,
+			},
+			string(nodeB): {
+				InvalidProposals:    1,
+				ConsecutiveFailures: 0,
+				LastFailureHeight:   10,
+			},
+		},
+		tracker.getRecords(),
+	)
+
+	// Make sure the failures of an unfinished height are dropped
+	tracker.fail(0, proposerFailure{proposer: nodeB})
+	tracker.reset()
+	tracker.finalize(11, nodeA)
+
+	assert.Equal(
+		t,
+		ProposerRecords{
+			string(nodeA): {
+				Timeouts:          1,
+				InvalidProposals:  1,
+				LastFailureHeight: 10,
+			},
+			string(nodeB): {
+				InvalidProposals:  1,
+				LastFailureHeight: 10,
+			},
+		},
+		tracker.getRecords(),
+	)
+}
+
+func TestIBFT_ProposerSelector(t *testing.T) {
+	t.Parallel()
+
+	var (
+		nodeID    = []byte("node 0")
+		proposers = [][]byte{[]byte("node 1"), []byte("node 2")}
+
+		backend = selectorBackend{
+			mockBackend: mockBackend{
+				idFn: func() []byte {
+					return nodeID
+				},
+				isProposerFn: func(_ []byte, _ uint64, _ uint64) bool {
+					t.Fatal("the proposer is checked using the backend")
+
+					return false
+				},
+			},
+			// Skip the proposers with consecutive failures
+			selectProposerFn: func(_ uint64, round uint64, records ProposerRecords) []byte {
+				proposer := proposers[round%2]
+				if records[string(proposer)].ConsecutiveFailures > 0 {
+					return proposers[(round+1)%2]
+				}
+
+				return proposer
+			},
+		}
+	)
+
+	i := NewIBFT(mockLogger{}, backend, mockTransport{})
+	i.state.setView(&proto.View{Height: 1, Round: 0})
+
+	assert.True(t, i.isProposer(proposers[0], 1, 0))
+	assert.True(t, i.isProposer(proposers[1], 1, 1))
+
+	// Make sure invalid proposals are only recorded for the proposer
+	i.recordInvalidProposal(1, 0, proposers[1])
+	i.recordInvalidProposal(1, 0, nodeID)
+
+	// Round 0 times out, and the proposer of round 1 is finalized
+	i.recordRoundTimeout(1, 0)
+	i.recordFinalization(1, 1)
+
+	assert.Equal(
+		t,
+		ProposerRecords{
+			string(proposers[0]): {
+				Timeouts:            1,
+				ConsecutiveFailures: 1,
+				LastFailureHeight:   1,
+			},
+		},
+		i.ProposerRecords(),
+	)
+
+	// Make sure the failing proposer is demoted
+	assert.False(t, i.isProposer(proposers[0], 2, 0))
+	assert.True(t, i.isProposer(proposers[1], 2, 0))
+
+	// Make sure timeouts are not recorded once a proposal is accepted
+	i.state.setProposalMessage(&proto.Message{From: proposers[1]})
+	i.recordRoundTimeout(2, 0)
+	i.recordFinalization(2, 1)
+
+	assert.Equal(t, uint64(1), i.ProposerRecords()[string(proposers[0])].Timeouts)
+}
@@ -0,0 +1,41 @@
+package core
+
+import "github.com/renloi/ibft/messages/proto"
+
+// MessageProvider serves stored consensus messages to peers that pull them,
+// for peers behind NATs or with unreliable gossip.
+// It can be exposed by the node over any RPC transport
+type MessageProvider interface {
+	// GetMessages fetches the messages of a specific type for the specified view,
+	// that arrived after the since cursor, along with the cursor for the next call.
+	// A since cursor of 0 fetches all the messages for the view
+	GetMessages(
+		view *proto.View,
+		messageType proto.MessageType,
+		since uint64,
+	) ([]*proto.Message, uint64)
+}
+
+// GetMessages fetches the received messages of a specific type for the specified view,
+// that arrived after the since cursor, along with the cursor for the next call.
+// The requests for the unknown types, or the types that are never stored, are rejected
+func (i *IBFT) GetMessages(
+	view *proto.View,
+	messageType proto.MessageType,
+	since uint64,
+) ([]*proto.Message, uint64) {
+	if view == nil || !isPullableType(messageType) {
+		return nil, since
+	}
+
+	return i.messages.GetMessages(view, messageType, since)
+}
+
+// isPullableType checks if the messages of the type can be pulled
+func isPullableType(messageType proto.MessageType) bool {
+	if _, known := proto.MessageType_name[int32(messageType)]; !known {
+		return false
+	}
+
+	return !isUnstoredType(messageType)
+}
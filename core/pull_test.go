@@ -0,0 +1,52 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+func TestIBFT_GetMessages(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view = &proto.View{
+			Height: 1,
+			Round:  0,
+		}
+
+		prepares = []*proto.Message{
+			buildBasicPrepareMessage(nil, []byte("node 1"), view),
+			buildBasicPrepareMessage(nil, []byte("node 2"), view),
+		}
+	)
+
+	i := NewIBFT(mockLogger{}, mockBackend{}, mockTransport{})
+	i.state.setView(view)
+
+	var provider MessageProvider = i
+
+	// Make sure invalid requests are handled
+	messages, cursor := provider.GetMessages(nil, proto.MessageType_PREPARE, 5)
+	assert.Nil(t, messages)
+	assert.Equal(t, uint64(5), cursor)
+
+	for _, messageType := range []proto.MessageType{proto.MessageType_FINALITY, proto.MessageType(99)} {
+		messages, cursor = provider.GetMessages(view, messageType, 5)
+		assert.Nil(t, messages)
+		assert.Equal(t, uint64(5), cursor)
+	}
+
+	i.AddMessage(prepares[0])
+
+	messages, cursor = provider.GetMessages(view, proto.MessageType_PREPARE, 0)
+	assert.Equal(t, prepares[:1], messages)
+
+	i.AddMessage(prepares[1])
+
+	// Make sure only the missing messages are pulled
+	messages, _ = provider.GetMessages(view, proto.MessageType_PREPARE, cursor)
+	assert.Equal(t, prepares[1:], messages)
+}
@@ -0,0 +1,348 @@
+package core
+
+import (
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// QuorumVerifier determines if a set of messages of the same type reaches quorum.
+// It decouples the quorum rules from the Backend, so the same backend
+// can serve networks with different quorum rules
+type QuorumVerifier interface {
+	// HasQuorum returns true if the quorum is reached
+	// for the specified height
+	HasQuorum(height uint64, msgs []*proto.Message, msgType proto.MessageType) bool
+}
+
+// backendQuorum is the default quorum verifier, which uses the Backend
+type backendQuorum struct {
+	backend Backend
+}
+
+// HasQuorum returns true if the backend reports the quorum is reached
+func (q backendQuorum) HasQuorum(height uint64, msgs []*proto.Message, msgType proto.MessageType) bool {
+	return q.backend.HasQuorum(height, msgs, msgType)
+}
+
+// MaxFaulty returns the maximum number of faulty validators (f)
+// tolerated out of the total number of validators, as N >= 3f + 1
+func MaxFaulty(validators uint64) uint64 {
+	if validators == 0 {
+		return 0
+	}
+
+	return (validators - 1) / 3
+}
+
+// QuorumSize returns the minimum number of validators required for quorum,
+// out of the total number of validators: ceil(2N/3), or N if no faulty
+// validators are tolerated. It is the quorum used by CountQuorum
+func QuorumSize(validators uint64) uint64 {
+	if MaxFaulty(validators) == 0 {
+		return validators
+	}
+
+	return (2*validators + 2) / 3
+}
+
+// RejectionQuorumSize returns the minimum number of validators rejecting the proposal
+// (see WithVetoes) for the PREPARE quorum to be out of reach: N - QuorumSize(N) + 1,
+// which is f+1 for N = 3f+1. It is the rejection quorum used by CountQuorum
+func RejectionQuorumSize(validators uint64) uint64 {
+	return validators - QuorumSize(validators) + 1
+}
+
+// WeightedMaxFaulty returns the maximum voting power of faulty validators
+// tolerated out of the total voting power, as the faulty power must be below 1/3
+func WeightedMaxFaulty(totalPower uint64) uint64 {
+	return MaxFaulty(totalPower)
+}
+
+// WeightedQuorum returns the minimum voting power required for quorum,
+// out of the total voting power: more than 2/3 of it.
+// It is the quorum used by WeightQuorum
+func WeightedQuorum(totalPower uint64) uint64 {
+	return totalPower - WeightedMaxFaulty(totalPower)
+}
+
+// WeightedRejectionQuorum returns the minimum voting power rejecting the proposal
+// for the PREPARE quorum to be out of reach, out of the total voting power.
+// It is the rejection quorum used by WeightQuorum
+func WeightedRejectionQuorum(totalPower uint64) uint64 {
+	return totalPower - WeightedQuorum(totalPower) + 1
+}
+
+// CountQuorum is the quorum verifier for validators with equal voting power.
+// The quorum is ceil(2N/3) distinct senders, or N senders if no faulty validators are tolerated.
+// The proposer does not send a PREPARE message, so it is counted towards the PREPARE quorum
+// if it is not already among the senders (ex. through the PREPREPARE of a prepared certificate).
+// The VETO messages reach quorum with RejectionQuorumSize distinct senders
+type CountQuorum struct {
+	// ValidatorCount returns the number of validators at the height
+	ValidatorCount func(height uint64) uint64
+
+	// Proposer returns the proposer for the view. If it is not set, the proposer
+	// is identified by the PREPREPARE message among the messages, if any,
+	// and is otherwise assumed not to be among the PREPARE senders
+	Proposer func(height, round uint64) []byte
+}
+
+// HasQuorum returns true if there are enough distinct senders for quorum
+func (q CountQuorum) HasQuorum(height uint64, msgs []*proto.Message, msgType proto.MessageType) bool {
+	if msgType == proto.MessageType_PREPREPARE {
+		return len(msgs) >= 1
+	}
+
+	if len(msgs) == 0 {
+		return false
+	}
+
+	var (
+		quorum  = QuorumSize(q.ValidatorCount(height))
+		senders = make(map[string]struct{}, len(msgs)+1)
+
+		hasProposal bool
+	)
+
+	for _, msg := range msgs {
+		senders[string(msg.From)] = struct{}{}
+
+		if msg.Type == proto.MessageType_PREPREPARE {
+			hasProposal = true
+		}
+	}
+
+	switch msgType {
+	case proto.MessageType_PREPARE:
+		count := uint64(len(senders))
+
+		switch {
+		case q.Proposer != nil:
+			if !hasSender(msgs, string(q.Proposer(height, msgs[0].View.GetRound()))) {
+				count++
+			}
+		case !hasProposal:
+			count++
+		}
+
+		return count >= quorum
+	case proto.MessageType_COMMIT, proto.MessageType_ROUND_CHANGE:
+		return uint64(len(senders)) >= quorum
+	case proto.MessageType_VETO:
+		return uint64(len(senders)) >= RejectionQuorumSize(q.ValidatorCount(height))
+	}
+
+	return false
+}
+
+// WeightQuorum is the quorum verifier for validators with different voting power.
+// The quorum is reached when the senders hold more than 2/3 of the total voting power.
+// The VETO messages reach quorum when the senders hold WeightedRejectionQuorum of it
+type WeightQuorum struct {
+	// Weights returns the voting power of each validator at the height, by validator ID
+	Weights func(height uint64) map[string]uint64
+
+	// Proposer returns the proposer for the view. If set, the voting power
+	// of the proposer is counted towards the PREPARE quorum, as it does
+	// not send a PREPARE message
+	Proposer func(height, round uint64) []byte
+}
+
+// HasQuorum returns true if the senders hold enough voting power for quorum
+func (q WeightQuorum) HasQuorum(height uint64, msgs []*proto.Message, msgType proto.MessageType) bool {
+	if msgType == proto.MessageType_PREPREPARE {
+		return len(msgs) >= 1
+	}
+
+	if len(msgs) == 0 {
+		return false
+	}
+
+	var (
+		weights = q.Weights(height)
+		senders = make(map[string]struct{}, len(msgs)+1)
+
+		total, power uint64
+	)
+
+	for _, weight := range weights {
+		total += weight
+	}
+
+	for _, msg := range msgs {
+		senders[string(msg.From)] = struct{}{}
+	}
+
+	if msgType == proto.MessageType_PREPARE && q.Proposer != nil {
+		senders[string(q.Proposer(height, msgs[0].View.GetRound()))] = struct{}{}
+	}
+
+	for sender := range senders {
+		power += weights[sender]
+	}
+
+	if msgType == proto.MessageType_VETO {
+		return total > 0 && power >= WeightedRejectionQuorum(total)
+	}
+
+	return total > 0 && power >= WeightedQuorum(total)
+}
+
+// ValidatorClass is the class of a validator in dual-class networks
+type ValidatorClass uint8
+
+const (
+	// PrimaryClass is the primary validator class (ex. core validators)
+	PrimaryClass ValidatorClass = iota
+
+	// SecondaryClass is the secondary validator class (ex. community validators)
+	SecondaryClass
+)
+
+// String returns the human-readable validator class
+func (c ValidatorClass) String() string {
+	switch c {
+	case PrimaryClass:
+		return "primary"
+	case SecondaryClass:
+		return "secondary"
+	}
+
+	return "unknown"
+}
+
+// DualClassQuorum is the quorum verifier for networks with two validator classes.
+// The quorum is reached when both classes independently reach their count quorum
+// (ceil(2N/3) of the class validators). A class without validators imposes no threshold.
+// The VETO messages reach quorum when either class reaches its rejection quorum,
+// as the PREPARE quorum of the class is then out of reach
+type DualClassQuorum struct {
+	// Classes returns the class of each validator at the height, by validator ID
+	Classes func(height uint64) map[string]ValidatorClass
+
+	// Proposer returns the proposer for the view. If set, the proposer
+	// is counted towards the PREPARE quorum of its class, as it does
+	// not send a PREPARE message
+	Proposer func(height, round uint64) []byte
+}
+
+// HasQuorum returns true if both validator classes reach quorum
+func (q DualClassQuorum) HasQuorum(height uint64, msgs []*proto.Message, msgType proto.MessageType) bool {
+	if msgType == proto.MessageType_PREPREPARE {
+		return len(msgs) >= 1
+	}
+
+	if len(msgs) == 0 {
+		return false
+	}
+
+	var (
+		classes = q.Classes(height)
+		sizes   = make(map[ValidatorClass]uint64, 2)
+		counts  = q.CountByClass(height, msgs)
+	)
+
+	for _, class := range classes {
+		sizes[class]++
+	}
+
+	if msgType == proto.MessageType_PREPARE && q.Proposer != nil {
+		proposer := string(q.Proposer(height, msgs[0].View.GetRound()))
+
+		if class, ok := classes[proposer]; ok && !hasSender(msgs, proposer) {
+			counts[class]++
+		}
+	}
+
+	if msgType == proto.MessageType_VETO {
+		for _, class := range []ValidatorClass{PrimaryClass, SecondaryClass} {
+			if sizes[class] > 0 && counts[class] >= RejectionQuorumSize(sizes[class]) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	for _, class := range []ValidatorClass{PrimaryClass, SecondaryClass} {
+		if counts[class] < QuorumSize(sizes[class]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CountByClass returns the number of distinct validator senders in each class.
+// Senders that are not validators at the height are not counted
+func (q DualClassQuorum) CountByClass(height uint64, msgs []*proto.Message) map[ValidatorClass]uint64 {
+	var (
+		classes = q.Classes(height)
+		counts  = make(map[ValidatorClass]uint64, 2)
+		seen    = make(map[string]struct{}, len(msgs))
+	)
+
+	for _, msg := range msgs {
+		if _, ok := seen[string(msg.From)]; ok {
+			continue
+		}
+
+		seen[string(msg.From)] = struct{}{}
+
+		if class, ok := classes[string(msg.From)]; ok {
+			counts[class]++
+		}
+	}
+
+	return counts
+}
+
+// SplitByClass groups the certificate messages by the class of their sender,
+// for chains that keep a per-class representation of certificates.
+// Senders that are not validators at the height are left out
+func (q DualClassQuorum) SplitByClass(height uint64, msgs []*proto.Message) map[ValidatorClass][]*proto.Message {
+	var (
+		classes = q.Classes(height)
+		split   = make(map[ValidatorClass][]*proto.Message, 2)
+	)
+
+	for _, msg := range msgs {
+		if class, ok := classes[string(msg.From)]; ok {
+			split[class] = append(split[class], msg)
+		}
+	}
+
+	return split
+}
+
+// SplitSealsByClass groups the committed seals by the class of their signer,
+// for chains that keep a per-class representation of the commit certificate.
+// Signers that are not validators at the height are left out
+func (q DualClassQuorum) SplitSealsByClass(
+	height uint64,
+	seals []*messages.CommittedSeal,
+) map[ValidatorClass][]*messages.CommittedSeal {
+	var (
+		classes = q.Classes(height)
+		split   = make(map[ValidatorClass][]*messages.CommittedSeal, 2)
+	)
+
+	for _, seal := range seals {
+		if class, ok := classes[string(seal.Signer)]; ok {
+			split[class] = append(split[class], seal)
+		}
+	}
+
+	return split
+}
+
+// hasSender checks if any of the messages is from the sender
+func hasSender(msgs []*proto.Message, sender string) bool {
+	for _, msg := range msgs {
+		if string(msg.From) == sender {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,484 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// generateQuorumMessages generates messages of the type from the specified senders
+func generateQuorumMessages(msgType proto.MessageType, senders ...string) []*proto.Message {
+	msgs := make([]*proto.Message, 0, len(senders))
+
+	for _, sender := range senders {
+		msgs = append(msgs, &proto.Message{
+			View: &proto.View{Height: 1, Round: 0},
+			From: []byte(sender),
+			Type: msgType,
+		})
+	}
+
+	return msgs
+}
+
+// generateSenders generates the specified number of sender IDs
+func generateSenders(count int) []string {
+	senders := make([]string, count)
+
+	for index := range senders {
+		senders[index] = fmt.Sprintf("validator %d", index)
+	}
+
+	return senders
+}
+
+func TestQuorumSize(t *testing.T) {
+	t.Parallel()
+
+	testTable := []struct {
+		validators uint64
+		quorum     uint64
+	}{
+		{1, 1},
+		{3, 3},
+		{4, 3},
+		{5, 4},
+		{6, 4},
+		{7, 5},
+		{10, 7},
+		{100, 67},
+	}
+
+	for _, testCase := range testTable {
+		assert.Equal(
+			t,
+			testCase.quorum,
+			QuorumSize(testCase.validators),
+			fmt.Sprintf("validators %d", testCase.validators),
+		)
+	}
+
+	// Make sure any two quorums intersect in at least one honest validator
+	for validators := uint64(1); validators <= 100; validators++ {
+		assert.Greater(
+			t,
+			2*QuorumSize(validators),
+			validators+MaxFaulty(validators),
+			fmt.Sprintf("validators %d", validators),
+		)
+	}
+}
+
+func TestRejectionQuorumSize(t *testing.T) {
+	t.Parallel()
+
+	for validators := uint64(1); validators <= 100; validators++ {
+		rejection := RejectionQuorumSize(validators)
+
+		// Make sure the rejection quorum leaves the PREPARE quorum out of reach
+		assert.Less(t, validators-rejection, QuorumSize(validators), fmt.Sprintf("validators %d", validators))
+
+		// Make sure the faulty validators alone cannot reach the rejection quorum
+		assert.Greater(t, rejection, MaxFaulty(validators), fmt.Sprintf("validators %d", validators))
+	}
+
+	assert.Equal(t, uint64(2), RejectionQuorumSize(4))
+	assert.Equal(t, uint64(34), RejectionQuorumSize(100))
+	assert.Equal(t, uint64(34), WeightedRejectionQuorum(100))
+}
+
+func TestMaxFaulty(t *testing.T) {
+	t.Parallel()
+
+	testTable := []struct {
+		validators uint64
+		faulty     uint64
+	}{
+		{0, 0},
+		{1, 0},
+		{3, 0},
+		{4, 1},
+		{6, 1},
+		{7, 2},
+		{100, 33},
+	}
+
+	for _, testCase := range testTable {
+		assert.Equal(
+			t,
+			testCase.faulty,
+			MaxFaulty(testCase.validators),
+			fmt.Sprintf("validators %d", testCase.validators),
+		)
+	}
+}
+
+func TestWeightedQuorum(t *testing.T) {
+	t.Parallel()
+
+	testTable := []struct {
+		totalPower uint64
+		quorum     uint64
+		faulty     uint64
+	}{
+		{0, 0, 0},
+		{1, 1, 0},
+		{3, 3, 0},
+		{4, 3, 1},
+		{6, 5, 1},
+		{100, 67, 33},
+		{math.MaxUint64, math.MaxUint64 - (math.MaxUint64/3 - 1), math.MaxUint64/3 - 1},
+	}
+
+	for _, testCase := range testTable {
+		assert.Equal(
+			t,
+			testCase.quorum,
+			WeightedQuorum(testCase.totalPower),
+			fmt.Sprintf("total power %d", testCase.totalPower),
+		)
+		assert.Equal(
+			t,
+			testCase.faulty,
+			WeightedMaxFaulty(testCase.totalPower),
+			fmt.Sprintf("total power %d", testCase.totalPower),
+		)
+	}
+
+	// Make sure the quorum is the minimum power above 2/3 of the total
+	for totalPower := uint64(1); totalPower <= 100; totalPower++ {
+		quorum := WeightedQuorum(totalPower)
+
+		assert.Greater(t, 3*quorum, 2*totalPower)
+		assert.LessOrEqual(t, 3*(quorum-1), 2*totalPower)
+	}
+}
+
+func TestCountQuorum(t *testing.T) {
+	t.Parallel()
+
+	var (
+		quorum = CountQuorum{
+			ValidatorCount: func(_ uint64) uint64 {
+				return 4
+			},
+		}
+		senders = generateSenders(4)
+	)
+
+	testTable := []struct {
+		name     string
+		msgType  proto.MessageType
+		senders  []string
+		expected bool
+	}{
+		{"proposal", proto.MessageType_PREPREPARE, senders[:1], true},
+		{"missing proposal", proto.MessageType_PREPREPARE, nil, false},
+		{"prepare quorum", proto.MessageType_PREPARE, senders[:2], true},
+		{"prepare no quorum", proto.MessageType_PREPARE, senders[:1], false},
+		{"prepare duplicate senders", proto.MessageType_PREPARE, []string{senders[0], senders[0]}, false},
+		{"commit duplicate senders", proto.MessageType_COMMIT, []string{senders[0], senders[1], senders[1]}, false},
+		{"commit quorum", proto.MessageType_COMMIT, senders[:3], true},
+		{"commit no quorum", proto.MessageType_COMMIT, senders[:2], false},
+		{"round change quorum", proto.MessageType_ROUND_CHANGE, senders[:3], true},
+		{"round change no quorum", proto.MessageType_ROUND_CHANGE, senders[:2], false},
+		{"veto quorum", proto.MessageType_VETO, senders[:2], true},
+		{"veto no quorum", proto.MessageType_VETO, senders[:1], false},
+		{"veto duplicate senders", proto.MessageType_VETO, []string{senders[0], senders[0]}, false},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(
+				t,
+				testCase.expected,
+				quorum.HasQuorum(1, generateQuorumMessages(testCase.msgType, testCase.senders...), testCase.msgType),
+			)
+		})
+	}
+}
+
+func TestWeightQuorum(t *testing.T) {
+	t.Parallel()
+
+	var (
+		weights = map[string]uint64{
+			"heavy":   50,
+			"medium":  20,
+			"light 1": 15,
+			"light 2": 15,
+		}
+		quorum = WeightQuorum{
+			Weights: func(_ uint64) map[string]uint64 {
+				return weights
+			},
+			Proposer: func(_, _ uint64) []byte {
+				return []byte("heavy")
+			},
+		}
+	)
+
+	testTable := []struct {
+		name     string
+		msgType  proto.MessageType
+		senders  []string
+		expected bool
+	}{
+		{"proposal", proto.MessageType_PREPREPARE, []string{"light 1"}, true},
+		{"commit quorum", proto.MessageType_COMMIT, []string{"heavy", "medium"}, true},
+		{"commit below 2/3", proto.MessageType_COMMIT, []string{"heavy", "light 1"}, false},
+		{"commit no quorum", proto.MessageType_COMMIT, []string{"medium", "light 1", "light 2"}, false},
+		{"duplicate senders", proto.MessageType_COMMIT, []string{"heavy", "light 1", "light 1"}, false},
+		{"unknown senders", proto.MessageType_COMMIT, []string{"heavy", "unknown"}, false},
+		{"prepare counts the proposer", proto.MessageType_PREPARE, []string{"medium"}, true},
+		{"veto quorum", proto.MessageType_VETO, []string{"medium", "light 1"}, true},
+		{"veto no quorum", proto.MessageType_VETO, []string{"light 1", "light 2"}, false},
+		{"empty", proto.MessageType_ROUND_CHANGE, nil, false},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(
+				t,
+				testCase.expected,
+				quorum.HasQuorum(1, generateQuorumMessages(testCase.msgType, testCase.senders...), testCase.msgType),
+			)
+		})
+	}
+}
+
+func TestCountQuorum_Proposer(t *testing.T) {
+	t.Parallel()
+
+	var (
+		senders        = generateSenders(4)
+		validatorCount = func(_ uint64) uint64 {
+			return 4
+		}
+	)
+
+	t.Run("proposal counts the proposer", func(t *testing.T) {
+		t.Parallel()
+
+		quorum := CountQuorum{ValidatorCount: validatorCount}
+
+		msgs := append(
+			generateQuorumMessages(proto.MessageType_PREPREPARE, senders[0]),
+			generateQuorumMessages(proto.MessageType_PREPARE, senders[1])...,
+		)
+
+		assert.False(t, quorum.HasQuorum(1, msgs, proto.MessageType_PREPARE))
+
+		msgs = append(msgs, generateQuorumMessages(proto.MessageType_PREPARE, senders[2])...)
+
+		assert.True(t, quorum.HasQuorum(1, msgs, proto.MessageType_PREPARE))
+	})
+
+	t.Run("known proposer is counted once", func(t *testing.T) {
+		t.Parallel()
+
+		quorum := CountQuorum{
+			ValidatorCount: validatorCount,
+			Proposer: func(_, _ uint64) []byte {
+				return []byte(senders[0])
+			},
+		}
+
+		assert.False(
+			t,
+			quorum.HasQuorum(1, generateQuorumMessages(proto.MessageType_PREPARE, senders[:2]...), proto.MessageType_PREPARE),
+		)
+		assert.True(
+			t,
+			quorum.HasQuorum(1, generateQuorumMessages(proto.MessageType_PREPARE, senders[1:3]...), proto.MessageType_PREPARE),
+		)
+	})
+}
+
+func TestIBFT_WithQuorumVerifier(t *testing.T) {
+	t.Parallel()
+
+	var (
+		msgs    = generateQuorumMessages(proto.MessageType_COMMIT, generateSenders(3)...)
+		backend = mockBackend{
+			hasQuorumFn: func(_ uint64, _ []*proto.Message, _ proto.MessageType) bool {
+				return false
+			},
+		}
+	)
+
+	// Make sure the backend determines quorum by default
+	i := NewIBFT(mockLogger{}, backend, mockTransport{})
+	assert.False(t, i.quorum.HasQuorum(1, msgs, proto.MessageType_COMMIT))
+
+	// Make sure the quorum verifier is used instead of the backend
+	i = NewIBFT(
+		mockLogger{},
+		backend,
+		mockTransport{},
+		WithQuorumVerifier(CountQuorum{
+			ValidatorCount: func(_ uint64) uint64 {
+				return 4
+			},
+		}),
+	)
+	assert.True(t, i.quorum.HasQuorum(1, msgs, proto.MessageType_COMMIT))
+}
+
+func TestDualClassQuorum(t *testing.T) {
+	t.Parallel()
+
+	var (
+		// 4 core validators (quorum 3), and 7 community validators (quorum 5)
+		core      = []string{"core 0", "core 1", "core 2", "core 3"}
+		community = []string{"community 0", "community 1", "community 2", "community 3", "community 4", "community 5", "community 6"}
+
+		quorum = DualClassQuorum{
+			Classes: func(_ uint64) map[string]ValidatorClass {
+				classes := make(map[string]ValidatorClass)
+
+				for _, validator := range core {
+					classes[validator] = PrimaryClass
+				}
+
+				for _, validator := range community {
+					classes[validator] = SecondaryClass
+				}
+
+				return classes
+			},
+			Proposer: func(_, _ uint64) []byte {
+				return []byte(core[0])
+			},
+		}
+	)
+
+	senders := func(groups ...[]string) []string {
+		all := make([]string, 0)
+		for _, group := range groups {
+			all = append(all, group...)
+		}
+
+		return all
+	}
+
+	testTable := []struct {
+		name     string
+		msgType  proto.MessageType
+		senders  []string
+		expected bool
+	}{
+		{
+			"both classes reach quorum",
+			proto.MessageType_COMMIT,
+			senders(core[:3], community[:5]),
+			true,
+		},
+		{
+			"primary class short",
+			proto.MessageType_COMMIT,
+			senders(core[:2], community),
+			false,
+		},
+		{
+			"secondary class short",
+			proto.MessageType_ROUND_CHANGE,
+			senders(core, community[:4]),
+			false,
+		},
+		{
+			"overall 2/3 without class quorum",
+			proto.MessageType_COMMIT,
+			senders(core[:2], community[:6]),
+			false,
+		},
+		{
+			"prepare counts the proposer in its class",
+			proto.MessageType_PREPARE,
+			senders(core[1:3], community[:5]),
+			true,
+		},
+		{
+			"prepare does not count the proposer twice",
+			proto.MessageType_PREPARE,
+			senders(core[:2], community[:5]),
+			false,
+		},
+		{
+			"duplicate and unknown senders",
+			proto.MessageType_COMMIT,
+			senders(core[:2], core[:2], []string{"unknown"}, community[:5]),
+			false,
+		},
+		{
+			"primary class vetoes",
+			proto.MessageType_VETO,
+			senders(core[:2]),
+			true,
+		},
+		{
+			"secondary class vetoes",
+			proto.MessageType_VETO,
+			senders(core[:1], community[:3]),
+			true,
+		},
+		{
+			"vetoes below the class rejection quorums",
+			proto.MessageType_VETO,
+			senders(core[:1], community[:2]),
+			false,
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(
+				t,
+				testCase.expected,
+				quorum.HasQuorum(1, generateQuorumMessages(testCase.msgType, testCase.senders...), testCase.msgType),
+			)
+		})
+	}
+
+	msgs := generateQuorumMessages(proto.MessageType_COMMIT, senders(core[:2], core[:1], community[:3], []string{"unknown"})...)
+
+	// Make sure the distinct senders are counted per class
+	assert.Equal(
+		t,
+		map[ValidatorClass]uint64{PrimaryClass: 2, SecondaryClass: 3},
+		quorum.CountByClass(1, msgs),
+	)
+
+	// Make sure the certificate messages are split per class
+	split := quorum.SplitByClass(1, msgs)
+
+	assert.Len(t, split[PrimaryClass], 3)
+	assert.Len(t, split[SecondaryClass], 3)
+
+	seals := quorum.SplitSealsByClass(1, []*messages.CommittedSeal{
+		{Signer: []byte(core[0])},
+		{Signer: []byte(community[0])},
+		{Signer: []byte("unknown")},
+	})
+
+	assert.Equal(t, []byte(core[0]), seals[PrimaryClass][0].Signer)
+	assert.Equal(t, []byte(community[0]), seals[SecondaryClass][0].Signer)
+	assert.Len(t, seals, 2)
+}
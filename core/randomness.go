@@ -0,0 +1,24 @@
+package core
+
+import (
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// isValidRandomness checks if the committed seal carries a valid randomness
+// contribution, if the backend implements the RandomnessBeacon extension.
+// The contributions are not checked otherwise
+func (i *IBFT) isValidRandomness(view *proto.View, proposalHash []byte, seal *messages.CommittedSeal) bool {
+	beacon, ok := i.backend.(RandomnessBeacon)
+	if !ok {
+		return true
+	}
+
+	if len(seal.Randomness) == 0 || !beacon.IsValidRandomness(view, proposalHash, seal) {
+		i.log.Debug("invalid randomness contribution", "sender", seal.Signer, "height", view.Height)
+
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// beaconBackend is a mock backend validating the randomness contributions
+type beaconBackend struct {
+	mockBackend
+}
+
+func (b beaconBackend) IsValidRandomness(_ *proto.View, _ []byte, seal *messages.CommittedSeal) bool {
+	return bytes.Equal(seal.Randomness, append([]byte("randomness of "), seal.Signer...))
+}
+
+// buildRandomCommitMessage builds a COMMIT message carrying the randomness contribution
+func buildRandomCommitMessage(from, randomness []byte, view *proto.View) *proto.Message {
+	message := buildBasicCommitMessage(correctRoundMessage.hash, correctRoundMessage.seal, from, view)
+	message.GetCommitData().Randomness = randomness
+
+	return message
+}
+
+// TestIBFT_HandleCommit_Randomness makes sure the COMMIT messages without a valid
+// randomness contribution are not counted toward the quorum, and the contributions
+// are carried over to the committed seals
+func TestIBFT_HandleCommit_Randomness(t *testing.T) {
+	t.Parallel()
+
+	var (
+		nodes = generateNodeAddresses(4)
+		view  = &proto.View{Height: 1, Round: 0}
+
+		backend = beaconBackend{
+			mockBackend: mockBackend{
+				isValidProposalHashFn: func(_ *proto.Proposal, hash []byte) bool {
+					return bytes.Equal(hash, correctRoundMessage.hash)
+				},
+				isValidCommittedSealFn: func(_ []byte, _ *messages.CommittedSeal) bool {
+					return true
+				},
+				hasQuorumFn: commonHasQuorumFn(4),
+			},
+		}
+	)
+
+	randomness := func(node []byte) []byte {
+		return append([]byte("randomness of "), node...)
+	}
+
+	i := NewIBFT(mockLogger{}, backend, mockTransport{})
+	i.state.setView(view)
+	i.state.setProposalMessage(
+		buildBasicPreprepareMessage(
+			correctRoundMessage.proposal.GetRawProposal(),
+			correctRoundMessage.hash,
+			nil,
+			nodes[0],
+			view,
+		),
+	)
+
+	i.messages.AddMessage(buildRandomCommitMessage(nodes[0], randomness(nodes[0]), view))
+	i.messages.AddMessage(buildRandomCommitMessage(nodes[1], randomness(nodes[1]), view))
+	i.messages.AddMessage(buildRandomCommitMessage(nodes[2], []byte("biased randomness"), view))
+	i.messages.AddMessage(buildRandomCommitMessage(nodes[3], nil, view))
+
+	// Make sure the invalid and missing contributions are not counted
+	require.False(t, i.handleCommit(view, make(validatedMessages)))
+
+	i.messages.AddMessage(buildRandomCommitMessage(nodes[3], randomness(nodes[3]), view))
+
+	require.True(t, i.handleCommit(view, make(validatedMessages)))
+
+	seals := i.state.getCommittedSeals()
+	require.Len(t, seals, 3)
+
+	for _, seal := range seals {
+		assert.Equal(t, randomness(seal.Signer), seal.Randomness)
+	}
+}
@@ -155,7 +155,7 @@ func generatePropertyTestEvent(t *rapid.T) *propertyTestSetup {
 	var (
 		numNodes      = rapid.Uint64Range(4, 30).Draw(t, "number of cluster nodes")
 		desiredHeight = rapid.Uint64Range(5, 20).Draw(t, "minimum height to be reached")
-		maxBadNodes   = maxFaulty(numNodes)
+		maxBadNodes   = MaxFaulty(numNodes)
 	)
 
 	setup := &propertyTestSetup{
@@ -335,13 +335,13 @@ func TestProperty(t *testing.T) {
 		for height := uint64(0); height < setup.desiredHeight; height++ {
 			// Create context timeout based on the bad nodes number
 			rounds := uint64(len(setup.events[height]))
-			ctxTimeout := getRoundTimeout(testRoundTimeout, testRoundTimeout, rounds*2)
+			ctxTimeout := getRoundTimeout(ExponentialTimeout{}, testRoundTimeout, 0, 0, rounds*2)
 
 			// Start the main run loops
 			cluster.runSequence(height)
 
 			ctx, cancelFn := context.WithTimeout(context.Background(), ctxTimeout)
-			err := cluster.awaitNCompletions(ctx, int64(quorum(setup.nodes)))
+			err := cluster.awaitNCompletions(ctx, int64(QuorumSize(setup.nodes)))
 			assert.NoError(t, err, "unable to wait for nodes to complete on height %d", height)
 			cancelFn()
 
@@ -376,7 +376,7 @@ func TestProperty(t *testing.T) {
 			}
 
 			// Make sure the total number of inserted blocks >= quorum
-			assert.GreaterOrEqual(t, proposalsNumber, int(quorum(setup.nodes)))
+			assert.GreaterOrEqual(t, proposalsNumber, int(QuorumSize(setup.nodes)))
 
 			// Reset proposals map for the next height
 			insertedProposals = newMockInsertedProposals(setup.nodes)
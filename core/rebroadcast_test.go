@@ -0,0 +1,679 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	protoBuf "google.golang.org/protobuf/proto"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// multicastRecorder is a mock transport that records multicasted messages
+type multicastRecorder struct {
+	sync.Mutex
+
+	multicasted []*proto.Message
+}
+
+func (r *multicastRecorder) Multicast(message *proto.Message) error {
+	r.Lock()
+	defer r.Unlock()
+
+	r.multicasted = append(r.multicasted, message)
+
+	return nil
+}
+
+func (r *multicastRecorder) messages() []*proto.Message {
+	r.Lock()
+	defer r.Unlock()
+
+	return append([]*proto.Message(nil), r.multicasted...)
+}
+
+func TestIBFT_Rebroadcast(t *testing.T) {
+	t.Parallel()
+
+	view := &proto.View{
+		Height: 1,
+		Round:  0,
+	}
+
+	t.Run("nothing sent", func(t *testing.T) {
+		t.Parallel()
+
+		transport := &multicastRecorder{}
+
+		i := NewIBFT(mockLogger{}, mockBackend{}, transport)
+		i.Rebroadcast(context.Background())
+
+		assert.Len(t, transport.messages(), 0)
+	})
+
+	t.Run("latest message rebroadcasted", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			transport = &multicastRecorder{}
+			backend   = mockBackend{
+				buildPrepareMessageFn: func(_ []byte, view *proto.View) *proto.Message {
+					return &proto.Message{
+						View: view,
+						Type: proto.MessageType_PREPARE,
+					}
+				},
+				buildCommitMessageFn: func(_ []byte, view *proto.View) *proto.Message {
+					return &proto.Message{
+						View: view,
+						Type: proto.MessageType_COMMIT,
+					}
+				},
+			}
+		)
+
+		i := NewIBFT(mockLogger{}, backend, transport)
+		i.acceptProposal(buildBasicPreprepareMessage(nil, nil, nil, nil, view))
+
+		i.sendPrepareMessage(context.Background(), view)
+		i.sendCommitMessage(context.Background(), view)
+
+		i.Rebroadcast(context.Background())
+
+		multicasted := transport.messages()
+		if !assert.Len(t, multicasted, 3) {
+			return
+		}
+
+		// Make sure the latest sent message was rebroadcasted
+		assert.True(t, protoBuf.Equal(multicasted[1], multicasted[2]))
+		assert.Equal(t, proto.MessageType_COMMIT, multicasted[2].Type)
+
+		// Make sure nothing is rebroadcasted in a new height
+		i.state.clear(view.Height + 1)
+		i.Rebroadcast(context.Background())
+
+		assert.Len(t, transport.messages(), 3)
+	})
+}
+
+func TestIBFT_RebroadcastOnDuplicateProposal(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view = &proto.View{
+			Height: 1,
+			Round:  0,
+		}
+		proposer = []byte("proposer")
+
+		transport = &multicastRecorder{}
+		backend   = mockBackend{
+			buildPrepareMessageFn: func(_ []byte, view *proto.View) *proto.Message {
+				return &proto.Message{
+					View: view,
+					Type: proto.MessageType_PREPARE,
+				}
+			},
+		}
+	)
+
+	i := NewIBFT(mockLogger{}, backend, transport)
+	i.state.setView(view)
+	i.acceptProposal(buildBasicPreprepareMessage(nil, nil, nil, proposer, view))
+	i.sendPrepareMessage(context.Background(), view)
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+
+	group := newWorkerGroup(ctx)
+
+	group.spawn("duplicate proposals", i.watchForDuplicateProposals)
+
+	// Make sure the first proposal does not trigger a rebroadcast
+	i.AddMessage(buildBasicPreprepareMessage(nil, nil, nil, proposer, view))
+	assert.Len(t, i.duplicateProposal, 0)
+
+	// Make sure proposals for other views do not trigger a rebroadcast
+	i.AddMessage(buildBasicPreprepareMessage(nil, nil, nil, proposer, &proto.View{
+		Height: view.Height,
+		Round:  view.Round + 1,
+	}))
+	assert.Len(t, i.duplicateProposal, 0)
+
+	// Make sure the duplicate proposal triggers a rebroadcast
+	i.AddMessage(buildBasicPreprepareMessage(nil, nil, nil, proposer, view))
+
+	assert.Eventually(t, func() bool {
+		return len(transport.messages()) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	multicasted := transport.messages()
+	if assert.Len(t, multicasted, 2) {
+		assert.True(t, protoBuf.Equal(multicasted[0], multicasted[1]))
+	}
+
+	// Make sure the rebroadcasts are throttled
+	i.AddMessage(buildBasicPreprepareMessage(nil, nil, nil, proposer, view))
+
+	assert.Eventually(t, func() bool {
+		return len(i.duplicateProposal) == 0
+	}, time.Second, 10*time.Millisecond)
+	assert.Len(t, transport.messages(), 2)
+
+	cancelFn()
+	assert.NoError(t, group.wait())
+}
+
+func TestIBFT_AddMessage_DuplicateProposalDoesNotBlock(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view = &proto.View{
+			Height: 1,
+			Round:  0,
+		}
+		proposer = []byte("proposer")
+
+		// The transport blocks the rebroadcast indefinitely
+		transport = blockingTransport{
+			unblock: make(chan struct{}),
+		}
+	)
+
+	defer close(transport.unblock)
+
+	i := NewIBFT(mockLogger{}, mockBackend{}, transport)
+	i.state.setView(view)
+	i.state.setLastSent(&proto.Message{View: view, Type: proto.MessageType_PREPARE})
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+
+	group := newWorkerGroup(ctx)
+
+	group.spawn("duplicate proposals", i.watchForDuplicateProposals)
+
+	added := make(chan struct{})
+
+	go func() {
+		defer close(added)
+
+		for range []int{0, 1, 2} {
+			i.AddMessage(buildBasicPreprepareMessage(nil, nil, nil, proposer, view))
+		}
+	}()
+
+	// Make sure the message reception is not blocked by the rebroadcast
+	select {
+	case <-added:
+	case <-time.After(5 * time.Second):
+		t.Fatal("message reception blocked by the rebroadcast")
+	}
+}
+
+// blockingTransport is a mock transport that blocks until unblocked
+type blockingTransport struct {
+	unblock chan struct{}
+}
+
+func (t blockingTransport) Multicast(_ *proto.Message) error {
+	<-t.unblock
+
+	return nil
+}
+
+func TestIBFT_RoundChangeRebroadcastThrottling(t *testing.T) {
+	t.Parallel()
+
+	var (
+		height = uint64(1)
+		round  = uint64(2)
+
+		backend = mockBackend{
+			buildRoundChangeMessageFn: func(
+				_ *proto.Proposal,
+				_ *proto.PreparedCertificate,
+				view *proto.View,
+			) *proto.Message {
+				return &proto.Message{
+					View: view,
+					Type: proto.MessageType_ROUND_CHANGE,
+				}
+			},
+		}
+	)
+
+	t.Run("rebroadcasts rate-limited", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			transport = &multicastRecorder{}
+			metrics   = &counterMetrics{}
+		)
+
+		i := NewIBFT(mockLogger{}, backend, transport, WithMetrics(metrics))
+		i.sendRoundChangeMessage(context.Background(), height, round)
+
+		// Make sure the message is not multicasted again within the interval
+		i.Rebroadcast(context.Background())
+		i.Rebroadcast(context.Background())
+
+		assert.Len(t, transport.messages(), 1)
+		assert.Equal(t, float32(2), metrics.counter(roundChangeThrottledKey))
+
+		// Make sure the next round is not rate-limited
+		i.sendRoundChangeMessage(context.Background(), height, round+1)
+
+		assert.Len(t, transport.messages(), 2)
+	})
+
+	t.Run("rate limit disabled", func(t *testing.T) {
+		t.Parallel()
+
+		transport := &multicastRecorder{}
+
+		i := NewIBFT(mockLogger{}, backend, transport, WithRoundChangeRebroadcastInterval(0))
+		i.sendRoundChangeMessage(context.Background(), height, round)
+
+		i.Rebroadcast(context.Background())
+		i.Rebroadcast(context.Background())
+
+		assert.Len(t, transport.messages(), 3)
+	})
+
+	t.Run("rebroadcasts suppressed after the quorum", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			transport = &multicastRecorder{}
+			metrics   = &counterMetrics{}
+		)
+
+		i := NewIBFT(
+			mockLogger{},
+			backend,
+			transport,
+			WithMetrics(metrics),
+			WithRoundChangeRebroadcastInterval(0),
+		)
+		i.sendRoundChangeMessage(context.Background(), height, round)
+
+		// Make sure the quorum of a lower round does not suppress the rebroadcast
+		i.quorumMemo.setQuorum(&proto.View{Height: height, Round: round - 1}, proto.MessageType_ROUND_CHANGE)
+		i.Rebroadcast(context.Background())
+
+		assert.Len(t, transport.messages(), 2)
+
+		// Make sure the quorum of a higher round suppresses the rebroadcast
+		i.quorumMemo.setQuorum(&proto.View{Height: height, Round: round + 1}, proto.MessageType_ROUND_CHANGE)
+		i.Rebroadcast(context.Background())
+
+		assert.Len(t, transport.messages(), 2)
+		assert.Equal(t, float32(1), metrics.counter(roundChangeSuppressedKey))
+	})
+
+	t.Run("repeated sends throttled", func(t *testing.T) {
+		t.Parallel()
+
+		transport := &multicastRecorder{}
+
+		i := NewIBFT(mockLogger{}, backend, transport)
+
+		// Make sure re-entering the same round does not multicast the message again
+		i.sendRoundChangeMessage(context.Background(), height, round)
+		i.sendRoundChangeMessage(context.Background(), height, round)
+
+		assert.Len(t, transport.messages(), 1)
+	})
+}
+
+func TestRoundChangeThrottle_Backoff(t *testing.T) {
+	t.Parallel()
+
+	var (
+		now      = time.Now()
+		view     = &proto.View{Height: 1, Round: 2}
+		nextView = &proto.View{Height: 1, Round: 3}
+	)
+
+	throttle := newRoundChangeThrottle(time.Second, 5*time.Second)
+
+	assert.True(t, throttle.allow(view, now))
+
+	// Make sure the interval doubles after each multicast, up to the limit
+	for _, backoff := range []time.Duration{
+		time.Second,
+		2 * time.Second,
+		4 * time.Second,
+		5 * time.Second,
+		5 * time.Second,
+	} {
+		assert.False(t, throttle.allow(view, now.Add(backoff-time.Millisecond)))
+
+		now = now.Add(backoff)
+
+		assert.True(t, throttle.allow(view, now))
+	}
+
+	// Make sure moving to another round resets the backoff
+	assert.True(t, throttle.allow(nextView, now))
+	assert.False(t, throttle.allow(nextView, now.Add(time.Second-time.Millisecond)))
+	assert.True(t, throttle.allow(nextView, now.Add(time.Second)))
+
+	// Make sure the backoff is disabled with a limit not above the interval
+	throttle = newRoundChangeThrottle(time.Second, 0)
+
+	assert.True(t, throttle.allow(view, now))
+
+	for range [3]struct{}{} {
+		now = now.Add(time.Second)
+
+		assert.True(t, throttle.allow(view, now))
+	}
+}
+
+func TestIBFT_RebroadcastCommit(t *testing.T) {
+	t.Parallel()
+
+	view := &proto.View{
+		Height: 1,
+		Round:  0,
+	}
+
+	testTable := []struct {
+		name          string
+		opts          []Option
+		sentType      proto.MessageType
+		quorum        bool
+		rebroadcasted int
+	}{
+		{
+			name:          "commit quorum not observed",
+			opts:          []Option{WithCommitRebroadcast(0.001, 3)},
+			sentType:      proto.MessageType_COMMIT,
+			rebroadcasted: 3,
+		},
+		{
+			name:     "commit quorum observed",
+			opts:     []Option{WithCommitRebroadcast(0.001, 3)},
+			sentType: proto.MessageType_COMMIT,
+			quorum:   true,
+		},
+		{
+			name:     "rebroadcasts disabled",
+			opts:     []Option{WithCommitRebroadcast(0, 3)},
+			sentType: proto.MessageType_COMMIT,
+		},
+		{
+			name:     "latest sent message not COMMIT",
+			opts:     []Option{WithCommitRebroadcast(0.001, 3)},
+			sentType: proto.MessageType_ROUND_CHANGE,
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				transport = &multicastRecorder{}
+				metrics   = &counterMetrics{}
+			)
+
+			i := NewIBFT(mockLogger{}, mockBackend{}, transport, append(testCase.opts, WithMetrics(metrics))...)
+			i.state.setView(view)
+			i.state.setLastSent(&proto.Message{
+				View: view,
+				Type: testCase.sentType,
+			})
+
+			if testCase.quorum {
+				i.quorumMemo.setQuorum(view, proto.MessageType_COMMIT)
+			}
+
+			i.rebroadcastCommit(context.Background(), view)
+
+			multicasted := transport.messages()
+
+			assert.Len(t, multicasted, testCase.rebroadcasted)
+			assert.Equal(t, float32(testCase.rebroadcasted), metrics.counter(commitRebroadcastKey))
+
+			for _, message := range multicasted {
+				assert.Equal(t, proto.MessageType_COMMIT, message.Type)
+			}
+		})
+	}
+}
+
+func TestIBFT_RebroadcastCommit_Cancelled(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view      = &proto.View{Height: 1, Round: 0}
+		transport = &multicastRecorder{}
+		done      = make(chan struct{})
+	)
+
+	i := NewIBFT(mockLogger{}, mockBackend{}, transport)
+	i.state.setLastSent(&proto.Message{
+		View: view,
+		Type: proto.MessageType_COMMIT,
+	})
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+
+	go func() {
+		defer close(done)
+
+		i.rebroadcastCommit(ctx, view)
+	}()
+
+	// Make sure the rebroadcasts stop once the round is over
+	cancelFn()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("commit rebroadcasts not stopped")
+	}
+
+	assert.Empty(t, transport.messages())
+}
+
+// unicastRecorder is a mock transport that records unicasted messages
+type unicastRecorder struct {
+	multicastRecorder
+
+	unicasted []*proto.Message
+	receivers [][]byte
+}
+
+func (r *unicastRecorder) Unicast(to []byte, message *proto.Message) error {
+	r.Lock()
+	defer r.Unlock()
+
+	r.unicasted = append(r.unicasted, message)
+	r.receivers = append(r.receivers, to)
+
+	return nil
+}
+
+func (r *unicastRecorder) replies() ([]*proto.Message, [][]byte) {
+	r.Lock()
+	defer r.Unlock()
+
+	return append([]*proto.Message(nil), r.unicasted...), append([][]byte(nil), r.receivers...)
+}
+
+func TestIBFT_ReplyToProposer(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view = &proto.View{
+			Height: 1,
+			Round:  2,
+		}
+		node     = []byte("node")
+		proposer = []byte("proposer")
+
+		prepare = buildBasicPrepareMessage([]byte("hash"), node, view)
+		commit  = buildBasicCommitMessage([]byte("hash"), []byte("seal"), node, view)
+	)
+
+	testTable := []struct {
+		name     string
+		stored   []*proto.Message
+		lastSent *proto.Message
+		proposer []byte
+		replies  []proto.MessageType
+	}{
+		{
+			name:     "committed",
+			stored:   []*proto.Message{prepare, commit},
+			lastSent: commit,
+			proposer: proposer,
+			replies:  []proto.MessageType{proto.MessageType_PREPARE, proto.MessageType_COMMIT},
+		},
+		{
+			name:     "own messages not delivered back",
+			stored:   []*proto.Message{prepare},
+			lastSent: commit,
+			proposer: proposer,
+			replies:  []proto.MessageType{proto.MessageType_PREPARE, proto.MessageType_COMMIT},
+		},
+		{
+			name:     "prepared",
+			lastSent: prepare,
+			proposer: proposer,
+			replies:  []proto.MessageType{proto.MessageType_PREPARE},
+		},
+		{
+			name:     "messages of the previous round",
+			lastSent: buildBasicCommitMessage([]byte("hash"), []byte("seal"), node, &proto.View{Height: 1, Round: 1}),
+			proposer: proposer,
+		},
+		{
+			name:     "not prepared",
+			proposer: proposer,
+		},
+		{
+			name:     "own proposal",
+			stored:   []*proto.Message{prepare, commit},
+			lastSent: commit,
+			proposer: node,
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				transport = &unicastRecorder{}
+				metrics   = &counterMetrics{}
+				backend   = mockBackend{
+					idFn: func() []byte {
+						return node
+					},
+				}
+			)
+
+			i := NewIBFT(mockLogger{}, backend, transport, WithMetrics(metrics))
+			i.state.setView(view)
+
+			for _, message := range testCase.stored {
+				i.messages.AddMessage(message)
+			}
+
+			if testCase.lastSent != nil {
+				i.state.setLastSent(testCase.lastSent)
+			}
+
+			i.replyToProposer(testCase.proposer)
+
+			replies, receivers := transport.replies()
+
+			if assert.Len(t, replies, len(testCase.replies)) {
+				for index, messageType := range testCase.replies {
+					assert.Equal(t, messageType, replies[index].Type)
+					assert.Equal(t, proposer, receivers[index])
+				}
+			}
+
+			assert.Equal(t, float32(len(testCase.replies)), metrics.counter(proposalReplyKey))
+
+			// Make sure the replies are not multicasted
+			assert.Empty(t, transport.messages())
+		})
+	}
+}
+
+func TestIBFT_ReplyToDuplicateProposal(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view = &proto.View{
+			Height: 1,
+			Round:  0,
+		}
+		proposer = []byte("proposer")
+	)
+
+	t.Run("duplicate proposal replied to", func(t *testing.T) {
+		t.Parallel()
+
+		transport := &unicastRecorder{}
+
+		i := NewIBFT(mockLogger{}, mockBackend{}, transport)
+		i.state.setView(view)
+		i.state.setLastSent(buildBasicCommitMessage(nil, nil, nil, view))
+
+		ctx, cancelFn := context.WithCancel(context.Background())
+		defer cancelFn()
+
+		group := newWorkerGroup(ctx)
+
+		group.spawn("duplicate proposals", i.watchForDuplicateProposals)
+
+		i.AddMessage(buildBasicPreprepareMessage(nil, nil, nil, proposer, view))
+		i.AddMessage(buildBasicPreprepareMessage(nil, nil, nil, proposer, view))
+
+		// Make sure the proposer is sent the COMMIT message of the node
+		assert.Eventually(t, func() bool {
+			replies, _ := transport.replies()
+
+			return len(replies) == 1
+		}, time.Second, 10*time.Millisecond)
+
+		replies, receivers := transport.replies()
+		if assert.Len(t, replies, 1) {
+			assert.Equal(t, proto.MessageType_COMMIT, replies[0].Type)
+			assert.Equal(t, proposer, receivers[0])
+		}
+
+		cancelFn()
+		assert.NoError(t, group.wait())
+	})
+
+	t.Run("transport does not unicast", func(t *testing.T) {
+		t.Parallel()
+
+		i := NewIBFT(mockLogger{}, mockBackend{}, &multicastRecorder{})
+		i.state.setView(view)
+
+		i.AddMessage(buildBasicPreprepareMessage(nil, nil, nil, proposer, view))
+		i.AddMessage(buildBasicPreprepareMessage(nil, nil, nil, proposer, view))
+
+		// Make sure only the rebroadcast is requested
+		assert.Len(t, i.duplicateProposal, 1)
+		assert.Len(t, i.proposalReplies, 0)
+	})
+}
@@ -0,0 +1,175 @@
+package core
+
+import (
+	"bytes"
+	"context"
+
+	protoBuf "google.golang.org/protobuf/proto"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// RecoveryState is the consensus progress of the node within a height,
+// saved before each message the node multicasts
+type RecoveryState struct {
+	// View is the view the node was in
+	View *proto.View
+
+	// ProposalMessage is the proposal accepted (or built) for the round, if any
+	ProposalMessage *proto.Message
+
+	// CommitSent is the flag indicating if the COMMIT message for the round was sent
+	CommitSent bool
+
+	// LatestPC is the latest prepared certificate of the node, if any
+	LatestPC *proto.PreparedCertificate
+
+	// LatestPreparedProposal is the proposal of the latest prepared certificate
+	LatestPreparedProposal *proto.Proposal
+
+	// LastSent is the latest PREPARE, COMMIT or ROUND_CHANGE message sent in the height
+	LastSent *proto.Message
+}
+
+// RecoveryStore is an optional Backend extension for persisting the consensus
+// progress of the node, so a node that crashes mid-round resumes the round
+// instead of starting the height over. If the backend implements it, the recovery
+// state is saved before each PREPREPARE, PREPARE, COMMIT and ROUND_CHANGE multicast,
+// and RunSequence restores the state saved for its height. The restored node:
+//
+//   - resumes the saved round with the saved proposal, so a node that crashed after
+//     sending PREPARE (but before COMMIT) never proposes, or prepares, a different
+//     proposal in that round, and sends its COMMIT once it observes a quorum of PREPARE messages
+//   - keeps the saved prepared certificate, so a node that crashed after sending COMMIT
+//     (but before InsertProposal) never sends COMMIT again in that round, and carries
+//     the prepared proposal over in its ROUND_CHANGE messages of the later rounds
+//   - rebroadcasts its own proposal and the last sent message, so the other validators
+//     can still reach quorum on them. The proposal is inserted once the node observes
+//     a quorum of COMMIT messages again
+//
+// Without a recovery store, a restarted node starts the height over from round 0
+type RecoveryStore interface {
+	// SaveRecoveryState durably saves the recovery state, replacing the saved one.
+	// If the state cannot be saved, the message it precedes is not multicasted,
+	// as the node could contradict it after a crash
+	SaveRecoveryState(state *RecoveryState) error
+
+	// LoadRecoveryState returns the saved recovery state, if any.
+	// A state saved for a different height is ignored
+	LoadRecoveryState() (*RecoveryState, error)
+}
+
+// recoveryState returns a copy of the recovery state of the current snapshot
+func (s *state) recoveryState() *RecoveryState {
+	snapshot := s.load()
+
+	recovery := &RecoveryState{
+		View: &proto.View{
+			Height: snapshot.view.Height,
+			Round:  snapshot.view.Round,
+		},
+		CommitSent: snapshot.commitSent,
+	}
+
+	recovery.ProposalMessage, _ = protoBuf.Clone(snapshot.proposalMessage).(*proto.Message)
+	recovery.LastSent, _ = protoBuf.Clone(snapshot.lastSent).(*proto.Message)
+
+	if snapshot.latestPC != nil {
+		recovery.LatestPC = snapshot.latestPC.Copy()
+	}
+
+	if snapshot.latestPreparedProposal != nil {
+		recovery.LatestPreparedProposal = snapshot.latestPreparedProposal.Copy()
+	}
+
+	return recovery
+}
+
+// restore replaces the current snapshot with the recovery state
+func (s *state) restore(recovery *RecoveryState) {
+	var (
+		proposalMessage, _ = protoBuf.Clone(recovery.ProposalMessage).(*proto.Message)
+		lastSent, _        = protoBuf.Clone(recovery.LastSent).(*proto.Message)
+		latestPC           *proto.PreparedCertificate
+		latestProposal     *proto.Proposal
+	)
+
+	if recovery.LatestPC != nil {
+		latestPC = recovery.LatestPC.Copy()
+	}
+
+	if recovery.LatestPreparedProposal != nil {
+		latestProposal = recovery.LatestPreparedProposal.Copy()
+	}
+
+	s.update(func(next *stateSnapshot) {
+		*next = stateSnapshot{
+			view: &proto.View{
+				Height: recovery.View.Height,
+				Round:  recovery.View.Round,
+			},
+			latestPC:               latestPC,
+			latestPreparedProposal: latestProposal,
+			proposalMessage:        proposalMessage,
+			commitSent:             recovery.CommitSent,
+			lastSent:               lastSent,
+		}
+	})
+}
+
+// saveRecoveryState saves the current recovery state, if the backend persists it.
+// Returns false if the state could not be saved
+func (i *IBFT) saveRecoveryState() bool {
+	store, ok := i.backend.(RecoveryStore)
+	if !ok {
+		return true
+	}
+
+	if err := store.SaveRecoveryState(i.state.recoveryState()); err != nil {
+		i.log.Error("unable to save the recovery state", "err", err)
+		i.metrics.IncrCounter(recoverySaveFailedKey, 1)
+
+		return false
+	}
+
+	return true
+}
+
+// restoreRecoveryState restores the recovery state saved for the height,
+// if the backend persists it, and rebroadcasts the restored messages of the node
+func (i *IBFT) restoreRecoveryState(ctx context.Context, height uint64) {
+	store, ok := i.backend.(RecoveryStore)
+	if !ok {
+		return
+	}
+
+	recovery, err := store.LoadRecoveryState()
+	if err != nil {
+		i.log.Error("unable to load the recovery state", "height", height, "err", err)
+
+		return
+	}
+
+	if recovery == nil || recovery.View == nil || recovery.View.Height != height {
+		return
+	}
+
+	i.state.restore(recovery)
+
+	i.log.Info(
+		"consensus state recovered",
+		"height", height,
+		"round", recovery.View.Round,
+		"commit sent", recovery.CommitSent,
+	)
+
+	// The messages sent before the crash may not have reached the other validators
+	if proposalMessage := i.state.getProposalMessage(); proposalMessage != nil &&
+		bytes.Equal(proposalMessage.From, i.backend.ID()) {
+		i.multicast(ctx, proposalMessage)
+	}
+
+	if lastSent := i.state.getLastSent(); lastSent != nil {
+		i.multicast(ctx, lastSent)
+	}
+}
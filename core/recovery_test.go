@@ -0,0 +1,470 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	protoBuf "google.golang.org/protobuf/proto"
+
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// memoryRecoveryStore keeps the saved recovery state in memory,
+// so it survives the restarts of the node
+type memoryRecoveryStore struct {
+	sync.Mutex
+
+	saved   *RecoveryState
+	saveErr error
+}
+
+func (s *memoryRecoveryStore) load() *RecoveryState {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.saved
+}
+
+// recoveryBackend is a mock backend that persists the recovery state
+type recoveryBackend struct {
+	mockBackend
+
+	store *memoryRecoveryStore
+}
+
+func (b recoveryBackend) SaveRecoveryState(state *RecoveryState) error {
+	b.store.Lock()
+	defer b.store.Unlock()
+
+	if b.store.saveErr != nil {
+		return b.store.saveErr
+	}
+
+	b.store.saved = state
+
+	return nil
+}
+
+func (b recoveryBackend) LoadRecoveryState() (*RecoveryState, error) {
+	return b.store.load(), nil
+}
+
+// recoveryHash returns the proposal hash of the raw proposal
+func recoveryHash(rawProposal []byte) []byte {
+	return append([]byte("hash of "), rawProposal...)
+}
+
+// recoveryNode is node 1 of a 4 validator set, which can crash
+// and restart at any point, keeping only its recovery store
+type recoveryNode struct {
+	t *testing.T
+
+	validators [][]byte
+	store      *memoryRecoveryStore
+	backend    recoveryBackend
+
+	ibft         *IBFT
+	cancelFn     context.CancelFunc
+	sequenceDone <-chan struct{}
+
+	multicasted chan *proto.Message
+	inserted    chan *proto.Proposal
+}
+
+// newRecoveryNode creates the node, with the proposer of each
+// round determined by the proposer function
+func newRecoveryNode(t *testing.T, proposerFn func(round uint64) int) *recoveryNode {
+	t.Helper()
+
+	n := &recoveryNode{
+		t:           t,
+		validators:  generateNodeAddresses(4),
+		store:       &memoryRecoveryStore{},
+		multicasted: make(chan *proto.Message, 16),
+		inserted:    make(chan *proto.Proposal, 1),
+	}
+
+	id := n.validators[1]
+
+	n.backend = recoveryBackend{
+		store: n.store,
+		mockBackend: mockBackend{
+			idFn: func() []byte {
+				return id
+			},
+			isProposerFn: func(from []byte, _, round uint64) bool {
+				return bytes.Equal(from, n.validators[proposerFn(round)])
+			},
+			hasQuorumFn: commonHasQuorumFn(4),
+			isValidProposalHashFn: func(proposal *proto.Proposal, hash []byte) bool {
+				return bytes.Equal(hash, recoveryHash(proposal.GetRawProposal()))
+			},
+			buildPrePrepareMessageFn: func(
+				rawProposal []byte,
+				certificate *proto.RoundChangeCertificate,
+				view *proto.View,
+			) *proto.Message {
+				return buildBasicPreprepareMessage(rawProposal, recoveryHash(rawProposal), certificate, id, view)
+			},
+			buildPrepareMessageFn: func(proposalHash []byte, view *proto.View) *proto.Message {
+				return buildBasicPrepareMessage(proposalHash, id, view)
+			},
+			buildCommitMessageFn: func(proposalHash []byte, view *proto.View) *proto.Message {
+				return buildBasicCommitMessage(proposalHash, []byte("seal"), id, view)
+			},
+			buildRoundChangeMessageFn: func(
+				proposal *proto.Proposal,
+				certificate *proto.PreparedCertificate,
+				view *proto.View,
+			) *proto.Message {
+				return buildBasicRoundChangeMessage(proposal, certificate, view, id)
+			},
+			insertProposalFn: func(proposal *proto.Proposal, _ []*messages.CommittedSeal) {
+				n.inserted <- proposal
+			},
+		},
+	}
+
+	return n
+}
+
+// start starts the node at the height
+func (n *recoveryNode) start(height uint64) {
+	transport := mockTransport{
+		multicastFn: func(message *proto.Message) {
+			n.assertSaved(message)
+
+			n.multicasted <- message
+		},
+	}
+
+	n.ibft = NewIBFT(mockLogger{}, n.backend, transport)
+
+	var ctx context.Context
+
+	ctx, n.cancelFn = context.WithCancel(context.Background())
+	n.sequenceDone = runSequenceAsync(ctx, n.ibft, height)
+}
+
+// crash stops the node, discarding its in-memory state
+func (n *recoveryNode) crash() {
+	n.cancelFn()
+	waitSequenceDone(n.t, n.sequenceDone)
+}
+
+// assertSaved makes sure the multicasted message
+// is recorded in the saved recovery state
+func (n *recoveryNode) assertSaved(message *proto.Message) {
+	saved := n.store.load()
+	if !assert.NotNil(n.t, saved, "message multicasted before the recovery state is saved") {
+		return
+	}
+
+	if message.Type == proto.MessageType_PREPREPARE {
+		assert.True(n.t, protoBuf.Equal(message, saved.ProposalMessage))
+
+		return
+	}
+
+	assert.True(n.t, protoBuf.Equal(message, saved.LastSent))
+}
+
+// expectMulticast waits for the next multicasted message, and makes sure it is of the type
+func (n *recoveryNode) expectMulticast(messageType proto.MessageType) *proto.Message {
+	n.t.Helper()
+
+	select {
+	case message := <-n.multicasted:
+		require.Equal(n.t, messageType, message.Type)
+
+		return message
+	case <-time.After(5 * time.Second):
+		n.t.Fatalf("%s message not multicasted", messageType)
+	}
+
+	return nil
+}
+
+// expectInserted waits for the inserted proposal
+func (n *recoveryNode) expectInserted() *proto.Proposal {
+	n.t.Helper()
+
+	select {
+	case proposal := <-n.inserted:
+		return proposal
+	case <-time.After(5 * time.Second):
+		n.t.Fatal("proposal not inserted")
+	}
+
+	return nil
+}
+
+// addProposal adds the proposal of the validator for the view
+func (n *recoveryNode) addProposal(from int, rawProposal []byte, view *proto.View) {
+	n.ibft.AddMessage(buildBasicPreprepareMessage(
+		rawProposal,
+		recoveryHash(rawProposal),
+		nil,
+		n.validators[from],
+		view,
+	))
+}
+
+// addPrepares adds the PREPARE messages of the validators for the view
+func (n *recoveryNode) addPrepares(rawProposal []byte, view *proto.View, from ...int) {
+	for _, index := range from {
+		n.ibft.AddMessage(buildBasicPrepareMessage(recoveryHash(rawProposal), n.validators[index], view))
+	}
+}
+
+// addCommits adds the COMMIT messages of the validators for the view
+func (n *recoveryNode) addCommits(rawProposal []byte, view *proto.View, from ...int) {
+	for _, index := range from {
+		n.ibft.AddMessage(buildBasicCommitMessage(
+			recoveryHash(rawProposal),
+			[]byte("seal"),
+			n.validators[index],
+			view,
+		))
+	}
+}
+
+// extractPrepareHash extracts the proposal hash of the PREPARE message
+func extractPrepareHash(t *testing.T, message *proto.Message) []byte {
+	t.Helper()
+
+	prepareData, err := messages.ExtractPayload[*proto.PrepareMessage](message)
+	require.NoError(t, err)
+
+	return prepareData.ProposalHash
+}
+
+// extractCommitHash extracts the proposal hash of the COMMIT message
+func extractCommitHash(t *testing.T, message *proto.Message) []byte {
+	t.Helper()
+
+	commitData, err := messages.ExtractPayload[*proto.CommitMessage](message)
+	require.NoError(t, err)
+
+	return commitData.ProposalHash
+}
+
+// TestIBFT_Recovery_CrashAfterPrepare makes sure a node that crashed
+// after sending PREPARE, but before COMMIT, resumes the round with the prepared
+// proposal, and does not prepare a conflicting proposal of an equivocating proposer
+func TestIBFT_Recovery_CrashAfterPrepare(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view      = &proto.View{Height: 1, Round: 0}
+		proposal  = []byte("proposal")
+		conflicts = []byte("conflicting proposal")
+		node      = newRecoveryNode(t, func(_ uint64) int {
+			return 0
+		})
+	)
+
+	node.start(view.Height)
+
+	node.addProposal(0, proposal, view)
+	assert.Equal(t, recoveryHash(proposal), extractPrepareHash(t, node.expectMulticast(proto.MessageType_PREPARE)))
+
+	node.crash()
+
+	saved := node.store.load()
+	require.NotNil(t, saved)
+	assert.Equal(t, view, saved.View)
+	assert.False(t, saved.CommitSent)
+	assert.Nil(t, saved.LatestPC)
+
+	node.start(view.Height)
+	defer node.crash()
+
+	// Make sure the PREPARE message is rebroadcasted on restart
+	assert.Equal(t, recoveryHash(proposal), extractPrepareHash(t, node.expectMulticast(proto.MessageType_PREPARE)))
+
+	// The proposer equivocates, after the node forgot the messages it received
+	node.addProposal(0, conflicts, view)
+
+	// Make sure the node commits the proposal it prepared before the crash,
+	// without preparing the conflicting one
+	node.addPrepares(proposal, view, 2, 3)
+	assert.Equal(t, recoveryHash(proposal), extractCommitHash(t, node.expectMulticast(proto.MessageType_COMMIT)))
+
+	node.addCommits(proposal, view, 0, 2, 3)
+	assert.Equal(t, proposal, node.expectInserted().GetRawProposal())
+}
+
+// TestIBFT_Recovery_CrashAfterCommit makes sure a node that crashed
+// after sending COMMIT, but before inserting the proposal, keeps its lock,
+// and does not send COMMIT again for the round
+func TestIBFT_Recovery_CrashAfterCommit(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view     = &proto.View{Height: 1, Round: 0}
+		proposal = []byte("proposal")
+	)
+
+	// crashAfterCommit runs the round until the node sends COMMIT,
+	// and restarts the node
+	crashAfterCommit := func(t *testing.T) *recoveryNode {
+		t.Helper()
+
+		node := newRecoveryNode(t, func(round uint64) int {
+			return int(round % 4)
+		})
+
+		node.start(view.Height)
+
+		node.addProposal(0, proposal, view)
+		node.expectMulticast(proto.MessageType_PREPARE)
+
+		node.addPrepares(proposal, view, 2, 3)
+		node.expectMulticast(proto.MessageType_COMMIT)
+
+		node.crash()
+
+		saved := node.store.load()
+		require.NotNil(t, saved)
+		assert.True(t, saved.CommitSent)
+		assert.NotNil(t, saved.LatestPC)
+		assert.Equal(t, proposal, saved.LatestPreparedProposal.GetRawProposal())
+
+		node.start(view.Height)
+
+		// Make sure the COMMIT message is rebroadcasted on restart
+		assert.Equal(t, recoveryHash(proposal), extractCommitHash(t, node.expectMulticast(proto.MessageType_COMMIT)))
+
+		return node
+	}
+
+	t.Run("proposal inserted on commit quorum", func(t *testing.T) {
+		t.Parallel()
+
+		node := crashAfterCommit(t)
+		defer node.crash()
+
+		// The PREPARE messages are received again, which must not trigger another COMMIT
+		node.addPrepares(proposal, view, 0, 2, 3)
+		node.addCommits(proposal, view, 0, 2, 3)
+
+		assert.Equal(t, proposal, node.expectInserted().GetRawProposal())
+		assert.Empty(t, node.multicasted)
+	})
+
+	t.Run("lock carried over to the next round", func(t *testing.T) {
+		t.Parallel()
+
+		node := crashAfterCommit(t)
+		defer node.crash()
+
+		node.ibft.SetRoundHint(view.Height, 1)
+
+		roundChange := node.expectMulticast(proto.MessageType_ROUND_CHANGE)
+		assert.Equal(t, uint64(1), roundChange.View.Round)
+
+		roundChangeData, err := messages.ExtractPayload[*proto.RoundChangeMessage](roundChange)
+		require.NoError(t, err)
+
+		assert.Equal(t, proposal, roundChangeData.LastPreparedProposal.GetRawProposal())
+		assert.NotNil(t, roundChangeData.LatestPreparedCertificate)
+	})
+}
+
+// TestIBFT_Recovery_CrashAfterProposal makes sure a proposer that crashed
+// after multicasting its proposal rebroadcasts it, instead of building another one
+func TestIBFT_Recovery_CrashAfterProposal(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view  = &proto.View{Height: 1, Round: 0}
+		built = make(chan struct{}, 2)
+		node  = newRecoveryNode(t, func(_ uint64) int {
+			return 1
+		})
+	)
+
+	node.backend.buildProposalFn = func(_ uint64) []byte {
+		built <- struct{}{}
+
+		return []byte("proposal")
+	}
+
+	node.start(view.Height)
+
+	proposal := node.expectMulticast(proto.MessageType_PREPREPARE)
+
+	node.crash()
+	node.start(view.Height)
+	defer node.crash()
+
+	// Make sure the same proposal is rebroadcasted, and no other one is built
+	assert.True(t, protoBuf.Equal(proposal, node.expectMulticast(proto.MessageType_PREPREPARE)))
+	assert.Len(t, built, 1)
+}
+
+// TestIBFT_Recovery_OtherHeight makes sure the recovery state
+// saved for a different height is ignored
+func TestIBFT_Recovery_OtherHeight(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view     = &proto.View{Height: 2, Round: 0}
+		proposal = []byte("proposal")
+		node     = newRecoveryNode(t, func(_ uint64) int {
+			return 0
+		})
+	)
+
+	node.store.saved = &RecoveryState{
+		View:       &proto.View{Height: 1, Round: 3},
+		CommitSent: true,
+		LastSent:   buildBasicCommitMessage(recoveryHash([]byte("old proposal")), nil, node.validators[1], view),
+	}
+
+	node.start(view.Height)
+	defer node.crash()
+
+	// Make sure the node starts the height over
+	node.addProposal(0, proposal, view)
+	assert.Equal(t, recoveryHash(proposal), extractPrepareHash(t, node.expectMulticast(proto.MessageType_PREPARE)))
+	assert.Equal(t, view, node.ibft.state.getView())
+}
+
+// TestIBFT_Recovery_SaveFailed makes sure a message is not multicasted
+// if the recovery state cannot be saved
+func TestIBFT_Recovery_SaveFailed(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view     = &proto.View{Height: 1, Round: 0}
+		metrics  = &counterMetrics{}
+		recorder = &multicastRecorder{}
+		backend  = recoveryBackend{
+			store: &memoryRecoveryStore{
+				saveErr: errors.New("disk full"),
+			},
+			mockBackend: mockBackend{
+				buildPrepareMessageFn: func(proposalHash []byte, view *proto.View) *proto.Message {
+					return buildBasicPrepareMessage(proposalHash, nil, view)
+				},
+			},
+		}
+	)
+
+	i := NewIBFT(mockLogger{}, backend, recorder, WithMetrics(metrics))
+	i.state.setView(view)
+
+	i.sendPrepareMessage(context.Background(), view)
+
+	assert.Empty(t, recorder.messages())
+	assert.Equal(t, float32(1), metrics.counter(recoverySaveFailedKey))
+}
@@ -0,0 +1,128 @@
+package core
+
+import (
+	"sync/atomic"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// rejectionReason is the reason an incoming message is not accepted
+type rejectionReason string
+
+const (
+	// rejectInvalidValidator is the rejection of a message
+	// that is not signed by a validator
+	rejectInvalidValidator rejectionReason = "invalid_validator"
+
+	// rejectNilView is the rejection of a message without a view
+	rejectNilView rejectionReason = "nil_view"
+
+	// rejectStaleHeight is the rejection of a message for a past height
+	rejectStaleHeight rejectionReason = "stale_height"
+
+	// rejectStaleRound is the rejection of a message
+	// for a past round of the current height
+	rejectStaleRound rejectionReason = "stale_round"
+
+	// rejectUnsupportedType is the rejection of a message of a type
+	// not handled by the consensus (ex. the DKG ceremony messages)
+	rejectUnsupportedType rejectionReason = "unsupported_type"
+
+	// rejectFutureTimestamp is the rejection of a message with a timestamp
+	// further in the future than the allowed clock skew
+	rejectFutureTimestamp rejectionReason = "future_timestamp"
+
+	// rejectInvalidEnvelope is the rejection of a raw message
+	// whose envelope is not signed by the message sender
+	rejectInvalidEnvelope rejectionReason = "invalid_envelope"
+
+	// rejectIgnoredSender is the rejection of a message
+	// of a sender ignored using IgnoreSender
+	rejectIgnoredSender rejectionReason = "ignored_sender"
+
+	// rejectUnknownPeer is the rejection of a message delivered
+	// by a transport peer that is not a sentry of the node (see WithSentries)
+	rejectUnknownPeer rejectionReason = "unknown_peer"
+)
+
+// rejectionReasons are all the reasons an incoming message is not accepted
+var rejectionReasons = []rejectionReason{
+	rejectInvalidValidator,
+	rejectNilView,
+	rejectStaleHeight,
+	rejectStaleRound,
+	rejectUnsupportedType,
+	rejectFutureTimestamp,
+	rejectInvalidEnvelope,
+	rejectIgnoredSender,
+	rejectUnknownPeer,
+}
+
+// rejectionKey returns the counter key of the messages rejected for the reason
+func rejectionKey(reason rejectionReason) []string {
+	return []string{"ibft", "messages", "rejected", string(reason)}
+}
+
+// rejectionSampler samples the rejected messages for logging.
+// Rejections are frequent under attack or lag, so logging each one
+// would flood the logs
+type rejectionSampler struct {
+	// every is the sampling interval: every n-th rejection
+	// for a reason is logged. A zero interval disables the logs
+	every uint64
+
+	// counts are the number of rejections, by reason
+	counts map[rejectionReason]*atomic.Uint64
+}
+
+// newRejectionSampler creates a new sampler, logging every n-th rejection for a reason
+func newRejectionSampler(every uint64) *rejectionSampler {
+	counts := make(map[rejectionReason]*atomic.Uint64, len(rejectionReasons))
+	for _, reason := range rejectionReasons {
+		counts[reason] = &atomic.Uint64{}
+	}
+
+	return &rejectionSampler{
+		every:  every,
+		counts: counts,
+	}
+}
+
+// sample counts the rejection, and returns the number of rejections
+// for the reason, if the rejection should be logged
+func (s *rejectionSampler) sample(reason rejectionReason) (uint64, bool) {
+	count := s.counts[reason].Add(1)
+
+	if s.every == 0 || (count-1)%s.every != 0 {
+		return 0, false
+	}
+
+	return count, true
+}
+
+// rejectMessage records the rejection of the incoming message, reports the offense
+// of the sender, if any, and returns false, so it can be returned by the acceptance check
+func (i *IBFT) rejectMessage(message *proto.Message, reason rejectionReason) bool {
+	return i.rejectDelivery(message, nil, reason)
+}
+
+// rejectDelivery records the rejection of the incoming message, as rejectMessage does,
+// attributing the offense to the transport peer that delivered the message, if known
+func (i *IBFT) rejectDelivery(message *proto.Message, peer []byte, reason rejectionReason) bool {
+	i.metrics.IncrCounter(rejectionKey(reason), 1)
+	i.reportRejection(message.GetFrom(), peer, reason)
+
+	if count, ok := i.rejections.sample(reason); ok {
+		i.log.Info(
+			"message rejected",
+			"reason", string(reason),
+			"from", message.GetFrom(),
+			"type", message.GetType(),
+			"height", message.GetView().GetHeight(),
+			"round", message.GetView().GetRound(),
+			"rejected", count,
+		)
+	}
+
+	return false
+}
@@ -0,0 +1,59 @@
+package core
+
+import (
+	"bytes"
+
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// defaultRelayTTL is the relay hop limit of the relayed messages
+// without a TTL, if the message TTL is not configured (see WithMessageTTL)
+const defaultRelayTTL uint32 = 8
+
+// RelayMessage adds the message to the IBFT message system, as AddMessage does,
+// and evaluates the relay policy for it. It returns the copy of the message to relay
+// to the peers, with the hop count incremented (see messages.PrepareRelay), or false
+// if the message must not be relayed: it is not acceptable (ex. not signed by a validator,
+// or stale), it was already received, or it reached its TTL.
+// It is meant for the sentry and relay nodes using the package as their message-handling
+// layer, which are not validators themselves (see WithRelayMode)
+func (i *IBFT) RelayMessage(message *proto.Message) (*proto.Message, bool) {
+	// A message that was already received is not relayed again, which breaks the relay loops
+	if message == nil || i.isReceivedMessage(message) {
+		return nil, false
+	}
+
+	if !i.isAcceptableMessage(message, untrustedSender) {
+		return nil, false
+	}
+
+	if i.handleUnstoredMessage(message) {
+		i.messages.AddMessage(message)
+	}
+
+	ttl := i.messageTTL
+	if ttl == 0 {
+		ttl = defaultRelayTTL
+	}
+
+	return messages.PrepareRelay(message, ttl)
+}
+
+// isReceivedMessage checks if the same message of the sender is already stored.
+// The messages of the unstored types are never reported as received
+func (i *IBFT) isReceivedMessage(message *proto.Message) bool {
+	if message.View == nil || isUnstoredType(message.Type) {
+		return false
+	}
+
+	stored, _ := i.messages.GetMessages(message.View, message.Type, 0)
+
+	for _, storedMessage := range stored {
+		if bytes.Equal(storedMessage.From, message.From) && bytes.Equal(storedMessage.Signature, message.Signature) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,118 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+func TestIBFT_RelayMessage(t *testing.T) {
+	t.Parallel()
+
+	var (
+		outsider = []byte("outsider")
+		view     = &proto.View{Height: 1, Round: 0}
+
+		backend = mockBackend{
+			IsValidValidatorFn: func(message *proto.Message) bool {
+				return !bytes.Equal(message.From, outsider)
+			},
+		}
+	)
+
+	prepare := func(from []byte, ttl, hops uint32) *proto.Message {
+		message := buildBasicPrepareMessage(correctRoundMessage.hash, from, view)
+		message.Signature = append([]byte("signature of "), from...)
+		message.Ttl = ttl
+		message.Hops = hops
+
+		return message
+	}
+
+	i := NewIBFT(mockLogger{}, backend, mockTransport{}, WithRelayMode())
+	i.state.setView(view)
+
+	// Make sure the valid messages are stored and relayed
+	relayed, ok := i.RelayMessage(prepare([]byte("node 0"), 0, 0))
+	require.True(t, ok)
+	assert.Equal(t, defaultRelayTTL, relayed.Ttl)
+	assert.Equal(t, uint32(1), relayed.Hops)
+
+	stored, _ := i.messages.GetMessages(view, proto.MessageType_PREPARE, 0)
+	assert.Len(t, stored, 1)
+
+	testTable := []struct {
+		name    string
+		message *proto.Message
+	}{
+		{"already received", prepare([]byte("node 0"), 0, 1)},
+		{"not signed by a validator", prepare(outsider, 0, 0)},
+		{"stale height", buildBasicPrepareMessage(correctRoundMessage.hash, []byte("node 1"), &proto.View{Height: 0})},
+		{"TTL reached", prepare([]byte("node 2"), 3, 3)},
+		{"nil message", nil},
+	}
+
+	for _, testCase := range testTable {
+		relayed, ok := i.RelayMessage(testCase.message)
+
+		assert.False(t, ok, testCase.name)
+		assert.Nil(t, relayed, testCase.name)
+	}
+
+	// Make sure the message that reached its TTL is still received
+	stored, _ = i.messages.GetMessages(view, proto.MessageType_PREPARE, 0)
+	assert.Len(t, stored, 2)
+}
+
+// TestIBFT_RelayMode makes sure a node that is not a validator
+// only observes the consensus
+func TestIBFT_RelayMode(t *testing.T) {
+	t.Parallel()
+
+	i := NewIBFT(mockLogger{}, mockBackend{}, mockTransport{}, WithRelayMode())
+
+	i.updateParticipation(1)
+
+	assert.True(t, i.IsObserving())
+	assert.True(t, i.Config().RelayMode)
+}
+
+// TestIBFT_RelayUnstoredMessage makes sure the messages
+// of the types that are never stored can be relayed
+func TestIBFT_RelayUnstoredMessage(t *testing.T) {
+	t.Parallel()
+
+	view := &proto.View{Height: 1, Round: 0}
+
+	messageTypes := []proto.MessageType{
+		proto.MessageType_CURRENT_VIEW,
+		proto.MessageType_PROPOSER_UNAVAILABLE,
+		proto.MessageType_FINALITY,
+	}
+
+	for _, messageType := range messageTypes {
+		messageType := messageType
+
+		t.Run(messageType.String(), func(t *testing.T) {
+			t.Parallel()
+
+			i := NewIBFT(mockLogger{}, mockBackend{}, mockTransport{}, WithRelayMode())
+			i.state.setView(view)
+
+			message := &proto.Message{
+				View:      view,
+				From:      []byte("node 0"),
+				Signature: []byte("signature"),
+				Type:      messageType,
+			}
+
+			assert.NotPanics(t, func() {
+				i.RelayMessage(message)
+			})
+		})
+	}
+}
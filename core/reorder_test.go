@@ -0,0 +1,226 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"pgregory.net/rapid"
+
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// reorderSetup is a single height of a validator set, whose messages
+// are delivered to the node under test in an adversarial order
+type reorderSetup struct {
+	// nodes are the validator IDs
+	nodes [][]byte
+
+	// height is the height of the sequence
+	height uint64
+
+	// self is the index of the node under test. It is never
+	// the proposer of round 0, so it waits for the PREPREPARE
+	self int
+}
+
+// drawReorderSetup draws a validator set, a height and the node under test
+func drawReorderSetup(t *rapid.T) reorderSetup {
+	var (
+		numNodes = rapid.Uint64Range(4, 10).Draw(t, "number of nodes")
+		height   = rapid.Uint64Range(1, 100).Draw(t, "height")
+		proposer = int(getProposer(height, 0, numNodes))
+	)
+
+	self := rapid.IntRange(0, int(numNodes)-2).Draw(t, "node under test")
+	if self >= proposer {
+		// Skip over the proposer
+		self++
+	}
+
+	return reorderSetup{
+		nodes:  generateNodeAddresses(numNodes),
+		height: height,
+		self:   self,
+	}
+}
+
+// proposer returns the ID of the proposer for the round
+func (s reorderSetup) proposer(round uint64) []byte {
+	return s.nodes[getProposer(s.height, round, uint64(len(s.nodes)))]
+}
+
+// others returns the IDs of the validators other than the node under test
+func (s reorderSetup) others() [][]byte {
+	others := make([][]byte, 0, len(s.nodes)-1)
+
+	for index, node := range s.nodes {
+		if index != s.self {
+			others = append(others, node)
+		}
+	}
+
+	return others
+}
+
+// messages returns the messages the other validators send for the height.
+// The round 0 messages finalize the proposal, accompanied by noise that
+// must not affect the outcome: the round changes of the faulty validators,
+// and the messages of the previous height
+func (s reorderSetup) messages(t *rapid.T) []*proto.Message {
+	var (
+		proposer = s.proposer(0)
+		others   = s.others()
+
+		view         = &proto.View{Height: s.height, Round: 0}
+		nextView     = &proto.View{Height: s.height, Round: 1}
+		previousView = &proto.View{Height: s.height - 1, Round: 0}
+
+		msgs = []*proto.Message{
+			buildBasicPreprepareMessage(
+				correctRoundMessage.proposal.GetRawProposal(),
+				correctRoundMessage.hash,
+				nil,
+				proposer,
+				view,
+			),
+		}
+	)
+
+	for _, node := range others {
+		if !bytes.Equal(node, proposer) {
+			msgs = append(msgs, buildBasicPrepareMessage(correctRoundMessage.hash, node, view))
+		}
+
+		msgs = append(msgs, buildBasicCommitMessage(correctRoundMessage.hash, correctRoundMessage.seal, node, view))
+	}
+
+	// Round changes of the faulty validators, too few to change the round
+	faulty := rapid.Permutation(others).Draw(t, "round change senders")[:MaxFaulty(uint64(len(s.nodes)))]
+	for _, node := range faulty {
+		msgs = append(msgs, buildBasicRoundChangeMessage(nil, nil, nextView, node))
+	}
+
+	// Late messages of the previous height
+	for _, node := range others {
+		msgs = append(
+			msgs,
+			buildBasicPrepareMessage(badRoundMessage.hash, node, previousView),
+			buildBasicCommitMessage(badRoundMessage.hash, badRoundMessage.seal, node, previousView),
+		)
+	}
+
+	// Redelivered messages
+	duplicates := rapid.SliceOfN(rapid.SampledFrom(msgs), 0, len(msgs)).Draw(t, "duplicates")
+
+	return append(msgs, duplicates...)
+}
+
+// checkReorderedDelivery runs the sequence of the node under test, while the
+// messages of the height are delivered in a random order, partly before
+// the sequence is started. The node must finalize the proposal exactly once
+func checkReorderedDelivery(t *rapid.T) {
+	var (
+		setup = drawReorderSetup(t)
+		msgs  = rapid.Permutation(setup.messages(t)).Draw(t, "delivery order")
+		early = rapid.IntRange(0, len(msgs)).Draw(t, "delivered before the sequence")
+
+		insertedLock sync.Mutex
+		inserted     []*proto.Proposal
+		seals        []*messages.CommittedSeal
+
+		backend = mockBackend{
+			idFn: func() []byte {
+				return setup.nodes[setup.self]
+			},
+			isProposerFn: func(id []byte, _ uint64, round uint64) bool {
+				return bytes.Equal(id, setup.proposer(round))
+			},
+			isValidProposalHashFn: func(_ *proto.Proposal, hash []byte) bool {
+				return bytes.Equal(hash, correctRoundMessage.hash)
+			},
+			isValidCommittedSealFn: func(_ []byte, seal *messages.CommittedSeal) bool {
+				return bytes.Equal(seal.Signature, correctRoundMessage.seal)
+			},
+			buildPrepareMessageFn: func(hash []byte, view *proto.View) *proto.Message {
+				return buildBasicPrepareMessage(hash, setup.nodes[setup.self], view)
+			},
+			buildCommitMessageFn: func(hash []byte, view *proto.View) *proto.Message {
+				return buildBasicCommitMessage(hash, correctRoundMessage.seal, setup.nodes[setup.self], view)
+			},
+			insertProposalFn: func(proposal *proto.Proposal, committedSeals []*messages.CommittedSeal) {
+				insertedLock.Lock()
+				defer insertedLock.Unlock()
+
+				inserted = append(inserted, proposal)
+				seals = committedSeals
+			},
+		}
+
+		verifier = CountQuorum{
+			ValidatorCount: func(uint64) uint64 {
+				return uint64(len(setup.nodes))
+			},
+			Proposer: func(_, round uint64) []byte {
+				return setup.proposer(round)
+			},
+		}
+	)
+
+	i := NewIBFT(mockLogger{}, backend, mockTransport{}, WithQuorumVerifier(verifier))
+
+	for _, message := range msgs[:early] {
+		i.AddMessage(message)
+	}
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFn()
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for _, message := range msgs[early:] {
+			i.AddMessage(message)
+		}
+	}()
+
+	i.RunSequence(ctx, setup.height)
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		t.Fatalf("sequence not finalized")
+	}
+
+	insertedLock.Lock()
+	defer insertedLock.Unlock()
+
+	// Make sure the correct proposal is finalized exactly once
+	if assert.Len(t, inserted, 1) {
+		assert.Equal(t, correctRoundMessage.proposal.GetRawProposal(), inserted[0].GetRawProposal())
+		assert.Equal(t, uint64(0), inserted[0].GetRound())
+	}
+
+	assert.GreaterOrEqual(t, uint64(len(seals)), QuorumSize(uint64(len(setup.nodes))))
+	assert.Equal(t, uint64(0), i.state.getRound())
+}
+
+// TestIBFT_ReorderedDelivery makes sure the node finalizes the proposal
+// of the height exactly once, whatever the order the messages are delivered in
+// (ex. the COMMIT messages before the PREPREPARE, interleaved with round changes)
+func TestIBFT_ReorderedDelivery(t *testing.T) {
+	t.Parallel()
+
+	rapid.Check(t, checkReorderedDelivery)
+}
+
+func FuzzIBFT_ReorderedDelivery(f *testing.F) {
+	f.Fuzz(rapid.MakeFuzz(checkReorderedDelivery))
+}
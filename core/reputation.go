@@ -0,0 +1,89 @@
+package core
+
+// Offense is the misbehavior of a message sender
+type Offense string
+
+const (
+	// OffenseInvalidSignature is the offense of a message
+	// that is not signed by a validator
+	OffenseInvalidSignature Offense = "invalid_signature"
+
+	// OffenseInvalidEnvelope is the offense of a raw message
+	// whose envelope is not signed by the message sender
+	OffenseInvalidEnvelope Offense = "invalid_envelope"
+
+	// OffenseMalformedMessage is the offense of a message
+	// that cannot be processed (ex. a message without a view)
+	OffenseMalformedMessage Offense = "malformed_message"
+
+	// OffenseFutureTimestamp is the offense of a message with a timestamp
+	// further in the future than the allowed clock skew
+	OffenseFutureTimestamp Offense = "future_timestamp"
+
+	// OffenseInvalidProposal is the offense of a proposer
+	// whose proposal fails the validation
+	OffenseInvalidProposal Offense = "invalid_proposal"
+
+	// OffenseMalformedCommit is the offense of a COMMIT message
+	// with a malformed committed seal
+	OffenseMalformedCommit Offense = "malformed_commit"
+
+	// OffenseEquivocation is the offense of a validator
+	// that signed conflicting messages
+	OffenseEquivocation Offense = "equivocation"
+)
+
+// rejectionOffenses are the offenses of the message rejections.
+// Rejections of messages that are only late (ex. for a past round) are not offenses
+var rejectionOffenses = map[rejectionReason]Offense{
+	rejectInvalidValidator: OffenseInvalidSignature,
+	rejectInvalidEnvelope:  OffenseInvalidEnvelope,
+	rejectNilView:          OffenseMalformedMessage,
+	rejectFutureTimestamp:  OffenseFutureTimestamp,
+}
+
+// PeerReporter reports the offenses of the message senders to the embedding
+// P2P layer, so it can score and eventually disconnect abusive peers.
+// The reports are made while handling the messages, so they must not block.
+//
+// The sender of a message with an invalid signature or envelope is not
+// authenticated, and may be forged to frame another validator. The P2P layer
+// should attribute such offenses to the peer that delivered the message
+type PeerReporter interface {
+	// ReportPeer reports the offense of the sender
+	ReportPeer(sender []byte, offense Offense)
+}
+
+// DeliveryReporter is an optional PeerReporter extension for the deployments
+// where the messages are forwarded (ex. a validator behind sentry nodes, see WithSentries),
+// so the transport peer that delivered a message is not its sender. If the reporter
+// implements it, the offenses of the messages delivered by a known peer (see AddPeerMessage)
+// are reported with the peer. The offenses of the validators proven by their own
+// signatures (ex. equivocation) are still reported with ReportPeer
+type DeliveryReporter interface {
+	// ReportDelivery reports the offense of the message sender,
+	// delivered by the transport peer
+	ReportDelivery(peer, sender []byte, offense Offense)
+}
+
+// nopPeerReporter is the default peer reporter, which discards all reports
+type nopPeerReporter struct{}
+
+func (nopPeerReporter) ReportPeer(_ []byte, _ Offense) {}
+
+// reportRejection reports the offense of the rejected message sender, if any,
+// attributed to the transport peer that delivered the message, if known
+func (i *IBFT) reportRejection(sender, peer []byte, reason rejectionReason) {
+	offense, ok := rejectionOffenses[reason]
+	if !ok {
+		return
+	}
+
+	if reporter, ok := i.peerReporter.(DeliveryReporter); ok && peer != nil {
+		reporter.ReportDelivery(peer, sender, offense)
+
+		return
+	}
+
+	i.peerReporter.ReportPeer(sender, offense)
+}
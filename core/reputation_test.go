@@ -0,0 +1,216 @@
+package core
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// offenseReport is a reported offense of a sender
+type offenseReport struct {
+	sender  string
+	offense Offense
+}
+
+// offenseRecorder is a peer reporter that records the reported offenses
+type offenseRecorder struct {
+	sync.Mutex
+
+	reports []offenseReport
+}
+
+func (r *offenseRecorder) ReportPeer(sender []byte, offense Offense) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.reports = append(r.reports, offenseReport{
+		sender:  string(sender),
+		offense: offense,
+	})
+}
+
+func (r *offenseRecorder) reported() []offenseReport {
+	r.Lock()
+	defer r.Unlock()
+
+	return append([]offenseReport(nil), r.reports...)
+}
+
+func TestIBFT_ReportPeer_Rejection(t *testing.T) {
+	t.Parallel()
+
+	var (
+		now  = time.Unix(1700000000, 0)
+		view = &proto.View{Height: 1, Round: 1}
+	)
+
+	testTable := []struct {
+		name     string
+		message  *proto.Message
+		invalid  bool
+		reported []offenseReport
+	}{
+		{
+			name:     "invalid signature",
+			message:  buildBasicPrepareMessage(nil, []byte("node 1"), view),
+			invalid:  true,
+			reported: []offenseReport{{"node 1", OffenseInvalidSignature}},
+		},
+		{
+			name:     "missing view",
+			message:  &proto.Message{From: []byte("node 1"), Type: proto.MessageType_PREPARE},
+			reported: []offenseReport{{"node 1", OffenseMalformedMessage}},
+		},
+		{
+			name: "future timestamp",
+			message: &proto.Message{
+				View: view,
+				From: []byte("node 1"),
+				Type: proto.MessageType_PREPREPARE,
+				Payload: &proto.Message_PreprepareData{
+					PreprepareData: &proto.PrePrepareMessage{
+						Timestamp: uint64(now.Add(time.Minute).UnixNano()),
+					},
+				},
+			},
+			reported: []offenseReport{{"node 1", OffenseFutureTimestamp}},
+		},
+		{
+			name:    "stale round",
+			message: buildBasicPrepareMessage(nil, []byte("node 1"), &proto.View{Height: 1, Round: 0}),
+		},
+		{
+			name:    "stale height",
+			message: buildBasicPrepareMessage(nil, []byte("node 1"), &proto.View{Height: 0, Round: 1}),
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				reporter = &offenseRecorder{}
+				backend  = mockBackend{
+					IsValidValidatorFn: func(_ *proto.Message) bool {
+						return !testCase.invalid
+					},
+				}
+			)
+
+			i := NewIBFT(
+				mockLogger{},
+				backend,
+				mockTransport{},
+				WithPeerReporter(reporter),
+				WithClockSkewTolerance(time.Second),
+				WithClock(func() time.Time {
+					return now
+				}),
+			)
+			i.state.setView(view)
+
+			assert.False(t, i.isAcceptableMessage(testCase.message, untrustedSender))
+
+			// Make sure only the offenses are reported, not the late messages
+			assert.Equal(t, testCase.reported, reporter.reported())
+		})
+	}
+}
+
+func TestIBFT_ReportPeer_InvalidProposal(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view     = &proto.View{Height: 1, Round: 0}
+		reporter = &offenseRecorder{}
+	)
+
+	i := NewIBFT(mockLogger{}, mockBackend{
+		isProposerFn: func(_ []byte, _, _ uint64) bool {
+			return false
+		},
+	}, mockTransport{}, WithPeerReporter(reporter))
+	i.state.setView(view)
+
+	i.messages.AddMessage(buildBasicPreprepareMessage(
+		correctRoundMessage.proposal.GetRawProposal(),
+		correctRoundMessage.hash,
+		nil,
+		[]byte("node 1"),
+		view,
+	))
+
+	assert.Nil(t, i.handlePrePrepare(view))
+
+	// Make sure the proposal of a validator that is not the proposer is reported
+	assert.Equal(t, []offenseReport{{"node 1", OffenseInvalidProposal}}, reporter.reported())
+}
+
+func TestIBFT_ReportPeer_MalformedCommit(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view      = &proto.View{Height: 1, Round: 0}
+		reporter  = &offenseRecorder{}
+		malformed = &proto.Message{
+			View: view,
+			From: []byte("node 2"),
+			Type: proto.MessageType_COMMIT,
+		}
+	)
+
+	i := NewIBFT(mockLogger{}, mockBackend{
+		hasQuorumFn: func(_ uint64, messages []*proto.Message, _ proto.MessageType) bool {
+			return len(messages) >= 1
+		},
+	}, mockTransport{}, WithPeerReporter(reporter))
+	i.state.setView(view)
+	i.state.setProposalMessage(
+		buildBasicPreprepareMessage(
+			correctRoundMessage.proposal.GetRawProposal(),
+			correctRoundMessage.hash,
+			nil,
+			[]byte("proposer"),
+			view,
+		),
+	)
+
+	i.messages.AddMessage(malformed)
+
+	validated := make(validatedMessages)
+	validated[malformed] = struct{}{}
+
+	i.handleCommit(view, validated)
+
+	assert.Equal(t, []offenseReport{{"node 2", OffenseMalformedCommit}}, reporter.reported())
+}
+
+func TestIBFT_ReportPeer_Equivocation(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view     = &proto.View{Height: 1, Round: 0}
+		reporter = &offenseRecorder{}
+	)
+
+	i := NewIBFT(
+		mockLogger{},
+		mockBackend{},
+		mockTransport{},
+		WithPeerReporter(reporter),
+		WithEquivocationPolicy(messages.EquivocationReject),
+	)
+
+	i.messages.AddMessage(buildBasicPrepareMessage([]byte("first hash"), []byte("node 1"), view))
+	i.messages.AddMessage(buildBasicPrepareMessage([]byte("second hash"), []byte("node 1"), view))
+
+	assert.Equal(t, []offenseReport{{"node 1", OffenseEquivocation}}, reporter.reported())
+}
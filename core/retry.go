@@ -0,0 +1,93 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	protoBuf "google.golang.org/protobuf/proto"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// RetryPolicy configures the retries of failed multicasts.
+// Retries block the sending routine until the round is over,
+// so the total backoff should be kept well below the round timeout
+type RetryPolicy struct {
+	// MaxRetries is the maximum number of retries
+	// after the first failed attempt
+	MaxRetries uint
+
+	// InitialBackoff is the wait before the first retry.
+	// The backoff doubles with each subsequent retry
+	InitialBackoff time.Duration
+
+	// MaxBackoff is the ceiling for the backoff, if set
+	MaxBackoff time.Duration
+
+	// IsTransient reports if the multicast error is worth retrying.
+	// If not set, all errors are retried
+	IsTransient func(err error) bool
+}
+
+// backoff returns the wait before the specified retry (starting from 0)
+func (p RetryPolicy) backoff(retry uint) time.Duration {
+	backoff := ExponentialTimeout{}.RoundTimeout(p.InitialBackoff, uint64(retry))
+
+	if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+
+	return backoff
+}
+
+// shouldRetry checks if the specified retry (starting from 0) should be attempted
+func (p RetryPolicy) shouldRetry(retry uint, err error) bool {
+	if retry >= p.MaxRetries {
+		return false
+	}
+
+	return p.IsTransient == nil || p.IsTransient(err)
+}
+
+// multicast multicasts the message using the transport (or sends it to the sentries
+// of the node, see WithSentries), retrying failures according to the retry policy until the context is cancelled
+func (i *IBFT) multicast(ctx context.Context, message *proto.Message) {
+	// Limit the relay hops of the message, if configured.
+	// The TTL is not covered by the signature, so it can be set after signing.
+	// It is set on a copy, as the message may be shared (ex. recorded as the last sent message)
+	if i.messageTTL > 0 && message != nil && message.Ttl == 0 {
+		message, _ = protoBuf.Clone(message).(*proto.Message)
+		message.Ttl = i.messageTTL
+	}
+
+	for retry := uint(0); ; retry++ {
+		err := i.send(message)
+		if err == nil {
+			return
+		}
+
+		i.metrics.IncrCounter(multicastFailedKey, 1)
+
+		if !i.retryPolicy.shouldRetry(retry, err) {
+			i.log.Error("unable to multicast message", "type", message.GetType(), "err", err)
+			i.metrics.IncrCounter(multicastDroppedKey, 1)
+
+			return
+		}
+
+		i.log.Debug("retrying multicast", "type", message.GetType(), "retry", retry+1, "err", err)
+		i.metrics.IncrCounter(multicastRetriedKey, 1)
+
+		backoff := time.NewTimer(i.retryPolicy.backoff(retry))
+
+		select {
+		case <-ctx.Done():
+			backoff.Stop()
+			i.log.Debug("multicast retries aborted", "type", message.GetType())
+			i.metrics.IncrCounter(multicastDroppedKey, 1)
+
+			return
+		case <-backoff.C:
+		}
+	}
+}
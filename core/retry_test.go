@@ -0,0 +1,256 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+var (
+	errTransient = errors.New("transient error")
+	errPermanent = errors.New("permanent error")
+)
+
+// failingTransport is a mock transport that fails
+// the specified number of multicast attempts
+type failingTransport struct {
+	sync.Mutex
+
+	failures int
+	err      error
+	attempts int
+}
+
+func (f *failingTransport) Multicast(_ *proto.Message) error {
+	f.Lock()
+	defer f.Unlock()
+
+	f.attempts++
+
+	if f.attempts <= f.failures {
+		return f.err
+	}
+
+	return nil
+}
+
+// counterMetrics is a metrics sink that tracks counters
+type counterMetrics struct {
+	nopMetrics
+	sync.Mutex
+
+	counters map[string]float32
+}
+
+func (c *counterMetrics) IncrCounter(key []string, val float32) {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.counters == nil {
+		c.counters = make(map[string]float32)
+	}
+
+	c.counters[strings.Join(key, ".")] += val
+}
+
+func (c *counterMetrics) counter(key []string) float32 {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.counters[strings.Join(key, ".")]
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	t.Parallel()
+
+	policy := RetryPolicy{
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     50 * time.Millisecond,
+	}
+
+	assert.Equal(t, 10*time.Millisecond, policy.backoff(0))
+	assert.Equal(t, 20*time.Millisecond, policy.backoff(1))
+	assert.Equal(t, 40*time.Millisecond, policy.backoff(2))
+	assert.Equal(t, 50*time.Millisecond, policy.backoff(3))
+	assert.Equal(t, 50*time.Millisecond, policy.backoff(100))
+}
+
+func TestIBFT_MulticastRetry(t *testing.T) {
+	t.Parallel()
+
+	isTransient := func(err error) bool {
+		return errors.Is(err, errTransient)
+	}
+
+	testTable := []struct {
+		name             string
+		policy           RetryPolicy
+		failures         int
+		err              error
+		expectedAttempts int
+		expectedRetries  float32
+		expectedDropped  float32
+	}{
+		{
+			"retries disabled",
+			RetryPolicy{},
+			1,
+			errTransient,
+			1,
+			0,
+			1,
+		},
+		{
+			"transient failure recovered",
+			RetryPolicy{
+				MaxRetries:     3,
+				InitialBackoff: time.Millisecond,
+				IsTransient:    isTransient,
+			},
+			2,
+			errTransient,
+			3,
+			2,
+			0,
+		},
+		{
+			"retries exhausted",
+			RetryPolicy{
+				MaxRetries:     2,
+				InitialBackoff: time.Millisecond,
+			},
+			5,
+			errTransient,
+			3,
+			2,
+			1,
+		},
+		{
+			"permanent failure not retried",
+			RetryPolicy{
+				MaxRetries:     3,
+				InitialBackoff: time.Millisecond,
+				IsTransient:    isTransient,
+			},
+			1,
+			errPermanent,
+			1,
+			0,
+			1,
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				transport = &failingTransport{
+					failures: testCase.failures,
+					err:      testCase.err,
+				}
+				metrics = &counterMetrics{}
+			)
+
+			i := NewIBFT(
+				mockLogger{},
+				mockBackend{},
+				transport,
+				WithRetryPolicy(testCase.policy),
+				WithMetrics(metrics),
+			)
+
+			i.multicast(context.Background(), &proto.Message{Type: proto.MessageType_PREPARE})
+
+			assert.Equal(t, testCase.expectedAttempts, transport.attempts)
+			assert.Equal(t, testCase.expectedRetries, metrics.counter(multicastRetriedKey))
+			assert.Equal(t, testCase.expectedDropped, metrics.counter(multicastDroppedKey))
+			assert.Equal(
+				t,
+				testCase.expectedRetries+testCase.expectedDropped,
+				metrics.counter(multicastFailedKey),
+			)
+		})
+	}
+}
+
+func TestIBFT_WithMessageTTL(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ttl       = uint32(4)
+		transport = &multicastRecorder{}
+	)
+
+	i := NewIBFT(mockLogger{}, mockBackend{}, transport, WithMessageTTL(ttl))
+
+	prepare := &proto.Message{Type: proto.MessageType_PREPARE}
+
+	i.multicast(context.Background(), prepare)
+	i.multicast(context.Background(), &proto.Message{Type: proto.MessageType_COMMIT, Ttl: 1})
+
+	multicasted := transport.messages()
+	if !assert.Len(t, multicasted, 2) {
+		return
+	}
+
+	// Make sure the TTL is set, unless already present
+	assert.Equal(t, ttl, multicasted[0].Ttl)
+	assert.Equal(t, uint32(1), multicasted[1].Ttl)
+
+	// Make sure the multicasted message is not modified
+	assert.Zero(t, prepare.Ttl)
+}
+
+func TestIBFT_MulticastRetry_Cancelled(t *testing.T) {
+	t.Parallel()
+
+	var (
+		transport = &failingTransport{
+			failures: 10,
+			err:      errors.New("transient"),
+		}
+		metrics = &counterMetrics{}
+	)
+
+	i := NewIBFT(
+		mockLogger{},
+		mockBackend{},
+		transport,
+		WithRetryPolicy(RetryPolicy{
+			MaxRetries:     10,
+			InitialBackoff: time.Hour,
+		}),
+		WithMetrics(metrics),
+	)
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	cancelFn()
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		i.multicast(ctx, &proto.Message{Type: proto.MessageType_PREPARE})
+	}()
+
+	// Make sure the backoff does not outlive the context
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("multicast retries not aborted")
+	}
+
+	assert.Equal(t, 1, transport.attempts)
+	assert.Equal(t, float32(1), metrics.counter(multicastDroppedKey))
+}
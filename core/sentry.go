@@ -0,0 +1,73 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+var (
+	// ErrNoSentryReached is an error indicating the message
+	// could not be sent to any of the sentries of the node
+	ErrNoSentryReached = errors.New("message not sent to any sentry")
+)
+
+// AddPeerMessage adds a new message to the IBFT message system, as AddMessage does,
+// for a message delivered by the transport peer, which is not necessarily its sender
+// (ex. a sentry forwarding the messages of the validators). The consensus attribution
+// still uses the message sender, while the offenses of the unauthenticated messages
+// are reported with the peer (see DeliveryReporter). If the node is behind sentries
+// (see WithSentries), the messages delivered by any other peer are rejected
+func (i *IBFT) AddPeerMessage(peer []byte, message *proto.Message) {
+	i.addMessage(message, untrustedSender, peer)
+}
+
+// isSentry checks if the transport peer is a sentry of the node.
+// Any peer is accepted if the node is not behind sentries
+func (i *IBFT) isSentry(peer []byte) bool {
+	if len(i.sentries) == 0 {
+		return true
+	}
+
+	for _, sentry := range i.sentries {
+		if bytes.Equal(sentry, peer) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// send sends the message of the node to the network. The node behind sentries
+// sends it only to each of its sentries, which forward it unchanged, so the other
+// validators attribute it to the node by its signature. It fails only if the message
+// could not be sent to any of the sentries
+func (i *IBFT) send(message *proto.Message) error {
+	unicaster, ok := i.transport.(Unicaster)
+	if len(i.sentries) == 0 || !ok {
+		return i.transport.Multicast(message)
+	}
+
+	var lastErr error
+
+	sent := false
+
+	for _, sentry := range i.sentries {
+		if err := unicaster.Unicast(sentry, message); err != nil {
+			i.log.Debug("unable to send message to sentry", "type", message.GetType(), "sentry", sentry, "err", err)
+			lastErr = err
+
+			continue
+		}
+
+		sent = true
+	}
+
+	if !sent {
+		return fmt.Errorf("%w: %v", ErrNoSentryReached, lastErr)
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// deliveryRecorder is a peer reporter that records the offenses
+// attributed to the delivering peers
+type deliveryRecorder struct {
+	offenseRecorder
+
+	deliveries []offenseReport
+}
+
+func (r *deliveryRecorder) ReportDelivery(peer, _ []byte, offense Offense) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.deliveries = append(r.deliveries, offenseReport{
+		sender:  string(peer),
+		offense: offense,
+	})
+}
+
+func (r *deliveryRecorder) delivered() []offenseReport {
+	r.Lock()
+	defer r.Unlock()
+
+	return append([]offenseReport(nil), r.deliveries...)
+}
+
+// failingUnicaster is a mock transport failing the unicasts to the specified peers
+type failingUnicaster struct {
+	unicastRecorder
+
+	failing map[string]bool
+}
+
+func (f *failingUnicaster) Unicast(to []byte, message *proto.Message) error {
+	if f.failing[string(to)] {
+		return errors.New("peer unreachable")
+	}
+
+	return f.unicastRecorder.Unicast(to, message)
+}
+
+func TestIBFT_AddPeerMessage(t *testing.T) {
+	t.Parallel()
+
+	var (
+		sentry = []byte("sentry 0")
+		view   = &proto.View{Height: 1, Round: 0}
+	)
+
+	i := NewIBFT(mockLogger{}, mockBackend{}, &unicastRecorder{}, WithSentries(sentry))
+	i.state.setView(view)
+
+	// Make sure only the messages delivered by the sentries are accepted
+	i.AddPeerMessage([]byte("peer 0"), buildBasicPrepareMessage(nil, []byte("node 0"), view))
+	i.AddPeerMessage(sentry, buildBasicPrepareMessage(nil, []byte("node 1"), view))
+
+	stored, _ := i.messages.GetMessages(view, proto.MessageType_PREPARE, 0)
+	if assert.Len(t, stored, 1) {
+		assert.Equal(t, []byte("node 1"), stored[0].From)
+	}
+
+	// Make sure the nodes that are not behind sentries accept any peer
+	i = NewIBFT(mockLogger{}, mockBackend{}, mockTransport{})
+	i.state.setView(view)
+
+	i.AddPeerMessage([]byte("peer 0"), buildBasicPrepareMessage(nil, []byte("node 0"), view))
+
+	stored, _ = i.messages.GetMessages(view, proto.MessageType_PREPARE, 0)
+	assert.Len(t, stored, 1)
+}
+
+// TestIBFT_AddPeerMessage_Attribution makes sure the offenses of the unauthenticated
+// messages are attributed to the delivering peer, if the reporter supports it
+func TestIBFT_AddPeerMessage_Attribution(t *testing.T) {
+	t.Parallel()
+
+	var (
+		sentry = []byte("sentry 0")
+		view   = &proto.View{Height: 1, Round: 0}
+
+		backend = mockBackend{
+			IsValidValidatorFn: func(_ *proto.Message) bool {
+				return false
+			},
+		}
+	)
+
+	reporter := &deliveryRecorder{}
+
+	i := NewIBFT(mockLogger{}, backend, mockTransport{}, WithPeerReporter(reporter))
+	i.state.setView(view)
+
+	i.AddPeerMessage(sentry, buildBasicPrepareMessage(nil, []byte("node 0"), view))
+	i.AddMessage(buildBasicPrepareMessage(nil, []byte("node 1"), view))
+
+	assert.Equal(t, []offenseReport{{"sentry 0", OffenseInvalidSignature}}, reporter.delivered())
+	assert.Equal(t, []offenseReport{{"node 1", OffenseInvalidSignature}}, reporter.reported())
+
+	// Make sure the reporters without the extension are reported the sender
+	plain := &offenseRecorder{}
+
+	i = NewIBFT(mockLogger{}, backend, mockTransport{}, WithPeerReporter(plain))
+	i.state.setView(view)
+
+	i.AddPeerMessage(sentry, buildBasicPrepareMessage(nil, []byte("node 0"), view))
+
+	assert.Equal(t, []offenseReport{{"node 0", OffenseInvalidSignature}}, plain.reported())
+}
+
+// TestIBFT_Multicast_Sentries makes sure the node behind sentries
+// sends its messages only to each of its sentries
+func TestIBFT_Multicast_Sentries(t *testing.T) {
+	t.Parallel()
+
+	var (
+		sentries = [][]byte{[]byte("sentry 0"), []byte("sentry 1")}
+		message  = buildBasicPrepareMessage(nil, []byte("node 0"), &proto.View{Height: 1})
+	)
+
+	transport := &failingUnicaster{failing: map[string]bool{"sentry 1": true}}
+
+	i := NewIBFT(mockLogger{}, mockBackend{}, transport, WithSentries(sentries...))
+
+	i.multicast(context.Background(), message)
+
+	unicasted, receivers := transport.replies()
+	assert.Equal(t, []*proto.Message{message}, unicasted)
+	assert.Equal(t, [][]byte{[]byte("sentry 0")}, receivers)
+	assert.Empty(t, transport.messages())
+
+	// Make sure the send fails if no sentry is reached
+	transport.failing["sentry 0"] = true
+
+	assert.ErrorIs(t, i.send(message), ErrNoSentryReached)
+	assert.Equal(t, sentries, i.Config().Sentries)
+}
@@ -0,0 +1,124 @@
+package core
+
+import "errors"
+
+var (
+	// ErrSequenceRunning is returned when RunSequence is called
+	// while another sequence is running
+	ErrSequenceRunning = errors.New("sequence already running")
+
+	// ErrHeightFinalized is returned when RunSequence is called for a height
+	// at or below the highest height finalized by the node
+	ErrHeightFinalized = errors.New("height already finalized")
+)
+
+// SequenceEndReason is the reason the sequence for a height ended
+type SequenceEndReason string
+
+const (
+	// SequenceFinalized is the sequence ending with the proposal
+	// of the height inserted
+	SequenceFinalized SequenceEndReason = "finalized"
+
+	// SequenceCancelled is the sequence ending with the context cancellation,
+	// before the height was finalized
+	SequenceCancelled SequenceEndReason = "cancelled"
+
+	// SequenceDeadlineExceeded is the sequence ending with its deadline passing,
+	// before the height was finalized (see WithSequenceDeadline)
+	SequenceDeadlineExceeded SequenceEndReason = "deadline exceeded"
+)
+
+// SequencePhase is the phase of the round the sequence was in
+type SequencePhase string
+
+const (
+	// PhasePrePrepare is the phase before the proposal for the round is accepted
+	PhasePrePrepare SequencePhase = "preprepare"
+
+	// PhasePrepare is the phase after the proposal for the round is accepted,
+	// until a quorum of PREPARE messages is observed
+	PhasePrepare SequencePhase = "prepare"
+
+	// PhaseCommit is the phase after the COMMIT message for the round is sent,
+	// until a quorum of COMMIT messages is observed
+	PhaseCommit SequencePhase = "commit"
+)
+
+// SequenceResult is the outcome of a sequence run, returned by RunSequence
+// and emitted as the payload of the EventSequenceDone event. A node orchestrator
+// can use it to decide whether the same height needs to be run again
+type SequenceResult struct {
+	// Height is the height of the sequence
+	Height uint64
+
+	// Round is the round the sequence was in when it ended
+	Round uint64
+
+	// Reason is the reason the sequence ended
+	Reason SequenceEndReason
+
+	// Phase is the phase of the round the sequence was in when it ended
+	Phase SequencePhase
+}
+
+// Finalized checks if the sequence ended with the height finalized
+func (r SequenceResult) Finalized() bool {
+	return r.Reason == SequenceFinalized
+}
+
+// currentPhase returns the phase of the current round
+func (i *IBFT) currentPhase() SequencePhase {
+	switch {
+	case i.state.getCommitSent():
+		return PhaseCommit
+	case i.state.hasProposalMessage():
+		return PhasePrepare
+	default:
+		return PhasePrePrepare
+	}
+}
+
+// endSequence builds the result of the sequence ending for the specified reason,
+// and emits it as the final event of the sequence
+func (i *IBFT) endSequence(reason SequenceEndReason) SequenceResult {
+	view := i.state.getView()
+
+	result := SequenceResult{
+		Height: view.Height,
+		Round:  view.Round,
+		Reason: reason,
+		Phase:  i.currentPhase(),
+	}
+
+	i.emitEvent(EventSequenceDone, view, result)
+
+	return result
+}
+
+// HighestFinalizedHeight returns the highest height finalized by the node,
+// either by a sequence or by a catch-up import (see ImportForHeight).
+// It returns false if the node did not finalize any height yet
+func (i *IBFT) HighestFinalizedHeight() (uint64, bool) {
+	stored := i.finalizedHeight.Load()
+	if stored == 0 {
+		return 0, false
+	}
+
+	return stored - 1, true
+}
+
+// recordFinalizedHeight records the finalized height,
+// if it is higher than the highest finalized height
+func (i *IBFT) recordFinalizedHeight(height uint64) {
+	for {
+		stored := i.finalizedHeight.Load()
+		if stored > height {
+			return
+		}
+
+		if i.finalizedHeight.CompareAndSwap(stored, height+1) {
+			return
+		}
+	}
+}
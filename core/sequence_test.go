@@ -0,0 +1,275 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// TestIBFT_RunSequence_Result makes sure the sequence reports
+// why it ended, and the phase the round was in
+func TestIBFT_RunSequence_Result(t *testing.T) {
+	t.Parallel()
+
+	var (
+		nodeID   = []byte("node ID")
+		proposal = correctRoundMessage
+	)
+
+	testTable := []struct {
+		name string
+
+		// isProposer is the flag indicating if the node proposes in round 0
+		isProposer bool
+
+		// hasPrepareQuorum is the flag indicating if the PREPARE messages reach quorum
+		hasPrepareQuorum bool
+
+		// cancelOn is the event after which the sequence is cancelled, if any
+		cancelOn *EventType
+
+		expected SequenceResult
+	}{
+		{
+			"finalized",
+			true,
+			true,
+			nil,
+			SequenceResult{Height: 1, Reason: SequenceFinalized, Phase: PhaseCommit},
+		},
+		{
+			"cancelled while waiting for the proposal",
+			false,
+			true,
+			eventTypePtr(EventRoundStarted),
+			SequenceResult{Height: 1, Reason: SequenceCancelled, Phase: PhasePrePrepare},
+		},
+		{
+			"cancelled while waiting for the PREPARE quorum",
+			true,
+			false,
+			eventTypePtr(EventProposalAccepted),
+			SequenceResult{Height: 1, Reason: SequenceCancelled, Phase: PhasePrepare},
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			var multicastFn func(message *proto.Message)
+
+			backend := mockBackend{
+				hasQuorumFn: func(_ uint64, messages []*proto.Message, msgType proto.MessageType) bool {
+					if msgType == proto.MessageType_PREPARE && !testCase.hasPrepareQuorum {
+						return false
+					}
+
+					return commonHasQuorumFn(1)(1, messages, msgType)
+				},
+				idFn: func() []byte {
+					return nodeID
+				},
+				isProposerFn: func(id []byte, _ uint64, _ uint64) bool {
+					return testCase.isProposer && bytes.Equal(id, nodeID)
+				},
+				buildProposalFn: func(_ uint64) []byte {
+					return proposal.proposal.GetRawProposal()
+				},
+				buildPrePrepareMessageFn: func(
+					rawProposal []byte,
+					certificate *proto.RoundChangeCertificate,
+					view *proto.View,
+				) *proto.Message {
+					return buildBasicPreprepareMessage(rawProposal, proposal.hash, certificate, nodeID, view)
+				},
+				buildPrepareMessageFn: func(_ []byte, view *proto.View) *proto.Message {
+					return buildBasicPrepareMessage(proposal.hash, nodeID, view)
+				},
+				buildCommitMessageFn: func(_ []byte, view *proto.View) *proto.Message {
+					return buildBasicCommitMessage(proposal.hash, proposal.seal, nodeID, view)
+				},
+			}
+			transport := mockTransport{
+				multicastFn: func(message *proto.Message) {
+					multicastFn(message)
+				},
+			}
+
+			i := NewIBFT(mockLogger{}, backend, transport)
+
+			// Relay the multicast messages back to the node
+			multicastFn = i.AddMessage
+
+			sub := i.SubscribeEvents()
+			defer i.UnsubscribeEvents(sub.ID)
+
+			ctx, cancelFn := context.WithCancel(context.Background())
+			defer cancelFn()
+
+			resultCh := make(chan SequenceResult, 1)
+
+			go func() {
+				result, _ := i.RunSequence(ctx, 1)
+				resultCh <- result
+			}()
+
+			if testCase.cancelOn != nil {
+				for event := range sub.EventCh {
+					if event.Type == *testCase.cancelOn {
+						break
+					}
+				}
+
+				cancelFn()
+			}
+
+			result := <-resultCh
+			assert.Equal(t, testCase.expected, result)
+
+			// Make sure the result is emitted as the final event
+			events := drainEvents(sub, EventSequenceDone)
+			if assert.Len(t, events, 1) {
+				assert.Equal(t, result, events[0].Data)
+			}
+		})
+	}
+}
+
+// TestIBFT_RunSequence_Deadline makes sure the sequence that cannot finalize
+// the height is aborted once its deadline passes
+func TestIBFT_RunSequence_Deadline(t *testing.T) {
+	t.Parallel()
+
+	backend := mockBackend{
+		idFn: func() []byte {
+			return []byte("node ID")
+		},
+		isProposerFn: func(_ []byte, _ uint64, _ uint64) bool {
+			return false
+		},
+	}
+
+	i := NewIBFT(mockLogger{}, backend, mockTransport{}, WithSequenceDeadline(100*time.Millisecond))
+
+	sub := i.SubscribeEvents()
+	defer i.UnsubscribeEvents(sub.ID)
+
+	result, err := i.RunSequence(context.Background(), 1)
+	require.NoError(t, err)
+
+	assert.Equal(t, SequenceResult{Height: 1, Reason: SequenceDeadlineExceeded, Phase: PhasePrePrepare}, result)
+	assert.False(t, result.Finalized())
+
+	// Make sure the result is emitted as the final event
+	events := drainEvents(sub, EventSequenceDone)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, result, events[0].Data)
+	}
+}
+
+// TestIBFT_RunSequence_Overlapping makes sure an overlapping sequence is rejected,
+// without affecting the running one
+func TestIBFT_RunSequence_Overlapping(t *testing.T) {
+	t.Parallel()
+
+	backend := mockBackend{
+		isProposerFn: func(_ []byte, _ uint64, _ uint64) bool {
+			return false
+		},
+	}
+
+	i := NewIBFT(mockLogger{}, backend, mockTransport{})
+
+	sub := i.SubscribeEvents()
+	defer i.UnsubscribeEvents(sub.ID)
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+
+	resultCh := make(chan SequenceResult, 1)
+
+	go func() {
+		result, _ := i.RunSequence(ctx, 1)
+		resultCh <- result
+	}()
+
+	// Wait for the node to start working on the height
+	for event := range sub.EventCh {
+		if event.Type == EventRoundStarted {
+			break
+		}
+	}
+
+	for _, height := range []uint64{1, 2} {
+		result, err := i.RunSequence(ctx, height)
+
+		assert.ErrorIs(t, err, ErrSequenceRunning)
+		assert.Equal(t, SequenceResult{}, result)
+	}
+
+	// Make sure the running sequence is not affected
+	assert.Equal(t, uint64(1), i.state.getHeight())
+
+	cancelFn()
+	assert.Equal(t, SequenceCancelled, (<-resultCh).Reason)
+
+	// Make sure a new sequence can run once the previous one ended
+	result, err := i.RunSequence(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), result.Height)
+}
+
+// TestIBFT_RunSequence_HeightRegression makes sure the sequences of the heights
+// at or below the highest finalized height are refused, unless the check is disabled
+func TestIBFT_RunSequence_HeightRegression(t *testing.T) {
+	t.Parallel()
+
+	cancelledCtx, cancelFn := context.WithCancel(context.Background())
+	cancelFn()
+
+	i := NewIBFT(mockLogger{}, mockBackend{}, mockTransport{})
+
+	_, hasFinalized := i.HighestFinalizedHeight()
+	assert.False(t, hasFinalized)
+
+	i.recordFinalizedHeight(5)
+	i.recordFinalizedHeight(3)
+
+	finalized, hasFinalized := i.HighestFinalizedHeight()
+	assert.True(t, hasFinalized)
+	assert.Equal(t, uint64(5), finalized)
+
+	for _, height := range []uint64{3, 5} {
+		result, err := i.RunSequence(cancelledCtx, height)
+
+		assert.ErrorIs(t, err, ErrHeightFinalized)
+		assert.Equal(t, SequenceResult{}, result)
+	}
+
+	// Make sure the next height can run
+	result, err := i.RunSequence(cancelledCtx, 6)
+	require.NoError(t, err)
+	assert.Equal(t, SequenceCancelled, result.Reason)
+
+	// Make sure the finalized heights can run with the check disabled
+	i = NewIBFT(mockLogger{}, mockBackend{}, mockTransport{}, WithoutHeightRegressionCheck())
+	i.recordFinalizedHeight(5)
+
+	result, err = i.RunSequence(cancelledCtx, 5)
+	require.NoError(t, err)
+	assert.Equal(t, SequenceCancelled, result.Reason)
+}
+
+// eventTypePtr returns a pointer to the event type
+func eventTypePtr(eventType EventType) *EventType {
+	return &eventType
+}
@@ -2,6 +2,8 @@ package core
 
 import (
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/renloi/ibft/messages"
 	"github.com/renloi/ibft/messages/proto"
@@ -9,9 +11,9 @@ import (
 	protoBuf "google.golang.org/protobuf/proto"
 )
 
-type state struct {
-	sync.RWMutex
-
+// stateSnapshot is an immutable snapshot of the consensus state.
+// A published snapshot is never modified, writers publish a modified copy instead
+type stateSnapshot struct {
 	//	current view (sequence, round)
 	view *proto.View
 
@@ -33,95 +35,157 @@ type state struct {
 
 	//  commitSent for current round
 	commitSent bool
+
+	//	phaseStarts are the local multicast times for the current round,
+	//	used for measuring the time it takes to reach quorum
+	phaseStarts map[proto.MessageType]time.Time
+
+	//	lastSent is the latest PREPARE, COMMIT or ROUND_CHANGE
+	//	message multicasted in the current height
+	lastSent *proto.Message
+}
+
+// state is the consensus state. Readers load the current snapshot
+// without locking, so they never contend with writers, and always
+// observe a consistent state
+type state struct {
+	// writeLock serializes the writers
+	writeLock sync.Mutex
+
+	// snapshot is the current state snapshot
+	snapshot atomic.Pointer[stateSnapshot]
+}
+
+// newState creates a new state for the specified height
+func newState(height uint64) *state {
+	s := &state{}
+	s.snapshot.Store(&stateSnapshot{
+		view: &proto.View{
+			Height: height,
+			Round:  0,
+		},
+		seals: make([]*messages.CommittedSeal, 0),
+	})
+
+	return s
+}
+
+// load returns the current state snapshot
+func (s *state) load() *stateSnapshot {
+	return s.snapshot.Load()
+}
+
+// update publishes a copy of the current snapshot, modified by the passed in function
+func (s *state) update(modify func(next *stateSnapshot)) {
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+
+	next := *s.load()
+	modify(&next)
+
+	s.snapshot.Store(&next)
 }
 
 func (s *state) getView() *proto.View {
-	s.RLock()
-	defer s.RUnlock()
+	view := s.load().view
 
 	return &proto.View{
-		Height: s.view.Height,
-		Round:  s.view.Round,
+		Height: view.Height,
+		Round:  view.Round,
 	}
 }
 
 func (s *state) clear(height uint64) {
-	s.Lock()
-	defer s.Unlock()
-
-	s.seals = nil
-	s.roundStarted = false
-	s.commitSent = false
-	s.proposalMessage = nil
-	s.latestPC = nil
-	s.latestPreparedProposal = nil
-
-	s.view = &proto.View{
-		Height: height,
-		Round:  0,
-	}
+	s.update(func(next *stateSnapshot) {
+		*next = stateSnapshot{
+			view: &proto.View{
+				Height: height,
+				Round:  0,
+			},
+		}
+	})
 }
 
+// The getters return copies of the state data, so the callers
+// can freely retain or modify them without racing with other readers
+
 func (s *state) getLatestPC() *proto.PreparedCertificate {
-	s.RLock()
-	defer s.RUnlock()
+	if latestPC := s.load().latestPC; latestPC != nil {
+		return latestPC.Copy()
+	}
 
-	return s.latestPC
+	return nil
 }
 
 func (s *state) getLatestPreparedProposal() *proto.Proposal {
-	s.RLock()
-	defer s.RUnlock()
+	if latestPreparedProposal := s.load().latestPreparedProposal; latestPreparedProposal != nil {
+		return latestPreparedProposal.Copy()
+	}
 
-	return s.latestPreparedProposal
+	return nil
 }
 
 func (s *state) getProposalMessage() *proto.Message {
-	s.RLock()
-	defer s.RUnlock()
+	proposalMessage, _ := protoBuf.Clone(s.load().proposalMessage).(*proto.Message)
 
-	return s.proposalMessage
+	return proposalMessage
 }
 
-func (s *state) getProposalHash() []byte {
-	s.RLock()
-	defer s.RUnlock()
+// hasProposalMessage checks if a proposal is accepted for the round,
+// without copying it
+func (s *state) hasProposalMessage() bool {
+	return s.load().proposalMessage != nil
+}
 
-	return messages.ExtractProposalHash(s.proposalMessage)
+func (s *state) getProposalHash() []byte {
+	return copyBytes(extractProposalHash(s.load().proposalMessage))
 }
 
 func (s *state) setProposalMessage(proposalMessage *proto.Message) {
-	s.Lock()
-	defer s.Unlock()
+	proposalMsg, _ := protoBuf.Clone(proposalMessage).(*proto.Message)
+
+	s.update(func(next *stateSnapshot) {
+		next.proposalMessage = proposalMsg
+	})
+}
 
+// claimProposalMessage sets the proposal message, unless one is already set.
+// It returns false if the proposal message was already set
+func (s *state) claimProposalMessage(proposalMessage *proto.Message) bool {
 	proposalMsg, _ := protoBuf.Clone(proposalMessage).(*proto.Message)
+	claimed := false
+
+	s.update(func(next *stateSnapshot) {
+		if next.proposalMessage == nil {
+			next.proposalMessage = proposalMsg
+			claimed = true
+		}
+	})
 
-	s.proposalMessage = proposalMsg
+	return claimed
 }
 
 func (s *state) getRound() uint64 {
-	s.RLock()
-	defer s.RUnlock()
-
-	return s.view.Round
+	return s.load().view.Round
 }
 
 func (s *state) getHeight() uint64 {
-	s.RLock()
-	defer s.RUnlock()
-
-	return s.view.Height
+	return s.load().view.Height
 }
 
 func (s *state) getProposal() *proto.Proposal {
-	s.RLock()
-	defer s.RUnlock()
-
-	if s.proposalMessage != nil {
-		return messages.ExtractProposal(s.proposalMessage)
+	preprepareData, err := messages.ExtractPayload[*proto.PrePrepareMessage](s.load().proposalMessage)
+	if err != nil || preprepareData.Proposal == nil {
+		return nil
 	}
 
-	return nil
+	return preprepareData.Proposal.Copy()
+}
+
+func (s *state) isNilProposal() bool {
+	preprepareData, err := messages.ExtractPayload[*proto.PrePrepareMessage](s.load().proposalMessage)
+
+	return err == nil && preprepareData.NilProposal
 }
 
 func (s *state) getRawDataFromProposal() []byte {
@@ -134,67 +198,136 @@ func (s *state) getRawDataFromProposal() []byte {
 }
 
 func (s *state) getCommittedSeals() []*messages.CommittedSeal {
-	s.RLock()
-	defer s.RUnlock()
+	seals := s.load().seals
+	copied := make([]*messages.CommittedSeal, 0, len(seals))
 
-	return s.seals
+	for _, seal := range seals {
+		copied = append(copied, seal.Copy())
+	}
+
+	return copied
 }
 
-func (s *state) setRoundStarted(started bool) {
-	s.Lock()
-	defer s.Unlock()
+func (s *state) getRoundStarted() bool {
+	return s.load().roundStarted
+}
 
-	s.roundStarted = started
+func (s *state) setRoundStarted(started bool) {
+	s.update(func(next *stateSnapshot) {
+		next.roundStarted = started
+	})
 }
 
 func (s *state) getCommitSent() bool {
-	s.RLock()
-	defer s.RUnlock()
-
-	return s.commitSent
+	return s.load().commitSent
 }
 
 func (s *state) setCommitSent(sent bool) {
-	s.Lock()
-	defer s.Unlock()
-
-	s.commitSent = sent
+	s.update(func(next *stateSnapshot) {
+		next.commitSent = sent
+	})
 }
 
 func (s *state) setView(view *proto.View) {
-	s.Lock()
-	defer s.Unlock()
-
-	s.view = view
+	s.update(func(next *stateSnapshot) {
+		next.view = &proto.View{
+			Height: view.Height,
+			Round:  view.Round,
+		}
+	})
 }
 
 func (s *state) setCommittedSeals(seals []*messages.CommittedSeal) {
-	s.Lock()
-	defer s.Unlock()
-
-	s.seals = s.seals[:0]
+	copied := make([]*messages.CommittedSeal, 0, len(seals))
 
 	for _, seal := range seals {
-		s.seals = append(s.seals, seal.Copy())
+		copied = append(copied, seal.Copy())
 	}
+
+	s.update(func(next *stateSnapshot) {
+		next.seals = copied
+	})
 }
 
 func (s *state) newRound() {
-	s.Lock()
-	defer s.Unlock()
-
-	if !s.roundStarted {
-		s.roundStarted = true
-	}
+	s.update(func(next *stateSnapshot) {
+		next.roundStarted = true
+	})
 }
 
 func (s *state) finalizePrepare(
 	certificate *proto.PreparedCertificate,
 	latestPPB *proto.Proposal,
 ) {
-	s.Lock()
-	defer s.Unlock()
+	var (
+		latestPC               = certificate.Copy()
+		latestPreparedProposal = latestPPB.Copy()
+	)
+
+	s.update(func(next *stateSnapshot) {
+		next.latestPC = latestPC
+		next.latestPreparedProposal = latestPreparedProposal
+	})
+}
+
+func (s *state) setPhaseStart(messageType proto.MessageType, start time.Time) {
+	s.update(func(next *stateSnapshot) {
+		phaseStarts := make(map[proto.MessageType]time.Time, len(next.phaseStarts)+1)
+
+		for phase, phaseStart := range next.phaseStarts {
+			phaseStarts[phase] = phaseStart
+		}
+
+		phaseStarts[messageType] = start
+		next.phaseStarts = phaseStarts
+	})
+}
+
+func (s *state) getPhaseStart(messageType proto.MessageType) (time.Time, bool) {
+	start, ok := s.load().phaseStarts[messageType]
+
+	return start, ok
+}
+
+func (s *state) resetPhaseStarts() {
+	s.update(func(next *stateSnapshot) {
+		next.phaseStarts = nil
+	})
+}
+
+func (s *state) setLastSent(message *proto.Message) {
+	lastSent, _ := protoBuf.Clone(message).(*proto.Message)
+
+	s.update(func(next *stateSnapshot) {
+		next.lastSent = lastSent
+	})
+}
+
+func (s *state) getLastSent() *proto.Message {
+	lastSent, _ := protoBuf.Clone(s.load().lastSent).(*proto.Message)
+
+	return lastSent
+}
+
+// copyBytes returns a copy of the byte slice
+func copyBytes(data []byte) []byte {
+	if data == nil {
+		return nil
+	}
+
+	copied := make([]byte, len(data))
+	copy(copied, data)
+
+	return copied
+}
+
+// extractProposalHash extracts the proposal hash from the PREPREPARE message.
+// Returns nil if the message is not a well-formed PREPREPARE message
+func extractProposalHash(proposalMessage *proto.Message) []byte {
+	preprepareData, err := messages.ExtractPayload[*proto.PrePrepareMessage](proposalMessage)
+	if err != nil {
+		return nil
+	}
 
-	s.latestPC = certificate.Copy()
-	s.latestPreparedProposal = latestPPB.Copy()
+	return preprepareData.ProposalHash
 }
@@ -0,0 +1,131 @@
+package core
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+func TestState_Snapshot(t *testing.T) {
+	t.Parallel()
+
+	t.Run("published snapshots are not modified", func(t *testing.T) {
+		t.Parallel()
+
+		s := newState(1)
+		snapshot := s.load()
+
+		s.setView(&proto.View{Height: 1, Round: 2})
+		s.setCommitSent(true)
+		s.setPhaseStart(proto.MessageType_PREPARE, time.Now())
+
+		assert.Equal(t, uint64(0), snapshot.view.Round)
+		assert.False(t, snapshot.commitSent)
+		assert.Nil(t, snapshot.phaseStarts)
+
+		assert.Equal(t, uint64(2), s.getRound())
+		assert.True(t, s.getCommitSent())
+	})
+
+	t.Run("the view passed to the state is not retained", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			s    = newState(1)
+			view = &proto.View{Height: 1, Round: 1}
+		)
+
+		s.setView(view)
+		view.Round = 5
+
+		assert.Equal(t, uint64(1), s.getRound())
+	})
+
+	t.Run("readers never observe torn views", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			s  = newState(0)
+			wg sync.WaitGroup
+		)
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			// The round always matches the height
+			for height := uint64(1); height <= 1000; height++ {
+				s.setView(&proto.View{Height: height, Round: height})
+			}
+		}()
+
+		for index := 0; index < 1000; index++ {
+			view := s.getView()
+
+			assert.Equal(t, view.Height, view.Round)
+		}
+
+		wg.Wait()
+	})
+}
+
+func TestState_GettersReturnCopies(t *testing.T) {
+	t.Parallel()
+
+	var (
+		s        = newState(1)
+		view     = &proto.View{Height: 1, Round: 0}
+		proposal = buildBasicPreprepareMessage(
+			correctRoundMessage.proposal.GetRawProposal(),
+			correctRoundMessage.hash,
+			nil,
+			[]byte("proposer"),
+			view,
+		)
+		prepare = buildBasicPrepareMessage(correctRoundMessage.hash, []byte("node"), view)
+	)
+
+	s.setProposalMessage(proposal)
+	s.setLastSent(prepare)
+	s.finalizePrepare(
+		&proto.PreparedCertificate{
+			ProposalMessage: proposal,
+			PrepareMessages: []*proto.Message{prepare},
+		},
+		correctRoundMessage.proposal,
+	)
+	s.setCommittedSeals([]*messages.CommittedSeal{
+		{Signer: []byte("node"), Signature: []byte("seal")},
+	})
+
+	// Modify everything returned by the getters
+	s.getView().Round = 10
+	s.getProposalMessage().From = []byte("modified")
+	s.getProposal().RawProposal[0] = 'x'
+	s.getProposalHash()[0] = 'x'
+	s.getLatestPC().ProposalMessage.From = []byte("modified")
+	s.getLatestPreparedProposal().Round = 10
+	s.getCommittedSeals()[0].Signer = []byte("modified")
+	s.getLastSent().Hops = 10
+
+	// Make sure the state is not modified
+	assert.Equal(t, uint64(0), s.getRound())
+	assert.Equal(t, []byte("proposer"), s.getProposalMessage().From)
+	assert.Equal(t, correctRoundMessage.proposal.GetRawProposal(), s.getProposal().RawProposal)
+	assert.Equal(t, correctRoundMessage.hash, s.getProposalHash())
+	assert.Equal(t, []byte("proposer"), s.getLatestPC().ProposalMessage.From)
+	assert.Equal(t, correctRoundMessage.proposal.Round, s.getLatestPreparedProposal().Round)
+	assert.Equal(t, []byte("node"), s.getCommittedSeals()[0].Signer)
+	assert.Equal(t, uint32(0), s.getLastSent().Hops)
+
+	// Make sure the message passed to the state is not retained
+	prepare.Hops = 5
+
+	assert.Equal(t, uint32(0), s.getLastSent().Hops)
+}
@@ -0,0 +1,55 @@
+package core
+
+import (
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// hasQuorum checks if the messages of the type reached quorum.
+// With threshold signing, the COMMIT messages reach quorum once there
+// are enough partial signatures to combine the threshold signature
+func (i *IBFT) hasQuorum(height uint64, msgs []*proto.Message, msgType proto.MessageType) bool {
+	if signer, ok := i.backend.(ThresholdSigner); ok && msgType == proto.MessageType_COMMIT {
+		threshold := signer.Threshold(height)
+
+		return threshold > 0 && uint64(len(msgs)) >= threshold
+	}
+
+	return i.quorum.HasQuorum(height, msgs, msgType)
+}
+
+// isValidCommitSeal checks the committed seal of the COMMIT message.
+// With threshold signing, the seal is the partial signature of the sender
+func (i *IBFT) isValidCommitSeal(height uint64, proposalHash []byte, committedSeal *messages.CommittedSeal) bool {
+	if signer, ok := i.backend.(ThresholdSigner); ok {
+		return signer.IsValidPartialSeal(height, proposalHash, committedSeal)
+	}
+
+	return i.isValidCommittedSeal(height, proposalHash, committedSeal)
+}
+
+// combineThresholdSeal combines the partial signatures of the COMMIT messages
+// over the proposal hash into the threshold signature, and verifies it
+func (i *IBFT) combineThresholdSeal(
+	signer ThresholdSigner,
+	view *proto.View,
+	proposalHash []byte,
+	partialSeals []*messages.CommittedSeal,
+) ([]byte, bool) {
+	thresholdSeal, err := signer.CombineSeals(view.Height, proposalHash, partialSeals)
+	if err != nil {
+		i.log.Error("failed to combine the partial signatures", "height", view.Height, "round", view.Round, "err", err)
+		i.metrics.IncrCounter(thresholdSealFailedKey, 1)
+
+		return nil, false
+	}
+
+	if !signer.IsValidThresholdSeal(view.Height, proposalHash, thresholdSeal) {
+		i.log.Error("invalid threshold signature combined", "height", view.Height, "round", view.Round)
+		i.metrics.IncrCounter(thresholdSealFailedKey, 1)
+
+		return nil, false
+	}
+
+	return thresholdSeal, true
+}
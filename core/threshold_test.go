@@ -0,0 +1,243 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// thresholdBackend is a mock backend that seals the proposals
+// with a threshold signature, combined by joining the partial signatures
+type thresholdBackend struct {
+	mockBackend
+
+	threshold                 uint64
+	combineErr                error
+	isValidThresholdSealFn    func([]byte, []byte) bool
+	insertThresholdProposalFn func(*proto.Proposal, []byte, []*messages.CommittedSeal)
+}
+
+func (b thresholdBackend) Threshold(_ uint64) uint64 {
+	return b.threshold
+}
+
+func (b thresholdBackend) IsValidPartialSeal(_ uint64, _ []byte, partialSeal *messages.CommittedSeal) bool {
+	return bytes.Equal(partialSeal.Signature, partialSignature(partialSeal.Signer))
+}
+
+func (b thresholdBackend) CombineSeals(
+	_ uint64,
+	_ []byte,
+	partialSeals []*messages.CommittedSeal,
+) ([]byte, error) {
+	if b.combineErr != nil {
+		return nil, b.combineErr
+	}
+
+	signatures := make([][]byte, 0, len(partialSeals))
+	for _, seal := range partialSeals {
+		signatures = append(signatures, seal.Signature)
+	}
+
+	return bytes.Join(signatures, []byte(",")), nil
+}
+
+func (b thresholdBackend) IsValidThresholdSeal(_ uint64, proposalHash []byte, thresholdSeal []byte) bool {
+	if b.isValidThresholdSealFn != nil {
+		return b.isValidThresholdSealFn(proposalHash, thresholdSeal)
+	}
+
+	return true
+}
+
+func (b thresholdBackend) InsertThresholdProposal(
+	proposal *proto.Proposal,
+	thresholdSeal []byte,
+	partialSeals []*messages.CommittedSeal,
+) {
+	if b.insertThresholdProposalFn != nil {
+		b.insertThresholdProposalFn(proposal, thresholdSeal, partialSeals)
+	}
+}
+
+// partialSignature returns the valid partial signature of the signer
+func partialSignature(signer []byte) []byte {
+	return append([]byte("partial "), signer...)
+}
+
+// addThresholdCommits adds the COMMIT messages of the validators,
+// with the specified partial signatures
+func addThresholdCommits(i *IBFT, view *proto.View, validators [][]byte, partialFn func([]byte) []byte) {
+	for _, validator := range validators {
+		i.messages.AddMessage(buildBasicCommitMessage(
+			correctRoundMessage.hash,
+			partialFn(validator),
+			validator,
+			view,
+		))
+	}
+}
+
+// newThresholdIBFT creates the IBFT instance with 7 validators (a quorum of 5),
+// which accepted the correct proposal for the view
+func newThresholdIBFT(backend thresholdBackend, view *proto.View, opts ...Option) *IBFT {
+	backend.mockBackend = mockBackend{
+		isValidProposalHashFn: func(_ *proto.Proposal, hash []byte) bool {
+			return bytes.Equal(hash, correctRoundMessage.hash)
+		},
+		insertProposalFn: func(_ *proto.Proposal, _ []*messages.CommittedSeal) {
+			panic("proposal inserted without the threshold signature")
+		},
+	}
+
+	verifier := CountQuorum{
+		ValidatorCount: func(uint64) uint64 {
+			return 7
+		},
+	}
+
+	i := NewIBFT(mockLogger{}, backend, mockTransport{}, append(opts, WithQuorumVerifier(verifier))...)
+	i.state.setView(view)
+	i.state.setProposalMessage(buildBasicPreprepareMessage(
+		correctRoundMessage.proposal.GetRawProposal(),
+		correctRoundMessage.hash,
+		nil,
+		[]byte("proposer"),
+		view,
+	))
+
+	return i
+}
+
+func TestIBFT_ThresholdCommit(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view       = &proto.View{Height: 3, Round: 1}
+		validators = generateNodeAddresses(7)
+
+		insertedProposal *proto.Proposal
+		insertedSeal     []byte
+		insertedPartials []*messages.CommittedSeal
+	)
+
+	i := newThresholdIBFT(thresholdBackend{
+		threshold: 3,
+		insertThresholdProposalFn: func(
+			proposal *proto.Proposal,
+			thresholdSeal []byte,
+			partialSeals []*messages.CommittedSeal,
+		) {
+			insertedProposal = proposal
+			insertedSeal = thresholdSeal
+			insertedPartials = partialSeals
+		},
+	}, view)
+
+	// Invalid partial signatures are not counted towards the threshold
+	addThresholdCommits(i, view, validators[:2], partialSignature)
+	addThresholdCommits(i, view, validators[2:4], func([]byte) []byte {
+		return []byte("invalid partial")
+	})
+
+	assert.False(t, i.handleCommit(view, make(validatedMessages)))
+	assert.Nil(t, insertedProposal)
+
+	// Make sure the proposal is finalized with the threshold of partial
+	// signatures, before a quorum of COMMIT messages
+	addThresholdCommits(i, view, validators[4:5], partialSignature)
+
+	assert.True(t, i.handleCommit(view, make(validatedMessages)))
+
+	if assert.NotNil(t, insertedProposal) {
+		assert.Equal(t, correctRoundMessage.proposal.GetRawProposal(), insertedProposal.GetRawProposal())
+		assert.Equal(t, view.Round, insertedProposal.GetRound())
+	}
+
+	assert.Len(t, insertedPartials, 3)
+	assert.Equal(t, 3, len(bytes.Split(insertedSeal, []byte(","))))
+}
+
+func TestIBFT_ThresholdCommit_InvalidSeal(t *testing.T) {
+	t.Parallel()
+
+	testTable := []struct {
+		name    string
+		backend thresholdBackend
+	}{
+		{
+			name: "partial signatures not combined",
+			backend: thresholdBackend{
+				threshold:  3,
+				combineErr: errors.New("combination failed"),
+			},
+		},
+		{
+			name: "invalid threshold signature",
+			backend: thresholdBackend{
+				threshold: 3,
+				isValidThresholdSealFn: func(_ []byte, _ []byte) bool {
+					return false
+				},
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				view    = &proto.View{Height: 3, Round: 0}
+				metrics = &counterMetrics{}
+				backend = testCase.backend
+			)
+
+			backend.insertThresholdProposalFn = func(_ *proto.Proposal, _ []byte, _ []*messages.CommittedSeal) {
+				t.Fatal("proposal inserted with an invalid threshold signature")
+			}
+
+			i := newThresholdIBFT(backend, view, WithMetrics(metrics))
+
+			addThresholdCommits(i, view, generateNodeAddresses(7), partialSignature)
+
+			i.state.setPhaseStart(proto.MessageType_COMMIT, time.Now())
+
+			assert.False(t, i.handleCommit(view, make(validatedMessages)))
+			assert.Equal(t, float32(1), metrics.counter(thresholdSealFailedKey))
+
+			// Make sure the failed quorum is not measured
+			assert.Equal(t, uint64(0), i.LatencyEstimator().Samples())
+		})
+	}
+}
+
+func TestIBFT_ThresholdQuorum(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view = &proto.View{Height: 1, Round: 0}
+		i    = newThresholdIBFT(thresholdBackend{threshold: 2}, view)
+		msgs = []*proto.Message{{}, {}}
+	)
+
+	// The threshold determines the COMMIT quorum
+	assert.True(t, i.hasQuorum(view.Height, msgs, proto.MessageType_COMMIT))
+	assert.False(t, i.hasQuorum(view.Height, msgs[:1], proto.MessageType_COMMIT))
+
+	// The quorum verifier determines the quorum of the other message types
+	assert.False(t, i.hasQuorum(view.Height, msgs, proto.MessageType_PREPARE))
+
+	// A zero threshold is never reached
+	i = newThresholdIBFT(thresholdBackend{}, view)
+
+	assert.False(t, i.hasQuorum(view.Height, msgs, proto.MessageType_COMMIT))
+}
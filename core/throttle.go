@@ -0,0 +1,116 @@
+package core
+
+import (
+	"sync"
+	"time"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+const (
+	// defaultRoundChangeRebroadcastInterval is the minimum time between
+	// two multicasts of the ROUND_CHANGE message for the same view
+	defaultRoundChangeRebroadcastInterval = 2 * time.Second
+
+	// defaultRoundChangeBackoffLimit is the maximum time between two multicasts
+	// of the ROUND_CHANGE message for the same view, once backed off
+	defaultRoundChangeBackoffLimit = 30 * time.Second
+)
+
+// roundChangeThrottle rate-limits the re-multicasts of the ROUND_CHANGE message.
+// After an outage all validators rebroadcast their ROUND_CHANGE messages at once,
+// and each one is relayed to every peer, which floods large networks.
+// The interval doubles after each multicast for the same view, up to the backoff limit,
+// and is reset once the node moves to another view (ex. by jumping to a higher round)
+type roundChangeThrottle struct {
+	sync.Mutex
+
+	// interval is the minimum time between the first two multicasts
+	// for the same view. A zero interval disables the throttling
+	interval time.Duration
+
+	// backoffLimit is the maximum time between two multicasts for the same view.
+	// A limit not above the interval disables the backoff
+	backoffLimit time.Duration
+
+	// view and sent are the view and the time
+	// of the latest ROUND_CHANGE multicast
+	view *proto.View
+	sent time.Time
+
+	// multicasts is the number of multicasts for the view
+	multicasts uint
+}
+
+// newRoundChangeThrottle creates a new throttle with the specified interval and backoff limit
+func newRoundChangeThrottle(interval, backoffLimit time.Duration) *roundChangeThrottle {
+	return &roundChangeThrottle{
+		interval:     interval,
+		backoffLimit: backoffLimit,
+	}
+}
+
+// backoff returns the minimum time between the latest multicast for the view and the next one
+func (t *roundChangeThrottle) backoff() time.Duration {
+	backoff := t.interval
+
+	for i := uint(1); i < t.multicasts && backoff < t.backoffLimit; i++ {
+		backoff *= 2
+	}
+
+	if backoff > t.backoffLimit && t.backoffLimit > t.interval {
+		return t.backoffLimit
+	}
+
+	return backoff
+}
+
+// allow checks if the ROUND_CHANGE message for the view can be multicasted (again),
+// and marks it as multicasted if so. The first multicast for a view is always allowed
+func (t *roundChangeThrottle) allow(view *proto.View, now time.Time) bool {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.view == nil || t.view.Height != view.Height || t.view.Round != view.Round {
+		t.view = &proto.View{
+			Height: view.Height,
+			Round:  view.Round,
+		}
+		t.sent = now
+		t.multicasts = 1
+
+		return true
+	}
+
+	if now.Sub(t.sent) < t.backoff() {
+		return false
+	}
+
+	t.sent = now
+	t.multicasts++
+
+	return true
+}
+
+// allowRoundChangeRebroadcast checks if the ROUND_CHANGE message for the view
+// can be multicasted again. The rebroadcast is suppressed once the node observes
+// a quorum of ROUND_CHANGE messages for the round (or a higher one), as the proposer
+// does not need the message to build the RCC, and it is rate-limited with
+// an exponential backoff otherwise (see WithRoundChangeBackoff)
+func (i *IBFT) allowRoundChangeRebroadcast(view *proto.View) bool {
+	if i.quorumMemo.hasQuorumFrom(view, proto.MessageType_ROUND_CHANGE) {
+		i.log.Debug("round change rebroadcast suppressed, quorum observed", "round", view.Round)
+		i.metrics.IncrCounter(roundChangeSuppressedKey, 1)
+
+		return false
+	}
+
+	if !i.roundChangeThrottle.allow(view, time.Now()) {
+		i.log.Debug("round change rebroadcast throttled", "round", view.Round)
+		i.metrics.IncrCounter(roundChangeThrottledKey, 1)
+
+		return false
+	}
+
+	return true
+}
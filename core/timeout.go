@@ -0,0 +1,133 @@
+package core
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// TimeoutStrategy determines the timeout for each round of consensus,
+// before the additional timeout and the timeout ceiling are applied
+type TimeoutStrategy interface {
+	// RoundTimeout returns the timeout for the specified round,
+	// based on the configured base round timeout
+	RoundTimeout(baseRoundTimeout time.Duration, round uint64) time.Duration
+}
+
+// ExponentialTimeout is the default timeout strategy, which
+// doubles the base round timeout with each round
+type ExponentialTimeout struct{}
+
+// RoundTimeout returns base * 2^round, saturating at the maximum duration
+func (ExponentialTimeout) RoundTimeout(baseRoundTimeout time.Duration, round uint64) time.Duration {
+	timeout := float64(baseRoundTimeout) * math.Pow(roundFactorBase, float64(round))
+
+	// Make sure the exponential growth doesn't overflow
+	if timeout >= math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+
+	return time.Duration(timeout)
+}
+
+// ScheduleTimeout is an explicit per-round timeout table, for chains that
+// want an escalation profile different from a single formula.
+// Rounds past the end of the table use the last entry, and an empty
+// table falls back to the base round timeout
+type ScheduleTimeout []time.Duration
+
+// RoundTimeout returns the scheduled timeout for the round
+func (s ScheduleTimeout) RoundTimeout(baseRoundTimeout time.Duration, round uint64) time.Duration {
+	if len(s) == 0 {
+		return baseRoundTimeout
+	}
+
+	if round >= uint64(len(s)) {
+		return s[len(s)-1]
+	}
+
+	return s[round]
+}
+
+// AdaptiveTimeout derives the base round timeout from the observed network latency,
+// instead of a guessed constant. The base timeout is the latency estimate scaled by
+// the multiplier, and never lower than the floor. Until latency samples are observed,
+// the configured base round timeout is used. The growth over rounds is delegated
+// to the wrapped strategy
+type AdaptiveTimeout struct {
+	// Estimator is the source of the latency estimate
+	Estimator *LatencyEstimator
+
+	// Multiplier scales the latency estimate into the base round timeout
+	Multiplier float64
+
+	// MinTimeout is the floor for the derived base round timeout
+	MinTimeout time.Duration
+
+	// Strategy determines the growth over rounds.
+	// If not set, the exponential growth is used
+	Strategy TimeoutStrategy
+}
+
+// RoundTimeout returns the timeout of the wrapped strategy,
+// for the base round timeout derived from the latency estimate
+func (a AdaptiveTimeout) RoundTimeout(baseRoundTimeout time.Duration, round uint64) time.Duration {
+	var strategy TimeoutStrategy = ExponentialTimeout{}
+	if a.Strategy != nil {
+		strategy = a.Strategy
+	}
+
+	if a.Estimator == nil {
+		return strategy.RoundTimeout(baseRoundTimeout, round)
+	}
+
+	if estimate, ok := a.Estimator.Estimate(); ok {
+		scaled := float64(estimate) * a.Multiplier
+
+		// Make sure the scaled estimate doesn't overflow
+		if scaled >= math.MaxInt64 {
+			baseRoundTimeout = time.Duration(math.MaxInt64)
+		} else {
+			baseRoundTimeout = time.Duration(scaled)
+		}
+
+		if baseRoundTimeout < a.MinTimeout {
+			baseRoundTimeout = a.MinTimeout
+		}
+	}
+
+	return strategy.RoundTimeout(baseRoundTimeout, round)
+}
+
+// RoundTimer signals the expiry of a consensus round. Embedders can implement
+// timers driven by external signals (block-sync progress, slot boundaries)
+// instead of the wall clock
+type RoundTimer interface {
+	// Wait blocks until the round for the specified view expires, or the context is cancelled.
+	// The timeout is the one determined by the timeout strategy, and can be ignored
+	// by timers that are not driven by the wall clock.
+	// Returns true if the round expired
+	Wait(ctx context.Context, view *proto.View, timeout time.Duration) bool
+}
+
+// WallClockTimer is the default round timer,
+// which expires the round after the timeout passes
+type WallClockTimer struct{}
+
+// Wait blocks until the timeout passes, or the context is cancelled
+func (WallClockTimer) Wait(ctx context.Context, _ *proto.View, timeout time.Duration) bool {
+	//	Create a new timer instance
+	timer := time.NewTimer(timeout)
+
+	select {
+	case <-ctx.Done():
+		// Stop signal received, stop the timer
+		timer.Stop()
+
+		return false
+	case <-timer.C:
+		return true
+	}
+}
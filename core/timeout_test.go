@@ -0,0 +1,156 @@
+package core
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+func TestExponentialTimeout_RoundTimeout(t *testing.T) {
+	t.Parallel()
+
+	strategy := ExponentialTimeout{}
+
+	assert.Equal(t, time.Second, strategy.RoundTimeout(time.Second, 0))
+	assert.Equal(t, 8*time.Second, strategy.RoundTimeout(time.Second, 3))
+
+	// Make sure huge rounds saturate instead of overflowing
+	assert.Equal(t, time.Duration(math.MaxInt64), strategy.RoundTimeout(time.Second, 200))
+}
+
+func TestScheduleTimeout_RoundTimeout(t *testing.T) {
+	t.Parallel()
+
+	t.Run("rounds within the schedule", func(t *testing.T) {
+		t.Parallel()
+
+		schedule := ScheduleTimeout{time.Second, 2 * time.Second, 30 * time.Second}
+
+		assert.Equal(t, time.Second, schedule.RoundTimeout(time.Minute, 0))
+		assert.Equal(t, 2*time.Second, schedule.RoundTimeout(time.Minute, 1))
+		assert.Equal(t, 30*time.Second, schedule.RoundTimeout(time.Minute, 2))
+	})
+
+	t.Run("rounds past the schedule use the last entry", func(t *testing.T) {
+		t.Parallel()
+
+		schedule := ScheduleTimeout{time.Second, 30 * time.Second}
+
+		assert.Equal(t, 30*time.Second, schedule.RoundTimeout(time.Minute, 2))
+		assert.Equal(t, 30*time.Second, schedule.RoundTimeout(time.Minute, 1000))
+	})
+
+	t.Run("empty schedule uses the base timeout", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, time.Minute, ScheduleTimeout{}.RoundTimeout(time.Minute, 5))
+	})
+}
+
+// TestIBFT_WithRoundTimeoutSchedule makes sure the scheduled
+// timeouts are capped like the default ones
+func TestIBFT_WithRoundTimeoutSchedule(t *testing.T) {
+	t.Parallel()
+
+	i := NewIBFT(
+		mockLogger{},
+		mockBackend{},
+		mockTransport{},
+		WithRoundTimeoutSchedule(time.Second, time.Second, time.Second, time.Hour),
+		WithMaxRoundTimeout(time.Minute),
+	)
+
+	assert.Equal(t, time.Second, i.roundTimeout(0))
+	assert.Equal(t, time.Second, i.roundTimeout(2))
+	assert.Equal(t, time.Minute, i.roundTimeout(3))
+}
+
+// signalTimer is a round timer driven by an external signal
+type signalTimer struct {
+	views   chan *proto.View
+	expires chan struct{}
+}
+
+func (t *signalTimer) Wait(ctx context.Context, view *proto.View, _ time.Duration) bool {
+	t.views <- view
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.expires:
+		return true
+	}
+}
+
+func TestWallClockTimer_Wait(t *testing.T) {
+	t.Parallel()
+
+	t.Run("timer expires", func(t *testing.T) {
+		t.Parallel()
+
+		assert.True(t, WallClockTimer{}.Wait(context.Background(), &proto.View{}, 0))
+	})
+
+	t.Run("timer cancelled", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancelFn := context.WithCancel(context.Background())
+		cancelFn()
+
+		assert.False(t, WallClockTimer{}.Wait(ctx, &proto.View{}, time.Hour))
+	})
+}
+
+func TestIBFT_WithRoundTimer(t *testing.T) {
+	t.Parallel()
+
+	timer := &signalTimer{
+		views:   make(chan *proto.View, 1),
+		expires: make(chan struct{}),
+	}
+
+	i := NewIBFT(
+		mockLogger{},
+		mockBackend{},
+		mockTransport{},
+		WithRoundTimer(timer),
+	)
+
+	i.state.setView(&proto.View{
+		Height: 10,
+		Round:  0,
+	})
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+
+	group := newWorkerGroup(ctx)
+
+	group.spawn("round timer", func(ctx context.Context) {
+		i.startRoundTimer(ctx, 3)
+	})
+
+	// Make sure the timer received the correct view
+	view := <-timer.views
+	assert.Equal(t, uint64(10), view.Height)
+	assert.Equal(t, uint64(3), view.Round)
+
+	// Make sure the round does not expire on the wall clock
+	select {
+	case <-i.roundEvents:
+		t.Fatal("round expired without the external signal")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Expire the round using the external signal
+	close(timer.expires)
+
+	<-i.roundEvents
+
+	assert.NoError(t, group.wait())
+}
@@ -1,10 +1,119 @@
 package core
 
-import "github.com/renloi/ibft/messages/proto"
+import (
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
 
 // Transport defines an interface
 // the node uses to communicate with other peers
 type Transport interface {
-	// Multicast multicasts the message to other peers
-	Multicast(message *proto.Message)
+	// Multicast multicasts the message to other peers.
+	// An error indicates the message could not be sent,
+	// and the multicast may be retried
+	Multicast(message *proto.Message) error
+}
+
+// Unicaster is an optional Transport extension for sending a message to a single peer.
+// If the transport implements it, the node replies to a duplicate proposal
+// for a view it already prepared or committed with its PREPARE and COMMIT
+// messages for the view, sent only to the proposer
+type Unicaster interface {
+	// Unicast sends the message to the peer with the specified address.
+	// An error indicates the message could not be sent
+	Unicast(to []byte, message *proto.Message) error
+}
+
+// CodecTransport is a Transport that encodes the messages
+// using the codec, and multicasts the raw data
+type CodecTransport struct {
+	// marshaler encodes the messages
+	marshaler messages.Marshaler
+
+	// multicastFn multicasts the raw data to other peers
+	multicastFn func(data []byte) error
+
+	// metrics is the sink of the sent bytes
+	metrics Metrics
+}
+
+// CodecTransportOption is the option of the codec transport
+type CodecTransportOption func(*CodecTransport)
+
+// WithTransportMetrics sets the sink of the bytes sent by the transport,
+// accounted by message type (and by peer, for the unicasts)
+func WithTransportMetrics(metrics Metrics) CodecTransportOption {
+	return func(t *CodecTransport) {
+		t.metrics = metrics
+	}
+}
+
+// NewCodecTransport creates a new transport that encodes the messages using the marshaler,
+// and multicasts the raw data using the passed in function
+func NewCodecTransport(
+	marshaler messages.Marshaler,
+	multicastFn func(data []byte) error,
+	opts ...CodecTransportOption,
+) *CodecTransport {
+	t := &CodecTransport{
+		marshaler:   marshaler,
+		multicastFn: multicastFn,
+		metrics:     nopMetrics{},
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t
+}
+
+// Multicast encodes the message, and multicasts it to other peers
+func (t *CodecTransport) Multicast(message *proto.Message) error {
+	data, err := t.marshaler.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	if err := t.multicastFn(data); err != nil {
+		return err
+	}
+
+	recordBandwidth(t.metrics, bandwidthSent, nil, message.GetType(), len(data))
+
+	return nil
+}
+
+// CodecUnicaster is a CodecTransport that also implements the Unicaster extension,
+// sending the raw data to a single peer
+type CodecUnicaster struct {
+	*CodecTransport
+
+	// unicastFn sends the raw data to the peer
+	unicastFn func(to, data []byte) error
+}
+
+// NewCodecUnicaster creates a new transport that multicasts the messages using the transport,
+// and unicasts the raw data using the passed in function
+func NewCodecUnicaster(transport *CodecTransport, unicastFn func(to, data []byte) error) *CodecUnicaster {
+	return &CodecUnicaster{
+		CodecTransport: transport,
+		unicastFn:      unicastFn,
+	}
+}
+
+// Unicast encodes the message, and sends it to the peer
+func (t *CodecUnicaster) Unicast(to []byte, message *proto.Message) error {
+	data, err := t.marshaler.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	if err := t.unicastFn(to, data); err != nil {
+		return err
+	}
+
+	recordBandwidth(t.metrics, bandwidthSent, to, message.GetType(), len(data))
+
+	return nil
 }
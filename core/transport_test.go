@@ -0,0 +1,395 @@
+package core
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	protoBuf "google.golang.org/protobuf/proto"
+
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+var errInvalidPrefix = errors.New("invalid prefix")
+
+// prefixCodec is a test codec that prefixes the proto encoding
+type prefixCodec struct {
+	messages.ProtoCodec
+}
+
+func (c prefixCodec) Marshal(message *proto.Message) ([]byte, error) {
+	data, err := c.ProtoCodec.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte("prefix"), data...), nil
+}
+
+func (c prefixCodec) Unmarshal(data []byte, message *proto.Message) error {
+	if !bytes.HasPrefix(data, []byte("prefix")) {
+		return errInvalidPrefix
+	}
+
+	return c.ProtoCodec.Unmarshal(data[len("prefix"):], message)
+}
+
+func TestCodecTransport(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view = &proto.View{
+			Height: 1,
+			Round:  0,
+		}
+		message = buildBasicPrepareMessage([]byte("hash"), []byte("node 1"), view)
+
+		multicasted [][]byte
+	)
+
+	transport := NewCodecTransport(prefixCodec{}, func(data []byte) error {
+		multicasted = append(multicasted, data)
+
+		return nil
+	})
+
+	assert.NoError(t, transport.Multicast(message))
+
+	if !assert.Len(t, multicasted, 1) {
+		return
+	}
+
+	// Make sure the receiving node decodes the message using the same codec
+	i := NewIBFT(mockLogger{}, mockBackend{}, mockTransport{}, WithCodec(prefixCodec{}))
+	i.state.setView(view)
+
+	assert.NoError(t, i.AddRawMessage(multicasted[0]))
+
+	received, _ := i.GetMessages(view, proto.MessageType_PREPARE, 0)
+	if assert.Len(t, received, 1) {
+		assert.Equal(t, []byte("node 1"), received[0].From)
+		assert.Equal(t, []byte("hash"), messages.ExtractPrepareHash(received[0]))
+	}
+
+	// Make sure data in a different encoding is rejected
+	assert.ErrorIs(t, i.AddRawMessage([]byte("invalid")), errInvalidPrefix)
+}
+
+func TestCodecTransport_LazyCodec(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view = &proto.View{
+			Height: 1,
+			Round:  0,
+		}
+		proposer = []byte("proposer")
+		message  = buildBasicPreprepareMessage(
+			correctRoundMessage.proposal.RawProposal,
+			correctRoundMessage.hash,
+			nil,
+			proposer,
+			view,
+		)
+
+		codec       = messages.LazyCodec{MinLazySize: 1}
+		multicasted [][]byte
+	)
+
+	transport := NewCodecTransport(codec, func(data []byte) error {
+		multicasted = append(multicasted, data)
+
+		return nil
+	})
+
+	assert.NoError(t, transport.Multicast(message))
+
+	if !assert.Len(t, multicasted, 1) {
+		return
+	}
+
+	// Make sure the receiving node validates the lazily decoded proposal
+	i := NewIBFT(mockLogger{}, mockBackend{
+		isProposerFn: func(id []byte, _ uint64, _ uint64) bool {
+			return bytes.Equal(id, proposer)
+		},
+	}, mockTransport{}, WithCodec(codec))
+	i.state.setView(view)
+
+	assert.NoError(t, i.AddRawMessage(multicasted[0]))
+
+	received, _ := i.GetMessages(view, proto.MessageType_PREPREPARE, 0)
+	if assert.Len(t, received, 1) {
+		assert.Nil(t, received[0].Payload)
+	}
+
+	proposalMessage := i.handlePrePrepare(view)
+	if assert.NotNil(t, proposalMessage) {
+		i.acceptProposal(proposalMessage)
+
+		assert.Equal(t, correctRoundMessage.proposal.RawProposal, i.state.getProposal().RawProposal)
+		assert.Equal(t, correctRoundMessage.hash, i.state.getProposalHash())
+	}
+}
+
+// hashEnvelopeSigner is a test envelope signer, whose signature
+// is the hash of the sender and the payload
+type hashEnvelopeSigner struct {
+	id []byte
+}
+
+func (s hashEnvelopeSigner) ID() []byte {
+	return s.id
+}
+
+func (s hashEnvelopeSigner) SignEnvelope(payload []byte) ([]byte, error) {
+	return hashEnvelopeSignature(s.id, payload), nil
+}
+
+// hashEnvelopeVerifier is a test envelope verifier, accepting the hash signatures
+type hashEnvelopeVerifier struct{}
+
+func (hashEnvelopeVerifier) VerifyEnvelope(from, payload, signature []byte) bool {
+	return bytes.Equal(hashEnvelopeSignature(from, payload), signature)
+}
+
+// hashEnvelopeSignature returns the hash signature of the sender over the payload
+func hashEnvelopeSignature(from, payload []byte) []byte {
+	hash := sha256.Sum256(append(append([]byte(nil), from...), payload...))
+
+	return hash[:]
+}
+
+// membershipBackend is a mock backend that checks the membership of the senders,
+// and rejects the message signatures
+type membershipBackend struct {
+	mockBackend
+
+	validators [][]byte
+}
+
+func (b membershipBackend) IsValidator(from []byte, _ uint64) bool {
+	for _, validator := range b.validators {
+		if bytes.Equal(validator, from) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestCodecTransport_Envelope(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view = &proto.View{
+			Height: 1,
+			Round:  0,
+		}
+		validator = []byte("node 1")
+	)
+
+	// seal seals the message of the sender in an envelope signed by the signer
+	seal := func(t *testing.T, signer, sender []byte, messageType proto.MessageType) []byte {
+		t.Helper()
+
+		var sealed []byte
+
+		transport := NewCodecTransport(
+			messages.NewEnvelopeMarshaler(messages.ProtoCodec{}, hashEnvelopeSigner{id: signer}),
+			func(data []byte) error {
+				sealed = data
+
+				return nil
+			},
+		)
+
+		message := buildBasicCommitMessage([]byte("hash"), []byte("seal"), sender, view)
+		if messageType == proto.MessageType_PREPARE {
+			message = buildBasicPrepareMessage([]byte("hash"), sender, view)
+		}
+
+		assert.NoError(t, transport.Multicast(message))
+
+		return sealed
+	}
+
+	testTable := []struct {
+		name        string
+		messageType proto.MessageType
+		data        func(t *testing.T) []byte
+		err         error
+		accepted    bool
+	}{
+		{
+			name:        "validator envelope",
+			messageType: proto.MessageType_COMMIT,
+			data: func(t *testing.T) []byte {
+				return seal(t, validator, validator, proto.MessageType_COMMIT)
+			},
+			accepted: true,
+		},
+		{
+			name:        "validator envelope of a certificate message",
+			messageType: proto.MessageType_PREPARE,
+			data: func(t *testing.T) []byte {
+				return seal(t, validator, validator, proto.MessageType_PREPARE)
+			},
+		},
+		{
+			name:        "envelope of another sender",
+			messageType: proto.MessageType_COMMIT,
+			data: func(t *testing.T) []byte {
+				return seal(t, []byte("node 2"), validator, proto.MessageType_COMMIT)
+			},
+			err: messages.ErrEnvelopeSenderMismatch,
+		},
+		{
+			name:        "authenticated non-validator",
+			messageType: proto.MessageType_COMMIT,
+			data: func(t *testing.T) []byte {
+				return seal(t, []byte("node 2"), []byte("node 2"), proto.MessageType_COMMIT)
+			},
+		},
+		{
+			name:        "tampered envelope",
+			messageType: proto.MessageType_COMMIT,
+			data: func(t *testing.T) []byte {
+				envelope := &proto.Envelope{}
+				assert.NoError(t, protoBuf.Unmarshal(seal(t, validator, validator, proto.MessageType_COMMIT), envelope))
+
+				envelope.Payload = append(envelope.Payload, 0)
+
+				data, err := protoBuf.Marshal(envelope)
+				assert.NoError(t, err)
+
+				return data
+			},
+			err: messages.ErrInvalidEnvelopeSignature,
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				metrics = &counterMetrics{}
+				backend = membershipBackend{
+					mockBackend: mockBackend{
+						IsValidValidatorFn: func(_ *proto.Message) bool {
+							return false
+						},
+					},
+					validators: [][]byte{validator},
+				}
+			)
+
+			i := NewIBFT(
+				mockLogger{},
+				backend,
+				mockTransport{},
+				WithEnvelopeVerifier(hashEnvelopeVerifier{}),
+				WithMetrics(metrics),
+			)
+			i.state.setView(view)
+
+			err := i.AddRawMessage(testCase.data(t))
+			if testCase.err != nil {
+				assert.ErrorIs(t, err, testCase.err)
+				assert.Equal(t, float32(1), metrics.counter(rejectionKey(rejectInvalidEnvelope)))
+			} else {
+				assert.NoError(t, err)
+			}
+
+			// Make sure only the authenticated messages of the validators are accepted,
+			// without verifying the message signature, unless they are relayed in the certificates
+			received, _ := i.GetMessages(view, testCase.messageType, 0)
+			assert.Equal(t, testCase.accepted, len(received) == 1)
+		})
+	}
+}
+
+func TestIBFT_AddMessage_MembershipRequiresEnvelope(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view      = &proto.View{Height: 1, Round: 0}
+		validator = []byte("node 1")
+		backend   = membershipBackend{
+			mockBackend: mockBackend{
+				IsValidValidatorFn: func(_ *proto.Message) bool {
+					return false
+				},
+			},
+			validators: [][]byte{validator},
+		}
+	)
+
+	i := NewIBFT(mockLogger{}, backend, mockTransport{}, WithEnvelopeVerifier(hashEnvelopeVerifier{}))
+	i.state.setView(view)
+
+	// Make sure the messages added directly still need a valid signature
+	i.AddMessage(buildBasicPrepareMessage([]byte("hash"), validator, view))
+
+	received, _ := i.GetMessages(view, proto.MessageType_PREPARE, 0)
+	assert.Empty(t, received)
+
+	// Make sure raw messages outside of an envelope are rejected
+	data, err := messages.ProtoCodec{}.Marshal(buildBasicPrepareMessage([]byte("hash"), validator, view))
+	assert.NoError(t, err)
+
+	assert.Error(t, i.AddRawMessage(data))
+}
+
+func TestCodecTransport_Bandwidth(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view    = &proto.View{Height: 1, Round: 0}
+		message = buildBasicPrepareMessage([]byte("hash"), []byte("node 1"), view)
+		metrics = &counterMetrics{}
+		peer    = []byte("peer")
+
+		sent [][]byte
+	)
+
+	send := func(data []byte) error {
+		sent = append(sent, data)
+
+		return nil
+	}
+
+	transport := NewCodecUnicaster(
+		NewCodecTransport(messages.ProtoCodec{}, send, WithTransportMetrics(metrics)),
+		func(_, data []byte) error {
+			return send(data)
+		},
+	)
+
+	assert.NoError(t, transport.Multicast(message))
+	assert.NoError(t, transport.Unicast(peer, message))
+
+	size := float32(len(sent[0]))
+
+	// Make sure the sent bytes are accounted by type, and by peer for the unicasts
+	assert.Equal(t, 2*size, metrics.counter(bandwidthKey(bandwidthSent, proto.MessageType_PREPARE)))
+	assert.Equal(t, size, metrics.counter(peerBandwidthKey(bandwidthSent, peer, proto.MessageType_PREPARE)))
+
+	// Make sure the received bytes are accounted by type, and by the delivering peer
+	received := &counterMetrics{}
+
+	i := NewIBFT(mockLogger{}, mockBackend{}, mockTransport{}, WithMetrics(received))
+	i.state.setView(view)
+
+	assert.NoError(t, i.AddRawMessage(sent[0]))
+	assert.NoError(t, i.AddRawPeerMessage(peer, sent[1]))
+
+	assert.Equal(t, 2*size, received.counter(bandwidthKey(bandwidthReceived, proto.MessageType_PREPARE)))
+	assert.Equal(t, size, received.counter(peerBandwidthKey(bandwidthReceived, peer, proto.MessageType_PREPARE)))
+}
@@ -0,0 +1,121 @@
+package core
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInvalidBaseTimeout is an error indicating
+	// the rounds would expire immediately
+	ErrInvalidBaseTimeout = errors.New("base round timeout must be positive")
+
+	// ErrInvalidTimeoutStrategy is an error indicating the timeout strategy is missing
+	ErrInvalidTimeoutStrategy = errors.New("invalid timeout strategy")
+)
+
+// timeoutTuning are the round timeout settings tuned at runtime.
+// They are staged, and applied at the next round boundary,
+// so the running round keeps the timeout its timer was started with
+type timeoutTuning struct {
+	sync.Mutex
+
+	// baseTimeout is the staged base round timeout, if any
+	baseTimeout *time.Duration
+
+	// maxRound is the staged round the timeout growth stops at, if any
+	maxRound *uint64
+
+	// strategy is the staged timeout strategy, if any
+	strategy TimeoutStrategy
+}
+
+// SetBaseTimeout sets the base round timeout the timeout strategy grows from.
+// It is safe to call while a sequence is running, and takes effect at the next round
+func (i *IBFT) SetBaseTimeout(timeout time.Duration) error {
+	if timeout <= 0 {
+		return ErrInvalidBaseTimeout
+	}
+
+	i.tuning.Lock()
+	defer i.tuning.Unlock()
+
+	i.tuning.baseTimeout = &timeout
+
+	return nil
+}
+
+// SetMaxRound sets the round the round timeout stops growing at. The later rounds
+// reuse its timeout, so a network stuck for many rounds keeps retrying at a steady pace.
+// A zero round disables the cap. It is safe to call while a sequence is running,
+// and takes effect at the next round
+func (i *IBFT) SetMaxRound(round uint64) {
+	i.tuning.Lock()
+	defer i.tuning.Unlock()
+
+	i.tuning.maxRound = &round
+}
+
+// SetTimeoutStrategy sets the strategy used for determining the round timeouts.
+// It is safe to call while a sequence is running, and takes effect at the next round
+func (i *IBFT) SetTimeoutStrategy(strategy TimeoutStrategy) error {
+	if strategy == nil {
+		return ErrInvalidTimeoutStrategy
+	}
+
+	i.tuning.Lock()
+	defer i.tuning.Unlock()
+
+	i.tuning.strategy = strategy
+
+	return nil
+}
+
+// applyTuning applies the staged round timeout settings.
+// It is called at the round boundaries
+func (i *IBFT) applyTuning() {
+	i.tuning.Lock()
+	defer i.tuning.Unlock()
+
+	if i.tuning.baseTimeout == nil && i.tuning.maxRound == nil && i.tuning.strategy == nil {
+		return
+	}
+
+	i.timeoutsLock.Lock()
+	defer i.timeoutsLock.Unlock()
+
+	if i.tuning.baseTimeout != nil {
+		i.baseRoundTimeout = *i.tuning.baseTimeout
+	}
+
+	if i.tuning.maxRound != nil {
+		i.maxRound = *i.tuning.maxRound
+	}
+
+	if i.tuning.strategy != nil {
+		i.timeoutStrategy = i.tuning.strategy
+	}
+
+	i.tuning.baseTimeout, i.tuning.maxRound, i.tuning.strategy = nil, nil, nil
+
+	i.log.Info(
+		"round timeouts tuned",
+		"base", i.baseRoundTimeout,
+		"max round", i.maxRound,
+	)
+}
+
+// roundTimeout returns the timeout of the round, using the applied settings
+func (i *IBFT) roundTimeout(round uint64) time.Duration {
+	i.timeoutsLock.RLock()
+	defer i.timeoutsLock.RUnlock()
+
+	return getRoundTimeout(
+		i.timeoutStrategy,
+		i.baseRoundTimeout,
+		i.maxRoundTimeout,
+		i.maxRound,
+		round,
+	)
+}
@@ -0,0 +1,101 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// recordingTimer is a round timer recording the round timeouts,
+// and expiring the rounds on an external signal
+type recordingTimer struct {
+	timeouts chan time.Duration
+	expires  chan struct{}
+}
+
+func (t *recordingTimer) Wait(ctx context.Context, _ *proto.View, timeout time.Duration) bool {
+	t.timeouts <- timeout
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.expires:
+		return true
+	}
+}
+
+func TestIBFT_RuntimeTuning_Validation(t *testing.T) {
+	t.Parallel()
+
+	i := NewIBFT(mockLogger{}, mockBackend{}, mockTransport{})
+
+	assert.ErrorIs(t, i.SetBaseTimeout(0), ErrInvalidBaseTimeout)
+	assert.ErrorIs(t, i.SetBaseTimeout(-time.Second), ErrInvalidBaseTimeout)
+	assert.ErrorIs(t, i.SetTimeoutStrategy(nil), ErrInvalidTimeoutStrategy)
+
+	// Make sure the rejected settings are not staged
+	i.applyTuning()
+
+	config := i.Config()
+
+	assert.Equal(t, round0Timeout, config.BaseRoundTimeout)
+	assert.IsType(t, ExponentialTimeout{}, config.TimeoutStrategy)
+}
+
+// TestIBFT_RuntimeTuning makes sure the round timeouts tuned
+// while the sequence runs take effect at the next round boundary
+func TestIBFT_RuntimeTuning(t *testing.T) {
+	t.Parallel()
+
+	timer := &recordingTimer{
+		timeouts: make(chan time.Duration),
+		expires:  make(chan struct{}),
+	}
+
+	i := NewIBFT(mockLogger{}, mockBackend{}, mockTransport{}, WithRoundTimer(timer))
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	sequenceDone := make(chan struct{})
+
+	go func() {
+		defer close(sequenceDone)
+
+		i.RunSequence(ctx, 1)
+	}()
+
+	defer func() {
+		cancelFn()
+		<-sequenceDone
+	}()
+
+	// Make sure round 0 keeps its timeout once tuned
+	assert.Equal(t, round0Timeout, <-timer.timeouts)
+
+	assert.NoError(t, i.SetBaseTimeout(time.Minute))
+	i.SetMaxRound(1)
+
+	assert.Equal(t, round0Timeout, i.Config().BaseRoundTimeout)
+
+	// Make sure the next round uses the tuned base timeout
+	timer.expires <- struct{}{}
+
+	assert.Equal(t, 2*time.Minute, <-timer.timeouts)
+	assert.Equal(t, time.Minute, i.Config().BaseRoundTimeout)
+	assert.Equal(t, uint64(1), i.Config().MaxRound)
+
+	// Make sure the timeout stops growing at the max round
+	timer.expires <- struct{}{}
+
+	assert.Equal(t, 2*time.Minute, <-timer.timeouts)
+
+	// Make sure the next round uses the tuned strategy
+	assert.NoError(t, i.SetTimeoutStrategy(ScheduleTimeout{time.Second, 3 * time.Second}))
+
+	timer.expires <- struct{}{}
+
+	assert.Equal(t, 3*time.Second, <-timer.timeouts)
+}
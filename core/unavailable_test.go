@@ -0,0 +1,247 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// availabilityBackend is a mock backend that detects proposer unavailability
+type availabilityBackend struct {
+	mockBackend
+
+	isProposerAvailableFn func(*proto.View) bool
+}
+
+func (b availabilityBackend) IsProposerAvailable(view *proto.View) bool {
+	return b.isProposerAvailableFn(view)
+}
+
+func (b availabilityBackend) BuildProposerUnavailableMessage(view *proto.View) *proto.Message {
+	return &proto.Message{
+		View: view,
+		From: b.ID(),
+		Type: proto.MessageType_PROPOSER_UNAVAILABLE,
+	}
+}
+
+func TestIBFT_StartRound_UnavailableProposer(t *testing.T) {
+	t.Parallel()
+
+	var (
+		id      = []byte("node")
+		view    = &proto.View{Height: 1, Round: 2}
+		backend = availabilityBackend{
+			mockBackend: mockBackend{
+				idFn: func() []byte {
+					return id
+				},
+				isProposerFn: func(from []byte, _, _ uint64) bool {
+					return bytes.Equal(from, id)
+				},
+				buildProposalFn: func(_ uint64) []byte {
+					t.Fatal("proposal built by an unavailable proposer")
+
+					return nil
+				},
+			},
+			isProposerAvailableFn: func(_ *proto.View) bool {
+				return false
+			},
+		}
+
+		recorder = &multicastRecorder{}
+	)
+
+	i := NewIBFT(mockLogger{}, backend, recorder)
+	i.state.setView(view)
+
+	i.startRound(context.Background())
+
+	// Make sure the unavailability is announced instead of the proposal
+	multicasted := recorder.messages()
+	if assert.Len(t, multicasted, 1) {
+		assert.Equal(t, proto.MessageType_PROPOSER_UNAVAILABLE, multicasted[0].Type)
+		assert.Equal(t, view, multicasted[0].View)
+	}
+
+	// Make sure the local round is shortened as well
+	assert.Len(t, i.unavailableProposer, 1)
+}
+
+func TestIBFT_AddMessage_UnavailableProposer(t *testing.T) {
+	t.Parallel()
+
+	var (
+		proposer = []byte("proposer")
+		view     = &proto.View{Height: 1, Round: 2}
+	)
+
+	testTable := []struct {
+		name     string
+		from     []byte
+		round    uint64
+		shortens bool
+	}{
+		{
+			"current proposer",
+			proposer,
+			view.Round,
+			true,
+		},
+		{
+			"not the proposer",
+			[]byte("validator"),
+			view.Round,
+			false,
+		},
+		{
+			"future round",
+			proposer,
+			view.Round + 1,
+			false,
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			backend := mockBackend{
+				isProposerFn: func(from []byte, _, _ uint64) bool {
+					return bytes.Equal(from, proposer)
+				},
+			}
+
+			i := NewIBFT(mockLogger{}, backend, mockTransport{})
+			i.state.setView(view)
+
+			sub := i.SubscribeEvents()
+			defer i.UnsubscribeEvents(sub.ID)
+
+			i.AddMessage(&proto.Message{
+				View: &proto.View{Height: view.Height, Round: testCase.round},
+				From: testCase.from,
+				Type: proto.MessageType_PROPOSER_UNAVAILABLE,
+			})
+
+			// Make sure the announcement is not stored
+			assert.Empty(t, i.messages.ViewCounts())
+
+			if !testCase.shortens {
+				assert.Len(t, i.unavailableProposer, 0)
+				assert.Len(t, sub.EventCh, 0)
+
+				return
+			}
+
+			assert.Len(t, i.unavailableProposer, 1)
+
+			if assert.Len(t, sub.EventCh, 1) {
+				event := <-sub.EventCh
+
+				assert.Equal(t, EventProposerUnavailable, event.Type)
+				assert.Equal(t, proposer, event.Data)
+			}
+		})
+	}
+}
+
+func TestIBFT_WatchForUnavailableProposer(t *testing.T) {
+	t.Parallel()
+
+	view := &proto.View{Height: 1, Round: 2}
+
+	i := NewIBFT(
+		mockLogger{},
+		mockBackend{},
+		mockTransport{},
+		WithUnavailableProposerTimeout(10*time.Millisecond),
+	)
+	i.state.setView(view)
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+
+	group := newWorkerGroup(ctx)
+
+	group.spawn("unavailable proposer", i.watchForUnavailableProposer)
+
+	// Make sure stale announcements are ignored
+	i.signalUnavailableProposer(&proto.View{Height: view.Height, Round: view.Round - 1})
+
+	select {
+	case <-i.roundEvents:
+		t.Fatal("round expired on a stale announcement")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Make sure the round expires after the shortened timeout
+	i.signalUnavailableProposer(view)
+
+	select {
+	case <-i.roundEvents:
+	case <-time.After(5 * time.Second):
+		t.Fatal("round not expired")
+	}
+
+	assert.NoError(t, group.wait())
+}
+
+func TestIBFT_WatchForUnavailableProposer_RoundTimer(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view  = &proto.View{Height: 1, Round: 2}
+		timer = &signalTimer{
+			views:   make(chan *proto.View, 1),
+			expires: make(chan struct{}),
+		}
+	)
+
+	i := NewIBFT(
+		mockLogger{},
+		mockBackend{},
+		mockTransport{},
+		WithRoundTimer(timer),
+		WithUnavailableProposerTimeout(time.Millisecond),
+	)
+	i.state.setView(view)
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+
+	group := newWorkerGroup(ctx)
+
+	group.spawn("unavailable proposer", i.watchForUnavailableProposer)
+
+	i.signalUnavailableProposer(view)
+
+	// Make sure the shortened round is expired by the round timer
+	timerView := <-timer.views
+	assert.Equal(t, view.Height, timerView.Height)
+	assert.Equal(t, view.Round, timerView.Round)
+
+	select {
+	case <-i.roundEvents:
+		t.Fatal("round expired on the wall clock")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(timer.expires)
+
+	select {
+	case <-i.roundEvents:
+	case <-time.After(5 * time.Second):
+		t.Fatal("round not expired")
+	}
+
+	assert.NoError(t, group.wait())
+}
@@ -0,0 +1,44 @@
+package core
+
+import "github.com/renloi/ibft/messages/proto"
+
+// senderTrust is how the sender of an incoming message is already authenticated
+type senderTrust uint8
+
+const (
+	// untrustedSender is the sender authenticated only by the message signature,
+	// which is verified by the engine
+	untrustedSender senderTrust = iota
+
+	// authenticatedSender is the sender authenticated by the message envelope
+	// (see WithEnvelopeVerifier)
+	authenticatedSender
+
+	// verifiedSender is the sender of a message whose signature
+	// was verified by the network layer (see AddVerifiedMessage)
+	verifiedSender
+)
+
+// AddVerifiedMessage adds a new message to the IBFT message system, as AddMessage does,
+// for a message the network layer already verified (ex. during gossip validation)
+// as IsValidValidator would: signed by its sender, which is a validator at the height.
+// The engine then skips the redundant signature verification of the message.
+// The messages relayed in its certificates are still verified by the engine,
+// as are the messages of validators that rotated their key (see RotateKey).
+// The trust can be disabled with WithoutVerifiedMessageTrust, for defense in depth
+func (i *IBFT) AddVerifiedMessage(message *proto.Message) {
+	i.addMessage(message, verifiedSender, nil)
+}
+
+// isTrustedVerification checks if the verification of the message
+// by the network layer can be trusted
+func (i *IBFT) isTrustedVerification(msg *proto.Message) bool {
+	if !i.trustVerifiedMessages || msg.View == nil {
+		return false
+	}
+
+	// The network layer is not aware of the rotated keys
+	_, rotated := i.keys.keyAt(msg.From, msg.View.Height)
+
+	return !rotated
+}
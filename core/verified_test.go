@@ -0,0 +1,103 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// TestIBFT_AddVerifiedMessage makes sure the signature verification is skipped
+// for the messages verified by the network layer, unless the trust is disabled
+// or the sender rotated its key
+func TestIBFT_AddVerifiedMessage(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view    = &proto.View{Height: 2, Round: 0}
+		sender  = []byte("node 1")
+		rotated = []byte("node 2")
+	)
+
+	testTable := []struct {
+		name     string
+		opts     []Option
+		sender   []byte
+		verified bool
+		accepted bool
+	}{
+		{
+			"verified message is trusted",
+			nil,
+			sender,
+			true,
+			true,
+		},
+		{
+			"unverified message is verified by the engine",
+			nil,
+			sender,
+			false,
+			false,
+		},
+		{
+			"verified message is verified by the engine without the trust",
+			[]Option{WithoutVerifiedMessageTrust()},
+			sender,
+			true,
+			false,
+		},
+		{
+			"verified message of a rotated key is verified by the engine",
+			nil,
+			rotated,
+			true,
+			false,
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			validations := 0
+
+			reject := func(_ *proto.Message) bool {
+				validations++
+
+				return false
+			}
+
+			backend := keyBackend{
+				mockBackend: mockBackend{
+					IsValidValidatorFn: reject,
+				},
+				isValidValidatorKeyFn: func(message *proto.Message, _ []byte) bool {
+					return reject(message)
+				},
+			}
+
+			i := NewIBFT(mockLogger{}, backend, mockTransport{}, testCase.opts...)
+			i.state.setView(&proto.View{Height: 1, Round: 0})
+
+			assert.NoError(t, i.RotateKey(rotated, []byte("new key"), view.Height))
+
+			message := buildBasicPrepareMessage([]byte("hash"), testCase.sender, view)
+
+			if testCase.verified {
+				i.AddVerifiedMessage(message)
+			} else {
+				i.AddMessage(message)
+			}
+
+			received, _ := i.GetMessages(view, proto.MessageType_PREPARE, 0)
+			assert.Equal(t, testCase.accepted, len(received) == 1)
+
+			// Make sure the trusted messages are not verified again
+			assert.Equal(t, testCase.accepted, validations == 0)
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package core
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// sendVeto multicasts the VETO message rejecting the invalid proposal of the round
+func (i *IBFT) sendVeto(ctx context.Context, view *proto.View, proposalMessage *proto.Message) {
+	builder, ok := i.backend.(VetoBuilder)
+	if !ok || i.isObserving() {
+		return
+	}
+
+	i.log.Info("vetoing invalid proposal", "height", view.Height, "round", view.Round)
+
+	i.multicastAndRecord(ctx, builder.BuildVetoMessage(extractProposalHash(proposalMessage), view))
+}
+
+// hasVetoed checks if the node vetoed the proposal of the view. A node that vetoed
+// the proposal never prepares the round, so the vetoes and the PREPARE
+// messages of a round cannot both reach quorum
+func (i *IBFT) hasVetoed(view *proto.View) bool {
+	lastSent := i.state.getLastSent()
+
+	return lastSent != nil &&
+		lastSent.Type == proto.MessageType_VETO &&
+		lastSent.View.Height == view.Height &&
+		lastSent.View.Round == view.Round
+}
+
+// watchForVetoes moves the node to the next round once the proposal of the round
+// is rejected by a rejection quorum of validators, which means the round
+// cannot reach the PREPARE quorum, so it is not worth waiting for the timeout
+func (i *IBFT) watchForVetoes(ctx context.Context) {
+	var (
+		view = i.state.getView()
+
+		sub = i.messages.Subscribe(
+			ctx,
+			messages.SubscriptionDetails{
+				MessageType: proto.MessageType_VETO,
+				View:        view,
+				HasQuorumFn: i.quorum.HasQuorum,
+			},
+		)
+	)
+
+	for {
+		if vetoes := i.handleVetoes(view); vetoes != nil {
+			vetoers := make([][]byte, 0, len(vetoes))
+			for _, veto := range vetoes {
+				vetoers = append(vetoers, veto.From)
+			}
+
+			i.log.Info("proposal vetoed, skipping round", "height", view.Height, "round", view.Round)
+			i.emitEvent(EventVetoQuorum, view, VetoQuorumData{Vetoers: vetoers})
+
+			i.signalRoundEvent(ctx, roundEvent{eventType: roundEventVeto})
+
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-sub.SubCh:
+			if !ok {
+				// The subscription was removed, exit
+				return
+			}
+		}
+	}
+}
+
+// handleVetoes returns the VETO messages of the view,
+// if they reach the rejection quorum for the same proposal
+func (i *IBFT) handleVetoes(view *proto.View) []*proto.Message {
+	proposalHash := i.vetoedProposalHash(view)
+
+	isValidVeto := func(message *proto.Message) bool {
+		vetoData, err := messages.ExtractPayload[*proto.VetoMessage](message)
+		if err != nil {
+			i.log.Debug("malformed veto message", "err", err)
+
+			return false
+		}
+
+		// The vetoes of other proposals (ex. of an equivocating proposer) are not counted
+		if proposalHash != nil && !bytes.Equal(vetoData.ProposalHash, proposalHash) {
+			return false
+		}
+
+		// The proposer does not reject its own proposal
+		return !i.isProposer(message.From, view.Height, view.Round)
+	}
+
+	vetoes := i.messages.GetValidMessages(view, proto.MessageType_VETO, isValidVeto)
+
+	if proposalHash != nil {
+		if !i.quorum.HasQuorum(view.Height, vetoes, proto.MessageType_VETO) {
+			return nil
+		}
+
+		return vetoes
+	}
+
+	// The proposal is not known to the node, so the vetoes
+	// must reach the quorum for any single proposal
+	byProposal := make(map[string][]*proto.Message)
+
+	for _, veto := range vetoes {
+		vetoData, _ := messages.ExtractPayload[*proto.VetoMessage](veto)
+		byProposal[string(vetoData.ProposalHash)] = append(byProposal[string(vetoData.ProposalHash)], veto)
+	}
+
+	for _, proposalVetoes := range byProposal {
+		if i.quorum.HasQuorum(view.Height, proposalVetoes, proto.MessageType_VETO) {
+			return proposalVetoes
+		}
+	}
+
+	return nil
+}
+
+// vetoedProposalHash returns the hash of the proposal of the view the counted VETO
+// messages must reject: the proposal accepted by the node, or the proposal it vetoed.
+// Returns nil if the node has not received the proposal of the view
+func (i *IBFT) vetoedProposalHash(view *proto.View) []byte {
+	if i.state.hasProposalMessage() {
+		return i.state.getProposalHash()
+	}
+
+	if !i.hasVetoed(view) {
+		return nil
+	}
+
+	vetoData, err := messages.ExtractPayload[*proto.VetoMessage](i.state.getLastSent())
+	if err != nil {
+		return nil
+	}
+
+	return vetoData.ProposalHash
+}
+
+// isVetoable checks if the invalid proposal message can be vetoed by the node.
+// Only the proposals of the round proposer are vetoed, the invalid messages
+// of the other validators are not proposals of the round
+func (i *IBFT) isVetoable(message *proto.Message, view *proto.View) bool {
+	return i.vetoes &&
+		!bytes.Equal(message.From, i.backend.ID()) &&
+		i.isProposer(message.From, view.Height, view.Round)
+}
@@ -0,0 +1,246 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// vetoBackend is a mock backend that builds VETO messages
+type vetoBackend struct {
+	mockBackend
+}
+
+func (b vetoBackend) BuildVetoMessage(proposalHash []byte, view *proto.View) *proto.Message {
+	return buildBasicVetoMessage(proposalHash, b.ID(), view)
+}
+
+// buildBasicVetoMessage builds a VETO message of the sender
+func buildBasicVetoMessage(proposalHash, from []byte, view *proto.View) *proto.Message {
+	return &proto.Message{
+		View: view,
+		From: from,
+		Type: proto.MessageType_VETO,
+		Payload: &proto.Message_VetoData{
+			VetoData: &proto.VetoMessage{
+				ProposalHash: proposalHash,
+			},
+		},
+	}
+}
+
+// TestIBFT_RunPrePrepare_Veto makes sure the invalid proposals of the round proposer
+// are vetoed instead of prepared, only if the vetoes are enabled
+func TestIBFT_RunPrePrepare_Veto(t *testing.T) {
+	t.Parallel()
+
+	var (
+		id       = []byte("node")
+		proposer = []byte("proposer")
+		view     = &proto.View{Height: 1, Round: 0}
+	)
+
+	testTable := []struct {
+		name   string
+		from   []byte
+		opts   []Option
+		vetoed bool
+	}{
+		{"proposal of the proposer", proposer, []Option{WithVetoes()}, true},
+		{"proposal of another validator", []byte("validator"), []Option{WithVetoes()}, false},
+		{"vetoes disabled", proposer, nil, false},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				backend = vetoBackend{
+					mockBackend: mockBackend{
+						idFn: func() []byte {
+							return id
+						},
+						isProposerFn: func(from []byte, _, _ uint64) bool {
+							return bytes.Equal(from, proposer)
+						},
+						isValidProposalFn: func(_ []byte) bool {
+							return false
+						},
+					},
+				}
+
+				recorder = &multicastRecorder{}
+			)
+
+			i := NewIBFT(mockLogger{}, backend, recorder, testCase.opts...)
+			i.state.setView(view)
+
+			i.messages.AddMessage(buildBasicPreprepareMessage(
+				correctRoundMessage.proposal.GetRawProposal(),
+				correctRoundMessage.hash,
+				nil,
+				testCase.from,
+				view,
+			))
+
+			ctx, cancelFn := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancelFn()
+
+			i.runPrePrepare(ctx)
+
+			multicasted := recorder.messages()
+
+			if !testCase.vetoed {
+				assert.Empty(t, multicasted)
+				assert.False(t, i.hasVetoed(view))
+
+				return
+			}
+
+			// Make sure the proposal is vetoed, and is never prepared
+			require.Len(t, multicasted, 1)
+			assert.Equal(t, proto.MessageType_VETO, multicasted[0].Type)
+			assert.Equal(t, id, multicasted[0].From)
+			assert.Equal(t, correctRoundMessage.hash, multicasted[0].GetVetoData().GetProposalHash())
+			assert.True(t, i.hasVetoed(view))
+			assert.False(t, i.state.hasProposalMessage())
+		})
+	}
+}
+
+// TestIBFT_RunSequence_VetoQuorum makes sure the node moves to the next round
+// once the vetoes of the round proposal reach the rejection quorum
+func TestIBFT_RunSequence_VetoQuorum(t *testing.T) {
+	t.Parallel()
+
+	var (
+		nodes = generateNodeAddresses(4)
+		view  = &proto.View{Height: 1, Round: 0}
+
+		backend = vetoBackend{
+			mockBackend: mockBackend{
+				idFn: func() []byte {
+					return nodes[3]
+				},
+				isProposerFn: func(from []byte, _, round uint64) bool {
+					return bytes.Equal(from, nodes[round%4])
+				},
+			},
+		}
+
+		verifier = CountQuorum{
+			ValidatorCount: func(_ uint64) uint64 {
+				return 4
+			},
+		}
+	)
+
+	i := NewIBFT(mockLogger{}, backend, mockTransport{}, WithVetoes(), WithQuorumVerifier(verifier))
+
+	sub := i.SubscribeEvents()
+	defer i.UnsubscribeEvents(sub.ID)
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+
+	go i.RunSequence(ctx, 1)
+
+	// Wait for the node to start working on the height
+	for event := range sub.EventCh {
+		if event.Type == EventRoundStarted {
+			break
+		}
+	}
+
+	// The vetoes of the proposer are not counted
+	i.AddMessage(buildBasicVetoMessage(correctRoundMessage.hash, nodes[0], view))
+	i.AddMessage(buildBasicVetoMessage(correctRoundMessage.hash, nodes[1], view))
+
+	// Make sure a single veto does not skip the round
+	assert.Never(t, func() bool {
+		return i.state.getRound() != 0
+	}, 100*time.Millisecond, 10*time.Millisecond)
+
+	i.AddMessage(buildBasicVetoMessage(correctRoundMessage.hash, nodes[2], view))
+
+	var vetoQuorum, roundChange Event
+
+	for event := range sub.EventCh {
+		if event.Type == EventVetoQuorum {
+			vetoQuorum = event
+		}
+
+		if event.Type == EventRoundChange {
+			roundChange = event
+
+			break
+		}
+	}
+
+	// Make sure the vetoes skip the round
+	assert.Equal(t, RoundChangeData{PreviousRound: 0, Reason: RoundChangeVeto}, roundChange.Data)
+
+	require.Equal(t, EventVetoQuorum, vetoQuorum.Type)
+	assert.Equal(t, view, vetoQuorum.View)
+	assert.ElementsMatch(t, [][]byte{nodes[1], nodes[2]}, vetoQuorum.Data.(VetoQuorumData).Vetoers)
+}
+
+// TestIBFT_HandleVetoes makes sure only the vetoes
+// rejecting the same proposal reach the rejection quorum
+func TestIBFT_HandleVetoes(t *testing.T) {
+	t.Parallel()
+
+	var (
+		nodes = generateNodeAddresses(4)
+		view  = &proto.View{Height: 1, Round: 0}
+
+		backend = mockBackend{
+			isProposerFn: func(from []byte, _, _ uint64) bool {
+				return bytes.Equal(from, nodes[0])
+			},
+		}
+
+		verifier = CountQuorum{
+			ValidatorCount: func(_ uint64) uint64 {
+				return 4
+			},
+		}
+	)
+
+	newIBFT := func(vetoedHashes ...[]byte) *IBFT {
+		i := NewIBFT(mockLogger{}, backend, mockTransport{}, WithVetoes(), WithQuorumVerifier(verifier))
+		i.state.setView(view)
+
+		for index, hash := range vetoedHashes {
+			i.messages.AddMessage(buildBasicVetoMessage(hash, nodes[index+1], view))
+		}
+
+		return i
+	}
+
+	var (
+		vetoed = []byte("vetoed proposal")
+		other  = []byte("other proposal")
+	)
+
+	// Make sure the vetoes of the same proposal reach the quorum
+	assert.Len(t, newIBFT(vetoed, vetoed).handleVetoes(view), 2)
+
+	// Make sure the vetoes of different proposals are not counted together
+	assert.Nil(t, newIBFT(vetoed, other).handleVetoes(view))
+
+	// Make sure only the vetoes of the proposal accepted by the node are counted
+	i := newIBFT(vetoed, vetoed, other)
+	i.state.setProposalMessage(buildBasicPreprepareMessage(nil, other, nil, nodes[0], view))
+
+	assert.Nil(t, i.handleVetoes(view))
+}
@@ -0,0 +1,37 @@
+package core
+
+import "sync"
+
+// wakeup is a broadcast signal for the receptions, woken by the state changes
+// that are not message events (ex. the accepted proposal). A reception grabs
+// the channel before checking the state, so a change made while it checks
+// wakes it once it starts waiting
+type wakeup struct {
+	sync.Mutex
+
+	ch chan struct{}
+}
+
+// newWakeup creates a new wakeup signal
+func newWakeup() *wakeup {
+	return &wakeup{
+		ch: make(chan struct{}),
+	}
+}
+
+// wait returns the channel closed on the next broadcast
+func (w *wakeup) wait() <-chan struct{} {
+	w.Lock()
+	defer w.Unlock()
+
+	return w.ch
+}
+
+// broadcast wakes all the receptions waiting on the signal
+func (w *wakeup) broadcast() {
+	w.Lock()
+	defer w.Unlock()
+
+	close(w.ch)
+	w.ch = make(chan struct{})
+}
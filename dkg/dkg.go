@@ -0,0 +1,509 @@
+// Package dkg defines a sub-module for coordinating distributed key generation
+// ceremonies among the validator set, over the IBFT message store and transport
+package dkg
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/renloi/ibft/core"
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+var (
+	// ErrNotParticipant is an error indicating the node
+	// is not a participant of the ceremony
+	ErrNotParticipant = errors.New("node is not a ceremony participant")
+
+	// ErrInvalidDeal is an error indicating the deal of the node
+	// does not have a share for each participant
+	ErrInvalidDeal = errors.New("invalid deal")
+
+	// ErrNotEnoughDealers is an error indicating too few dealers qualified,
+	// so the key could be known to the faulty participants
+	ErrNotEnoughDealers = errors.New("not enough qualified dealers")
+
+	// ErrMissingShare is an error indicating the node did not receive
+	// a valid share from a dealer qualified by the other participants
+	ErrMissingShare = errors.New("missing share of a qualified dealer")
+
+	// ErrNoAgreement is an error indicating a quorum of the participants
+	// did not agree on the group public key before the phase timeout
+	ErrNoAgreement = errors.New("no agreement on the group public key")
+
+	// ErrPublicKeyMismatch is an error indicating the group public key derived
+	// by the node differs from the one agreed by a quorum of the participants
+	ErrPublicKeyMismatch = errors.New("group public key differs from the agreed one")
+)
+
+const (
+	// defaultPhaseTimeout is the default time each ceremony phase waits for the participants
+	defaultPhaseTimeout = 30 * time.Second
+)
+
+// Phase is the phase of the ceremony, carried as the round of the message view
+type Phase uint64
+
+const (
+	// PhaseDeal is the phase in which each dealer distributes
+	// the encrypted shares of its secret, and the commitments to them
+	PhaseDeal Phase = iota
+
+	// PhaseComplaint is the phase in which each participant complains
+	// about the dealers whose shares are missing or invalid
+	PhaseComplaint
+
+	// PhaseFinalization is the phase in which each participant announces
+	// the group public key, derived from the deals of the qualified dealers
+	PhaseFinalization
+)
+
+// String returns the human-readable phase
+func (p Phase) String() string {
+	switch p {
+	case PhaseDeal:
+		return "deal"
+	case PhaseComplaint:
+		return "complaint"
+	case PhaseFinalization:
+		return "finalization"
+	}
+
+	return "unknown"
+}
+
+// Deal is the deal of a dealer, as received by the node
+type Deal struct {
+	// Dealer is the ID of the dealer
+	Dealer []byte
+
+	// Commitments are the public commitments to the polynomial of the dealer
+	Commitments []byte
+
+	// Share is the encrypted share of the dealer for the node
+	Share []byte
+}
+
+// Backend is the cryptographic backend of the ceremony
+// (ex. Feldman or Pedersen VSS for BLS keys)
+type Backend interface {
+	// ID returns the validator's ID
+	ID() []byte
+
+	// BuildMessage builds a signed DKG message for the view, with the payload
+	BuildMessage(view *proto.View, payload *proto.DKGMessage) *proto.Message
+
+	// IsValidMessage checks if the DKG message is signed by its sender
+	IsValidMessage(message *proto.Message) bool
+
+	// Deal generates the secret of the node, and returns the commitments to it,
+	// and the encrypted shares for each participant, in the participant order
+	Deal(ceremony uint64, participants [][]byte) ([]byte, [][]byte, error)
+
+	// VerifyShare decrypts the share the dealer dealt to the node,
+	// and checks it against the commitments of the dealer
+	VerifyShare(ceremony uint64, deal Deal) bool
+
+	// Finalize derives the key share of the node from the deals
+	// of the qualified dealers, and returns the group public key.
+	// The backend keeps the key share for threshold signing
+	Finalize(ceremony uint64, deals []Deal) ([]byte, error)
+}
+
+// Result is the outcome of a successful ceremony
+type Result struct {
+	// PublicKey is the group public key agreed by a quorum of the participants
+	PublicKey []byte
+
+	// Qualified are the dealers whose deals form the key, in the participant order
+	Qualified [][]byte
+}
+
+// options are the optional ceremony settings
+type options struct {
+	// phaseTimeout is the time each phase waits for the participants
+	phaseTimeout time.Duration
+}
+
+// Option is the optional ceremony configuration setter
+type Option func(*options)
+
+// WithPhaseTimeout sets the time each ceremony phase waits for the participants.
+// The deal and complaint phases end early once all the participants sent their messages
+func WithPhaseTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.phaseTimeout = timeout
+	}
+}
+
+// Ceremony coordinates a single distributed key generation ceremony.
+// The ceremony messages are kept in a dedicated message store, with the
+// ceremony ID as the view height, and the phase as the view round
+type Ceremony struct {
+	log       core.Logger
+	backend   Backend
+	transport core.Transport
+	messages  *messages.Messages
+
+	// id is the ceremony ID
+	id uint64
+
+	// participants are the IDs of the participants, usually the validator set
+	participants [][]byte
+
+	// indexes are the participant indexes, by ID
+	indexes map[string]int
+
+	// phaseTimeout is the time each phase waits for the participants
+	phaseTimeout time.Duration
+}
+
+// NewCeremony creates a new ceremony among the participants
+func NewCeremony(
+	log core.Logger,
+	backend Backend,
+	transport core.Transport,
+	id uint64,
+	participants [][]byte,
+	opts ...Option,
+) *Ceremony {
+	o := &options{
+		phaseTimeout: defaultPhaseTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	indexes := make(map[string]int, len(participants))
+	for index, participant := range participants {
+		indexes[string(participant)] = index
+	}
+
+	return &Ceremony{
+		log:          log,
+		backend:      backend,
+		transport:    transport,
+		messages:     messages.NewMessages(),
+		id:           id,
+		participants: participants,
+		indexes:      indexes,
+		phaseTimeout: o.phaseTimeout,
+	}
+}
+
+// AddMessage adds a DKG message received from the network.
+// Messages of other ceremonies, or from non-participants, are discarded
+func (c *Ceremony) AddMessage(message *proto.Message) {
+	if !c.isAcceptableMessage(message) {
+		return
+	}
+
+	c.messages.AddMessage(message)
+}
+
+// isAcceptableMessage checks if the message belongs to the ceremony
+func (c *Ceremony) isAcceptableMessage(message *proto.Message) bool {
+	if message == nil || message.Type != proto.MessageType_DKG || message.View == nil {
+		return false
+	}
+
+	if message.View.Height != c.id || Phase(message.View.Round) > PhaseFinalization {
+		return false
+	}
+
+	if _, ok := c.indexes[string(message.From)]; !ok {
+		return false
+	}
+
+	if _, err := messages.ExtractPayload[*proto.DKGMessage](message); err != nil {
+		return false
+	}
+
+	return c.backend.IsValidMessage(message)
+}
+
+// Run runs the ceremony phases, and returns the group public key once
+// a quorum of the participants agrees on it. Dealers complained about
+// by more than the tolerated number of faulty participants are disqualified.
+// There is no justification phase, so a node that did not receive a valid share
+// from a qualified dealer fails the ceremony with ErrMissingShare.
+// The ceremony message store is closed once Run returns, so a ceremony runs only once
+func (c *Ceremony) Run(ctx context.Context) (*Result, error) {
+	defer c.messages.Close()
+
+	self, ok := c.indexes[string(c.backend.ID())]
+	if !ok {
+		return nil, ErrNotParticipant
+	}
+
+	// Deal phase
+	commitments, shares, err := c.backend.Deal(c.id, c.participants)
+	if err != nil {
+		return nil, fmt.Errorf("unable to deal: %w", err)
+	}
+
+	if len(shares) != len(c.participants) {
+		return nil, ErrInvalidDeal
+	}
+
+	c.multicast(PhaseDeal, &proto.DKGMessage{
+		Commitments: commitments,
+		Shares:      shares,
+	})
+
+	dealMessages, err := c.awaitPhase(ctx, PhaseDeal, c.allSent)
+	if err != nil {
+		return nil, err
+	}
+
+	// Complaint phase
+	deals, complaints := c.verifyDeals(self, dealMessages)
+
+	c.multicast(PhaseComplaint, &proto.DKGMessage{
+		Complaints: complaints,
+	})
+
+	complaintMessages, err := c.awaitPhase(ctx, PhaseComplaint, c.allSent)
+	if err != nil {
+		return nil, err
+	}
+
+	// Finalization phase
+	qualified := c.qualifiedDealers(dealMessages, complaintMessages)
+	if uint64(len(qualified)) <= core.MaxFaulty(uint64(len(c.participants))) {
+		return nil, ErrNotEnoughDealers
+	}
+
+	qualifiedDeals := make([]Deal, 0, len(qualified))
+
+	for _, dealer := range qualified {
+		deal, ok := deals[string(dealer)]
+		if !ok {
+			return nil, ErrMissingShare
+		}
+
+		qualifiedDeals = append(qualifiedDeals, deal)
+	}
+
+	publicKey, err := c.backend.Finalize(c.id, qualifiedDeals)
+	if err != nil {
+		return nil, fmt.Errorf("unable to finalize: %w", err)
+	}
+
+	c.multicast(PhaseFinalization, &proto.DKGMessage{
+		PublicKey: publicKey,
+	})
+
+	finalizationMessages, err := c.awaitPhase(ctx, PhaseFinalization, func(msgs []*proto.Message) bool {
+		return c.agreedPublicKey(msgs) != nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	agreed := c.agreedPublicKey(finalizationMessages)
+	if agreed == nil {
+		return nil, ErrNoAgreement
+	}
+
+	if !bytes.Equal(agreed, publicKey) {
+		return nil, ErrPublicKeyMismatch
+	}
+
+	c.log.Info("DKG ceremony finalized", "ceremony", c.id, "qualified", len(qualified))
+
+	return &Result{
+		PublicKey: publicKey,
+		Qualified: qualified,
+	}, nil
+}
+
+// multicast builds the message of the node for the phase, and multicasts it.
+// The message is also added to the store directly, so the transport
+// does not need to deliver it back to the node
+func (c *Ceremony) multicast(phase Phase, payload *proto.DKGMessage) {
+	message := c.backend.BuildMessage(c.view(phase), payload)
+
+	c.messages.AddMessage(message)
+
+	if err := c.transport.Multicast(message); err != nil {
+		c.log.Error("unable to multicast DKG message", "ceremony", c.id, "phase", phase.String(), "err", err)
+	}
+}
+
+// view returns the message view of the phase
+func (c *Ceremony) view(phase Phase) *proto.View {
+	return &proto.View{
+		Height: c.id,
+		Round:  uint64(phase),
+	}
+}
+
+// awaitPhase waits until the phase messages satisfy the condition, or the phase times out,
+// and returns the phase messages. An error is returned only if the context is cancelled
+func (c *Ceremony) awaitPhase(
+	ctx context.Context,
+	phase Phase,
+	isDone func(msgs []*proto.Message) bool,
+) ([]*proto.Message, error) {
+	var (
+		view    = c.view(phase)
+		timeout = time.NewTimer(c.phaseTimeout)
+	)
+
+	defer timeout.Stop()
+
+	subCtx, cancelFn := context.WithCancel(ctx)
+	defer cancelFn()
+
+	sub := c.messages.Subscribe(subCtx, messages.SubscriptionDetails{
+		MessageType: proto.MessageType_DKG,
+		View:        view,
+		HasQuorumFn: func(_ uint64, msgs []*proto.Message, _ proto.MessageType) bool {
+			return isDone(msgs)
+		},
+	})
+
+	for {
+		msgs := c.messages.GetValidMessages(view, proto.MessageType_DKG, func(_ *proto.Message) bool {
+			return true
+		})
+
+		if isDone(msgs) {
+			return msgs, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timeout.C:
+			c.log.Debug("DKG phase timed out", "ceremony", c.id, "phase", phase.String(), "messages", len(msgs))
+
+			return msgs, nil
+		case <-sub.SubCh:
+		}
+	}
+}
+
+// allSent checks if all the participants sent their phase message
+func (c *Ceremony) allSent(msgs []*proto.Message) bool {
+	return len(msgs) == len(c.participants)
+}
+
+// verifyDeals verifies the shares dealt to the node, and returns the valid deals,
+// by dealer, and the dealers whose shares are invalid
+func (c *Ceremony) verifyDeals(self int, dealMessages []*proto.Message) (map[string]Deal, [][]byte) {
+	var (
+		deals      = make(map[string]Deal, len(dealMessages))
+		complaints = make([][]byte, 0)
+	)
+
+	for _, message := range dealMessages {
+		payload, _ := messages.ExtractPayload[*proto.DKGMessage](message)
+
+		if len(payload.GetShares()) != len(c.participants) {
+			complaints = append(complaints, message.From)
+
+			continue
+		}
+
+		deal := Deal{
+			Dealer:      message.From,
+			Commitments: payload.GetCommitments(),
+			Share:       payload.GetShares()[self],
+		}
+
+		if !c.backend.VerifyShare(c.id, deal) {
+			c.log.Debug("invalid DKG share", "ceremony", c.id, "dealer", message.From)
+
+			complaints = append(complaints, message.From)
+
+			continue
+		}
+
+		deals[string(message.From)] = deal
+	}
+
+	return deals, complaints
+}
+
+// qualifiedDealers returns the dealers that dealt in the deal phase, and were
+// complained about by at most the tolerated number of faulty participants,
+// in the participant order. A dealer with more complaints was complained about
+// by at least one honest participant
+func (c *Ceremony) qualifiedDealers(dealMessages, complaintMessages []*proto.Message) [][]byte {
+	complaints := make(map[string]uint64)
+
+	for _, message := range complaintMessages {
+		payload, _ := messages.ExtractPayload[*proto.DKGMessage](message)
+
+		// A dealer is counted once per complaining participant
+		seen := make(map[string]struct{})
+
+		for _, dealer := range payload.GetComplaints() {
+			if _, ok := seen[string(dealer)]; ok {
+				continue
+			}
+
+			seen[string(dealer)] = struct{}{}
+			complaints[string(dealer)]++
+		}
+	}
+
+	var (
+		maxFaulty = core.MaxFaulty(uint64(len(c.participants)))
+		dealt     = make(map[string]struct{}, len(dealMessages))
+		qualified = make([][]byte, 0, len(dealMessages))
+	)
+
+	for _, message := range dealMessages {
+		dealt[string(message.From)] = struct{}{}
+	}
+
+	for _, participant := range c.participants {
+		if _, ok := dealt[string(participant)]; !ok {
+			continue
+		}
+
+		if complaints[string(participant)] > maxFaulty {
+			c.log.Info("DKG dealer disqualified", "ceremony", c.id, "dealer", participant)
+
+			continue
+		}
+
+		qualified = append(qualified, participant)
+	}
+
+	return qualified
+}
+
+// agreedPublicKey returns the group public key announced
+// by a quorum of the participants, if any
+func (c *Ceremony) agreedPublicKey(finalizationMessages []*proto.Message) []byte {
+	var (
+		quorum = core.QuorumSize(uint64(len(c.participants)))
+		votes  = make(map[string]uint64)
+	)
+
+	for _, message := range finalizationMessages {
+		payload, _ := messages.ExtractPayload[*proto.DKGMessage](message)
+
+		publicKey := payload.GetPublicKey()
+		if len(publicKey) == 0 {
+			continue
+		}
+
+		votes[string(publicKey)]++
+
+		if votes[string(publicKey)] >= quorum {
+			return publicKey
+		}
+	}
+
+	return nil
+}
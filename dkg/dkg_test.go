@@ -0,0 +1,380 @@
+package dkg
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+func TestMain(m *testing.M) {
+	goleak.VerifyTestMain(m)
+}
+
+type mockLogger struct{}
+
+func (mockLogger) Info(_ string, _ ...interface{}) {}
+
+func (mockLogger) Debug(_ string, _ ...interface{}) {}
+
+func (mockLogger) Error(_ string, _ ...interface{}) {}
+
+// mockBackend is a toy DKG backend. The commitments of a dealer are its ID,
+// the share for a participant is derived from the dealer and the participant index,
+// and the group public key is the hash of the qualified dealers
+type mockBackend struct {
+	id    []byte
+	index int
+
+	// corruptShareFn marks the participants the node deals invalid shares to
+	corruptShareFn func(participant int) bool
+}
+
+func (b mockBackend) ID() []byte {
+	return b.id
+}
+
+func (b mockBackend) BuildMessage(view *proto.View, payload *proto.DKGMessage) *proto.Message {
+	return &proto.Message{
+		View:      view,
+		From:      b.id,
+		Signature: signature(b.id),
+		Type:      proto.MessageType_DKG,
+		Payload: &proto.Message_DkgData{
+			DkgData: payload,
+		},
+	}
+}
+
+func (b mockBackend) IsValidMessage(message *proto.Message) bool {
+	return bytes.Equal(message.Signature, signature(message.From))
+}
+
+func (b mockBackend) Deal(_ uint64, participants [][]byte) ([]byte, [][]byte, error) {
+	shares := make([][]byte, 0, len(participants))
+
+	for index := range participants {
+		share := dealtShare(b.id, index)
+		if b.corruptShareFn != nil && b.corruptShareFn(index) {
+			share = []byte("corrupt share")
+		}
+
+		shares = append(shares, share)
+	}
+
+	return b.id, shares, nil
+}
+
+func (b mockBackend) VerifyShare(_ uint64, deal Deal) bool {
+	return bytes.Equal(deal.Commitments, deal.Dealer) &&
+		bytes.Equal(deal.Share, dealtShare(deal.Dealer, b.index))
+}
+
+func (b mockBackend) Finalize(_ uint64, deals []Deal) ([]byte, error) {
+	hash := sha256.New()
+
+	for _, deal := range deals {
+		hash.Write(deal.Commitments)
+	}
+
+	return hash.Sum(nil), nil
+}
+
+// signature returns the valid signature of the sender
+func signature(sender []byte) []byte {
+	return append([]byte("signature "), sender...)
+}
+
+// dealtShare returns the valid share of the dealer for the participant
+func dealtShare(dealer []byte, participant int) []byte {
+	return []byte(fmt.Sprintf("share of %s for %d", dealer, participant))
+}
+
+// generateParticipants generates the participant IDs
+func generateParticipants(count int) [][]byte {
+	participants := make([][]byte, count)
+
+	for index := range participants {
+		participants[index] = []byte(fmt.Sprintf("validator %d", index))
+	}
+
+	return participants
+}
+
+// mockTransport delivers the multicasted messages to the other ceremonies
+type mockTransport struct {
+	sender     int
+	ceremonies []*Ceremony
+}
+
+func (t mockTransport) Multicast(message *proto.Message) error {
+	for index, ceremony := range t.ceremonies {
+		if index != t.sender && ceremony != nil {
+			ceremony.AddMessage(message)
+		}
+	}
+
+	return nil
+}
+
+// outcome is the outcome of the ceremony of a participant
+type outcome struct {
+	result *Result
+	err    error
+}
+
+// runCluster runs the ceremony for the participants, and returns their outcomes.
+// The silent participants do not run the ceremony
+func runCluster(
+	t *testing.T,
+	participants [][]byte,
+	backendFn func(index int) mockBackend,
+	silent ...int,
+) []outcome {
+	t.Helper()
+
+	var (
+		ceremonies = make([]*Ceremony, len(participants))
+		outcomes   = make([]outcome, len(participants))
+		isSilent   = make(map[int]bool, len(silent))
+	)
+
+	for _, index := range silent {
+		isSilent[index] = true
+	}
+
+	for index := range participants {
+		if isSilent[index] {
+			continue
+		}
+
+		ceremonies[index] = NewCeremony(
+			mockLogger{},
+			backendFn(index),
+			mockTransport{sender: index, ceremonies: ceremonies},
+			7,
+			participants,
+			WithPhaseTimeout(200*time.Millisecond),
+		)
+	}
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFn()
+
+	var wg sync.WaitGroup
+
+	for index, ceremony := range ceremonies {
+		if ceremony == nil {
+			continue
+		}
+
+		index := index
+		ceremony := ceremony
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			result, err := ceremony.Run(ctx)
+			outcomes[index] = outcome{result: result, err: err}
+		}()
+	}
+
+	wg.Wait()
+
+	return outcomes
+}
+
+// honestBackend returns the backend of an honest participant
+func honestBackend(participants [][]byte) func(index int) mockBackend {
+	return func(index int) mockBackend {
+		return mockBackend{
+			id:    participants[index],
+			index: index,
+		}
+	}
+}
+
+// assertAgreement makes sure the participants finalized the same key,
+// formed by the deals of the qualified dealers
+func assertAgreement(t *testing.T, outcomes []outcome, participants [][]byte, qualified ...int) {
+	t.Helper()
+
+	expected := make([][]byte, 0, len(qualified))
+	for _, index := range qualified {
+		expected = append(expected, participants[index])
+	}
+
+	var publicKey []byte
+
+	for _, outcome := range outcomes {
+		require.NoError(t, outcome.err)
+
+		assert.Equal(t, expected, outcome.result.Qualified)
+
+		if publicKey == nil {
+			publicKey = outcome.result.PublicKey
+		}
+
+		assert.Equal(t, publicKey, outcome.result.PublicKey)
+	}
+}
+
+func TestCeremony_Run(t *testing.T) {
+	t.Parallel()
+
+	participants := generateParticipants(4)
+
+	outcomes := runCluster(t, participants, honestBackend(participants))
+
+	assertAgreement(t, outcomes, participants, 0, 1, 2, 3)
+}
+
+func TestCeremony_FaultyDealer(t *testing.T) {
+	t.Parallel()
+
+	var (
+		participants = generateParticipants(4)
+		honest       = honestBackend(participants)
+	)
+
+	outcomes := runCluster(t, participants, func(index int) mockBackend {
+		backend := honest(index)
+		if index == 2 {
+			// The dealer deals invalid shares to the other participants
+			backend.corruptShareFn = func(participant int) bool {
+				return participant != 2
+			}
+		}
+
+		return backend
+	})
+
+	// Make sure the faulty dealer is disqualified
+	assertAgreement(t, append(outcomes[:2], outcomes[3]), participants, 0, 1, 3)
+}
+
+func TestCeremony_SilentParticipant(t *testing.T) {
+	t.Parallel()
+
+	participants := generateParticipants(4)
+
+	outcomes := runCluster(t, participants, honestBackend(participants), 1)
+
+	// Make sure the remaining quorum finalizes the key without the silent participant
+	assertAgreement(t, append(outcomes[:1], outcomes[2:]...), participants, 0, 2, 3)
+}
+
+func TestCeremony_MissingShare(t *testing.T) {
+	t.Parallel()
+
+	var (
+		participants = generateParticipants(4)
+		honest       = honestBackend(participants)
+	)
+
+	outcomes := runCluster(t, participants, func(index int) mockBackend {
+		backend := honest(index)
+		if index == 0 {
+			// A single complaint is within the tolerated faults
+			backend.corruptShareFn = func(participant int) bool {
+				return participant == 3
+			}
+		}
+
+		return backend
+	})
+
+	// Make sure the dealer stays qualified, and only the participant
+	// without its share fails the ceremony
+	assert.ErrorIs(t, outcomes[3].err, ErrMissingShare)
+	assertAgreement(t, outcomes[:3], participants, 0, 1, 2, 3)
+}
+
+func TestCeremony_NotParticipant(t *testing.T) {
+	t.Parallel()
+
+	ceremony := NewCeremony(
+		mockLogger{},
+		mockBackend{id: []byte("outsider")},
+		mockTransport{},
+		7,
+		generateParticipants(4),
+	)
+
+	_, err := ceremony.Run(context.Background())
+
+	assert.ErrorIs(t, err, ErrNotParticipant)
+}
+
+func TestCeremony_AddMessage(t *testing.T) {
+	t.Parallel()
+
+	var (
+		participants = generateParticipants(4)
+		backend      = mockBackend{id: participants[0]}
+		ceremony     = NewCeremony(mockLogger{}, backend, mockTransport{}, 7, participants)
+		view         = &proto.View{Height: 7, Round: uint64(PhaseDeal)}
+	)
+
+	testTable := []struct {
+		name       string
+		modifyFn   func(message *proto.Message)
+		acceptable bool
+	}{
+		{
+			name:       "valid message",
+			acceptable: true,
+		},
+		{
+			name: "other ceremony",
+			modifyFn: func(message *proto.Message) {
+				message.View = &proto.View{Height: 8, Round: view.Round}
+			},
+		},
+		{
+			name: "unknown phase",
+			modifyFn: func(message *proto.Message) {
+				message.View = &proto.View{Height: view.Height, Round: uint64(PhaseFinalization) + 1}
+			},
+		},
+		{
+			name: "non-participant",
+			modifyFn: func(message *proto.Message) {
+				message.From = []byte("outsider")
+				message.Signature = signature(message.From)
+			},
+		},
+		{
+			name: "invalid signature",
+			modifyFn: func(message *proto.Message) {
+				message.Signature = nil
+			},
+		},
+		{
+			name: "consensus message",
+			modifyFn: func(message *proto.Message) {
+				message.Type = proto.MessageType_COMMIT
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		message := mockBackend{id: participants[1]}.BuildMessage(view, &proto.DKGMessage{})
+		if testCase.modifyFn != nil {
+			testCase.modifyFn(message)
+		}
+
+		assert.Equal(t, testCase.acceptable, ceremony.isAcceptableMessage(message), testCase.name)
+	}
+}
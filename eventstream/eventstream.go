@@ -0,0 +1,125 @@
+// Package eventstream defines a sub-module for streaming live consensus events
+// to external observers, such as explorers and dashboards
+package eventstream
+
+import (
+	"context"
+	"encoding/json"
+	"runtime/pprof"
+
+	"github.com/renloi/ibft/core"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// Source is the source of consensus events, usually the IBFT instance
+type Source interface {
+	// SubscribeEvents creates a new subscription to consensus events
+	SubscribeEvents() *core.EventSubscription
+
+	// UnsubscribeEvents cancels the event subscription
+	UnsubscribeEvents(id core.EventSubscriptionID)
+}
+
+// Sender is the sending side of an event stream.
+// Its method set matches the server stream generated by gRPC
+// for a server-streaming RPC returning Event messages
+type Sender interface {
+	// Send sends the event to the stream
+	Send(event *proto.Event) error
+
+	// Context returns the context of the stream
+	Context() context.Context
+}
+
+// Channel streams the consensus events to the returned channel,
+// until the context is cancelled. The channel is closed afterwards
+func Channel(ctx context.Context, source Source) <-chan core.Event {
+	var (
+		sub     = source.SubscribeEvents()
+		eventCh = make(chan core.Event)
+	)
+
+	go pprof.Do(ctx, pprof.Labels("worker", "event stream"), func(ctx context.Context) {
+		defer func() {
+			source.UnsubscribeEvents(sub.ID)
+
+			close(eventCh)
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-sub.EventCh:
+				if !ok {
+					return
+				}
+
+				select {
+				case eventCh <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	})
+
+	return eventCh
+}
+
+// Serve streams the consensus events to the sender, until the stream
+// context is cancelled or sending fails
+func Serve(source Source, stream Sender) error {
+	var (
+		ctx = stream.Context()
+		sub = source.SubscribeEvents()
+	)
+
+	defer source.UnsubscribeEvents(sub.ID)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-sub.EventCh:
+			if !ok {
+				return nil
+			}
+
+			encoded, err := ToProto(event)
+			if err != nil {
+				return err
+			}
+
+			if err := stream.Send(encoded); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ToProto converts the consensus event to its wire representation
+func ToProto(event core.Event) (*proto.Event, error) {
+	encoded := &proto.Event{
+		Type:      event.Type.String(),
+		Timestamp: event.Timestamp.UnixNano(),
+	}
+
+	if event.View != nil {
+		encoded.View = &proto.View{
+			Height: event.View.Height,
+			Round:  event.View.Round,
+		}
+	}
+
+	if event.Data != nil {
+		data, err := json.Marshal(event.Data)
+		if err != nil {
+			return nil, err
+		}
+
+		encoded.Data = data
+	}
+
+	return encoded, nil
+}
@@ -0,0 +1,427 @@
+package eventstream
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/goleak"
+
+	"github.com/renloi/ibft/core"
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+func TestMain(m *testing.M) {
+	goleak.VerifyTestMain(m)
+}
+
+// mockSource is a single-subscription event source
+type mockSource struct {
+	sync.Mutex
+
+	eventCh      chan core.Event
+	unsubscribed bool
+}
+
+func newMockSource() *mockSource {
+	return &mockSource{
+		eventCh: make(chan core.Event, 10),
+	}
+}
+
+func (s *mockSource) SubscribeEvents() *core.EventSubscription {
+	return &core.EventSubscription{
+		ID:      1,
+		EventCh: s.eventCh,
+	}
+}
+
+func (s *mockSource) UnsubscribeEvents(_ core.EventSubscriptionID) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.unsubscribed = true
+}
+
+func (s *mockSource) isUnsubscribed() bool {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.unsubscribed
+}
+
+// mockSender is a mock event stream
+type mockSender struct {
+	ctx    context.Context
+	sendFn func(*proto.Event) error
+}
+
+func (s mockSender) Send(event *proto.Event) error {
+	return s.sendFn(event)
+}
+
+func (s mockSender) Context() context.Context {
+	return s.ctx
+}
+
+// generateEvent generates a simple prepared mismatch event
+func generateEvent(round uint64) core.Event {
+	return core.Event{
+		Type: core.EventPreparedMismatch,
+		View: &proto.View{
+			Height: 1,
+			Round:  round,
+		},
+		Timestamp: time.Unix(0, 100),
+		Data: core.PreparedMismatchData{
+			PreparedRound: 0,
+			PreparedHash:  []byte("prepared hash"),
+			ProposalHash:  []byte("proposal hash"),
+		},
+	}
+}
+
+func TestToProto(t *testing.T) {
+	t.Parallel()
+
+	event := generateEvent(2)
+
+	encoded, err := ToProto(event)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "prepared mismatch", encoded.Type)
+	assert.Equal(t, uint64(1), encoded.View.Height)
+	assert.Equal(t, uint64(2), encoded.View.Round)
+	assert.Equal(t, int64(100), encoded.Timestamp)
+
+	var data core.PreparedMismatchData
+
+	assert.NoError(t, json.Unmarshal(encoded.Data, &data))
+	assert.Equal(t, event.Data, data)
+}
+
+func TestChannel(t *testing.T) {
+	t.Parallel()
+
+	var (
+		source = newMockSource()
+		events = []core.Event{generateEvent(1), generateEvent(2)}
+	)
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+
+	eventCh := Channel(ctx, source)
+
+	for _, event := range events {
+		source.eventCh <- event
+	}
+
+	// Make sure the events are streamed in order
+	for _, event := range events {
+		assert.Equal(t, event, <-eventCh)
+	}
+
+	// Make sure the channel is closed and the source is
+	// unsubscribed when the context is cancelled
+	cancelFn()
+
+	for range eventCh {
+	}
+
+	assert.True(t, source.isUnsubscribed())
+}
+
+func TestServe(t *testing.T) {
+	t.Parallel()
+
+	t.Run("events streamed until cancelled", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			source = newMockSource()
+			sent   = make(chan *proto.Event, 2)
+		)
+
+		ctx, cancelFn := context.WithCancel(context.Background())
+		defer cancelFn()
+
+		source.eventCh <- generateEvent(1)
+		source.eventCh <- generateEvent(2)
+
+		errCh := make(chan error, 1)
+
+		go func() {
+			errCh <- Serve(source, mockSender{
+				ctx: ctx,
+				sendFn: func(event *proto.Event) error {
+					sent <- event
+
+					return nil
+				},
+			})
+		}()
+
+		assert.Equal(t, uint64(1), (<-sent).View.Round)
+		assert.Equal(t, uint64(2), (<-sent).View.Round)
+
+		cancelFn()
+
+		assert.ErrorIs(t, <-errCh, context.Canceled)
+		assert.True(t, source.isUnsubscribed())
+	})
+
+	t.Run("send error", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			source  = newMockSource()
+			sendErr = errors.New("stream closed")
+		)
+
+		source.eventCh <- generateEvent(1)
+
+		err := Serve(source, mockSender{
+			ctx: context.Background(),
+			sendFn: func(_ *proto.Event) error {
+				return sendErr
+			},
+		})
+
+		assert.ErrorIs(t, err, sendErr)
+		assert.True(t, source.isUnsubscribed())
+	})
+}
+
+// nopLogger is a logger that discards the log messages
+type nopLogger struct{}
+
+func (nopLogger) Info(_ string, _ ...interface{})  {}
+func (nopLogger) Debug(_ string, _ ...interface{}) {}
+func (nopLogger) Error(_ string, _ ...interface{}) {}
+
+// soloBackend is the backend of a single validator network,
+// where the validator proposes in every round
+type soloBackend struct {
+	id []byte
+}
+
+func (b soloBackend) ID() []byte {
+	return b.id
+}
+
+func (b soloBackend) BuildProposal(_ *proto.View) []byte {
+	return []byte("proposal")
+}
+
+func (b soloBackend) InsertProposal(_ *proto.Proposal, _ []*messages.CommittedSeal) {}
+
+func (b soloBackend) IsValidProposal(_ []byte) bool {
+	return true
+}
+
+func (b soloBackend) IsValidValidator(_ *proto.Message) bool {
+	return true
+}
+
+func (b soloBackend) IsProposer(id []byte, _, _ uint64) bool {
+	return bytes.Equal(id, b.id)
+}
+
+func (b soloBackend) IsValidProposalHash(_ *proto.Proposal, _ []byte) bool {
+	return true
+}
+
+func (b soloBackend) IsValidCommittedSeal(_ []byte, _ *messages.CommittedSeal) bool {
+	return true
+}
+
+func (b soloBackend) HasQuorum(_ uint64, msgs []*proto.Message, msgType proto.MessageType) bool {
+	// The proposer does not send a PREPARE message
+	if msgType == proto.MessageType_PREPARE {
+		return true
+	}
+
+	return len(msgs) >= 1
+}
+
+func (b soloBackend) BuildPrePrepareMessage(
+	rawProposal []byte,
+	certificate *proto.RoundChangeCertificate,
+	view *proto.View,
+) *proto.Message {
+	return &proto.Message{
+		View: view,
+		From: b.id,
+		Type: proto.MessageType_PREPREPARE,
+		Payload: &proto.Message_PreprepareData{
+			PreprepareData: &proto.PrePrepareMessage{
+				Proposal: &proto.Proposal{
+					RawProposal: rawProposal,
+					Round:       view.Round,
+				},
+				Certificate:  certificate,
+				ProposalHash: []byte("proposal hash"),
+			},
+		},
+	}
+}
+
+func (b soloBackend) BuildPrepareMessage(proposalHash []byte, view *proto.View) *proto.Message {
+	return &proto.Message{
+		View: view,
+		From: b.id,
+		Type: proto.MessageType_PREPARE,
+		Payload: &proto.Message_PrepareData{
+			PrepareData: &proto.PrepareMessage{
+				ProposalHash: proposalHash,
+			},
+		},
+	}
+}
+
+func (b soloBackend) BuildCommitMessage(proposalHash []byte, view *proto.View) *proto.Message {
+	return &proto.Message{
+		View: view,
+		From: b.id,
+		Type: proto.MessageType_COMMIT,
+		Payload: &proto.Message_CommitData{
+			CommitData: &proto.CommitMessage{
+				ProposalHash:  proposalHash,
+				CommittedSeal: []byte("seal"),
+			},
+		},
+	}
+}
+
+func (b soloBackend) BuildRoundChangeMessage(
+	_ *proto.Proposal,
+	_ *proto.PreparedCertificate,
+	view *proto.View,
+) *proto.Message {
+	return &proto.Message{
+		View: view,
+		From: b.id,
+		Type: proto.MessageType_ROUND_CHANGE,
+		Payload: &proto.Message_RoundChangeData{
+			RoundChangeData: &proto.RoundChangeMessage{},
+		},
+	}
+}
+
+// loopbackTransport relays the multicast messages back to the node
+type loopbackTransport struct {
+	addMessageFn func(*proto.Message)
+}
+
+func (t *loopbackTransport) Multicast(message *proto.Message) error {
+	t.addMessageFn(message)
+
+	return nil
+}
+
+// subscribedSource signals when the source is subscribed to
+type subscribedSource struct {
+	Source
+
+	subscribed chan struct{}
+}
+
+func (s subscribedSource) SubscribeEvents() *core.EventSubscription {
+	defer close(s.subscribed)
+
+	return s.Source.SubscribeEvents()
+}
+
+// newSoloIBFT creates an IBFT instance of a single validator network
+func newSoloIBFT() *core.IBFT {
+	transport := &loopbackTransport{}
+	i := core.NewIBFT(nopLogger{}, soloBackend{id: []byte("node")}, transport)
+	transport.addMessageFn = i.AddMessage
+
+	return i
+}
+
+// roundProgress are the events emitted by a node finalizing a height in round 0
+var roundProgress = []core.EventType{
+	core.EventRoundStarted,
+	core.EventProposalAccepted,
+	core.EventPrepareQuorum,
+	core.EventCommitQuorum,
+	core.EventFinalized,
+}
+
+func TestChannel_RoundProgress(t *testing.T) {
+	t.Parallel()
+
+	var (
+		i         = newSoloIBFT()
+		received  = make([]core.EventType, 0, len(roundProgress))
+		ctx, stop = context.WithCancel(context.Background())
+	)
+
+	eventCh := Channel(ctx, i)
+
+	i.RunSequence(context.Background(), 1)
+
+	for range roundProgress {
+		received = append(received, (<-eventCh).Type)
+	}
+
+	stop()
+
+	for range eventCh {
+	}
+
+	// Make sure the round progress of the sequence is streamed
+	assert.Equal(t, roundProgress, received)
+}
+
+func TestServe_RoundProgress(t *testing.T) {
+	t.Parallel()
+
+	var (
+		i      = newSoloIBFT()
+		source = subscribedSource{
+			Source:     i,
+			subscribed: make(chan struct{}),
+		}
+		sent      = make(chan *proto.Event, len(roundProgress))
+		errCh     = make(chan error, 1)
+		ctx, stop = context.WithCancel(context.Background())
+	)
+
+	go func() {
+		errCh <- Serve(source, mockSender{
+			ctx: ctx,
+			sendFn: func(event *proto.Event) error {
+				sent <- event
+
+				return nil
+			},
+		})
+	}()
+
+	<-source.subscribed
+
+	i.RunSequence(context.Background(), 1)
+
+	// Make sure the round progress of the sequence is streamed
+	for _, eventType := range roundProgress {
+		event := <-sent
+
+		assert.Equal(t, eventType.String(), event.Type)
+		assert.Equal(t, uint64(1), event.View.Height)
+	}
+
+	stop()
+
+	assert.ErrorIs(t, <-errCh, context.Canceled)
+}
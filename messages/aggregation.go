@@ -0,0 +1,189 @@
+package messages
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+var (
+	// ErrAggregationSchemeMismatch is an error indicating the commit certificate
+	// is aggregated with a scheme other than the one it is verified with
+	ErrAggregationSchemeMismatch = errors.New("aggregation scheme mismatch")
+
+	// ErrInvalidCommittedSeal is an error indicating a committed seal
+	// of the commit certificate does not verify
+	ErrInvalidCommittedSeal = errors.New("invalid committed seal")
+
+	// ErrInvalidAggregate is an error indicating the aggregated seal
+	// of the commit certificate does not verify
+	ErrInvalidAggregate = errors.New("invalid aggregated seal")
+)
+
+// AggregationScheme is the signature scheme whose committed seals can be
+// aggregated into a single signature of constant size (ex. BLS, Schnorr with MuSig),
+// so the commit certificate size does not depend on the committee size
+type AggregationScheme interface {
+	Aggregator
+
+	// Name returns the name of the scheme, recorded in the commit certificate
+	Name() string
+
+	// VerifyAggregate checks if the aggregated seal is the valid aggregate
+	// of the committed seals of the signers, over the proposal hash
+	VerifyAggregate(proposalHash []byte, signers [][]byte, aggregate []byte) bool
+}
+
+// certificateOptions are the options of the commit certificate construction
+type certificateOptions struct {
+	sealsRoot bool
+}
+
+// CertificateOption is the option of the commit certificate construction
+type CertificateOption func(*certificateOptions)
+
+// WithSealsRoot commits the certificate to its individual committed seals, using
+// their Merkle root (see SealsRoot). The inclusion proofs (see BuildSealProof) then
+// prove a specific validator sealed the finalized proposal, without the whole
+// committed set, which keeps the per-validator accountability of aggregated certificates
+func WithSealsRoot() CertificateOption {
+	return func(o *certificateOptions) {
+		o.sealsRoot = true
+	}
+}
+
+// NewCommitCertificate creates the commit certificate of the finalized proposal
+// from its committed seals. The seals are aggregated if the scheme is set.
+// The randomness contributions of the seals, if any, are carried individually,
+// so the randomness beacon output can be derived (see CommitCertificateBeacon)
+func NewCommitCertificate(
+	view *proto.View,
+	proposalHash []byte,
+	seals []*CommittedSeal,
+	validators [][]byte,
+	scheme AggregationScheme,
+	opts ...CertificateOption,
+) (*proto.CommitCertificate, error) {
+	var options certificateOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var (
+		aggregator Aggregator
+		schemeName string
+	)
+
+	if scheme != nil {
+		aggregator = scheme
+		schemeName = scheme.Name()
+	}
+
+	compact, err := CompactCommittedSealSet(seals, validators, aggregator)
+	if err != nil {
+		return nil, err
+	}
+
+	randomness, err := orderedRandomness(seals, validators)
+	if err != nil {
+		return nil, err
+	}
+
+	certificate := &proto.CommitCertificate{
+		View: &proto.View{
+			Height: view.GetHeight(),
+			Round:  view.GetRound(),
+		},
+		ProposalHash:      proposalHash,
+		Signers:           compact.Signers,
+		AggregationScheme: schemeName,
+		Randomness:        randomness,
+	}
+
+	if options.sealsRoot {
+		certificate.SealsRoot = SealsRoot(seals)
+	}
+
+	return certificate, nil
+}
+
+// VerifyCommitCertificate verifies the seals of the commit certificate,
+// and returns its signers, in the validator set order. The individual seals
+// are verified with isValidSeal, and the aggregated seal with the scheme.
+// The seals root, if any, is verified against the individual seals;
+// the root of aggregated seals cannot be verified, as the seals are not restored.
+// The randomness contributions, if any, must match the signers.
+// Whether the signers form a quorum, and whether the contributions are valid, is left to the caller
+func VerifyCommitCertificate(
+	certificate *proto.CommitCertificate,
+	validators [][]byte,
+	scheme AggregationScheme,
+	isValidSeal func(proposalHash []byte, seal *CommittedSeal) bool,
+) ([][]byte, error) {
+	set := certificate.GetSigners()
+	if set == nil || certificate.GetView() == nil {
+		return nil, ErrInvalidSignerSet
+	}
+
+	if randomness := certificate.GetRandomness(); len(randomness) != 0 && len(randomness) != len(SignerIndexes(set)) {
+		return nil, ErrRandomnessMismatch
+	}
+
+	if len(set.GetAggregate()) == 0 {
+		if certificate.GetAggregationScheme() != "" {
+			return nil, ErrInvalidSignerSet
+		}
+
+		seals, err := CommitCertificateSeals(certificate, validators)
+		if err != nil {
+			return nil, err
+		}
+
+		signers := make([][]byte, 0, len(seals))
+
+		for _, seal := range seals {
+			if !isValidSeal(certificate.GetProposalHash(), seal) {
+				return nil, ErrInvalidCommittedSeal
+			}
+
+			signers = append(signers, seal.Signer)
+		}
+
+		if root := certificate.GetSealsRoot(); len(root) != 0 && !bytes.Equal(root, SealsRoot(seals)) {
+			return nil, ErrSealsRootMismatch
+		}
+
+		return signers, nil
+	}
+
+	if scheme == nil || scheme.Name() != certificate.GetAggregationScheme() {
+		return nil, ErrAggregationSchemeMismatch
+	}
+
+	signers, err := Signers(set, validators)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(signers) == 0 || len(set.GetSignatures()) != 0 {
+		return nil, ErrInvalidSignerSet
+	}
+
+	if !scheme.VerifyAggregate(certificate.GetProposalHash(), signers, set.GetAggregate()) {
+		return nil, ErrInvalidAggregate
+	}
+
+	return signers, nil
+}
+
+// CommitCertificateSeals returns the individual committed seals of the commit
+// certificate, ordered by the validator index. Aggregated seals cannot be restored
+func CommitCertificateSeals(
+	certificate *proto.CommitCertificate,
+	validators [][]byte,
+) ([]*CommittedSeal, error) {
+	return ExpandCommittedSealSet(&proto.CompactCommittedSeals{
+		Signers: certificate.GetSigners(),
+	}, validators)
+}
@@ -0,0 +1,281 @@
+package messages
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	protoBuf "google.golang.org/protobuf/proto"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// xorScheme is a test aggregation scheme, where the committed seal
+// of a signer is the hash of the signer and the proposal hash,
+// and the aggregate is the XOR of the seals
+type xorScheme struct{}
+
+func (xorScheme) Name() string {
+	return "xor"
+}
+
+func (xorScheme) Aggregate(signatures [][]byte) ([]byte, error) {
+	aggregate := make([]byte, sha256.Size)
+
+	for _, signature := range signatures {
+		for index := range aggregate {
+			aggregate[index] ^= signature[index]
+		}
+	}
+
+	return aggregate, nil
+}
+
+func (s xorScheme) VerifyAggregate(proposalHash []byte, signers [][]byte, aggregate []byte) bool {
+	seals := make([][]byte, 0, len(signers))
+	for _, signer := range signers {
+		seals = append(seals, xorSeal(signer, proposalHash))
+	}
+
+	expected, _ := s.Aggregate(seals)
+
+	return string(expected) == string(aggregate)
+}
+
+// xorSeal returns the committed seal of the signer for the xorScheme
+func xorSeal(signer, proposalHash []byte) []byte {
+	seal := sha256.Sum256(append(append([]byte{}, signer...), proposalHash...))
+
+	return seal[:]
+}
+
+// buildXorSeals builds the committed seals of the specified validators
+func buildXorSeals(validators [][]byte, proposalHash []byte, signers ...int) []*CommittedSeal {
+	seals := make([]*CommittedSeal, 0, len(signers))
+	for _, signer := range signers {
+		seals = append(seals, &CommittedSeal{
+			Signer:    validators[signer],
+			Signature: xorSeal(validators[signer], proposalHash),
+		})
+	}
+
+	return seals
+}
+
+// isValidXorSeal checks the individual seal of the xorScheme
+func isValidXorSeal(proposalHash []byte, seal *CommittedSeal) bool {
+	return string(seal.Signature) == string(xorSeal(seal.Signer, proposalHash))
+}
+
+func TestCommitCertificate_Individual(t *testing.T) {
+	t.Parallel()
+
+	var (
+		validators   = generateValidators(8)
+		proposalHash = []byte("proposal hash")
+		view         = &proto.View{Height: 10, Round: 2}
+		seals        = buildXorSeals(validators, proposalHash, 6, 1, 3)
+	)
+
+	certificate, err := NewCommitCertificate(view, proposalHash, seals, validators, nil)
+	require.NoError(t, err)
+
+	assert.Empty(t, certificate.AggregationScheme)
+	assert.Equal(t, []byte{0b01001010}, certificate.Signers.Bitmap)
+
+	signers, err := VerifyCommitCertificate(certificate, validators, xorScheme{}, isValidXorSeal)
+
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{validators[1], validators[3], validators[6]}, signers)
+
+	restored, err := CommitCertificateSeals(certificate, validators)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*CommittedSeal{seals[1], seals[2], seals[0]}, restored)
+
+	// Make sure invalid seals are detected
+	certificate.ProposalHash = []byte("other proposal hash")
+
+	_, err = VerifyCommitCertificate(certificate, validators, nil, isValidXorSeal)
+	assert.ErrorIs(t, err, ErrInvalidCommittedSeal)
+}
+
+func TestCommitCertificate_Aggregated(t *testing.T) {
+	t.Parallel()
+
+	var (
+		proposalHash = []byte("proposal hash")
+		view         = &proto.View{Height: 10, Round: 2}
+	)
+
+	// buildCertificate builds the aggregated certificate
+	// of the first quorum of the validators
+	buildCertificate := func(t *testing.T, validators [][]byte) *proto.CommitCertificate {
+		t.Helper()
+
+		signers := make([]int, 0, len(validators))
+		for index := 0; index < len(validators)*2/3+1; index++ {
+			signers = append(signers, index)
+		}
+
+		certificate, err := NewCommitCertificate(
+			view,
+			proposalHash,
+			buildXorSeals(validators, proposalHash, signers...),
+			validators,
+			xorScheme{},
+		)
+		require.NoError(t, err)
+
+		return certificate
+	}
+
+	validators := generateValidators(100)
+	certificate := buildCertificate(t, validators)
+
+	assert.Equal(t, "xor", certificate.AggregationScheme)
+	assert.Empty(t, certificate.Signers.Signatures)
+
+	signers, err := VerifyCommitCertificate(certificate, validators, xorScheme{}, isValidXorSeal)
+
+	assert.NoError(t, err)
+	assert.Len(t, signers, 67)
+
+	// Aggregated seals cannot be restored
+	_, err = CommitCertificateSeals(certificate, validators)
+	assert.ErrorIs(t, err, ErrAggregatedSignatures)
+
+	// Make sure the certificate only grows by the signer bitmap with the committee size
+	smallCertificate := buildCertificate(t, generateValidators(8))
+
+	assert.Equal(
+		t,
+		len(certificate.Signers.Bitmap)-len(smallCertificate.Signers.Bitmap),
+		protoBuf.Size(certificate)-protoBuf.Size(smallCertificate),
+	)
+}
+
+func TestCommitCertificate_SealsRoot(t *testing.T) {
+	t.Parallel()
+
+	var (
+		validators   = generateValidators(8)
+		proposalHash = []byte("proposal hash")
+		view         = &proto.View{Height: 10, Round: 2}
+		seals        = buildXorSeals(validators, proposalHash, 6, 1, 3, 4, 0)
+	)
+
+	// Make sure the certificates do not commit to the seals by default
+	certificate, err := NewCommitCertificate(view, proposalHash, seals, validators, xorScheme{})
+	require.NoError(t, err)
+	assert.Empty(t, certificate.SealsRoot)
+
+	for _, scheme := range []AggregationScheme{nil, xorScheme{}} {
+		certificate, err := NewCommitCertificate(view, proposalHash, seals, validators, scheme, WithSealsRoot())
+		require.NoError(t, err)
+		assert.Equal(t, SealsRoot(seals), certificate.SealsRoot)
+
+		_, err = VerifyCommitCertificate(certificate, validators, xorScheme{}, isValidXorSeal)
+		assert.NoError(t, err)
+
+		// Make sure each seal of the certificate can be proven
+		for _, seal := range seals {
+			proof, err := BuildSealProof(seals, seal.Signer)
+			require.NoError(t, err)
+
+			assert.True(t, VerifySealProof(certificate.SealsRoot, seal, proof))
+		}
+	}
+
+	// Make sure the root of the individual seals is verified
+	certificate, err = NewCommitCertificate(view, proposalHash, seals, validators, nil, WithSealsRoot())
+	require.NoError(t, err)
+
+	certificate.SealsRoot = SealsRoot(seals[1:])
+
+	_, err = VerifyCommitCertificate(certificate, validators, nil, isValidXorSeal)
+	assert.ErrorIs(t, err, ErrSealsRootMismatch)
+}
+
+func TestVerifyCommitCertificate_Errors(t *testing.T) {
+	t.Parallel()
+
+	var (
+		validators   = generateValidators(4)
+		proposalHash = []byte("proposal hash")
+		view         = &proto.View{Height: 1, Round: 0}
+		seals        = buildXorSeals(validators, proposalHash, 0, 1, 2)
+	)
+
+	aggregated, err := NewCommitCertificate(view, proposalHash, seals, validators, xorScheme{})
+	require.NoError(t, err)
+
+	testTable := []struct {
+		name        string
+		scheme      AggregationScheme
+		modifyFn    func(certificate *proto.CommitCertificate)
+		expectedErr error
+	}{
+		{
+			name:        "missing scheme",
+			expectedErr: ErrAggregationSchemeMismatch,
+		},
+		{
+			name:   "different scheme",
+			scheme: xorScheme{},
+			modifyFn: func(certificate *proto.CommitCertificate) {
+				certificate.AggregationScheme = "bls"
+			},
+			expectedErr: ErrAggregationSchemeMismatch,
+		},
+		{
+			name:   "forged signer",
+			scheme: xorScheme{},
+			modifyFn: func(certificate *proto.CommitCertificate) {
+				certificate.Signers.Bitmap = []byte{0b1111}
+			},
+			expectedErr: ErrInvalidAggregate,
+		},
+		{
+			name:   "signer outside the validator set",
+			scheme: xorScheme{},
+			modifyFn: func(certificate *proto.CommitCertificate) {
+				certificate.Signers.Bitmap = []byte{0b10000}
+			},
+			expectedErr: ErrInvalidSignerSet,
+		},
+		{
+			name:   "scheme without the aggregate",
+			scheme: xorScheme{},
+			modifyFn: func(certificate *proto.CommitCertificate) {
+				certificate.Signers.Aggregate = nil
+			},
+			expectedErr: ErrInvalidSignerSet,
+		},
+		{
+			name:   "missing signers",
+			scheme: xorScheme{},
+			modifyFn: func(certificate *proto.CommitCertificate) {
+				certificate.Signers = nil
+			},
+			expectedErr: ErrInvalidSignerSet,
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			certificate, _ := protoBuf.Clone(aggregated).(*proto.CommitCertificate)
+			if testCase.modifyFn != nil {
+				testCase.modifyFn(certificate)
+			}
+
+			_, err := VerifyCommitCertificate(certificate, validators, testCase.scheme, isValidXorSeal)
+			assert.ErrorIs(t, err, testCase.expectedErr)
+		})
+	}
+}
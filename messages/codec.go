@@ -0,0 +1,40 @@
+package messages
+
+import (
+	protoBuf "google.golang.org/protobuf/proto"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// Marshaler encodes messages before they cross the transport boundary
+type Marshaler interface {
+	// Marshal encodes the message
+	Marshal(message *proto.Message) ([]byte, error)
+}
+
+// Unmarshaler decodes messages received over the transport boundary
+type Unmarshaler interface {
+	// Unmarshal decodes the raw data into the message
+	Unmarshal(data []byte, message *proto.Message) error
+}
+
+// Codec is the serializer used wherever messages cross the transport.
+// Chains can swap the default proto encoding for their native encoding (RLP, SSZ),
+// while keeping the in-memory proto types
+type Codec interface {
+	Marshaler
+	Unmarshaler
+}
+
+// ProtoCodec is the default codec, which uses the proto wire encoding
+type ProtoCodec struct{}
+
+// Marshal encodes the message using the proto wire encoding
+func (ProtoCodec) Marshal(message *proto.Message) ([]byte, error) {
+	return protoBuf.Marshal(message)
+}
+
+// Unmarshal decodes the proto encoded data into the message
+func (ProtoCodec) Unmarshal(data []byte, message *proto.Message) error {
+	return protoBuf.Unmarshal(data, message)
+}
@@ -0,0 +1,32 @@
+package messages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	protoBuf "google.golang.org/protobuf/proto"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+func TestProtoCodec(t *testing.T) {
+	t.Parallel()
+
+	var (
+		codec   Codec = ProtoCodec{}
+		message       = generateRandomMessages(1, &proto.View{Height: 1, Round: 2}, proto.MessageType_COMMIT)[0]
+	)
+
+	data, err := codec.Marshal(message)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	decoded := &proto.Message{}
+
+	assert.NoError(t, codec.Unmarshal(data, decoded))
+	assert.True(t, protoBuf.Equal(message, decoded))
+
+	// Make sure corrupted data is rejected
+	assert.Error(t, codec.Unmarshal([]byte{0xff, 0xff}, &proto.Message{}))
+}
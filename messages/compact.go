@@ -0,0 +1,443 @@
+package messages
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+var (
+	// ErrUnknownSigner is an error indicating the signer
+	// is not part of the validator set
+	ErrUnknownSigner = errors.New("signer is not in the validator set")
+
+	// ErrDuplicateSigner is an error indicating the signer
+	// is present more than once
+	ErrDuplicateSigner = errors.New("duplicate signer")
+
+	// ErrInvalidSignerSet is an error indicating the signer set
+	// does not match the validator set, or its signatures
+	ErrInvalidSignerSet = errors.New("invalid signer set")
+
+	// ErrInconsistentCertificate is an error indicating the certificate messages
+	// do not share the view, type or proposal hash, so they cannot be compacted
+	ErrInconsistentCertificate = errors.New("inconsistent certificate messages")
+
+	// ErrAggregatedSignatures is an error indicating the compact form
+	// holds an aggregated signature, so the individual messages cannot be restored
+	ErrAggregatedSignatures = errors.New("aggregated signatures cannot be expanded")
+)
+
+// Aggregator aggregates the individual signatures of a certificate
+// into a single signature (ex. BLS)
+type Aggregator interface {
+	// Aggregate aggregates the signatures, ordered by the validator index
+	Aggregate(signatures [][]byte) ([]byte, error)
+}
+
+// signerOrder returns the validator index of each signer, and the signer
+// positions ordered by the validator index
+func signerOrder(validators, signers [][]byte) ([]int, []int, error) {
+	lookup := make(map[string]int, len(validators))
+	for index, validator := range validators {
+		lookup[string(validator)] = index
+	}
+
+	var (
+		indexes = make([]int, len(signers))
+		order   = make([]int, len(signers))
+		seen    = make(map[int]struct{}, len(signers))
+	)
+
+	for position, signer := range signers {
+		index, ok := lookup[string(signer)]
+		if !ok {
+			return nil, nil, ErrUnknownSigner
+		}
+
+		if _, ok := seen[index]; ok {
+			return nil, nil, ErrDuplicateSigner
+		}
+
+		seen[index] = struct{}{}
+		indexes[position] = index
+		order[position] = position
+	}
+
+	sort.Slice(order, func(i, j int) bool {
+		return indexes[order[i]] < indexes[order[j]]
+	})
+
+	return indexes, order, nil
+}
+
+// NewSignerSet creates the compact signer set for the signers and their signatures.
+// The signatures are aggregated if the aggregator is set
+func NewSignerSet(
+	validators [][]byte,
+	signers [][]byte,
+	signatures [][]byte,
+	aggregator Aggregator,
+) (*proto.SignerSet, error) {
+	if len(signers) != len(signatures) {
+		return nil, ErrInvalidSignerSet
+	}
+
+	indexes, order, err := signerOrder(validators, signers)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		bitmap  = make([]byte, (len(validators)+7)/8)
+		ordered = make([][]byte, 0, len(signatures))
+	)
+
+	for _, position := range order {
+		index := indexes[position]
+		bitmap[index/8] |= 1 << (index % 8)
+
+		ordered = append(ordered, signatures[position])
+	}
+
+	if aggregator == nil {
+		return &proto.SignerSet{
+			Bitmap:     bitmap,
+			Signatures: ordered,
+		}, nil
+	}
+
+	aggregate, err := aggregator.Aggregate(ordered)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.SignerSet{
+		Bitmap:    bitmap,
+		Aggregate: aggregate,
+	}, nil
+}
+
+// SignerIndexes returns the validator indexes of the signers, in ascending order
+func SignerIndexes(set *proto.SignerSet) []int {
+	indexes := make([]int, 0)
+
+	for byteIndex, b := range set.GetBitmap() {
+		for bit := 0; bit < 8; bit++ {
+			if b&(1<<bit) != 0 {
+				indexes = append(indexes, byteIndex*8+bit)
+			}
+		}
+	}
+
+	return indexes
+}
+
+// Signers returns the IDs of the signers, in the validator set order
+func Signers(set *proto.SignerSet, validators [][]byte) ([][]byte, error) {
+	indexes := SignerIndexes(set)
+	signers := make([][]byte, 0, len(indexes))
+
+	for _, index := range indexes {
+		if index >= len(validators) {
+			return nil, ErrInvalidSignerSet
+		}
+
+		signers = append(signers, validators[index])
+	}
+
+	return signers, nil
+}
+
+// expandSigners returns the IDs and the individual signatures of the signers,
+// in the validator set order
+func expandSigners(set *proto.SignerSet, validators [][]byte) ([][]byte, [][]byte, error) {
+	if len(set.GetAggregate()) != 0 {
+		return nil, nil, ErrAggregatedSignatures
+	}
+
+	signers, err := Signers(set, validators)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(signers) != len(set.GetSignatures()) {
+		return nil, nil, ErrInvalidSignerSet
+	}
+
+	return signers, set.GetSignatures(), nil
+}
+
+// messageSigners returns the senders and the signatures of the messages
+func messageSigners(messages []*proto.Message) ([][]byte, [][]byte) {
+	var (
+		signers    = make([][]byte, 0, len(messages))
+		signatures = make([][]byte, 0, len(messages))
+	)
+
+	for _, message := range messages {
+		signers = append(signers, message.From)
+		signatures = append(signatures, message.Signature)
+	}
+
+	return signers, signatures
+}
+
+// isSameView checks if the views have the same height and round
+func isSameView(a, b *proto.View) bool {
+	return a.GetHeight() == b.GetHeight() && a.GetRound() == b.GetRound()
+}
+
+// CompactPreparedCertificate converts the PC into its compact form,
+// keeping only the signers of the PREPARE messages.
+// The unsigned relay metadata of the PREPARE messages is not kept
+func CompactPreparedCertificate(
+	certificate *proto.PreparedCertificate,
+	validators [][]byte,
+	aggregator Aggregator,
+) (*proto.CompactPreparedCertificate, error) {
+	if certificate == nil {
+		return nil, nil
+	}
+
+	proposal := certificate.ProposalMessage
+	if proposal == nil || proposal.View == nil ||
+		proposal.Type != proto.MessageType_PREPREPARE {
+		return nil, ErrInconsistentCertificate
+	}
+
+	proposalHash := ExtractProposalHash(proposal)
+
+	for _, prepare := range certificate.PrepareMessages {
+		if prepare.Type != proto.MessageType_PREPARE ||
+			!isSameView(prepare.View, proposal.View) ||
+			!bytes.Equal(ExtractPrepareHash(prepare), proposalHash) {
+			return nil, ErrInconsistentCertificate
+		}
+	}
+
+	signers, signatures := messageSigners(certificate.PrepareMessages)
+
+	prepareSigners, err := NewSignerSet(validators, signers, signatures, aggregator)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.CompactPreparedCertificate{
+		ProposalMessage: proposal,
+		PrepareSigners:  prepareSigners,
+	}, nil
+}
+
+// ExpandPreparedCertificate restores the PC from its compact form.
+// The PREPARE messages are ordered by the validator index
+func ExpandPreparedCertificate(
+	compact *proto.CompactPreparedCertificate,
+	validators [][]byte,
+) (*proto.PreparedCertificate, error) {
+	if compact == nil {
+		return nil, nil
+	}
+
+	proposal := compact.ProposalMessage
+	if proposal == nil || proposal.View == nil ||
+		proposal.Type != proto.MessageType_PREPREPARE {
+		return nil, ErrInconsistentCertificate
+	}
+
+	signers, signatures, err := expandSigners(compact.PrepareSigners, validators)
+	if err != nil {
+		return nil, err
+	}
+
+	prepares := make([]*proto.Message, 0, len(signers))
+
+	for index, signer := range signers {
+		prepares = append(prepares, &proto.Message{
+			View: &proto.View{
+				Height: proposal.View.Height,
+				Round:  proposal.View.Round,
+			},
+			From:      signer,
+			Signature: signatures[index],
+			Type:      proto.MessageType_PREPARE,
+			Payload: &proto.Message_PrepareData{
+				PrepareData: &proto.PrepareMessage{
+					ProposalHash: ExtractProposalHash(proposal),
+				},
+			},
+		})
+	}
+
+	return &proto.PreparedCertificate{
+		ProposalMessage: proposal,
+		PrepareMessages: prepares,
+	}, nil
+}
+
+// CompactRoundChangeCertificate converts the RCC into its compact form,
+// keeping the shared view once, and the PCs of the ROUND CHANGE messages
+// in their compact form
+func CompactRoundChangeCertificate(
+	certificate *proto.RoundChangeCertificate,
+	validators [][]byte,
+	aggregator Aggregator,
+) (*proto.CompactRoundChangeCertificate, error) {
+	if certificate == nil {
+		return nil, nil
+	}
+
+	roundChanges := certificate.RoundChangeMessages
+	if len(roundChanges) == 0 || roundChanges[0].View == nil {
+		return nil, ErrInconsistentCertificate
+	}
+
+	view := roundChanges[0].View
+
+	for _, roundChange := range roundChanges {
+		if roundChange.Type != proto.MessageType_ROUND_CHANGE ||
+			!isSameView(roundChange.View, view) {
+			return nil, ErrInconsistentCertificate
+		}
+	}
+
+	signers, signatures := messageSigners(roundChanges)
+
+	set, err := NewSignerSet(validators, signers, signatures, aggregator)
+	if err != nil {
+		return nil, err
+	}
+
+	// The payloads are kept in the validator set order, same as the signatures
+	_, order, _ := signerOrder(validators, signers)
+	compactRoundChanges := make([]*proto.CompactRoundChange, 0, len(roundChanges))
+
+	for _, position := range order {
+		roundChange := roundChanges[position]
+
+		pc, err := CompactPreparedCertificate(ExtractLatestPC(roundChange), validators, aggregator)
+		if err != nil {
+			return nil, err
+		}
+
+		compactRoundChanges = append(compactRoundChanges, &proto.CompactRoundChange{
+			LastPreparedProposal:      ExtractLastPreparedProposal(roundChange),
+			LatestPreparedCertificate: pc,
+		})
+	}
+
+	return &proto.CompactRoundChangeCertificate{
+		View: &proto.View{
+			Height: view.Height,
+			Round:  view.Round,
+		},
+		Signers:      set,
+		RoundChanges: compactRoundChanges,
+	}, nil
+}
+
+// ExpandRoundChangeCertificate restores the RCC from its compact form.
+// The ROUND CHANGE messages are ordered by the validator index
+func ExpandRoundChangeCertificate(
+	compact *proto.CompactRoundChangeCertificate,
+	validators [][]byte,
+) (*proto.RoundChangeCertificate, error) {
+	if compact == nil {
+		return nil, nil
+	}
+
+	if compact.View == nil {
+		return nil, ErrInconsistentCertificate
+	}
+
+	signers, signatures, err := expandSigners(compact.Signers, validators)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(signers) != len(compact.RoundChanges) {
+		return nil, ErrInvalidSignerSet
+	}
+
+	roundChanges := make([]*proto.Message, 0, len(signers))
+
+	for index, signer := range signers {
+		compactRoundChange := compact.RoundChanges[index]
+
+		pc, err := ExpandPreparedCertificate(compactRoundChange.LatestPreparedCertificate, validators)
+		if err != nil {
+			return nil, err
+		}
+
+		roundChanges = append(roundChanges, &proto.Message{
+			View: &proto.View{
+				Height: compact.View.Height,
+				Round:  compact.View.Round,
+			},
+			From:      signer,
+			Signature: signatures[index],
+			Type:      proto.MessageType_ROUND_CHANGE,
+			Payload: &proto.Message_RoundChangeData{
+				RoundChangeData: &proto.RoundChangeMessage{
+					LastPreparedProposal:      compactRoundChange.LastPreparedProposal,
+					LatestPreparedCertificate: pc,
+				},
+			},
+		})
+	}
+
+	return &proto.RoundChangeCertificate{
+		RoundChangeMessages: roundChanges,
+	}, nil
+}
+
+// CompactCommittedSealSet converts the committed seals into their compact form
+func CompactCommittedSealSet(
+	seals []*CommittedSeal,
+	validators [][]byte,
+	aggregator Aggregator,
+) (*proto.CompactCommittedSeals, error) {
+	var (
+		signers    = make([][]byte, 0, len(seals))
+		signatures = make([][]byte, 0, len(seals))
+	)
+
+	for _, seal := range seals {
+		signers = append(signers, seal.Signer)
+		signatures = append(signatures, seal.Signature)
+	}
+
+	set, err := NewSignerSet(validators, signers, signatures, aggregator)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.CompactCommittedSeals{
+		Signers: set,
+	}, nil
+}
+
+// ExpandCommittedSealSet restores the committed seals from their compact form.
+// The seals are ordered by the validator index
+func ExpandCommittedSealSet(
+	compact *proto.CompactCommittedSeals,
+	validators [][]byte,
+) ([]*CommittedSeal, error) {
+	signers, signatures, err := expandSigners(compact.GetSigners(), validators)
+	if err != nil {
+		return nil, err
+	}
+
+	seals := make([]*CommittedSeal, 0, len(signers))
+
+	for index, signer := range signers {
+		seals = append(seals, &CommittedSeal{
+			Signer:    signer,
+			Signature: signatures[index],
+		})
+	}
+
+	return seals, nil
+}
@@ -0,0 +1,346 @@
+package messages
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	protoBuf "google.golang.org/protobuf/proto"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// concatAggregator is a test aggregator that concatenates the signatures
+type concatAggregator struct{}
+
+func (concatAggregator) Aggregate(signatures [][]byte) ([]byte, error) {
+	return bytes.Join(signatures, nil), nil
+}
+
+// generateValidators generates the validator IDs
+func generateValidators(count int) [][]byte {
+	validators := make([][]byte, count)
+
+	for index := range validators {
+		validators[index] = []byte(fmt.Sprintf("validator %d", index))
+	}
+
+	return validators
+}
+
+// buildCompactTestPC builds a PC with the PREPARE messages from the specified validators
+func buildCompactTestPC(view *proto.View, validators [][]byte, signers ...int) *proto.PreparedCertificate {
+	proposalHash := []byte("proposal hash")
+
+	prepares := make([]*proto.Message, 0, len(signers))
+	for _, signer := range signers {
+		prepares = append(prepares, &proto.Message{
+			View:      &proto.View{Height: view.Height, Round: view.Round},
+			From:      validators[signer],
+			Signature: []byte(fmt.Sprintf("prepare signature %d", signer)),
+			Type:      proto.MessageType_PREPARE,
+			Payload: &proto.Message_PrepareData{
+				PrepareData: &proto.PrepareMessage{
+					ProposalHash: proposalHash,
+				},
+			},
+		})
+	}
+
+	return &proto.PreparedCertificate{
+		ProposalMessage: &proto.Message{
+			View:      view,
+			From:      validators[0],
+			Signature: []byte("proposal signature"),
+			Type:      proto.MessageType_PREPREPARE,
+			Payload: &proto.Message_PreprepareData{
+				PreprepareData: &proto.PrePrepareMessage{
+					Proposal: &proto.Proposal{
+						RawProposal: []byte("raw proposal"),
+						Round:       view.Round,
+					},
+					ProposalHash: proposalHash,
+				},
+			},
+		},
+		PrepareMessages: prepares,
+	}
+}
+
+func TestSignerSet(t *testing.T) {
+	t.Parallel()
+
+	var (
+		validators = generateValidators(10)
+		signers    = [][]byte{validators[9], validators[0], validators[3]}
+		signatures = [][]byte{[]byte("sig 9"), []byte("sig 0"), []byte("sig 3")}
+	)
+
+	set, err := NewSignerSet(validators, signers, signatures, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// Make sure the signers are marked in the bitmap, and ordered by index
+	assert.Equal(t, []byte{0b00001001, 0b00000010}, set.Bitmap)
+	assert.Equal(t, []int{0, 3, 9}, SignerIndexes(set))
+	assert.Equal(t, [][]byte{[]byte("sig 0"), []byte("sig 3"), []byte("sig 9")}, set.Signatures)
+
+	ids, err := Signers(set, validators)
+
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{validators[0], validators[3], validators[9]}, ids)
+
+	// Make sure the signatures are aggregated in the index order
+	set, err = NewSignerSet(validators, signers, signatures, concatAggregator{})
+
+	assert.NoError(t, err)
+	assert.Empty(t, set.Signatures)
+	assert.Equal(t, []byte("sig 0sig 3sig 9"), set.Aggregate)
+
+	// Make sure signers outside the validator set are rejected
+	_, err = Signers(set, validators[:5])
+	assert.ErrorIs(t, err, ErrInvalidSignerSet)
+}
+
+func TestSignerSet_Errors(t *testing.T) {
+	t.Parallel()
+
+	validators := generateValidators(4)
+
+	testTable := []struct {
+		name        string
+		signers     [][]byte
+		signatures  [][]byte
+		expectedErr error
+	}{
+		{
+			"signature count mismatch",
+			[][]byte{validators[0]},
+			nil,
+			ErrInvalidSignerSet,
+		},
+		{
+			"unknown signer",
+			[][]byte{[]byte("unknown")},
+			[][]byte{[]byte("sig")},
+			ErrUnknownSigner,
+		},
+		{
+			"duplicate signer",
+			[][]byte{validators[1], validators[1]},
+			[][]byte{[]byte("sig"), []byte("sig")},
+			ErrDuplicateSigner,
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			set, err := NewSignerSet(validators, testCase.signers, testCase.signatures, nil)
+
+			assert.Nil(t, set)
+			assert.ErrorIs(t, err, testCase.expectedErr)
+		})
+	}
+}
+
+func TestCompactPreparedCertificate(t *testing.T) {
+	t.Parallel()
+
+	var (
+		validators = generateValidators(100)
+		view       = &proto.View{Height: 10, Round: 2}
+		pc         = buildCompactTestPC(view, validators, 5, 1, 70, 42)
+		sorted     = buildCompactTestPC(view, validators, 1, 5, 42, 70)
+	)
+
+	compact, err := CompactPreparedCertificate(pc, validators, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// Make sure the compact form is smaller
+	assert.Less(t, protoBuf.Size(compact), protoBuf.Size(pc))
+
+	// Make sure the PC is restored, ordered by the validator index
+	expanded, err := ExpandPreparedCertificate(compact, validators)
+
+	assert.NoError(t, err)
+	assert.True(t, protoBuf.Equal(sorted, expanded))
+
+	// Make sure the PC cannot be restored from an aggregated signature
+	compact, err = CompactPreparedCertificate(pc, validators, concatAggregator{})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = ExpandPreparedCertificate(compact, validators)
+	assert.ErrorIs(t, err, ErrAggregatedSignatures)
+
+	// Make sure missing certificates are kept missing
+	compact, err = CompactPreparedCertificate(nil, validators, nil)
+
+	assert.NoError(t, err)
+	assert.Nil(t, compact)
+}
+
+func TestCompactPreparedCertificate_Inconsistent(t *testing.T) {
+	t.Parallel()
+
+	var (
+		validators = generateValidators(4)
+		view       = &proto.View{Height: 10, Round: 2}
+	)
+
+	testTable := []struct {
+		name   string
+		modify func(*proto.PreparedCertificate)
+	}{
+		{
+			"missing proposal",
+			func(pc *proto.PreparedCertificate) {
+				pc.ProposalMessage = nil
+			},
+		},
+		{
+			"different view",
+			func(pc *proto.PreparedCertificate) {
+				pc.PrepareMessages[0].View.Round++
+			},
+		},
+		{
+			"different proposal hash",
+			func(pc *proto.PreparedCertificate) {
+				pc.PrepareMessages[0].GetPrepareData().ProposalHash = []byte("other hash")
+			},
+		},
+		{
+			"different type",
+			func(pc *proto.PreparedCertificate) {
+				pc.PrepareMessages[0].Type = proto.MessageType_COMMIT
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			pc := buildCompactTestPC(&proto.View{Height: view.Height, Round: view.Round}, validators, 1, 2)
+			testCase.modify(pc)
+
+			_, err := CompactPreparedCertificate(pc, validators, nil)
+			assert.ErrorIs(t, err, ErrInconsistentCertificate)
+		})
+	}
+}
+
+func TestCompactRoundChangeCertificate(t *testing.T) {
+	t.Parallel()
+
+	var (
+		validators = generateValidators(100)
+		view       = &proto.View{Height: 10, Round: 3}
+		pc         = buildCompactTestPC(&proto.View{Height: 10, Round: 2}, validators, 3, 4, 5)
+	)
+
+	buildRoundChange := func(signer int, prepared bool) *proto.Message {
+		data := &proto.RoundChangeMessage{}
+		if prepared {
+			data.LastPreparedProposal = ExtractProposal(pc.ProposalMessage)
+			data.LatestPreparedCertificate = pc
+		}
+
+		return &proto.Message{
+			View:      &proto.View{Height: view.Height, Round: view.Round},
+			From:      validators[signer],
+			Signature: []byte(fmt.Sprintf("round change signature %d", signer)),
+			Type:      proto.MessageType_ROUND_CHANGE,
+			Payload: &proto.Message_RoundChangeData{
+				RoundChangeData: data,
+			},
+		}
+	}
+
+	var (
+		rcc = &proto.RoundChangeCertificate{
+			RoundChangeMessages: []*proto.Message{
+				buildRoundChange(9, true),
+				buildRoundChange(2, false),
+				buildRoundChange(50, true),
+			},
+		}
+		sorted = &proto.RoundChangeCertificate{
+			RoundChangeMessages: []*proto.Message{
+				buildRoundChange(2, false),
+				buildRoundChange(9, true),
+				buildRoundChange(50, true),
+			},
+		}
+	)
+
+	compact, err := CompactRoundChangeCertificate(rcc, validators, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// Make sure the compact form is smaller
+	assert.Less(t, protoBuf.Size(compact), protoBuf.Size(rcc))
+
+	// Make sure the RCC is restored, ordered by the validator index
+	expanded, err := ExpandRoundChangeCertificate(compact, validators)
+
+	assert.NoError(t, err)
+	assert.True(t, protoBuf.Equal(sorted, expanded))
+
+	// Make sure the signatures of the restored messages are over the same payloads
+	for index, message := range expanded.RoundChangeMessages {
+		expectedPayload, _ := sorted.RoundChangeMessages[index].PayloadNoSig()
+		payload, _ := message.PayloadNoSig()
+
+		assert.Equal(t, expectedPayload, payload)
+	}
+
+	// Make sure messages for different views are rejected
+	rcc.RoundChangeMessages[1].View.Round++
+
+	_, err = CompactRoundChangeCertificate(rcc, validators, nil)
+	assert.ErrorIs(t, err, ErrInconsistentCertificate)
+}
+
+func TestCompactCommittedSealSet(t *testing.T) {
+	t.Parallel()
+
+	var (
+		validators = generateValidators(8)
+		seals      = []*CommittedSeal{
+			{Signer: validators[7], Signature: []byte("seal 7")},
+			{Signer: validators[2], Signature: []byte("seal 2")},
+		}
+	)
+
+	compact, err := CompactCommittedSealSet(seals, validators, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, []byte{0b10000100}, compact.Signers.Bitmap)
+
+	expanded, err := ExpandCommittedSealSet(compact, validators)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*CommittedSeal{seals[1], seals[0]}, expanded)
+
+	// Make sure signature count mismatches are detected
+	compact.Signers.Signatures = compact.Signers.Signatures[:1]
+
+	_, err = ExpandCommittedSealSet(compact, validators)
+	assert.ErrorIs(t, err, ErrInvalidSignerSet)
+}
@@ -0,0 +1,329 @@
+package messages
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+
+	protoBuf "google.golang.org/protobuf/proto"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// ErrInvalidDedupCertificate is an error indicating the deduplicated
+// certificate references missing certificates
+var ErrInvalidDedupCertificate = errors.New("invalid deduplicated certificate")
+
+// DedupPreparedCertificate converts the PC into its deduplicated encoding,
+// keeping only the senders and the signatures of the PREPARE messages.
+// The unsigned relay metadata of the PREPARE messages is not kept
+func DedupPreparedCertificate(certificate *proto.PreparedCertificate) (*proto.DedupPreparedCertificate, error) {
+	if certificate == nil {
+		return nil, nil
+	}
+
+	proposal := certificate.ProposalMessage
+	if proposal == nil || proposal.View == nil ||
+		proposal.Type != proto.MessageType_PREPREPARE {
+		return nil, ErrInconsistentCertificate
+	}
+
+	var (
+		proposalHash = ExtractProposalHash(proposal)
+		prepares     = make([]*proto.SenderSignature, 0, len(certificate.PrepareMessages))
+	)
+
+	for _, prepare := range certificate.PrepareMessages {
+		if prepare.Type != proto.MessageType_PREPARE ||
+			!isSameView(prepare.View, proposal.View) ||
+			!bytes.Equal(ExtractPrepareHash(prepare), proposalHash) {
+			return nil, ErrInconsistentCertificate
+		}
+
+		prepares = append(prepares, &proto.SenderSignature{
+			From:      prepare.From,
+			Signature: prepare.Signature,
+			FromIndex: prepare.FromIndex,
+		})
+	}
+
+	return &proto.DedupPreparedCertificate{
+		ProposalMessage: proposal,
+		Prepares:        prepares,
+	}, nil
+}
+
+// ExpandPreparedCertificateDedup restores the PC from its deduplicated encoding
+func ExpandPreparedCertificateDedup(dedup *proto.DedupPreparedCertificate) (*proto.PreparedCertificate, error) {
+	if dedup == nil {
+		return nil, nil
+	}
+
+	proposal := dedup.ProposalMessage
+	if proposal == nil || proposal.View == nil ||
+		proposal.Type != proto.MessageType_PREPREPARE {
+		return nil, ErrInconsistentCertificate
+	}
+
+	prepares := make([]*proto.Message, 0, len(dedup.Prepares))
+
+	for _, prepare := range dedup.Prepares {
+		prepares = append(prepares, &proto.Message{
+			View: &proto.View{
+				Height: proposal.View.Height,
+				Round:  proposal.View.Round,
+			},
+			From:      prepare.From,
+			Signature: prepare.Signature,
+			FromIndex: prepare.FromIndex,
+			Type:      proto.MessageType_PREPARE,
+			Payload: &proto.Message_PrepareData{
+				PrepareData: &proto.PrepareMessage{
+					ProposalHash: ExtractProposalHash(proposal),
+				},
+			},
+		})
+	}
+
+	return &proto.PreparedCertificate{
+		ProposalMessage: proposal,
+		PrepareMessages: prepares,
+	}, nil
+}
+
+// DedupRoundChangeCertificate converts the RCC into its deduplicated encoding.
+// The shared view is encoded once, and the PCs (and their proposals)
+// carried by multiple ROUND CHANGE messages are encoded once.
+// The unsigned relay metadata of the ROUND CHANGE messages is not kept
+func DedupRoundChangeCertificate(
+	certificate *proto.RoundChangeCertificate,
+) (*proto.DedupRoundChangeCertificate, error) {
+	if certificate == nil {
+		return nil, nil
+	}
+
+	roundChanges := certificate.RoundChangeMessages
+	if len(roundChanges) == 0 || roundChanges[0].View == nil {
+		return nil, ErrInconsistentCertificate
+	}
+
+	var (
+		view  = roundChanges[0].View
+		dedup = &proto.DedupRoundChangeCertificate{
+			View: &proto.View{
+				Height: view.Height,
+				Round:  view.Round,
+			},
+			RoundChanges: make([]*proto.DedupRoundChange, 0, len(roundChanges)),
+		}
+
+		// certificateIndexes are the 1-based indexes of the
+		// distinct PCs, by their message digests
+		certificateIndexes = make(map[string]uint32)
+	)
+
+	for _, roundChange := range roundChanges {
+		if roundChange.Type != proto.MessageType_ROUND_CHANGE ||
+			!isSameView(roundChange.View, view) {
+			return nil, ErrInconsistentCertificate
+		}
+
+		dedupRoundChange := &proto.DedupRoundChange{
+			From:                 roundChange.From,
+			Signature:            roundChange.Signature,
+			FromIndex:            roundChange.FromIndex,
+			LastPreparedProposal: ExtractLastPreparedProposal(roundChange),
+		}
+
+		if pc := ExtractLatestPC(roundChange); pc != nil {
+			key, err := certificateKey(pc)
+			if err != nil {
+				return nil, err
+			}
+
+			index, ok := certificateIndexes[key]
+			if !ok {
+				dedupPC, err := DedupPreparedCertificate(pc)
+				if err != nil {
+					return nil, err
+				}
+
+				dedup.Certificates = append(dedup.Certificates, dedupPC)
+				index = uint32(len(dedup.Certificates))
+				certificateIndexes[key] = index
+			}
+
+			dedupRoundChange.Certificate = index
+
+			// The last prepared proposal is usually the proposal of the PC
+			if protoBuf.Equal(dedupRoundChange.LastPreparedProposal, ExtractProposal(pc.ProposalMessage)) {
+				dedupRoundChange.LastPreparedProposal = nil
+				dedupRoundChange.CertificateProposal = true
+			}
+		}
+
+		dedup.RoundChanges = append(dedup.RoundChanges, dedupRoundChange)
+	}
+
+	return dedup, nil
+}
+
+// certificateKey returns the key identifying the PC, made of the digests
+// of its messages. The digests are cached, so the PC messages validated
+// on ingress are not encoded again
+func certificateKey(certificate *proto.PreparedCertificate) (string, error) {
+	key := make([]byte, 0, (len(certificate.PrepareMessages)+1)*sha256.Size)
+
+	for _, message := range append([]*proto.Message{certificate.ProposalMessage}, certificate.PrepareMessages...) {
+		if message == nil {
+			return "", ErrInconsistentCertificate
+		}
+
+		digest, err := MessageDigest(message)
+		if err != nil {
+			return "", err
+		}
+
+		key = append(key, digest.Hash[:]...)
+	}
+
+	return string(key), nil
+}
+
+// ExpandRoundChangeCertificateDedup restores the RCC from its deduplicated encoding
+func ExpandRoundChangeCertificateDedup(
+	dedup *proto.DedupRoundChangeCertificate,
+) (*proto.RoundChangeCertificate, error) {
+	if dedup == nil {
+		return nil, nil
+	}
+
+	if dedup.View == nil {
+		return nil, ErrInconsistentCertificate
+	}
+
+	certificates := make([]*proto.PreparedCertificate, 0, len(dedup.Certificates))
+
+	for _, dedupPC := range dedup.Certificates {
+		pc, err := ExpandPreparedCertificateDedup(dedupPC)
+		if err != nil {
+			return nil, err
+		}
+
+		certificates = append(certificates, pc)
+	}
+
+	roundChanges := make([]*proto.Message, 0, len(dedup.RoundChanges))
+
+	for _, dedupRoundChange := range dedup.RoundChanges {
+		var (
+			pc       *proto.PreparedCertificate
+			proposal = dedupRoundChange.LastPreparedProposal
+		)
+
+		if index := dedupRoundChange.Certificate; index != 0 {
+			if int(index) > len(certificates) {
+				return nil, ErrInvalidDedupCertificate
+			}
+
+			// The PCs are shared by the ROUND CHANGE messages
+			pc = protoBuf.Clone(certificates[index-1]).(*proto.PreparedCertificate)
+		}
+
+		if dedupRoundChange.CertificateProposal {
+			if pc == nil {
+				return nil, ErrInvalidDedupCertificate
+			}
+
+			proposal = ExtractProposal(pc.ProposalMessage)
+		}
+
+		roundChanges = append(roundChanges, &proto.Message{
+			View: &proto.View{
+				Height: dedup.View.Height,
+				Round:  dedup.View.Round,
+			},
+			From:      dedupRoundChange.From,
+			Signature: dedupRoundChange.Signature,
+			FromIndex: dedupRoundChange.FromIndex,
+			Type:      proto.MessageType_ROUND_CHANGE,
+			Payload: &proto.Message_RoundChangeData{
+				RoundChangeData: &proto.RoundChangeMessage{
+					LastPreparedProposal:      proposal,
+					LatestPreparedCertificate: pc,
+				},
+			},
+		})
+	}
+
+	return &proto.RoundChangeCertificate{
+		RoundChangeMessages: roundChanges,
+	}, nil
+}
+
+// DedupCodec is a codec using the proto wire encoding, that deduplicates the
+// certificates carried by PREPREPARE and ROUND CHANGE messages. It roughly halves
+// the round change bandwidth for big committees. The deduplication is transparent,
+// as the certificates are restored on decode; however, all nodes
+// in the network must use the codec
+type DedupCodec struct{}
+
+// Marshal encodes the message, deduplicating its certificate, if any.
+// Certificates that cannot be deduplicated are encoded as is
+func (DedupCodec) Marshal(message *proto.Message) ([]byte, error) {
+	switch payload := message.Payload.(type) {
+	case *proto.Message_PreprepareData:
+		dedup, err := DedupRoundChangeCertificate(payload.PreprepareData.GetCertificate())
+		if err != nil || dedup == nil {
+			break
+		}
+
+		message = protoBuf.Clone(message).(*proto.Message)
+		data := message.GetPreprepareData()
+
+		data.Certificate = nil
+		data.DedupCertificate = dedup
+	case *proto.Message_RoundChangeData:
+		dedup, err := DedupPreparedCertificate(payload.RoundChangeData.GetLatestPreparedCertificate())
+		if err != nil || dedup == nil {
+			break
+		}
+
+		message = protoBuf.Clone(message).(*proto.Message)
+		data := message.GetRoundChangeData()
+
+		data.LatestPreparedCertificate = nil
+		data.DedupCertificate = dedup
+	}
+
+	return protoBuf.Marshal(message)
+}
+
+// Unmarshal decodes the message, restoring its deduplicated certificate, if any
+func (DedupCodec) Unmarshal(data []byte, message *proto.Message) error {
+	if err := protoBuf.Unmarshal(data, message); err != nil {
+		return err
+	}
+
+	if preprepare := message.GetPreprepareData(); preprepare.GetDedupCertificate() != nil {
+		certificate, err := ExpandRoundChangeCertificateDedup(preprepare.DedupCertificate)
+		if err != nil {
+			return err
+		}
+
+		preprepare.Certificate = certificate
+		preprepare.DedupCertificate = nil
+	}
+
+	if roundChange := message.GetRoundChangeData(); roundChange.GetDedupCertificate() != nil {
+		certificate, err := ExpandPreparedCertificateDedup(roundChange.DedupCertificate)
+		if err != nil {
+			return err
+		}
+
+		roundChange.LatestPreparedCertificate = certificate
+		roundChange.DedupCertificate = nil
+	}
+
+	return nil
+}
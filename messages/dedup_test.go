@@ -0,0 +1,221 @@
+package messages
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	protoBuf "google.golang.org/protobuf/proto"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// buildDedupTestRoundChange builds a ROUND CHANGE message with the specified PC
+func buildDedupTestRoundChange(
+	view *proto.View,
+	from []byte,
+	pc *proto.PreparedCertificate,
+) *proto.Message {
+	data := &proto.RoundChangeMessage{}
+	if pc != nil {
+		data.LastPreparedProposal = ExtractProposal(pc.ProposalMessage)
+		data.LatestPreparedCertificate = pc
+	}
+
+	return &proto.Message{
+		View:      &proto.View{Height: view.Height, Round: view.Round},
+		From:      from,
+		Signature: []byte(fmt.Sprintf("round change signature %s", from)),
+		Type:      proto.MessageType_ROUND_CHANGE,
+		Payload: &proto.Message_RoundChangeData{
+			RoundChangeData: data,
+		},
+	}
+}
+
+// buildDedupTestProposal builds a PREPREPARE message with an RCC
+// of ROUND CHANGE messages, most of which carry the same PC
+func buildDedupTestProposal(numValidators int) *proto.Message {
+	var (
+		validators = generateValidators(numValidators)
+		view       = &proto.View{Height: 10, Round: 3}
+		signers    = make([]int, 0, numValidators)
+	)
+
+	for index := 0; index < numValidators*2/3+1; index++ {
+		signers = append(signers, index)
+	}
+
+	var (
+		pc      = buildCompactTestPC(&proto.View{Height: 10, Round: 2}, validators, signers...)
+		otherPC = buildCompactTestPC(&proto.View{Height: 10, Round: 1}, validators, signers...)
+
+		roundChanges = make([]*proto.Message, 0, len(signers))
+	)
+
+	otherPC.ProposalMessage.GetPreprepareData().Proposal.RawProposal = []byte("other raw proposal")
+
+	for _, signer := range signers {
+		switch signer {
+		case 0:
+			roundChanges = append(roundChanges, buildDedupTestRoundChange(view, validators[signer], nil))
+		case 1:
+			roundChanges = append(roundChanges, buildDedupTestRoundChange(view, validators[signer], otherPC))
+		default:
+			roundChanges = append(roundChanges, buildDedupTestRoundChange(view, validators[signer], pc))
+		}
+	}
+
+	return &proto.Message{
+		View:      view,
+		From:      validators[0],
+		Signature: []byte("proposal signature"),
+		Type:      proto.MessageType_PREPREPARE,
+		Payload: &proto.Message_PreprepareData{
+			PreprepareData: &proto.PrePrepareMessage{
+				Proposal:     ExtractProposal(pc.ProposalMessage),
+				ProposalHash: ExtractProposalHash(pc.ProposalMessage),
+				Certificate: &proto.RoundChangeCertificate{
+					RoundChangeMessages: roundChanges,
+				},
+			},
+		},
+	}
+}
+
+// assertDedupRoundTrip makes sure the message is restored
+// by the dedup codec, and returns the encoded message
+func assertDedupRoundTrip(t *testing.T, message *proto.Message) []byte {
+	t.Helper()
+
+	codec := DedupCodec{}
+
+	data, err := codec.Marshal(message)
+	if !assert.NoError(t, err) {
+		return nil
+	}
+
+	decoded := &proto.Message{}
+	if !assert.NoError(t, codec.Unmarshal(data, decoded)) {
+		return nil
+	}
+
+	assert.True(t, protoBuf.Equal(message, decoded))
+
+	// Make sure the signed payload is restored
+	expectedPayload, _ := message.PayloadNoSig()
+	payload, _ := decoded.PayloadNoSig()
+
+	assert.Equal(t, expectedPayload, payload)
+
+	return data
+}
+
+func TestDedupCodec_Proposal(t *testing.T) {
+	t.Parallel()
+
+	var (
+		message = buildDedupTestProposal(100)
+		data    = assertDedupRoundTrip(t, message)
+	)
+
+	// Make sure the distinct PCs are encoded once
+	dedup, err := DedupRoundChangeCertificate(ExtractRoundChangeCertificate(message))
+	if assert.NoError(t, err) {
+		assert.Len(t, dedup.Certificates, 2)
+	}
+
+	// Make sure the encoding is at least halved
+	raw, _ := ProtoCodec{}.Marshal(message)
+	assert.Less(t, 2*len(data), len(raw))
+
+	// Make sure the message is not modified
+	assert.NotNil(t, ExtractRoundChangeCertificate(message))
+	assert.Nil(t, message.GetPreprepareData().DedupCertificate)
+}
+
+func TestDedupCodec_RoundChange(t *testing.T) {
+	t.Parallel()
+
+	var (
+		validators = generateValidators(10)
+		pc         = buildCompactTestPC(&proto.View{Height: 10, Round: 2}, validators, 1, 2, 3)
+		view       = &proto.View{Height: 10, Round: 3}
+	)
+
+	assertDedupRoundTrip(t, buildDedupTestRoundChange(view, validators[0], pc))
+	assertDedupRoundTrip(t, buildDedupTestRoundChange(view, validators[0], nil))
+}
+
+func TestDedupCodec_Fallback(t *testing.T) {
+	t.Parallel()
+
+	message := buildDedupTestProposal(4)
+
+	// Make sure inconsistent certificates are encoded as is
+	ExtractRoundChangeCertificate(message).RoundChangeMessages[0].View.Round++
+
+	_, err := DedupRoundChangeCertificate(ExtractRoundChangeCertificate(message))
+	assert.ErrorIs(t, err, ErrInconsistentCertificate)
+
+	data := assertDedupRoundTrip(t, message)
+	raw, _ := ProtoCodec{}.Marshal(message)
+
+	assert.Equal(t, raw, data)
+
+	// Make sure messages without certificates are encoded as is
+	prepare := generateRandomMessages(1, &proto.View{Height: 1}, proto.MessageType_PREPARE)[0]
+
+	data = assertDedupRoundTrip(t, prepare)
+	raw, _ = ProtoCodec{}.Marshal(prepare)
+
+	assert.Equal(t, raw, data)
+}
+
+func TestDedupCodec_InvalidCertificate(t *testing.T) {
+	t.Parallel()
+
+	testTable := []struct {
+		name        string
+		roundChange *proto.DedupRoundChange
+	}{
+		{
+			"missing certificate",
+			&proto.DedupRoundChange{Certificate: 1},
+		},
+		{
+			"missing certificate proposal",
+			&proto.DedupRoundChange{CertificateProposal: true},
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			data, err := protoBuf.Marshal(&proto.Message{
+				View: &proto.View{Height: 1, Round: 1},
+				Type: proto.MessageType_PREPREPARE,
+				Payload: &proto.Message_PreprepareData{
+					PreprepareData: &proto.PrePrepareMessage{
+						DedupCertificate: &proto.DedupRoundChangeCertificate{
+							View:         &proto.View{Height: 1, Round: 1},
+							RoundChanges: []*proto.DedupRoundChange{testCase.roundChange},
+						},
+					},
+				},
+			})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.ErrorIs(
+				t,
+				DedupCodec{}.Unmarshal(data, &proto.Message{}),
+				ErrInvalidDedupCertificate,
+			)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package messages
+
+import (
+	"crypto/sha256"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// Digest is the canonical representation of a message,
+// computed once per stored message and cached
+type Digest struct {
+	// SigningPayload is the encoding of the message covered
+	// by the signature (see proto.Message.PayloadNoSig).
+	// It is shared, and must not be modified
+	SigningPayload []byte
+
+	// Hash is the SHA-256 hash of the signing payload and the signature.
+	// It identifies the message regardless of its relay metadata
+	Hash [sha256.Size]byte
+}
+
+// MessageDigest returns the digest of the message. The digest of a message kept
+// in a store is computed on first access and cached, so the message is not re-encoded
+// by the subsequent verifications. The digest of any other message is computed on each access
+func MessageDigest(message *proto.Message) (*Digest, error) {
+	if message == nil {
+		return nil, ErrNilMessage
+	}
+
+	metadata := metadataCache.lookup(message)
+
+	metadata.digestOnce.Do(func() {
+		signingPayload, err := message.PayloadNoSig()
+		if err != nil {
+			metadata.digestErr = err
+
+			return
+		}
+
+		hasher := sha256.New()
+		hasher.Write(signingPayload)
+		hasher.Write(message.Signature)
+
+		digest := &Digest{
+			SigningPayload: signingPayload,
+		}
+		copy(digest.Hash[:], hasher.Sum(nil))
+
+		metadata.digest = digest
+	})
+
+	return metadata.digest, metadata.digestErr
+}
@@ -0,0 +1,144 @@
+package messages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	protoBuf "google.golang.org/protobuf/proto"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// buildDigestMessage builds a signed PREPARE message for the height
+func buildDigestMessage(height uint64) *proto.Message {
+	return &proto.Message{
+		View:      &proto.View{Height: height, Round: 1},
+		From:      []byte("validator"),
+		Signature: []byte("signature"),
+		Type:      proto.MessageType_PREPARE,
+		Payload: &proto.Message_PrepareData{
+			PrepareData: &proto.PrepareMessage{
+				ProposalHash: []byte("proposal hash"),
+			},
+		},
+		Ttl:  3,
+		Hops: 1,
+	}
+}
+
+func TestMessageDigest(t *testing.T) {
+	t.Parallel()
+
+	message := buildDigestMessage(1020)
+
+	digest, err := MessageDigest(message)
+	require.NoError(t, err)
+
+	// Make sure the signing payload is the signed data
+	signingPayload, err := message.PayloadNoSig()
+	require.NoError(t, err)
+
+	assert.Equal(t, signingPayload, digest.SigningPayload)
+
+	// Make sure the digest is not cached for a message that is not stored
+	uncached, err := MessageDigest(message)
+	require.NoError(t, err)
+
+	assert.NotSame(t, digest, uncached)
+	assert.Equal(t, digest, uncached)
+
+	// Make sure the digest is cached once the message is stored
+	store := NewMessages()
+	defer store.Close()
+
+	store.AddMessage(message)
+
+	digest, err = MessageDigest(message)
+	require.NoError(t, err)
+
+	cached, err := MessageDigest(message)
+	require.NoError(t, err)
+
+	assert.Same(t, digest, cached)
+
+	// Make sure the relay metadata does not change the digest
+	relayed, ok := protoBuf.Clone(message).(*proto.Message)
+	require.True(t, ok)
+
+	relayed.Ttl, relayed.Hops = 1, 3
+
+	relayedDigest, err := MessageDigest(relayed)
+	require.NoError(t, err)
+
+	assert.Equal(t, digest.Hash, relayedDigest.Hash)
+
+	// Make sure the signature changes the digest
+	resigned, ok := protoBuf.Clone(message).(*proto.Message)
+	require.True(t, ok)
+
+	resigned.Signature = []byte("other signature")
+
+	resignedDigest, err := MessageDigest(resigned)
+	require.NoError(t, err)
+
+	assert.NotEqual(t, digest.Hash, resignedDigest.Hash)
+}
+
+func TestMessageDigest_NilMessage(t *testing.T) {
+	t.Parallel()
+
+	_, err := MessageDigest(nil)
+	assert.ErrorIs(t, err, ErrNilMessage)
+}
+
+// TestMessageMetadataCache makes sure the cache keeps the metadata
+// of the stored messages only, until all their stores prune them
+func TestMessageMetadataCache(t *testing.T) {
+	t.Parallel()
+
+	var (
+		cache  = newMessageMetadataCache(2)
+		stores = []*Messages{{}, {}}
+
+		messages = []*proto.Message{
+			buildDigestMessage(1),
+			buildDigestMessage(2),
+			buildDigestMessage(3),
+		}
+	)
+
+	// Make sure the lookups do not cache the messages
+	cache.lookup(messages[0])
+
+	assert.False(t, cache.cached(messages[0]))
+
+	cache.track(stores[0], messages[0])
+	cache.track(stores[1], messages[0])
+	cache.track(stores[0], messages[1])
+
+	assert.Same(t, cache.lookup(messages[0]), cache.lookup(messages[0]))
+
+	// Make sure the prune of a store does not drop the messages of the other stores
+	cache.prune(stores[0], 3)
+
+	assert.True(t, cache.cached(messages[0]))
+	assert.False(t, cache.cached(messages[1]))
+
+	cache.prune(stores[1], 3)
+
+	assert.False(t, cache.cached(messages[0]))
+
+	// Make sure the oldest message is dropped once the capacity is reached
+	for _, message := range messages {
+		cache.track(stores[0], message)
+	}
+
+	assert.False(t, cache.cached(messages[0]))
+	assert.True(t, cache.cached(messages[1]))
+	assert.True(t, cache.cached(messages[2]))
+
+	// Make sure the evicted message is no longer indexed for the store
+	assert.Len(t, cache.owned[stores[0]], 2)
+	assert.NotContains(t, cache.owned[stores[0]], messages[0])
+}
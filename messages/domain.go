@@ -0,0 +1,131 @@
+package messages
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// signingDomainTag prefixes all signing preimages, so they cannot collide with
+// other data signed by the validator keys (ex. the committed seals,
+// which sign the bare proposal hash). It is versioned, so the preimage layout
+// can change without the old signatures verifying against the new layout
+const signingDomainTag = "ibft/message/v1"
+
+var (
+	// ErrUnknownMessageType is an error indicating the message type
+	// has no signing preimage
+	ErrUnknownMessageType = errors.New("unknown message type")
+
+	// ErrNilView is an error indicating the message has no view
+	ErrNilView = errors.New("message view is nil")
+)
+
+// messageTypeTags are the type tags of the signing preimages. They are fixed
+// strings, so renaming the enum values does not invalidate the signatures
+var messageTypeTags = map[proto.MessageType]string{
+	proto.MessageType_PREPREPARE:           "PREPREPARE",
+	proto.MessageType_PREPARE:              "PREPARE",
+	proto.MessageType_COMMIT:               "COMMIT",
+	proto.MessageType_ROUND_CHANGE:         "ROUND_CHANGE",
+	proto.MessageType_PROPOSER_UNAVAILABLE: "PROPOSER_UNAVAILABLE",
+	proto.MessageType_DKG:                  "DKG",
+	proto.MessageType_CURRENT_VIEW:         "CURRENT_VIEW",
+	proto.MessageType_FINALITY:             "FINALITY",
+	proto.MessageType_VETO:                 "VETO",
+}
+
+// PreimageSigner signs the signing preimages of the node's messages
+type PreimageSigner interface {
+	// SignPreimage signs the preimage
+	SignPreimage(preimage []byte) ([]byte, error)
+}
+
+// PreimageVerifier verifies the signatures over the signing preimages
+type PreimageVerifier interface {
+	// VerifyPreimage checks if the preimage is signed by the sender
+	VerifyPreimage(from, preimage, signature []byte) bool
+}
+
+// SigningDomain separates the message signatures of a chain from the signatures
+// of other chains (ex. a testnet sharing the validator keys with the mainnet),
+// and the signatures of a message type from the signatures of other types
+type SigningDomain struct {
+	// ChainID identifies the chain
+	ChainID []byte
+}
+
+// Preimage returns the signing preimage of the message. The preimage is the
+// concatenation of:
+//   - the domain tag
+//   - the length-prefixed chain ID
+//   - the length-prefixed message type tag
+//   - the big-endian view height and round
+//   - the SHA-256 hash of the signing payload (see proto.Message.PayloadNoSig)
+//
+// The fixed-size fields are encoded explicitly, so a signature over the preimage
+// is bound to the chain, the view and the message type, regardless of the payload encoding
+func (d SigningDomain) Preimage(message *proto.Message) ([]byte, error) {
+	if message == nil {
+		return nil, ErrNilMessage
+	}
+
+	if message.View == nil {
+		return nil, ErrNilView
+	}
+
+	typeTag, ok := messageTypeTags[message.Type]
+	if !ok {
+		return nil, ErrUnknownMessageType
+	}
+
+	payload, err := message.PayloadNoSig()
+	if err != nil {
+		return nil, err
+	}
+
+	payloadHash := sha256.Sum256(payload)
+
+	preimage := make([]byte, 0, len(signingDomainTag)+len(d.ChainID)+len(typeTag)+4*binary.MaxVarintLen64)
+	preimage = append(preimage, signingDomainTag...)
+	preimage = binary.AppendUvarint(preimage, uint64(len(d.ChainID)))
+	preimage = append(preimage, d.ChainID...)
+	preimage = binary.AppendUvarint(preimage, uint64(len(typeTag)))
+	preimage = append(preimage, typeTag...)
+	preimage = binary.BigEndian.AppendUint64(preimage, message.View.Height)
+	preimage = binary.BigEndian.AppendUint64(preimage, message.View.Round)
+	preimage = append(preimage, payloadHash[:]...)
+
+	return preimage, nil
+}
+
+// Sign signs the preimage of the message using the signer,
+// and sets the message signature
+func (d SigningDomain) Sign(message *proto.Message, signer PreimageSigner) error {
+	preimage, err := d.Preimage(message)
+	if err != nil {
+		return err
+	}
+
+	signature, err := signer.SignPreimage(preimage)
+	if err != nil {
+		return err
+	}
+
+	message.Signature = signature
+
+	return nil
+}
+
+// Verify checks if the message signature is the signature
+// of the message sender over the preimage of the message
+func (d SigningDomain) Verify(message *proto.Message, verifier PreimageVerifier) bool {
+	preimage, err := d.Preimage(message)
+	if err != nil {
+		return false
+	}
+
+	return verifier.VerifyPreimage(message.From, preimage, message.Signature)
+}
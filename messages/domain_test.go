@@ -0,0 +1,209 @@
+package messages
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	protoBuf "google.golang.org/protobuf/proto"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// hashPreimageSigner is a mock preimage signer, whose signature
+// is the hash of the sender and the preimage
+type hashPreimageSigner struct {
+	id []byte
+}
+
+func (s hashPreimageSigner) SignPreimage(preimage []byte) ([]byte, error) {
+	return hashSignature(s.id, preimage), nil
+}
+
+// hashPreimageVerifier is a mock preimage verifier, accepting the hash signatures
+type hashPreimageVerifier struct{}
+
+func (hashPreimageVerifier) VerifyPreimage(from, preimage, signature []byte) bool {
+	return bytes.Equal(hashSignature(from, preimage), signature)
+}
+
+// signedMessage returns the message of the type, signed in the domain
+func signedMessage(t *testing.T, domain SigningDomain, messageType proto.MessageType) *proto.Message {
+	t.Helper()
+
+	message := generateRandomMessages(1, &proto.View{Height: 4, Round: 2}, messageType)[0]
+
+	assert.NoError(t, domain.Sign(message, hashPreimageSigner{id: message.From}))
+
+	return message
+}
+
+func TestSigningDomain_SignVerify(t *testing.T) {
+	t.Parallel()
+
+	domain := SigningDomain{ChainID: []byte("mainnet")}
+
+	for messageType := range messageTypeTags {
+		messageType := messageType
+
+		t.Run(messageType.String(), func(t *testing.T) {
+			t.Parallel()
+
+			message := signedMessage(t, domain, messageType)
+
+			assert.NotEmpty(t, message.Signature)
+			assert.True(t, domain.Verify(message, hashPreimageVerifier{}))
+
+			// Make sure the relay metadata is not covered by the signature
+			message.Ttl, message.Hops = 5, 2
+
+			assert.True(t, domain.Verify(message, hashPreimageVerifier{}))
+		})
+	}
+}
+
+func TestSigningDomain_Reuse(t *testing.T) {
+	t.Parallel()
+
+	domain := SigningDomain{ChainID: []byte("mainnet")}
+
+	testTable := []struct {
+		name   string
+		domain SigningDomain
+		modify func(message *proto.Message)
+	}{
+		{
+			name:   "other chain",
+			domain: SigningDomain{ChainID: []byte("testnet")},
+			modify: func(_ *proto.Message) {},
+		},
+		{
+			name:   "no chain",
+			domain: SigningDomain{},
+			modify: func(_ *proto.Message) {},
+		},
+		{
+			name:   "other type",
+			domain: domain,
+			modify: func(message *proto.Message) {
+				message.Type = proto.MessageType_COMMIT
+			},
+		},
+		{
+			name:   "other height",
+			domain: domain,
+			modify: func(message *proto.Message) {
+				message.View = &proto.View{Height: 5, Round: 2}
+			},
+		},
+		{
+			name:   "other round",
+			domain: domain,
+			modify: func(message *proto.Message) {
+				message.View = &proto.View{Height: 4, Round: 3}
+			},
+		},
+		{
+			name:   "other payload",
+			domain: domain,
+			modify: func(message *proto.Message) {
+				message.Payload = &proto.Message_PrepareData{
+					PrepareData: &proto.PrepareMessage{ProposalHash: []byte("other hash")},
+				}
+			},
+		},
+		{
+			name:   "other sender",
+			domain: domain,
+			modify: func(message *proto.Message) {
+				message.From = []byte("other sender")
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			message := signedMessage(t, domain, proto.MessageType_PREPARE)
+
+			testCase.modify(message)
+
+			// Make sure the signature is not accepted outside of its domain
+			assert.False(t, testCase.domain.Verify(message, hashPreimageVerifier{}))
+		})
+	}
+}
+
+func TestSigningDomain_Preimage(t *testing.T) {
+	t.Parallel()
+
+	message := generateRandomMessages(1, &proto.View{Height: 4, Round: 2}, proto.MessageType_PREPARE)[0]
+
+	preimage, err := SigningDomain{ChainID: []byte("mainnet")}.Preimage(message)
+	assert.NoError(t, err)
+
+	payload, err := message.PayloadNoSig()
+	assert.NoError(t, err)
+
+	payloadHash := sha256.Sum256(payload)
+
+	// Make sure the preimage is bound to the domain, the type and the view
+	assert.Equal(t, append([]byte(
+		"ibft/message/v1"+
+			"\x07mainnet"+
+			"\x07PREPARE"+
+			"\x00\x00\x00\x00\x00\x00\x00\x04"+
+			"\x00\x00\x00\x00\x00\x00\x00\x02",
+	), payloadHash[:]...), preimage)
+
+	// Make sure the preimages of the messages without a view or a known type are rejected
+	_, err = SigningDomain{}.Preimage(nil)
+	assert.ErrorIs(t, err, ErrNilMessage)
+
+	_, err = SigningDomain{}.Preimage(&proto.Message{Type: proto.MessageType_PREPARE})
+	assert.ErrorIs(t, err, ErrNilView)
+
+	_, err = SigningDomain{}.Preimage(&proto.Message{View: &proto.View{}, Type: proto.MessageType(100)})
+	assert.ErrorIs(t, err, ErrUnknownMessageType)
+
+	assert.False(t, SigningDomain{}.Verify(&proto.Message{}, hashPreimageVerifier{}))
+}
+
+func TestSigningDomain_LazyCodec(t *testing.T) {
+	t.Parallel()
+
+	var (
+		domain  = SigningDomain{ChainID: []byte("mainnet")}
+		codec   = LazyCodec{MinLazySize: 1}
+		message = &proto.Message{
+			View: &proto.View{Height: 4, Round: 2},
+			From: []byte("node 1"),
+			Type: proto.MessageType_ROUND_CHANGE,
+			Payload: &proto.Message_RoundChangeData{
+				RoundChangeData: &proto.RoundChangeMessage{
+					LastPreparedProposal: &proto.Proposal{
+						RawProposal: []byte("proposal"),
+						Round:       1,
+					},
+				},
+			},
+		}
+	)
+
+	assert.NoError(t, domain.Sign(message, hashPreimageSigner{id: message.From}))
+
+	data, err := codec.Marshal(message)
+	assert.NoError(t, err)
+
+	decoded := &proto.Message{}
+	assert.NoError(t, codec.Unmarshal(data, decoded))
+
+	// Make sure the lazily decoded messages verify without decoding the payload
+	assert.Nil(t, decoded.Payload)
+	assert.True(t, domain.Verify(decoded, hashPreimageVerifier{}))
+	assert.True(t, protoBuf.Equal(message.View, decoded.View))
+}
@@ -0,0 +1,105 @@
+package messages
+
+import (
+	"bytes"
+	"errors"
+
+	protoBuf "google.golang.org/protobuf/proto"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+var (
+	// ErrInvalidEnvelopeSignature is an error indicating the envelope
+	// payload is not signed by the envelope sender
+	ErrInvalidEnvelopeSignature = errors.New("invalid envelope signature")
+
+	// ErrEnvelopeSenderMismatch is an error indicating the message
+	// in the envelope is not sent by the envelope sender
+	ErrEnvelopeSenderMismatch = errors.New("envelope sender does not match the message sender")
+)
+
+// EnvelopeSigner signs the envelopes of the messages sent by the node
+type EnvelopeSigner interface {
+	// ID returns the address of the node, recorded as the envelope sender
+	ID() []byte
+
+	// SignEnvelope signs the envelope payload
+	SignEnvelope(payload []byte) ([]byte, error)
+}
+
+// EnvelopeVerifier verifies the envelopes of the received messages
+type EnvelopeVerifier interface {
+	// VerifyEnvelope checks if the envelope payload is signed by the sender
+	VerifyEnvelope(from, payload, signature []byte) bool
+}
+
+// EnvelopeMarshaler is a Marshaler that seals the encoded messages in signed envelopes.
+// Only the node's own messages can be sealed, relayed messages
+// are forwarded in the envelopes of their senders
+type EnvelopeMarshaler struct {
+	// marshaler encodes the messages into the envelope payload
+	marshaler Marshaler
+
+	// signer signs the envelopes
+	signer EnvelopeSigner
+}
+
+// NewEnvelopeMarshaler creates a new marshaler that encodes the messages
+// using the passed in marshaler, and seals them in envelopes signed by the signer
+func NewEnvelopeMarshaler(marshaler Marshaler, signer EnvelopeSigner) *EnvelopeMarshaler {
+	return &EnvelopeMarshaler{
+		marshaler: marshaler,
+		signer:    signer,
+	}
+}
+
+// Marshal encodes the message, and seals it in a signed envelope
+func (m *EnvelopeMarshaler) Marshal(message *proto.Message) ([]byte, error) {
+	payload, err := m.marshaler.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := m.signer.SignEnvelope(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return protoBuf.Marshal(&proto.Envelope{
+		Payload:   payload,
+		Signature: signature,
+		From:      m.signer.ID(),
+	})
+}
+
+// OpenEnvelope verifies the signature of the envelope, and decodes its payload
+// into the message using the unmarshaler. The payload is decoded only if the signature
+// is valid, so unauthenticated data never reaches the decoder. The message must be
+// sent by the envelope sender, which makes the sender of the message authenticated
+func OpenEnvelope(
+	data []byte,
+	verifier EnvelopeVerifier,
+	unmarshaler Unmarshaler,
+	message *proto.Message,
+) error {
+	envelope := &proto.Envelope{}
+	if err := protoBuf.Unmarshal(data, envelope); err != nil {
+		return err
+	}
+
+	if len(envelope.From) == 0 ||
+		!verifier.VerifyEnvelope(envelope.From, envelope.Payload, envelope.Signature) {
+		return ErrInvalidEnvelopeSignature
+	}
+
+	if err := unmarshaler.Unmarshal(envelope.Payload, message); err != nil {
+		return err
+	}
+
+	if !bytes.Equal(message.From, envelope.From) {
+		return ErrEnvelopeSenderMismatch
+	}
+
+	return nil
+}
@@ -0,0 +1,190 @@
+package messages
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	protoBuf "google.golang.org/protobuf/proto"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+var errSigningFailed = errors.New("signing failed")
+
+// hashSigner is a mock envelope signer, whose signature
+// is the hash of the sender and the payload
+type hashSigner struct {
+	id  []byte
+	err error
+}
+
+func (s hashSigner) ID() []byte {
+	return s.id
+}
+
+func (s hashSigner) SignEnvelope(payload []byte) ([]byte, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	return hashSignature(s.id, payload), nil
+}
+
+// hashVerifier is a mock envelope verifier, accepting the hash signatures of the validators
+type hashVerifier struct {
+	validators [][]byte
+}
+
+func (v hashVerifier) VerifyEnvelope(from, payload, signature []byte) bool {
+	for _, validator := range v.validators {
+		if bytes.Equal(validator, from) {
+			return bytes.Equal(hashSignature(from, payload), signature)
+		}
+	}
+
+	return false
+}
+
+// hashSignature returns the hash signature of the sender over the payload
+func hashSignature(from, payload []byte) []byte {
+	hash := sha256.Sum256(append(append([]byte(nil), from...), payload...))
+
+	return hash[:]
+}
+
+func TestEnvelope(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view     = &proto.View{Height: 1, Round: 2}
+		sender   = []byte("node 1")
+		verifier = hashVerifier{validators: [][]byte{sender, []byte("node 2")}}
+	)
+
+	message := generateRandomMessages(1, view, proto.MessageType_PREPARE)[0]
+	message.From = sender
+
+	// seal seals the message, and modifies the envelope before it is sent
+	seal := func(t *testing.T, signer hashSigner, modify func(envelope *proto.Envelope)) []byte {
+		t.Helper()
+
+		data, err := NewEnvelopeMarshaler(ProtoCodec{}, signer).Marshal(message)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		envelope := &proto.Envelope{}
+		assert.NoError(t, protoBuf.Unmarshal(data, envelope))
+
+		modify(envelope)
+
+		data, err = protoBuf.Marshal(envelope)
+		assert.NoError(t, err)
+
+		return data
+	}
+
+	testTable := []struct {
+		name   string
+		signer hashSigner
+		modify func(envelope *proto.Envelope)
+		err    error
+	}{
+		{
+			name:   "valid envelope",
+			signer: hashSigner{id: sender},
+			modify: func(_ *proto.Envelope) {},
+		},
+		{
+			name:   "tampered payload",
+			signer: hashSigner{id: sender},
+			modify: func(envelope *proto.Envelope) {
+				envelope.Payload = append(envelope.Payload, 0)
+			},
+			err: ErrInvalidEnvelopeSignature,
+		},
+		{
+			name:   "forged sender",
+			signer: hashSigner{id: sender},
+			modify: func(envelope *proto.Envelope) {
+				envelope.From = []byte("node 2")
+			},
+			err: ErrInvalidEnvelopeSignature,
+		},
+		{
+			name:   "missing sender",
+			signer: hashSigner{id: sender},
+			modify: func(envelope *proto.Envelope) {
+				envelope.From = nil
+			},
+			err: ErrInvalidEnvelopeSignature,
+		},
+		{
+			name:   "unknown sender",
+			signer: hashSigner{id: []byte("node 3")},
+			modify: func(_ *proto.Envelope) {},
+			err:    ErrInvalidEnvelopeSignature,
+		},
+		{
+			name:   "message of another sender",
+			signer: hashSigner{id: []byte("node 2")},
+			modify: func(_ *proto.Envelope) {},
+			err:    ErrEnvelopeSenderMismatch,
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			opened := &proto.Message{}
+
+			err := OpenEnvelope(seal(t, testCase.signer, testCase.modify), verifier, ProtoCodec{}, opened)
+			if testCase.err != nil {
+				assert.ErrorIs(t, err, testCase.err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.True(t, protoBuf.Equal(message, opened))
+		})
+	}
+}
+
+func TestEnvelope_InvalidData(t *testing.T) {
+	t.Parallel()
+
+	verifier := hashVerifier{validators: [][]byte{[]byte("node 1")}}
+
+	// Make sure corrupted envelopes are rejected
+	assert.Error(t, OpenEnvelope([]byte{0xff, 0xff}, verifier, ProtoCodec{}, &proto.Message{}))
+
+	// Make sure signed payloads that are not messages are rejected
+	payload := []byte{0xff, 0xff}
+
+	data, err := protoBuf.Marshal(&proto.Envelope{
+		Payload:   payload,
+		Signature: hashSignature([]byte("node 1"), payload),
+		From:      []byte("node 1"),
+	})
+	assert.NoError(t, err)
+
+	err = OpenEnvelope(data, verifier, ProtoCodec{}, &proto.Message{})
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrInvalidEnvelopeSignature)
+}
+
+func TestEnvelopeMarshaler_SigningFailed(t *testing.T) {
+	t.Parallel()
+
+	marshaler := NewEnvelopeMarshaler(ProtoCodec{}, hashSigner{err: errSigningFailed})
+
+	_, err := marshaler.Marshal(&proto.Message{})
+	assert.ErrorIs(t, err, errSigningFailed)
+}
@@ -0,0 +1,100 @@
+package messages
+
+import (
+	protoBuf "google.golang.org/protobuf/proto"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// EquivocationPolicy is the policy for handling conflicting messages, which a sender
+// submitted for the same view and message type. Resends of the same signed message
+// (ex. relayed by different peers) are not conflicting
+type EquivocationPolicy uint8
+
+const (
+	// EquivocationKeepLatest keeps the latest received message. The ROUND_CHANGE
+	// messages are kept according to the round change retention policy instead
+	// (see WithRoundChangeRetention). This is the default policy
+	EquivocationKeepLatest EquivocationPolicy = iota
+
+	// EquivocationKeepFirst keeps the first received message
+	EquivocationKeepFirst
+
+	// EquivocationReject keeps the first received message, and reports each rejected
+	// conflicting message as evidence to the equivocation handler
+	// (see WithEquivocationHandler)
+	EquivocationReject
+)
+
+// Equivocation is the evidence of a sender submitting conflicting messages
+// for the same view and message type. Both messages are signed by the sender
+type Equivocation struct {
+	// Stored is the message kept in the store
+	Stored *proto.Message
+
+	// Rejected is the conflicting message, which was not stored
+	Rejected *proto.Message
+}
+
+// EquivocationHandler is the handler of the equivocation evidence. It is called
+// while the store is locked for the message type, so it must not call into the store
+type EquivocationHandler func(evidence Equivocation)
+
+// WithEquivocationPolicy sets the policy for handling conflicting messages
+// a sender submitted for the same view and message type
+func WithEquivocationPolicy(policy EquivocationPolicy) Option {
+	return func(ms *Messages) {
+		ms.equivocationPolicy = policy
+	}
+}
+
+// WithEquivocationHandler sets the handler of the equivocation evidence,
+// reported with the EquivocationReject policy
+func WithEquivocationHandler(handler EquivocationHandler) Option {
+	return func(ms *Messages) {
+		ms.equivocationHandler = handler
+	}
+}
+
+// replacesStored checks if the received message replaces the stored message
+// of the same sender, according to the equivocation policy
+func (ms *Messages) replacesStored(stored, received *proto.Message, counters *messageCounters) bool {
+	if !conflicts(stored, received) {
+		return true
+	}
+
+	switch ms.equivocationPolicy {
+	case EquivocationKeepFirst:
+		return false
+	case EquivocationReject:
+		counters.equivocations++
+
+		if ms.equivocationHandler != nil {
+			ms.equivocationHandler(Equivocation{
+				Stored:   stored,
+				Rejected: received,
+			})
+		}
+
+		return false
+	}
+
+	if received.Type == proto.MessageType_ROUND_CHANGE {
+		return ms.roundChangeRetention.replaces(stored, received)
+	}
+
+	return true
+}
+
+// conflicts checks if the messages of the same sender are different signed messages.
+// The unsigned relay metadata is not compared
+func conflicts(stored, received *proto.Message) bool {
+	storedDigest, storedErr := MessageDigest(stored)
+	receivedDigest, receivedErr := MessageDigest(received)
+
+	if storedErr != nil || receivedErr != nil {
+		return !protoBuf.Equal(stored, received)
+	}
+
+	return storedDigest.Hash != receivedDigest.Hash
+}
@@ -0,0 +1,132 @@
+package messages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	protoBuf "google.golang.org/protobuf/proto"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// buildEquivocationPrepare builds a signed PREPARE message of the sender for the proposal hash
+func buildEquivocationPrepare(view *proto.View, proposalHash string) *proto.Message {
+	return &proto.Message{
+		View:      view,
+		From:      []byte("sender"),
+		Signature: []byte("signature of " + proposalHash),
+		Type:      proto.MessageType_PREPARE,
+		Payload: &proto.Message_PrepareData{
+			PrepareData: &proto.PrepareMessage{
+				ProposalHash: []byte(proposalHash),
+			},
+		},
+	}
+}
+
+func TestMessages_EquivocationPolicy(t *testing.T) {
+	t.Parallel()
+
+	testTable := []struct {
+		name          string
+		policy        EquivocationPolicy
+		expected      string
+		equivocations int
+	}{
+		{
+			"latest message kept",
+			EquivocationKeepLatest,
+			"second",
+			0,
+		},
+		{
+			"first message kept",
+			EquivocationKeepFirst,
+			"first",
+			0,
+		},
+		{
+			"conflicting message rejected and reported",
+			EquivocationReject,
+			"first",
+			1,
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				view     = &proto.View{Height: 1010, Round: 0}
+				first    = buildEquivocationPrepare(view, "first")
+				second   = buildEquivocationPrepare(view, "second")
+				evidence []Equivocation
+			)
+
+			messages := NewMessages(
+				WithEquivocationPolicy(testCase.policy),
+				WithEquivocationHandler(func(equivocation Equivocation) {
+					evidence = append(evidence, equivocation)
+				}),
+			)
+			defer messages.Close()
+
+			messages.AddMessage(first)
+			messages.AddMessage(second)
+
+			stored := messages.GetValidMessages(view, proto.MessageType_PREPARE, func(_ *proto.Message) bool {
+				return true
+			})
+
+			if assert.Len(t, stored, 1) {
+				assert.Equal(t, []byte(testCase.expected), stored[0].GetPrepareData().ProposalHash)
+			}
+
+			// Make sure the rejected messages are reported, and counted
+			assert.Len(t, evidence, testCase.equivocations)
+
+			if testCase.equivocations > 0 {
+				assert.Equal(t, Equivocation{Stored: first, Rejected: second}, evidence[0])
+			}
+
+			stats := messages.Stats()
+			if assert.Len(t, stats, 1) {
+				assert.Equal(t, testCase.equivocations, stats[0].Types[proto.MessageType_PREPARE].Equivocations)
+			}
+		})
+	}
+}
+
+func TestMessages_EquivocationPolicy_Resend(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view     = &proto.View{Height: 1011, Round: 0}
+		message  = buildEquivocationPrepare(view, "proposal")
+		evidence []Equivocation
+	)
+
+	messages := NewMessages(
+		WithEquivocationPolicy(EquivocationReject),
+		WithEquivocationHandler(func(equivocation Equivocation) {
+			evidence = append(evidence, equivocation)
+		}),
+	)
+	defer messages.Close()
+
+	// Relay the same signed message, with different relay metadata
+	relayed, _ := protoBuf.Clone(message).(*proto.Message)
+	relayed.Hops = 2
+
+	messages.AddMessage(message)
+	messages.AddMessage(relayed)
+
+	// Make sure resends are not reported as equivocations
+	assert.Empty(t, evidence)
+	assert.Len(t, messages.GetValidMessages(view, proto.MessageType_PREPARE, func(_ *proto.Message) bool {
+		return true
+	}), 1)
+}
@@ -2,6 +2,8 @@
 package messages
 
 import (
+	"context"
+	"runtime/pprof"
 	"sync"
 	"sync/atomic"
 
@@ -12,17 +14,85 @@ import (
 
 type eventManager struct {
 	subscriptions     map[SubscriptionID]*eventSubscription
+	shards            map[shardKey]*subscriptionShard
 	subscriptionsLock sync.RWMutex
 	numSubscriptions  int64
+
+	// dropped is the number of events dropped by all the subscriptions
+	dropped atomic.Uint64
 }
 
 func newEventManager() *eventManager {
 	return &eventManager{
 		subscriptions:    make(map[SubscriptionID]*eventSubscription),
+		shards:           make(map[shardKey]*subscriptionShard),
 		numSubscriptions: 0,
 	}
 }
 
+// shardKey identifies the subscriptions for a message type at a height
+type shardKey struct {
+	height      uint64
+	messageType proto.MessageType
+}
+
+// subscriptionShard holds the subscriptions for a message type at a height,
+// indexed so a message event only reaches the subscriptions for its round
+type subscriptionShard struct {
+	// rounds are the subscriptions for an exact round, by round
+	rounds map[uint64]map[SubscriptionID]*eventSubscription
+
+	// minRounds are the subscriptions for a minimum round
+	minRounds map[SubscriptionID]*eventSubscription
+}
+
+// add adds the subscription to the shard
+func (s *subscriptionShard) add(id SubscriptionID, subscription *eventSubscription) {
+	if subscription.details.HasMinRound {
+		s.minRounds[id] = subscription
+
+		return
+	}
+
+	round := subscription.details.View.Round
+
+	if _, ok := s.rounds[round]; !ok {
+		s.rounds[round] = make(map[SubscriptionID]*eventSubscription)
+	}
+
+	s.rounds[round][id] = subscription
+}
+
+// remove removes the subscription from the shard
+func (s *subscriptionShard) remove(id SubscriptionID, subscription *eventSubscription) {
+	if subscription.details.HasMinRound {
+		delete(s.minRounds, id)
+
+		return
+	}
+
+	round := subscription.details.View.Round
+
+	delete(s.rounds[round], id)
+
+	if len(s.rounds[round]) == 0 {
+		delete(s.rounds, round)
+	}
+}
+
+// isEmpty checks if the shard has no subscriptions
+func (s *subscriptionShard) isEmpty() bool {
+	return len(s.rounds) == 0 && len(s.minRounds) == 0
+}
+
+// shardKeyFor returns the shard key of the subscription
+func shardKeyFor(details SubscriptionDetails) shardKey {
+	return shardKey{
+		height:      details.View.Height,
+		messageType: details.MessageType,
+	}
+}
+
 // SubscriptionID is a unique number to identify Subscription
 type SubscriptionID int32
 
@@ -33,8 +103,18 @@ type Subscription struct {
 	ID SubscriptionID
 
 	// SubCh is the notification channel
-	// on which the listener will receive notifications
+	// on which the listener will receive notifications.
+	// It is not set for the callback subscriptions
 	SubCh chan uint64
+
+	// dropped is the number of events dropped by the subscription
+	dropped *atomic.Uint64
+}
+
+// Dropped returns the number of events dropped or coalesced by the subscription,
+// as its consumer was busy and the notification buffer was full (see DropPolicy)
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
 }
 
 // SubscriptionDetails contain the requested
@@ -58,30 +138,83 @@ type SubscriptionDetails struct {
 
 	// HasQuorumFn is the function used to check for quorum existence
 	HasQuorumFn func(height uint64, messages []*proto.Message, msgType proto.MessageType) bool
+
+	// BufferSize is the number of events buffered while the consumer is busy.
+	// Defaults to a single event
+	BufferSize int
+
+	// DropPolicy determines which event is dropped once the buffer is full.
+	// Defaults to DropNewest
+	DropPolicy DropPolicy
 }
 
-// subscribe registers a new listener for message events
-func (em *eventManager) subscribe(details SubscriptionDetails) *Subscription {
+// SubscriptionCallback is the callback invoked with the round of the message
+// event, for the subscriptions with push semantics
+type SubscriptionCallback func(round uint64)
+
+// subscribe registers a new listener for message events,
+// removed once the passed in context is cancelled.
+// If the callback is set, the events are passed to it instead of the channel
+func (em *eventManager) subscribe(
+	ctx context.Context,
+	details SubscriptionDetails,
+	callback SubscriptionCallback,
+) *Subscription {
 	em.subscriptionsLock.Lock()
 	defer em.subscriptionsLock.Unlock()
 
+	bufferSize := details.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = 1
+	}
+
 	id := uuid.New().ID()
 	subscription := &eventSubscription{
-		details:  details,
-		outputCh: make(chan uint64, 1),
-		doneCh:   make(chan struct{}),
-		notifyCh: make(chan uint64, 1),
+		ctx:          ctx,
+		details:      details,
+		callback:     callback,
+		outputCh:     make(chan uint64, 1),
+		doneCh:       make(chan struct{}),
+		notifyCh:     make(chan uint64, bufferSize),
+		dropped:      &atomic.Uint64{},
+		totalDropped: &em.dropped,
 	}
 
 	em.subscriptions[SubscriptionID(id)] = subscription
 
-	go subscription.runLoop()
+	key := shardKeyFor(details)
+
+	shard, ok := em.shards[key]
+	if !ok {
+		shard = &subscriptionShard{
+			rounds:    make(map[uint64]map[SubscriptionID]*eventSubscription),
+			minRounds: make(map[SubscriptionID]*eventSubscription),
+		}
+		em.shards[key] = shard
+	}
+
+	shard.add(SubscriptionID(id), subscription)
+
+	// The dispatch goroutine inherits the profiler labels of the subscriber context
+	go pprof.Do(ctx, pprof.Labels("subscription", details.MessageType.String()), func(context.Context) {
+		subscription.runLoop(func() {
+			em.cancelSubscription(SubscriptionID(id))
+		})
+	})
 
 	atomic.AddInt64(&em.numSubscriptions, 1)
 
+	if callback != nil {
+		return &Subscription{
+			ID:      SubscriptionID(id),
+			dropped: subscription.dropped,
+		}
+	}
+
 	return &Subscription{
-		ID:    SubscriptionID(id),
-		SubCh: subscription.outputCh,
+		ID:      SubscriptionID(id),
+		SubCh:   subscription.outputCh,
+		dropped: subscription.dropped,
 	}
 }
 
@@ -93,11 +226,23 @@ func (em *eventManager) cancelSubscription(id SubscriptionID) {
 	if subscription, ok := em.subscriptions[id]; ok {
 		subscription.close()
 		delete(em.subscriptions, id)
+
+		key := shardKeyFor(subscription.details)
+
+		if shard, ok := em.shards[key]; ok {
+			shard.remove(id, subscription)
+
+			if shard.isEmpty() {
+				delete(em.shards, key)
+			}
+		}
+
 		atomic.AddInt64(&em.numSubscriptions, -1)
 	}
 }
 
-// close stops the event manager, effectively cancelling all subscriptions
+// close stops the event manager, effectively cancelling all subscriptions.
+// The subscriptions are removed, so cancelling them afterwards is a no-op
 func (em *eventManager) close() {
 	em.subscriptionsLock.Lock()
 	defer em.subscriptionsLock.Unlock()
@@ -106,10 +251,14 @@ func (em *eventManager) close() {
 		subscription.close()
 	}
 
+	em.subscriptions = make(map[SubscriptionID]*eventSubscription)
+	em.shards = make(map[shardKey]*subscriptionShard)
+
 	atomic.StoreInt64(&em.numSubscriptions, 0)
 }
 
-// signalEvent is a helper method for alerting listeners of a new message event
+// signalEvent is a helper method for alerting listeners of a new message event.
+// Only the subscriptions for the message type, height and round are notified
 func (em *eventManager) signalEvent(
 	messageType proto.MessageType,
 	view *proto.View,
@@ -123,7 +272,19 @@ func (em *eventManager) signalEvent(
 	em.subscriptionsLock.RLock()
 	defer em.subscriptionsLock.RUnlock()
 
-	for _, subscription := range em.subscriptions {
+	shard, ok := em.shards[shardKey{height: view.Height, messageType: messageType}]
+	if !ok {
+		return
+	}
+
+	for _, subscription := range shard.rounds[view.Round] {
+		subscription.pushEvent(
+			messageType,
+			view,
+		)
+	}
+
+	for _, subscription := range shard.minRounds {
 		subscription.pushEvent(
 			messageType,
 			view,
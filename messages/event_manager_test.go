@@ -1,7 +1,9 @@
 package messages
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -29,7 +31,7 @@ func TestEventManager_SubscribeCancel(t *testing.T) {
 
 	// Create the subscriptions
 	for i := 0; i < numSubscriptions; i++ {
-		subscriptions[i] = em.subscribe(baseDetails)
+		subscriptions[i] = em.subscribe(context.Background(), baseDetails, nil)
 
 		// Check that the number is up-to-date
 		assert.Equal(t, int64(i+1), em.numSubscriptions)
@@ -68,6 +70,47 @@ func TestEventManager_SubscribeCancel(t *testing.T) {
 	assert.Equal(t, int64(0), em.numSubscriptions)
 }
 
+func TestEventManager_SubscribeContextCancel(t *testing.T) {
+	t.Parallel()
+
+	details := SubscriptionDetails{
+		MessageType: proto.MessageType_PREPARE,
+		View: &proto.View{
+			Height: 0,
+			Round:  0,
+		},
+		MinNumMessages: 1,
+	}
+
+	em := newEventManager()
+	defer em.close()
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+
+	subscription := em.subscribe(ctx, details, nil)
+	assert.Equal(t, int64(1), em.numSubscriptions)
+
+	cancelFn()
+
+	// Make sure the cancelled subscription is not notified
+	em.signalEvent(details.MessageType, details.View)
+
+	// Make sure the subscription is removed, and the channel closed
+	select {
+	case _, ok := <-subscription.SubCh:
+		assert.False(t, ok)
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscription channel not closed")
+	}
+
+	em.subscriptionsLock.RLock()
+	defer em.subscriptionsLock.RUnlock()
+
+	assert.Equal(t, int64(0), em.numSubscriptions)
+	assert.Len(t, em.subscriptions, 0)
+	assert.Len(t, em.shards, 0)
+}
+
 func TestEventManager_SubscribeClose(t *testing.T) {
 	t.Parallel()
 
@@ -86,7 +129,7 @@ func TestEventManager_SubscribeClose(t *testing.T) {
 
 	// Create the subscriptions
 	for i := 0; i < numSubscriptions; i++ {
-		subscriptions[i] = em.subscribe(baseDetails)
+		subscriptions[i] = em.subscribe(context.Background(), baseDetails, nil)
 
 		// Check that the number is up-to-date
 		assert.Equal(t, int64(i+1), em.numSubscriptions)
@@ -103,3 +146,189 @@ func TestEventManager_SubscribeClose(t *testing.T) {
 		}
 	}
 }
+
+// TestEventManager_CancelAfterClose makes sure the subscriptions
+// can be cancelled once the event manager is closed
+func TestEventManager_CancelAfterClose(t *testing.T) {
+	t.Parallel()
+
+	details := SubscriptionDetails{
+		MessageType: proto.MessageType_PREPARE,
+		View: &proto.View{
+			Height: 0,
+			Round:  0,
+		},
+		MinNumMessages: 1,
+	}
+
+	t.Run("unsubscribe after close", func(t *testing.T) {
+		t.Parallel()
+
+		em := newEventManager()
+
+		subscription := em.subscribe(context.Background(), details, nil)
+
+		em.close()
+
+		assert.NotPanics(t, func() {
+			em.cancelSubscription(subscription.ID)
+		})
+
+		_, more := <-subscription.SubCh
+		assert.False(t, more)
+	})
+
+	t.Run("context cancel and close", func(t *testing.T) {
+		t.Parallel()
+
+		for i := 0; i < 100; i++ {
+			em := newEventManager()
+
+			ctx, cancelFn := context.WithCancel(context.Background())
+
+			subscription := em.subscribe(ctx, details, nil)
+
+			// The subscription loop cancels the subscription concurrently
+			cancelFn()
+
+			assert.NotPanics(t, em.close)
+
+			_, more := <-subscription.SubCh
+			assert.False(t, more)
+		}
+	})
+}
+
+func TestEventManager_SignalEventShards(t *testing.T) {
+	t.Parallel()
+
+	em := newEventManager()
+	defer em.close()
+
+	subscribe := func(messageType proto.MessageType, round uint64, hasMinRound bool) *Subscription {
+		return em.subscribe(context.Background(), SubscriptionDetails{
+			MessageType: messageType,
+			View: &proto.View{
+				Height: 1,
+				Round:  round,
+			},
+			HasMinRound: hasMinRound,
+		}, nil)
+	}
+
+	var (
+		prepareRound0 = subscribe(proto.MessageType_PREPARE, 0, false)
+		prepareRound1 = subscribe(proto.MessageType_PREPARE, 1, false)
+		commitRound0  = subscribe(proto.MessageType_COMMIT, 0, false)
+		roundChange   = subscribe(proto.MessageType_ROUND_CHANGE, 1, true)
+	)
+
+	notified := func(subscription *Subscription) bool {
+		select {
+		case <-subscription.SubCh:
+			return true
+		case <-time.After(50 * time.Millisecond):
+			return false
+		}
+	}
+
+	// Make sure only the subscription for the message view and type is notified
+	em.signalEvent(proto.MessageType_PREPARE, &proto.View{Height: 1, Round: 0})
+
+	assert.True(t, notified(prepareRound0))
+	assert.False(t, notified(prepareRound1))
+	assert.False(t, notified(commitRound0))
+	assert.False(t, notified(roundChange))
+
+	// Make sure the min round subscriptions are notified for higher rounds
+	em.signalEvent(proto.MessageType_ROUND_CHANGE, &proto.View{Height: 1, Round: 3})
+	assert.True(t, notified(roundChange))
+
+	em.signalEvent(proto.MessageType_ROUND_CHANGE, &proto.View{Height: 1, Round: 0})
+	assert.False(t, notified(roundChange))
+
+	// Make sure other heights are not notified
+	em.signalEvent(proto.MessageType_COMMIT, &proto.View{Height: 2, Round: 0})
+	assert.False(t, notified(commitRound0))
+
+	// Make sure the shards are removed with the last subscription
+	for _, subscription := range []*Subscription{prepareRound0, prepareRound1, commitRound0, roundChange} {
+		em.cancelSubscription(subscription.ID)
+	}
+
+	assert.Len(t, em.shards, 0)
+}
+
+func TestEventManager_DropPolicy(t *testing.T) {
+	t.Parallel()
+
+	testTable := []struct {
+		name      string
+		policy    DropPolicy
+		delivered []uint64
+	}{
+		{"drop newest", DropNewest, []uint64{1, 5, 4}},
+		{"drop oldest", DropOldest, []uint64{1, 3, 2}},
+		{"coalesce rounds", CoalesceRounds, []uint64{1, 5, 4}},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			em := newEventManager()
+			defer em.close()
+
+			var (
+				busyCh    = make(chan struct{})
+				releaseCh = make(chan struct{})
+				roundsCh  = make(chan uint64, 10)
+			)
+
+			subscription := em.subscribe(context.Background(), SubscriptionDetails{
+				MessageType: proto.MessageType_ROUND_CHANGE,
+				View:        &proto.View{Height: 1, Round: 0},
+				HasMinRound: true,
+				BufferSize:  2,
+				DropPolicy:  testCase.policy,
+			}, func(round uint64) {
+				roundsCh <- round
+
+				if round == 1 {
+					close(busyCh)
+					<-releaseCh
+				}
+			})
+
+			signal := func(round uint64) {
+				em.signalEvent(proto.MessageType_ROUND_CHANGE, &proto.View{Height: 1, Round: round})
+			}
+
+			// Keep the consumer busy, and overflow the buffer
+			signal(1)
+			<-busyCh
+
+			for _, round := range []uint64{5, 4, 3, 2} {
+				signal(round)
+			}
+
+			close(releaseCh)
+
+			delivered := make([]uint64, 0, len(testCase.delivered))
+			for range testCase.delivered {
+				select {
+				case round := <-roundsCh:
+					delivered = append(delivered, round)
+				case <-time.After(time.Second):
+					t.Fatal("events not delivered")
+				}
+			}
+
+			assert.Equal(t, testCase.delivered, delivered)
+			assert.Equal(t, uint64(2), subscription.Dropped())
+			assert.Equal(t, uint64(2), em.dropped.Load())
+		})
+	}
+}
@@ -1,21 +1,75 @@
 package messages
 
 import (
+	"context"
+	"sync/atomic"
+
 	"github.com/renloi/ibft/messages/proto"
 )
 
+// DropPolicy determines which event is dropped when an event is signaled
+// to a subscription whose buffer is full, because its consumer is busy.
+// The signaling path never blocks on a busy consumer
+type DropPolicy uint8
+
+const (
+	// DropNewest drops the signaled event, keeping the pending ones. The events only
+	// signal the subscriber to check the store, so the pending events cover the dropped one.
+	// It is the default policy
+	DropNewest DropPolicy = iota
+
+	// DropOldest drops the oldest pending event to make room for the signaled one,
+	// so the subscriber receives the latest events
+	DropOldest
+
+	// CoalesceRounds coalesces the signaled event with the oldest pending one
+	// into a single event for the higher of their rounds, so the subscriptions
+	// for a minimum round (see SubscriptionDetails.HasMinRound) receive the highest round
+	CoalesceRounds
+)
+
+// String returns the human-readable drop policy
+func (p DropPolicy) String() string {
+	switch p {
+	case DropNewest:
+		return "drop newest"
+	case DropOldest:
+		return "drop oldest"
+	case CoalesceRounds:
+		return "coalesce rounds"
+	}
+
+	return "unknown"
+}
+
 type eventSubscription struct {
+	// ctx is the subscription context, the subscription
+	// is removed once it is cancelled
+	ctx context.Context
+
 	// details contains the details of the event subscription
 	details SubscriptionDetails
 
+	// callback is the event callback, if the subscription
+	// has push semantics
+	callback SubscriptionCallback
+
 	// outputCh is the update channel for the subscriber
 	outputCh chan uint64
 
 	// doneCh is the channel for handling stop signals
 	doneCh chan struct{}
 
-	// notifyCh is the channel for receiving event requests
+	// notifyCh is the channel for receiving event requests,
+	// buffered as configured (see SubscriptionDetails.BufferSize)
 	notifyCh chan uint64
+
+	// dropped is the number of events dropped or coalesced,
+	// as the notification buffer was full
+	dropped *atomic.Uint64
+
+	// totalDropped is the number of events dropped by all the subscriptions
+	totalDropped *atomic.Uint64
 }
 
 // close stops the event subscription
@@ -23,17 +77,39 @@ func (es *eventSubscription) close() {
 	close(es.doneCh)
 }
 
-// runLoop is the main loop that listens for notifications and handles the event / close signals
-func (es *eventSubscription) runLoop() {
+// runLoop is the main loop that listens for notifications and handles the event / close signals.
+// The cancel callback is invoked once the subscription context is cancelled
+func (es *eventSubscription) runLoop(cancel func()) {
 	defer close(es.outputCh)
 
 	for {
 		select {
 		case <-es.doneCh: // Break if a close signal has been received
+			return
+		case <-es.ctx.Done(): // Remove the subscription if the context is cancelled
+			cancel()
+
 			return
 		case round := <-es.notifyCh: // Listen for new events to appear
+			if es.callback != nil {
+				// Push the event, unless a close signal has been received meanwhile
+				select {
+				case <-es.doneCh:
+					return
+				default:
+				}
+
+				es.callback(round)
+
+				continue
+			}
+
 			select {
 			case <-es.doneCh: // Break if a close signal has been received
+				return
+			case <-es.ctx.Done(): // Remove the subscription if the context is cancelled
+				cancel()
+
 				return
 			case es.outputCh <- round: // Pass the event to the output
 			}
@@ -77,8 +153,41 @@ func (es *eventSubscription) pushEvent(
 		return
 	}
 
+	// Subscriptions with a cancelled context are not notified,
+	// even before they are removed
+	if es.ctx.Err() != nil {
+		return
+	}
+
 	select {
 	case es.notifyCh <- view.Round: // Notify the worker thread
+		return
+	default:
+	}
+
+	// The buffer is full, apply the drop policy
+	es.dropped.Add(1)
+	es.totalDropped.Add(1)
+
+	if es.details.DropPolicy == DropNewest {
+		return
+	}
+
+	round := view.Round
+
+	// Make room for the signaled event, unless
+	// the worker thread drained the buffer meanwhile
+	select {
+	case pending := <-es.notifyCh:
+		if es.details.DropPolicy == CoalesceRounds && pending > round {
+			round = pending
+		}
+	default:
+	}
+
+	select {
+	case es.notifyCh <- round:
 	default:
+		// Another signaler took the room
 	}
 }
@@ -0,0 +1,90 @@
+package messages
+
+import (
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// NewFinalityMessage builds the FINALITY message proving the proposal was finalized
+// in the view, with the committed seals of the finalization, and their randomness contributions,
+// if any. The message is not signed, as the committed seals prove the finality,
+// so any node that finalized the height can serve it
+func NewFinalityMessage(
+	view *proto.View,
+	proposal *proto.Proposal,
+	proposalHash []byte,
+	committedSeals []*CommittedSeal,
+) *proto.Message {
+	var (
+		seals      = make([]*proto.SenderSignature, 0, len(committedSeals))
+		randomness [][]byte
+	)
+
+	for _, seal := range committedSeals {
+		seals = append(seals, &proto.SenderSignature{
+			From:      seal.Signer,
+			Signature: seal.Signature,
+		})
+	}
+
+	if hasRandomness(committedSeals) {
+		randomness = make([][]byte, 0, len(committedSeals))
+		for _, seal := range committedSeals {
+			randomness = append(randomness, seal.Randomness)
+		}
+	}
+
+	return &proto.Message{
+		View: &proto.View{
+			Height: view.Height,
+			Round:  view.Round,
+		},
+		Type: proto.MessageType_FINALITY,
+		Payload: &proto.Message_FinalityData{
+			FinalityData: &proto.FinalityMessage{
+				Proposal:       proposal,
+				ProposalHash:   proposalHash,
+				CommittedSeals: seals,
+				Randomness:     randomness,
+			},
+		},
+	}
+}
+
+// ExtractFinalityCommits converts the committed seals of the FINALITY message
+// into the (unsigned) COMMIT messages of the finalization, one for each signer,
+// so they can be verified like the COMMIT messages received from the validators
+func ExtractFinalityCommits(finalityMessage *proto.Message) ([]*proto.Message, error) {
+	finalityData, err := ExtractPayload[*proto.FinalityMessage](finalityMessage)
+	if err != nil {
+		return nil, err
+	}
+
+	randomness := finalityData.Randomness
+	if len(randomness) != 0 && len(randomness) != len(finalityData.CommittedSeals) {
+		return nil, ErrRandomnessMismatch
+	}
+
+	commits := make([]*proto.Message, 0, len(finalityData.CommittedSeals))
+
+	for index, seal := range finalityData.CommittedSeals {
+		var contribution []byte
+		if len(randomness) != 0 {
+			contribution = randomness[index]
+		}
+
+		commits = append(commits, &proto.Message{
+			View: finalityMessage.View,
+			From: seal.From,
+			Type: proto.MessageType_COMMIT,
+			Payload: &proto.Message_CommitData{
+				CommitData: &proto.CommitMessage{
+					ProposalHash:  finalityData.ProposalHash,
+					CommittedSeal: seal.Signature,
+					Randomness:    contribution,
+				},
+			},
+		})
+	}
+
+	return commits, nil
+}
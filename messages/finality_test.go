@@ -0,0 +1,50 @@
+package messages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+func TestMessages_FinalityCommits(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view         = &proto.View{Height: 5, Round: 2}
+		proposalHash = []byte("proposal hash")
+		proposal     = &proto.Proposal{
+			RawProposal: []byte("raw proposal"),
+			Round:       2,
+		}
+		seals = []*CommittedSeal{
+			{Signer: []byte("signer 1"), Signature: []byte("seal 1")},
+			{Signer: []byte("signer 2"), Signature: []byte("seal 2")},
+		}
+	)
+
+	finality := NewFinalityMessage(view, proposal, proposalHash, seals)
+
+	assert.Equal(t, proto.MessageType_FINALITY, finality.Type)
+	assert.Equal(t, proposal, finality.GetFinalityData().GetProposal())
+
+	commits, err := ExtractFinalityCommits(finality)
+	require.NoError(t, err)
+
+	// Make sure the seals convert back into the COMMIT messages of the finalization
+	for _, commit := range commits {
+		assert.Equal(t, proto.MessageType_COMMIT, commit.Type)
+		assert.Equal(t, view, commit.View)
+		assert.Equal(t, proposalHash, ExtractCommitHash(commit))
+	}
+
+	extracted, err := ExtractCommittedSeals(commits)
+	require.NoError(t, err)
+	assert.Equal(t, seals, extracted)
+
+	// Make sure messages of other types are rejected
+	_, err = ExtractFinalityCommits(&proto.Message{Type: proto.MessageType_COMMIT})
+	assert.ErrorIs(t, err, ErrPayloadTypeMismatch)
+}
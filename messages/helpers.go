@@ -3,6 +3,11 @@ package messages
 import (
 	"bytes"
 	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	protoBuf "google.golang.org/protobuf/proto"
 
 	"github.com/renloi/ibft/messages/proto"
 )
@@ -16,6 +21,10 @@ var (
 type CommittedSeal struct {
 	Signer    []byte
 	Signature []byte
+
+	// Randomness is the randomness beacon contribution
+	// of the signer, if the chain uses the randomness beacon
+	Randomness []byte
 }
 
 // Copy is a helper method for deep copy of CommittedSeal
@@ -26,117 +35,243 @@ func (cs *CommittedSeal) Copy() *CommittedSeal {
 	copy(signer, cs.Signer)
 	copy(signature, cs.Signature)
 
+	var randomness []byte
+	if cs.Randomness != nil {
+		randomness = append([]byte{}, cs.Randomness...)
+	}
+
 	return &CommittedSeal{
-		Signer:    signer,
-		Signature: signature,
+		Signer:     signer,
+		Signature:  signature,
+		Randomness: randomness,
 	}
 }
 
-// ExtractCommittedSeals extracts the committed seals from the passed in messages
+// CommittedSealError is the error of extracting the committed seal
+// from a malformed message, identifying the message and its sender
+type CommittedSealError struct {
+	// Index is the index of the malformed message in the extracted messages
+	Index int
+
+	// From is the sender of the malformed message
+	From []byte
+
+	// Err is the reason the message is malformed
+	Err error
+}
+
+func (e *CommittedSealError) Error() string {
+	return fmt.Sprintf("malformed COMMIT message %d from %x: %v", e.Index, e.From, e.Err)
+}
+
+func (e *CommittedSealError) Unwrap() error {
+	return e.Err
+}
+
+// PrepareRelay prepares the message for relaying to other peers.
+// It returns a copy of the message with the hop count incremented,
+// or false if the message has reached its TTL and must not be relayed.
+// Messages without a TTL are relayed up to defaultTTL hops
+func PrepareRelay(message *proto.Message, defaultTTL uint32) (*proto.Message, bool) {
+	ttl := message.GetTtl()
+	if ttl == 0 {
+		ttl = defaultTTL
+	}
+
+	if message.GetHops() >= ttl {
+		return nil, false
+	}
+
+	relayed, _ := protoBuf.Clone(message).(*proto.Message)
+	relayed.Ttl = ttl
+	relayed.Hops++
+
+	return relayed, true
+}
+
+// ExtractCommittedSeals extracts the committed seals from the passed in messages.
+// If a message is malformed, a CommittedSealError identifying it is returned
 func ExtractCommittedSeals(commitMessages []*proto.Message) ([]*CommittedSeal, error) {
 	committedSeals := make([]*CommittedSeal, 0)
 
-	for _, commitMessage := range commitMessages {
+	for index, commitMessage := range commitMessages {
 		if commitMessage.Type != proto.MessageType_COMMIT {
 			// safe check
-			return nil, ErrWrongCommitMessageType
+			return nil, &CommittedSealError{
+				Index: index,
+				From:  commitMessage.From,
+				Err:   ErrWrongCommitMessageType,
+			}
+		}
+
+		commitData, err := ExtractPayload[*proto.CommitMessage](commitMessage)
+		if err != nil {
+			return nil, &CommittedSealError{
+				Index: index,
+				From:  commitMessage.From,
+				Err:   err,
+			}
 		}
 
-		committedSeals = append(committedSeals, ExtractCommittedSeal(commitMessage))
+		committedSeals = append(committedSeals, &CommittedSeal{
+			Signer:     commitMessage.From,
+			Signature:  commitData.CommittedSeal,
+			Randomness: commitData.Randomness,
+		})
 	}
 
 	return committedSeals, nil
 }
 
-// ExtractCommittedSeal extracts the committed seal from the passed in message
+// ExtractCommittedSeal extracts the committed seal from the passed in message.
+// Returns nil if the message is not a well-formed COMMIT message
+//
+// Deprecated: use ExtractPayload, which reports malformed messages
 func ExtractCommittedSeal(commitMessage *proto.Message) *CommittedSeal {
-	if commitMessage.Type != proto.MessageType_COMMIT {
+	commitData, err := ExtractPayload[*proto.CommitMessage](commitMessage)
+	if err != nil {
 		return nil
 	}
 
-	commitData, _ := commitMessage.Payload.(*proto.Message_CommitData)
-
 	return &CommittedSeal{
-		Signer:    commitMessage.From,
-		Signature: commitData.CommitData.CommittedSeal,
+		Signer:     commitMessage.From,
+		Signature:  commitData.CommittedSeal,
+		Randomness: commitData.Randomness,
 	}
 }
 
-// ExtractCommitHash extracts the commit proposal hash from the passed in message
+// ExtractCommitHash extracts the commit proposal hash from the passed in message.
+// Returns nil if the message is not a well-formed COMMIT message
+//
+// Deprecated: use ExtractPayload, which reports malformed messages
 func ExtractCommitHash(commitMessage *proto.Message) []byte {
-	if commitMessage.Type != proto.MessageType_COMMIT {
+	commitData, err := ExtractPayload[*proto.CommitMessage](commitMessage)
+	if err != nil {
 		return nil
 	}
 
-	commitData, _ := commitMessage.Payload.(*proto.Message_CommitData)
-
-	return commitData.CommitData.ProposalHash
+	return commitData.ProposalHash
 }
 
-// ExtractProposal extracts the (rawData,r) proposal from the passed in message
+// ExtractProposal extracts the (rawData,r) proposal from the passed in message.
+// Returns nil if the message is not a well-formed PREPREPARE message
+//
+// Deprecated: use ExtractPayload, which reports malformed messages
 func ExtractProposal(proposalMessage *proto.Message) *proto.Proposal {
-	if proposalMessage.Type != proto.MessageType_PREPREPARE {
+	preprepareData, err := ExtractPayload[*proto.PrePrepareMessage](proposalMessage)
+	if err != nil {
 		return nil
 	}
 
-	preprepareData, _ := proposalMessage.Payload.(*proto.Message_PreprepareData)
-
-	return preprepareData.PreprepareData.Proposal
+	return preprepareData.Proposal
 }
 
-// ExtractProposalHash extracts the proposal hash from the passed in message
+// ExtractProposalHash extracts the proposal hash from the passed in message.
+// Returns nil if the message is not a well-formed PREPREPARE message
+//
+// Deprecated: use ExtractPayload, which reports malformed messages
 func ExtractProposalHash(proposalMessage *proto.Message) []byte {
-	if proposalMessage.Type != proto.MessageType_PREPREPARE {
+	preprepareData, err := ExtractPayload[*proto.PrePrepareMessage](proposalMessage)
+	if err != nil {
 		return nil
 	}
 
-	preprepareData, _ := proposalMessage.Payload.(*proto.Message_PreprepareData)
+	return preprepareData.ProposalHash
+}
+
+// ExtractProposalTimestamp extracts the proposal timestamp from the passed in message.
+// Returns the zero time if the message is not a well-formed PREPREPARE, or the timestamp is not set
+func ExtractProposalTimestamp(proposalMessage *proto.Message) time.Time {
+	preprepareData, err := ExtractPayload[*proto.PrePrepareMessage](proposalMessage)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return ProposalTimestamp(preprepareData)
+}
+
+// ProposalTimestamp returns the proposal timestamp of the PREPREPARE payload.
+// Returns the zero time if the timestamp is not set. The timestamps beyond
+// the time range (see IsValidTimestamp) are clamped to the latest representable time
+func ProposalTimestamp(preprepareData *proto.PrePrepareMessage) time.Time {
+	timestamp := preprepareData.Timestamp
+	if timestamp == 0 {
+		return time.Time{}
+	}
+
+	if !IsValidTimestamp(timestamp) {
+		return time.Unix(0, math.MaxInt64)
+	}
+
+	return time.Unix(0, int64(timestamp))
+}
 
-	return preprepareData.PreprepareData.ProposalHash
+// IsValidTimestamp checks if the proposal timestamp, in nanoseconds since the Unix epoch,
+// is within the time range. The timestamps above math.MaxInt64 would wrap into the past
+func IsValidTimestamp(timestamp uint64) bool {
+	return timestamp <= math.MaxInt64
 }
 
-// ExtractRoundChangeCertificate extracts the RCC from the passed in message
+// IsNilProposal checks if the PREPREPARE message is an explicit NIL proposal
+func IsNilProposal(proposalMessage *proto.Message) bool {
+	preprepareData, err := ExtractPayload[*proto.PrePrepareMessage](proposalMessage)
+	if err != nil {
+		return false
+	}
+
+	return preprepareData.NilProposal
+}
+
+// ExtractRoundChangeCertificate extracts the RCC from the passed in message.
+// Returns nil if the message is not a well-formed PREPREPARE message
+//
+// Deprecated: use ExtractPayload, which reports malformed messages
 func ExtractRoundChangeCertificate(proposalMessage *proto.Message) *proto.RoundChangeCertificate {
-	if proposalMessage.Type != proto.MessageType_PREPREPARE {
+	preprepareData, err := ExtractPayload[*proto.PrePrepareMessage](proposalMessage)
+	if err != nil {
 		return nil
 	}
 
-	preprepareData, _ := proposalMessage.Payload.(*proto.Message_PreprepareData)
-
-	return preprepareData.PreprepareData.Certificate
+	return preprepareData.Certificate
 }
 
-// ExtractPrepareHash extracts the prepare proposal hash from the passed in message
+// ExtractPrepareHash extracts the prepare proposal hash from the passed in message.
+// Returns nil if the message is not a well-formed PREPARE message
+//
+// Deprecated: use ExtractPayload, which reports malformed messages
 func ExtractPrepareHash(prepareMessage *proto.Message) []byte {
-	if prepareMessage.Type != proto.MessageType_PREPARE {
+	prepareData, err := ExtractPayload[*proto.PrepareMessage](prepareMessage)
+	if err != nil {
 		return nil
 	}
 
-	prepareData, _ := prepareMessage.Payload.(*proto.Message_PrepareData)
-
-	return prepareData.PrepareData.ProposalHash
+	return prepareData.ProposalHash
 }
 
-// ExtractLatestPC extracts the latest PC from the passed in message
+// ExtractLatestPC extracts the latest PC from the passed in message.
+// Returns nil if the message is not a well-formed ROUND_CHANGE message
+//
+// Deprecated: use ExtractPayload, which reports malformed messages
 func ExtractLatestPC(roundChangeMessage *proto.Message) *proto.PreparedCertificate {
-	if roundChangeMessage.Type != proto.MessageType_ROUND_CHANGE {
+	rcData, err := ExtractPayload[*proto.RoundChangeMessage](roundChangeMessage)
+	if err != nil {
 		return nil
 	}
 
-	rcData, _ := roundChangeMessage.Payload.(*proto.Message_RoundChangeData)
-
-	return rcData.RoundChangeData.LatestPreparedCertificate
+	return rcData.LatestPreparedCertificate
 }
 
-// ExtractLastPreparedProposal extracts the latest prepared proposal from the passed in message
+// ExtractLastPreparedProposal extracts the latest prepared proposal from the passed in message.
+// Returns nil if the message is not a well-formed ROUND_CHANGE message
+//
+// Deprecated: use ExtractPayload, which reports malformed messages
 func ExtractLastPreparedProposal(roundChangeMessage *proto.Message) *proto.Proposal {
-	if roundChangeMessage.Type != proto.MessageType_ROUND_CHANGE {
+	rcData, err := ExtractPayload[*proto.RoundChangeMessage](roundChangeMessage)
+	if err != nil {
 		return nil
 	}
 
-	rcData, _ := roundChangeMessage.Payload.(*proto.Message_RoundChangeData)
-
-	return rcData.RoundChangeData.LastPreparedProposal
+	return rcData.LastPreparedProposal
 }
 
 // HasUniqueSenders checks if the messages have unique senders
@@ -172,9 +307,19 @@ func HaveSameProposalHash(messages []*proto.Message) bool {
 
 		switch message.Type {
 		case proto.MessageType_PREPREPARE:
-			extractedHash = ExtractProposalHash(message)
+			preprepareData, err := ExtractPayload[*proto.PrePrepareMessage](message)
+			if err != nil {
+				return false
+			}
+
+			extractedHash = preprepareData.ProposalHash
 		case proto.MessageType_PREPARE:
-			extractedHash = ExtractPrepareHash(message)
+			prepareData, err := ExtractPayload[*proto.PrepareMessage](message)
+			if err != nil {
+				return false
+			}
+
+			extractedHash = prepareData.ProposalHash
 		case proto.MessageType_COMMIT, proto.MessageType_ROUND_CHANGE:
 			return false
 		default:
@@ -1,9 +1,14 @@
 package messages
 
 import (
+	"math"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+	protoBuf "google.golang.org/protobuf/proto"
 
 	"github.com/renloi/ibft/messages/proto"
 )
@@ -30,6 +35,7 @@ func TestMessages_ExtractCommittedSeals(t *testing.T) {
 	createWrongMessage := func(signer string, msgType proto.MessageType) *proto.Message {
 		return &proto.Message{
 			Type: msgType,
+			From: []byte(signer),
 		}
 	}
 
@@ -67,6 +73,15 @@ func TestMessages_ExtractCommittedSeals(t *testing.T) {
 			expected: nil,
 			err:      ErrWrongCommitMessageType,
 		},
+		{
+			name: "contains COMMIT messages without the payload",
+			messages: []*proto.Message{
+				createCommitMessage("signer1"),
+				createWrongMessage("signer2", proto.MessageType_COMMIT),
+			},
+			expected: nil,
+			err:      ErrMissingPayload,
+		},
 	}
 
 	for _, test := range tests {
@@ -78,11 +93,39 @@ func TestMessages_ExtractCommittedSeals(t *testing.T) {
 			seals, err := ExtractCommittedSeals(test.messages)
 
 			assert.Equal(t, test.expected, seals)
-			assert.Equal(t, test.err, err)
+			assert.ErrorIs(t, err, test.err)
 		})
 	}
 }
 
+func TestMessages_ExtractCommittedSeals_MalformedMessage(t *testing.T) {
+	t.Parallel()
+
+	commitMessages := []*proto.Message{
+		{
+			Type: proto.MessageType_COMMIT,
+			From: []byte("signer1"),
+			Payload: &proto.Message_CommitData{
+				CommitData: &proto.CommitMessage{},
+			},
+		},
+		{
+			Type: proto.MessageType_COMMIT,
+			From: []byte("signer2"),
+		},
+	}
+
+	_, err := ExtractCommittedSeals(commitMessages)
+
+	// Make sure the malformed message is identified
+	var sealErr *CommittedSealError
+
+	require.ErrorAs(t, err, &sealErr)
+	assert.Equal(t, 1, sealErr.Index)
+	assert.Equal(t, []byte("signer2"), sealErr.From)
+	assert.ErrorIs(t, err, ErrMissingPayload)
+}
+
 func TestMessages_ExtractCommitHash(t *testing.T) {
 	t.Parallel()
 
@@ -221,6 +264,85 @@ func TestMessages_ExtractProposalHash(t *testing.T) {
 	}
 }
 
+func TestMessages_ExtractProposalTimestamp(t *testing.T) {
+	t.Parallel()
+
+	timestamp := time.Unix(1700000000, 500)
+
+	testTable := []struct {
+		name              string
+		expectedTimestamp time.Time
+		message           *proto.Message
+	}{
+		{
+			"valid message",
+			timestamp,
+			&proto.Message{
+				Type: proto.MessageType_PREPREPARE,
+				Payload: &proto.Message_PreprepareData{
+					PreprepareData: &proto.PrePrepareMessage{
+						Timestamp: uint64(timestamp.UnixNano()),
+					},
+				},
+			},
+		},
+		{
+			"timestamp not set",
+			time.Time{},
+			&proto.Message{
+				Type: proto.MessageType_PREPREPARE,
+				Payload: &proto.Message_PreprepareData{
+					PreprepareData: &proto.PrePrepareMessage{},
+				},
+			},
+		},
+		{
+			"latest timestamp",
+			time.Unix(0, math.MaxInt64),
+			&proto.Message{
+				Type: proto.MessageType_PREPREPARE,
+				Payload: &proto.Message_PreprepareData{
+					PreprepareData: &proto.PrePrepareMessage{
+						Timestamp: math.MaxInt64,
+					},
+				},
+			},
+		},
+		{
+			"timestamp beyond the time range",
+			time.Unix(0, math.MaxInt64),
+			&proto.Message{
+				Type: proto.MessageType_PREPREPARE,
+				Payload: &proto.Message_PreprepareData{
+					PreprepareData: &proto.PrePrepareMessage{
+						Timestamp: math.MaxInt64 + 1,
+					},
+				},
+			},
+		},
+		{
+			"invalid message",
+			time.Time{},
+			&proto.Message{
+				Type: proto.MessageType_PREPARE,
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.True(
+				t,
+				testCase.expectedTimestamp.Equal(ExtractProposalTimestamp(testCase.message)),
+			)
+		})
+	}
+}
+
 func TestMessages_ExtractRCC(t *testing.T) {
 	t.Parallel()
 
@@ -709,3 +831,205 @@ func TestMessages_AllHaveSameHeight(t *testing.T) {
 		})
 	}
 }
+
+func TestMessages_PrepareRelay(t *testing.T) {
+	t.Parallel()
+
+	defaultTTL := uint32(3)
+
+	testTable := []struct {
+		name         string
+		ttl          uint32
+		hops         uint32
+		relayed      bool
+		expectedTTL  uint32
+		expectedHops uint32
+	}{
+		{
+			"message with TTL",
+			2,
+			1,
+			true,
+			2,
+			2,
+		},
+		{
+			"message at TTL",
+			2,
+			2,
+			false,
+			0,
+			0,
+		},
+		{
+			"message without TTL",
+			0,
+			0,
+			true,
+			defaultTTL,
+			1,
+		},
+		{
+			"message without TTL at default TTL",
+			0,
+			defaultTTL,
+			false,
+			0,
+			0,
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			message := &proto.Message{
+				View: &proto.View{
+					Height: 1,
+				},
+				Type: proto.MessageType_PREPARE,
+				Ttl:  testCase.ttl,
+				Hops: testCase.hops,
+			}
+
+			relayed, ok := PrepareRelay(message, defaultTTL)
+			assert.Equal(t, testCase.relayed, ok)
+
+			if !ok {
+				assert.Nil(t, relayed)
+
+				return
+			}
+
+			assert.Equal(t, testCase.expectedTTL, relayed.Ttl)
+			assert.Equal(t, testCase.expectedHops, relayed.Hops)
+
+			// Make sure the original message is not modified
+			assert.Equal(t, testCase.ttl, message.Ttl)
+			assert.Equal(t, testCase.hops, message.Hops)
+		})
+	}
+}
+
+func TestMessages_PayloadNoSigExcludesRelayMetadata(t *testing.T) {
+	t.Parallel()
+
+	message := &proto.Message{
+		View: &proto.View{
+			Height: 1,
+		},
+		Type:      proto.MessageType_PREPARE,
+		Signature: []byte("signature"),
+	}
+
+	payload, err := message.PayloadNoSig()
+	assert.NoError(t, err)
+
+	relayed, ok := PrepareRelay(message, 5)
+	assert.True(t, ok)
+
+	relayedPayload, err := relayed.PayloadNoSig()
+	assert.NoError(t, err)
+
+	// Make sure relaying does not invalidate the signature
+	assert.Equal(t, payload, relayedPayload)
+}
+
+func TestMessages_MarshalCanonical(t *testing.T) {
+	t.Parallel()
+
+	message := &proto.Message{
+		View: &proto.View{
+			Height: 1,
+			Round:  2,
+		},
+		From: []byte("sender"),
+		Type: proto.MessageType_COMMIT,
+		Payload: &proto.Message_CommitData{
+			CommitData: &proto.CommitMessage{
+				ProposalHash:  []byte("proposal hash"),
+				CommittedSeal: []byte("committed seal"),
+			},
+		},
+	}
+
+	expected, err := proto.MarshalCanonical(message)
+	require.NoError(t, err)
+
+	// Make sure the encoding is stable
+	for index := 0; index < 10; index++ {
+		encoded, err := proto.MarshalCanonical(message)
+		require.NoError(t, err)
+		assert.Equal(t, expected, encoded)
+	}
+
+	// Make sure the fields unknown to the node (ex. added by a later
+	// schema version) are encoded, on any level of the message
+	extend := func(value uint64) *proto.Message {
+		unknown := protowire.AppendVarint(protowire.AppendTag(nil, 1000, protowire.VarintType), value)
+
+		extended, _ := protoBuf.Clone(message).(*proto.Message)
+		extended.ProtoReflect().SetUnknown(unknown)
+		extended.View.ProtoReflect().SetUnknown(unknown)
+
+		return extended
+	}
+
+	extended, err := proto.MarshalCanonical(extend(1))
+	require.NoError(t, err)
+	assert.NotEqual(t, expected, extended)
+
+	encoded, err := proto.MarshalCanonical(extend(1))
+	require.NoError(t, err)
+	assert.Equal(t, extended, encoded)
+
+	encoded, err = proto.MarshalCanonical(extend(2))
+	require.NoError(t, err)
+	assert.NotEqual(t, extended, encoded)
+
+	// Make sure the known fields kept encoded (ex. by the lazy decoding)
+	// are encoded as if they were decoded
+	payload, err := protoBuf.Marshal(&proto.Message{Payload: message.Payload})
+	require.NoError(t, err)
+
+	deferred, _ := protoBuf.Clone(message).(*proto.Message)
+	deferred.Payload = nil
+	deferred.ProtoReflect().SetUnknown(payload)
+
+	encoded, err = proto.MarshalCanonical(deferred)
+	require.NoError(t, err)
+	assert.Equal(t, expected, encoded)
+
+	// Make sure the original message is not modified
+	assert.Nil(t, deferred.Payload)
+}
+
+func TestMessages_IsValidTimestamp(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, IsValidTimestamp(0))
+	assert.True(t, IsValidTimestamp(math.MaxInt64))
+	assert.False(t, IsValidTimestamp(math.MaxInt64+1))
+	assert.False(t, IsValidTimestamp(math.MaxUint64))
+}
+
+func TestMessages_IsNilProposal(t *testing.T) {
+	t.Parallel()
+
+	buildProposal := func(nilProposal bool) *proto.Message {
+		return &proto.Message{
+			Type: proto.MessageType_PREPREPARE,
+			Payload: &proto.Message_PreprepareData{
+				PreprepareData: &proto.PrePrepareMessage{
+					NilProposal: nilProposal,
+				},
+			},
+		}
+	}
+
+	assert.True(t, IsNilProposal(buildProposal(true)))
+	assert.False(t, IsNilProposal(buildProposal(false)))
+	assert.False(t, IsNilProposal(&proto.Message{Type: proto.MessageType_PREPARE}))
+}
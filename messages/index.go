@@ -0,0 +1,175 @@
+package messages
+
+import (
+	"errors"
+	"fmt"
+
+	protoBuf "google.golang.org/protobuf/proto"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// ErrUnknownValidatorIndex is an error indicating the message references
+// a validator index outside the validator set of its height
+var ErrUnknownValidatorIndex = errors.New("unknown validator index")
+
+// ValidatorSetResolver resolves the validator set of a height,
+// so the validators can be referenced by their index in it (see IndexCodec).
+// The resolved sets are the ones the compact certificate formats
+// (ex. CompactPreparedCertificate) index their signer bitmaps into
+type ValidatorSetResolver interface {
+	// ValidatorSet returns the ordered validator set of the height,
+	// or false if it is not known
+	ValidatorSet(height uint64) ([][]byte, bool)
+}
+
+// senderRef is a reference to the sender of a message, or of a signature
+// of a deduplicated message, and the height of its validator set
+type senderRef struct {
+	height uint64
+	from   *[]byte
+	index  *uint32
+}
+
+// senderRefs returns the references to the senders of the message,
+// and of the messages carried by its certificates
+func senderRefs(message *proto.Message) []senderRef {
+	if message == nil || message.View == nil {
+		return nil
+	}
+
+	height := message.View.Height
+	refs := []senderRef{{height: height, from: &message.From, index: &message.FromIndex}}
+
+	// The senders of the lazily decoded payloads are rewritten as well
+	materializePayload(message)
+
+	switch payload := message.Payload.(type) {
+	case *proto.Message_PreprepareData:
+		for _, roundChange := range payload.PreprepareData.GetCertificate().GetRoundChangeMessages() {
+			refs = append(refs, senderRefs(roundChange)...)
+		}
+
+		if dedup := payload.PreprepareData.GetDedupCertificate(); dedup != nil {
+			for _, certificate := range dedup.Certificates {
+				refs = append(refs, dedupCertificateRefs(certificate)...)
+			}
+
+			for _, roundChange := range dedup.RoundChanges {
+				refs = append(refs, senderRef{
+					height: dedup.GetView().GetHeight(),
+					from:   &roundChange.From,
+					index:  &roundChange.FromIndex,
+				})
+			}
+		}
+	case *proto.Message_RoundChangeData:
+		if certificate := payload.RoundChangeData.GetLatestPreparedCertificate(); certificate != nil {
+			refs = append(refs, senderRefs(certificate.ProposalMessage)...)
+
+			for _, prepare := range certificate.PrepareMessages {
+				refs = append(refs, senderRefs(prepare)...)
+			}
+		}
+
+		refs = append(refs, dedupCertificateRefs(payload.RoundChangeData.GetDedupCertificate())...)
+	case *proto.Message_FinalityData:
+		for _, seal := range payload.FinalityData.GetCommittedSeals() {
+			refs = append(refs, senderRef{height: height, from: &seal.From, index: &seal.FromIndex})
+		}
+	}
+
+	return refs
+}
+
+// dedupCertificateRefs returns the references to the senders
+// of the messages of the deduplicated PC
+func dedupCertificateRefs(certificate *proto.DedupPreparedCertificate) []senderRef {
+	if certificate == nil {
+		return nil
+	}
+
+	refs := senderRefs(certificate.ProposalMessage)
+
+	for _, prepare := range certificate.Prepares {
+		refs = append(refs, senderRef{
+			height: certificate.GetProposalMessage().GetView().GetHeight(),
+			from:   &prepare.From,
+			index:  &prepare.FromIndex,
+		})
+	}
+
+	return refs
+}
+
+// IndexCodec is a codec referencing the validators by their index in the validator set
+// of the message height, instead of by their (raw) ID, which shrinks every message
+// and certificate on the wire. The references are resolved on decode, so they are
+// transparent to the consensus, and do not affect the signatures. Senders outside
+// the known validator set are encoded as is. All nodes in the network must use the codec.
+// On top of the LazyCodec, the payloads are decoded eagerly, as their senders are resolved
+type IndexCodec struct {
+	codec    Codec
+	resolver ValidatorSetResolver
+}
+
+// NewIndexCodec creates a new codec referencing the validators by their index,
+// on top of the specified codec (ex. ProtoCodec, or DedupCodec)
+func NewIndexCodec(codec Codec, resolver ValidatorSetResolver) *IndexCodec {
+	return &IndexCodec{
+		codec:    codec,
+		resolver: resolver,
+	}
+}
+
+// Marshal encodes the message, replacing the validator IDs with their indexes
+func (c *IndexCodec) Marshal(message *proto.Message) ([]byte, error) {
+	message, _ = protoBuf.Clone(message).(*proto.Message)
+
+	// lookups are the 1-based validator indexes, by height
+	lookups := make(map[uint64]map[string]uint32)
+
+	for _, ref := range senderRefs(message) {
+		lookup, ok := lookups[ref.height]
+		if !ok {
+			lookup = make(map[string]uint32)
+
+			validators, _ := c.resolver.ValidatorSet(ref.height)
+			for index, validator := range validators {
+				lookup[string(validator)] = uint32(index + 1)
+			}
+
+			lookups[ref.height] = lookup
+		}
+
+		if index, ok := lookup[string(*ref.from)]; ok {
+			*ref.from = nil
+			*ref.index = index
+		}
+	}
+
+	return c.codec.Marshal(message)
+}
+
+// Unmarshal decodes the message, resolving the validator indexes into their IDs
+func (c *IndexCodec) Unmarshal(data []byte, message *proto.Message) error {
+	if err := c.codec.Unmarshal(data, message); err != nil {
+		return err
+	}
+
+	for _, ref := range senderRefs(message) {
+		if *ref.index == 0 {
+			continue
+		}
+
+		validators, _ := c.resolver.ValidatorSet(ref.height)
+		if int(*ref.index) > len(validators) {
+			return fmt.Errorf("%w: %d at height %d", ErrUnknownValidatorIndex, *ref.index, ref.height)
+		}
+
+		*ref.from = append([]byte(nil), validators[*ref.index-1]...)
+		*ref.index = 0
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package messages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	protoBuf "google.golang.org/protobuf/proto"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// staticValidatorSets is the resolver of the same validator set for all heights
+type staticValidatorSets [][]byte
+
+func (s staticValidatorSets) ValidatorSet(_ uint64) ([][]byte, bool) {
+	return s, true
+}
+
+func TestIndexCodec_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	var (
+		proposal   = buildDedupTestProposal(10)
+		validators = generateValidators(10)
+		finality   = NewFinalityMessage(
+			&proto.View{Height: 10, Round: 3},
+			&proto.Proposal{RawProposal: []byte("raw proposal")},
+			[]byte("proposal hash"),
+			[]*CommittedSeal{
+				{Signer: validators[0], Signature: []byte("seal 0")},
+				{Signer: validators[1], Signature: []byte("seal 1")},
+			},
+		)
+	)
+
+	testTable := []struct {
+		name    string
+		codec   Codec
+		message *proto.Message
+	}{
+		{"proposal", ProtoCodec{}, proposal},
+		{"deduplicated proposal", DedupCodec{}, proposal},
+		{"round change", ProtoCodec{}, proposal.GetPreprepareData().Certificate.RoundChangeMessages[2]},
+		{"deduplicated round change", DedupCodec{}, proposal.GetPreprepareData().Certificate.RoundChangeMessages[2]},
+		{"finality proof", ProtoCodec{}, finality},
+		{"lazily decoded proposal", LazyCodec{MinLazySize: 1}, proposal},
+		{"lazily decoded finality proof", LazyCodec{MinLazySize: 1}, finality},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			codec := NewIndexCodec(testCase.codec, staticValidatorSets(validators))
+
+			encoded, err := codec.Marshal(testCase.message)
+			require.NoError(t, err)
+
+			plain, err := testCase.codec.Marshal(testCase.message)
+			require.NoError(t, err)
+
+			// Make sure the references shrink the message
+			assert.Less(t, len(encoded), len(plain))
+
+			decoded := &proto.Message{}
+			require.NoError(t, codec.Unmarshal(encoded, decoded))
+
+			// Make sure the message is restored, so its signature is still valid
+			assert.True(t, protoBuf.Equal(testCase.message, decoded))
+
+			// Make sure the lazily decoded messages are encoded with the references
+			lazy := &proto.Message{}
+			require.NoError(t, testCase.codec.Unmarshal(plain, lazy))
+
+			reencoded, err := codec.Marshal(lazy)
+			require.NoError(t, err)
+			assert.Equal(t, len(encoded), len(reencoded))
+		})
+	}
+}
+
+func TestIndexCodec_UnknownValidators(t *testing.T) {
+	t.Parallel()
+
+	var (
+		validators = generateValidators(4)
+		codec      = NewIndexCodec(ProtoCodec{}, staticValidatorSets(validators))
+		message    = &proto.Message{
+			View:      &proto.View{Height: 1},
+			From:      []byte("outsider"),
+			Signature: []byte("signature"),
+			Type:      proto.MessageType_PREPARE,
+		}
+	)
+
+	// Make sure the senders outside the validator set are encoded as is
+	encoded, err := codec.Marshal(message)
+	require.NoError(t, err)
+
+	decoded := &proto.Message{}
+	require.NoError(t, codec.Unmarshal(encoded, decoded))
+	assert.True(t, protoBuf.Equal(message, decoded))
+
+	// Make sure references outside the validator set are rejected
+	message.From = nil
+	message.FromIndex = uint32(len(validators) + 1)
+
+	encoded, err = ProtoCodec{}.Marshal(message)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, codec.Unmarshal(encoded, &proto.Message{}), ErrUnknownValidatorIndex)
+}
@@ -0,0 +1,146 @@
+package messages
+
+import (
+	"google.golang.org/protobuf/encoding/protowire"
+	protoBuf "google.golang.org/protobuf/proto"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// DefaultMinLazySize is the default minimum encoded size
+// of a payload decoded lazily by the LazyCodec
+const DefaultMinLazySize = 1024
+
+// payloadFields are the field numbers of the message payload oneof,
+// derived from the message descriptor so new payload types are deferred as well
+var payloadFields = func() map[protowire.Number]struct{} {
+	fields := (&proto.Message{}).ProtoReflect().Descriptor().Oneofs().ByName("payload").Fields()
+
+	numbers := make(map[protowire.Number]struct{}, fields.Len())
+	for idx := 0; idx < fields.Len(); idx++ {
+		numbers[fields.Get(idx).Number()] = struct{}{}
+	}
+
+	return numbers
+}()
+
+// LazyCodec is a codec using the proto wire encoding, which defers decoding
+// the large message payloads (proposals and certificates) until they are accessed
+// with ExtractPayload. Nodes that only relay messages, or only count them
+// toward quorum, never pay for decoding the payloads.
+//
+// The payload of a lazily decoded message is kept encoded in the unknown fields
+// of the message, so the message is relayed and verified byte for byte
+// (the PayloadNoSig bytes match the eagerly decoded message). Consumers must access
+// the payloads with ExtractPayload, as the generated payload getters return nil
+// until the payload is decoded
+type LazyCodec struct {
+	// MinLazySize is the minimum encoded size of a payload to decode it lazily,
+	// smaller payloads are decoded eagerly. If not set, DefaultMinLazySize is used
+	MinLazySize int
+}
+
+// Marshal encodes the message using the proto wire encoding.
+// Lazily decoded payloads are encoded as received
+func (LazyCodec) Marshal(message *proto.Message) ([]byte, error) {
+	return protoBuf.Marshal(message)
+}
+
+// Unmarshal decodes the proto encoded data into the message,
+// deferring the decoding of the large payloads
+func (c LazyCodec) Unmarshal(data []byte, message *proto.Message) error {
+	minLazySize := c.MinLazySize
+	if minLazySize <= 0 {
+		minLazySize = DefaultMinLazySize
+	}
+
+	var (
+		envelope = make([]byte, 0, len(data))
+		payload  []byte
+	)
+
+	for remaining := data; len(remaining) > 0; {
+		number, wireType, tagLen := protowire.ConsumeTag(remaining)
+		if tagLen < 0 {
+			return protowire.ParseError(tagLen)
+		}
+
+		valueLen := protowire.ConsumeFieldValue(number, wireType, remaining[tagLen:])
+		if valueLen < 0 {
+			return protowire.ParseError(valueLen)
+		}
+
+		field := remaining[:tagLen+valueLen]
+		remaining = remaining[tagLen+valueLen:]
+
+		if isPayloadField(number, wireType) && len(field) >= minLazySize {
+			payload = append(payload, field...)
+
+			continue
+		}
+
+		envelope = append(envelope, field...)
+	}
+
+	if err := protoBuf.Unmarshal(envelope, message); err != nil {
+		return err
+	}
+
+	if len(payload) > 0 {
+		reflected := message.ProtoReflect()
+		reflected.SetUnknown(append(reflected.GetUnknown(), payload...))
+	}
+
+	return nil
+}
+
+// isPayloadField checks if the field is a field of the message payload oneof
+func isPayloadField(number protowire.Number, wireType protowire.Type) bool {
+	if wireType != protowire.BytesType {
+		return false
+	}
+
+	_, ok := payloadFields[number]
+
+	return ok
+}
+
+// decodedPayload returns the message with the decoded payload of the lazily
+// decoded message, or nil if the message payload is not lazily decoded.
+// The payload of a message kept in a store is decoded once, and cached
+func decodedPayload(message *proto.Message) *proto.Message {
+	if message.Payload != nil {
+		return nil
+	}
+
+	unknown := message.ProtoReflect().GetUnknown()
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	metadata := metadataCache.lookup(message)
+
+	metadata.payloadOnce.Do(func() {
+		decoded := &proto.Message{}
+		if err := protoBuf.Unmarshal(unknown, decoded); err != nil {
+			return
+		}
+
+		metadata.decodedPayload = decoded
+	})
+
+	return metadata.decodedPayload
+}
+
+// materializePayload decodes the lazily decoded payload of the message into its payload
+// fields, for the codecs rewriting the payloads (see IndexCodec). The message is left as is
+// if its payload is not lazily decoded, or is malformed
+func materializePayload(message *proto.Message) {
+	decoded := decodedPayload(message)
+	if decoded == nil {
+		return
+	}
+
+	message.Payload = decoded.Payload
+	message.ProtoReflect().SetUnknown(decoded.ProtoReflect().GetUnknown())
+}
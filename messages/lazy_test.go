@@ -0,0 +1,268 @@
+package messages
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+	protoBuf "google.golang.org/protobuf/proto"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// buildLazyProposal builds a signed PREPREPARE message, with the proposal of the specified size
+func buildLazyProposal(height uint64, size int) *proto.Message {
+	return &proto.Message{
+		View:      &proto.View{Height: height, Round: 1},
+		From:      []byte("proposer"),
+		Signature: []byte("signature"),
+		Type:      proto.MessageType_PREPREPARE,
+		Payload: &proto.Message_PreprepareData{
+			PreprepareData: &proto.PrePrepareMessage{
+				Proposal: &proto.Proposal{
+					RawProposal: bytes.Repeat([]byte{0x1}, size),
+					Round:       1,
+				},
+				ProposalHash: []byte("proposal hash"),
+				NilProposal:  false,
+			},
+		},
+		Ttl:  3,
+		Hops: 1,
+	}
+}
+
+func TestLazyCodec_DefersLargePayloads(t *testing.T) {
+	t.Parallel()
+
+	var (
+		codec   Codec = LazyCodec{}
+		message       = buildLazyProposal(1001, 4*DefaultMinLazySize)
+	)
+
+	data, err := codec.Marshal(message)
+	require.NoError(t, err)
+
+	decoded := &proto.Message{}
+	require.NoError(t, codec.Unmarshal(data, decoded))
+
+	// Make sure the envelope is decoded, but the payload is not
+	assert.True(t, protoBuf.Equal(message.View, decoded.View))
+	assert.Equal(t, message.From, decoded.From)
+	assert.Equal(t, message.Signature, decoded.Signature)
+	assert.Equal(t, message.Type, decoded.Type)
+	assert.Equal(t, message.Ttl, decoded.Ttl)
+	assert.Equal(t, message.Hops, decoded.Hops)
+	assert.Nil(t, decoded.Payload)
+
+	// Make sure the signed bytes match the eagerly decoded message
+	expectedPayload, err := message.PayloadNoSig()
+	require.NoError(t, err)
+
+	payload, err := decoded.PayloadNoSig()
+	require.NoError(t, err)
+
+	assert.Equal(t, expectedPayload, payload)
+
+	// Make sure the payload is decoded on access
+	preprepareData, err := ExtractPayload[*proto.PrePrepareMessage](decoded)
+	require.NoError(t, err)
+	assert.True(t, protoBuf.Equal(message.GetPreprepareData(), preprepareData))
+
+	// Make sure the payload is cached once the message is stored
+	store := NewMessages()
+	defer store.Close()
+
+	store.AddMessage(decoded)
+
+	preprepareData, err = ExtractPayload[*proto.PrePrepareMessage](decoded)
+	require.NoError(t, err)
+
+	cached, err := ExtractPayload[*proto.PrePrepareMessage](decoded)
+	require.NoError(t, err)
+	assert.Same(t, preprepareData, cached)
+
+	assert.False(t, IsNilProposal(decoded))
+
+	// Make sure the payload type is still checked
+	_, err = ExtractPayload[*proto.RoundChangeMessage](decoded)
+	assert.ErrorIs(t, err, ErrPayloadTypeMismatch)
+
+	// Make sure the relayed message decodes into the original one
+	relayed, err := codec.Marshal(decoded)
+	require.NoError(t, err)
+
+	eager := &proto.Message{}
+	require.NoError(t, ProtoCodec{}.Unmarshal(relayed, eager))
+	assert.True(t, protoBuf.Equal(message, eager))
+}
+
+func TestLazyCodec_DecodesSmallPayloadsEagerly(t *testing.T) {
+	t.Parallel()
+
+	var (
+		codec   Codec = LazyCodec{}
+		message       = generateRandomMessages(1, &proto.View{Height: 1, Round: 2}, proto.MessageType_COMMIT)[0]
+	)
+
+	data, err := codec.Marshal(message)
+	require.NoError(t, err)
+
+	decoded := &proto.Message{}
+	require.NoError(t, codec.Unmarshal(data, decoded))
+
+	assert.True(t, protoBuf.Equal(message, decoded))
+
+	// Make sure corrupted data is rejected
+	assert.Error(t, codec.Unmarshal([]byte{0xff, 0xff}, &proto.Message{}))
+}
+
+func TestLazyCodec_MalformedPayload(t *testing.T) {
+	t.Parallel()
+
+	// The payload field is a valid length-delimited field,
+	// with contents that are not a valid PREPREPARE payload
+	data := protoBuf.Message(&proto.Message{
+		View: &proto.View{Height: 1002},
+		Type: proto.MessageType_PREPREPARE,
+	})
+
+	encoded, err := protoBuf.Marshal(data)
+	require.NoError(t, err)
+
+	encoded = append(encoded, 0x2a, 0x02, 0xff, 0xff)
+
+	decoded := &proto.Message{}
+	require.NoError(t, LazyCodec{MinLazySize: 1}.Unmarshal(encoded, decoded))
+
+	// Make sure the malformed payload is reported on access
+	_, err = ExtractPayload[*proto.PrePrepareMessage](decoded)
+	assert.ErrorIs(t, err, ErrMissingPayload)
+}
+
+func TestMessages_PruneLazyPayloads(t *testing.T) {
+	t.Parallel()
+
+	var (
+		height = uint64(7)
+		codec  = LazyCodec{MinLazySize: 1}
+
+		messages = NewMessages()
+	)
+
+	defer messages.Close()
+
+	data, err := codec.Marshal(buildLazyProposal(height, 64))
+	require.NoError(t, err)
+
+	decoded := &proto.Message{}
+	require.NoError(t, codec.Unmarshal(data, decoded))
+
+	messages.AddMessage(decoded)
+
+	_, err = ExtractPayload[*proto.PrePrepareMessage](decoded)
+	require.NoError(t, err)
+
+	assert.True(t, metadataCache.cached(decoded))
+
+	// Make sure the decoded payload is dropped with the height
+	messages.PruneByHeight(height + 1)
+
+	assert.False(t, metadataCache.cached(decoded))
+}
+
+// TestMessages_ReleaseMetadata makes sure the cached metadata is dropped
+// with the messages removed from the store
+func TestMessages_ReleaseMetadata(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view     = &proto.View{Height: 1, Round: 0}
+		messages = NewMessages()
+	)
+
+	defer messages.Close()
+
+	// Make sure the metadata of a replaced message is dropped
+	prepare := generateRandomMessages(1, view, proto.MessageType_PREPARE)[0]
+	messages.AddMessage(prepare)
+
+	replacement, _ := protoBuf.Clone(prepare).(*proto.Message)
+	messages.AddMessage(replacement)
+
+	assert.False(t, metadataCache.cached(prepare))
+	assert.True(t, metadataCache.cached(replacement))
+
+	// Make sure the metadata of the messages of the pruned rounds is dropped
+	messages.PruneByRound(view.Height, view.Round+1)
+
+	assert.False(t, metadataCache.cached(replacement))
+
+	// Make sure the metadata of the expired messages is dropped
+	roundChange := generateRandomMessages(1, view, proto.MessageType_ROUND_CHANGE)[0]
+	messages.AddMessage(roundChange)
+
+	assert.Equal(t, 1, messages.PruneExpired(0))
+	assert.False(t, metadataCache.cached(roundChange))
+
+	// Make sure the metadata of the messages of a closed store is dropped,
+	// and not cached afterwards
+	commits := generateRandomMessages(2, view, proto.MessageType_COMMIT)
+
+	messages.AddMessage(commits[0])
+	messages.Close()
+	messages.AddMessage(commits[1])
+
+	assert.False(t, metadataCache.cached(commits[0]))
+	assert.False(t, metadataCache.cached(commits[1]))
+
+	metadataCache.Lock()
+	defer metadataCache.Unlock()
+
+	assert.NotContains(t, metadataCache.owned, messages)
+}
+
+// TestLazyCodec_DefersFinalityPayloads makes sure the payload types
+// beyond the consensus phases are deferred as well
+func TestLazyCodec_DefersFinalityPayloads(t *testing.T) {
+	t.Parallel()
+
+	var (
+		codec   Codec = LazyCodec{MinLazySize: 1}
+		message       = NewFinalityMessage(
+			&proto.View{Height: 1, Round: 0},
+			&proto.Proposal{RawProposal: bytes.Repeat([]byte{0x1}, 64)},
+			[]byte("proposal hash"),
+			[]*CommittedSeal{{Signer: []byte("validator"), Signature: []byte("seal")}},
+		)
+	)
+
+	data, err := codec.Marshal(message)
+	require.NoError(t, err)
+
+	decoded := &proto.Message{}
+	require.NoError(t, codec.Unmarshal(data, decoded))
+
+	assert.Nil(t, decoded.Payload)
+
+	finalityData, err := ExtractPayload[*proto.FinalityMessage](decoded)
+	require.NoError(t, err)
+	assert.True(t, protoBuf.Equal(message.GetFinalityData(), finalityData))
+}
+
+func TestIsPayloadField(t *testing.T) {
+	t.Parallel()
+
+	// Make sure all the payload oneof fields are deferred, and the envelope fields are not
+	for _, number := range []protowire.Number{5, 6, 7, 8, 11, 12, 14} {
+		assert.True(t, isPayloadField(number, protowire.BytesType), "field %d", number)
+	}
+
+	for _, number := range []protowire.Number{1, 2, 3, 9, 10, 13} {
+		assert.False(t, isPayloadField(number, protowire.BytesType), "field %d", number)
+	}
+
+	assert.False(t, isPayloadField(5, protowire.VarintType))
+}
@@ -0,0 +1,186 @@
+package messages
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"sort"
+)
+
+var (
+	// ErrSealNotCommitted is an error indicating the signer
+	// has no committed seal in the committed set
+	ErrSealNotCommitted = errors.New("committed seal not in the committed set")
+
+	// ErrSealsRootMismatch is an error indicating the seals root
+	// of the commit certificate does not match its committed seals
+	ErrSealsRootMismatch = errors.New("committed seals root mismatch")
+)
+
+// The domain prefixes of the Merkle tree nodes, so a leaf
+// cannot be passed off as an inner node, and vice versa
+const (
+	sealLeafPrefix  byte = 0x00
+	sealInnerPrefix byte = 0x01
+)
+
+// SealProof is the inclusion proof of a committed seal in the committed set,
+// proving the validator signed the proposal of the certificate with the seals root
+type SealProof struct {
+	// Index is the position of the seal leaf in the tree
+	Index uint64
+
+	// Leaves is the number of seals in the committed set
+	Leaves uint64
+
+	// Siblings are the hashes of the sibling nodes on the path
+	// from the seal leaf to the root, bottom up
+	Siblings [][]byte
+}
+
+// SealsRoot returns the Merkle root of the committed seals. The seals are ordered
+// by their signer, so the root does not depend on the order they were received in.
+// The root of an empty set is nil
+func SealsRoot(seals []*CommittedSeal) []byte {
+	level := sealLeaves(seals)
+	if len(level) == 0 {
+		return nil
+	}
+
+	for len(level) > 1 {
+		level = nextSealLevel(level)
+	}
+
+	return level[0]
+}
+
+// BuildSealProof builds the inclusion proof of the committed seal
+// of the signer in the committed seals (see SealsRoot)
+func BuildSealProof(seals []*CommittedSeal, signer []byte) (*SealProof, error) {
+	sorted := sortedSeals(seals)
+
+	index := sort.Search(len(sorted), func(i int) bool {
+		return bytes.Compare(sorted[i].Signer, signer) >= 0
+	})
+
+	if index == len(sorted) || !bytes.Equal(sorted[index].Signer, signer) {
+		return nil, ErrSealNotCommitted
+	}
+
+	proof := &SealProof{
+		Index:  uint64(index),
+		Leaves: uint64(len(sorted)),
+	}
+
+	level := sealLeaves(sorted)
+
+	for position := index; len(level) > 1; position /= 2 {
+		// The last node of an odd level has no sibling, it is promoted as is
+		if sibling := position ^ 1; sibling < len(level) {
+			proof.Siblings = append(proof.Siblings, level[sibling])
+		}
+
+		level = nextSealLevel(level)
+	}
+
+	return proof, nil
+}
+
+// VerifySealProof checks if the inclusion proof proves the committed seal
+// is part of the committed seals with the root. The seal itself
+// (its signature over the proposal hash) is not verified
+func VerifySealProof(root []byte, seal *CommittedSeal, proof *SealProof) bool {
+	if seal == nil || proof == nil || proof.Index >= proof.Leaves {
+		return false
+	}
+
+	var (
+		hash     = sealLeafHash(seal)
+		siblings = proof.Siblings
+	)
+
+	for position, width := proof.Index, proof.Leaves; width > 1; position, width = position/2, (width+1)/2 {
+		// The last node of an odd level has no sibling, it is promoted as is
+		if position == width-1 && width%2 == 1 {
+			continue
+		}
+
+		if len(siblings) == 0 {
+			return false
+		}
+
+		if position%2 == 0 {
+			hash = sealInnerHash(hash, siblings[0])
+		} else {
+			hash = sealInnerHash(siblings[0], hash)
+		}
+
+		siblings = siblings[1:]
+	}
+
+	return len(siblings) == 0 && bytes.Equal(hash, root)
+}
+
+// sortedSeals returns the committed seals ordered by their signer
+func sortedSeals(seals []*CommittedSeal) []*CommittedSeal {
+	sorted := make([]*CommittedSeal, len(seals))
+	copy(sorted, seals)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].Signer, sorted[j].Signer) < 0
+	})
+
+	return sorted
+}
+
+// sealLeaves returns the leaf hashes of the committed seals, ordered by their signer
+func sealLeaves(seals []*CommittedSeal) [][]byte {
+	sorted := sortedSeals(seals)
+
+	leaves := make([][]byte, 0, len(sorted))
+	for _, seal := range sorted {
+		leaves = append(leaves, sealLeafHash(seal))
+	}
+
+	return leaves
+}
+
+// nextSealLevel returns the parent level of the tree level
+func nextSealLevel(level [][]byte) [][]byte {
+	next := make([][]byte, 0, (len(level)+1)/2)
+
+	for index := 0; index < len(level); index += 2 {
+		if index+1 == len(level) {
+			next = append(next, level[index])
+
+			continue
+		}
+
+		next = append(next, sealInnerHash(level[index], level[index+1]))
+	}
+
+	return next
+}
+
+// sealLeafHash returns the leaf hash of the committed seal.
+// The signer is length-prefixed, so the signer and signature boundary is unambiguous
+func sealLeafHash(seal *CommittedSeal) []byte {
+	hasher := sha256.New()
+	hasher.Write([]byte{sealLeafPrefix})
+	hasher.Write(binary.AppendUvarint(nil, uint64(len(seal.Signer))))
+	hasher.Write(seal.Signer)
+	hasher.Write(seal.Signature)
+
+	return hasher.Sum(nil)
+}
+
+// sealInnerHash returns the hash of the inner node with the children
+func sealInnerHash(left, right []byte) []byte {
+	hasher := sha256.New()
+	hasher.Write([]byte{sealInnerPrefix})
+	hasher.Write(left)
+	hasher.Write(right)
+
+	return hasher.Sum(nil)
+}
@@ -0,0 +1,104 @@
+package messages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealProof_Inclusion(t *testing.T) {
+	t.Parallel()
+
+	proposalHash := []byte("proposal hash")
+
+	// Cover the balanced trees, and the trees promoting the unpaired nodes
+	for count := 1; count <= 9; count++ {
+		var (
+			validators = generateValidators(count)
+			seals      = buildXorSeals(validators, proposalHash, reversedIndexes(count)...)
+			root       = SealsRoot(seals)
+		)
+
+		// Make sure the root does not depend on the seal order
+		assert.Equal(t, root, SealsRoot(buildXorSeals(validators, proposalHash, indexes(count)...)))
+
+		for _, seal := range seals {
+			proof, err := BuildSealProof(seals, seal.Signer)
+			require.NoError(t, err)
+
+			assert.True(t, VerifySealProof(root, seal, proof), "%d seals, signer %s", count, seal.Signer)
+
+			// Make sure the proof does not prove a different seal of the signer
+			forged := &CommittedSeal{Signer: seal.Signer, Signature: []byte("forged seal")}
+			assert.False(t, VerifySealProof(root, forged, proof))
+		}
+	}
+}
+
+func TestSealProof_Invalid(t *testing.T) {
+	t.Parallel()
+
+	var (
+		proposalHash = []byte("proposal hash")
+		validators   = generateValidators(5)
+		seals        = buildXorSeals(validators, proposalHash, 0, 1, 2, 3)
+		root         = SealsRoot(seals)
+	)
+
+	// Make sure the signers without a seal have no proof
+	_, err := BuildSealProof(seals, validators[4])
+	assert.ErrorIs(t, err, ErrSealNotCommitted)
+
+	proof, err := BuildSealProof(seals, seals[1].Signer)
+	require.NoError(t, err)
+
+	testTable := []struct {
+		name  string
+		root  []byte
+		proof *SealProof
+	}{
+		{"other root", SealsRoot(seals[:3]), proof},
+		{"missing proof", root, nil},
+		{"missing sibling", root, &SealProof{Index: proof.Index, Leaves: proof.Leaves, Siblings: proof.Siblings[1:]}},
+		{"extra sibling", root, &SealProof{
+			Index:    proof.Index,
+			Leaves:   proof.Leaves,
+			Siblings: append(append([][]byte{}, proof.Siblings...), root),
+		}},
+		{"other index", root, &SealProof{Index: proof.Index ^ 1, Leaves: proof.Leaves, Siblings: proof.Siblings}},
+		{"index outside the set", root, &SealProof{Index: proof.Leaves, Leaves: proof.Leaves, Siblings: proof.Siblings}},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.False(t, VerifySealProof(testCase.root, seals[1], testCase.proof))
+		})
+	}
+
+	assert.Nil(t, SealsRoot(nil))
+}
+
+// indexes returns the indexes from 0 to count-1
+func indexes(count int) []int {
+	result := make([]int, 0, count)
+	for index := 0; index < count; index++ {
+		result = append(result, index)
+	}
+
+	return result
+}
+
+// reversedIndexes returns the indexes from count-1 to 0
+func reversedIndexes(count int) []int {
+	result := make([]int, 0, count)
+	for index := count - 1; index >= 0; index-- {
+		result = append(result, index)
+	}
+
+	return result
+}
@@ -1,7 +1,11 @@
 package messages
 
 import (
+	"context"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/renloi/ibft/messages/proto"
 )
@@ -18,13 +22,75 @@ type Messages struct {
 	preprepareMessages,
 	prepareMessages,
 	commitMessages,
-	roundChangeMessages heightMessageMap
+	roundChangeMessages,
+	dkgMessages,
+	vetoMessages heightMessageMap
+
+	// counters are the duplicate and invalid message counters,
+	// by message type. They are protected by the message type mutex
+	counters map[proto.MessageType]heightCounters
+
+	// arrivalSeq is the arrival sequence number of the latest added message
+	arrivalSeq atomic.Uint64
+
+	// prunePolicy determines the heights pruned when the node moves to a new height
+	prunePolicy PrunePolicy
+
+	// roundChangeRetention determines which of the ROUND_CHANGE messages
+	// of a sender for the same view is kept
+	roundChangeRetention RoundChangeRetention
+
+	// equivocationPolicy determines which of the conflicting
+	// messages of a sender for the same view is kept
+	equivocationPolicy EquivocationPolicy
+
+	// equivocationHandler is the handler of the equivocation evidence, if set
+	equivocationHandler EquivocationHandler
+
+	// storeTTL is the age after which the views with no new messages
+	// are discarded by the periodic sweep. A zero TTL disables the sweep (see WithStoreTTL)
+	storeTTL time.Duration
+
+	// quorumFn decides when the subscriptions fire, if set (see WithQuorumFn)
+	quorumFn QuorumFn
+
+	// quorums are the views and message types that reached quorum
+	quorums *quorumSet
+
+	// closeCh is closed once the store is closed
+	closeCh   chan struct{}
+	closeOnce sync.Once
+}
+
+// Subscribe creates a new message type subscription. The subscription
+// is removed once the context is cancelled, or when it is unsubscribed.
+// The notification channel is closed when the subscription is removed
+func (ms *Messages) Subscribe(ctx context.Context, details SubscriptionDetails) *Subscription {
+	return ms.subscribe(ctx, details, nil)
+}
+
+// SubscribeFunc creates a new message type subscription with push semantics.
+// The callback is invoked with the round of each event on the dispatch goroutine
+// of the subscription, so the invocations are never concurrent. Events signaled
+// while the callback runs are coalesced into a single invocation.
+// The subscription is removed like the ones created by Subscribe
+func (ms *Messages) SubscribeFunc(
+	ctx context.Context,
+	details SubscriptionDetails,
+	callback SubscriptionCallback,
+) SubscriptionID {
+	return ms.subscribe(ctx, details, callback).ID
 }
 
-// Subscribe creates a new message type subscription
-func (ms *Messages) Subscribe(details SubscriptionDetails) *Subscription {
+// subscribe creates a new message type subscription, and signals it
+// right away if the subscription conditions are already met
+func (ms *Messages) subscribe(
+	ctx context.Context,
+	details SubscriptionDetails,
+	callback SubscriptionCallback,
+) *Subscription {
 	// Create the subscription
-	subscription := ms.eventManager.subscribe(details)
+	subscription := ms.eventManager.subscribe(ctx, details, callback)
 
 	// Check if any condition is already met
 	msgs := ms.GetValidMessages(details.View, details.MessageType, func(_ *proto.Message) bool { return true })
@@ -42,12 +108,23 @@ func (ms *Messages) Unsubscribe(id SubscriptionID) {
 }
 
 // NewMessages returns a new Messages wrapper
-func NewMessages() *Messages {
-	return &Messages{
+func NewMessages(opts ...Option) *Messages {
+	ms := &Messages{
 		preprepareMessages:  make(heightMessageMap),
 		prepareMessages:     make(heightMessageMap),
 		commitMessages:      make(heightMessageMap),
 		roundChangeMessages: make(heightMessageMap),
+		dkgMessages:         make(heightMessageMap),
+		vetoMessages:        make(heightMessageMap),
+
+		counters: map[proto.MessageType]heightCounters{
+			proto.MessageType_PREPREPARE:   {},
+			proto.MessageType_PREPARE:      {},
+			proto.MessageType_COMMIT:       {},
+			proto.MessageType_ROUND_CHANGE: {},
+			proto.MessageType_DKG:          {},
+			proto.MessageType_VETO:         {},
+		},
 
 		eventManager: newEventManager(),
 
@@ -56,40 +133,129 @@ func NewMessages() *Messages {
 			proto.MessageType_PREPARE:      {},
 			proto.MessageType_COMMIT:       {},
 			proto.MessageType_ROUND_CHANGE: {},
+			proto.MessageType_DKG:          {},
+			proto.MessageType_VETO:         {},
 		},
+
+		prunePolicy: HeightWindowPolicy{},
+
+		quorums: newQuorumSet(),
+		closeCh: make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(ms)
+	}
+
+	if ms.storeTTL > 0 {
+		go ms.runTTLSweep()
 	}
+
+	return ms
 }
 
-// AddMessage adds a new message to the message queue
+// AddMessage adds a new message to the message queue. A conflicting message
+// of the same sender for the view is handled according to the equivocation policy.
+// By default, the latest message is kept, except for the ROUND_CHANGE messages,
+// which are kept according to the round change retention policy
+// (by default, the message with the highest PC is kept).
+// The subscriptions of the message view and type are signaled once the message is added,
+// if the stored messages reached quorum (see WithQuorumFn)
 func (ms *Messages) AddMessage(message *proto.Message) {
 	mux := ms.muxMap[message.Type]
 	mux.Lock()
-	defer mux.Unlock()
+	ms.addMessage(message)
+	mux.Unlock()
+
+	ms.notify(message.View, message.Type)
+}
+
+// AddMessages adds a batch of messages to the message queues, as AddMessage does.
+// The lock of each message type is acquired once for the batch, so batches delivered
+// by the network layer (or imported during catch-up) do not contend with the readers
+// message by message. The messages of a type are added in the batch order.
+// The subscriptions are signaled once for each view and type of the batch,
+// after the whole batch is added
+func (ms *Messages) AddMessages(messages []*proto.Message) {
+	for _, messageType := range allMessageTypes {
+		ms.addMessagesOfType(messageType, messages)
+	}
+
+	ms.notifyBatch(messages)
+}
+
+// addMessagesOfType adds the messages of the type in the batch, under a single lock acquisition
+func (ms *Messages) addMessagesOfType(messageType proto.MessageType, messages []*proto.Message) {
+	locked := false
+
+	for _, message := range messages {
+		if message.Type != messageType {
+			continue
+		}
+
+		if !locked {
+			mux := ms.muxMap[messageType]
+			mux.Lock()
+			defer mux.Unlock()
 
+			locked = true
+		}
+
+		ms.addMessage(message)
+	}
+}
+
+// addMessage adds a new message to the message queue.
+// The lock of the message type must be held
+func (ms *Messages) addMessage(message *proto.Message) {
 	// Get the corresponding height map
 	heightMsgMap := ms.getMessageMap(message.Type)
 
 	// Append the message to the appropriate queue
 	messages := heightMsgMap.getViewMessages(message.View)
-	messages[string(message.From)] = message
-}
 
-// SignalEvent signals event
-func (ms *Messages) SignalEvent(message *proto.Message) {
-	ms.eventManager.signalEvent(
-		message.Type,
-		&proto.View{
-			Height: message.View.Height,
-			Round:  message.View.Round,
-		},
-	)
+	if stored, duplicate := messages[string(message.From)]; duplicate {
+		counters := ms.counters[message.Type].getViewCounters(message.View)
+		counters.duplicates++
+
+		if !ms.replacesStored(stored.Message, message, counters) {
+			return
+		}
+
+		metadataCache.untrack(ms, stored.Message)
+	}
+
+	messages[string(message.From)] = &storedMessage{
+		Message:  message,
+		arrival:  ms.arrivalSeq.Add(1),
+		received: time.Now(),
+	}
+
+	metadataCache.track(ms, message)
 }
 
-// Close closes event manager
+// Close closes the event manager, and stops the TTL sweep (see WithStoreTTL)
 func (ms *Messages) Close() {
+	ms.closeOnce.Do(func() {
+		close(ms.closeCh)
+
+		// Drop the cached metadata of the store messages
+		metadataCache.release(ms, func(*messageMetadata) bool { return true })
+	})
+
 	ms.eventManager.close()
 }
 
+// isClosed checks if the store is closed
+func (ms *Messages) isClosed() bool {
+	select {
+	case <-ms.closeCh:
+		return true
+	default:
+		return false
+	}
+}
+
 // getMessageMap fetches the corresponding message map by type
 func (ms *Messages) getMessageMap(messageType proto.MessageType) heightMessageMap {
 	switch messageType {
@@ -101,6 +267,10 @@ func (ms *Messages) getMessageMap(messageType proto.MessageType) heightMessageMa
 		return ms.commitMessages
 	case proto.MessageType_ROUND_CHANGE:
 		return ms.roundChangeMessages
+	case proto.MessageType_DKG:
+		return ms.dkgMessages
+	case proto.MessageType_VETO:
+		return ms.vetoMessages
 	}
 
 	return nil
@@ -132,35 +302,145 @@ func (ms *Messages) numMessages(
 	return len(messages)
 }
 
-// PruneByHeight prunes out all old messages from the message queues
-// by the specified height in the view
+// allMessageTypes are the message types kept in the store
+var allMessageTypes = []proto.MessageType{
+	proto.MessageType_PREPREPARE,
+	proto.MessageType_PREPARE,
+	proto.MessageType_COMMIT,
+	proto.MessageType_ROUND_CHANGE,
+	proto.MessageType_DKG,
+	proto.MessageType_VETO,
+}
+
+// PruneByHeight prunes out the old messages from the message queues,
+// as determined by the prune policy for the specified current height.
+// By default, all messages below the height are pruned
 func (ms *Messages) PruneByHeight(height uint64) {
-	possibleMaps := []proto.MessageType{
-		proto.MessageType_PREPREPARE,
-		proto.MessageType_PREPARE,
-		proto.MessageType_COMMIT,
-		proto.MessageType_ROUND_CHANGE,
+	// Drop the cached metadata of the past messages
+	metadataCache.prune(ms, height)
+
+	pruned := ms.prunePolicy.PruneHeights(height, ms.heightStats())
+	if len(pruned) == 0 {
+		return
 	}
 
-	// Prune out the views from all possible message types
-	for _, messageType := range possibleMaps {
+	// Prune out the heights from all possible message types
+	for _, messageType := range allMessageTypes {
 		mux := ms.muxMap[messageType]
 		mux.Lock()
 
 		messageMap := ms.getMessageMap(messageType)
 
-		// Delete all height maps up until the specified
-		// view height
-		for msgHeight := range messageMap {
-			if msgHeight < height {
-				delete(messageMap, msgHeight)
+		for _, prunedHeight := range pruned {
+			delete(messageMap, prunedHeight)
+			delete(ms.counters[messageType], prunedHeight)
+		}
+
+		mux.Unlock()
+	}
+
+	prunedHeights := make(map[uint64]struct{}, len(pruned))
+	for _, prunedHeight := range pruned {
+		prunedHeights[prunedHeight] = struct{}{}
+	}
+
+	ms.quorums.forget(func(key notifyKey) bool {
+		_, ok := prunedHeights[key.height]
+
+		return ok
+	})
+}
+
+// roundScopedTypes are the message types that are only relevant for their round.
+// The ROUND_CHANGE messages justify the higher rounds, and the DKG messages
+// belong to the ceremony, so they are not round-scoped
+var roundScopedTypes = []proto.MessageType{
+	proto.MessageType_PREPREPARE,
+	proto.MessageType_PREPARE,
+	proto.MessageType_COMMIT,
+	proto.MessageType_VETO,
+}
+
+// PruneByRound prunes out the round-scoped messages (PREPREPARE, PREPARE, COMMIT and VETO)
+// of the height, for the rounds below the specified round. It is meant for reclaiming
+// the messages of the obsolete rounds once the node moves to a higher round,
+// instead of retaining them until the height is pruned
+func (ms *Messages) PruneByRound(height, round uint64) {
+	for _, messageType := range roundScopedTypes {
+		mux := ms.muxMap[messageType]
+		mux.Lock()
+
+		roundMessages := ms.getMessageMap(messageType)[height]
+		roundCounters := ms.counters[messageType][height]
+
+		for storedRound, messages := range roundMessages {
+			if storedRound < round {
+				ms.untrackMessages(messages)
+
+				delete(roundMessages, storedRound)
+				delete(roundCounters, storedRound)
 			}
 		}
 
 		mux.Unlock()
+
+		ms.quorums.forget(func(key notifyKey) bool {
+			return key.messageType == messageType && key.height == height && key.round < round
+		})
 	}
 }
 
+// untrackMessages drops the cached metadata of the messages removed from the store
+func (ms *Messages) untrackMessages(messages protoMessages) {
+	removed := make([]*proto.Message, 0, len(messages))
+	for _, message := range messages {
+		removed = append(removed, message.Message)
+	}
+
+	metadataCache.untrack(ms, removed...)
+}
+
+// heightStats returns the stats of the stored heights, ordered by height
+func (ms *Messages) heightStats() []HeightStats {
+	statsMap := make(map[uint64]*HeightStats)
+
+	for _, messageType := range allMessageTypes {
+		mux := ms.muxMap[messageType]
+		mux.RLock()
+
+		for height, roundMessages := range ms.getMessageMap(messageType) {
+			stats, ok := statsMap[height]
+			if !ok {
+				stats = &HeightStats{Height: height}
+				statsMap[height] = stats
+			}
+
+			for _, messages := range roundMessages {
+				stats.Messages += len(messages)
+
+				for _, message := range messages {
+					if message.received.After(stats.LastReceived) {
+						stats.LastReceived = message.received
+					}
+				}
+			}
+		}
+
+		mux.RUnlock()
+	}
+
+	heights := make([]HeightStats, 0, len(statsMap))
+	for _, stats := range statsMap {
+		heights = append(heights, *stats)
+	}
+
+	sort.Slice(heights, func(i, j int) bool {
+		return heights[i].Height < heights[j].Height
+	})
+
+	return heights
+}
+
 // getProtoMessages fetches the underlying proto messages for the specified view
 // and message type
 func (ms *Messages) getProtoMessages(
@@ -195,23 +475,122 @@ func (ms *Messages) GetValidMessages(
 	messages := ms.getProtoMessages(view, messageType)
 
 	for key, message := range messages {
-		if !isValid(message) {
+		if !isValid(message.Message) {
 			invalidMessageKeys = append(invalidMessageKeys, key)
 
 			continue
 		}
 
-		validMessages = append(validMessages, message)
+		validMessages = append(validMessages, message.Message)
 	}
 
 	// Prune out invalid messages
 	for _, key := range invalidMessageKeys {
+		metadataCache.untrack(ms, messages[key].Message)
 		delete(messages, key)
 	}
 
+	if len(invalidMessageKeys) > 0 {
+		ms.counters[messageType].getViewCounters(view).invalid += len(invalidMessageKeys)
+	}
+
 	return validMessages
 }
 
+// ScanValidMessages visits the messages of a specific type for the specified view
+// that pass the validity check, in the order of their arrival, until the visit returns false.
+// Only the scanned messages are validated, so the consumers that need only the first
+// valid message, or that count the messages until a quorum, do not validate the entire set.
+// The invalid messages scanned are pruned out, as GetValidMessages does.
+// The store is locked for the message type while scanning, so the visit
+// must not call back into the store
+func (ms *Messages) ScanValidMessages(
+	view *proto.View,
+	messageType proto.MessageType,
+	isValid func(message *proto.Message) bool,
+	visit func(message *proto.Message) bool,
+) {
+	mux := ms.muxMap[messageType]
+	mux.Lock()
+	defer mux.Unlock()
+
+	messages := ms.getProtoMessages(view, messageType)
+
+	keys := make([]string, 0, len(messages))
+	for key := range messages {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return messages[keys[i]].arrival < messages[keys[j]].arrival
+	})
+
+	invalid := 0
+
+	for _, key := range keys {
+		message := messages[key].Message
+
+		if !isValid(message) {
+			metadataCache.untrack(ms, message)
+			delete(messages, key)
+			invalid++
+
+			continue
+		}
+
+		if !visit(message) {
+			break
+		}
+	}
+
+	if invalid > 0 {
+		ms.counters[messageType].getViewCounters(view).invalid += invalid
+	}
+}
+
+// GetMessages fetches the messages of a specific type for the specified view,
+// that arrived after the since cursor, ordered by their arrival.
+// It also returns the cursor to pass in the next call, in order to fetch only newer messages.
+// A since cursor of 0 fetches all stored messages.
+// It is meant for serving peers that pull missing messages, instead of relying on gossip.
+// No messages are returned for the types that are not kept in the store
+func (ms *Messages) GetMessages(
+	view *proto.View,
+	messageType proto.MessageType,
+	since uint64,
+) ([]*proto.Message, uint64) {
+	mux, ok := ms.muxMap[messageType]
+	if !ok {
+		return nil, 0
+	}
+
+	mux.RLock()
+	defer mux.RUnlock()
+
+	// All messages of the type up until the cursor are present,
+	// as messages are added under the same lock
+	cursor := ms.arrivalSeq.Load()
+
+	stored := make([]*storedMessage, 0)
+
+	for _, message := range ms.getProtoMessages(view, messageType) {
+		if message.arrival > since {
+			stored = append(stored, message)
+		}
+	}
+
+	sort.Slice(stored, func(i, j int) bool {
+		return stored[i].arrival < stored[j].arrival
+	})
+
+	messages := make([]*proto.Message, 0, len(stored))
+	for _, message := range stored {
+		messages = append(messages, message.Message)
+	}
+
+	return messages, cursor
+}
+
 // GetExtendedRCC returns Round-Change-Certificate for the highest round
 func (ms *Messages) GetExtendedRCC(
 	height uint64,
@@ -240,11 +619,11 @@ func (ms *Messages) GetExtendedRCC(
 		}
 
 		for _, msg := range messages {
-			if !isValidMessage(msg) {
+			if !isValidMessage(msg.Message) {
 				continue
 			}
 
-			validMessages = append(validMessages, msg)
+			validMessages = append(validMessages, msg.Message)
 		}
 
 		if !isValidRCC(round, validMessages) {
@@ -293,12 +672,138 @@ func (ms *Messages) GetMostRoundChangeMessages(minRound, height uint64) []*proto
 
 	messages := make([]*proto.Message, 0, bestRoundMessagesCount)
 	for _, msg := range roundMessageMap[bestRound] {
-		messages = append(messages, msg)
+		messages = append(messages, msg.Message)
 	}
 
 	return messages
 }
 
+// NumSubscriptions returns the number of active message subscriptions
+func (ms *Messages) NumSubscriptions() int {
+	return int(atomic.LoadInt64(&ms.eventManager.numSubscriptions))
+}
+
+// DroppedEvents returns the number of events dropped or coalesced by all the subscriptions,
+// as their consumers were busy and the notification buffers were full (see DropPolicy)
+func (ms *Messages) DroppedEvents() uint64 {
+	return ms.eventManager.dropped.Load()
+}
+
+// ViewCount is the number of stored messages of each type for a single view
+type ViewCount struct {
+	// Height is the height of the view
+	Height uint64
+
+	// Round is the round of the view
+	Round uint64
+
+	// Counts is the number of stored messages, by message type
+	Counts map[proto.MessageType]int
+}
+
+// ViewCounts returns the number of stored messages for each view, ordered by the view
+func (ms *Messages) ViewCounts() []ViewCount {
+	type viewKey struct {
+		height, round uint64
+	}
+
+	counts := make(map[viewKey]map[proto.MessageType]int)
+
+	for _, messageType := range allMessageTypes {
+		mux := ms.muxMap[messageType]
+		mux.RLock()
+
+		for height, roundMessages := range ms.getMessageMap(messageType) {
+			for round, messages := range roundMessages {
+				if len(messages) == 0 {
+					continue
+				}
+
+				key := viewKey{height, round}
+				if counts[key] == nil {
+					counts[key] = make(map[proto.MessageType]int)
+				}
+
+				counts[key][messageType] = len(messages)
+			}
+		}
+
+		mux.RUnlock()
+	}
+
+	viewCounts := make([]ViewCount, 0, len(counts))
+	for key, typeCounts := range counts {
+		viewCounts = append(viewCounts, ViewCount{
+			Height: key.height,
+			Round:  key.round,
+			Counts: typeCounts,
+		})
+	}
+
+	sort.Slice(viewCounts, func(i, j int) bool {
+		if viewCounts[i].Height != viewCounts[j].Height {
+			return viewCounts[i].Height < viewCounts[j].Height
+		}
+
+		return viewCounts[i].Round < viewCounts[j].Round
+	})
+
+	return viewCounts
+}
+
+// Len returns the number of stored messages, of all types
+func (ms *Messages) Len() int {
+	total := 0
+
+	for _, messageType := range allMessageTypes {
+		mux := ms.muxMap[messageType]
+		mux.RLock()
+
+		for _, roundMessages := range ms.getMessageMap(messageType) {
+			for _, messages := range roundMessages {
+				total += len(messages)
+			}
+		}
+
+		mux.RUnlock()
+	}
+
+	return total
+}
+
+// OldestHeight returns the lowest height with stored messages of any type,
+// and false if the store is empty. An oldest height far below the current height
+// indicates the store is not pruned (see WithPrunePolicy)
+func (ms *Messages) OldestHeight() (uint64, bool) {
+	var (
+		oldest uint64
+		found  bool
+	)
+
+	for _, messageType := range allMessageTypes {
+		mux := ms.muxMap[messageType]
+		mux.RLock()
+
+		for height, roundMessages := range ms.getMessageMap(messageType) {
+			if found && height >= oldest {
+				continue
+			}
+
+			for _, messages := range roundMessages {
+				if len(messages) > 0 {
+					oldest, found = height, true
+
+					break
+				}
+			}
+		}
+
+		mux.RUnlock()
+	}
+
+	return oldest, found
+}
+
 // heightMessageMap maps the height number -> round message map
 type heightMessageMap map[uint64]roundMessageMap
 
@@ -307,7 +812,18 @@ type roundMessageMap map[uint64]protoMessages
 
 // protoMessages is the set of messages that circulate.
 // It contains a mapping between the sender and their messages to avoid duplicates
-type protoMessages map[string]*proto.Message
+type protoMessages map[string]*storedMessage
+
+// storedMessage is a message kept in the store, along with its arrival metadata
+type storedMessage struct {
+	*proto.Message
+
+	// arrival is the store-wide arrival sequence number of the message
+	arrival uint64
+
+	// received is the time the message was added to the store
+	received time.Time
+}
 
 // getViewMessages fetches the message queue for the specified view (height + round).
 // It will initialize a new message array if it's not found
@@ -1,6 +1,7 @@
 package messages
 
 import (
+	"context"
 	"strconv"
 	"testing"
 	"time"
@@ -93,6 +94,47 @@ func TestMessages_AddMessage(t *testing.T) {
 	assert.Equal(t, numMessages, messages.numMessages(initialView, proto.MessageType_ROUND_CHANGE))
 }
 
+// TestMessages_AddMessages tests if a batch of messages
+// of different types is added, in the batch order
+func TestMessages_AddMessages(t *testing.T) {
+	t.Parallel()
+
+	numMessages := 5
+	initialView := &proto.View{
+		Height: 1,
+		Round:  1,
+	}
+
+	messages := NewMessages()
+	defer messages.Close()
+
+	randomMessages := generateRandomMessages(
+		numMessages,
+		initialView,
+		proto.MessageType_PREPARE,
+		proto.MessageType_COMMIT,
+		proto.MessageType_ROUND_CHANGE,
+	)
+
+	// Messages of types that are not stored are skipped
+	messages.AddMessages(append(randomMessages, &proto.Message{
+		From: []byte("sender"),
+		View: initialView,
+		Type: proto.MessageType_CURRENT_VIEW,
+	}))
+
+	// Make sure that the messages are present
+	assert.Equal(t, numMessages, messages.numMessages(initialView, proto.MessageType_PREPARE))
+	assert.Equal(t, numMessages, messages.numMessages(initialView, proto.MessageType_COMMIT))
+	assert.Equal(t, numMessages, messages.numMessages(initialView, proto.MessageType_ROUND_CHANGE))
+
+	// Make sure the messages of a type arrived in the batch order
+	pulled, _ := messages.GetMessages(initialView, proto.MessageType_PREPARE, 0)
+	for index, message := range pulled {
+		assert.Equal(t, []byte(strconv.Itoa(index)), message.From)
+	}
+}
+
 // TestMessages_AddDuplicates tests that no duplicates
 // can be added to the height -> round -> message queue,
 // meaning a sender cannot fill the message queue with duplicate messages
@@ -127,6 +169,257 @@ func TestMessages_AddDuplicates(t *testing.T) {
 	assert.Equal(t, 1, messages.numMessages(initialView, commonType))
 }
 
+// TestMessages_GetMessages tests if messages can be pulled
+// incrementally, in their arrival order
+func TestMessages_GetMessages(t *testing.T) {
+	t.Parallel()
+
+	view := &proto.View{
+		Height: 1,
+		Round:  1,
+	}
+
+	messages := NewMessages()
+	defer messages.Close()
+
+	randomMessages := generateRandomMessages(4, view, proto.MessageType_PREPARE)
+
+	// Add the first batch of messages, and a message of another type
+	messages.AddMessage(randomMessages[0])
+	messages.AddMessage(randomMessages[1])
+	messages.AddMessage(generateRandomMessages(1, view, proto.MessageType_COMMIT)[0])
+
+	pulled, cursor := messages.GetMessages(view, proto.MessageType_PREPARE, 0)
+	assert.Equal(t, randomMessages[:2], pulled)
+
+	// Make sure no messages are returned if there are no new ones
+	pulled, nextCursor := messages.GetMessages(view, proto.MessageType_PREPARE, cursor)
+	assert.Len(t, pulled, 0)
+	assert.Equal(t, cursor, nextCursor)
+
+	// Add the second batch of messages, in reverse sender order
+	messages.AddMessage(randomMessages[3])
+	messages.AddMessage(randomMessages[2])
+
+	// Make sure only the new messages are returned, in arrival order
+	pulled, _ = messages.GetMessages(view, proto.MessageType_PREPARE, cursor)
+	assert.Equal(t, []*proto.Message{randomMessages[3], randomMessages[2]}, pulled)
+
+	// Make sure other views have no messages
+	pulled, _ = messages.GetMessages(&proto.View{Height: 1, Round: 2}, proto.MessageType_PREPARE, 0)
+	assert.Len(t, pulled, 0)
+
+	// Make sure the types that are not stored have no messages
+	for _, messageType := range []proto.MessageType{proto.MessageType_FINALITY, proto.MessageType(99)} {
+		pulled, cursor = messages.GetMessages(view, messageType, 0)
+		assert.Nil(t, pulled)
+		assert.Zero(t, cursor)
+	}
+}
+
+func TestMessages_ScanValidMessages(t *testing.T) {
+	t.Parallel()
+
+	view := &proto.View{
+		Height: 1,
+		Round:  1,
+	}
+
+	messages := NewMessages()
+	defer messages.Close()
+
+	randomMessages := generateRandomMessages(4, view, proto.MessageType_PREPARE)
+
+	// Add the messages in reverse sender order
+	for index := len(randomMessages) - 1; index >= 0; index-- {
+		messages.AddMessage(randomMessages[index])
+	}
+
+	var (
+		invalid   = randomMessages[3]
+		validated = 0
+		visited   = make([]*proto.Message, 0)
+	)
+
+	isValid := func(message *proto.Message) bool {
+		validated++
+
+		return message != invalid
+	}
+
+	// Make sure the scan stops at the first valid message, in arrival order
+	messages.ScanValidMessages(view, proto.MessageType_PREPARE, isValid, func(message *proto.Message) bool {
+		visited = append(visited, message)
+
+		return false
+	})
+
+	assert.Equal(t, []*proto.Message{randomMessages[2]}, visited)
+	assert.Equal(t, 2, validated)
+
+	// Make sure the invalid scanned message is pruned, and the remaining are visited
+	visited = visited[:0]
+
+	messages.ScanValidMessages(view, proto.MessageType_PREPARE, isValid, func(message *proto.Message) bool {
+		visited = append(visited, message)
+
+		return true
+	})
+
+	assert.Equal(t, []*proto.Message{randomMessages[2], randomMessages[1], randomMessages[0]}, visited)
+	assert.Equal(t, 3, messages.numMessages(view, proto.MessageType_PREPARE))
+}
+
+// TestMessages_ViewCounts tests if the stored messages
+// are counted correctly for each view
+func TestMessages_ViewCounts(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view1 = &proto.View{Height: 2, Round: 0}
+		view2 = &proto.View{Height: 1, Round: 3}
+	)
+
+	messages := NewMessages()
+	defer messages.Close()
+
+	for _, message := range generateRandomMessages(3, view1, proto.MessageType_PREPARE, proto.MessageType_COMMIT) {
+		messages.AddMessage(message)
+	}
+
+	for _, message := range generateRandomMessages(2, view2, proto.MessageType_ROUND_CHANGE) {
+		messages.AddMessage(message)
+	}
+
+	// Make sure the views are ordered, and the messages counted
+	assert.Equal(
+		t,
+		[]ViewCount{
+			{
+				Height: 1,
+				Round:  3,
+				Counts: map[proto.MessageType]int{
+					proto.MessageType_ROUND_CHANGE: 2,
+				},
+			},
+			{
+				Height: 2,
+				Round:  0,
+				Counts: map[proto.MessageType]int{
+					proto.MessageType_PREPARE: 3,
+					proto.MessageType_COMMIT:  3,
+				},
+			},
+		},
+		messages.ViewCounts(),
+	)
+
+	// Make sure the subscriptions are counted
+	assert.Equal(t, 0, messages.NumSubscriptions())
+
+	sub := messages.Subscribe(context.Background(), SubscriptionDetails{
+		MessageType: proto.MessageType_PREPARE,
+		View:        view1,
+		HasQuorumFn: func(_ uint64, _ []*proto.Message, _ proto.MessageType) bool {
+			return false
+		},
+	})
+
+	assert.Equal(t, 1, messages.NumSubscriptions())
+
+	messages.Unsubscribe(sub.ID)
+
+	assert.Equal(t, 0, messages.NumSubscriptions())
+}
+
+// TestMessages_Occupancy makes sure the stored messages
+// and the oldest retained height are reported
+func TestMessages_Occupancy(t *testing.T) {
+	t.Parallel()
+
+	messages := NewMessages()
+	defer messages.Close()
+
+	// Make sure the empty store is reported
+	_, found := messages.OldestHeight()
+
+	assert.False(t, found)
+	assert.Equal(t, 0, messages.Len())
+
+	messages.AddMessages(generateRandomMessages(3, &proto.View{Height: 5, Round: 0}, proto.MessageType_PREPARE))
+	messages.AddMessages(generateRandomMessages(2, &proto.View{Height: 3, Round: 1}, proto.MessageType_ROUND_CHANGE))
+	messages.AddMessages(generateRandomMessages(1, &proto.View{Height: 4, Round: 0}, proto.MessageType_COMMIT))
+
+	assert.Equal(t, 6, messages.Len())
+
+	oldest, found := messages.OldestHeight()
+
+	assert.True(t, found)
+	assert.Equal(t, uint64(3), oldest)
+
+	// Make sure the pruned heights are no longer retained
+	messages.PruneByHeight(5)
+
+	oldest, found = messages.OldestHeight()
+
+	assert.True(t, found)
+	assert.Equal(t, uint64(5), oldest)
+	assert.Equal(t, 3, messages.Len())
+}
+
+// TestMessages_SubscribeFunc tests if the callback subscriptions
+// receive the message events
+func TestMessages_SubscribeFunc(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view = &proto.View{
+			Height: 1,
+			Round:  2,
+		}
+		rounds = make(chan uint64, 2)
+
+		messages = NewMessages()
+	)
+
+	defer messages.Close()
+
+	// Make sure the subscription is signaled right away if the quorum exists
+	messages.AddMessage(generateRandomMessages(1, view, proto.MessageType_COMMIT)[0])
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+
+	messages.SubscribeFunc(ctx, SubscriptionDetails{
+		MessageType: proto.MessageType_COMMIT,
+		View:        view,
+		HasMinRound: true,
+		HasQuorumFn: func(_ uint64, msgs []*proto.Message, _ proto.MessageType) bool {
+			return len(msgs) >= 1
+		},
+	}, func(round uint64) {
+		rounds <- round
+	})
+
+	assert.Equal(t, 1, messages.NumSubscriptions())
+	assert.Equal(t, view.Round, <-rounds)
+
+	// Make sure the callback receives the events for higher rounds
+	higherView := &proto.View{
+		Height: view.Height,
+		Round:  view.Round + 1,
+	}
+
+	messages.AddMessage(generateRandomMessages(1, higherView, proto.MessageType_COMMIT)[0])
+	assert.Equal(t, higherView.Round, <-rounds)
+
+	// Make sure the subscription is removed with the context
+	cancelFn()
+
+	assert.Eventually(t, func() bool {
+		return messages.NumSubscriptions() == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
 // TestMessages_Prune tests if pruning of certain messages works
 func TestMessages_Prune(t *testing.T) {
 	t.Parallel()
@@ -177,6 +470,67 @@ func TestMessages_Prune(t *testing.T) {
 	assert.Equal(t, 0, messages.numMessages(views[2], messageType))
 }
 
+// TestMessages_PruneByRound makes sure only the round-scoped
+// messages of the lower rounds are pruned out
+func TestMessages_PruneByRound(t *testing.T) {
+	t.Parallel()
+
+	numMessages := 5
+	messages := NewMessages()
+
+	t.Cleanup(func() {
+		messages.Close()
+	})
+
+	views := make([]*proto.View, 0)
+	for index := uint64(0); index <= 2; index++ {
+		views = append(views, &proto.View{
+			Height: 1,
+			Round:  index,
+		})
+	}
+
+	otherHeight := &proto.View{
+		Height: 2,
+		Round:  0,
+	}
+
+	messageTypes := []proto.MessageType{
+		proto.MessageType_PREPARE,
+		proto.MessageType_COMMIT,
+		proto.MessageType_ROUND_CHANGE,
+	}
+
+	for _, messageType := range messageTypes {
+		for _, view := range append(views, otherHeight) {
+			for _, message := range generateRandomMessages(numMessages, view, messageType) {
+				messages.AddMessage(message)
+			}
+		}
+	}
+
+	// Prune out the messages below round 2
+	messages.PruneByRound(1, 2)
+
+	for _, messageType := range []proto.MessageType{
+		proto.MessageType_PREPARE,
+		proto.MessageType_COMMIT,
+	} {
+		// Make sure the lower round messages are pruned out
+		assert.Equal(t, 0, messages.numMessages(views[0], messageType))
+		assert.Equal(t, 0, messages.numMessages(views[1], messageType))
+
+		// Make sure the current round and other height messages are kept
+		assert.Equal(t, numMessages, messages.numMessages(views[2], messageType))
+		assert.Equal(t, numMessages, messages.numMessages(otherHeight, messageType))
+	}
+
+	// Make sure the round changes are kept
+	for _, view := range views {
+		assert.Equal(t, numMessages, messages.numMessages(view, proto.MessageType_ROUND_CHANGE))
+	}
+}
+
 // TestMessages_GetMessage makes sure
 // that messages are fetched correctly for the
 // corresponding message type
@@ -388,7 +742,7 @@ func TestMessages_EventManager(t *testing.T) {
 	}
 
 	// Create the subscription
-	subscription := messages.Subscribe(SubscriptionDetails{
+	subscription := messages.Subscribe(context.Background(), SubscriptionDetails{
 		MessageType: messageType,
 		View:        baseView,
 		HasQuorumFn: func(_ uint64, messages []*proto.Message, _ proto.MessageType) bool {
@@ -402,7 +756,6 @@ func TestMessages_EventManager(t *testing.T) {
 	randomMessages := generateRandomMessages(numMessages, baseView, messageType)
 	for _, message := range randomMessages {
 		messages.AddMessage(message)
-		messages.SignalEvent(message)
 	}
 
 	// Wait for the subscription event to happen
@@ -0,0 +1,227 @@
+package messages
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// metadataCacheCapacity is the maximum number of messages with cached metadata.
+// Once it is reached, the metadata of the oldest cached message is dropped
+const metadataCacheCapacity = 1 << 16
+
+// messageMetadata is the data derived from a message, computed once
+// and cached for as long as the message is kept in a store
+type messageMetadata struct {
+	// height is the height of the message, used for pruning
+	height uint64
+
+	// payloadOnce guards the decoding of the lazily decoded payload
+	payloadOnce sync.Once
+
+	// decodedPayload is the message decoded from the lazily decoded payload
+	// fields, or nil if the payload is malformed
+	decodedPayload *proto.Message
+
+	// digestOnce guards the computation of the digest
+	digestOnce sync.Once
+
+	// digest is the message digest, or nil if the message cannot be encoded
+	digest    *Digest
+	digestErr error
+}
+
+// metadataEntry is the cached metadata of a message,
+// along with the stores keeping the message
+type metadataEntry struct {
+	metadata *messageMetadata
+
+	// owners are the stores keeping the message
+	owners map[*Messages]struct{}
+
+	// element is the position of the message in the eviction order
+	element *list.Element
+}
+
+// messageMetadataCache caches the metadata of the messages kept in the stores, by message.
+// Only the messages added to a store are cached, so the messages rejected on ingress
+// (or never stored) do not grow the cache. The metadata is dropped once all the stores
+// keeping the message remove it, or once the cache capacity is reached.
+// Messages are never modified after they are received, so the metadata
+// stays valid for as long as the message is referenced
+type messageMetadataCache struct {
+	sync.Mutex
+
+	capacity int
+
+	metadata map[*proto.Message]*metadataEntry
+
+	// owned are the cached messages kept by each store
+	owned map[*Messages]map[*proto.Message]struct{}
+
+	// order are the cached messages, from the oldest to the newest
+	order *list.List
+}
+
+var metadataCache = newMessageMetadataCache(metadataCacheCapacity)
+
+// newMessageMetadataCache creates a new metadata cache with the specified capacity
+func newMessageMetadataCache(capacity int) *messageMetadataCache {
+	return &messageMetadataCache{
+		capacity: capacity,
+		metadata: make(map[*proto.Message]*metadataEntry),
+		owned:    make(map[*Messages]map[*proto.Message]struct{}),
+		order:    list.New(),
+	}
+}
+
+// lookup returns the cached metadata of the message. The metadata of a message
+// that is not kept in any store is not cached, so it is created on each lookup
+func (c *messageMetadataCache) lookup(message *proto.Message) *messageMetadata {
+	c.Lock()
+	defer c.Unlock()
+
+	if entry, ok := c.metadata[message]; ok {
+		return entry.metadata
+	}
+
+	return newMessageMetadata(message)
+}
+
+// track caches the metadata of the message kept by the store.
+// The messages of a closed store are not cached
+func (c *messageMetadataCache) track(owner *Messages, message *proto.Message) {
+	c.Lock()
+	defer c.Unlock()
+
+	if owner.isClosed() {
+		return
+	}
+
+	entry, ok := c.metadata[message]
+	if !ok {
+		if c.capacity <= 0 {
+			return
+		}
+
+		if len(c.metadata) >= c.capacity {
+			c.evict(c.order.Front().Value.(*proto.Message))
+		}
+
+		entry = &metadataEntry{
+			metadata: newMessageMetadata(message),
+			owners:   make(map[*Messages]struct{}, 1),
+			element:  c.order.PushBack(message),
+		}
+		c.metadata[message] = entry
+	}
+
+	entry.owners[owner] = struct{}{}
+
+	owned, ok := c.owned[owner]
+	if !ok {
+		owned = make(map[*proto.Message]struct{})
+		c.owned[owner] = owned
+	}
+
+	owned[message] = struct{}{}
+}
+
+// evict drops the metadata of the message, for all the stores keeping it.
+// The cache lock must be held
+func (c *messageMetadataCache) evict(message *proto.Message) {
+	entry, ok := c.metadata[message]
+	if !ok {
+		return
+	}
+
+	for owner := range entry.owners {
+		c.disown(owner, message)
+	}
+
+	c.order.Remove(entry.element)
+	delete(c.metadata, message)
+}
+
+// disown drops the message from the messages kept by the store.
+// The cache lock must be held
+func (c *messageMetadataCache) disown(owner *Messages, message *proto.Message) {
+	owned := c.owned[owner]
+
+	delete(owned, message)
+
+	if len(owned) == 0 {
+		delete(c.owned, owner)
+	}
+}
+
+// untrack drops the store from the owners of the messages, once they are removed from the store.
+// The metadata of the messages no longer kept by any store is dropped
+func (c *messageMetadataCache) untrack(owner *Messages, messages ...*proto.Message) {
+	c.Lock()
+	defer c.Unlock()
+
+	for _, message := range messages {
+		c.releaseMessage(owner, message)
+	}
+}
+
+// release drops the store from the owners of its messages matching the filter.
+// The metadata of the messages no longer kept by any store is dropped
+func (c *messageMetadataCache) release(owner *Messages, matches func(*messageMetadata) bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	for message := range c.owned[owner] {
+		if matches(c.metadata[message].metadata) {
+			c.releaseMessage(owner, message)
+		}
+	}
+}
+
+// releaseMessage drops the store from the owners of the message.
+// The cache lock must be held
+func (c *messageMetadataCache) releaseMessage(owner *Messages, message *proto.Message) {
+	entry, ok := c.metadata[message]
+	if !ok {
+		return
+	}
+
+	if _, owned := entry.owners[owner]; !owned {
+		return
+	}
+
+	delete(entry.owners, owner)
+	c.disown(owner, message)
+
+	if len(entry.owners) == 0 {
+		c.order.Remove(entry.element)
+		delete(c.metadata, message)
+	}
+}
+
+// prune drops the metadata of the store messages below the specified height.
+// The metadata of the retained past heights is recomputed on access
+func (c *messageMetadataCache) prune(owner *Messages, height uint64) {
+	c.release(owner, func(metadata *messageMetadata) bool {
+		return metadata.height < height
+	})
+}
+
+// cached checks if the metadata of the message is cached
+func (c *messageMetadataCache) cached(message *proto.Message) bool {
+	c.Lock()
+	defer c.Unlock()
+
+	_, ok := c.metadata[message]
+
+	return ok
+}
+
+// newMessageMetadata creates the empty metadata of the message
+func newMessageMetadata(message *proto.Message) *messageMetadata {
+	return &messageMetadata{
+		height: message.GetView().GetHeight(),
+	}
+}
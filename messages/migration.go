@@ -0,0 +1,156 @@
+package messages
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+var (
+	// ErrUnknownSchemaVersion is an error indicating the schema version
+	// is not known to this release
+	ErrUnknownSchemaVersion = errors.New("unknown schema version")
+
+	// ErrMissingMigration is an error indicating there is no migration
+	// registered for upgrading from a schema version
+	ErrMissingMigration = errors.New("missing schema migration")
+)
+
+// SchemaVersion is the version of the message schema
+// used to encode persisted messages
+type SchemaVersion uint32
+
+const (
+	// SchemaV1 is the schema of the initial release
+	SchemaV1 SchemaVersion = iota + 1
+
+	// SchemaV2 adds the proposal timestamp to PREPREPARE messages
+	SchemaV2
+
+	// SchemaV3 adds the unsigned relay metadata (TTL and hop count) to messages
+	SchemaV3
+
+	// SchemaV4 adds the deduplicated certificates, the explicit NIL proposal flag,
+	// and the PROPOSER_UNAVAILABLE message type
+	SchemaV4
+
+	// SchemaV5 adds the DKG message type, for distributed key generation ceremonies
+	SchemaV5
+
+	// SchemaV6 adds the CURRENT_VIEW message type, for gossiping the view of the node
+	SchemaV6
+
+	// SchemaV7 adds the FINALITY message type, for serving the finality proofs
+	// to the nodes lagging behind
+	SchemaV7
+
+	// SchemaV8 adds the validator index references, replacing the sender IDs on the wire
+	SchemaV8
+
+	// SchemaV9 adds the VETO message type, for rejecting invalid proposals explicitly
+	SchemaV9
+
+	// SchemaV10 adds the randomness beacon contributions to COMMIT and FINALITY messages
+	SchemaV10
+
+	// CurrentSchemaVersion is the schema version of this release
+	CurrentSchemaVersion = SchemaV10
+)
+
+// Migration upgrades the encoded message from
+// a schema version to the next one
+type Migration func(data []byte) ([]byte, error)
+
+// identityMigration is the migration for schema changes
+// that only add new fields, which are left unset
+func identityMigration(data []byte) ([]byte, error) {
+	return data, nil
+}
+
+// Migrator upgrades persisted messages (journals, WALs, message stores)
+// from older schema versions to the current one
+type Migrator struct {
+	// migrations are the registered migrations, by source version
+	migrations map[SchemaVersion]Migration
+}
+
+// NewMigrator creates a new migrator with the migrations
+// for the default proto encoding registered
+func NewMigrator() *Migrator {
+	return &Migrator{
+		migrations: map[SchemaVersion]Migration{
+			SchemaV1: identityMigration,
+			SchemaV2: identityMigration,
+			SchemaV3: identityMigration,
+			SchemaV4: identityMigration,
+			SchemaV5: identityMigration,
+			SchemaV6: identityMigration,
+			SchemaV7: identityMigration,
+			SchemaV8: identityMigration,
+			SchemaV9: identityMigration,
+		},
+	}
+}
+
+// Register sets the migration from the specified schema version to the next one,
+// replacing any previously registered migration. Chains using a custom Codec
+// register migrations for their own encoding
+func (m *Migrator) Register(from SchemaVersion, migration Migration) {
+	m.migrations[from] = migration
+}
+
+// Upgrade applies the migrations in order, converting the data
+// encoded using the specified schema version to the current schema version
+func (m *Migrator) Upgrade(data []byte, from SchemaVersion) ([]byte, error) {
+	if from == 0 || from > CurrentSchemaVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownSchemaVersion, from)
+	}
+
+	for version := from; version < CurrentSchemaVersion; version++ {
+		migration, ok := m.migrations[version]
+		if !ok {
+			return nil, fmt.Errorf("%w: from version %d", ErrMissingMigration, version)
+		}
+
+		upgraded, err := migration(data)
+		if err != nil {
+			return nil, fmt.Errorf("unable to migrate from version %d: %w", version, err)
+		}
+
+		data = upgraded
+	}
+
+	return data, nil
+}
+
+// migratingUnmarshaler upgrades the data before decoding it
+type migratingUnmarshaler struct {
+	unmarshaler Unmarshaler
+	migrator    *Migrator
+	from        SchemaVersion
+}
+
+// NewMigratingUnmarshaler creates an unmarshaler that decodes data encoded
+// using the specified (older) schema version, by upgrading it before decoding
+func NewMigratingUnmarshaler(
+	unmarshaler Unmarshaler,
+	migrator *Migrator,
+	from SchemaVersion,
+) Unmarshaler {
+	return &migratingUnmarshaler{
+		unmarshaler: unmarshaler,
+		migrator:    migrator,
+		from:        from,
+	}
+}
+
+// Unmarshal upgrades the data to the current schema version, and decodes it
+func (u *migratingUnmarshaler) Unmarshal(data []byte, message *proto.Message) error {
+	upgraded, err := u.migrator.Upgrade(data, u.from)
+	if err != nil {
+		return err
+	}
+
+	return u.unmarshaler.Unmarshal(upgraded, message)
+}
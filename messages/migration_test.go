@@ -0,0 +1,160 @@
+package messages
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	protoBuf "google.golang.org/protobuf/proto"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+func TestMigrator_Upgrade(t *testing.T) {
+	t.Parallel()
+
+	migrator := NewMigrator()
+
+	for name, expected := range goldenMessages() {
+		name := name
+		expected := expected
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			upgraded, err := migrator.Upgrade(readGoldenFile(t, name), SchemaV1)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			// Make sure the upgraded message decodes using the current schema
+			decoded := &proto.Message{}
+
+			assert.NoError(t, protoBuf.Unmarshal(upgraded, decoded))
+			assert.True(t, protoBuf.Equal(expected, decoded))
+		})
+	}
+}
+
+func TestMigrator_UpgradeOrder(t *testing.T) {
+	t.Parallel()
+
+	migrator := NewMigrator()
+
+	for version := SchemaV1; version < CurrentSchemaVersion; version++ {
+		version := version
+
+		migrator.Register(version, func(data []byte) ([]byte, error) {
+			return append(data, byte(version)), nil
+		})
+	}
+
+	// Make sure all migrations are applied in order
+	upgraded, err := migrator.Upgrade([]byte{0}, SchemaV1)
+
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		[]byte{
+			0, byte(SchemaV1), byte(SchemaV2), byte(SchemaV3), byte(SchemaV4),
+			byte(SchemaV5), byte(SchemaV6), byte(SchemaV7), byte(SchemaV8), byte(SchemaV9),
+		},
+		upgraded,
+	)
+
+	// Make sure only the migrations after the source version are applied
+	upgraded, err = migrator.Upgrade([]byte{0}, SchemaV2)
+
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		[]byte{
+			0, byte(SchemaV2), byte(SchemaV3), byte(SchemaV4), byte(SchemaV5),
+			byte(SchemaV6), byte(SchemaV7), byte(SchemaV8), byte(SchemaV9),
+		},
+		upgraded,
+	)
+
+	// Make sure current data is not migrated
+	upgraded, err = migrator.Upgrade([]byte{0}, CurrentSchemaVersion)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0}, upgraded)
+}
+
+func TestMigrator_UpgradeErrors(t *testing.T) {
+	t.Parallel()
+
+	errMigration := errors.New("migration error")
+
+	testTable := []struct {
+		name        string
+		migrator    *Migrator
+		from        SchemaVersion
+		expectedErr error
+	}{
+		{
+			"unset version",
+			NewMigrator(),
+			0,
+			ErrUnknownSchemaVersion,
+		},
+		{
+			"future version",
+			NewMigrator(),
+			CurrentSchemaVersion + 1,
+			ErrUnknownSchemaVersion,
+		},
+		{
+			"missing migration",
+			&Migrator{migrations: map[SchemaVersion]Migration{}},
+			SchemaV1,
+			ErrMissingMigration,
+		},
+		{
+			"failed migration",
+			&Migrator{
+				migrations: map[SchemaVersion]Migration{
+					SchemaV1: func(_ []byte) ([]byte, error) {
+						return nil, errMigration
+					},
+				},
+			},
+			SchemaV1,
+			errMigration,
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			upgraded, err := testCase.migrator.Upgrade([]byte{0}, testCase.from)
+
+			assert.Nil(t, upgraded)
+			assert.ErrorIs(t, err, testCase.expectedErr)
+		})
+	}
+}
+
+func TestMigratingUnmarshaler(t *testing.T) {
+	t.Parallel()
+
+	var (
+		expected    = goldenMessages()["commit.bin"]
+		unmarshaler = NewMigratingUnmarshaler(ProtoCodec{}, NewMigrator(), SchemaV1)
+		decoded     = &proto.Message{}
+	)
+
+	assert.NoError(t, unmarshaler.Unmarshal(readGoldenFile(t, "commit.bin"), decoded))
+	assert.True(t, protoBuf.Equal(expected, decoded))
+
+	// Make sure migration errors are propagated
+	assert.ErrorIs(
+		t,
+		NewMigratingUnmarshaler(ProtoCodec{}, NewMigrator(), 0).Unmarshal(nil, decoded),
+		ErrUnknownSchemaVersion,
+	)
+}
@@ -0,0 +1,127 @@
+package messages
+
+import (
+	"sync"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// QuorumFn checks if the stored messages of the type reached quorum for the view
+type QuorumFn func(view *proto.View, messages []*proto.Message, messageType proto.MessageType) bool
+
+// WithQuorumFn sets the function the store uses to decide when the subscriptions fire.
+// Once messages are added, the subscriptions of their view and type are signaled
+// only if the stored messages reached quorum. Without the quorum function,
+// every added message signals the subscriptions of its view and type
+func WithQuorumFn(fn QuorumFn) Option {
+	return func(ms *Messages) {
+		ms.quorumFn = fn
+	}
+}
+
+// notifyKey identifies the messages of a type for a view
+type notifyKey struct {
+	height      uint64
+	round       uint64
+	messageType proto.MessageType
+}
+
+// newNotifyKey returns the key of the messages of the type for the view
+func newNotifyKey(view *proto.View, messageType proto.MessageType) notifyKey {
+	return notifyKey{
+		height:      view.Height,
+		round:       view.Round,
+		messageType: messageType,
+	}
+}
+
+// quorumSet is the set of views and message types that reached quorum.
+// Adding messages never breaks a quorum, so once a quorum is reached
+// new messages for the view signal the subscriptions without scanning the store
+type quorumSet struct {
+	sync.RWMutex
+
+	reached map[notifyKey]struct{}
+}
+
+// newQuorumSet creates a new empty quorum set
+func newQuorumSet() *quorumSet {
+	return &quorumSet{
+		reached: make(map[notifyKey]struct{}),
+	}
+}
+
+// has checks if the key reached quorum
+func (s *quorumSet) has(key notifyKey) bool {
+	s.RLock()
+	defer s.RUnlock()
+
+	_, ok := s.reached[key]
+
+	return ok
+}
+
+// add marks the key as reaching quorum
+func (s *quorumSet) add(key notifyKey) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.reached[key] = struct{}{}
+}
+
+// remove removes the key, once its messages are pruned from the store
+func (s *quorumSet) remove(key notifyKey) {
+	s.Lock()
+	defer s.Unlock()
+
+	delete(s.reached, key)
+}
+
+// forget removes the keys matching the filter,
+// once their messages are pruned from the store
+func (s *quorumSet) forget(matches func(key notifyKey) bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	for key := range s.reached {
+		if matches(key) {
+			delete(s.reached, key)
+		}
+	}
+}
+
+// notify signals the subscriptions of the view and type after messages were added,
+// if the stored messages reached quorum (see WithQuorumFn).
+// The lock of the message type must not be held
+func (ms *Messages) notify(view *proto.View, messageType proto.MessageType) {
+	if ms.quorumFn != nil {
+		key := newNotifyKey(view, messageType)
+
+		if !ms.quorums.has(key) {
+			msgs := ms.GetValidMessages(view, messageType, func(_ *proto.Message) bool { return true })
+			if !ms.quorumFn(view, msgs, messageType) {
+				return
+			}
+
+			ms.quorums.add(key)
+		}
+	}
+
+	ms.eventManager.signalEvent(messageType, view)
+}
+
+// notifyBatch signals the subscriptions once for each view and type of the added batch
+func (ms *Messages) notifyBatch(messages []*proto.Message) {
+	notified := make(map[notifyKey]struct{})
+
+	for _, message := range messages {
+		key := newNotifyKey(message.View, message.Type)
+		if _, ok := notified[key]; ok {
+			continue
+		}
+
+		notified[key] = struct{}{}
+
+		ms.notify(message.View, message.Type)
+	}
+}
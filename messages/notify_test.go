@@ -0,0 +1,114 @@
+package messages
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// TestMessages_QuorumFn makes sure the store signals the subscriptions
+// only once the quorum is reached, and checks the quorum until it is reached
+func TestMessages_QuorumFn(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view        = &proto.View{Height: 1, Round: 0}
+		messageType = proto.MessageType_COMMIT
+
+		quorumChecks int
+	)
+
+	messages := NewMessages(WithQuorumFn(func(_ *proto.View, msgs []*proto.Message, _ proto.MessageType) bool {
+		quorumChecks++
+
+		return len(msgs) >= 2
+	}))
+
+	t.Cleanup(func() {
+		messages.Close()
+	})
+
+	subscription := messages.Subscribe(context.Background(), SubscriptionDetails{
+		MessageType: messageType,
+		View:        view,
+		HasQuorumFn: func(_ uint64, _ []*proto.Message, _ proto.MessageType) bool {
+			return false
+		},
+		BufferSize: 4,
+	})
+
+	signals := func() int {
+		count := 0
+
+		for {
+			select {
+			case <-subscription.SubCh:
+				count++
+			case <-time.After(50 * time.Millisecond):
+				return count
+			}
+		}
+	}
+
+	commits := generateRandomMessages(4, view, messageType)
+
+	// Make sure the subscription is not signaled before the quorum
+	messages.AddMessage(commits[0])
+
+	assert.Equal(t, 0, signals())
+	assert.Equal(t, 1, quorumChecks)
+
+	// Make sure the subscription is signaled once the quorum is reached
+	messages.AddMessage(commits[1])
+
+	assert.Equal(t, 1, signals())
+	assert.Equal(t, 2, quorumChecks)
+
+	// Make sure the reached quorum signals without checking again
+	messages.AddMessage(commits[2])
+
+	assert.Equal(t, 1, signals())
+	assert.Equal(t, 2, quorumChecks)
+
+	// Make sure the pruned views are checked again
+	messages.PruneByRound(view.Height, view.Round+1)
+	messages.AddMessage(commits[3])
+
+	assert.Equal(t, 0, signals())
+	assert.Equal(t, 3, quorumChecks)
+}
+
+// TestMessages_QuorumFn_Batch makes sure the quorum is checked
+// once for each view and message type of the batch
+func TestMessages_QuorumFn_Batch(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view       = &proto.View{Height: 1, Round: 0}
+		futureView = &proto.View{Height: 1, Round: 1}
+
+		checked []uint64
+	)
+
+	messages := NewMessages(WithQuorumFn(func(view *proto.View, msgs []*proto.Message, _ proto.MessageType) bool {
+		checked = append(checked, view.Round)
+
+		return len(msgs) >= 3
+	}))
+
+	t.Cleanup(func() {
+		messages.Close()
+	})
+
+	batch := generateRandomMessages(3, view, proto.MessageType_PREPARE)
+	batch = append(batch, generateRandomMessages(1, futureView, proto.MessageType_PREPARE)...)
+
+	messages.AddMessages(batch)
+
+	// Make sure the quorum is checked once per view, after the whole batch is stored
+	assert.Equal(t, []uint64{view.Round, futureView.Round}, checked)
+}
@@ -0,0 +1,92 @@
+package messages
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+var (
+	// ErrNilMessage is an error indicating the message is not set
+	ErrNilMessage = errors.New("message is nil")
+
+	// ErrPayloadTypeMismatch is an error indicating the requested
+	// payload does not belong to the message type
+	ErrPayloadTypeMismatch = errors.New("payload does not match the message type")
+
+	// ErrMissingPayload is an error indicating the message payload is not set,
+	// or the payload oneof does not match the message type
+	ErrMissingPayload = errors.New("message payload is missing")
+)
+
+// Payload is the constraint for the message payload types
+type Payload interface {
+	*proto.PrePrepareMessage |
+		*proto.PrepareMessage |
+		*proto.CommitMessage |
+		*proto.RoundChangeMessage |
+		*proto.DKGMessage |
+		*proto.FinalityMessage |
+		*proto.VetoMessage
+}
+
+// ExtractPayload extracts the payload of the specified type from the message.
+// It returns an error if the payload type does not belong to the message type,
+// or if the payload oneof of the message does not match its type.
+// Lazily decoded payloads (see LazyCodec) are decoded on first access, and cached
+func ExtractPayload[T Payload](message *proto.Message) (T, error) {
+	var (
+		payload T
+
+		expectedType proto.MessageType
+		data         interface{}
+		present      bool
+	)
+
+	if message == nil {
+		return payload, ErrNilMessage
+	}
+
+	// The payload of a lazily decoded message is decoded on first access
+	source := message
+	if decoded := decodedPayload(message); decoded != nil {
+		source = decoded
+	}
+
+	switch any(payload).(type) {
+	case *proto.PrePrepareMessage:
+		preprepareData := source.GetPreprepareData()
+		expectedType, data, present = proto.MessageType_PREPREPARE, preprepareData, preprepareData != nil
+	case *proto.PrepareMessage:
+		prepareData := source.GetPrepareData()
+		expectedType, data, present = proto.MessageType_PREPARE, prepareData, prepareData != nil
+	case *proto.CommitMessage:
+		commitData := source.GetCommitData()
+		expectedType, data, present = proto.MessageType_COMMIT, commitData, commitData != nil
+	case *proto.RoundChangeMessage:
+		roundChangeData := source.GetRoundChangeData()
+		expectedType, data, present = proto.MessageType_ROUND_CHANGE, roundChangeData, roundChangeData != nil
+	case *proto.DKGMessage:
+		dkgData := source.GetDkgData()
+		expectedType, data, present = proto.MessageType_DKG, dkgData, dkgData != nil
+	case *proto.FinalityMessage:
+		finalityData := source.GetFinalityData()
+		expectedType, data, present = proto.MessageType_FINALITY, finalityData, finalityData != nil
+	case *proto.VetoMessage:
+		vetoData := source.GetVetoData()
+		expectedType, data, present = proto.MessageType_VETO, vetoData, vetoData != nil
+	}
+
+	if message.Type != expectedType {
+		return payload, fmt.Errorf("%w: %s payload requested from a %s message", ErrPayloadTypeMismatch, expectedType, message.Type)
+	}
+
+	if !present {
+		return payload, fmt.Errorf("%w: %s message", ErrMissingPayload, message.Type)
+	}
+
+	payload, _ = data.(T)
+
+	return payload, nil
+}
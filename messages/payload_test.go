@@ -0,0 +1,126 @@
+package messages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+func TestMessages_ExtractPayload(t *testing.T) {
+	t.Parallel()
+
+	t.Run("payload matches the message type", func(t *testing.T) {
+		t.Parallel()
+
+		commitData := &proto.CommitMessage{
+			ProposalHash:  []byte("proposal hash"),
+			CommittedSeal: []byte("committed seal"),
+		}
+
+		payload, err := ExtractPayload[*proto.CommitMessage](&proto.Message{
+			Type: proto.MessageType_COMMIT,
+			Payload: &proto.Message_CommitData{
+				CommitData: commitData,
+			},
+		})
+
+		require.NoError(t, err)
+		assert.Same(t, commitData, payload)
+	})
+
+	testTable := []struct {
+		name        string
+		message     *proto.Message
+		expectedErr error
+	}{
+		{
+			"nil message",
+			nil,
+			ErrNilMessage,
+		},
+		{
+			"payload of another message type requested",
+			&proto.Message{
+				Type: proto.MessageType_PREPARE,
+				Payload: &proto.Message_PrepareData{
+					PrepareData: &proto.PrepareMessage{},
+				},
+			},
+			ErrPayloadTypeMismatch,
+		},
+		{
+			"payload oneof does not match the message type",
+			&proto.Message{
+				Type: proto.MessageType_COMMIT,
+				Payload: &proto.Message_PrepareData{
+					PrepareData: &proto.PrepareMessage{},
+				},
+			},
+			ErrMissingPayload,
+		},
+		{
+			"payload not set",
+			&proto.Message{
+				Type: proto.MessageType_COMMIT,
+			},
+			ErrMissingPayload,
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			payload, err := ExtractPayload[*proto.CommitMessage](testCase.message)
+
+			assert.ErrorIs(t, err, testCase.expectedErr)
+			assert.Nil(t, payload)
+		})
+	}
+}
+
+func TestMessages_ExtractHelpers_MalformedPayload(t *testing.T) {
+	t.Parallel()
+
+	// The message types do not match the payload oneofs
+	var (
+		preprepare = &proto.Message{
+			Type: proto.MessageType_PREPREPARE,
+			Payload: &proto.Message_CommitData{
+				CommitData: &proto.CommitMessage{},
+			},
+		}
+		prepare = &proto.Message{
+			Type: proto.MessageType_PREPARE,
+		}
+		commit = &proto.Message{
+			Type: proto.MessageType_COMMIT,
+			Payload: &proto.Message_PreprepareData{
+				PreprepareData: &proto.PrePrepareMessage{},
+			},
+		}
+		roundChange = &proto.Message{
+			Type: proto.MessageType_ROUND_CHANGE,
+		}
+	)
+
+	// Make sure the helpers do not panic on malformed messages
+	assert.Nil(t, ExtractProposal(preprepare))
+	assert.Nil(t, ExtractProposalHash(preprepare))
+	assert.Nil(t, ExtractRoundChangeCertificate(preprepare))
+	assert.True(t, ExtractProposalTimestamp(preprepare).IsZero())
+	assert.Nil(t, ExtractPrepareHash(prepare))
+	assert.Nil(t, ExtractCommitHash(commit))
+	assert.Nil(t, ExtractCommittedSeal(commit))
+	assert.Nil(t, ExtractLatestPC(roundChange))
+	assert.Nil(t, ExtractLastPreparedProposal(roundChange))
+	assert.False(t, HaveSameProposalHash([]*proto.Message{prepare, prepare}))
+
+	_, err := ExtractCommittedSeals([]*proto.Message{commit})
+	assert.ErrorIs(t, err, ErrMissingPayload)
+}
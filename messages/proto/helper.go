@@ -1,14 +1,91 @@
 // Package proto defines the code for protocol buffer
 package proto
 
-import "google.golang.org/protobuf/proto"
+import (
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
 
-// PayloadNoSig returns marshaled message without signature
+// canonicalOptions are the marshaling options of the canonical encoding
+var canonicalOptions = proto.MarshalOptions{Deterministic: true}
+
+// MarshalCanonical returns the canonical encoding of the message, used for the payloads
+// that are hashed and signed. The encoding is deterministic (the map entries, if any,
+// are sorted), and the known fields kept encoded in the unknown fields (ex. the payloads
+// deferred by the lazy decoding) are decoded first, so the lazily decoded messages
+// encode as the decoded ones. The fields unknown to the node (ex. added by a later
+// schema version) are kept, so they are covered by the signature.
+// The deterministic marshaling is only stable for the same library version,
+// it is not a canonical encoding across versions or implementations
+func MarshalCanonical(m proto.Message) ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+
+	cloned := proto.Clone(m)
+	if err := canonicalize(cloned.ProtoReflect()); err != nil {
+		return nil, err
+	}
+
+	return canonicalOptions.Marshal(cloned)
+}
+
+// canonicalize prepares the message, and its nested messages, for the canonical encoding.
+// The known fields kept encoded in the unknown fields (ex. the payloads deferred by
+// the lazy decoding) are decoded into their fields, and the other unknown fields are kept
+func canonicalize(m protoreflect.Message) error {
+	if unknown := m.GetUnknown(); len(unknown) != 0 {
+		m.SetUnknown(nil)
+
+		options := proto.UnmarshalOptions{Merge: true}
+		if err := options.Unmarshal(unknown, m.Interface()); err != nil {
+			return err
+		}
+	}
+
+	var err error
+
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.IsList() && fd.Message() != nil:
+			list := v.List()
+			for index := 0; index < list.Len() && err == nil; index++ {
+				err = canonicalize(list.Get(index).Message())
+			}
+		case fd.IsMap():
+			if fd.MapValue().Message() == nil {
+				break
+			}
+
+			v.Map().Range(func(_ protoreflect.MapKey, value protoreflect.Value) bool {
+				err = canonicalize(value.Message())
+
+				return err == nil
+			})
+		case fd.Message() != nil:
+			err = canonicalize(v.Message())
+		}
+
+		return err == nil
+	})
+
+	return err
+}
+
+// PayloadNoSig returns the canonical encoding (see MarshalCanonical) of the message
+// without signature. The relay metadata (TTL and hop count) is excluded as well,
+// as it is modified by the relaying peers
 func (m *Message) PayloadNoSig() ([]byte, error) {
 	mm, _ := proto.Clone(m).(*Message)
 	mm.Signature = nil
+	mm.Ttl = 0
+	mm.Hops = 0
+
+	if err := canonicalize(mm.ProtoReflect()); err != nil {
+		return nil, err
+	}
 
-	raw, err := proto.Marshal(mm)
+	raw, err := canonicalOptions.Marshal(mm)
 	if err != nil {
 		return nil, err
 	}
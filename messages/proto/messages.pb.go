@@ -30,10 +30,15 @@ const _ = proto.ProtoPackageIsVersion4
 type MessageType int32
 
 const (
-	MessageType_PREPREPARE   MessageType = 0
-	MessageType_PREPARE      MessageType = 1
-	MessageType_COMMIT       MessageType = 2
-	MessageType_ROUND_CHANGE MessageType = 3
+	MessageType_PREPREPARE           MessageType = 0
+	MessageType_PREPARE              MessageType = 1
+	MessageType_COMMIT               MessageType = 2
+	MessageType_ROUND_CHANGE         MessageType = 3
+	MessageType_PROPOSER_UNAVAILABLE MessageType = 4
+	MessageType_DKG                  MessageType = 5
+	MessageType_CURRENT_VIEW         MessageType = 6
+	MessageType_FINALITY             MessageType = 7
+	MessageType_VETO                 MessageType = 8
 )
 
 // Enum value maps for MessageType.
@@ -43,12 +48,22 @@ var (
 		1: "PREPARE",
 		2: "COMMIT",
 		3: "ROUND_CHANGE",
+		4: "PROPOSER_UNAVAILABLE",
+		5: "DKG",
+		6: "CURRENT_VIEW",
+		7: "FINALITY",
+		8: "VETO",
 	}
 	MessageType_value = map[string]int32{
-		"PREPREPARE":   0,
-		"PREPARE":      1,
-		"COMMIT":       2,
-		"ROUND_CHANGE": 3,
+		"PREPREPARE":           0,
+		"PREPARE":              1,
+		"COMMIT":               2,
+		"ROUND_CHANGE":         3,
+		"PROPOSER_UNAVAILABLE": 4,
+		"DKG":                  5,
+		"CURRENT_VIEW":         6,
+		"FINALITY":             7,
+		"VETO":                 8,
 	}
 )
 
@@ -158,7 +173,20 @@ type Message struct {
 	//	*Message_PrepareData
 	//	*Message_CommitData
 	//	*Message_RoundChangeData
+	//	*Message_DkgData
+	//	*Message_FinalityData
+	//	*Message_VetoData
 	Payload isMessage_Payload `protobuf_oneof:"payload"`
+	// ttl is the maximum number of relay hops for the message,
+	// it is not covered by the signature
+	Ttl uint32 `protobuf:"varint,9,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	// hops is the number of times the message was relayed,
+	// it is not covered by the signature
+	Hops uint32 `protobuf:"varint,10,opt,name=hops,proto3" json:"hops,omitempty"`
+	// fromIndex is the 1-based index of the sender in the validator set
+	// of the message height, replacing from on the wire (see IndexCodec).
+	// Zero if the sender is referenced by from
+	FromIndex uint32 `protobuf:"varint,13,opt,name=fromIndex,proto3" json:"fromIndex,omitempty"`
 }
 
 func (x *Message) Reset() {
@@ -256,6 +284,48 @@ func (x *Message) GetRoundChangeData() *RoundChangeMessage {
 	return nil
 }
 
+func (x *Message) GetDkgData() *DKGMessage {
+	if x, ok := x.GetPayload().(*Message_DkgData); ok {
+		return x.DkgData
+	}
+	return nil
+}
+
+func (x *Message) GetFinalityData() *FinalityMessage {
+	if x, ok := x.GetPayload().(*Message_FinalityData); ok {
+		return x.FinalityData
+	}
+	return nil
+}
+
+func (x *Message) GetVetoData() *VetoMessage {
+	if x, ok := x.GetPayload().(*Message_VetoData); ok {
+		return x.VetoData
+	}
+	return nil
+}
+
+func (x *Message) GetTtl() uint32 {
+	if x != nil {
+		return x.Ttl
+	}
+	return 0
+}
+
+func (x *Message) GetHops() uint32 {
+	if x != nil {
+		return x.Hops
+	}
+	return 0
+}
+
+func (x *Message) GetFromIndex() uint32 {
+	if x != nil {
+		return x.FromIndex
+	}
+	return 0
+}
+
 type isMessage_Payload interface {
 	isMessage_Payload()
 }
@@ -276,6 +346,18 @@ type Message_RoundChangeData struct {
 	RoundChangeData *RoundChangeMessage `protobuf:"bytes,8,opt,name=roundChangeData,proto3,oneof"`
 }
 
+type Message_DkgData struct {
+	DkgData *DKGMessage `protobuf:"bytes,11,opt,name=dkgData,proto3,oneof"`
+}
+
+type Message_FinalityData struct {
+	FinalityData *FinalityMessage `protobuf:"bytes,12,opt,name=finalityData,proto3,oneof"`
+}
+
+type Message_VetoData struct {
+	VetoData *VetoMessage `protobuf:"bytes,14,opt,name=vetoData,proto3,oneof"`
+}
+
 func (*Message_PreprepareData) isMessage_Payload() {}
 
 func (*Message_PrepareData) isMessage_Payload() {}
@@ -284,6 +366,12 @@ func (*Message_CommitData) isMessage_Payload() {}
 
 func (*Message_RoundChangeData) isMessage_Payload() {}
 
+func (*Message_DkgData) isMessage_Payload() {}
+
+func (*Message_FinalityData) isMessage_Payload() {}
+
+func (*Message_VetoData) isMessage_Payload() {}
+
 // PrePrepareMessage is the message for the PREPREPARE phase
 type PrePrepareMessage struct {
 	state         protoimpl.MessageState
@@ -297,6 +385,15 @@ type PrePrepareMessage struct {
 	// certificate is the RCC that can accompany
 	// a proposal message
 	Certificate *RoundChangeCertificate `protobuf:"bytes,3,opt,name=certificate,proto3" json:"certificate,omitempty"`
+	// timestamp is the proposal creation time,
+	// in nanoseconds since the unix epoch
+	Timestamp uint64 `protobuf:"varint,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// dedupCertificate is the deduplicated encoding of the certificate,
+	// used only on the wire by the DedupCodec
+	DedupCertificate *DedupRoundChangeCertificate `protobuf:"bytes,5,opt,name=dedupCertificate,proto3" json:"dedupCertificate,omitempty"`
+	// nilProposal marks an explicit NIL proposal, which proposes
+	// to skip the round; the raw proposal and its hash are empty
+	NilProposal bool `protobuf:"varint,6,opt,name=nilProposal,proto3" json:"nilProposal,omitempty"`
 }
 
 func (x *PrePrepareMessage) Reset() {
@@ -352,6 +449,27 @@ func (x *PrePrepareMessage) GetCertificate() *RoundChangeCertificate {
 	return nil
 }
 
+func (x *PrePrepareMessage) GetTimestamp() uint64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *PrePrepareMessage) GetDedupCertificate() *DedupRoundChangeCertificate {
+	if x != nil {
+		return x.DedupCertificate
+	}
+	return nil
+}
+
+func (x *PrePrepareMessage) GetNilProposal() bool {
+	if x != nil {
+		return x.NilProposal
+	}
+	return false
+}
+
 // PrepareMessage is the message for the PREPARE phase
 type PrepareMessage struct {
 	state         protoimpl.MessageState
@@ -411,6 +529,10 @@ type CommitMessage struct {
 	ProposalHash []byte `protobuf:"bytes,1,opt,name=proposalHash,proto3" json:"proposalHash,omitempty"`
 	// committedSeal is the seal of the sender
 	CommittedSeal []byte `protobuf:"bytes,2,opt,name=committedSeal,proto3" json:"committedSeal,omitempty"`
+	// randomness is the randomness beacon contribution of the sender
+	// (ex. a VRF output with its proof), validated by the backend.
+	// Empty if the chain does not use the randomness beacon
+	Randomness []byte `protobuf:"bytes,3,opt,name=randomness,proto3" json:"randomness,omitempty"`
 }
 
 func (x *CommitMessage) Reset() {
@@ -459,6 +581,13 @@ func (x *CommitMessage) GetCommittedSeal() []byte {
 	return nil
 }
 
+func (x *CommitMessage) GetRandomness() []byte {
+	if x != nil {
+		return x.Randomness
+	}
+	return nil
+}
+
 // RoundChangeMessage is the message for the ROUND CHANGE phase
 type RoundChangeMessage struct {
 	state         protoimpl.MessageState
@@ -471,6 +600,9 @@ type RoundChangeMessage struct {
 	// latestPreparedCertificate is the PC that accompanies
 	// the last proposal
 	LatestPreparedCertificate *PreparedCertificate `protobuf:"bytes,2,opt,name=latestPreparedCertificate,proto3" json:"latestPreparedCertificate,omitempty"`
+	// dedupCertificate is the deduplicated encoding of the latest PC,
+	// used only on the wire by the DedupCodec
+	DedupCertificate *DedupPreparedCertificate `protobuf:"bytes,3,opt,name=dedupCertificate,proto3" json:"dedupCertificate,omitempty"`
 }
 
 func (x *RoundChangeMessage) Reset() {
@@ -519,6 +651,224 @@ func (x *RoundChangeMessage) GetLatestPreparedCertificate() *PreparedCertificate
 	return nil
 }
 
+func (x *RoundChangeMessage) GetDedupCertificate() *DedupPreparedCertificate {
+	if x != nil {
+		return x.DedupCertificate
+	}
+	return nil
+}
+
+// DKGMessage is the message of a distributed key generation ceremony.
+// The view height is the ceremony ID, and the view round is the ceremony phase
+type DKGMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// commitments are the public commitments to the polynomial
+	// of the dealer, sent in the deal phase
+	Commitments []byte `protobuf:"bytes,1,opt,name=commitments,proto3" json:"commitments,omitempty"`
+	// shares are the encrypted shares of the dealer for each participant,
+	// in the participant order, sent in the deal phase
+	Shares [][]byte `protobuf:"bytes,2,rep,name=shares,proto3" json:"shares,omitempty"`
+	// complaints are the dealers whose shares for the sender are missing
+	// or invalid, sent in the complaint phase
+	Complaints [][]byte `protobuf:"bytes,3,rep,name=complaints,proto3" json:"complaints,omitempty"`
+	// publicKey is the group public key derived by the sender,
+	// sent in the finalization phase
+	PublicKey []byte `protobuf:"bytes,4,opt,name=publicKey,proto3" json:"publicKey,omitempty"`
+}
+
+func (x *DKGMessage) Reset() {
+	*x = DKGMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_messages_proto_messages_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DKGMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DKGMessage) ProtoMessage() {}
+
+func (x *DKGMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_proto_messages_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DKGMessage.ProtoReflect.Descriptor instead.
+func (*DKGMessage) Descriptor() ([]byte, []int) {
+	return file_messages_proto_messages_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DKGMessage) GetCommitments() []byte {
+	if x != nil {
+		return x.Commitments
+	}
+	return nil
+}
+
+func (x *DKGMessage) GetShares() [][]byte {
+	if x != nil {
+		return x.Shares
+	}
+	return nil
+}
+
+func (x *DKGMessage) GetComplaints() [][]byte {
+	if x != nil {
+		return x.Complaints
+	}
+	return nil
+}
+
+func (x *DKGMessage) GetPublicKey() []byte {
+	if x != nil {
+		return x.PublicKey
+	}
+	return nil
+}
+
+// FinalityMessage is the proof a height was finalized, served by any node
+// that finalized the height to the nodes still working on it.
+// The view is the view the proposal was finalized in
+type FinalityMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// proposal is the finalized proposal
+	Proposal *Proposal `protobuf:"bytes,1,opt,name=proposal,proto3" json:"proposal,omitempty"`
+	// proposalHash is the Keccak hash of the finalized proposal
+	ProposalHash []byte `protobuf:"bytes,2,opt,name=proposalHash,proto3" json:"proposalHash,omitempty"`
+	// committedSeals are the committed seals of a quorum of validators
+	// over the proposal hash, with their signers
+	CommittedSeals []*SenderSignature `protobuf:"bytes,3,rep,name=committedSeals,proto3" json:"committedSeals,omitempty"`
+	// randomness are the randomness beacon contributions of the signers,
+	// in the committed seal order. Empty if the seals carry no contributions
+	Randomness [][]byte `protobuf:"bytes,4,rep,name=randomness,proto3" json:"randomness,omitempty"`
+}
+
+func (x *FinalityMessage) Reset() {
+	*x = FinalityMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_messages_proto_messages_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FinalityMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FinalityMessage) ProtoMessage() {}
+
+func (x *FinalityMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_proto_messages_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FinalityMessage.ProtoReflect.Descriptor instead.
+func (*FinalityMessage) Descriptor() ([]byte, []int) {
+	return file_messages_proto_messages_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *FinalityMessage) GetProposal() *Proposal {
+	if x != nil {
+		return x.Proposal
+	}
+	return nil
+}
+
+func (x *FinalityMessage) GetProposalHash() []byte {
+	if x != nil {
+		return x.ProposalHash
+	}
+	return nil
+}
+
+func (x *FinalityMessage) GetCommittedSeals() []*SenderSignature {
+	if x != nil {
+		return x.CommittedSeals
+	}
+	return nil
+}
+
+func (x *FinalityMessage) GetRandomness() [][]byte {
+	if x != nil {
+		return x.Randomness
+	}
+	return nil
+}
+
+// VetoMessage is the explicit rejection of the proposal of the round,
+// sent instead of the PREPARE message by the validators that find it invalid
+type VetoMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// proposalHash is the hash of the rejected proposal, as carried by it
+	ProposalHash []byte `protobuf:"bytes,1,opt,name=proposalHash,proto3" json:"proposalHash,omitempty"`
+}
+
+func (x *VetoMessage) Reset() {
+	*x = VetoMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_messages_proto_messages_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *VetoMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VetoMessage) ProtoMessage() {}
+
+func (x *VetoMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_proto_messages_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VetoMessage.ProtoReflect.Descriptor instead.
+func (*VetoMessage) Descriptor() ([]byte, []int) {
+	return file_messages_proto_messages_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *VetoMessage) GetProposalHash() []byte {
+	if x != nil {
+		return x.ProposalHash
+	}
+	return nil
+}
+
 // PreparedCertificate is a collection of
 // prepare messages for a certain proposal
 type PreparedCertificate struct {
@@ -536,7 +886,7 @@ type PreparedCertificate struct {
 func (x *PreparedCertificate) Reset() {
 	*x = PreparedCertificate{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_messages_proto_messages_proto_msgTypes[6]
+		mi := &file_messages_proto_messages_proto_msgTypes[9]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -549,7 +899,7 @@ func (x *PreparedCertificate) String() string {
 func (*PreparedCertificate) ProtoMessage() {}
 
 func (x *PreparedCertificate) ProtoReflect() protoreflect.Message {
-	mi := &file_messages_proto_messages_proto_msgTypes[6]
+	mi := &file_messages_proto_messages_proto_msgTypes[9]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -562,7 +912,7 @@ func (x *PreparedCertificate) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PreparedCertificate.ProtoReflect.Descriptor instead.
 func (*PreparedCertificate) Descriptor() ([]byte, []int) {
-	return file_messages_proto_messages_proto_rawDescGZIP(), []int{6}
+	return file_messages_proto_messages_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *PreparedCertificate) GetProposalMessage() *Message {
@@ -593,7 +943,7 @@ type RoundChangeCertificate struct {
 func (x *RoundChangeCertificate) Reset() {
 	*x = RoundChangeCertificate{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_messages_proto_messages_proto_msgTypes[7]
+		mi := &file_messages_proto_messages_proto_msgTypes[10]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -606,7 +956,7 @@ func (x *RoundChangeCertificate) String() string {
 func (*RoundChangeCertificate) ProtoMessage() {}
 
 func (x *RoundChangeCertificate) ProtoReflect() protoreflect.Message {
-	mi := &file_messages_proto_messages_proto_msgTypes[7]
+	mi := &file_messages_proto_messages_proto_msgTypes[10]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -619,7 +969,7 @@ func (x *RoundChangeCertificate) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RoundChangeCertificate.ProtoReflect.Descriptor instead.
 func (*RoundChangeCertificate) Descriptor() ([]byte, []int) {
-	return file_messages_proto_messages_proto_rawDescGZIP(), []int{7}
+	return file_messages_proto_messages_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *RoundChangeCertificate) GetRoundChangeMessages() []*Message {
@@ -644,7 +994,7 @@ type Proposal struct {
 func (x *Proposal) Reset() {
 	*x = Proposal{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_messages_proto_messages_proto_msgTypes[8]
+		mi := &file_messages_proto_messages_proto_msgTypes[11]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -657,7 +1007,7 @@ func (x *Proposal) String() string {
 func (*Proposal) ProtoMessage() {}
 
 func (x *Proposal) ProtoReflect() protoreflect.Message {
-	mi := &file_messages_proto_messages_proto_msgTypes[8]
+	mi := &file_messages_proto_messages_proto_msgTypes[11]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -670,7 +1020,7 @@ func (x *Proposal) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Proposal.ProtoReflect.Descriptor instead.
 func (*Proposal) Descriptor() ([]byte, []int) {
-	return file_messages_proto_messages_proto_rawDescGZIP(), []int{8}
+	return file_messages_proto_messages_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *Proposal) GetRawProposal() []byte {
@@ -687,97 +1037,1110 @@ func (x *Proposal) GetRound() uint64 {
 	return 0
 }
 
-var File_messages_proto_messages_proto protoreflect.FileDescriptor
+// Event is a consensus event, streamed to external observers
+type Event struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
 
-var file_messages_proto_messages_proto_rawDesc = []byte{
-	0x0a, 0x1d, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x2f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22,
-	0x34, 0x0a, 0x04, 0x56, 0x69, 0x65, 0x77, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68,
-	0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x12,
-	0x14, 0x0a, 0x05, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05,
-	0x72, 0x6f, 0x75, 0x6e, 0x64, 0x22, 0xe9, 0x02, 0x0a, 0x07, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
-	0x65, 0x12, 0x19, 0x0a, 0x04, 0x76, 0x69, 0x65, 0x77, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
-	0x05, 0x2e, 0x56, 0x69, 0x65, 0x77, 0x52, 0x04, 0x76, 0x69, 0x65, 0x77, 0x12, 0x12, 0x0a, 0x04,
-	0x66, 0x72, 0x6f, 0x6d, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x66, 0x72, 0x6f, 0x6d,
-	0x12, 0x1c, 0x0a, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x03, 0x20,
-	0x01, 0x28, 0x0c, 0x52, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x20,
-	0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0c, 0x2e, 0x4d,
-	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65,
-	0x12, 0x3c, 0x0a, 0x0e, 0x70, 0x72, 0x65, 0x70, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x44, 0x61,
-	0x74, 0x61, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x50, 0x72, 0x65, 0x50, 0x72,
-	0x65, 0x70, 0x61, 0x72, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x48, 0x00, 0x52, 0x0e,
-	0x70, 0x72, 0x65, 0x70, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x44, 0x61, 0x74, 0x61, 0x12, 0x33,
-	0x0a, 0x0b, 0x70, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x44, 0x61, 0x74, 0x61, 0x18, 0x06, 0x20,
-	0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x4d, 0x65, 0x73,
-	0x73, 0x61, 0x67, 0x65, 0x48, 0x00, 0x52, 0x0b, 0x70, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x44,
-	0x61, 0x74, 0x61, 0x12, 0x30, 0x0a, 0x0a, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x44, 0x61, 0x74,
-	0x61, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74,
-	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x48, 0x00, 0x52, 0x0a, 0x63, 0x6f, 0x6d, 0x6d, 0x69,
-	0x74, 0x44, 0x61, 0x74, 0x61, 0x12, 0x3f, 0x0a, 0x0f, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x43, 0x68,
-	0x61, 0x6e, 0x67, 0x65, 0x44, 0x61, 0x74, 0x61, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13,
-	0x2e, 0x52, 0x6f, 0x75, 0x6e, 0x64, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x4d, 0x65, 0x73, 0x73,
-	0x61, 0x67, 0x65, 0x48, 0x00, 0x52, 0x0f, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x43, 0x68, 0x61, 0x6e,
-	0x67, 0x65, 0x44, 0x61, 0x74, 0x61, 0x42, 0x09, 0x0a, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61,
-	0x64, 0x22, 0x99, 0x01, 0x0a, 0x11, 0x50, 0x72, 0x65, 0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65,
-	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x25, 0x0a, 0x08, 0x70, 0x72, 0x6f, 0x70, 0x6f,
-	0x73, 0x61, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x50, 0x72, 0x6f, 0x70,
-	0x6f, 0x73, 0x61, 0x6c, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x12, 0x22,
-	0x0a, 0x0c, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x48, 0x61, 0x73, 0x68, 0x18, 0x02,
-	0x20, 0x01, 0x28, 0x0c, 0x52, 0x0c, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x48, 0x61,
-	0x73, 0x68, 0x12, 0x39, 0x0a, 0x0b, 0x63, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74,
-	0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x52, 0x6f, 0x75, 0x6e, 0x64, 0x43,
-	0x68, 0x61, 0x6e, 0x67, 0x65, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65,
-	0x52, 0x0b, 0x63, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x22, 0x34, 0x0a,
-	0x0e, 0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12,
-	0x22, 0x0a, 0x0c, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x48, 0x61, 0x73, 0x68, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0c, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x48,
-	0x61, 0x73, 0x68, 0x22, 0x59, 0x0a, 0x0d, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x4d, 0x65, 0x73,
-	0x73, 0x61, 0x67, 0x65, 0x12, 0x22, 0x0a, 0x0c, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c,
-	0x48, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0c, 0x70, 0x72, 0x6f, 0x70,
-	0x6f, 0x73, 0x61, 0x6c, 0x48, 0x61, 0x73, 0x68, 0x12, 0x24, 0x0a, 0x0d, 0x63, 0x6f, 0x6d, 0x6d,
-	0x69, 0x74, 0x74, 0x65, 0x64, 0x53, 0x65, 0x61, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52,
-	0x0d, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x64, 0x53, 0x65, 0x61, 0x6c, 0x22, 0xa7,
-	0x01, 0x0a, 0x12, 0x52, 0x6f, 0x75, 0x6e, 0x64, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x4d, 0x65,
-	0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x3d, 0x0a, 0x14, 0x6c, 0x61, 0x73, 0x74, 0x50, 0x72, 0x65,
-	0x70, 0x61, 0x72, 0x65, 0x64, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x52, 0x14,
-	0x6c, 0x61, 0x73, 0x74, 0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x64, 0x50, 0x72, 0x6f, 0x70,
-	0x6f, 0x73, 0x61, 0x6c, 0x12, 0x52, 0x0a, 0x19, 0x6c, 0x61, 0x74, 0x65, 0x73, 0x74, 0x50, 0x72,
-	0x65, 0x70, 0x61, 0x72, 0x65, 0x64, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74,
-	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x50, 0x72, 0x65, 0x70, 0x61, 0x72,
-	0x65, 0x64, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x52, 0x19, 0x6c,
-	0x61, 0x74, 0x65, 0x73, 0x74, 0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x64, 0x43, 0x65, 0x72,
-	0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x22, 0x7d, 0x0a, 0x13, 0x50, 0x72, 0x65, 0x70,
-	0x61, 0x72, 0x65, 0x64, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x12,
-	0x32, 0x0a, 0x0f, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x4d, 0x65, 0x73, 0x73, 0x61,
-	0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x08, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61,
-	0x67, 0x65, 0x52, 0x0f, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x4d, 0x65, 0x73, 0x73,
-	0x61, 0x67, 0x65, 0x12, 0x32, 0x0a, 0x0f, 0x70, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x4d, 0x65,
-	0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x08, 0x2e, 0x4d,
-	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x52, 0x0f, 0x70, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x4d,
-	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x22, 0x54, 0x0a, 0x16, 0x52, 0x6f, 0x75, 0x6e, 0x64,
-	0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74,
-	0x65, 0x12, 0x3a, 0x0a, 0x13, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65,
-	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x08,
-	0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x52, 0x13, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x43,
-	0x68, 0x61, 0x6e, 0x67, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x22, 0x42, 0x0a,
-	0x08, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x12, 0x20, 0x0a, 0x0b, 0x72, 0x61, 0x77,
-	0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0b,
-	0x72, 0x61, 0x77, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x12, 0x14, 0x0a, 0x05, 0x72,
-	0x6f, 0x75, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x72, 0x6f, 0x75, 0x6e,
-	0x64, 0x2a, 0x48, 0x0a, 0x0b, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x54, 0x79, 0x70, 0x65,
-	0x12, 0x0e, 0x0a, 0x0a, 0x50, 0x52, 0x45, 0x50, 0x52, 0x45, 0x50, 0x41, 0x52, 0x45, 0x10, 0x00,
-	0x12, 0x0b, 0x0a, 0x07, 0x50, 0x52, 0x45, 0x50, 0x41, 0x52, 0x45, 0x10, 0x01, 0x12, 0x0a, 0x0a,
-	0x06, 0x43, 0x4f, 0x4d, 0x4d, 0x49, 0x54, 0x10, 0x02, 0x12, 0x10, 0x0a, 0x0c, 0x52, 0x4f, 0x55,
-	0x4e, 0x44, 0x5f, 0x43, 0x48, 0x41, 0x4e, 0x47, 0x45, 0x10, 0x03, 0x42, 0x11, 0x5a, 0x0f, 0x2f,
-	0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	// type is the human-readable event type
+	Type string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	// view is the view the event occurred in
+	View *View `protobuf:"bytes,2,opt,name=view,proto3" json:"view,omitempty"`
+	// timestamp is the time the event occurred at,
+	// in nanoseconds since the unix epoch
+	Timestamp int64 `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// data is the JSON encoded event payload
+	Data []byte `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
 }
 
-var (
-	file_messages_proto_messages_proto_rawDescOnce sync.Once
-	file_messages_proto_messages_proto_rawDescData = file_messages_proto_messages_proto_rawDesc
-)
+func (x *Event) Reset() {
+	*x = Event{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_messages_proto_messages_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_proto_messages_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_messages_proto_messages_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *Event) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Event) GetView() *View {
+	if x != nil {
+		return x.View
+	}
+	return nil
+}
+
+func (x *Event) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *Event) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+// SignerSet is the compact representation of the signers of a certificate,
+// as indexes into the known validator set
+type SignerSet struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// bitmap marks the signers; the bit i (LSB first) is set
+	// if the validator at index i is a signer
+	Bitmap []byte `protobuf:"bytes,1,opt,name=bitmap,proto3" json:"bitmap,omitempty"`
+	// signatures are the individual signatures of the signers,
+	// in the validator set order. Empty if the signatures are aggregated
+	Signatures [][]byte `protobuf:"bytes,2,rep,name=signatures,proto3" json:"signatures,omitempty"`
+	// aggregate is the aggregated signature of the signers, if any
+	Aggregate []byte `protobuf:"bytes,3,opt,name=aggregate,proto3" json:"aggregate,omitempty"`
+}
+
+func (x *SignerSet) Reset() {
+	*x = SignerSet{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_messages_proto_messages_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SignerSet) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SignerSet) ProtoMessage() {}
+
+func (x *SignerSet) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_proto_messages_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SignerSet.ProtoReflect.Descriptor instead.
+func (*SignerSet) Descriptor() ([]byte, []int) {
+	return file_messages_proto_messages_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *SignerSet) GetBitmap() []byte {
+	if x != nil {
+		return x.Bitmap
+	}
+	return nil
+}
+
+func (x *SignerSet) GetSignatures() [][]byte {
+	if x != nil {
+		return x.Signatures
+	}
+	return nil
+}
+
+func (x *SignerSet) GetAggregate() []byte {
+	if x != nil {
+		return x.Aggregate
+	}
+	return nil
+}
+
+// CompactPreparedCertificate is the compact form of the PreparedCertificate.
+// The PREPARE messages share the view and the proposal hash of the proposal,
+// so only their signers are kept
+type CompactPreparedCertificate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// proposalMessage is the proposal message of the certificate
+	ProposalMessage *Message `protobuf:"bytes,1,opt,name=proposalMessage,proto3" json:"proposalMessage,omitempty"`
+	// prepareSigners are the signers of the PREPARE messages
+	PrepareSigners *SignerSet `protobuf:"bytes,2,opt,name=prepareSigners,proto3" json:"prepareSigners,omitempty"`
+}
+
+func (x *CompactPreparedCertificate) Reset() {
+	*x = CompactPreparedCertificate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_messages_proto_messages_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CompactPreparedCertificate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompactPreparedCertificate) ProtoMessage() {}
+
+func (x *CompactPreparedCertificate) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_proto_messages_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompactPreparedCertificate.ProtoReflect.Descriptor instead.
+func (*CompactPreparedCertificate) Descriptor() ([]byte, []int) {
+	return file_messages_proto_messages_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *CompactPreparedCertificate) GetProposalMessage() *Message {
+	if x != nil {
+		return x.ProposalMessage
+	}
+	return nil
+}
+
+func (x *CompactPreparedCertificate) GetPrepareSigners() *SignerSet {
+	if x != nil {
+		return x.PrepareSigners
+	}
+	return nil
+}
+
+// CompactRoundChange is the compact form of the ROUND CHANGE message payload
+type CompactRoundChange struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// lastPreparedProposal is the last prepared proposal, if any
+	LastPreparedProposal *Proposal `protobuf:"bytes,1,opt,name=lastPreparedProposal,proto3" json:"lastPreparedProposal,omitempty"`
+	// latestPreparedCertificate is the compact PC of the last prepared proposal, if any
+	LatestPreparedCertificate *CompactPreparedCertificate `protobuf:"bytes,2,opt,name=latestPreparedCertificate,proto3" json:"latestPreparedCertificate,omitempty"`
+}
+
+func (x *CompactRoundChange) Reset() {
+	*x = CompactRoundChange{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_messages_proto_messages_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CompactRoundChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompactRoundChange) ProtoMessage() {}
+
+func (x *CompactRoundChange) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_proto_messages_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompactRoundChange.ProtoReflect.Descriptor instead.
+func (*CompactRoundChange) Descriptor() ([]byte, []int) {
+	return file_messages_proto_messages_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *CompactRoundChange) GetLastPreparedProposal() *Proposal {
+	if x != nil {
+		return x.LastPreparedProposal
+	}
+	return nil
+}
+
+func (x *CompactRoundChange) GetLatestPreparedCertificate() *CompactPreparedCertificate {
+	if x != nil {
+		return x.LatestPreparedCertificate
+	}
+	return nil
+}
+
+// CompactRoundChangeCertificate is the compact form of the RoundChangeCertificate.
+// The ROUND CHANGE messages share the view, so it is kept once
+type CompactRoundChangeCertificate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// view is the view of the ROUND CHANGE messages
+	View *View `protobuf:"bytes,1,opt,name=view,proto3" json:"view,omitempty"`
+	// signers are the senders of the ROUND CHANGE messages
+	Signers *SignerSet `protobuf:"bytes,2,opt,name=signers,proto3" json:"signers,omitempty"`
+	// roundChanges are the ROUND CHANGE payloads, in the validator set order
+	RoundChanges []*CompactRoundChange `protobuf:"bytes,3,rep,name=roundChanges,proto3" json:"roundChanges,omitempty"`
+}
+
+func (x *CompactRoundChangeCertificate) Reset() {
+	*x = CompactRoundChangeCertificate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_messages_proto_messages_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CompactRoundChangeCertificate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompactRoundChangeCertificate) ProtoMessage() {}
+
+func (x *CompactRoundChangeCertificate) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_proto_messages_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompactRoundChangeCertificate.ProtoReflect.Descriptor instead.
+func (*CompactRoundChangeCertificate) Descriptor() ([]byte, []int) {
+	return file_messages_proto_messages_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *CompactRoundChangeCertificate) GetView() *View {
+	if x != nil {
+		return x.View
+	}
+	return nil
+}
+
+func (x *CompactRoundChangeCertificate) GetSigners() *SignerSet {
+	if x != nil {
+		return x.Signers
+	}
+	return nil
+}
+
+func (x *CompactRoundChangeCertificate) GetRoundChanges() []*CompactRoundChange {
+	if x != nil {
+		return x.RoundChanges
+	}
+	return nil
+}
+
+// CompactCommittedSeals is the compact form of the committed seals
+// of a finalized proposal
+type CompactCommittedSeals struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// signers are the signers of the committed seals
+	Signers *SignerSet `protobuf:"bytes,1,opt,name=signers,proto3" json:"signers,omitempty"`
+}
+
+func (x *CompactCommittedSeals) Reset() {
+	*x = CompactCommittedSeals{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_messages_proto_messages_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CompactCommittedSeals) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompactCommittedSeals) ProtoMessage() {}
+
+func (x *CompactCommittedSeals) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_proto_messages_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompactCommittedSeals.ProtoReflect.Descriptor instead.
+func (*CompactCommittedSeals) Descriptor() ([]byte, []int) {
+	return file_messages_proto_messages_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *CompactCommittedSeals) GetSigners() *SignerSet {
+	if x != nil {
+		return x.Signers
+	}
+	return nil
+}
+
+// CommitCertificate is the certificate of a finalized proposal.
+// With an aggregation scheme, the committed seals are aggregated
+// into a single signature, so the certificate size does not grow
+// with the committee size, apart from the signer bitmap
+type CommitCertificate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// view is the view the proposal was finalized in
+	View *View `protobuf:"bytes,1,opt,name=view,proto3" json:"view,omitempty"`
+	// proposalHash is the hash of the finalized proposal
+	ProposalHash []byte `protobuf:"bytes,2,opt,name=proposalHash,proto3" json:"proposalHash,omitempty"`
+	// signers are the signers of the committed seals, with either
+	// their individual seals, or the aggregated seal
+	Signers *SignerSet `protobuf:"bytes,3,opt,name=signers,proto3" json:"signers,omitempty"`
+	// aggregationScheme is the name of the scheme the seals
+	// are aggregated with. Empty if the seals are not aggregated
+	AggregationScheme string `protobuf:"bytes,4,opt,name=aggregationScheme,proto3" json:"aggregationScheme,omitempty"`
+	// sealsRoot is the Merkle root of the individual committed seals,
+	// which proves the seal of a validator is part of the certificate,
+	// even if the seals are aggregated. Empty if the certificate
+	// does not commit to the seals
+	SealsRoot []byte `protobuf:"bytes,5,opt,name=sealsRoot,proto3" json:"sealsRoot,omitempty"`
+	// randomness are the randomness beacon contributions of the signers,
+	// in the signer order. Empty if the seals carry no contributions
+	Randomness [][]byte `protobuf:"bytes,6,rep,name=randomness,proto3" json:"randomness,omitempty"`
+}
+
+func (x *CommitCertificate) Reset() {
+	*x = CommitCertificate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_messages_proto_messages_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CommitCertificate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommitCertificate) ProtoMessage() {}
+
+func (x *CommitCertificate) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_proto_messages_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommitCertificate.ProtoReflect.Descriptor instead.
+func (*CommitCertificate) Descriptor() ([]byte, []int) {
+	return file_messages_proto_messages_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *CommitCertificate) GetView() *View {
+	if x != nil {
+		return x.View
+	}
+	return nil
+}
+
+func (x *CommitCertificate) GetProposalHash() []byte {
+	if x != nil {
+		return x.ProposalHash
+	}
+	return nil
+}
+
+func (x *CommitCertificate) GetSigners() *SignerSet {
+	if x != nil {
+		return x.Signers
+	}
+	return nil
+}
+
+func (x *CommitCertificate) GetAggregationScheme() string {
+	if x != nil {
+		return x.AggregationScheme
+	}
+	return ""
+}
+
+func (x *CommitCertificate) GetSealsRoot() []byte {
+	if x != nil {
+		return x.SealsRoot
+	}
+	return nil
+}
+
+func (x *CommitCertificate) GetRandomness() [][]byte {
+	if x != nil {
+		return x.Randomness
+	}
+	return nil
+}
+
+// SenderSignature is the sender and the signature
+// of a deduplicated message
+type SenderSignature struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// from is the message sender
+	From []byte `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	// signature is the signature of the sender
+	Signature []byte `protobuf:"bytes,2,opt,name=signature,proto3" json:"signature,omitempty"`
+	// fromIndex is the 1-based index of the sender in the validator set,
+	// replacing from on the wire. Zero if the sender is referenced by from
+	FromIndex uint32 `protobuf:"varint,3,opt,name=fromIndex,proto3" json:"fromIndex,omitempty"`
+}
+
+func (x *SenderSignature) Reset() {
+	*x = SenderSignature{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_messages_proto_messages_proto_msgTypes[19]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SenderSignature) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SenderSignature) ProtoMessage() {}
+
+func (x *SenderSignature) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_proto_messages_proto_msgTypes[19]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SenderSignature.ProtoReflect.Descriptor instead.
+func (*SenderSignature) Descriptor() ([]byte, []int) {
+	return file_messages_proto_messages_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *SenderSignature) GetFrom() []byte {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *SenderSignature) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+func (x *SenderSignature) GetFromIndex() uint32 {
+	if x != nil {
+		return x.FromIndex
+	}
+	return 0
+}
+
+// DedupPreparedCertificate is the deduplicated encoding of the PreparedCertificate.
+// The PREPARE messages share the view and the proposal hash of the proposal,
+// so only their senders and signatures are encoded
+type DedupPreparedCertificate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// proposalMessage is the proposal message of the certificate
+	ProposalMessage *Message `protobuf:"bytes,1,opt,name=proposalMessage,proto3" json:"proposalMessage,omitempty"`
+	// prepares are the senders and signatures of the PREPARE messages
+	Prepares []*SenderSignature `protobuf:"bytes,2,rep,name=prepares,proto3" json:"prepares,omitempty"`
+}
+
+func (x *DedupPreparedCertificate) Reset() {
+	*x = DedupPreparedCertificate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_messages_proto_messages_proto_msgTypes[20]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DedupPreparedCertificate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DedupPreparedCertificate) ProtoMessage() {}
+
+func (x *DedupPreparedCertificate) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_proto_messages_proto_msgTypes[20]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DedupPreparedCertificate.ProtoReflect.Descriptor instead.
+func (*DedupPreparedCertificate) Descriptor() ([]byte, []int) {
+	return file_messages_proto_messages_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *DedupPreparedCertificate) GetProposalMessage() *Message {
+	if x != nil {
+		return x.ProposalMessage
+	}
+	return nil
+}
+
+func (x *DedupPreparedCertificate) GetPrepares() []*SenderSignature {
+	if x != nil {
+		return x.Prepares
+	}
+	return nil
+}
+
+// DedupRoundChange is the per-sender data of a deduplicated ROUND CHANGE message
+type DedupRoundChange struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// from is the message sender
+	From []byte `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	// signature is the signature of the sender
+	Signature []byte `protobuf:"bytes,2,opt,name=signature,proto3" json:"signature,omitempty"`
+	// lastPreparedProposal is the last prepared proposal,
+	// if it is not the proposal of the referenced certificate
+	LastPreparedProposal *Proposal `protobuf:"bytes,3,opt,name=lastPreparedProposal,proto3" json:"lastPreparedProposal,omitempty"`
+	// certificate is the 1-based index of the latest PC
+	// in the certificate list, or 0 if there is no PC
+	Certificate uint32 `protobuf:"varint,4,opt,name=certificate,proto3" json:"certificate,omitempty"`
+	// certificateProposal is the flag indicating the last prepared
+	// proposal is the proposal of the referenced certificate
+	CertificateProposal bool `protobuf:"varint,5,opt,name=certificateProposal,proto3" json:"certificateProposal,omitempty"`
+	// fromIndex is the 1-based index of the sender in the validator set,
+	// replacing from on the wire. Zero if the sender is referenced by from
+	FromIndex uint32 `protobuf:"varint,6,opt,name=fromIndex,proto3" json:"fromIndex,omitempty"`
+}
+
+func (x *DedupRoundChange) Reset() {
+	*x = DedupRoundChange{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_messages_proto_messages_proto_msgTypes[21]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DedupRoundChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DedupRoundChange) ProtoMessage() {}
+
+func (x *DedupRoundChange) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_proto_messages_proto_msgTypes[21]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DedupRoundChange.ProtoReflect.Descriptor instead.
+func (*DedupRoundChange) Descriptor() ([]byte, []int) {
+	return file_messages_proto_messages_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *DedupRoundChange) GetFrom() []byte {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *DedupRoundChange) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+func (x *DedupRoundChange) GetLastPreparedProposal() *Proposal {
+	if x != nil {
+		return x.LastPreparedProposal
+	}
+	return nil
+}
+
+func (x *DedupRoundChange) GetCertificate() uint32 {
+	if x != nil {
+		return x.Certificate
+	}
+	return 0
+}
+
+func (x *DedupRoundChange) GetCertificateProposal() bool {
+	if x != nil {
+		return x.CertificateProposal
+	}
+	return false
+}
+
+func (x *DedupRoundChange) GetFromIndex() uint32 {
+	if x != nil {
+		return x.FromIndex
+	}
+	return 0
+}
+
+// DedupRoundChangeCertificate is the deduplicated encoding of the RoundChangeCertificate.
+// The shared view, and the PCs shared by multiple senders are encoded once
+type DedupRoundChangeCertificate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// view is the view of the ROUND CHANGE messages
+	View *View `protobuf:"bytes,1,opt,name=view,proto3" json:"view,omitempty"`
+	// certificates are the distinct PCs of the ROUND CHANGE messages
+	Certificates []*DedupPreparedCertificate `protobuf:"bytes,2,rep,name=certificates,proto3" json:"certificates,omitempty"`
+	// roundChanges are the per-sender data of the ROUND CHANGE messages
+	RoundChanges []*DedupRoundChange `protobuf:"bytes,3,rep,name=roundChanges,proto3" json:"roundChanges,omitempty"`
+}
+
+func (x *DedupRoundChangeCertificate) Reset() {
+	*x = DedupRoundChangeCertificate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_messages_proto_messages_proto_msgTypes[22]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DedupRoundChangeCertificate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DedupRoundChangeCertificate) ProtoMessage() {}
+
+func (x *DedupRoundChangeCertificate) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_proto_messages_proto_msgTypes[22]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DedupRoundChangeCertificate.ProtoReflect.Descriptor instead.
+func (*DedupRoundChangeCertificate) Descriptor() ([]byte, []int) {
+	return file_messages_proto_messages_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *DedupRoundChangeCertificate) GetView() *View {
+	if x != nil {
+		return x.View
+	}
+	return nil
+}
+
+func (x *DedupRoundChangeCertificate) GetCertificates() []*DedupPreparedCertificate {
+	if x != nil {
+		return x.Certificates
+	}
+	return nil
+}
+
+func (x *DedupRoundChangeCertificate) GetRoundChanges() []*DedupRoundChange {
+	if x != nil {
+		return x.RoundChanges
+	}
+	return nil
+}
+
+// Envelope authenticates an encoded message at the transport boundary.
+// The sender signs the encoded message, so the receivers can drop
+// unauthenticated data before decoding it
+type Envelope struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// payload is the encoded message
+	Payload []byte `protobuf:"bytes,1,opt,name=payload,proto3" json:"payload,omitempty"`
+	// signature is the signature of the sender over the payload
+	Signature []byte `protobuf:"bytes,2,opt,name=signature,proto3" json:"signature,omitempty"`
+	// from is the envelope sender
+	From []byte `protobuf:"bytes,3,opt,name=from,proto3" json:"from,omitempty"`
+}
+
+func (x *Envelope) Reset() {
+	*x = Envelope{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_messages_proto_messages_proto_msgTypes[23]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Envelope) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Envelope) ProtoMessage() {}
+
+func (x *Envelope) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_proto_messages_proto_msgTypes[23]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Envelope.ProtoReflect.Descriptor instead.
+func (*Envelope) Descriptor() ([]byte, []int) {
+	return file_messages_proto_messages_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *Envelope) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *Envelope) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+func (x *Envelope) GetFrom() []byte {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+var File_messages_proto_messages_proto protoreflect.FileDescriptor
+
+var file_messages_proto_messages_proto_rawDesc = []byte{
+	0x0a, 0x1d, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x2f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22,
+	0x34, 0x0a, 0x04, 0x56, 0x69, 0x65, 0x77, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68,
+	0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x12,
+	0x14, 0x0a, 0x05, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05,
+	0x72, 0x6f, 0x75, 0x6e, 0x64, 0x22, 0xba, 0x04, 0x0a, 0x07, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x12, 0x19, 0x0a, 0x04, 0x76, 0x69, 0x65, 0x77, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x05, 0x2e, 0x56, 0x69, 0x65, 0x77, 0x52, 0x04, 0x76, 0x69, 0x65, 0x77, 0x12, 0x12, 0x0a, 0x04,
+	0x66, 0x72, 0x6f, 0x6d, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x66, 0x72, 0x6f, 0x6d,
+	0x12, 0x1c, 0x0a, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x20,
+	0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0c, 0x2e, 0x4d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65,
+	0x12, 0x3c, 0x0a, 0x0e, 0x70, 0x72, 0x65, 0x70, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x44, 0x61,
+	0x74, 0x61, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x50, 0x72, 0x65, 0x50, 0x72,
+	0x65, 0x70, 0x61, 0x72, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x48, 0x00, 0x52, 0x0e,
+	0x70, 0x72, 0x65, 0x70, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x44, 0x61, 0x74, 0x61, 0x12, 0x33,
+	0x0a, 0x0b, 0x70, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x44, 0x61, 0x74, 0x61, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x4d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x48, 0x00, 0x52, 0x0b, 0x70, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x44,
+	0x61, 0x74, 0x61, 0x12, 0x30, 0x0a, 0x0a, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x44, 0x61, 0x74,
+	0x61, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74,
+	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x48, 0x00, 0x52, 0x0a, 0x63, 0x6f, 0x6d, 0x6d, 0x69,
+	0x74, 0x44, 0x61, 0x74, 0x61, 0x12, 0x3f, 0x0a, 0x0f, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x43, 0x68,
+	0x61, 0x6e, 0x67, 0x65, 0x44, 0x61, 0x74, 0x61, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13,
+	0x2e, 0x52, 0x6f, 0x75, 0x6e, 0x64, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x4d, 0x65, 0x73, 0x73,
+	0x61, 0x67, 0x65, 0x48, 0x00, 0x52, 0x0f, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x43, 0x68, 0x61, 0x6e,
+	0x67, 0x65, 0x44, 0x61, 0x74, 0x61, 0x12, 0x27, 0x0a, 0x07, 0x64, 0x6b, 0x67, 0x44, 0x61, 0x74,
+	0x61, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x44, 0x4b, 0x47, 0x4d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x48, 0x00, 0x52, 0x07, 0x64, 0x6b, 0x67, 0x44, 0x61, 0x74, 0x61, 0x12,
+	0x36, 0x0a, 0x0c, 0x66, 0x69, 0x6e, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x44, 0x61, 0x74, 0x61, 0x18,
+	0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x46, 0x69, 0x6e, 0x61, 0x6c, 0x69, 0x74, 0x79,
+	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x48, 0x00, 0x52, 0x0c, 0x66, 0x69, 0x6e, 0x61, 0x6c,
+	0x69, 0x74, 0x79, 0x44, 0x61, 0x74, 0x61, 0x12, 0x2a, 0x0a, 0x08, 0x76, 0x65, 0x74, 0x6f, 0x44,
+	0x61, 0x74, 0x61, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x56, 0x65, 0x74, 0x6f,
+	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x48, 0x00, 0x52, 0x08, 0x76, 0x65, 0x74, 0x6f, 0x44,
+	0x61, 0x74, 0x61, 0x12, 0x10, 0x0a, 0x03, 0x74, 0x74, 0x6c, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x03, 0x74, 0x74, 0x6c, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x6f, 0x70, 0x73, 0x18, 0x0a, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x04, 0x68, 0x6f, 0x70, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x66, 0x72, 0x6f,
+	0x6d, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x09, 0x66, 0x72,
+	0x6f, 0x6d, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x42, 0x09, 0x0a, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f,
+	0x61, 0x64, 0x22, 0xa3, 0x02, 0x0a, 0x11, 0x50, 0x72, 0x65, 0x50, 0x72, 0x65, 0x70, 0x61, 0x72,
+	0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x25, 0x0a, 0x08, 0x70, 0x72, 0x6f, 0x70,
+	0x6f, 0x73, 0x61, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x50, 0x72, 0x6f,
+	0x70, 0x6f, 0x73, 0x61, 0x6c, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x12,
+	0x22, 0x0a, 0x0c, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x48, 0x61, 0x73, 0x68, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0c, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x48,
+	0x61, 0x73, 0x68, 0x12, 0x39, 0x0a, 0x0b, 0x63, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61,
+	0x74, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x52, 0x6f, 0x75, 0x6e, 0x64,
+	0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74,
+	0x65, 0x52, 0x0b, 0x63, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x12, 0x1c,
+	0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x48, 0x0a, 0x10,
+	0x64, 0x65, 0x64, 0x75, 0x70, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x44, 0x65, 0x64, 0x75, 0x70, 0x52, 0x6f,
+	0x75, 0x6e, 0x64, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69,
+	0x63, 0x61, 0x74, 0x65, 0x52, 0x10, 0x64, 0x65, 0x64, 0x75, 0x70, 0x43, 0x65, 0x72, 0x74, 0x69,
+	0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x6e, 0x69, 0x6c, 0x50, 0x72, 0x6f,
+	0x70, 0x6f, 0x73, 0x61, 0x6c, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x6e, 0x69, 0x6c,
+	0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x22, 0x34, 0x0a, 0x0e, 0x50, 0x72, 0x65, 0x70,
+	0x61, 0x72, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x22, 0x0a, 0x0c, 0x70, 0x72,
+	0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x48, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x0c, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x48, 0x61, 0x73, 0x68, 0x22, 0x79,
+	0x0a, 0x0d, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12,
+	0x22, 0x0a, 0x0c, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x48, 0x61, 0x73, 0x68, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0c, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x48,
+	0x61, 0x73, 0x68, 0x12, 0x24, 0x0a, 0x0d, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x64,
+	0x53, 0x65, 0x61, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0d, 0x63, 0x6f, 0x6d, 0x6d,
+	0x69, 0x74, 0x74, 0x65, 0x64, 0x53, 0x65, 0x61, 0x6c, 0x12, 0x1e, 0x0a, 0x0a, 0x72, 0x61, 0x6e,
+	0x64, 0x6f, 0x6d, 0x6e, 0x65, 0x73, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a, 0x72,
+	0x61, 0x6e, 0x64, 0x6f, 0x6d, 0x6e, 0x65, 0x73, 0x73, 0x22, 0xee, 0x01, 0x0a, 0x12, 0x52, 0x6f,
+	0x75, 0x6e, 0x64, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x12, 0x3d, 0x0a, 0x14, 0x6c, 0x61, 0x73, 0x74, 0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x64,
+	0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09,
+	0x2e, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x52, 0x14, 0x6c, 0x61, 0x73, 0x74, 0x50,
+	0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x64, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x12,
+	0x52, 0x0a, 0x19, 0x6c, 0x61, 0x74, 0x65, 0x73, 0x74, 0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65,
+	0x64, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x14, 0x2e, 0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x64, 0x43, 0x65, 0x72,
+	0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x52, 0x19, 0x6c, 0x61, 0x74, 0x65, 0x73, 0x74,
+	0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x64, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63,
+	0x61, 0x74, 0x65, 0x12, 0x45, 0x0a, 0x10, 0x64, 0x65, 0x64, 0x75, 0x70, 0x43, 0x65, 0x72, 0x74,
+	0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e,
+	0x44, 0x65, 0x64, 0x75, 0x70, 0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x64, 0x43, 0x65, 0x72,
+	0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x52, 0x10, 0x64, 0x65, 0x64, 0x75, 0x70, 0x43,
+	0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x22, 0x84, 0x01, 0x0a, 0x0a, 0x44,
+	0x4b, 0x47, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x63, 0x6f, 0x6d,
+	0x6d, 0x69, 0x74, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0b,
+	0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x73,
+	0x68, 0x61, 0x72, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x06, 0x73, 0x68, 0x61,
+	0x72, 0x65, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x61, 0x69, 0x6e, 0x74,
+	0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x0a, 0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x61, 0x69,
+	0x6e, 0x74, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65,
+	0x79, 0x22, 0xb6, 0x01, 0x0a, 0x0f, 0x46, 0x69, 0x6e, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x4d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x25, 0x0a, 0x08, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61,
+	0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73,
+	0x61, 0x6c, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x12, 0x22, 0x0a, 0x0c,
+	0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x48, 0x61, 0x73, 0x68, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x0c, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x48, 0x61, 0x73, 0x68,
+	0x12, 0x38, 0x0a, 0x0e, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x64, 0x53, 0x65, 0x61,
+	0x6c, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x53, 0x65, 0x6e, 0x64, 0x65,
+	0x72, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x52, 0x0e, 0x63, 0x6f, 0x6d, 0x6d,
+	0x69, 0x74, 0x74, 0x65, 0x64, 0x53, 0x65, 0x61, 0x6c, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x72, 0x61,
+	0x6e, 0x64, 0x6f, 0x6d, 0x6e, 0x65, 0x73, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x0a,
+	0x72, 0x61, 0x6e, 0x64, 0x6f, 0x6d, 0x6e, 0x65, 0x73, 0x73, 0x22, 0x31, 0x0a, 0x0b, 0x56, 0x65,
+	0x74, 0x6f, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x22, 0x0a, 0x0c, 0x70, 0x72, 0x6f,
+	0x70, 0x6f, 0x73, 0x61, 0x6c, 0x48, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x0c, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x48, 0x61, 0x73, 0x68, 0x22, 0x7d, 0x0a,
+	0x13, 0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x64, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69,
+	0x63, 0x61, 0x74, 0x65, 0x12, 0x32, 0x0a, 0x0f, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c,
+	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x08, 0x2e,
+	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x52, 0x0f, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61,
+	0x6c, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x32, 0x0a, 0x0f, 0x70, 0x72, 0x65, 0x70,
+	0x61, 0x72, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x08, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x52, 0x0f, 0x70, 0x72, 0x65,
+	0x70, 0x61, 0x72, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x22, 0x54, 0x0a, 0x16,
+	0x52, 0x6f, 0x75, 0x6e, 0x64, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x43, 0x65, 0x72, 0x74, 0x69,
+	0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x12, 0x3a, 0x0a, 0x13, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x43,
+	0x68, 0x61, 0x6e, 0x67, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x18, 0x01, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x08, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x52, 0x13, 0x72,
+	0x6f, 0x75, 0x6e, 0x64, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x73, 0x22, 0x42, 0x0a, 0x08, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x12, 0x20,
+	0x0a, 0x0b, 0x72, 0x61, 0x77, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x0b, 0x72, 0x61, 0x77, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c,
+	0x12, 0x14, 0x0a, 0x05, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x05, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x22, 0x68, 0x0a, 0x05, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12,
+	0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74,
+	0x79, 0x70, 0x65, 0x12, 0x19, 0x0a, 0x04, 0x76, 0x69, 0x65, 0x77, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x05, 0x2e, 0x56, 0x69, 0x65, 0x77, 0x52, 0x04, 0x76, 0x69, 0x65, 0x77, 0x12, 0x1c,
+	0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x12, 0x0a, 0x04,
+	0x64, 0x61, 0x74, 0x61, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61,
+	0x22, 0x61, 0x0a, 0x09, 0x53, 0x69, 0x67, 0x6e, 0x65, 0x72, 0x53, 0x65, 0x74, 0x12, 0x16, 0x0a,
+	0x06, 0x62, 0x69, 0x74, 0x6d, 0x61, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x62,
+	0x69, 0x74, 0x6d, 0x61, 0x70, 0x12, 0x1e, 0x0a, 0x0a, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75,
+	0x72, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x0a, 0x73, 0x69, 0x67, 0x6e, 0x61,
+	0x74, 0x75, 0x72, 0x65, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x61, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61,
+	0x74, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x61, 0x67, 0x67, 0x72, 0x65, 0x67,
+	0x61, 0x74, 0x65, 0x22, 0x84, 0x01, 0x0a, 0x1a, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x63, 0x74, 0x50,
+	0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x64, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61,
+	0x74, 0x65, 0x12, 0x32, 0x0a, 0x0f, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x4d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x08, 0x2e, 0x4d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x52, 0x0f, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x4d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x32, 0x0a, 0x0e, 0x70, 0x72, 0x65, 0x70, 0x61, 0x72,
+	0x65, 0x53, 0x69, 0x67, 0x6e, 0x65, 0x72, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a,
+	0x2e, 0x53, 0x69, 0x67, 0x6e, 0x65, 0x72, 0x53, 0x65, 0x74, 0x52, 0x0e, 0x70, 0x72, 0x65, 0x70,
+	0x61, 0x72, 0x65, 0x53, 0x69, 0x67, 0x6e, 0x65, 0x72, 0x73, 0x22, 0xae, 0x01, 0x0a, 0x12, 0x43,
+	0x6f, 0x6d, 0x70, 0x61, 0x63, 0x74, 0x52, 0x6f, 0x75, 0x6e, 0x64, 0x43, 0x68, 0x61, 0x6e, 0x67,
+	0x65, 0x12, 0x3d, 0x0a, 0x14, 0x6c, 0x61, 0x73, 0x74, 0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65,
+	0x64, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x09, 0x2e, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x52, 0x14, 0x6c, 0x61, 0x73, 0x74,
+	0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x64, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c,
+	0x12, 0x59, 0x0a, 0x19, 0x6c, 0x61, 0x74, 0x65, 0x73, 0x74, 0x50, 0x72, 0x65, 0x70, 0x61, 0x72,
+	0x65, 0x64, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x63, 0x74, 0x50, 0x72, 0x65,
+	0x70, 0x61, 0x72, 0x65, 0x64, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65,
+	0x52, 0x19, 0x6c, 0x61, 0x74, 0x65, 0x73, 0x74, 0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x64,
+	0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x22, 0x99, 0x01, 0x0a, 0x1d,
+	0x43, 0x6f, 0x6d, 0x70, 0x61, 0x63, 0x74, 0x52, 0x6f, 0x75, 0x6e, 0x64, 0x43, 0x68, 0x61, 0x6e,
+	0x67, 0x65, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x12, 0x19, 0x0a,
+	0x04, 0x76, 0x69, 0x65, 0x77, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x05, 0x2e, 0x56, 0x69,
+	0x65, 0x77, 0x52, 0x04, 0x76, 0x69, 0x65, 0x77, 0x12, 0x24, 0x0a, 0x07, 0x73, 0x69, 0x67, 0x6e,
+	0x65, 0x72, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x53, 0x69, 0x67, 0x6e,
+	0x65, 0x72, 0x53, 0x65, 0x74, 0x52, 0x07, 0x73, 0x69, 0x67, 0x6e, 0x65, 0x72, 0x73, 0x12, 0x37,
+	0x0a, 0x0c, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x73, 0x18, 0x03,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x63, 0x74, 0x52, 0x6f,
+	0x75, 0x6e, 0x64, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x0c, 0x72, 0x6f, 0x75, 0x6e, 0x64,
+	0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x73, 0x22, 0x3d, 0x0a, 0x15, 0x43, 0x6f, 0x6d, 0x70, 0x61,
+	0x63, 0x74, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x64, 0x53, 0x65, 0x61, 0x6c, 0x73,
+	0x12, 0x24, 0x0a, 0x07, 0x73, 0x69, 0x67, 0x6e, 0x65, 0x72, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x0a, 0x2e, 0x53, 0x69, 0x67, 0x6e, 0x65, 0x72, 0x53, 0x65, 0x74, 0x52, 0x07, 0x73,
+	0x69, 0x67, 0x6e, 0x65, 0x72, 0x73, 0x22, 0xe4, 0x01, 0x0a, 0x11, 0x43, 0x6f, 0x6d, 0x6d, 0x69,
+	0x74, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x12, 0x19, 0x0a, 0x04,
+	0x76, 0x69, 0x65, 0x77, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x05, 0x2e, 0x56, 0x69, 0x65,
+	0x77, 0x52, 0x04, 0x76, 0x69, 0x65, 0x77, 0x12, 0x22, 0x0a, 0x0c, 0x70, 0x72, 0x6f, 0x70, 0x6f,
+	0x73, 0x61, 0x6c, 0x48, 0x61, 0x73, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0c, 0x70,
+	0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x48, 0x61, 0x73, 0x68, 0x12, 0x24, 0x0a, 0x07, 0x73,
+	0x69, 0x67, 0x6e, 0x65, 0x72, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x53,
+	0x69, 0x67, 0x6e, 0x65, 0x72, 0x53, 0x65, 0x74, 0x52, 0x07, 0x73, 0x69, 0x67, 0x6e, 0x65, 0x72,
+	0x73, 0x12, 0x2c, 0x0a, 0x11, 0x61, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x53, 0x63, 0x68, 0x65, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x11, 0x61, 0x67,
+	0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x63, 0x68, 0x65, 0x6d, 0x65, 0x12,
+	0x1c, 0x0a, 0x09, 0x73, 0x65, 0x61, 0x6c, 0x73, 0x52, 0x6f, 0x6f, 0x74, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x09, 0x73, 0x65, 0x61, 0x6c, 0x73, 0x52, 0x6f, 0x6f, 0x74, 0x12, 0x1e, 0x0a,
+	0x0a, 0x72, 0x61, 0x6e, 0x64, 0x6f, 0x6d, 0x6e, 0x65, 0x73, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28,
+	0x0c, 0x52, 0x0a, 0x72, 0x61, 0x6e, 0x64, 0x6f, 0x6d, 0x6e, 0x65, 0x73, 0x73, 0x22, 0x61, 0x0a,
+	0x0f, 0x53, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65,
+	0x12, 0x12, 0x0a, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04,
+	0x66, 0x72, 0x6f, 0x6d, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75,
+	0x72, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x66, 0x72, 0x6f, 0x6d, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x09, 0x66, 0x72, 0x6f, 0x6d, 0x49, 0x6e, 0x64, 0x65, 0x78,
+	0x22, 0x7c, 0x0a, 0x18, 0x44, 0x65, 0x64, 0x75, 0x70, 0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65,
+	0x64, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x12, 0x32, 0x0a, 0x0f,
+	0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x08, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x52,
+	0x0f, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x12, 0x2c, 0x0a, 0x08, 0x70, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x10, 0x2e, 0x53, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x53, 0x69, 0x67, 0x6e, 0x61,
+	0x74, 0x75, 0x72, 0x65, 0x52, 0x08, 0x70, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x73, 0x22, 0xf5,
+	0x01, 0x0a, 0x10, 0x44, 0x65, 0x64, 0x75, 0x70, 0x52, 0x6f, 0x75, 0x6e, 0x64, 0x43, 0x68, 0x61,
+	0x6e, 0x67, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61,
+	0x74, 0x75, 0x72, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x73, 0x69, 0x67, 0x6e,
+	0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x3d, 0x0a, 0x14, 0x6c, 0x61, 0x73, 0x74, 0x50, 0x72, 0x65,
+	0x70, 0x61, 0x72, 0x65, 0x64, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x52, 0x14,
+	0x6c, 0x61, 0x73, 0x74, 0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x64, 0x50, 0x72, 0x6f, 0x70,
+	0x6f, 0x73, 0x61, 0x6c, 0x12, 0x20, 0x0a, 0x0b, 0x63, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63,
+	0x61, 0x74, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x63, 0x65, 0x72, 0x74, 0x69,
+	0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x12, 0x30, 0x0a, 0x13, 0x63, 0x65, 0x72, 0x74, 0x69, 0x66,
+	0x69, 0x63, 0x61, 0x74, 0x65, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x13, 0x63, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65,
+	0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x12, 0x1c, 0x0a, 0x09, 0x66, 0x72, 0x6f, 0x6d,
+	0x49, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x09, 0x66, 0x72, 0x6f,
+	0x6d, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x22, 0xae, 0x01, 0x0a, 0x1b, 0x44, 0x65, 0x64, 0x75, 0x70,
+	0x52, 0x6f, 0x75, 0x6e, 0x64, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x43, 0x65, 0x72, 0x74, 0x69,
+	0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x12, 0x19, 0x0a, 0x04, 0x76, 0x69, 0x65, 0x77, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x05, 0x2e, 0x56, 0x69, 0x65, 0x77, 0x52, 0x04, 0x76, 0x69, 0x65,
+	0x77, 0x12, 0x3d, 0x0a, 0x0c, 0x63, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65,
+	0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x44, 0x65, 0x64, 0x75, 0x70, 0x50,
+	0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x64, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61,
+	0x74, 0x65, 0x52, 0x0c, 0x63, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x73,
+	0x12, 0x35, 0x0a, 0x0c, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x73,
+	0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x44, 0x65, 0x64, 0x75, 0x70, 0x52, 0x6f,
+	0x75, 0x6e, 0x64, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x0c, 0x72, 0x6f, 0x75, 0x6e, 0x64,
+	0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x73, 0x22, 0x56, 0x0a, 0x08, 0x45, 0x6e, 0x76, 0x65, 0x6c,
+	0x6f, 0x70, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x1c, 0x0a,
+	0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x66,
+	0x72, 0x6f, 0x6d, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x2a,
+	0x95, 0x01, 0x0a, 0x0b, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x54, 0x79, 0x70, 0x65, 0x12,
+	0x0e, 0x0a, 0x0a, 0x50, 0x52, 0x45, 0x50, 0x52, 0x45, 0x50, 0x41, 0x52, 0x45, 0x10, 0x00, 0x12,
+	0x0b, 0x0a, 0x07, 0x50, 0x52, 0x45, 0x50, 0x41, 0x52, 0x45, 0x10, 0x01, 0x12, 0x0a, 0x0a, 0x06,
+	0x43, 0x4f, 0x4d, 0x4d, 0x49, 0x54, 0x10, 0x02, 0x12, 0x10, 0x0a, 0x0c, 0x52, 0x4f, 0x55, 0x4e,
+	0x44, 0x5f, 0x43, 0x48, 0x41, 0x4e, 0x47, 0x45, 0x10, 0x03, 0x12, 0x18, 0x0a, 0x14, 0x50, 0x52,
+	0x4f, 0x50, 0x4f, 0x53, 0x45, 0x52, 0x5f, 0x55, 0x4e, 0x41, 0x56, 0x41, 0x49, 0x4c, 0x41, 0x42,
+	0x4c, 0x45, 0x10, 0x04, 0x12, 0x07, 0x0a, 0x03, 0x44, 0x4b, 0x47, 0x10, 0x05, 0x12, 0x10, 0x0a,
+	0x0c, 0x43, 0x55, 0x52, 0x52, 0x45, 0x4e, 0x54, 0x5f, 0x56, 0x49, 0x45, 0x57, 0x10, 0x06, 0x12,
+	0x0c, 0x0a, 0x08, 0x46, 0x49, 0x4e, 0x41, 0x4c, 0x49, 0x54, 0x59, 0x10, 0x07, 0x12, 0x08, 0x0a,
+	0x04, 0x56, 0x45, 0x54, 0x4f, 0x10, 0x08, 0x42, 0x11, 0x5a, 0x0f, 0x2f, 0x6d, 0x65, 0x73, 0x73,
+	0x61, 0x67, 0x65, 0x73, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
+}
+
+var (
+	file_messages_proto_messages_proto_rawDescOnce sync.Once
+	file_messages_proto_messages_proto_rawDescData = file_messages_proto_messages_proto_rawDesc
+)
 
 func file_messages_proto_messages_proto_rawDescGZIP() []byte {
 	file_messages_proto_messages_proto_rawDescOnce.Do(func() {
@@ -787,18 +2150,33 @@ func file_messages_proto_messages_proto_rawDescGZIP() []byte {
 }
 
 var file_messages_proto_messages_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_messages_proto_messages_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_messages_proto_messages_proto_msgTypes = make([]protoimpl.MessageInfo, 24)
 var file_messages_proto_messages_proto_goTypes = []interface{}{
-	(MessageType)(0),               // 0: MessageType
-	(*View)(nil),                   // 1: View
-	(*Message)(nil),                // 2: Message
-	(*PrePrepareMessage)(nil),      // 3: PrePrepareMessage
-	(*PrepareMessage)(nil),         // 4: PrepareMessage
-	(*CommitMessage)(nil),          // 5: CommitMessage
-	(*RoundChangeMessage)(nil),     // 6: RoundChangeMessage
-	(*PreparedCertificate)(nil),    // 7: PreparedCertificate
-	(*RoundChangeCertificate)(nil), // 8: RoundChangeCertificate
-	(*Proposal)(nil),               // 9: Proposal
+	(MessageType)(0),                      // 0: MessageType
+	(*View)(nil),                          // 1: View
+	(*Message)(nil),                       // 2: Message
+	(*PrePrepareMessage)(nil),             // 3: PrePrepareMessage
+	(*PrepareMessage)(nil),                // 4: PrepareMessage
+	(*CommitMessage)(nil),                 // 5: CommitMessage
+	(*RoundChangeMessage)(nil),            // 6: RoundChangeMessage
+	(*DKGMessage)(nil),                    // 7: DKGMessage
+	(*FinalityMessage)(nil),               // 8: FinalityMessage
+	(*VetoMessage)(nil),                   // 9: VetoMessage
+	(*PreparedCertificate)(nil),           // 10: PreparedCertificate
+	(*RoundChangeCertificate)(nil),        // 11: RoundChangeCertificate
+	(*Proposal)(nil),                      // 12: Proposal
+	(*Event)(nil),                         // 13: Event
+	(*SignerSet)(nil),                     // 14: SignerSet
+	(*CompactPreparedCertificate)(nil),    // 15: CompactPreparedCertificate
+	(*CompactRoundChange)(nil),            // 16: CompactRoundChange
+	(*CompactRoundChangeCertificate)(nil), // 17: CompactRoundChangeCertificate
+	(*CompactCommittedSeals)(nil),         // 18: CompactCommittedSeals
+	(*CommitCertificate)(nil),             // 19: CommitCertificate
+	(*SenderSignature)(nil),               // 20: SenderSignature
+	(*DedupPreparedCertificate)(nil),      // 21: DedupPreparedCertificate
+	(*DedupRoundChange)(nil),              // 22: DedupRoundChange
+	(*DedupRoundChangeCertificate)(nil),   // 23: DedupRoundChangeCertificate
+	(*Envelope)(nil),                      // 24: Envelope
 }
 var file_messages_proto_messages_proto_depIdxs = []int32{
 	1,  // 0: Message.view:type_name -> View
@@ -807,18 +2185,42 @@ var file_messages_proto_messages_proto_depIdxs = []int32{
 	4,  // 3: Message.prepareData:type_name -> PrepareMessage
 	5,  // 4: Message.commitData:type_name -> CommitMessage
 	6,  // 5: Message.roundChangeData:type_name -> RoundChangeMessage
-	9,  // 6: PrePrepareMessage.proposal:type_name -> Proposal
-	8,  // 7: PrePrepareMessage.certificate:type_name -> RoundChangeCertificate
-	9,  // 8: RoundChangeMessage.lastPreparedProposal:type_name -> Proposal
-	7,  // 9: RoundChangeMessage.latestPreparedCertificate:type_name -> PreparedCertificate
-	2,  // 10: PreparedCertificate.proposalMessage:type_name -> Message
-	2,  // 11: PreparedCertificate.prepareMessages:type_name -> Message
-	2,  // 12: RoundChangeCertificate.roundChangeMessages:type_name -> Message
-	13, // [13:13] is the sub-list for method output_type
-	13, // [13:13] is the sub-list for method input_type
-	13, // [13:13] is the sub-list for extension type_name
-	13, // [13:13] is the sub-list for extension extendee
-	0,  // [0:13] is the sub-list for field type_name
+	7,  // 6: Message.dkgData:type_name -> DKGMessage
+	8,  // 7: Message.finalityData:type_name -> FinalityMessage
+	9,  // 8: Message.vetoData:type_name -> VetoMessage
+	12, // 9: PrePrepareMessage.proposal:type_name -> Proposal
+	11, // 10: PrePrepareMessage.certificate:type_name -> RoundChangeCertificate
+	23, // 11: PrePrepareMessage.dedupCertificate:type_name -> DedupRoundChangeCertificate
+	12, // 12: RoundChangeMessage.lastPreparedProposal:type_name -> Proposal
+	10, // 13: RoundChangeMessage.latestPreparedCertificate:type_name -> PreparedCertificate
+	21, // 14: RoundChangeMessage.dedupCertificate:type_name -> DedupPreparedCertificate
+	12, // 15: FinalityMessage.proposal:type_name -> Proposal
+	20, // 16: FinalityMessage.committedSeals:type_name -> SenderSignature
+	2,  // 17: PreparedCertificate.proposalMessage:type_name -> Message
+	2,  // 18: PreparedCertificate.prepareMessages:type_name -> Message
+	2,  // 19: RoundChangeCertificate.roundChangeMessages:type_name -> Message
+	1,  // 20: Event.view:type_name -> View
+	2,  // 21: CompactPreparedCertificate.proposalMessage:type_name -> Message
+	14, // 22: CompactPreparedCertificate.prepareSigners:type_name -> SignerSet
+	12, // 23: CompactRoundChange.lastPreparedProposal:type_name -> Proposal
+	15, // 24: CompactRoundChange.latestPreparedCertificate:type_name -> CompactPreparedCertificate
+	1,  // 25: CompactRoundChangeCertificate.view:type_name -> View
+	14, // 26: CompactRoundChangeCertificate.signers:type_name -> SignerSet
+	16, // 27: CompactRoundChangeCertificate.roundChanges:type_name -> CompactRoundChange
+	14, // 28: CompactCommittedSeals.signers:type_name -> SignerSet
+	1,  // 29: CommitCertificate.view:type_name -> View
+	14, // 30: CommitCertificate.signers:type_name -> SignerSet
+	2,  // 31: DedupPreparedCertificate.proposalMessage:type_name -> Message
+	20, // 32: DedupPreparedCertificate.prepares:type_name -> SenderSignature
+	12, // 33: DedupRoundChange.lastPreparedProposal:type_name -> Proposal
+	1,  // 34: DedupRoundChangeCertificate.view:type_name -> View
+	21, // 35: DedupRoundChangeCertificate.certificates:type_name -> DedupPreparedCertificate
+	22, // 36: DedupRoundChangeCertificate.roundChanges:type_name -> DedupRoundChange
+	37, // [37:37] is the sub-list for method output_type
+	37, // [37:37] is the sub-list for method input_type
+	37, // [37:37] is the sub-list for extension type_name
+	37, // [37:37] is the sub-list for extension extendee
+	0,  // [0:37] is the sub-list for field type_name
 }
 
 func init() { file_messages_proto_messages_proto_init() }
@@ -900,7 +2302,7 @@ func file_messages_proto_messages_proto_init() {
 			}
 		}
 		file_messages_proto_messages_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PreparedCertificate); i {
+			switch v := v.(*DKGMessage); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -912,7 +2314,7 @@ func file_messages_proto_messages_proto_init() {
 			}
 		}
 		file_messages_proto_messages_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*RoundChangeCertificate); i {
+			switch v := v.(*FinalityMessage); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -924,6 +2326,42 @@ func file_messages_proto_messages_proto_init() {
 			}
 		}
 		file_messages_proto_messages_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*VetoMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_messages_proto_messages_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PreparedCertificate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_messages_proto_messages_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RoundChangeCertificate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_messages_proto_messages_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Proposal); i {
 			case 0:
 				return &v.state
@@ -935,12 +2373,159 @@ func file_messages_proto_messages_proto_init() {
 				return nil
 			}
 		}
+		file_messages_proto_messages_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Event); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_messages_proto_messages_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SignerSet); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_messages_proto_messages_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CompactPreparedCertificate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_messages_proto_messages_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CompactRoundChange); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_messages_proto_messages_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CompactRoundChangeCertificate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_messages_proto_messages_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CompactCommittedSeals); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_messages_proto_messages_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CommitCertificate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_messages_proto_messages_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SenderSignature); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_messages_proto_messages_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DedupPreparedCertificate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_messages_proto_messages_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DedupRoundChange); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_messages_proto_messages_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DedupRoundChangeCertificate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_messages_proto_messages_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Envelope); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	file_messages_proto_messages_proto_msgTypes[1].OneofWrappers = []interface{}{
 		(*Message_PreprepareData)(nil),
 		(*Message_PrepareData)(nil),
 		(*Message_CommitData)(nil),
 		(*Message_RoundChangeData)(nil),
+		(*Message_DkgData)(nil),
+		(*Message_FinalityData)(nil),
+		(*Message_VetoData)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -948,7 +2533,7 @@ func file_messages_proto_messages_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_messages_proto_messages_proto_rawDesc,
 			NumEnums:      1,
-			NumMessages:   9,
+			NumMessages:   24,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
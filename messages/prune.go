@@ -0,0 +1,137 @@
+package messages
+
+import (
+	"sort"
+	"time"
+)
+
+// HeightStats are the statistics of the messages stored for a single height
+type HeightStats struct {
+	// Height is the height of the messages
+	Height uint64
+
+	// Messages is the number of stored messages, of all types
+	Messages int
+
+	// LastReceived is the time the latest message for the height was added
+	LastReceived time.Time
+}
+
+// PrunePolicy determines which heights are pruned from the message store
+// when the node moves to a new height
+type PrunePolicy interface {
+	// PruneHeights returns the heights whose messages should be pruned,
+	// given the current height and the stats of the stored heights, ordered by height
+	PruneHeights(current uint64, heights []HeightStats) []uint64
+}
+
+// HeightWindowPolicy prunes the heights that are more than Window heights
+// below the current height. The zero value prunes all heights
+// below the current height, which is the default store behavior
+type HeightWindowPolicy struct {
+	// Window is the number of past heights to retain
+	Window uint64
+}
+
+// PruneHeights returns the heights below the retained window
+func (p HeightWindowPolicy) PruneHeights(current uint64, heights []HeightStats) []uint64 {
+	pruned := make([]uint64, 0)
+
+	for _, stats := range heights {
+		if stats.Height+p.Window < current {
+			pruned = append(pruned, stats.Height)
+		}
+	}
+
+	return pruned
+}
+
+// AgePolicy prunes the past heights that did not receive
+// any message for longer than MaxAge
+type AgePolicy struct {
+	// MaxAge is the maximum time a past height is retained after its latest message
+	MaxAge time.Duration
+}
+
+// PruneHeights returns the past heights with no messages received within MaxAge
+func (p AgePolicy) PruneHeights(current uint64, heights []HeightStats) []uint64 {
+	pruned := make([]uint64, 0)
+
+	for _, stats := range heights {
+		if stats.Height < current && time.Since(stats.LastReceived) > p.MaxAge {
+			pruned = append(pruned, stats.Height)
+		}
+	}
+
+	return pruned
+}
+
+// SizePolicy prunes the oldest past heights, until the total
+// number of stored messages is at most MaxMessages.
+// The current and future heights are never pruned
+type SizePolicy struct {
+	// MaxMessages is the maximum number of stored messages
+	MaxMessages int
+}
+
+// PruneHeights returns the oldest past heights exceeding the size limit
+func (p SizePolicy) PruneHeights(current uint64, heights []HeightStats) []uint64 {
+	total := 0
+	for _, stats := range heights {
+		total += stats.Messages
+	}
+
+	pruned := make([]uint64, 0)
+
+	for _, stats := range heights {
+		if total <= p.MaxMessages || stats.Height >= current {
+			break
+		}
+
+		pruned = append(pruned, stats.Height)
+		total -= stats.Messages
+	}
+
+	return pruned
+}
+
+// AnyPolicy combines the prune policies, pruning
+// the heights selected by any of them
+func AnyPolicy(policies ...PrunePolicy) PrunePolicy {
+	return anyPolicy(policies)
+}
+
+type anyPolicy []PrunePolicy
+
+// PruneHeights returns the union of the heights selected by the policies
+func (p anyPolicy) PruneHeights(current uint64, heights []HeightStats) []uint64 {
+	selected := make(map[uint64]struct{})
+
+	for _, policy := range p {
+		for _, height := range policy.PruneHeights(current, heights) {
+			selected[height] = struct{}{}
+		}
+	}
+
+	pruned := make([]uint64, 0, len(selected))
+	for height := range selected {
+		pruned = append(pruned, height)
+	}
+
+	sort.Slice(pruned, func(i, j int) bool {
+		return pruned[i] < pruned[j]
+	})
+
+	return pruned
+}
+
+// Option is the optional message store configuration setter
+type Option func(*Messages)
+
+// WithPrunePolicy sets the policy used for pruning the store
+// when the node moves to a new height
+func WithPrunePolicy(policy PrunePolicy) Option {
+	return func(ms *Messages) {
+		ms.prunePolicy = policy
+	}
+}
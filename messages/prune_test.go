@@ -0,0 +1,124 @@
+package messages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+func TestPrunePolicy_PruneHeights(t *testing.T) {
+	t.Parallel()
+
+	var (
+		now     = time.Now()
+		current = uint64(10)
+		heights = []HeightStats{
+			{Height: 6, Messages: 4, LastReceived: now.Add(-time.Hour)},
+			{Height: 7, Messages: 4, LastReceived: now.Add(-time.Minute)},
+			{Height: 8, Messages: 4, LastReceived: now.Add(-time.Second)},
+			{Height: 9, Messages: 4, LastReceived: now},
+			{Height: 10, Messages: 4, LastReceived: now},
+			{Height: 11, Messages: 4, LastReceived: now.Add(-time.Hour)},
+		}
+	)
+
+	testTable := []struct {
+		name           string
+		policy         PrunePolicy
+		expectedHeight []uint64
+	}{
+		{
+			"default height window",
+			HeightWindowPolicy{},
+			[]uint64{6, 7, 8, 9},
+		},
+		{
+			"height window",
+			HeightWindowPolicy{Window: 2},
+			[]uint64{6, 7},
+		},
+		{
+			"age",
+			AgePolicy{MaxAge: 30 * time.Second},
+			[]uint64{6, 7},
+		},
+		{
+			"size",
+			SizePolicy{MaxMessages: 15},
+			[]uint64{6, 7, 8},
+		},
+		{
+			"size never prunes the current height",
+			SizePolicy{MaxMessages: 0},
+			[]uint64{6, 7, 8, 9},
+		},
+		{
+			"any",
+			AnyPolicy(
+				HeightWindowPolicy{Window: 3},
+				AgePolicy{MaxAge: 30 * time.Second},
+			),
+			[]uint64{6, 7},
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(
+				t,
+				testCase.expectedHeight,
+				testCase.policy.PruneHeights(current, heights),
+			)
+		})
+	}
+}
+
+func TestMessages_PrunePolicy(t *testing.T) {
+	t.Parallel()
+
+	var (
+		numMessages = 3
+		views       = []*proto.View{
+			{Height: 1, Round: 0},
+			{Height: 2, Round: 1},
+			{Height: 3, Round: 0},
+		}
+	)
+
+	messages := NewMessages(WithPrunePolicy(HeightWindowPolicy{Window: 1}))
+	defer messages.Close()
+
+	for _, view := range views {
+		for _, messageType := range allMessageTypes {
+			for _, message := range generateRandomMessages(numMessages, view, messageType) {
+				messages.AddMessage(message)
+			}
+		}
+	}
+
+	// Make sure the stats are gathered for all message types
+	stats := messages.heightStats()
+	if assert.Len(t, stats, len(views)) {
+		for index, view := range views {
+			assert.Equal(t, view.Height, stats[index].Height)
+			assert.Equal(t, numMessages*len(allMessageTypes), stats[index].Messages)
+			assert.False(t, stats[index].LastReceived.IsZero())
+		}
+	}
+
+	messages.PruneByHeight(views[2].Height)
+
+	// Make sure only the heights outside the window are pruned
+	for _, messageType := range allMessageTypes {
+		assert.Equal(t, 0, messages.numMessages(views[0], messageType))
+		assert.Equal(t, numMessages, messages.numMessages(views[1], messageType))
+		assert.Equal(t, numMessages, messages.numMessages(views[2], messageType))
+	}
+}
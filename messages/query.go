@@ -0,0 +1,150 @@
+package messages
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// MessageQuery selects the stored messages for a single height.
+// Empty filters match all messages
+type MessageQuery struct {
+	// Height is the height of the messages
+	Height uint64
+
+	// Round is the round of the messages, if set
+	Round *uint64
+
+	// Types are the accepted message types
+	Types []proto.MessageType
+
+	// Senders are the accepted message senders
+	Senders [][]byte
+}
+
+// Matches checks if the message is selected by the query
+func (q MessageQuery) Matches(message *proto.Message) bool {
+	if message == nil || message.View == nil || message.View.Height != q.Height {
+		return false
+	}
+
+	if q.Round != nil && message.View.Round != *q.Round {
+		return false
+	}
+
+	return q.matchesType(message.Type) && q.matchesSender(message.From)
+}
+
+// matchesType checks if the message type is accepted by the query
+func (q MessageQuery) matchesType(messageType proto.MessageType) bool {
+	if len(q.Types) == 0 {
+		return true
+	}
+
+	for _, accepted := range q.Types {
+		if accepted == messageType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesSender checks if the message sender is accepted by the query
+func (q MessageQuery) matchesSender(from []byte) bool {
+	if len(q.Senders) == 0 {
+		return true
+	}
+
+	for _, accepted := range q.Senders {
+		if bytes.Equal(accepted, from) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Query fetches the stored messages selected by the query, without validating them.
+// The messages are ordered by round, type and arrival, so auditors can
+// reconstruct the order in which the node observed the height
+func (ms *Messages) Query(query MessageQuery) []*proto.Message {
+	stored := make([]*storedMessage, 0)
+
+	for _, messageType := range allMessageTypes {
+		if !query.matchesType(messageType) {
+			continue
+		}
+
+		mux := ms.muxMap[messageType]
+		mux.RLock()
+
+		for round, messages := range ms.getMessageMap(messageType)[query.Height] {
+			if query.Round != nil && round != *query.Round {
+				continue
+			}
+
+			for _, message := range messages {
+				if query.matchesSender(message.From) {
+					stored = append(stored, message)
+				}
+			}
+		}
+
+		mux.RUnlock()
+	}
+
+	sort.Slice(stored, func(i, j int) bool {
+		a, b := stored[i], stored[j]
+
+		if a.View.Round != b.View.Round {
+			return a.View.Round < b.View.Round
+		}
+
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+
+		return a.arrival < b.arrival
+	})
+
+	messages := make([]*proto.Message, 0, len(stored))
+	for _, message := range stored {
+		messages = append(messages, message.Message)
+	}
+
+	return messages
+}
+
+// HighestRound returns the highest round of the height for which a ROUND_CHANGE
+// or PREPREPARE message is stored, and false if there is none. Compared with
+// the local round, it shows how far ahead of the node the network is
+func (ms *Messages) HighestRound(height uint64) (uint64, bool) {
+	var (
+		highest uint64
+		found   bool
+	)
+
+	for _, messageType := range []proto.MessageType{
+		proto.MessageType_PREPREPARE,
+		proto.MessageType_ROUND_CHANGE,
+	} {
+		mux := ms.muxMap[messageType]
+		mux.RLock()
+
+		for round, messages := range ms.getMessageMap(messageType)[height] {
+			if len(messages) == 0 {
+				continue
+			}
+
+			if !found || round > highest {
+				highest, found = round, true
+			}
+		}
+
+		mux.RUnlock()
+	}
+
+	return highest, found
+}
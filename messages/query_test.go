@@ -0,0 +1,234 @@
+package messages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+func TestMessageQuery_Matches(t *testing.T) {
+	t.Parallel()
+
+	var (
+		round   = uint64(1)
+		message = &proto.Message{
+			View: &proto.View{Height: 5, Round: round},
+			From: []byte("validator 1"),
+			Type: proto.MessageType_COMMIT,
+		}
+	)
+
+	testTable := []struct {
+		name     string
+		query    MessageQuery
+		expected bool
+	}{
+		{
+			"height only",
+			MessageQuery{Height: 5},
+			true,
+		},
+		{
+			"different height",
+			MessageQuery{Height: 4},
+			false,
+		},
+		{
+			"matching round",
+			MessageQuery{Height: 5, Round: &round},
+			true,
+		},
+		{
+			"different round",
+			MessageQuery{Height: 5, Round: new(uint64)},
+			false,
+		},
+		{
+			"matching type",
+			MessageQuery{
+				Height: 5,
+				Types:  []proto.MessageType{proto.MessageType_PREPARE, proto.MessageType_COMMIT},
+			},
+			true,
+		},
+		{
+			"different type",
+			MessageQuery{Height: 5, Types: []proto.MessageType{proto.MessageType_PREPARE}},
+			false,
+		},
+		{
+			"matching sender",
+			MessageQuery{Height: 5, Senders: [][]byte{[]byte("validator 1")}},
+			true,
+		},
+		{
+			"different sender",
+			MessageQuery{Height: 5, Senders: [][]byte{[]byte("validator 2")}},
+			false,
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, testCase.expected, testCase.query.Matches(message))
+		})
+	}
+
+	// Make sure messages without a view are not matched
+	assert.False(t, MessageQuery{}.Matches(&proto.Message{}))
+	assert.False(t, MessageQuery{}.Matches(nil))
+}
+
+func TestMessages_Query(t *testing.T) {
+	t.Parallel()
+
+	messages := NewMessages()
+	defer messages.Close()
+
+	buildMessage := func(height, round uint64, messageType proto.MessageType, from string) *proto.Message {
+		return &proto.Message{
+			View: &proto.View{Height: height, Round: round},
+			From: []byte(from),
+			Type: messageType,
+		}
+	}
+
+	var (
+		commit0      = buildMessage(1, 0, proto.MessageType_COMMIT, "validator 1")
+		prepare0     = buildMessage(1, 0, proto.MessageType_PREPARE, "validator 2")
+		prepare0Late = buildMessage(1, 0, proto.MessageType_PREPARE, "validator 1")
+		roundChange1 = buildMessage(1, 1, proto.MessageType_ROUND_CHANGE, "validator 2")
+		preprepare1  = buildMessage(1, 1, proto.MessageType_PREPREPARE, "validator 1")
+		otherHeight  = buildMessage(2, 0, proto.MessageType_PREPARE, "validator 1")
+	)
+
+	for _, message := range []*proto.Message{
+		roundChange1,
+		commit0,
+		prepare0,
+		preprepare1,
+		prepare0Late,
+		otherHeight,
+	} {
+		messages.AddMessage(message)
+	}
+
+	round := uint64(0)
+
+	testTable := []struct {
+		name     string
+		query    MessageQuery
+		expected []*proto.Message
+	}{
+		{
+			"all messages for the height",
+			MessageQuery{Height: 1},
+			[]*proto.Message{prepare0, prepare0Late, commit0, preprepare1, roundChange1},
+		},
+		{
+			"single round",
+			MessageQuery{Height: 1, Round: &round},
+			[]*proto.Message{prepare0, prepare0Late, commit0},
+		},
+		{
+			"single type",
+			MessageQuery{Height: 1, Types: []proto.MessageType{proto.MessageType_PREPARE}},
+			[]*proto.Message{prepare0, prepare0Late},
+		},
+		{
+			"single sender",
+			MessageQuery{Height: 1, Senders: [][]byte{[]byte("validator 2")}},
+			[]*proto.Message{prepare0, roundChange1},
+		},
+		{
+			"missing height",
+			MessageQuery{Height: 3},
+			[]*proto.Message{},
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, testCase.expected, messages.Query(testCase.query))
+		})
+	}
+}
+
+func TestMessages_HighestRound(t *testing.T) {
+	t.Parallel()
+
+	messages := NewMessages()
+	defer messages.Close()
+
+	for _, message := range []*proto.Message{
+		{View: &proto.View{Height: 1, Round: 2}, Type: proto.MessageType_PREPREPARE},
+		{View: &proto.View{Height: 1, Round: 4}, Type: proto.MessageType_ROUND_CHANGE},
+		{View: &proto.View{Height: 1, Round: 9}, Type: proto.MessageType_COMMIT},
+		{View: &proto.View{Height: 2, Round: 0}, Type: proto.MessageType_PREPREPARE},
+		{View: &proto.View{Height: 3, Round: 5}, Type: proto.MessageType_PREPARE},
+	} {
+		messages.AddMessage(message)
+	}
+
+	// Make sure an empty view left behind by a lookup is not reported
+	messages.GetValidMessages(
+		&proto.View{Height: 1, Round: 8},
+		proto.MessageType_ROUND_CHANGE,
+		func(_ *proto.Message) bool { return true },
+	)
+
+	testTable := []struct {
+		name   string
+		height uint64
+		round  uint64
+		found  bool
+	}{
+		{
+			"highest of the ROUND_CHANGE and PREPREPARE rounds",
+			1,
+			4,
+			true,
+		},
+		{
+			"round 0",
+			2,
+			0,
+			true,
+		},
+		{
+			"other message types only",
+			3,
+			0,
+			false,
+		},
+		{
+			"missing height",
+			4,
+			0,
+			false,
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			round, found := messages.HighestRound(testCase.height)
+
+			assert.Equal(t, testCase.found, found)
+			assert.Equal(t, testCase.round, round)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package messages
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+var (
+	// ErrRandomnessMismatch is an error indicating the randomness contributions
+	// do not match the committed seals they belong to
+	ErrRandomnessMismatch = errors.New("randomness contributions do not match the committed seals")
+)
+
+// beaconDomain is the domain prefix of the beacon output,
+// so it cannot collide with the other hashes of the seals
+var beaconDomain = []byte("IBFT_RANDOMNESS_BEACON")
+
+// BeaconOutput combines the randomness contributions of the committed seals
+// into the randomness beacon output of the finalized proposal. The contributions
+// are ordered by their signer, so the output does not depend on the order
+// the seals were received in. The output is nil if the seals carry no contributions.
+// The output depends on the committed set, so it must be derived from the seals
+// recorded by the chain (ex. in the commit certificate), not from a local quorum
+func BeaconOutput(seals []*CommittedSeal) []byte {
+	if !hasRandomness(seals) {
+		return nil
+	}
+
+	hasher := sha256.New()
+	hasher.Write(beaconDomain)
+
+	for _, seal := range sortedSeals(seals) {
+		hasher.Write(binary.AppendUvarint(nil, uint64(len(seal.Signer))))
+		hasher.Write(seal.Signer)
+		hasher.Write(binary.AppendUvarint(nil, uint64(len(seal.Randomness))))
+		hasher.Write(seal.Randomness)
+	}
+
+	return hasher.Sum(nil)
+}
+
+// CommitCertificateBeacon returns the randomness beacon output of the commit
+// certificate (see BeaconOutput), combined from the contributions of its signers.
+// The contributions are not validated; that is left to the caller
+func CommitCertificateBeacon(certificate *proto.CommitCertificate, validators [][]byte) ([]byte, error) {
+	signers, err := Signers(certificate.GetSigners(), validators)
+	if err != nil {
+		return nil, err
+	}
+
+	randomness := certificate.GetRandomness()
+	if len(randomness) != len(signers) {
+		return nil, ErrRandomnessMismatch
+	}
+
+	seals := make([]*CommittedSeal, 0, len(signers))
+
+	for index, signer := range signers {
+		seals = append(seals, &CommittedSeal{
+			Signer:     signer,
+			Randomness: randomness[index],
+		})
+	}
+
+	return BeaconOutput(seals), nil
+}
+
+// hasRandomness checks if any of the committed seals carries a randomness contribution
+func hasRandomness(seals []*CommittedSeal) bool {
+	for _, seal := range seals {
+		if len(seal.Randomness) != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// orderedRandomness returns the randomness contributions of the committed seals,
+// in the validator set order of their signers. It returns nil if the seals
+// carry no contributions
+func orderedRandomness(seals []*CommittedSeal, validators [][]byte) ([][]byte, error) {
+	if !hasRandomness(seals) {
+		return nil, nil
+	}
+
+	signers := make([][]byte, 0, len(seals))
+	for _, seal := range seals {
+		signers = append(signers, seal.Signer)
+	}
+
+	_, order, err := signerOrder(validators, signers)
+	if err != nil {
+		return nil, err
+	}
+
+	randomness := make([][]byte, 0, len(seals))
+	for _, position := range order {
+		randomness = append(randomness, seals[position].Randomness)
+	}
+
+	return randomness, nil
+}
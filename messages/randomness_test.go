@@ -0,0 +1,117 @@
+package messages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// buildRandomSeals builds the xorScheme seals of the signers,
+// each carrying the randomness contribution of its signer
+func buildRandomSeals(validators [][]byte, proposalHash []byte, signers ...int) []*CommittedSeal {
+	seals := buildXorSeals(validators, proposalHash, signers...)
+	for _, seal := range seals {
+		seal.Randomness = append([]byte("randomness of "), seal.Signer...)
+	}
+
+	return seals
+}
+
+func TestBeaconOutput(t *testing.T) {
+	t.Parallel()
+
+	var (
+		validators   = generateValidators(4)
+		proposalHash = []byte("proposal hash")
+		seals        = buildRandomSeals(validators, proposalHash, 0, 1, 2)
+		output       = BeaconOutput(seals)
+	)
+
+	require.NotEmpty(t, output)
+
+	// Make sure the output does not depend on the seal order
+	assert.Equal(t, output, BeaconOutput(buildRandomSeals(validators, proposalHash, 2, 0, 1)))
+
+	// Make sure the output depends on the committed set, and on each contribution
+	assert.NotEqual(t, output, BeaconOutput(buildRandomSeals(validators, proposalHash, 0, 1, 3)))
+
+	biased := buildRandomSeals(validators, proposalHash, 0, 1, 2)
+	biased[1].Randomness = []byte("biased randomness")
+
+	assert.NotEqual(t, output, BeaconOutput(biased))
+
+	// Make sure the seals without contributions have no output
+	assert.Nil(t, BeaconOutput(buildXorSeals(validators, proposalHash, 0, 1, 2)))
+}
+
+func TestCommitCertificate_Randomness(t *testing.T) {
+	t.Parallel()
+
+	var (
+		validators   = generateValidators(6)
+		proposalHash = []byte("proposal hash")
+		view         = &proto.View{Height: 10, Round: 2}
+		seals        = buildRandomSeals(validators, proposalHash, 4, 0, 2)
+	)
+
+	for _, scheme := range []AggregationScheme{nil, xorScheme{}} {
+		certificate, err := NewCommitCertificate(view, proposalHash, seals, validators, scheme)
+		require.NoError(t, err)
+
+		// Make sure the contributions follow the signer order
+		assert.Equal(t, [][]byte{seals[1].Randomness, seals[2].Randomness, seals[0].Randomness}, certificate.Randomness)
+
+		_, err = VerifyCommitCertificate(certificate, validators, xorScheme{}, isValidXorSeal)
+		require.NoError(t, err)
+
+		output, err := CommitCertificateBeacon(certificate, validators)
+		require.NoError(t, err)
+		assert.Equal(t, BeaconOutput(seals), output)
+
+		// Make sure the contributions must match the signers
+		certificate.Randomness = certificate.Randomness[1:]
+
+		_, err = VerifyCommitCertificate(certificate, validators, xorScheme{}, isValidXorSeal)
+		assert.ErrorIs(t, err, ErrRandomnessMismatch)
+
+		_, err = CommitCertificateBeacon(certificate, validators)
+		assert.ErrorIs(t, err, ErrRandomnessMismatch)
+	}
+
+	// Make sure the certificates of seals without contributions carry none
+	plainSeals := buildXorSeals(validators, proposalHash, 0, 1)
+
+	certificate, err := NewCommitCertificate(view, proposalHash, plainSeals, validators, nil)
+	require.NoError(t, err)
+	assert.Empty(t, certificate.Randomness)
+}
+
+func TestFinalityMessage_Randomness(t *testing.T) {
+	t.Parallel()
+
+	var (
+		validators   = generateValidators(4)
+		proposalHash = []byte("proposal hash")
+		view         = &proto.View{Height: 10, Round: 0}
+		seals        = buildRandomSeals(validators, proposalHash, 0, 1, 2)
+	)
+
+	finality := NewFinalityMessage(view, &proto.Proposal{RawProposal: []byte("proposal")}, proposalHash, seals)
+
+	commits, err := ExtractFinalityCommits(finality)
+	require.NoError(t, err)
+
+	// Make sure the contributions are carried over to the COMMIT messages
+	extracted, err := ExtractCommittedSeals(commits)
+	require.NoError(t, err)
+	assert.Equal(t, seals, extracted)
+
+	// Make sure the contributions must match the committed seals
+	finality.GetFinalityData().Randomness = finality.GetFinalityData().Randomness[1:]
+
+	_, err = ExtractFinalityCommits(finality)
+	assert.ErrorIs(t, err, ErrRandomnessMismatch)
+}
@@ -0,0 +1,66 @@
+package messages
+
+import (
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// RoundChangeRetention is the policy for choosing which of the ROUND_CHANGE messages
+// a sender submitted for the same view is kept in the store. The kept message
+// determines the PC the sender contributes to the RCC, and so the proposal
+// re-proposed after the round change
+type RoundChangeRetention uint8
+
+const (
+	// RetainHighestPC keeps the message carrying the prepared certificate
+	// of the highest round. Of the messages with the same PC round (or without a PC),
+	// the latest received one is kept. This is the default policy, as the highest PC
+	// is the one that must be carried over to keep the prepared proposal locked
+	RetainHighestPC RoundChangeRetention = iota
+
+	// RetainLatestRoundChange keeps the latest received message
+	RetainLatestRoundChange
+)
+
+// WithRoundChangeRetention sets the policy for choosing which of the ROUND_CHANGE
+// messages a sender submitted for the same view is kept in the store
+func WithRoundChangeRetention(retention RoundChangeRetention) Option {
+	return func(ms *Messages) {
+		ms.roundChangeRetention = retention
+	}
+}
+
+// replaces checks if the received ROUND_CHANGE message replaces
+// the stored message of the same sender, according to the policy
+func (r RoundChangeRetention) replaces(stored, received *proto.Message) bool {
+	if r == RetainLatestRoundChange {
+		return true
+	}
+
+	storedRound, storedOk := preparedRound(stored)
+	receivedRound, receivedOk := preparedRound(received)
+
+	switch {
+	case !storedOk:
+		return true
+	case !receivedOk:
+		return false
+	default:
+		return receivedRound >= storedRound
+	}
+}
+
+// preparedRound returns the round of the prepared certificate
+// carried by the ROUND_CHANGE message, if any
+func preparedRound(message *proto.Message) (uint64, bool) {
+	roundChangeData, err := ExtractPayload[*proto.RoundChangeMessage](message)
+	if err != nil {
+		return 0, false
+	}
+
+	proposalMessage := roundChangeData.GetLatestPreparedCertificate().GetProposalMessage()
+	if proposalMessage.GetView() == nil {
+		return 0, false
+	}
+
+	return proposalMessage.GetView().GetRound(), true
+}
@@ -0,0 +1,131 @@
+package messages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// buildRetentionRoundChange builds a ROUND_CHANGE message of the sender,
+// carrying a PC of the specified round, if set
+func buildRetentionRoundChange(view *proto.View, pcRound *uint64, signature string) *proto.Message {
+	roundChangeData := &proto.RoundChangeMessage{}
+
+	if pcRound != nil {
+		roundChangeData.LatestPreparedCertificate = &proto.PreparedCertificate{
+			ProposalMessage: &proto.Message{
+				View: &proto.View{Height: view.Height, Round: *pcRound},
+				Type: proto.MessageType_PREPREPARE,
+			},
+		}
+	}
+
+	return &proto.Message{
+		View:      view,
+		From:      []byte("sender"),
+		Signature: []byte(signature),
+		Type:      proto.MessageType_ROUND_CHANGE,
+		Payload: &proto.Message_RoundChangeData{
+			RoundChangeData: roundChangeData,
+		},
+	}
+}
+
+func TestMessages_RoundChangeRetention(t *testing.T) {
+	t.Parallel()
+
+	var (
+		round0 = uint64(0)
+		round1 = uint64(1)
+	)
+
+	testTable := []struct {
+		name      string
+		retention RoundChangeRetention
+		first     *uint64
+		second    *uint64
+		expected  string
+	}{
+		{
+			"higher PC replaces the lower one",
+			RetainHighestPC,
+			&round0,
+			&round1,
+			"second",
+		},
+		{
+			"lower PC does not replace the higher one",
+			RetainHighestPC,
+			&round1,
+			&round0,
+			"first",
+		},
+		{
+			"PC replaces no PC",
+			RetainHighestPC,
+			nil,
+			&round0,
+			"second",
+		},
+		{
+			"no PC does not replace a PC",
+			RetainHighestPC,
+			&round0,
+			nil,
+			"first",
+		},
+		{
+			"latest message kept for the same PC round",
+			RetainHighestPC,
+			&round1,
+			&round1,
+			"second",
+		},
+		{
+			"latest message kept without PCs",
+			RetainHighestPC,
+			nil,
+			nil,
+			"second",
+		},
+		{
+			"latest message kept regardless of the PC",
+			RetainLatestRoundChange,
+			&round1,
+			nil,
+			"second",
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			view := &proto.View{Height: 1, Round: 2}
+
+			messages := NewMessages(WithRoundChangeRetention(testCase.retention))
+			defer messages.Close()
+
+			messages.AddMessage(buildRetentionRoundChange(view, testCase.first, "first"))
+			messages.AddMessage(buildRetentionRoundChange(view, testCase.second, "second"))
+
+			stored := messages.GetValidMessages(view, proto.MessageType_ROUND_CHANGE, func(_ *proto.Message) bool {
+				return true
+			})
+
+			if assert.Len(t, stored, 1) {
+				assert.Equal(t, []byte(testCase.expected), stored[0].Signature)
+			}
+
+			// Make sure the duplicate is counted, regardless of the kept message
+			stats := messages.Stats()
+			if assert.Len(t, stats, 1) {
+				assert.Equal(t, 1, stats[0].Types[proto.MessageType_ROUND_CHANGE].Duplicates)
+			}
+		})
+	}
+}
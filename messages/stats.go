@@ -0,0 +1,177 @@
+package messages
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// RoundStats are the message store statistics of a single view (height, round),
+// meant for debugging quorum stalls (ex. finding the validators a quorum is missing)
+type RoundStats struct {
+	// Height is the height of the view
+	Height uint64
+
+	// Round is the round of the view
+	Round uint64
+
+	// Types are the statistics of each message type received for the view
+	Types map[proto.MessageType]MessageTypeStats
+
+	// Senders are the unique senders of the stored messages of any type, sorted
+	Senders [][]byte
+}
+
+// MessageTypeStats are the statistics of the messages of a single type for a view
+type MessageTypeStats struct {
+	// Messages is the number of stored messages
+	Messages int
+
+	// Senders are the senders of the stored messages, sorted
+	Senders [][]byte
+
+	// Duplicates is the number of received messages that replaced
+	// a stored message from the same sender
+	Duplicates int
+
+	// Invalid is the number of messages pruned out for failing validation
+	Invalid int
+
+	// Equivocations is the number of conflicting messages rejected
+	// by the EquivocationReject policy
+	Equivocations int
+}
+
+// messageCounters are the counters of the messages of a single type for a view,
+// which are not derivable from the stored messages
+type messageCounters struct {
+	duplicates    int
+	invalid       int
+	equivocations int
+}
+
+// heightCounters maps the height number -> round number -> message counters
+type heightCounters map[uint64]map[uint64]*messageCounters
+
+// getViewCounters fetches the message counters for the specified view (height + round).
+// It will initialize new counters if they are not found
+func (c heightCounters) getViewCounters(view *proto.View) *messageCounters {
+	roundCounters, exists := c[view.Height]
+	if !exists {
+		roundCounters = make(map[uint64]*messageCounters)
+
+		c[view.Height] = roundCounters
+	}
+
+	counters, exists := roundCounters[view.Round]
+	if !exists {
+		counters = &messageCounters{}
+
+		roundCounters[view.Round] = counters
+	}
+
+	return counters
+}
+
+// Stats returns the message statistics of each stored view, ordered by the view
+func (ms *Messages) Stats() []RoundStats {
+	type viewKey struct {
+		height, round uint64
+	}
+
+	stats := make(map[viewKey]*RoundStats)
+
+	getStats := func(key viewKey) *RoundStats {
+		roundStats, ok := stats[key]
+		if !ok {
+			roundStats = &RoundStats{
+				Height: key.height,
+				Round:  key.round,
+				Types:  make(map[proto.MessageType]MessageTypeStats),
+			}
+			stats[key] = roundStats
+		}
+
+		return roundStats
+	}
+
+	for _, messageType := range allMessageTypes {
+		mux := ms.muxMap[messageType]
+		mux.RLock()
+
+		for height, roundMessages := range ms.getMessageMap(messageType) {
+			for round, messages := range roundMessages {
+				if len(messages) == 0 {
+					continue
+				}
+
+				typeStats := MessageTypeStats{
+					Messages: len(messages),
+					Senders:  make([][]byte, 0, len(messages)),
+				}
+
+				for sender := range messages {
+					typeStats.Senders = append(typeStats.Senders, []byte(sender))
+				}
+
+				sortSenders(typeStats.Senders)
+
+				getStats(viewKey{height, round}).Types[messageType] = typeStats
+			}
+		}
+
+		for height, roundCounters := range ms.counters[messageType] {
+			for round, counters := range roundCounters {
+				roundStats := getStats(viewKey{height, round})
+
+				typeStats := roundStats.Types[messageType]
+				typeStats.Duplicates = counters.duplicates
+				typeStats.Invalid = counters.invalid
+				typeStats.Equivocations = counters.equivocations
+
+				roundStats.Types[messageType] = typeStats
+			}
+		}
+
+		mux.RUnlock()
+	}
+
+	roundStats := make([]RoundStats, 0, len(stats))
+
+	for _, viewStats := range stats {
+		senders := make(map[string]struct{})
+
+		for _, typeStats := range viewStats.Types {
+			for _, sender := range typeStats.Senders {
+				senders[string(sender)] = struct{}{}
+			}
+		}
+
+		viewStats.Senders = make([][]byte, 0, len(senders))
+		for sender := range senders {
+			viewStats.Senders = append(viewStats.Senders, []byte(sender))
+		}
+
+		sortSenders(viewStats.Senders)
+
+		roundStats = append(roundStats, *viewStats)
+	}
+
+	sort.Slice(roundStats, func(i, j int) bool {
+		if roundStats[i].Height != roundStats[j].Height {
+			return roundStats[i].Height < roundStats[j].Height
+		}
+
+		return roundStats[i].Round < roundStats[j].Round
+	})
+
+	return roundStats
+}
+
+// sortSenders sorts the senders in place
+func sortSenders(senders [][]byte) {
+	sort.Slice(senders, func(i, j int) bool {
+		return bytes.Compare(senders[i], senders[j]) < 0
+	})
+}
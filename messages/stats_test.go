@@ -0,0 +1,74 @@
+package messages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+func TestMessages_Stats(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view = &proto.View{Height: 1, Round: 2}
+
+		buildMessage = func(from string, messageType proto.MessageType) *proto.Message {
+			return &proto.Message{
+				View: view,
+				From: []byte(from),
+				Type: messageType,
+			}
+		}
+	)
+
+	messages := NewMessages()
+	defer messages.Close()
+
+	messages.AddMessage(buildMessage("node 1", proto.MessageType_PREPARE))
+	messages.AddMessage(buildMessage("node 0", proto.MessageType_PREPARE))
+	messages.AddMessage(buildMessage("node 2", proto.MessageType_PREPARE))
+	messages.AddMessage(buildMessage("node 3", proto.MessageType_COMMIT))
+
+	// Replace the PREPARE message of a sender
+	messages.AddMessage(buildMessage("node 0", proto.MessageType_PREPARE))
+
+	// Prune out an invalid PREPARE message
+	messages.GetValidMessages(view, proto.MessageType_PREPARE, func(message *proto.Message) bool {
+		return string(message.From) != "node 2"
+	})
+
+	stats := messages.Stats()
+	require.Len(t, stats, 1)
+
+	assert.Equal(t, view.Height, stats[0].Height)
+	assert.Equal(t, view.Round, stats[0].Round)
+	assert.Equal(
+		t,
+		[][]byte{[]byte("node 0"), []byte("node 1"), []byte("node 3")},
+		stats[0].Senders,
+	)
+	assert.Equal(
+		t,
+		map[proto.MessageType]MessageTypeStats{
+			proto.MessageType_PREPARE: {
+				Messages:   2,
+				Senders:    [][]byte{[]byte("node 0"), []byte("node 1")},
+				Duplicates: 1,
+				Invalid:    1,
+			},
+			proto.MessageType_COMMIT: {
+				Messages: 1,
+				Senders:  [][]byte{[]byte("node 3")},
+			},
+		},
+		stats[0].Types,
+	)
+
+	// Make sure the counters are pruned with the height
+	messages.PruneByHeight(view.Height + 1)
+
+	assert.Empty(t, messages.Stats())
+}
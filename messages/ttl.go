@@ -0,0 +1,112 @@
+package messages
+
+import (
+	"time"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// ttlSweepDivisor is the number of sweeps per TTL period,
+// bounding how long an expired view outlives the TTL
+const ttlSweepDivisor = 4
+
+// WithStoreTTL enables the periodic sweep of the store, which discards the messages
+// of the views that did not receive any message for longer than the TTL,
+// regardless of the height progress. It protects the long-stalled nodes from
+// unbounded memory growth, at the cost of forgetting the messages of the views
+// that stalled for longer than the TTL, including the current one.
+// The sweep stops once the store is closed
+func WithStoreTTL(ttl time.Duration) Option {
+	return func(ms *Messages) {
+		ms.storeTTL = ttl
+	}
+}
+
+// runTTLSweep periodically prunes out the expired views, until the store is closed
+func (ms *Messages) runTTLSweep() {
+	interval := ms.storeTTL / ttlSweepDivisor
+	if interval <= 0 {
+		interval = ms.storeTTL
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ms.closeCh:
+			return
+		case <-ticker.C:
+			ms.PruneExpired(ms.storeTTL)
+		}
+	}
+}
+
+// PruneExpired prunes out the messages of the views, of all message types,
+// that did not receive any message for longer than the specified age.
+// It returns the number of pruned messages
+func (ms *Messages) PruneExpired(age time.Duration) int {
+	var (
+		cutoff = time.Now().Add(-age)
+		pruned = 0
+	)
+
+	for _, messageType := range allMessageTypes {
+		mux := ms.muxMap[messageType]
+		mux.Lock()
+
+		pruned += ms.pruneExpired(messageType, cutoff)
+
+		mux.Unlock()
+	}
+
+	return pruned
+}
+
+// pruneExpired prunes out the views of the message type whose latest message
+// was received before the cutoff. The lock of the message type must be held
+func (ms *Messages) pruneExpired(messageType proto.MessageType, cutoff time.Time) int {
+	var (
+		heightMsgMap   = ms.getMessageMap(messageType)
+		heightCounters = ms.counters[messageType]
+		pruned         = 0
+	)
+
+	for height, roundMessages := range heightMsgMap {
+		for round, messages := range roundMessages {
+			if !isExpired(messages, cutoff) {
+				continue
+			}
+
+			pruned += len(messages)
+
+			ms.untrackMessages(messages)
+
+			delete(roundMessages, round)
+			delete(heightCounters[height], round)
+
+			ms.quorums.remove(notifyKey{height: height, round: round, messageType: messageType})
+		}
+
+		if len(roundMessages) == 0 {
+			delete(heightMsgMap, height)
+		}
+
+		if len(heightCounters[height]) == 0 {
+			delete(heightCounters, height)
+		}
+	}
+
+	return pruned
+}
+
+// isExpired checks if none of the view messages was received after the cutoff
+func isExpired(messages protoMessages, cutoff time.Time) bool {
+	for _, message := range messages {
+		if message.received.After(cutoff) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,93 @@
+package messages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// ageMessages moves the receive time of the stored view messages into the past
+func ageMessages(ms *Messages, view *proto.View, messageType proto.MessageType, age time.Duration) {
+	mux := ms.muxMap[messageType]
+	mux.Lock()
+	defer mux.Unlock()
+
+	for _, message := range ms.getMessageMap(messageType).getViewMessages(view) {
+		message.received = message.received.Add(-age)
+	}
+}
+
+// TestMessages_PruneExpired makes sure only the views
+// with no recent messages are pruned out
+func TestMessages_PruneExpired(t *testing.T) {
+	t.Parallel()
+
+	var (
+		numMessages = 4
+		messageType = proto.MessageType_PREPARE
+
+		staleView = &proto.View{Height: 1, Round: 0}
+		freshView = &proto.View{Height: 1, Round: 1}
+		mixedView = &proto.View{Height: 2, Round: 0}
+	)
+
+	messages := NewMessages()
+
+	t.Cleanup(func() {
+		messages.Close()
+	})
+
+	for _, view := range []*proto.View{staleView, freshView, mixedView} {
+		messages.AddMessages(generateRandomMessages(numMessages, view, messageType))
+	}
+
+	messages.AddMessages(generateRandomMessages(numMessages, staleView, proto.MessageType_COMMIT))
+
+	ageMessages(messages, staleView, messageType, time.Hour)
+
+	// The view with a recent message is retained as a whole
+	ageMessages(messages, mixedView, messageType, time.Hour)
+
+	recent := generateRandomMessages(1, mixedView, messageType)[0]
+	recent.From = []byte("recent sender")
+
+	messages.AddMessage(recent)
+
+	assert.Equal(t, numMessages, messages.PruneExpired(time.Minute))
+
+	assert.Equal(t, 0, messages.numMessages(staleView, messageType))
+	assert.Equal(t, numMessages, messages.numMessages(freshView, messageType))
+	assert.Equal(t, numMessages+1, messages.numMessages(mixedView, messageType))
+
+	// The ages are tracked per message type
+	assert.Equal(t, numMessages, messages.numMessages(staleView, proto.MessageType_COMMIT))
+
+	// Nothing is left to prune
+	assert.Equal(t, 0, messages.PruneExpired(time.Minute))
+}
+
+// TestMessages_StoreTTL makes sure the sweep
+// discards the expired views in the background
+func TestMessages_StoreTTL(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view        = &proto.View{Height: 1, Round: 0}
+		messageType = proto.MessageType_PREPARE
+	)
+
+	messages := NewMessages(WithStoreTTL(50 * time.Millisecond))
+
+	t.Cleanup(func() {
+		messages.Close()
+	})
+
+	messages.AddMessages(generateRandomMessages(3, view, messageType))
+
+	assert.Eventually(t, func() bool {
+		return messages.numMessages(view, messageType) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
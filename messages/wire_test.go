@@ -0,0 +1,477 @@
+package messages
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	protoBuf "google.golang.org/protobuf/proto"
+
+	"github.com/renloi/ibft/messages/proto"
+)
+
+// The golden files in testdata contain messages encoded by
+// the initial release of the message schema, and the ones in testdata/v4 to testdata/v10
+// contain messages using the fields added by SchemaV4 to SchemaV10. They must never be regenerated;
+// a failing test means the current schema is no longer wire-compatible
+// with nodes running prior releases
+
+// goldenMessages returns the messages encoded in the golden files, by file name
+func goldenMessages() map[string]*proto.Message {
+	var (
+		view = &proto.View{
+			Height: 100,
+			Round:  2,
+		}
+		proposal = &proto.Proposal{
+			RawProposal: []byte("raw proposal"),
+			Round:       1,
+		}
+		proposalHash = []byte("proposal hash")
+
+		proposalMessage = &proto.Message{
+			View:      &proto.View{Height: 100, Round: 1},
+			From:      []byte("proposer"),
+			Signature: []byte("proposer signature"),
+			Type:      proto.MessageType_PREPREPARE,
+			Payload: &proto.Message_PreprepareData{
+				PreprepareData: &proto.PrePrepareMessage{
+					Proposal:     proposal,
+					ProposalHash: proposalHash,
+				},
+			},
+		}
+		prepareMessage = &proto.Message{
+			View:      &proto.View{Height: 100, Round: 1},
+			From:      []byte("validator 1"),
+			Signature: []byte("validator 1 signature"),
+			Type:      proto.MessageType_PREPARE,
+			Payload: &proto.Message_PrepareData{
+				PrepareData: &proto.PrepareMessage{
+					ProposalHash: proposalHash,
+				},
+			},
+		}
+		roundChangeMessage = &proto.Message{
+			View:      view,
+			From:      []byte("validator 2"),
+			Signature: []byte("validator 2 signature"),
+			Type:      proto.MessageType_ROUND_CHANGE,
+			Payload: &proto.Message_RoundChangeData{
+				RoundChangeData: &proto.RoundChangeMessage{
+					LastPreparedProposal: proposal,
+					LatestPreparedCertificate: &proto.PreparedCertificate{
+						ProposalMessage: proposalMessage,
+						PrepareMessages: []*proto.Message{prepareMessage},
+					},
+				},
+			},
+		}
+	)
+
+	return map[string]*proto.Message{
+		"preprepare.bin": {
+			View:      view,
+			From:      []byte("proposer"),
+			Signature: []byte("proposer signature"),
+			Type:      proto.MessageType_PREPREPARE,
+			Payload: &proto.Message_PreprepareData{
+				PreprepareData: &proto.PrePrepareMessage{
+					Proposal:     proposal,
+					ProposalHash: proposalHash,
+					Certificate: &proto.RoundChangeCertificate{
+						RoundChangeMessages: []*proto.Message{roundChangeMessage},
+					},
+				},
+			},
+		},
+		"prepare.bin": prepareMessage,
+		"commit.bin": {
+			View:      view,
+			From:      []byte("validator 3"),
+			Signature: []byte("validator 3 signature"),
+			Type:      proto.MessageType_COMMIT,
+			Payload: &proto.Message_CommitData{
+				CommitData: &proto.CommitMessage{
+					ProposalHash:  proposalHash,
+					CommittedSeal: []byte("committed seal"),
+				},
+			},
+		},
+		"round_change.bin": roundChangeMessage,
+		"round_change_empty.bin": {
+			View:      view,
+			From:      []byte("validator 4"),
+			Signature: []byte("validator 4 signature"),
+			Type:      proto.MessageType_ROUND_CHANGE,
+			Payload: &proto.Message_RoundChangeData{
+				RoundChangeData: &proto.RoundChangeMessage{},
+			},
+		},
+	}
+}
+
+// goldenMessagesV4 returns the messages encoded in the SchemaV4 golden files
+// (testdata/v4), covering the fields and message types added by SchemaV4
+func goldenMessagesV4() map[string]*proto.Message {
+	var (
+		view = &proto.View{
+			Height: 100,
+			Round:  2,
+		}
+		proposal = &proto.Proposal{
+			RawProposal: []byte("raw proposal"),
+			Round:       1,
+		}
+		proposalMessage = &proto.Message{
+			View:      &proto.View{Height: 100, Round: 1},
+			From:      []byte("proposer"),
+			Signature: []byte("proposer signature"),
+			Type:      proto.MessageType_PREPREPARE,
+			Payload: &proto.Message_PreprepareData{
+				PreprepareData: &proto.PrePrepareMessage{
+					Proposal:     proposal,
+					ProposalHash: []byte("proposal hash"),
+				},
+			},
+		}
+		dedupPC = &proto.DedupPreparedCertificate{
+			ProposalMessage: proposalMessage,
+			Prepares: []*proto.SenderSignature{
+				{
+					From:      []byte("validator 1"),
+					Signature: []byte("validator 1 signature"),
+				},
+			},
+		}
+	)
+
+	return map[string]*proto.Message{
+		"v4/nil_proposal.bin": {
+			View:      view,
+			From:      []byte("proposer"),
+			Signature: []byte("proposer signature"),
+			Type:      proto.MessageType_PREPREPARE,
+			Payload: &proto.Message_PreprepareData{
+				PreprepareData: &proto.PrePrepareMessage{
+					Proposal: &proto.Proposal{
+						Round: view.Round,
+					},
+					NilProposal: true,
+				},
+			},
+		},
+		"v4/proposer_unavailable.bin": {
+			View:      view,
+			From:      []byte("proposer"),
+			Signature: []byte("proposer signature"),
+			Type:      proto.MessageType_PROPOSER_UNAVAILABLE,
+		},
+		"v4/dedup_preprepare.bin": {
+			View:      view,
+			From:      []byte("proposer"),
+			Signature: []byte("proposer signature"),
+			Type:      proto.MessageType_PREPREPARE,
+			Payload: &proto.Message_PreprepareData{
+				PreprepareData: &proto.PrePrepareMessage{
+					Proposal:     proposal,
+					ProposalHash: []byte("proposal hash"),
+					DedupCertificate: &proto.DedupRoundChangeCertificate{
+						View:         view,
+						Certificates: []*proto.DedupPreparedCertificate{dedupPC},
+						RoundChanges: []*proto.DedupRoundChange{
+							{
+								From:                []byte("validator 2"),
+								Signature:           []byte("validator 2 signature"),
+								Certificate:         1,
+								CertificateProposal: true,
+							},
+						},
+					},
+				},
+			},
+		},
+		"v4/dedup_round_change.bin": {
+			View:      view,
+			From:      []byte("validator 2"),
+			Signature: []byte("validator 2 signature"),
+			Type:      proto.MessageType_ROUND_CHANGE,
+			Payload: &proto.Message_RoundChangeData{
+				RoundChangeData: &proto.RoundChangeMessage{
+					LastPreparedProposal: proposal,
+					DedupCertificate:     dedupPC,
+				},
+			},
+		},
+	}
+}
+
+// goldenMessagesV5 returns the messages encoded in the SchemaV5 golden files
+// (testdata/v5), covering the DKG message type added by SchemaV5
+func goldenMessagesV5() map[string]*proto.Message {
+	view := &proto.View{
+		Height: 7,
+		Round:  0,
+	}
+
+	return map[string]*proto.Message{
+		"v5/dkg_deal.bin": {
+			View:      view,
+			From:      []byte("validator 1"),
+			Signature: []byte("validator 1 signature"),
+			Type:      proto.MessageType_DKG,
+			Payload: &proto.Message_DkgData{
+				DkgData: &proto.DKGMessage{
+					Commitments: []byte("commitments"),
+					Shares:      [][]byte{[]byte("share 0"), []byte("share 1")},
+				},
+			},
+		},
+		"v5/dkg_complaint.bin": {
+			View:      &proto.View{Height: view.Height, Round: 1},
+			From:      []byte("validator 1"),
+			Signature: []byte("validator 1 signature"),
+			Type:      proto.MessageType_DKG,
+			Payload: &proto.Message_DkgData{
+				DkgData: &proto.DKGMessage{
+					Complaints: [][]byte{[]byte("validator 0")},
+				},
+			},
+		},
+	}
+}
+
+// goldenMessagesV6 returns the messages encoded in the SchemaV6 golden files
+// (testdata/v6), covering the CURRENT_VIEW message type added by SchemaV6
+func goldenMessagesV6() map[string]*proto.Message {
+	return map[string]*proto.Message{
+		"v6/current_view.bin": {
+			View:      &proto.View{Height: 7, Round: 3},
+			From:      []byte("validator 1"),
+			Signature: []byte("validator 1 signature"),
+			Type:      proto.MessageType_CURRENT_VIEW,
+		},
+	}
+}
+
+// goldenMessagesV7 returns the messages encoded in the SchemaV7 golden files
+// (testdata/v7), covering the FINALITY message type added by SchemaV7
+func goldenMessagesV7() map[string]*proto.Message {
+	return map[string]*proto.Message{
+		"v7/finality.bin": {
+			View: &proto.View{Height: 7, Round: 1},
+			Type: proto.MessageType_FINALITY,
+			Payload: &proto.Message_FinalityData{
+				FinalityData: &proto.FinalityMessage{
+					Proposal: &proto.Proposal{
+						RawProposal: []byte("raw proposal"),
+						Round:       1,
+					},
+					ProposalHash: []byte("proposal hash"),
+					CommittedSeals: []*proto.SenderSignature{
+						{From: []byte("validator 1"), Signature: []byte("validator 1 seal")},
+						{From: []byte("validator 2"), Signature: []byte("validator 2 seal")},
+					},
+				},
+			},
+		},
+	}
+}
+
+// goldenMessagesV8 returns the messages encoded in the SchemaV8 golden files
+// (testdata/v8), covering the validator index references added by SchemaV8
+func goldenMessagesV8() map[string]*proto.Message {
+	return map[string]*proto.Message{
+		"v8/index_reference.bin": {
+			View:      &proto.View{Height: 7, Round: 1},
+			Signature: []byte("validator 3 signature"),
+			FromIndex: 3,
+			Type:      proto.MessageType_FINALITY,
+			Payload: &proto.Message_FinalityData{
+				FinalityData: &proto.FinalityMessage{
+					Proposal: &proto.Proposal{
+						RawProposal: []byte("raw proposal"),
+						Round:       1,
+					},
+					ProposalHash: []byte("proposal hash"),
+					CommittedSeals: []*proto.SenderSignature{
+						{FromIndex: 1, Signature: []byte("validator 1 seal")},
+						{FromIndex: 2, Signature: []byte("validator 2 seal")},
+					},
+				},
+			},
+		},
+	}
+}
+
+// goldenMessagesV9 returns the messages encoded in the SchemaV9 golden files
+// (testdata/v9), covering the VETO message type added by SchemaV9
+func goldenMessagesV9() map[string]*proto.Message {
+	return map[string]*proto.Message{
+		"v9/veto.bin": {
+			View:      &proto.View{Height: 12, Round: 4},
+			From:      []byte("validator 2"),
+			Signature: []byte("validator 2 signature"),
+			Type:      proto.MessageType_VETO,
+			Payload: &proto.Message_VetoData{
+				VetoData: &proto.VetoMessage{
+					ProposalHash: []byte("rejected proposal hash"),
+				},
+			},
+		},
+	}
+}
+
+// goldenMessagesV10 returns the messages encoded in the SchemaV10 golden files
+// (testdata/v10), covering the randomness beacon contribution added by SchemaV10
+func goldenMessagesV10() map[string]*proto.Message {
+	return map[string]*proto.Message{
+		"v10/commit_randomness.bin": {
+			View:      &proto.View{Height: 13, Round: 0},
+			From:      []byte("validator 1"),
+			Signature: []byte("validator 1 signature"),
+			Type:      proto.MessageType_COMMIT,
+			Payload: &proto.Message_CommitData{
+				CommitData: &proto.CommitMessage{
+					ProposalHash:  []byte("proposal hash"),
+					CommittedSeal: []byte("validator 1 seal"),
+					Randomness:    []byte("validator 1 randomness"),
+				},
+			},
+		},
+	}
+}
+
+// readGoldenFile reads the encoded message from the golden file
+func readGoldenFile(t *testing.T, name string) []byte {
+	t.Helper()
+
+	raw, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("unable to read golden file %s, %v", name, err)
+	}
+
+	return raw
+}
+
+func TestMessages_WireCompatibility_Decode(t *testing.T) {
+	t.Parallel()
+
+	for name, expected := range goldenMessages() {
+		name := name
+		expected := expected
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			decoded := &proto.Message{}
+
+			assert.NoError(t, protoBuf.Unmarshal(readGoldenFile(t, name), decoded))
+
+			// Make sure the message decodes into the same fields
+			assert.True(t, protoBuf.Equal(expected, decoded))
+
+			// Make sure no fields were decoded as unknown,
+			// which happens when fields are renumbered or retyped
+			assert.Len(t, decoded.ProtoReflect().GetUnknown(), 0)
+
+			// Make sure the fields added after the initial release are unset
+			assert.Equal(t, uint32(0), decoded.Ttl)
+			assert.Equal(t, uint32(0), decoded.Hops)
+			assert.True(t, ExtractProposalTimestamp(decoded).IsZero())
+			assert.False(t, decoded.GetPreprepareData().GetNilProposal())
+			assert.Nil(t, decoded.GetPreprepareData().GetDedupCertificate())
+			assert.Nil(t, decoded.GetRoundChangeData().GetDedupCertificate())
+		})
+	}
+}
+
+func TestMessages_WireCompatibility_Encode(t *testing.T) {
+	t.Parallel()
+
+	for name, message := range goldenMessages() {
+		name := name
+		message := message
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			raw, err := protoBuf.MarshalOptions{Deterministic: true}.Marshal(message)
+
+			// Make sure the message encodes into the same bytes
+			assert.NoError(t, err)
+			assert.Equal(t, readGoldenFile(t, name), raw)
+		})
+	}
+}
+
+// checkGoldenMessages makes sure the messages decode from, and encode into their golden files
+func checkGoldenMessages(t *testing.T, golden map[string]*proto.Message) {
+	t.Helper()
+
+	for name, expected := range golden {
+		name := name
+		expected := expected
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			decoded := &proto.Message{}
+
+			// Make sure the message decodes into the same fields,
+			// with none of them decoded as unknown
+			assert.NoError(t, protoBuf.Unmarshal(readGoldenFile(t, name), decoded))
+			assert.True(t, protoBuf.Equal(expected, decoded))
+			assert.Len(t, decoded.ProtoReflect().GetUnknown(), 0)
+
+			raw, err := protoBuf.MarshalOptions{Deterministic: true}.Marshal(expected)
+
+			// Make sure the message encodes into the same bytes
+			assert.NoError(t, err)
+			assert.Equal(t, readGoldenFile(t, name), raw)
+		})
+	}
+}
+
+func TestMessages_WireCompatibility_SchemaV4(t *testing.T) {
+	t.Parallel()
+
+	checkGoldenMessages(t, goldenMessagesV4())
+}
+
+func TestMessages_WireCompatibility_SchemaV5(t *testing.T) {
+	t.Parallel()
+
+	checkGoldenMessages(t, goldenMessagesV5())
+}
+
+func TestMessages_WireCompatibility_SchemaV6(t *testing.T) {
+	t.Parallel()
+
+	checkGoldenMessages(t, goldenMessagesV6())
+}
+
+func TestMessages_WireCompatibility_SchemaV7(t *testing.T) {
+	t.Parallel()
+
+	checkGoldenMessages(t, goldenMessagesV7())
+}
+
+func TestMessages_WireCompatibility_SchemaV8(t *testing.T) {
+	t.Parallel()
+
+	checkGoldenMessages(t, goldenMessagesV8())
+}
+
+func TestMessages_WireCompatibility_SchemaV9(t *testing.T) {
+	t.Parallel()
+
+	checkGoldenMessages(t, goldenMessagesV9())
+}
+
+func TestMessages_WireCompatibility_SchemaV10(t *testing.T) {
+	t.Parallel()
+
+	checkGoldenMessages(t, goldenMessagesV10())
+}
@@ -0,0 +1,396 @@
+// Package wiretap defines a sub-module for recording and replaying IBFT traffic
+package wiretap
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/renloi/ibft/core"
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+var (
+	// ErrInvalidDirection is an error indicating a corrupted record direction
+	ErrInvalidDirection = errors.New("invalid record direction")
+
+	// ErrRecordTooLarge is an error indicating a corrupted record size
+	ErrRecordTooLarge = errors.New("record exceeds maximum size")
+)
+
+const (
+	// maxRecordSize is the upper bound for a single encoded message
+	maxRecordSize = 64 * 1024 * 1024
+)
+
+// Direction is the direction of the recorded message
+type Direction uint8
+
+const (
+	// Outbound marks messages multicasted by the node
+	Outbound Direction = iota + 1
+
+	// Inbound marks messages received by the node
+	Inbound
+)
+
+// String returns the human-readable direction
+func (d Direction) String() string {
+	switch d {
+	case Outbound:
+		return "outbound"
+	case Inbound:
+		return "inbound"
+	}
+
+	return "unknown"
+}
+
+// MessageHandler is the consumer of inbound messages,
+// usually the IBFT instance
+type MessageHandler interface {
+	// AddMessage adds a new message to the message handler
+	AddMessage(message *proto.Message)
+}
+
+// options are the optional recorder and reader settings
+type options struct {
+	// codec is the message serializer
+	codec messages.Codec
+
+	// migrator upgrades records written by older releases, if set
+	migrator *messages.Migrator
+
+	// schemaVersion is the schema version of the read records
+	schemaVersion messages.SchemaVersion
+}
+
+// Option is the optional recorder and reader configuration setter
+type Option func(*options)
+
+// WithCodec sets the codec used for encoding the recorded messages.
+// The recording must be read using the same codec
+func WithCodec(codec messages.Codec) Option {
+	return func(o *options) {
+		o.codec = codec
+	}
+}
+
+// WithSchemaMigration sets the migrator used for reading recordings
+// written by an older release, using the specified schema version
+func WithSchemaMigration(migrator *messages.Migrator, from messages.SchemaVersion) Option {
+	return func(o *options) {
+		o.migrator = migrator
+		o.schemaVersion = from
+	}
+}
+
+// newOptions creates the settings from the passed in options
+func newOptions(opts []Option) *options {
+	o := &options{
+		codec: messages.ProtoCodec{},
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// Record is a single recorded message
+type Record struct {
+	// Timestamp is the time the message was recorded at
+	Timestamp time.Time
+
+	// Direction is the direction of the message
+	Direction Direction
+
+	// Message is the recorded message
+	Message *proto.Message
+}
+
+// Recorder is a Transport wrapper that records all
+// sent and received messages to the underlying writer
+type Recorder struct {
+	// transport is the wrapped transport
+	transport core.Transport
+
+	// handlerLock guards the handler, as it can be set
+	// while the messages are received
+	handlerLock sync.RWMutex
+	handler     MessageHandler
+
+	// writeLock guards the writer, as messages are
+	// recorded from different routines
+	writeLock sync.Mutex
+	writer    *bufio.Writer
+
+	// err is the first write error, if any
+	err error
+
+	// codec is the message serializer
+	codec messages.Marshaler
+}
+
+// NewRecorder creates a new recorder that wraps the transport
+func NewRecorder(w io.Writer, transport core.Transport, opts ...Option) *Recorder {
+	return &Recorder{
+		transport: transport,
+		writer:    bufio.NewWriter(w),
+		codec:     newOptions(opts).codec,
+	}
+}
+
+// SetHandler sets the consumer of inbound messages.
+// The handler is usually created after the recorder, as the
+// IBFT instance requires the (wrapped) transport on creation
+func (r *Recorder) SetHandler(handler MessageHandler) {
+	r.handlerLock.Lock()
+	defer r.handlerLock.Unlock()
+
+	r.handler = handler
+}
+
+// Multicast multicasts the outbound message using the wrapped transport,
+// and records it once it is sent. The failed multicasts are not recorded,
+// so a message retried by the engine is recorded only once
+func (r *Recorder) Multicast(message *proto.Message) error {
+	if err := r.transport.Multicast(message); err != nil {
+		return err
+	}
+
+	r.record(Outbound, message)
+
+	return nil
+}
+
+// AddMessage records the inbound message, and passes it
+// to the message handler
+func (r *Recorder) AddMessage(message *proto.Message) {
+	if message == nil {
+		return
+	}
+
+	r.record(Inbound, message)
+
+	r.handlerLock.RLock()
+	handler := r.handler
+	r.handlerLock.RUnlock()
+
+	if handler != nil {
+		handler.AddMessage(message)
+	}
+}
+
+// Flush flushes the buffered records to the underlying writer,
+// and returns the first error encountered while recording, if any
+func (r *Recorder) Flush() error {
+	r.writeLock.Lock()
+	defer r.writeLock.Unlock()
+
+	if r.err != nil {
+		return r.err
+	}
+
+	r.err = r.writer.Flush()
+
+	return r.err
+}
+
+// record writes the message to the underlying writer
+func (r *Recorder) record(direction Direction, message *proto.Message) {
+	r.writeLock.Lock()
+	defer r.writeLock.Unlock()
+
+	if r.err != nil {
+		// Recording stops on the first error
+		return
+	}
+
+	r.err = writeRecord(r.writer, r.codec, &Record{
+		Timestamp: time.Now(),
+		Direction: direction,
+		Message:   message,
+	})
+}
+
+// writeRecord encodes the record as:
+// direction (1 byte) | unix nano timestamp (8 bytes) | message size (uvarint) | message
+func writeRecord(w io.Writer, codec messages.Marshaler, record *Record) error {
+	raw, err := codec.Marshal(record.Message)
+	if err != nil {
+		return err
+	}
+
+	header := make([]byte, 1+8+binary.MaxVarintLen64)
+
+	header[0] = byte(record.Direction)
+	binary.BigEndian.PutUint64(header[1:9], uint64(record.Timestamp.UnixNano()))
+	headerSize := 9 + binary.PutUvarint(header[9:], uint64(len(raw)))
+
+	if _, err := w.Write(header[:headerSize]); err != nil {
+		return err
+	}
+
+	_, err = w.Write(raw)
+
+	return err
+}
+
+// Reader reads records written by the Recorder
+type Reader struct {
+	reader *bufio.Reader
+
+	// codec is the message serializer
+	codec messages.Unmarshaler
+}
+
+// NewReader creates a new record reader
+func NewReader(r io.Reader, opts ...Option) *Reader {
+	var (
+		o     = newOptions(opts)
+		codec = messages.Unmarshaler(o.codec)
+	)
+
+	if o.migrator != nil {
+		codec = messages.NewMigratingUnmarshaler(codec, o.migrator, o.schemaVersion)
+	}
+
+	return &Reader{
+		reader: bufio.NewReader(r),
+		codec:  codec,
+	}
+}
+
+// Next reads the next record. It returns io.EOF
+// when there are no more records
+func (r *Reader) Next() (*Record, error) {
+	direction, err := r.reader.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	if Direction(direction) != Outbound && Direction(direction) != Inbound {
+		return nil, ErrInvalidDirection
+	}
+
+	timestamp := make([]byte, 8)
+	if _, err := io.ReadFull(r.reader, timestamp); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	size, err := binary.ReadUvarint(r.reader)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	if size > maxRecordSize {
+		return nil, ErrRecordTooLarge
+	}
+
+	raw := make([]byte, size)
+	if _, err := io.ReadFull(r.reader, raw); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	message := &proto.Message{}
+	if err := r.codec.Unmarshal(raw, message); err != nil {
+		return nil, fmt.Errorf("unable to decode message: %w", err)
+	}
+
+	return &Record{
+		Timestamp: time.Unix(0, int64(binary.BigEndian.Uint64(timestamp))),
+		Direction: Direction(direction),
+		Message:   message,
+	}, nil
+}
+
+// unexpectedEOF converts io.EOF errors for partially read records
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
+
+// Replay feeds the inbound messages of the recording to the handler, in order.
+// If realTime is set, the original delays between the messages are preserved
+func Replay(
+	ctx context.Context,
+	r io.Reader,
+	handler MessageHandler,
+	realTime bool,
+	opts ...Option,
+) error {
+	var (
+		reader   = NewReader(r, opts...)
+		previous time.Time
+	)
+
+	for {
+		record, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if record.Direction != Inbound {
+			continue
+		}
+
+		if realTime && !previous.IsZero() {
+			delay := record.Timestamp.Sub(previous)
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		previous = record.Timestamp
+
+		handler.AddMessage(record.Message)
+	}
+}
+
+// Query reads the recording, and returns the records of
+// the messages selected by the query, in the recorded order
+func Query(r io.Reader, query messages.MessageQuery, opts ...Option) ([]*Record, error) {
+	var (
+		reader  = NewReader(r, opts...)
+		records = make([]*Record, 0)
+	)
+
+	for {
+		record, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if query.Matches(record.Message) {
+			records = append(records, record)
+		}
+	}
+}
@@ -0,0 +1,388 @@
+package wiretap
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/goleak"
+	"google.golang.org/protobuf/encoding/protojson"
+	protoBuf "google.golang.org/protobuf/proto"
+
+	"github.com/renloi/ibft/messages"
+	"github.com/renloi/ibft/messages/proto"
+)
+
+func TestMain(m *testing.M) {
+	goleak.VerifyTestMain(m)
+}
+
+type mockTransport struct {
+	multicastFn func(*proto.Message)
+	err         error
+}
+
+func (t mockTransport) Multicast(message *proto.Message) error {
+	if t.err != nil {
+		return t.err
+	}
+
+	if t.multicastFn != nil {
+		t.multicastFn(message)
+	}
+
+	return nil
+}
+
+type mockHandler struct {
+	sync.Mutex
+
+	received []*proto.Message
+}
+
+func (h *mockHandler) AddMessage(message *proto.Message) {
+	h.Lock()
+	defer h.Unlock()
+
+	h.received = append(h.received, message)
+}
+
+// generateMessage generates a simple prepare message
+func generateMessage(height, round uint64, from string) *proto.Message {
+	return &proto.Message{
+		View: &proto.View{
+			Height: height,
+			Round:  round,
+		},
+		From: []byte(from),
+		Type: proto.MessageType_PREPARE,
+		Payload: &proto.Message_PrepareData{
+			PrepareData: &proto.PrepareMessage{
+				ProposalHash: []byte("proposal hash"),
+			},
+		},
+	}
+}
+
+func TestRecorder_RecordsTraffic(t *testing.T) {
+	t.Parallel()
+
+	var (
+		buf         bytes.Buffer
+		multicasted []*proto.Message
+		handler     = &mockHandler{}
+
+		outbound = generateMessage(1, 0, "node 0")
+		inbound  = generateMessage(1, 0, "node 1")
+	)
+
+	recorder := NewRecorder(&buf, mockTransport{
+		multicastFn: func(message *proto.Message) {
+			multicasted = append(multicasted, message)
+		},
+	})
+	recorder.SetHandler(handler)
+
+	assert.NoError(t, recorder.Multicast(outbound))
+	recorder.AddMessage(inbound)
+	recorder.AddMessage(nil)
+
+	assert.NoError(t, recorder.Flush())
+
+	// Make sure the messages were passed through
+	assert.Equal(t, []*proto.Message{outbound}, multicasted)
+	assert.Equal(t, []*proto.Message{inbound}, handler.received)
+
+	// Make sure the messages were recorded in order
+	reader := NewReader(&buf)
+
+	expected := []struct {
+		direction Direction
+		message   *proto.Message
+	}{
+		{Outbound, outbound},
+		{Inbound, inbound},
+	}
+
+	var previous time.Time
+
+	for _, e := range expected {
+		record, err := reader.Next()
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, e.direction, record.Direction)
+		assert.True(t, protoBuf.Equal(e.message, record.Message))
+		assert.False(t, record.Timestamp.Before(previous))
+
+		previous = record.Timestamp
+	}
+
+	_, err := reader.Next()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+// TestRecorder_FailedMulticast makes sure only the sent messages are recorded
+func TestRecorder_FailedMulticast(t *testing.T) {
+	t.Parallel()
+
+	var (
+		buf          bytes.Buffer
+		multicastErr = errors.New("multicast failed")
+	)
+
+	recorder := NewRecorder(&buf, mockTransport{err: multicastErr})
+
+	assert.ErrorIs(t, recorder.Multicast(generateMessage(1, 0, "node 0")), multicastErr)
+	assert.NoError(t, recorder.Flush())
+
+	assert.Zero(t, buf.Len())
+}
+
+// TestRecorder_SetHandler makes sure the handler
+// can be set while the messages are received
+func TestRecorder_SetHandler(t *testing.T) {
+	t.Parallel()
+
+	var (
+		handler = &mockHandler{}
+		wg      sync.WaitGroup
+	)
+
+	recorder := NewRecorder(io.Discard, mockTransport{})
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for index := 0; index < 100; index++ {
+			recorder.AddMessage(generateMessage(1, 0, "node 1"))
+		}
+	}()
+
+	recorder.SetHandler(handler)
+
+	wg.Wait()
+
+	// Make sure the messages received once the handler is set are passed through
+	recorder.AddMessage(generateMessage(1, 0, "node 1"))
+
+	handler.Lock()
+	defer handler.Unlock()
+
+	assert.NotEmpty(t, handler.received)
+}
+
+func TestReader_CorruptedRecording(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	assert.NoError(t, writeRecord(&buf, messages.ProtoCodec{}, &Record{
+		Timestamp: time.Now(),
+		Direction: Inbound,
+		Message:   generateMessage(1, 0, "node 1"),
+	}))
+
+	raw := buf.Bytes()
+
+	t.Run("truncated record", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := NewReader(bytes.NewReader(raw[:len(raw)-1])).Next()
+		assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	})
+
+	t.Run("invalid direction", func(t *testing.T) {
+		t.Parallel()
+
+		corrupted := append([]byte{}, raw...)
+		corrupted[0] = 0
+
+		_, err := NewReader(bytes.NewReader(corrupted)).Next()
+		assert.ErrorIs(t, err, ErrInvalidDirection)
+	})
+}
+
+func TestReplay(t *testing.T) {
+	t.Parallel()
+
+	var (
+		buf      bytes.Buffer
+		start    = time.Now()
+		inbound1 = generateMessage(1, 0, "node 1")
+		inbound2 = generateMessage(1, 0, "node 2")
+	)
+
+	records := []*Record{
+		{Timestamp: start, Direction: Inbound, Message: inbound1},
+		{Timestamp: start.Add(10 * time.Millisecond), Direction: Outbound, Message: generateMessage(1, 0, "node 0")},
+		{Timestamp: start.Add(50 * time.Millisecond), Direction: Inbound, Message: inbound2},
+	}
+
+	for _, record := range records {
+		assert.NoError(t, writeRecord(&buf, messages.ProtoCodec{}, record))
+	}
+
+	t.Run("replay inbound messages", func(t *testing.T) {
+		t.Parallel()
+
+		handler := &mockHandler{}
+		replayStart := time.Now()
+
+		assert.NoError(t, Replay(context.Background(), bytes.NewReader(buf.Bytes()), handler, true))
+
+		// Make sure only inbound messages are replayed, in order
+		if !assert.Len(t, handler.received, 2) {
+			return
+		}
+
+		assert.True(t, protoBuf.Equal(inbound1, handler.received[0]))
+		assert.True(t, protoBuf.Equal(inbound2, handler.received[1]))
+
+		// Make sure the original timing was preserved
+		assert.GreaterOrEqual(t, time.Since(replayStart), 50*time.Millisecond)
+	})
+
+	t.Run("replay cancelled", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancelFn := context.WithCancel(context.Background())
+		cancelFn()
+
+		handler := &mockHandler{}
+
+		err := Replay(ctx, bytes.NewReader(buf.Bytes()), handler, true)
+		assert.True(t, errors.Is(err, context.Canceled))
+	})
+}
+
+// jsonCodec is a test codec using the proto JSON encoding
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(message *proto.Message) ([]byte, error) {
+	return protojson.Marshal(message)
+}
+
+func (jsonCodec) Unmarshal(data []byte, message *proto.Message) error {
+	return protojson.Unmarshal(data, message)
+}
+
+func TestRecorder_CustomCodec(t *testing.T) {
+	t.Parallel()
+
+	var (
+		buf     bytes.Buffer
+		inbound = generateMessage(1, 0, "node 1")
+	)
+
+	recorder := NewRecorder(&buf, mockTransport{}, WithCodec(jsonCodec{}))
+	recorder.AddMessage(inbound)
+
+	assert.NoError(t, recorder.Flush())
+
+	// Make sure the recording is in the custom encoding
+	assert.Contains(t, buf.String(), "PREPARE")
+
+	record, err := NewReader(bytes.NewReader(buf.Bytes()), WithCodec(jsonCodec{})).Next()
+	if assert.NoError(t, err) {
+		assert.True(t, protoBuf.Equal(inbound, record.Message))
+	}
+}
+
+func TestReader_SchemaMigration(t *testing.T) {
+	t.Parallel()
+
+	var (
+		buf     bytes.Buffer
+		inbound = generateMessage(1, 0, "node 1")
+	)
+
+	recorder := NewRecorder(&buf, mockTransport{})
+	recorder.AddMessage(inbound)
+
+	assert.NoError(t, recorder.Flush())
+
+	// Register a migration that rewrites the sender
+	migrator := messages.NewMigrator()
+	migrator.Register(messages.SchemaV2, func(data []byte) ([]byte, error) {
+		message := &proto.Message{}
+		if err := protoBuf.Unmarshal(data, message); err != nil {
+			return nil, err
+		}
+
+		message.From = []byte("migrated")
+
+		return protoBuf.Marshal(message)
+	})
+
+	// Make sure the records are upgraded when read
+	record, err := NewReader(
+		bytes.NewReader(buf.Bytes()),
+		WithSchemaMigration(migrator, messages.SchemaV1),
+	).Next()
+	if assert.NoError(t, err) {
+		assert.Equal(t, []byte("migrated"), record.Message.From)
+	}
+
+	// Make sure current records are not upgraded
+	record, err = NewReader(
+		bytes.NewReader(buf.Bytes()),
+		WithSchemaMigration(migrator, messages.CurrentSchemaVersion),
+	).Next()
+	if assert.NoError(t, err) {
+		assert.True(t, protoBuf.Equal(inbound, record.Message))
+	}
+
+	// Make sure unknown schema versions are rejected
+	_, err = NewReader(
+		bytes.NewReader(buf.Bytes()),
+		WithSchemaMigration(migrator, messages.CurrentSchemaVersion+1),
+	).Next()
+	assert.ErrorIs(t, err, messages.ErrUnknownSchemaVersion)
+}
+
+func TestQuery(t *testing.T) {
+	t.Parallel()
+
+	var (
+		buf      bytes.Buffer
+		recorded = []*proto.Message{
+			generateMessage(1, 0, "node 1"),
+			generateMessage(1, 1, "node 2"),
+			generateMessage(2, 0, "node 1"),
+			generateMessage(1, 0, "node 2"),
+		}
+	)
+
+	recorder := NewRecorder(&buf, mockTransport{})
+	for _, message := range recorded {
+		recorder.AddMessage(message)
+	}
+
+	assert.NoError(t, recorder.Flush())
+
+	// Make sure the selected records are returned in the recorded order
+	records, err := Query(bytes.NewReader(buf.Bytes()), messages.MessageQuery{
+		Height:  1,
+		Senders: [][]byte{[]byte("node 2")},
+	})
+	if !assert.NoError(t, err) || !assert.Len(t, records, 2) {
+		return
+	}
+
+	assert.True(t, protoBuf.Equal(recorded[1], records[0].Message))
+	assert.True(t, protoBuf.Equal(recorded[3], records[1].Message))
+	assert.Equal(t, Inbound, records[0].Direction)
+
+	// Make sure corrupted recordings are reported
+	_, err = Query(bytes.NewReader(buf.Bytes()[:buf.Len()-1]), messages.MessageQuery{Height: 1})
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}